@@ -0,0 +1,23 @@
+package admin
+
+import (
+	"crypto/subtle"
+	"net/http"
+)
+
+// RequireToken wraps the handler to reject the requests without the given bearer token in their Authorization
+// header. The handler is returned as is if the token is empty.
+func RequireToken(token string, next http.Handler) http.Handler {
+	if token == "" {
+		return next
+	}
+	expected := []byte("Bearer " + token)
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if subtle.ConstantTimeCompare([]byte(r.Header.Get("Authorization")), expected) != 1 {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ethtxparser admin"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
@@ -0,0 +1,450 @@
+// Package admin implements the operator endpoints, served on their own listener apart from the public REST API and
+// protected independently of it.
+package admin
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+type TxStore interface {
+	GetDeadLetters(ctx context.Context) ([]*store.DeadLetter, error)
+	PurgeAddress(ctx context.Context, addr string) (int, error)
+	ExtractRecords(ctx context.Context, beforeBlock int64) (*store.Records, error)
+	RestoreRecords(ctx context.Context, records *store.Records) (int, error)
+}
+
+type SubscriptionStore interface {
+	GetSubscription(ctx context.Context, addr string) (*store.Subscription, error)
+	SearchSubscriptions(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error)
+}
+
+// UsageStore keeps the usage of the tenants, the holders of the API keys.
+type UsageStore interface {
+	GetUsage(ctx context.Context) ([]*store.TenantUsage, error)
+}
+
+// Indexer is the indexing pipeline, which can be paused without stopping the servers.
+type Indexer interface {
+	Pause() bool
+	Resume() bool
+	Paused() bool
+}
+
+// ConfirmationDepth is the confirmation depth of the reorg filter, which can be changed at runtime.
+type ConfirmationDepth interface {
+	Get() uint
+	Set(depth uint)
+}
+
+// Backfiller records the past transactions of subscribed addresses.
+type Backfiller interface {
+	Backfill(ctx context.Context, sub *store.Subscription, fromBlock, toBlock int64) error
+	Progress(addr string) (backfill.Progress, bool)
+}
+
+// Reloader reloads the configuration that can be changed without a restart.
+type Reloader interface {
+	Reload(ctx context.Context) error
+}
+
+// ColdStorage keeps the records archived out of the store.
+type ColdStorage interface {
+	// Put archives the records, returning their location.
+	Put(ctx context.Context, records *store.Records) (string, error)
+	// Get returns the archived records of the blocks numbered from fromBlock to toBlock, inclusive.
+	Get(ctx context.Context, fromBlock, toBlock int64) (*store.Records, error)
+}
+
+type config struct {
+	indexer           Indexer
+	confirmationDepth ConfirmationDepth
+	backfiller        Backfiller
+	reloader          Reloader
+	coldStorage       ColdStorage
+	usageStore        UsageStore
+}
+
+type Option func(*config)
+
+// WithIndexer enables pausing and resuming indexing through the API.
+func WithIndexer(indexer Indexer) Option {
+	return func(c *config) {
+		c.indexer = indexer
+	}
+}
+
+// WithConfirmationDepth enables changing the confirmation depth of the reorg filter through the API.
+func WithConfirmationDepth(depth ConfirmationDepth) Option {
+	return func(c *config) {
+		c.confirmationDepth = depth
+	}
+}
+
+// WithBackfiller enables triggering backfills of subscribed addresses through the API.
+func WithBackfiller(backfiller Backfiller) Option {
+	return func(c *config) {
+		c.backfiller = backfiller
+	}
+}
+
+// WithReloader enables reloading the configuration through the API.
+func WithReloader(reloader Reloader) Option {
+	return func(c *config) {
+		c.reloader = reloader
+	}
+}
+
+// WithColdStorage enables archiving old records to cold storage and restoring them through the API.
+func WithColdStorage(coldStorage ColdStorage) Option {
+	return func(c *config) {
+		c.coldStorage = coldStorage
+	}
+}
+
+// WithUsage enables reporting the usage of the tenants through the API.
+func WithUsage(usageStore UsageStore) Option {
+	return func(c *config) {
+		c.usageStore = usageStore
+	}
+}
+
+type Server struct {
+	logger    logging.Logger
+	txStore   TxStore
+	subsStore SubscriptionStore
+	cfg       *config
+}
+
+func NewServer(logger logging.Logger, txStore TxStore, subsStore SubscriptionStore, opts ...Option) *Server {
+	cfg := &config{}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Server{
+		logger:    logger,
+		txStore:   txStore,
+		subsStore: subsStore,
+		cfg:       cfg,
+	}
+}
+
+// ListDeadLetters lists the blocks the indexer gave up on, so the gaps they left can be repaired.
+func (s *Server) ListDeadLetters(ctx context.Context, _ *ListDeadLettersRequest) (*ListDeadLettersResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	storedDeadLetters, err := s.txStore.GetDeadLetters(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list dead letter blocks from store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not list dead letter blocks from store")
+	}
+
+	deadLetters := make([]*DeadLetter, 0, len(storedDeadLetters))
+	for deadLetter := range slices.Values(storedDeadLetters) {
+		deadLetters = append(deadLetters, &DeadLetter{
+			BlockNumber:    fmt.Sprintf("0x%x", deadLetter.BlockNumber),
+			BlockNumberInt: deadLetter.BlockNumber,
+			BlockHash:      deadLetter.BlockHash,
+			ParentHash:     deadLetter.ParentHash,
+			Attempts:       deadLetter.Attempts,
+			Error:          deadLetter.Error,
+			FailedAt:       deadLetter.FailedAt.UTC().Format(time.RFC3339),
+		})
+	}
+
+	return &ListDeadLettersResponse{
+		DeadLetters: deadLetters,
+	}, nil
+}
+
+// GetIndexing returns whether indexing is paused.
+func (s *Server) GetIndexing(_ context.Context, _ *GetIndexingRequest) (*IndexingResponse, error) {
+	if s.cfg.indexer == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Indexing control is not enabled")
+	}
+
+	return &IndexingResponse{
+		Paused: s.cfg.indexer.Paused(),
+	}, nil
+}
+
+// PauseIndexing pauses indexing, e.g. during store maintenance, while the API keeps serving the indexed records.
+func (s *Server) PauseIndexing(ctx context.Context, _ *PauseIndexingRequest) (*IndexingResponse, error) {
+	if s.cfg.indexer == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Indexing control is not enabled")
+	}
+
+	if s.cfg.indexer.Pause() {
+		s.logger.WithContext(ctx).Warn("Indexing paused through the admin API")
+	}
+	return &IndexingResponse{
+		Paused: true,
+	}, nil
+}
+
+// ResumeIndexing resumes paused indexing.
+func (s *Server) ResumeIndexing(ctx context.Context, _ *ResumeIndexingRequest) (*IndexingResponse, error) {
+	if s.cfg.indexer == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Indexing control is not enabled")
+	}
+
+	if s.cfg.indexer.Resume() {
+		s.logger.WithContext(ctx).Info("Indexing resumed through the admin API")
+	}
+	return &IndexingResponse{
+		Paused: false,
+	}, nil
+}
+
+// GetReorg returns the confirmation depth of the reorg filter.
+func (s *Server) GetReorg(_ context.Context, _ *GetReorgRequest) (*ReorgResponse, error) {
+	if s.cfg.confirmationDepth == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Reorg control is not enabled")
+	}
+
+	return &ReorgResponse{
+		ConfirmationDepth: s.cfg.confirmationDepth.Get(),
+	}, nil
+}
+
+// UpdateReorg changes the confirmation depth of the reorg filter, applied from the next received block without
+// dropping the buffered ones.
+func (s *Server) UpdateReorg(ctx context.Context, req *UpdateReorgRequest) (*ReorgResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	if s.cfg.confirmationDepth == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Reorg control is not enabled")
+	}
+
+	old := s.cfg.confirmationDepth.Get()
+	s.cfg.confirmationDepth.Set(req.ConfirmationDepth)
+	logger.WithFields(logging.Fields{
+		"old_depth": old,
+		"new_depth": req.ConfirmationDepth,
+	}).Warn("Confirmation depth changed through the admin API")
+	return &ReorgResponse{
+		ConfirmationDepth: req.ConfirmationDepth,
+	}, nil
+}
+
+// TriggerBackfill queues the backfill of the transactions of a subscribed address over the given range of past
+// blocks, e.g. to repair the gap left by a dead letter block.
+func (s *Server) TriggerBackfill(ctx context.Context, req *TriggerBackfillRequest) (*TriggerBackfillResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	if s.cfg.backfiller == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Backfilling is not enabled")
+	}
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	sub, err := s.subsStore.GetSubscription(ctx, addr)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, restapi.NewErrf(http.StatusNotFound, "Address is not subscribed")
+		}
+		logger.WithError(err).Error("Failed to get subscription from store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not get subscription from store")
+	}
+
+	err = s.cfg.backfiller.Backfill(ctx, sub, req.FromBlock, req.ToBlock)
+	if err != nil {
+		switch {
+		case errors.Is(err, backfill.ErrInvalidRange), errors.Is(err, backfill.ErrTooManyBlocks):
+			return nil, restapi.NewErrf(http.StatusBadRequest, "Invalid backfill range: %s", err)
+		case errors.Is(err, backfill.ErrInProgress):
+			return nil, restapi.NewErrf(http.StatusConflict, "A backfill is already in progress for this address")
+		case errors.Is(err, backfill.ErrQueueFull):
+			return nil, restapi.NewErrf(http.StatusServiceUnavailable, "Too many backfills queued, please retry later")
+		}
+		logger.WithError(err).Error("Failed to queue backfill")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not queue backfill")
+	}
+	logger.WithFields(logging.Fields{
+		"from_block": req.FromBlock,
+		"to_block":   req.ToBlock,
+	}).Info("Backfill queued through the admin API")
+
+	progress, _ := s.cfg.backfiller.Progress(addr)
+	return &TriggerBackfillResponse{
+		Backfill: restapi.ConvertBackfillProgress(progress),
+	}, nil
+}
+
+// PurgeAddress deletes the recorded transactions, token transfers, stats and events of an address. Its subscription
+// is kept, so it's recorded again from the next indexed block.
+func (s *Server) PurgeAddress(ctx context.Context, req *PurgeAddressRequest) (*PurgeAddressResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	purged, err := s.txStore.PurgeAddress(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to purge address records from store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not purge address records from store")
+	}
+	logger.WithField("records", purged).Warn("Address records purged through the admin API")
+
+	return &PurgeAddressResponse{
+		Purged: purged,
+	}, nil
+}
+
+// ReloadConfig reloads the configuration that can be changed without a restart.
+func (s *Server) ReloadConfig(ctx context.Context, _ *ReloadConfigRequest) (*ReloadConfigResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	if s.cfg.reloader == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Config reload is not enabled")
+	}
+	err := s.cfg.reloader.Reload(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to reload config")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not reload config: %s", err)
+	}
+	logger.Info("Config reloaded through the admin API")
+
+	return &ReloadConfigResponse{
+		Ok: true,
+	}, nil
+}
+
+// ArchiveRecords moves the transactions, token transfers and events of the blocks before the given one out of the store
+// to cold storage, keeping the address stats. The records are put back into the store if they can't be archived.
+func (s *Server) ArchiveRecords(ctx context.Context, req *ArchiveRecordsRequest) (*ArchiveRecordsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("before_block", req.BeforeBlock)
+
+	if s.cfg.coldStorage == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Cold storage is not enabled")
+	}
+
+	records, err := s.txStore.ExtractRecords(ctx, req.BeforeBlock)
+	if err != nil {
+		logger.WithError(err).Error("Failed to extract records from store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not extract records from store")
+	}
+	fromBlock, toBlock, ok := records.BlockRange()
+	if !ok {
+		return &ArchiveRecordsResponse{}, nil
+	}
+
+	location, err := s.cfg.coldStorage.Put(ctx, records)
+	if err != nil {
+		logger.WithError(err).Error("Failed to archive records to cold storage")
+		_, restoreErr := s.txStore.RestoreRecords(context.WithoutCancel(ctx), records)
+		if restoreErr != nil {
+			logger.WithError(restoreErr).Error("Failed to restore records not archived to cold storage, they're lost")
+		}
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not archive records to cold storage")
+	}
+	logger.WithFields(logging.Fields{
+		"records":    records.Len(),
+		"from_block": fromBlock,
+		"to_block":   toBlock,
+		"location":   location,
+	}).Warn("Records archived to cold storage through the admin API")
+
+	return &ArchiveRecordsResponse{
+		Archived:  records.Len(),
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Location:  location,
+	}, nil
+}
+
+// RestoreRecords puts the archived records of the given range of blocks back into the store, skipping the ones it
+// already holds, e.g. to answer queries about old activity.
+func (s *Server) RestoreRecords(ctx context.Context, req *RestoreRecordsRequest) (*RestoreRecordsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithFields(logging.Fields{
+		"from_block": req.FromBlock,
+		"to_block":   req.ToBlock,
+	})
+
+	if s.cfg.coldStorage == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Cold storage is not enabled")
+	}
+	if req.FromBlock > req.ToBlock {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Invalid restore range: 'fromBlock' is after 'toBlock'")
+	}
+
+	records, err := s.cfg.coldStorage.Get(ctx, req.FromBlock, req.ToBlock)
+	if err != nil {
+		logger.WithError(err).Error("Failed to read records from cold storage")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not read records from cold storage")
+	}
+	restored, err := s.txStore.RestoreRecords(ctx, records)
+	if err != nil {
+		logger.WithError(err).Error("Failed to restore records into store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not restore records into store")
+	}
+	logger.WithField("records", restored).Info("Records restored from cold storage through the admin API")
+
+	return &RestoreRecordsResponse{
+		Restored: restored,
+	}, nil
+}
+
+// GetUsage reports the usage of every tenant: the API requests served, the subscriptions held and the transactions
+// delivered to them, e.g. for chargeback or quota decisions.
+func (s *Server) GetUsage(ctx context.Context, _ *GetUsageRequest) (*GetUsageResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	if s.cfg.usageStore == nil {
+		return nil, restapi.NewErrf(http.StatusBadRequest, "Usage tracking is not enabled")
+	}
+
+	storedUsages, err := s.cfg.usageStore.GetUsage(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get tenant usage from store")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not get tenant usage from store")
+	}
+	subs, err := s.subsStore.SearchSubscriptions(ctx, &store.SubscriptionQuery{})
+	if err != nil {
+		logger.WithError(err).Error("Failed to list subscriptions to count the ones of the tenants")
+		return nil, restapi.NewErrf(http.StatusInternalServerError, "Could not list subscriptions from store")
+	}
+
+	tenantToUsage := make(map[string]*TenantUsage, len(storedUsages))
+	usage := func(tenant string) *TenantUsage {
+		u, ok := tenantToUsage[tenant]
+		if !ok {
+			u = &TenantUsage{
+				Tenant:     tenant,
+				Deliveries: make(map[string]int64),
+			}
+			tenantToUsage[tenant] = u
+		}
+		return u
+	}
+	for stored := range slices.Values(storedUsages) {
+		u := usage(stored.Tenant)
+		u.Requests = stored.Requests
+		maps.Copy(u.Deliveries, stored.Deliveries)
+	}
+	for sub := range slices.Values(subs) {
+		// subscribed without an API key, e.g. from the watchlist
+		if sub.Tenant == "" {
+			continue
+		}
+		usage(sub.Tenant).Subscriptions++
+	}
+
+	tenants := slices.SortedFunc(maps.Values(tenantToUsage), func(a, b *TenantUsage) int {
+		return strings.Compare(a.Tenant, b.Tenant)
+	})
+	return &GetUsageResponse{
+		Tenants: tenants,
+	}, nil
+}
@@ -0,0 +1,530 @@
+package admin_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/api/admin"
+	"github.com/hedisam/ethtxparser/api/admin/mocks"
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+//go:generate moq -out mocks/tx_store.go -pkg mocks -skip-ensure . TxStore
+//go:generate moq -out mocks/subscriptions_store.go -pkg mocks -skip-ensure . SubscriptionStore
+//go:generate moq -out mocks/indexer.go -pkg mocks -skip-ensure . Indexer
+//go:generate moq -out mocks/confirmation_depth.go -pkg mocks -skip-ensure . ConfirmationDepth
+//go:generate moq -out mocks/backfiller.go -pkg mocks -skip-ensure . Backfiller
+//go:generate moq -out mocks/reloader.go -pkg mocks -skip-ensure . Reloader
+//go:generate moq -out mocks/cold_storage.go -pkg mocks -skip-ensure . ColdStorage
+//go:generate moq -out mocks/usage_store.go -pkg mocks -skip-ensure . UsageStore
+
+// call calls the server Func with the request validated first, as FuncAdapter does.
+func call[Req, Resp any](ctx context.Context, f restapi.Func[Req, Resp], req *Req) (*Resp, error) {
+	err := restapi.Validate(req)
+	if err != nil {
+		return nil, err
+	}
+	return f(ctx, req)
+}
+
+func TestListDeadLetters(t *testing.T) {
+	failedAt := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	storeMock := &mocks.TxStoreMock{
+		GetDeadLettersFunc: func(ctx context.Context) ([]*store.DeadLetter, error) {
+			return []*store.DeadLetter{{
+				BlockNumber: 255,
+				BlockHash:   "hash-255",
+				ParentHash:  "hash-254",
+				Attempts:    5,
+				Error:       "dummy error",
+				FailedAt:    failedAt,
+			}}, nil
+		},
+	}
+
+	s := admin.NewServer(logging.Logrus(logrus.New()), storeMock, nil)
+	resp, err := s.ListDeadLetters(context.Background(), &admin.ListDeadLettersRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &admin.ListDeadLettersResponse{
+		DeadLetters: []*admin.DeadLetter{{
+			BlockNumber:    "0xff",
+			BlockNumberInt: 255,
+			BlockHash:      "hash-255",
+			ParentHash:     "hash-254",
+			Attempts:       5,
+			Error:          "dummy error",
+			FailedAt:       "2024-01-02T03:04:05Z",
+		}},
+	}, resp)
+}
+
+func TestPauseAndResumeIndexing(t *testing.T) {
+	var paused bool
+	indexerMock := &mocks.IndexerMock{
+		PauseFunc: func() bool {
+			wasRunning := !paused
+			paused = true
+			return wasRunning
+		},
+		ResumeFunc: func() bool {
+			wasPaused := paused
+			paused = false
+			return wasPaused
+		},
+		PausedFunc: func() bool {
+			return paused
+		},
+	}
+	s := admin.NewServer(logging.Logrus(logrus.New()), nil, nil, admin.WithIndexer(indexerMock))
+	ctx := context.Background()
+
+	resp, err := s.PauseIndexing(ctx, &admin.PauseIndexingRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &admin.IndexingResponse{Paused: true}, resp)
+	resp, err = s.GetIndexing(ctx, &admin.GetIndexingRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &admin.IndexingResponse{Paused: true}, resp)
+
+	resp, err = s.ResumeIndexing(ctx, &admin.ResumeIndexingRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &admin.IndexingResponse{Paused: false}, resp)
+	assert.False(t, paused)
+	assert.Len(t, indexerMock.PauseCalls(), 1)
+	assert.Len(t, indexerMock.ResumeCalls(), 1)
+
+	_, err = admin.NewServer(logging.Logrus(logrus.New()), nil, nil).PauseIndexing(ctx, &admin.PauseIndexingRequest{})
+	castedErr := &restapi.Err{}
+	require.True(t, errors.As(err, &castedErr))
+	assert.Equal(t, http.StatusBadRequest, castedErr.StatusCode)
+}
+
+func TestUpdateReorg(t *testing.T) {
+	tests := map[string]struct {
+		depth         uint
+		expectedResp  *admin.ReorgResponse
+		expectedErr   *restapi.Err
+		expectedDepth uint
+	}{
+		"valid depth": {
+			depth:         6,
+			expectedResp:  &admin.ReorgResponse{ConfirmationDepth: 6},
+			expectedDepth: 6,
+		},
+		"zero depth": {
+			expectedErr: &restapi.Err{
+				Message:    "Invalid 'confirmationDepth', it must be between 1 and 1024",
+				StatusCode: http.StatusBadRequest,
+			},
+			expectedDepth: 3,
+		},
+		"too deep": {
+			depth: 1025,
+			expectedErr: &restapi.Err{
+				Message:    "Invalid 'confirmationDepth', it must be between 1 and 1024",
+				StatusCode: http.StatusBadRequest,
+			},
+			expectedDepth: 3,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			depth := uint(3)
+			depthMock := &mocks.ConfirmationDepthMock{
+				GetFunc: func() uint {
+					return depth
+				},
+				SetFunc: func(d uint) {
+					depth = d
+				},
+			}
+			s := admin.NewServer(logging.Logrus(logrus.New()), nil, nil, admin.WithConfirmationDepth(depthMock))
+			resp, err := call(context.Background(), s.UpdateReorg, &admin.UpdateReorgRequest{ConfirmationDepth: test.depth})
+			assert.Equal(t, test.expectedDepth, depth)
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+
+			resp, err = s.GetReorg(context.Background(), &admin.GetReorgRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestTriggerBackfill(t *testing.T) {
+	const addr = "0x28c6c06298d514db089934071355e5743bf21d60"
+	tests := map[string]struct {
+		req                *admin.TriggerBackfillRequest
+		subscribed         bool
+		backfillErr        error
+		expectedStatusCode int
+	}{
+		"queued": {
+			req:        &admin.TriggerBackfillRequest{Address: addr, FromBlock: 100, ToBlock: 200},
+			subscribed: true,
+		},
+		"invalid address": {
+			req:                &admin.TriggerBackfillRequest{Address: "0x1234", FromBlock: 100, ToBlock: 200},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"not subscribed": {
+			req:                &admin.TriggerBackfillRequest{Address: addr, FromBlock: 100, ToBlock: 200},
+			expectedStatusCode: http.StatusNotFound,
+		},
+		"invalid range": {
+			req:                &admin.TriggerBackfillRequest{Address: addr, FromBlock: 200, ToBlock: 100},
+			subscribed:         true,
+			backfillErr:        backfill.ErrInvalidRange,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"in progress": {
+			req:                &admin.TriggerBackfillRequest{Address: addr, FromBlock: 100, ToBlock: 200},
+			subscribed:         true,
+			backfillErr:        backfill.ErrInProgress,
+			expectedStatusCode: http.StatusConflict,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if !test.subscribed {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr}, nil
+				},
+			}
+			backfillerMock := &mocks.BackfillerMock{
+				BackfillFunc: func(ctx context.Context, sub *store.Subscription, fromBlock, toBlock int64) error {
+					return test.backfillErr
+				},
+				ProgressFunc: func(addr string) (backfill.Progress, bool) {
+					return backfill.Progress{Address: addr, FromBlock: 100, ToBlock: 200, NextBlock: 200, Status: backfill.StatusQueued}, true
+				},
+			}
+
+			s := admin.NewServer(logging.Logrus(logrus.New()), nil, subsStoreMock, admin.WithBackfiller(backfillerMock))
+			resp, err := call(context.Background(), s.TriggerBackfill, test.req)
+			if test.expectedStatusCode != 0 {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedStatusCode, castedErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			require.Len(t, backfillerMock.BackfillCalls(), 1)
+			assert.Equal(t, addr, backfillerMock.BackfillCalls()[0].Sub.Address)
+			assert.Equal(t, int64(100), resp.Backfill.FromBlock)
+			assert.Equal(t, string(backfill.StatusQueued), resp.Backfill.Status)
+		})
+	}
+}
+
+func TestPurgeAddress(t *testing.T) {
+	storeMock := &mocks.TxStoreMock{
+		PurgeAddressFunc: func(ctx context.Context, addr string) (int, error) {
+			return 3, nil
+		},
+	}
+	s := admin.NewServer(logging.Logrus(logrus.New()), storeMock, nil)
+
+	resp, err := s.PurgeAddress(context.Background(), &admin.PurgeAddressRequest{Address: "28C6c06298d514Db089934071355E5743bf21d60"})
+	require.NoError(t, err)
+	assert.Equal(t, &admin.PurgeAddressResponse{Purged: 3}, resp)
+	require.Len(t, storeMock.PurgeAddressCalls(), 1)
+	assert.Equal(t, "0x28c6c06298d514db089934071355e5743bf21d60", storeMock.PurgeAddressCalls()[0].Addr)
+
+	_, err = call(context.Background(), s.PurgeAddress, &admin.PurgeAddressRequest{Address: "0x1234"})
+	castedErr := &restapi.Err{}
+	require.True(t, errors.As(err, &castedErr))
+	assert.Equal(t, http.StatusBadRequest, castedErr.StatusCode)
+}
+
+func TestReloadConfig(t *testing.T) {
+	tests := map[string]struct {
+		reloadErr          error
+		expectedStatusCode int
+	}{
+		"reloaded": {},
+		"failed": {
+			reloadErr:          errors.New("dummy error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			reloaderMock := &mocks.ReloaderMock{
+				ReloadFunc: func(ctx context.Context) error {
+					return test.reloadErr
+				},
+			}
+			s := admin.NewServer(logging.Logrus(logrus.New()), nil, nil, admin.WithReloader(reloaderMock))
+			resp, err := s.ReloadConfig(context.Background(), &admin.ReloadConfigRequest{})
+			assert.Len(t, reloaderMock.ReloadCalls(), 1)
+			if test.expectedStatusCode != 0 {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedStatusCode, castedErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &admin.ReloadConfigResponse{Ok: true}, resp)
+		})
+	}
+}
+
+func TestArchiveRecords(t *testing.T) {
+	records := &store.Records{
+		AddrToTxs: map[string][]*store.TxRecord{
+			"0xaa": {{Hash: "0x01", BlockNumber: 3}, {Hash: "0x02", BlockNumber: 7}},
+		},
+	}
+	tests := map[string]struct {
+		records            *store.Records
+		putErr             error
+		expectedResp       *admin.ArchiveRecordsResponse
+		expectedRestored   bool
+		expectedStatusCode int
+	}{
+		"archived": {
+			records: records,
+			expectedResp: &admin.ArchiveRecordsResponse{
+				Archived:  2,
+				FromBlock: 3,
+				ToBlock:   7,
+				Location:  "s3://bucket/records",
+			},
+		},
+		"nothing to archive": {
+			records:      &store.Records{},
+			expectedResp: &admin.ArchiveRecordsResponse{},
+		},
+		"records put back if not archived": {
+			records:            records,
+			putErr:             errors.New("dummy error"),
+			expectedRestored:   true,
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.TxStoreMock{
+				ExtractRecordsFunc: func(ctx context.Context, beforeBlock int64) (*store.Records, error) {
+					assert.Equal(t, int64(10), beforeBlock)
+					return test.records, nil
+				},
+				RestoreRecordsFunc: func(ctx context.Context, records *store.Records) (int, error) {
+					return records.Len(), nil
+				},
+			}
+			coldStorageMock := &mocks.ColdStorageMock{
+				PutFunc: func(ctx context.Context, records *store.Records) (string, error) {
+					return "s3://bucket/records", test.putErr
+				},
+			}
+			s := admin.NewServer(logging.Logrus(logrus.New()), storeMock, nil, admin.WithColdStorage(coldStorageMock))
+
+			resp, err := call(context.Background(), s.ArchiveRecords, &admin.ArchiveRecordsRequest{BeforeBlock: 10})
+			if test.expectedRestored {
+				require.Len(t, storeMock.RestoreRecordsCalls(), 1)
+				assert.Equal(t, test.records, storeMock.RestoreRecordsCalls()[0].Records)
+			} else {
+				assert.Empty(t, storeMock.RestoreRecordsCalls())
+			}
+			if test.expectedStatusCode != 0 {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedStatusCode, castedErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+
+	s := admin.NewServer(logging.Logrus(logrus.New()), &mocks.TxStoreMock{}, nil)
+	_, err := call(context.Background(), s.ArchiveRecords, &admin.ArchiveRecordsRequest{BeforeBlock: 10})
+	castedErr := &restapi.Err{}
+	require.True(t, errors.As(err, &castedErr))
+	assert.Equal(t, &restapi.Err{StatusCode: http.StatusBadRequest, Message: "Cold storage is not enabled"}, castedErr)
+}
+
+func TestRestoreRecords(t *testing.T) {
+	records := &store.Records{
+		AddrToTxs: map[string][]*store.TxRecord{"0xaa": {{Hash: "0x01", BlockNumber: 3}}},
+	}
+	tests := map[string]struct {
+		req                *admin.RestoreRecordsRequest
+		getErr             error
+		expectedResp       *admin.RestoreRecordsResponse
+		expectedStatusCode int
+	}{
+		"restored": {
+			req:          &admin.RestoreRecordsRequest{FromBlock: 1, ToBlock: 5},
+			expectedResp: &admin.RestoreRecordsResponse{Restored: 1},
+		},
+		"invalid range": {
+			req:                &admin.RestoreRecordsRequest{FromBlock: 5, ToBlock: 1},
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"cold storage failure": {
+			req:                &admin.RestoreRecordsRequest{FromBlock: 1, ToBlock: 5},
+			getErr:             errors.New("dummy error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.TxStoreMock{
+				RestoreRecordsFunc: func(ctx context.Context, records *store.Records) (int, error) {
+					return records.Len(), nil
+				},
+			}
+			coldStorageMock := &mocks.ColdStorageMock{
+				GetFunc: func(ctx context.Context, fromBlock, toBlock int64) (*store.Records, error) {
+					return records, test.getErr
+				},
+			}
+			s := admin.NewServer(logging.Logrus(logrus.New()), storeMock, nil, admin.WithColdStorage(coldStorageMock))
+
+			resp, err := call(context.Background(), s.RestoreRecords, test.req)
+			if test.expectedStatusCode != 0 {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedStatusCode, castedErr.StatusCode)
+				assert.Empty(t, storeMock.RestoreRecordsCalls())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+			require.Len(t, coldStorageMock.GetCalls(), 1)
+			assert.Equal(t, int64(1), coldStorageMock.GetCalls()[0].FromBlock)
+			assert.Equal(t, int64(5), coldStorageMock.GetCalls()[0].ToBlock)
+		})
+	}
+}
+
+func TestRequireToken(t *testing.T) {
+	tests := map[string]struct {
+		token          string
+		authorization  string
+		expectedStatus int
+	}{
+		"valid token": {
+			token:          "secret",
+			authorization:  "Bearer secret",
+			expectedStatus: http.StatusOK,
+		},
+		"invalid token": {
+			token:          "secret",
+			authorization:  "Bearer guess",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"missing token": {
+			token:          "secret",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"no token required": {
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := admin.RequireToken(test.token, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/admin/indexing", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+		})
+	}
+}
+
+func TestGetUsage(t *testing.T) {
+	tests := map[string]struct {
+		disabled           bool
+		usageErr           error
+		expectedStatusCode int
+		expectedResp       *admin.GetUsageResponse
+	}{
+		"usage": {
+			expectedResp: &admin.GetUsageResponse{
+				Tenants: []*admin.TenantUsage{
+					{Tenant: "acme", Requests: 4, Subscriptions: 2, Deliveries: map[string]int64{"webhook": 7}},
+					{Tenant: "globex", Subscriptions: 1, Deliveries: map[string]int64{}},
+				},
+			},
+		},
+		"not enabled": {
+			disabled:           true,
+			expectedStatusCode: http.StatusBadRequest,
+		},
+		"store error": {
+			usageErr:           errors.New("dummy error"),
+			expectedStatusCode: http.StatusInternalServerError,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			usageMock := &mocks.UsageStoreMock{
+				GetUsageFunc: func(ctx context.Context) ([]*store.TenantUsage, error) {
+					return []*store.TenantUsage{
+						{Tenant: "acme", Requests: 4, Deliveries: map[string]int64{"webhook": 7}},
+					}, test.usageErr
+				},
+			}
+			subsMock := &mocks.SubscriptionStoreMock{
+				SearchSubscriptionsFunc: func(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+					return []*store.Subscription{
+						{Address: "0xaa", Tenant: "acme"},
+						{Address: "0xbb", Tenant: "globex"},
+						{Address: "0xcc", Tenant: "acme"},
+						// subscribed from the watchlist
+						{Address: "0xdd"},
+					}, nil
+				},
+			}
+			var opts []admin.Option
+			if !test.disabled {
+				opts = append(opts, admin.WithUsage(usageMock))
+			}
+			s := admin.NewServer(logging.Logrus(logrus.New()), nil, subsMock, opts...)
+			resp, err := s.GetUsage(context.Background(), &admin.GetUsageRequest{})
+			if test.expectedStatusCode != 0 {
+				castedErr := &restapi.Err{}
+				require.True(t, errors.As(err, &castedErr))
+				assert.Equal(t, test.expectedStatusCode, castedErr.StatusCode)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
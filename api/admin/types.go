@@ -0,0 +1,102 @@
+package admin
+
+import (
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+)
+
+type ListDeadLettersRequest struct{}
+
+type ListDeadLettersResponse struct {
+	DeadLetters []*DeadLetter `json:"deadLetters"`
+}
+
+type DeadLetter struct {
+	BlockNumber    string `json:"blockNumber"`
+	BlockNumberInt int64  `json:"blockNumberInt"`
+	BlockHash      string `json:"blockHash"`
+	ParentHash     string `json:"parentHash"`
+	Attempts       int    `json:"attempts"`
+	Error          string `json:"error"`
+	FailedAt       string `json:"failedAt"`
+}
+
+type GetIndexingRequest struct{}
+
+type PauseIndexingRequest struct{}
+
+type ResumeIndexingRequest struct{}
+
+type IndexingResponse struct {
+	Paused bool `json:"paused"`
+}
+
+type GetReorgRequest struct{}
+
+type UpdateReorgRequest struct {
+	ConfirmationDepth uint `json:"confirmationDepth" validate:"range=1:1024"`
+}
+
+type ReorgResponse struct {
+	ConfirmationDepth uint `json:"confirmationDepth"`
+}
+
+type TriggerBackfillRequest struct {
+	Address   string `json:"address" validate:"required,hexaddr"`
+	FromBlock int64  `json:"fromBlock"`
+	ToBlock   int64  `json:"toBlock"`
+}
+
+type TriggerBackfillResponse struct {
+	Backfill *restapi.BackfillProgress `json:"backfill"`
+}
+
+type PurgeAddressRequest struct {
+	Address string `json:"address" validate:"required,hexaddr"`
+}
+
+type PurgeAddressResponse struct {
+	Purged int `json:"purged"`
+}
+
+type ReloadConfigRequest struct{}
+
+type ReloadConfigResponse struct {
+	Ok bool `json:"ok"`
+}
+
+type ArchiveRecordsRequest struct {
+	BeforeBlock int64 `json:"beforeBlock" validate:"required"`
+}
+
+type ArchiveRecordsResponse struct {
+	Archived  int    `json:"archived"`
+	FromBlock int64  `json:"fromBlock,omitempty"`
+	ToBlock   int64  `json:"toBlock,omitempty"`
+	Location  string `json:"location,omitempty"`
+}
+
+type RestoreRecordsRequest struct {
+	FromBlock int64 `json:"fromBlock"`
+	ToBlock   int64 `json:"toBlock"`
+}
+
+type RestoreRecordsResponse struct {
+	Restored int `json:"restored"`
+}
+
+type GetUsageRequest struct{}
+
+type GetUsageResponse struct {
+	Tenants []*TenantUsage `json:"tenants"`
+}
+
+// TenantUsage is the usage of a tenant, the holder of an API key.
+type TenantUsage struct {
+	Tenant string `json:"tenant"`
+	// Requests is the number of API requests served, gRPC streams included.
+	Requests int64 `json:"requests"`
+	// Subscriptions is the number of addresses currently subscribed.
+	Subscriptions int `json:"subscriptions"`
+	// Deliveries is the number of transactions delivered, by channel: "webhook" or "grpc".
+	Deliveries map[string]int64 `json:"deliveries"`
+}
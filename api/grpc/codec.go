@@ -0,0 +1,101 @@
+package grpc
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"google.golang.org/protobuf/proto"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+const (
+	// messageHeaderSize is the size of the compressed flag and length prefixing every gRPC message.
+	messageHeaderSize = 5
+	// maxRequestSize is the maximum size of a request message.
+	maxRequestSize = 4 << 10
+)
+
+// errCompressed is returned when reading a compressed message, compression isn't supported.
+var errCompressed = errors.New("compressed messages are not supported")
+
+// readMessage reads a single length prefixed gRPC message.
+func readMessage(r io.Reader) ([]byte, error) {
+	var header [messageHeaderSize]byte
+	_, err := io.ReadFull(r, header[:])
+	if err != nil {
+		return nil, fmt.Errorf("could not read message header: %w", err)
+	}
+	if header[0] != 0 {
+		return nil, errCompressed
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxRequestSize {
+		return nil, fmt.Errorf("message of %d bytes is larger than %d bytes", size, maxRequestSize)
+	}
+
+	msg := make([]byte, size)
+	_, err = io.ReadFull(r, msg)
+	if err != nil {
+		return nil, fmt.Errorf("could not read message: %w", err)
+	}
+	return msg, nil
+}
+
+// writeMessage writes the given message, length prefixed and uncompressed.
+func writeMessage(w io.Writer, msg []byte) error {
+	var header [messageHeaderSize]byte
+	binary.BigEndian.PutUint32(header[1:], uint32(len(msg)))
+	_, err := w.Write(append(header[:], msg...))
+	return err
+}
+
+// decodeStreamTransactionsRequest decodes a StreamTransactionsRequest message and returns its address, skipping
+// unknown fields.
+func decodeStreamTransactionsRequest(msg []byte) (string, error) {
+	var req StreamTransactionsRequest
+	err := proto.Unmarshal(msg, &req)
+	if err != nil {
+		return "", err
+	}
+	return req.GetAddress(), nil
+}
+
+// encodeTransaction encodes the given record as a Transaction message, the labels sorted by key.
+func encodeTransaction(tx *store.TxRecord) ([]byte, error) {
+	return proto.MarshalOptions{Deterministic: true}.Marshal(newTransaction(tx))
+}
+
+// newTransaction returns the Transaction message of the given record.
+func newTransaction(tx *store.TxRecord) *Transaction {
+	msg := &Transaction{
+		Hash:           tx.Hash,
+		From:           checksumAddress(tx.From),
+		To:             checksumAddress(tx.To),
+		BlockNumber:    tx.BlockNumber,
+		BlockHash:      tx.BlockHash,
+		BlockTimestamp: tx.BlockTimestamp,
+		ValueUsd:       tx.ValueUSD,
+		Status:         string(tx.Status),
+		Removed:        tx.Removed,
+		Labels:         tx.Labels,
+	}
+	if tx.Value != nil {
+		msg.Value = tx.Value.String()
+	}
+	return msg
+}
+
+// checksumAddress returns the EIP-55 checksummed address, as the REST API returns it, or the address as is if it's not
+// a valid one.
+func checksumAddress(addr string) string {
+	checksummed, ok := ethaddr.Checksum(addr)
+	if !ok || !strings.HasPrefix(addr, "0x") {
+		return addr
+	}
+	return checksummed
+}
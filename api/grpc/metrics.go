@@ -0,0 +1,27 @@
+package grpc
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of a Server, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	openStreams    prometheus.Gauge
+	streamMessages *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		openStreams: auto.NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_grpc_streams",
+			Help: "Number of open gRPC transaction streams",
+		}),
+		streamMessages: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_grpc_stream_messages_total",
+			Help: "Total number of transactions sent to gRPC streams by result, dropped counting the streams ended for being too slow",
+		}, []string{"result"}),
+	}
+}
@@ -0,0 +1,347 @@
+// Package grpc serves the gRPC API defined in ethtxparser.proto. It speaks the gRPC over HTTP/2 protocol directly on
+// top of net/http, without compression, so the server must be configured for unencrypted HTTP/2 unless behind TLS.
+package grpc
+
+//go:generate protoc --go_out=. --go_opt=paths=source_relative ethtxparser.proto
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+const (
+	// StreamTransactionsPath is the HTTP/2 path of the StreamTransactions RPC.
+	StreamTransactionsPath = "/ethtxparser.v1.Transactions/StreamTransactions"
+
+	// DefaultStreamBuffer is the default number of transactions buffered per stream.
+	DefaultStreamBuffer = 64
+	// DefaultSendTimeout is the default time a transaction waits for room in the buffer of a slow stream.
+	DefaultSendTimeout = 10 * time.Second
+)
+
+// Code is a gRPC status code.
+type Code int
+
+const (
+	CodeOK                Code = 0
+	CodeInvalidArgument   Code = 3
+	CodeResourceExhausted Code = 8
+	CodeUnimplemented     Code = 12
+	CodeInternal          Code = 13
+	CodeUnavailable       Code = 14
+	CodeUnauthenticated   Code = 16
+)
+
+type config struct {
+	streamBuffer int
+	sendTimeout  time.Duration
+	keys         *metering.Keys
+	usage        metering.Recorder
+	registerer   prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithStreamBuffer sets the number of transactions buffered per stream before notifying blocks.
+func WithStreamBuffer(n int) Option {
+	return func(c *config) {
+		c.streamBuffer = n
+	}
+}
+
+// WithSendTimeout sets the time a transaction waits for room in the buffer of a stream before the stream is ended
+// with RESOURCE_EXHAUSTED.
+func WithSendTimeout(timeout time.Duration) Option {
+	return func(c *config) {
+		c.sendTimeout = timeout
+	}
+}
+
+// WithUsage requires the calls to carry one of the given API keys as their bearer token, recording the streams opened
+// and the transactions delivered to them as the usage of the tenants holding the keys. The calls aren't authenticated
+// if no key is set.
+func WithUsage(keys *metering.Keys, recorder metering.Recorder) Option {
+	return func(c *config) {
+		c.keys = keys
+		c.usage = recorder
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the server are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
+
+// stream is an open StreamTransactions call.
+type stream struct {
+	address string
+	txs     chan *store.TxRecord
+	// dropped is closed once the stream is dropped for being too slow.
+	dropped  chan struct{}
+	dropOnce sync.Once
+}
+
+func (st *stream) drop() {
+	st.dropOnce.Do(func() {
+		close(st.dropped)
+	})
+}
+
+// Server is the gRPC API server. It's an index notifier, publishing the recorded transactions to the streams of their
+// addresses.
+type Server struct {
+	logger  *logrus.Logger
+	cfg     *config
+	metrics *metrics
+
+	mu      sync.RWMutex
+	streams map[string][]*stream
+	closed  chan struct{}
+	once    sync.Once
+}
+
+func NewServer(logger *logrus.Logger, opts ...Option) *Server {
+	cfg := &config{
+		streamBuffer: DefaultStreamBuffer,
+		sendTimeout:  DefaultSendTimeout,
+		registerer:   custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Server{
+		logger:  logger,
+		cfg:     cfg,
+		metrics: newMetrics(cfg.registerer),
+		streams: make(map[string][]*stream),
+		closed:  make(chan struct{}),
+	}
+}
+
+// Close ends all the open streams with UNAVAILABLE, e.g. on shutdown, as the http server waits for them otherwise.
+func (s *Server) Close() {
+	s.once.Do(func() {
+		close(s.closed)
+	})
+}
+
+// Notify publishes the transaction to the streams of its sender and recipient. It blocks while their buffers are
+// full, applying backpressure to the notification queue, up to the send timeout after which a stream is dropped.
+func (s *Server) Notify(ctx context.Context, tx *store.TxRecord) error {
+	s.mu.RLock()
+	var streams []*stream
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		streams = append(streams, s.streams[addr]...)
+	}
+	s.mu.RUnlock()
+
+	for st := range slices.Values(streams) {
+		select {
+		case st.txs <- tx:
+			continue
+		case <-st.dropped:
+			continue
+		default:
+		}
+
+		timer := time.NewTimer(s.cfg.sendTimeout)
+		select {
+		case st.txs <- tx:
+		case <-st.dropped:
+		case <-timer.C:
+			s.logger.WithContext(ctx).WithField("addr", st.address).Warn("Dropping slow gRPC transaction stream")
+			st.drop()
+			s.metrics.streamMessages.WithLabelValues("dropped").Inc()
+		case <-ctx.Done():
+			timer.Stop()
+			return ctx.Err()
+		}
+		timer.Stop()
+	}
+	return nil
+}
+
+// ServeHTTP serves the gRPC calls.
+func (s *Server) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if r.Method != http.MethodPost || !strings.HasPrefix(r.Header.Get("Content-Type"), "application/grpc") {
+		http.Error(w, "Expected a gRPC request", http.StatusUnsupportedMediaType)
+		return
+	}
+	if r.URL.Path != StreamTransactionsPath {
+		writeStatus(w, CodeUnimplemented, "unknown method "+r.URL.Path)
+		return
+	}
+
+	s.streamTransactions(w, r)
+}
+
+func (s *Server) streamTransactions(w http.ResponseWriter, r *http.Request) {
+	logger := s.logger.WithContext(r.Context())
+
+	var tenant string
+	if s.cfg.keys.Enabled() {
+		var ok bool
+		tenant, ok = s.cfg.keys.Tenant(r)
+		if !ok {
+			writeStatus(w, CodeUnauthenticated, "missing or unknown API key")
+			return
+		}
+		logger = logger.WithField("tenant", tenant)
+		s.recordUsage(r.Context(), logger, tenant, false)
+	}
+
+	msg, err := readMessage(r.Body)
+	if err != nil {
+		code := CodeInvalidArgument
+		if errors.Is(err, errCompressed) {
+			code = CodeUnimplemented
+		}
+		logger.WithError(err).Warn("Failed to read StreamTransactions request")
+		writeStatus(w, code, err.Error())
+		return
+	}
+	addr, err := decodeStreamTransactionsRequest(msg)
+	if err != nil {
+		logger.WithError(err).Warn("Failed to decode StreamTransactions request")
+		writeStatus(w, CodeInvalidArgument, "could not decode request: "+err.Error())
+		return
+	}
+	addr, valid := ethaddr.Normalize(addr)
+	if !valid {
+		writeStatus(w, CodeInvalidArgument, "invalid Ethereum address, expected a 40-character hex string")
+		return
+	}
+
+	st := s.subscribe(addr)
+	defer s.unsubscribe(st)
+	logger = logger.WithField("addr", addr)
+	logger.Debug("Opened gRPC transaction stream")
+
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Trailer", "Grpc-Status, Grpc-Message")
+	w.WriteHeader(http.StatusOK)
+	rc := http.NewResponseController(w)
+	err = rc.Flush()
+	if err != nil {
+		logger.WithError(err).Warn("Failed to flush gRPC stream headers")
+		return
+	}
+
+	for {
+		select {
+		case <-r.Context().Done():
+			logger.Debug("gRPC transaction stream cancelled by client")
+			return
+		case <-s.closed:
+			setStatus(w, CodeUnavailable, "server is shutting down")
+			return
+		case <-st.dropped:
+			setStatus(w, CodeResourceExhausted, "stream too slow to keep up with transactions")
+			return
+		case tx := <-st.txs:
+			var msg []byte
+			msg, err = encodeTransaction(tx)
+			if err == nil {
+				err = writeMessage(w, msg)
+			}
+			if err == nil {
+				err = rc.Flush()
+			}
+			if err != nil {
+				logger.WithError(err).Warn("Failed to send transaction to gRPC stream")
+				s.metrics.streamMessages.WithLabelValues("failure").Inc()
+				return
+			}
+			s.metrics.streamMessages.WithLabelValues("success").Inc()
+			if tenant != "" {
+				s.recordUsage(r.Context(), logger, tenant, true)
+			}
+		}
+	}
+}
+
+// recordUsage records an opened stream, or a transaction delivered to it, as the usage of the tenant.
+func (s *Server) recordUsage(ctx context.Context, logger *logrus.Entry, tenant string, delivery bool) {
+	var err error
+	if delivery {
+		err = s.cfg.usage.IncrementDeliveries(ctx, tenant, metering.ChannelGRPC, 1)
+	} else {
+		err = s.cfg.usage.IncrementRequests(ctx, tenant)
+	}
+	if err != nil {
+		logger.WithError(err).Warn("Failed to record gRPC stream usage")
+	}
+}
+
+func (s *Server) subscribe(addr string) *stream {
+	st := &stream{
+		address: addr,
+		txs:     make(chan *store.TxRecord, s.cfg.streamBuffer),
+		dropped: make(chan struct{}),
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[addr] = append(s.streams[addr], st)
+	s.metrics.openStreams.Inc()
+	return st
+}
+
+func (s *Server) unsubscribe(st *stream) {
+	// unblocks notifiers waiting on the stream
+	st.drop()
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.streams[st.address] = slices.DeleteFunc(s.streams[st.address], func(other *stream) bool {
+		return other == st
+	})
+	if len(s.streams[st.address]) == 0 {
+		delete(s.streams, st.address)
+	}
+	s.metrics.openStreams.Dec()
+}
+
+// writeStatus ends a call with the given status before any message is sent, as a trailers-only response.
+func writeStatus(w http.ResponseWriter, code Code, msg string) {
+	w.Header().Set("Content-Type", "application/grpc")
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	if msg != "" {
+		w.Header().Set("Grpc-Message", msg)
+	}
+	w.WriteHeader(http.StatusOK)
+}
+
+// setStatus sets the status trailers of a call once its headers are sent.
+func setStatus(w http.ResponseWriter, code Code, msg string) {
+	w.Header().Set("Grpc-Status", strconv.Itoa(int(code)))
+	w.Header().Set("Grpc-Message", msg)
+}
+
+func uniqueAddresses(tx *store.TxRecord) []string {
+	addrs := make([]string, 0, 2)
+	if tx.From != "" {
+		addrs = append(addrs, tx.From)
+	}
+	if tx.To != "" && tx.To != tx.From {
+		addrs = append(addrs, tx.To)
+	}
+	return addrs
+}
@@ -0,0 +1,241 @@
+package grpc_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"google.golang.org/protobuf/encoding/protowire"
+
+	grpcapi "github.com/hedisam/ethtxparser/api/grpc"
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+const watched = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+
+// checksummed is the EIP-55 checksummed watched address, as streamed.
+const checksummed = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+
+func TestStreamTransactions(t *testing.T) {
+	server := grpcapi.NewServer(logrus.New())
+	url, client := startServer(t, server)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp := call(t, ctx, client, url+grpcapi.StreamTransactionsPath, "0x7A250D5630B4CF539739DF2C5DACB4C659F2488D")
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Empty(t, resp.Header.Get("Grpc-Status"))
+
+	txs := []*store.TxRecord{
+		{Hash: "0x01", From: watched, To: "0x01", Value: big.NewInt(1), BlockNumber: 10},
+		{Hash: "0x02", From: "0x02", To: "0x03", BlockNumber: 10},
+		{Hash: "0x03", From: "0x02", To: watched, BlockNumber: 11, Removed: true, Labels: map[string]string{"from": "Exchange"}},
+	}
+	for tx := range slices.Values(txs) {
+		require.NoError(t, server.Notify(ctx, tx))
+	}
+
+	first := readTransaction(t, resp.Body)
+	assert.Equal(t, map[protowire.Number]any{1: "0x01", 2: checksummed, 3: "0x01", 4: "1", 5: uint64(10)}, first)
+	second := readTransaction(t, resp.Body)
+	assert.Equal(t, "0x03", second[1])
+	assert.Equal(t, checksummed, second[3])
+	assert.Equal(t, uint64(1), second[10])
+	assert.NotNil(t, second[11])
+
+	server.Close()
+	_, err := io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "14", resp.Trailer.Get("Grpc-Status"))
+}
+
+func TestStreamTransactionsErrors(t *testing.T) {
+	tests := map[string]struct {
+		path         string
+		address      string
+		expectedCode string
+	}{
+		"invalid address": {
+			path:         grpcapi.StreamTransactionsPath,
+			address:      "0x1234",
+			expectedCode: "3",
+		},
+		"unknown method": {
+			path:         "/ethtxparser.v1.Transactions/ListTransactions",
+			address:      watched,
+			expectedCode: "12",
+		},
+	}
+
+	server := grpcapi.NewServer(logrus.New())
+	url, client := startServer(t, server)
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			resp := call(t, context.Background(), client, url+test.path, test.address)
+			defer resp.Body.Close()
+			_, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedCode, resp.Header.Get("Grpc-Status"))
+		})
+	}
+}
+
+func TestStreamTransactionsDropsSlowStreams(t *testing.T) {
+	server := grpcapi.NewServer(logrus.New(), grpcapi.WithStreamBuffer(1), grpcapi.WithSendTimeout(10*time.Millisecond))
+	url, client := startServer(t, server)
+
+	resp := call(t, context.Background(), client, url+grpcapi.StreamTransactionsPath, watched)
+	defer resp.Body.Close()
+
+	// the stream isn't read until its flow control window and buffer fill up, about 7MB of transactions
+	value := new(big.Int).Lsh(big.NewInt(1), 80000)
+	for range 300 {
+		require.NoError(t, server.Notify(context.Background(), &store.TxRecord{Hash: "0x01", From: watched, Value: value}))
+	}
+
+	_, err := io.Copy(io.Discard, resp.Body)
+	require.NoError(t, err)
+	assert.Equal(t, "8", resp.Trailer.Get("Grpc-Status"))
+}
+
+func TestStreamTransactionsUsage(t *testing.T) {
+	keys, err := metering.ParseKeys("acme:k1")
+	require.NoError(t, err)
+	usageStore := memdb.NewUsageStore()
+	server := grpcapi.NewServer(logrus.New(), grpcapi.WithUsage(keys, usageStore))
+	url, client := startServer(t, server)
+
+	resp := call(t, context.Background(), client, url+grpcapi.StreamTransactionsPath, watched)
+	_, err = io.ReadAll(resp.Body)
+	require.NoError(t, err)
+	resp.Body.Close()
+	assert.Equal(t, "16", resp.Header.Get("Grpc-Status"))
+
+	client.Transport = &bearerTransport{token: "k1", next: client.Transport}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	resp = call(t, ctx, client, url+grpcapi.StreamTransactionsPath, watched)
+	defer resp.Body.Close()
+	require.Empty(t, resp.Header.Get("Grpc-Status"))
+
+	require.NoError(t, server.Notify(ctx, &store.TxRecord{Hash: "0x01", From: watched, To: "0x01", Value: big.NewInt(1)}))
+	readTransaction(t, resp.Body)
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		usages, err := usageStore.GetUsage(ctx)
+		require.NoError(c, err)
+		assert.Equal(c, []*store.TenantUsage{
+			{Tenant: "acme", Requests: 1, Deliveries: map[string]int64{metering.ChannelGRPC: 1}},
+		}, usages)
+	}, time.Second, 10*time.Millisecond)
+}
+
+// TestTransactionMatchesREST keeps the Transaction message from drifting from the Transaction message of the REST API,
+// defined in api/rest/types.proto.
+func TestTransactionMatchesREST(t *testing.T) {
+	restFields := make(map[string]bool)
+	restType := reflect.TypeFor[restapi.Transaction]()
+	for i := range restType.NumField() {
+		name, _, _ := strings.Cut(restType.Field(i).Tag.Get("json"), ",")
+		restFields[name] = true
+	}
+
+	fields := (&grpcapi.Transaction{}).ProtoReflect().Descriptor().Fields()
+	for i := range fields.Len() {
+		assert.True(t, restFields[fields.Get(i).JSONName()], "field %s of the Transaction message missing from the REST API", fields.Get(i).Name())
+	}
+}
+
+func startServer(t *testing.T, server *grpcapi.Server) (string, *http.Client) {
+	t.Helper()
+
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := httptest.NewUnstartedServer(server)
+	srv.Config.Protocols = &protocols
+	srv.Config.RegisterOnShutdown(server.Close)
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	return srv.URL, &http.Client{Transport: &http.Transport{Protocols: &protocols}}
+}
+
+func call(t *testing.T, ctx context.Context, client *http.Client, url, address string) *http.Response {
+	t.Helper()
+
+	msg := protowire.AppendTag(nil, 1, protowire.BytesType)
+	msg = protowire.AppendString(msg, address)
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, url, bytes.NewReader(append(body, msg...)))
+	require.NoError(t, err)
+	req.Header.Set("Content-Type", "application/grpc")
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	return resp
+}
+
+// bearerTransport authorizes the requests with the bearer token.
+type bearerTransport struct {
+	token string
+	next  http.RoundTripper
+}
+
+func (t *bearerTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	req = req.Clone(req.Context())
+	req.Header.Set("Authorization", "Bearer "+t.token)
+	return t.next.RoundTrip(req)
+}
+
+// readTransaction reads a Transaction message, returning its fields by number, labels as their raw bytes.
+func readTransaction(t *testing.T, r io.Reader) map[protowire.Number]any {
+	t.Helper()
+
+	header := make([]byte, 5)
+	_, err := io.ReadFull(r, header)
+	require.NoError(t, err)
+	msg := make([]byte, binary.BigEndian.Uint32(header[1:]))
+	_, err = io.ReadFull(r, msg)
+	require.NoError(t, err)
+
+	fields := make(map[protowire.Number]any)
+	for len(msg) > 0 {
+		num, typ, n := protowire.ConsumeTag(msg)
+		require.Positive(t, n)
+		msg = msg[n:]
+		switch typ {
+		case protowire.VarintType:
+			v, n := protowire.ConsumeVarint(msg)
+			require.Positive(t, n)
+			fields[num] = v
+			msg = msg[n:]
+		case protowire.BytesType:
+			v, n := protowire.ConsumeBytes(msg)
+			require.Positive(t, n)
+			if num == 11 {
+				fields[num] = v
+			} else {
+				fields[num] = string(v)
+			}
+			msg = msg[n:]
+		default:
+			t.Fatalf("unexpected wire type %d of field %d", typ, num)
+		}
+	}
+	return fields
+}
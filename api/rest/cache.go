@@ -0,0 +1,195 @@
+package rest
+
+import (
+	"context"
+	"maps"
+	"net/http"
+	"sync"
+	"time"
+)
+
+const (
+	// DefaultResponseCacheEntries is the default number of responses the cache keeps.
+	DefaultResponseCacheEntries = 1024
+	// maxCachedResponseSize is the size of the largest response body cached, the larger ones being served uncached.
+	maxCachedResponseSize = 1 << 20
+)
+
+// CurrentBlockGetter returns the number of the last indexed block, which the cached responses are valid for.
+type CurrentBlockGetter interface {
+	GetCurrentBlockNumber(ctx context.Context) (int64, error)
+}
+
+// cachedResponse is a response cached for a block. It's filled by the first request missing the cache, the concurrent
+// requests for it waiting until done is closed instead of serving it again.
+type cachedResponse struct {
+	block     int64
+	expiresAt time.Time
+	done      chan struct{}
+	// ok is set once the response is filled, only if it can be replayed: a 200 small enough to be kept.
+	ok     bool
+	header http.Header
+	body   []byte
+}
+
+// ResponseCache caches the responses of the read endpoints, so that many clients polling them, e.g. dashboards,
+// don't each hit the store. The responses are cached for the last indexed block, up to the TTL, and invalidated as
+// soon as a new block is indexed. Streamed NDJSON responses aren't cached.
+type ResponseCache struct {
+	blocks     CurrentBlockGetter
+	ttl        time.Duration
+	maxEntries int
+
+	mu      sync.Mutex
+	entries map[string]*cachedResponse
+}
+
+func NewResponseCache(blocks CurrentBlockGetter, ttl time.Duration, maxEntries int) *ResponseCache {
+	return &ResponseCache{
+		blocks:     blocks,
+		ttl:        ttl,
+		maxEntries: max(maxEntries, 1),
+		entries:    make(map[string]*cachedResponse),
+	}
+}
+
+// Handler is a Middleware serving the GET and HEAD requests from the cache, keyed by their URL and Accept header.
+func (c *ResponseCache) Handler(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if (r.Method != http.MethodGet && r.Method != http.MethodHead) || acceptsMediaType(r.Header.Get("Accept"), ContentTypeNDJSON) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		// the block is read before the response is made, which is then cached for a block at most as recent as the
+		// data it was made of
+		block, err := c.blocks.GetCurrentBlockNumber(r.Context())
+		if err != nil {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		key := r.Method + " " + r.URL.RequestURI() + " " + r.Header.Get("Accept")
+		entry, fill := c.lookup(key, block)
+		if !fill {
+			select {
+			case <-entry.done:
+			case <-r.Context().Done():
+				return
+			}
+			if entry.ok {
+				responseCacheLookups.WithLabelValues(r.Pattern, "hit").Inc()
+				replay(w, r, entry)
+				return
+			}
+		}
+
+		responseCacheLookups.WithLabelValues(r.Pattern, "miss").Inc()
+		if !fill {
+			next.ServeHTTP(w, r)
+			return
+		}
+		defer close(entry.done)
+		rec := &cacheRecorder{ResponseWriter: w, status: http.StatusOK}
+		next.ServeHTTP(rec, r)
+		if rec.status != http.StatusOK || rec.overflow {
+			c.mu.Lock()
+			if c.entries[key] == entry {
+				delete(c.entries, key)
+			}
+			c.mu.Unlock()
+			return
+		}
+		entry.header = w.Header().Clone()
+		entry.header.Del(RequestIDHeader)
+		entry.body = rec.body
+		entry.ok = true
+	})
+}
+
+// lookup returns the response cached for the key at the given block, or a new one the caller must fill, reporting it.
+func (c *ResponseCache) lookup(key string, block int64) (*cachedResponse, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := c.entries[key]
+	if ok && entry.block == block && now.Before(entry.expiresAt) {
+		return entry, false
+	}
+
+	entry = &cachedResponse{
+		block:     block,
+		expiresAt: now.Add(c.ttl),
+		done:      make(chan struct{}),
+	}
+	if !ok && len(c.entries) >= c.maxEntries {
+		c.evict(block, now)
+	}
+	c.entries[key] = entry
+	return entry, true
+}
+
+// evict removes the responses of previous blocks and the expired ones, or any of them if none is. Must be called with
+// the lock held.
+func (c *ResponseCache) evict(block int64, now time.Time) {
+	maps.DeleteFunc(c.entries, func(_ string, entry *cachedResponse) bool {
+		return entry.block != block || !now.Before(entry.expiresAt)
+	})
+	for key := range c.entries {
+		if len(c.entries) < c.maxEntries {
+			return
+		}
+		delete(c.entries, key)
+	}
+}
+
+// replay writes the cached response, with the request ID of the request.
+func replay(w http.ResponseWriter, r *http.Request, entry *cachedResponse) {
+	header := w.Header()
+	maps.Copy(header, entry.header)
+	requestID := r.Header.Get(RequestIDHeader)
+	if requestID == "" {
+		requestID = newRequestID()
+	}
+	header.Set(RequestIDHeader, requestID)
+	w.WriteHeader(http.StatusOK)
+	if r.Method != http.MethodHead {
+		_, _ = w.Write(entry.body)
+	}
+}
+
+// cacheRecorder writes the response through, keeping a copy of its status code and body to be cached.
+type cacheRecorder struct {
+	http.ResponseWriter
+	status      int
+	wroteHeader bool
+	body        []byte
+	// overflow is set once the body outgrows maxCachedResponseSize, which is then no longer kept.
+	overflow bool
+}
+
+func (cr *cacheRecorder) WriteHeader(status int) {
+	if !cr.wroteHeader {
+		cr.status = status
+		cr.wroteHeader = true
+	}
+	cr.ResponseWriter.WriteHeader(status)
+}
+
+func (cr *cacheRecorder) Write(b []byte) (int, error) {
+	cr.wroteHeader = true
+	if !cr.overflow {
+		if len(cr.body)+len(b) > maxCachedResponseSize {
+			cr.overflow = true
+			cr.body = nil
+		} else {
+			cr.body = append(cr.body, b...)
+		}
+	}
+	return cr.ResponseWriter.Write(b)
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (cr *cacheRecorder) Unwrap() http.ResponseWriter {
+	return cr.ResponseWriter
+}
@@ -0,0 +1,175 @@
+package rest_test
+
+import (
+	"cmp"
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/api/rest/mocks"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+func TestResponseCache(t *testing.T) {
+	type request struct {
+		method string
+		path   string
+		accept string
+		// block is the current block the request is served at.
+		block int64
+		// after is how long after the previous request it's served.
+		after          time.Duration
+		expectedBody   string
+		expectedStatus int
+	}
+
+	tests := map[string]struct {
+		ttl      time.Duration
+		status   int
+		requests []request
+	}{
+		"cached until the next block": {
+			ttl: time.Minute,
+			requests: []request{
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1"},
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1"},
+				{path: "/transactions/0xbb", block: 1, expectedBody: "2"},
+				{path: "/transactions/0xaa?type=blob", block: 1, expectedBody: "3"},
+				{path: "/transactions/0xaa", block: 2, expectedBody: "4"},
+				{path: "/transactions/0xaa", block: 2, expectedBody: "4"},
+			},
+		},
+		"cached up to the ttl": {
+			ttl: 20 * time.Millisecond,
+			requests: []request{
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1"},
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1"},
+				{path: "/transactions/0xaa", block: 1, after: 30 * time.Millisecond, expectedBody: "2"},
+			},
+		},
+		"cached by accepted media type": {
+			ttl: time.Minute,
+			requests: []request{
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1"},
+				{path: "/transactions/0xaa", accept: "text/csv", block: 1, expectedBody: "2"},
+				{path: "/transactions/0xaa", accept: "text/csv", block: 1, expectedBody: "2"},
+			},
+		},
+		"streams not cached": {
+			ttl: time.Minute,
+			requests: []request{
+				{path: "/transactions/0xaa", accept: restapi.ContentTypeNDJSON, block: 1, expectedBody: "1"},
+				{path: "/transactions/0xaa", accept: restapi.ContentTypeNDJSON, block: 1, expectedBody: "2"},
+			},
+		},
+		"writes not cached": {
+			ttl: time.Minute,
+			requests: []request{
+				{method: http.MethodPut, path: "/subscriptions/0xaa", block: 1, expectedBody: "1"},
+				{method: http.MethodPut, path: "/subscriptions/0xaa", block: 1, expectedBody: "2"},
+			},
+		},
+		"errors not cached": {
+			ttl:    time.Minute,
+			status: http.StatusNotFound,
+			requests: []request{
+				{path: "/transactions/0xaa", block: 1, expectedBody: "1", expectedStatus: http.StatusNotFound},
+				{path: "/transactions/0xaa", block: 1, expectedBody: "2", expectedStatus: http.StatusNotFound},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var block atomic.Int64
+			txStoreMock := &mocks.TxStoreMock{
+				GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+					return block.Load(), nil
+				},
+			}
+			var calls int
+			handler := restapi.NewResponseCache(txStoreMock, test.ttl, 8).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				calls++
+				w.Header().Set("Content-Type", "application/json")
+				w.Header().Set(restapi.RequestIDHeader, r.Header.Get(restapi.RequestIDHeader))
+				w.WriteHeader(cmp.Or(test.status, http.StatusOK))
+				_, _ = w.Write([]byte(strconv.Itoa(calls)))
+			}))
+
+			for i, req := range test.requests {
+				time.Sleep(req.after)
+				block.Store(req.block)
+				r := httptest.NewRequest(cmp.Or(req.method, http.MethodGet), req.path, nil)
+				r.Header.Set(restapi.RequestIDHeader, "req-"+strconv.Itoa(i))
+				if req.accept != "" {
+					r.Header.Set("Accept", req.accept)
+				}
+				rec := httptest.NewRecorder()
+				handler.ServeHTTP(rec, r)
+				assert.Equal(t, cmp.Or(req.expectedStatus, http.StatusOK), rec.Code, "request %d", i)
+				assert.Equal(t, req.expectedBody, rec.Body.String(), "request %d", i)
+				assert.Equal(t, "application/json", rec.Header().Get("Content-Type"), "request %d", i)
+				// the request ids of the cached responses aren't replayed
+				assert.Equal(t, "req-"+strconv.Itoa(i), rec.Header().Get(restapi.RequestIDHeader), "request %d", i)
+			}
+		})
+	}
+}
+
+func TestResponseCacheCoalescesRequests(t *testing.T) {
+	txStoreMock := &mocks.TxStoreMock{
+		GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+			return 1, nil
+		},
+	}
+	var calls atomic.Int64
+	release := make(chan struct{})
+	handler := restapi.NewResponseCache(txStoreMock, time.Minute, 8).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls.Add(1)
+		<-release
+		_, _ = w.Write([]byte("ok"))
+	}))
+
+	var wg sync.WaitGroup
+	for range 10 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/blocks/current", nil))
+			assert.Equal(t, "ok", rec.Body.String())
+		}()
+	}
+	require.Eventually(t, func() bool {
+		return calls.Load() == 1
+	}, time.Second, time.Millisecond)
+	close(release)
+	wg.Wait()
+	assert.Equal(t, int64(1), calls.Load())
+}
+
+func TestResponseCacheWithoutBlocks(t *testing.T) {
+	txStoreMock := &mocks.TxStoreMock{
+		GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+			return 0, store.ErrNotFound
+		},
+	}
+	var calls int
+	handler := restapi.NewResponseCache(txStoreMock, time.Minute, 8).Handler(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		calls++
+	}))
+
+	for range 2 {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/blocks/current", nil))
+	}
+	assert.Equal(t, 2, calls)
+}
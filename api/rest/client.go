@@ -0,0 +1,137 @@
+package rest
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// maxErrBodySize caps how much of an error response is read into the returned error.
+const maxErrBodySize = 4096
+
+// Client calls the REST API of a running server.
+type Client struct {
+	httpClient *http.Client
+	serverURL  string
+	api        string
+}
+
+// NewClient returns a client of the server at serverURL, calling the API of the given chain, or of the chain followed
+// by the top-level options if chain is empty.
+func NewClient(httpClient *http.Client, serverURL, chain string) *Client {
+	api := "/api/v1"
+	if chain != "" {
+		api += "/chains/" + url.PathEscape(chain)
+	}
+	return &Client{
+		httpClient: httpClient,
+		serverURL:  strings.TrimSuffix(serverURL, "/"),
+		api:        api,
+	}
+}
+
+// GetCurrentBlock returns the last block parsed by the server.
+func (c *Client) GetCurrentBlock(ctx context.Context) (*GetCurrentBlockResponse, error) {
+	var resp GetCurrentBlockResponse
+	err := c.do(ctx, http.MethodGet, c.api+"/blocks/current", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Subscribe subscribes the address of the request, or updates its subscription.
+func (c *Client) Subscribe(ctx context.Context, req *SubscribeRequest) (*SubscribeResponse, error) {
+	var resp SubscribeResponse
+	err := c.do(ctx, http.MethodPut, c.api+"/subscriptions/"+url.PathEscape(req.Address), req, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListSubscriptions lists the subscribed addresses.
+func (c *Client) ListSubscriptions(ctx context.Context) (*ListSubscriptionResponse, error) {
+	var resp ListSubscriptionResponse
+	err := c.do(ctx, http.MethodGet, c.api+"/subscriptions/", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// ListTransactions lists the recorded transactions of a subscribed address.
+func (c *Client) ListTransactions(ctx context.Context, addr string) (*ListTransactionsResponse, error) {
+	var resp ListTransactionsResponse
+	err := c.do(ctx, http.MethodGet, c.api+"/transactions/"+url.PathEscape(addr), nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// Ready returns the readiness of the server, failing with the reason it isn't ready.
+func (c *Client) Ready(ctx context.Context) (*ReadyResponse, error) {
+	path := c.api + "/readyz"
+	if c.api == "/api/v1" {
+		path = "/readyz"
+	}
+	var resp ReadyResponse
+	err := c.do(ctx, http.MethodGet, path, nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// GetVersion returns the build version of the server.
+func (c *Client) GetVersion(ctx context.Context) (*VersionResponse, error) {
+	var resp VersionResponse
+	err := c.do(ctx, http.MethodGet, c.api+"/version", nil, &resp)
+	if err != nil {
+		return nil, err
+	}
+	return &resp, nil
+}
+
+// do sends the request, decoding the response into resp. Non-2xx responses are returned as *Err, with the message
+// written by the server.
+func (c *Client) do(ctx context.Context, method, path string, reqBody, resp any) error {
+	var body io.Reader
+	if reqBody != nil {
+		data, err := json.Marshal(reqBody)
+		if err != nil {
+			return fmt.Errorf("could not marshal request: %w", err)
+		}
+		body = bytes.NewReader(data)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, c.serverURL+path, body)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+	if reqBody != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	httpResp, err := c.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send request: %w", err)
+	}
+	defer httpResp.Body.Close()
+	if httpResp.StatusCode < 200 || httpResp.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(httpResp.Body, maxErrBodySize))
+		return NewErrf(httpResp.StatusCode, "%s", strings.TrimSpace(string(msg)))
+	}
+
+	err = json.NewDecoder(httpResp.Body).Decode(resp)
+	if err != nil {
+		return fmt.Errorf("could not decode response: %w", err)
+	}
+	return nil
+}
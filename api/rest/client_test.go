@@ -0,0 +1,94 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+func TestClient(t *testing.T) {
+	tests := map[string]struct {
+		chain      string
+		readyErr   error
+		expectedTx string
+		expectErr  *restapi.Err
+	}{
+		"primary chain": {
+			expectedTx: "0xprimary",
+		},
+		"chain by name": {
+			chain:      "polygon",
+			expectedTx: "0xpolygon",
+		},
+		"error returned by the server": {
+			readyErr:   restapi.NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet"),
+			expectedTx: "0xprimary",
+			expectErr: &restapi.Err{
+				Message:    "No parsed blocks yet",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := logging.Logrus(logrus.New())
+			mux := http.NewServeMux()
+			for prefix, hash := range map[string]string{"": "0xprimary", "/chains/polygon": "0xpolygon"} {
+				restapi.RegisterFunc(logger, mux, http.MethodGet, "/api/v1"+prefix+"/transactions/{address}",
+					func(ctx context.Context, req *restapi.ListTransactionsRequest) (*restapi.ListTransactionsResponse, error) {
+						return &restapi.ListTransactionsResponse{
+							Transactions: []*restapi.Transaction{{Hash: hash, To: req.Address}},
+						}, nil
+					})
+				restapi.RegisterFunc(logger, mux, http.MethodPut, "/api/v1"+prefix+"/subscriptions/{address}",
+					func(ctx context.Context, req *restapi.SubscribeRequest) (*restapi.SubscribeResponse, error) {
+						assert.Equal(t, "100", req.MinValue)
+						return &restapi.SubscribeResponse{Ok: true, StartBlock: 42}, nil
+					})
+			}
+			readyPath := "/readyz"
+			if test.chain != "" {
+				readyPath = "/api/v1/chains/" + test.chain + "/readyz"
+			}
+			restapi.RegisterFunc(logger, mux, http.MethodGet, readyPath,
+				func(ctx context.Context, req *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+					if test.readyErr != nil {
+						return nil, test.readyErr
+					}
+					return &restapi.ReadyResponse{Status: "ready"}, nil
+				})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			ctx := context.Background()
+			client := restapi.NewClient(srv.Client(), srv.URL+"/", test.chain)
+
+			subResp, err := client.Subscribe(ctx, &restapi.SubscribeRequest{Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", MinValue: "100"})
+			require.NoError(t, err)
+			assert.Equal(t, &restapi.SubscribeResponse{Ok: true, StartBlock: 42}, subResp)
+
+			txsResp, err := client.ListTransactions(ctx, "0x7a250d5630b4cf539739df2c5dacb4c659f2488d")
+			require.NoError(t, err)
+			require.Len(t, txsResp.Transactions, 1)
+			assert.Equal(t, test.expectedTx, txsResp.Transactions[0].Hash)
+			assert.Equal(t, "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", txsResp.Transactions[0].To)
+
+			readyResp, err := client.Ready(ctx)
+			if test.expectErr != nil {
+				assert.Equal(t, test.expectErr, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "ready", readyResp.Status)
+		})
+	}
+}
@@ -0,0 +1,247 @@
+// Command protoc-gen-go-rest generates the Go types of the REST messages, defined in api/rest/types.proto, as plain
+// structs encoded by encoding/json rather than protobuf messages, so that the JSON of the API keeps int64 fields as
+// numbers and omits the omit_empty fields only. The fields are tagged with their json_name and validate option, see
+// api/rest/options.proto for the options shaping their Go types.
+//
+// It's run by protoc with --go-rest_out once installed, see the go:generate directives of api/rest.
+package main
+
+import (
+	"cmp"
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+
+	"google.golang.org/protobuf/compiler/protogen"
+	"google.golang.org/protobuf/proto"
+	"google.golang.org/protobuf/reflect/protoreflect"
+	"google.golang.org/protobuf/reflect/protoregistry"
+	"google.golang.org/protobuf/types/descriptorpb"
+	"google.golang.org/protobuf/types/dynamicpb"
+	"google.golang.org/protobuf/types/pluginpb"
+)
+
+// optionsFile defines the field options, read as dynamic extensions since the plugin isn't compiled with them.
+const optionsFile = "options.proto"
+
+// initialisms are the parts of the field names upper cased in their Go names, e.g. webhook_url to WebhookURL.
+var initialisms = map[string]bool{
+	"abi": true,
+	"id":  true,
+	"url": true,
+	"usd": true,
+}
+
+// fieldOptions are the options of a field defined by options.proto.
+type fieldOptions struct {
+	omitEmpty bool
+	validate  string
+	goType    string
+	goName    string
+	embed     bool
+}
+
+func main() {
+	protogen.Options{}.Run(func(plugin *protogen.Plugin) error {
+		plugin.SupportedFeatures = uint64(pluginpb.CodeGeneratorResponse_FEATURE_PROTO3_OPTIONAL)
+
+		options, ok := plugin.FilesByPath[optionsFile]
+		if !ok {
+			return fmt.Errorf("%s is not imported", optionsFile)
+		}
+		extensions := new(protoregistry.Types)
+		for i := range options.Desc.Extensions().Len() {
+			err := extensions.RegisterExtension(dynamicpb.NewExtensionType(options.Desc.Extensions().Get(i)))
+			if err != nil {
+				return fmt.Errorf("could not register the options of %s: %w", optionsFile, err)
+			}
+		}
+
+		for file := range slices.Values(plugin.Files) {
+			if !file.Generate || file.Desc.Path() == optionsFile {
+				continue
+			}
+			err := generate(plugin, file, extensions)
+			if err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func generate(plugin *protogen.Plugin, file *protogen.File, extensions *protoregistry.Types) error {
+	g := plugin.NewGeneratedFile(file.GeneratedFilenamePrefix+"_rest.pb.go", file.GoImportPath)
+	g.P("// Code generated by protoc-gen-go-rest. DO NOT EDIT.")
+	g.P("// source: ", file.Desc.Path())
+	g.P()
+	g.P("package ", file.GoPackageName)
+
+	for message := range slices.Values(file.Messages) {
+		g.P()
+		if len(message.Fields) == 0 {
+			g.P(message.Comments.Leading, "type ", message.GoIdent, " struct{}")
+			continue
+		}
+
+		g.P(message.Comments.Leading, "type ", message.GoIdent, " struct {")
+		for field := range slices.Values(message.Fields) {
+			opts, err := optionsOf(field, extensions)
+			if err != nil {
+				return err
+			}
+			if opts.embed {
+				if field.Message == nil {
+					return fmt.Errorf("%s: only message fields can be embedded", field.Desc.FullName())
+				}
+				g.P(field.Comments.Leading, g.QualifiedGoIdent(field.Message.GoIdent))
+				continue
+			}
+
+			typ, err := goType(g, field, opts)
+			if err != nil {
+				return err
+			}
+			name := cmp.Or(opts.goName, goName(string(field.Desc.Name())))
+			g.P(field.Comments.Leading, name, " ", typ, tags(field, name, opts))
+		}
+		g.P("}")
+	}
+	return nil
+}
+
+// optionsOf returns the options of the field, parsed again with the extensions of options.proto.
+func optionsOf(field *protogen.Field, extensions *protoregistry.Types) (*fieldOptions, error) {
+	raw, err := proto.Marshal(field.Desc.Options())
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not marshal options: %w", field.Desc.FullName(), err)
+	}
+	parsed := &descriptorpb.FieldOptions{}
+	err = proto.UnmarshalOptions{Resolver: extensions}.Unmarshal(raw, parsed)
+	if err != nil {
+		return nil, fmt.Errorf("%s: could not unmarshal options: %w", field.Desc.FullName(), err)
+	}
+
+	opts := &fieldOptions{}
+	parsed.ProtoReflect().Range(func(fd protoreflect.FieldDescriptor, v protoreflect.Value) bool {
+		if !fd.IsExtension() || fd.ParentFile().Path() != optionsFile {
+			return true
+		}
+		switch fd.Name() {
+		case "omit_empty":
+			opts.omitEmpty = v.Bool()
+		case "validate":
+			opts.validate = v.String()
+		case "go_type":
+			opts.goType = v.String()
+		case "go_name":
+			opts.goName = v.String()
+		case "embed":
+			opts.embed = v.Bool()
+		}
+		return true
+	})
+	return opts, nil
+}
+
+// goType returns the Go type of the field: maps and repeated fields are maps and slices, messages are pointers to
+// their types, optional scalars pointers to theirs and the well-known Struct and Value any JSON.
+func goType(g *protogen.GeneratedFile, field *protogen.Field, opts *fieldOptions) (string, error) {
+	if opts.goType != "" {
+		return qualifiedType(g, opts.goType), nil
+	}
+	if field.Desc.IsMap() {
+		key, err := elemType(g, field.Message.Fields[0])
+		if err != nil {
+			return "", err
+		}
+		value, err := elemType(g, field.Message.Fields[1])
+		if err != nil {
+			return "", err
+		}
+		return "map[" + key + "]" + value, nil
+	}
+
+	typ, err := elemType(g, field)
+	if err != nil {
+		return "", err
+	}
+	switch {
+	case field.Desc.IsList():
+		return "[]" + typ, nil
+	case field.Desc.HasOptionalKeyword():
+		return "*" + typ, nil
+	}
+	return typ, nil
+}
+
+func elemType(g *protogen.GeneratedFile, field *protogen.Field) (string, error) {
+	switch field.Desc.Kind() {
+	case protoreflect.StringKind:
+		return "string", nil
+	case protoreflect.BoolKind:
+		return "bool", nil
+	case protoreflect.Int32Kind:
+		return "int32", nil
+	case protoreflect.Int64Kind:
+		return "int64", nil
+	case protoreflect.DoubleKind:
+		return "float64", nil
+	case protoreflect.BytesKind:
+		return "[]byte", nil
+	case protoreflect.MessageKind:
+		switch field.Message.Desc.FullName() {
+		case "google.protobuf.Struct":
+			return "map[string]any", nil
+		case "google.protobuf.Value":
+			return "any", nil
+		}
+		return "*" + g.QualifiedGoIdent(field.Message.GoIdent), nil
+	}
+	return "", fmt.Errorf("%s: unsupported field kind %s", field.Desc.FullName(), field.Desc.Kind())
+}
+
+// qualifiedType qualifies the package of a go_type, given by its import path, e.g. encoding/json.RawMessage, or
+// iter.Seq2[*Transaction, error] for a generic one.
+func qualifiedType(g *protogen.GeneratedFile, typ string) string {
+	name := typ
+	if i := strings.IndexByte(typ, '['); i >= 0 {
+		name = typ[:i]
+	}
+	i := strings.LastIndexByte(name, '.')
+	if i < 0 {
+		return typ
+	}
+	ident := protogen.GoIdent{GoName: name[i+1:], GoImportPath: protogen.GoImportPath(name[:i])}
+	return g.QualifiedGoIdent(ident) + typ[len(name):]
+}
+
+// goName returns the Go name of a field, its upper camel case name, e.g. block_number_int to BlockNumberInt.
+func goName(name string) string {
+	var b strings.Builder
+	for part := range strings.SplitSeq(name, "_") {
+		if initialisms[part] {
+			b.WriteString(strings.ToUpper(part))
+			continue
+		}
+		b.WriteString(strings.ToUpper(part[:1]) + part[1:])
+	}
+	return b.String()
+}
+
+// tags returns the struct tags of the field, none for an unexported one as it's left out of the JSON.
+func tags(field *protogen.Field, name string, opts *fieldOptions) string {
+	if unicode.IsLower(rune(name[0])) {
+		return ""
+	}
+	jsonTag := field.Desc.JSONName()
+	if opts.omitEmpty {
+		jsonTag += ",omitempty"
+	}
+	tags := fmt.Sprintf("json:%q", jsonTag)
+	if opts.validate != "" {
+		tags += fmt.Sprintf(" validate:%q", opts.validate)
+	}
+	return " `" + tags + "`"
+}
@@ -0,0 +1,96 @@
+package rest
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// unmatchedRoute labels the requests matching no route, so unknown paths don't blow up the cardinality of the metrics.
+const unmatchedRoute = "unmatched"
+
+var (
+	httpRequests = custompromauto.Auto().NewCounterVec(prometheus.CounterOpts{
+		Name: "ethtxparser_http_requests_total",
+		Help: "Total number of HTTP requests served, by route and status code",
+	}, []string{"route", "code"})
+	httpRequestDuration = custompromauto.Auto().NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ethtxparser_http_request_duration_seconds",
+		Help:    "Time taken to serve HTTP requests, by route and status code",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"route", "code"})
+	httpResponseSize = custompromauto.Auto().NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "ethtxparser_http_response_size_bytes",
+		Help:    "Size of the HTTP response bodies, by route and status code",
+		Buckets: prometheus.ExponentialBuckets(64, 4, 8), // 64B to 1MiB
+	}, []string{"route", "code"})
+	httpRequestsInFlight = custompromauto.Auto().NewGauge(prometheus.GaugeOpts{
+		Name: "ethtxparser_http_requests_in_flight",
+		Help: "Number of HTTP requests being served",
+	})
+	responseCacheLookups = custompromauto.Auto().NewCounterVec(prometheus.CounterOpts{
+		Name: "ethtxparser_http_response_cache_lookups_total",
+		Help: "Total number of HTTP requests looked up in the response cache, by route and result: hit or miss",
+	}, []string{"route", "result"})
+)
+
+// Instrument records the metrics of the requests served by the mux, labelled by the pattern of their route.
+func Instrument(mux http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		httpRequestsInFlight.Inc()
+		defer httpRequestsInFlight.Dec()
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w, status: http.StatusOK}
+		mux.ServeHTTP(rec, r)
+
+		// the mux sets the pattern of the matched route on the request
+		route := r.Pattern
+		if route == "" {
+			route = unmatchedRoute
+		}
+		code := strconv.Itoa(rec.status)
+		httpRequests.WithLabelValues(route, code).Inc()
+		httpRequestDuration.WithLabelValues(route, code).Observe(time.Since(start).Seconds())
+		httpResponseSize.WithLabelValues(route, code).Observe(float64(rec.size))
+	})
+}
+
+// responseRecorder records the status code and the body size of a response.
+type responseRecorder struct {
+	http.ResponseWriter
+	status      int
+	size        int
+	wroteHeader bool
+}
+
+func (rr *responseRecorder) WriteHeader(status int) {
+	if !rr.wroteHeader {
+		rr.status = status
+		rr.wroteHeader = true
+	}
+	rr.ResponseWriter.WriteHeader(status)
+}
+
+func (rr *responseRecorder) Write(b []byte) (int, error) {
+	rr.wroteHeader = true
+	n, err := rr.ResponseWriter.Write(b)
+	rr.size += n
+	return n, err
+}
+
+// Flush implements http.Flusher, for the streamed responses such as the pprof profiles.
+func (rr *responseRecorder) Flush() {
+	if f, ok := rr.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap returns the underlying writer, for http.ResponseController.
+func (rr *responseRecorder) Unwrap() http.ResponseWriter {
+	return rr.ResponseWriter
+}
@@ -5,6 +5,7 @@ package mocks
 
 import (
 	"context"
+	"github.com/hedisam/ethtxparser/internal/store"
 	"sync"
 )
 
@@ -14,14 +15,29 @@ import (
 //
 //		// make and configure a mocked rest.SubscriptionStore
 //		mockedSubscriptionStore := &SubscriptionStoreMock{
-//			AddSubscriptionFunc: func(ctx context.Context, addr string) error {
+//			AddEventSubscriptionFunc: func(ctx context.Context, sub *store.EventSubscription) error {
+//				panic("mock out the AddEventSubscription method")
+//			},
+//			AddSubscriptionFunc: func(ctx context.Context, sub *store.Subscription) error {
 //				panic("mock out the AddSubscription method")
 //			},
+//			GetEventSubscriptionsFunc: func(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+//				panic("mock out the GetEventSubscriptions method")
+//			},
+//			GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+//				panic("mock out the GetSubscription method")
+//			},
 //			GetSubscriptionsFunc: func(ctx context.Context) ([]string, error) {
 //				panic("mock out the GetSubscriptions method")
 //			},
-//			IsSubscribedFunc: func(ctx context.Context, addr string) (bool, error) {
-//				panic("mock out the IsSubscribed method")
+//			ListEventSubscriptionsFunc: func(ctx context.Context) ([]*store.EventSubscription, error) {
+//				panic("mock out the ListEventSubscriptions method")
+//			},
+//			RemoveSubscriptionFunc: func(ctx context.Context, addr string) error {
+//				panic("mock out the RemoveSubscription method")
+//			},
+//			SearchSubscriptionsFunc: func(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+//				panic("mock out the SearchSubscriptions method")
 //			},
 //		}
 //
@@ -30,19 +46,55 @@ import (
 //
 //	}
 type SubscriptionStoreMock struct {
+	// AddEventSubscriptionFunc mocks the AddEventSubscription method.
+	AddEventSubscriptionFunc func(ctx context.Context, sub *store.EventSubscription) error
+
 	// AddSubscriptionFunc mocks the AddSubscription method.
-	AddSubscriptionFunc func(ctx context.Context, addr string) error
+	AddSubscriptionFunc func(ctx context.Context, sub *store.Subscription) error
+
+	// GetEventSubscriptionsFunc mocks the GetEventSubscriptions method.
+	GetEventSubscriptionsFunc func(ctx context.Context, contract string) ([]*store.EventSubscription, error)
+
+	// GetSubscriptionFunc mocks the GetSubscription method.
+	GetSubscriptionFunc func(ctx context.Context, addr string) (*store.Subscription, error)
 
 	// GetSubscriptionsFunc mocks the GetSubscriptions method.
 	GetSubscriptionsFunc func(ctx context.Context) ([]string, error)
 
-	// IsSubscribedFunc mocks the IsSubscribed method.
-	IsSubscribedFunc func(ctx context.Context, addr string) (bool, error)
+	// ListEventSubscriptionsFunc mocks the ListEventSubscriptions method.
+	ListEventSubscriptionsFunc func(ctx context.Context) ([]*store.EventSubscription, error)
+
+	// RemoveSubscriptionFunc mocks the RemoveSubscription method.
+	RemoveSubscriptionFunc func(ctx context.Context, addr string) error
+
+	// SearchSubscriptionsFunc mocks the SearchSubscriptions method.
+	SearchSubscriptionsFunc func(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
+		// AddEventSubscription holds details about calls to the AddEventSubscription method.
+		AddEventSubscription []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Sub is the sub argument value.
+			Sub *store.EventSubscription
+		}
 		// AddSubscription holds details about calls to the AddSubscription method.
 		AddSubscription []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Sub is the sub argument value.
+			Sub *store.Subscription
+		}
+		// GetEventSubscriptions holds details about calls to the GetEventSubscriptions method.
+		GetEventSubscriptions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Contract is the contract argument value.
+			Contract string
+		}
+		// GetSubscription holds details about calls to the GetSubscription method.
+		GetSubscription []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// Addr is the addr argument value.
@@ -53,35 +105,88 @@ type SubscriptionStoreMock struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
-		// IsSubscribed holds details about calls to the IsSubscribed method.
-		IsSubscribed []struct {
+		// ListEventSubscriptions holds details about calls to the ListEventSubscriptions method.
+		ListEventSubscriptions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+		}
+		// RemoveSubscription holds details about calls to the RemoveSubscription method.
+		RemoveSubscription []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 			// Addr is the addr argument value.
 			Addr string
 		}
+		// SearchSubscriptions holds details about calls to the SearchSubscriptions method.
+		SearchSubscriptions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Query is the query argument value.
+			Query *store.SubscriptionQuery
+		}
 	}
-	lockAddSubscription  sync.RWMutex
-	lockGetSubscriptions sync.RWMutex
-	lockIsSubscribed     sync.RWMutex
+	lockAddEventSubscription   sync.RWMutex
+	lockAddSubscription        sync.RWMutex
+	lockGetEventSubscriptions  sync.RWMutex
+	lockGetSubscription        sync.RWMutex
+	lockGetSubscriptions       sync.RWMutex
+	lockListEventSubscriptions sync.RWMutex
+	lockRemoveSubscription     sync.RWMutex
+	lockSearchSubscriptions    sync.RWMutex
+}
+
+// AddEventSubscription calls AddEventSubscriptionFunc.
+func (mock *SubscriptionStoreMock) AddEventSubscription(ctx context.Context, sub *store.EventSubscription) error {
+	if mock.AddEventSubscriptionFunc == nil {
+		panic("SubscriptionStoreMock.AddEventSubscriptionFunc: method is nil but SubscriptionStore.AddEventSubscription was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+		Sub *store.EventSubscription
+	}{
+		Ctx: ctx,
+		Sub: sub,
+	}
+	mock.lockAddEventSubscription.Lock()
+	mock.calls.AddEventSubscription = append(mock.calls.AddEventSubscription, callInfo)
+	mock.lockAddEventSubscription.Unlock()
+	return mock.AddEventSubscriptionFunc(ctx, sub)
+}
+
+// AddEventSubscriptionCalls gets all the calls that were made to AddEventSubscription.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.AddEventSubscriptionCalls())
+func (mock *SubscriptionStoreMock) AddEventSubscriptionCalls() []struct {
+	Ctx context.Context
+	Sub *store.EventSubscription
+} {
+	var calls []struct {
+		Ctx context.Context
+		Sub *store.EventSubscription
+	}
+	mock.lockAddEventSubscription.RLock()
+	calls = mock.calls.AddEventSubscription
+	mock.lockAddEventSubscription.RUnlock()
+	return calls
 }
 
 // AddSubscription calls AddSubscriptionFunc.
-func (mock *SubscriptionStoreMock) AddSubscription(ctx context.Context, addr string) error {
+func (mock *SubscriptionStoreMock) AddSubscription(ctx context.Context, sub *store.Subscription) error {
 	if mock.AddSubscriptionFunc == nil {
 		panic("SubscriptionStoreMock.AddSubscriptionFunc: method is nil but SubscriptionStore.AddSubscription was just called")
 	}
 	callInfo := struct {
-		Ctx  context.Context
-		Addr string
+		Ctx context.Context
+		Sub *store.Subscription
 	}{
-		Ctx:  ctx,
-		Addr: addr,
+		Ctx: ctx,
+		Sub: sub,
 	}
 	mock.lockAddSubscription.Lock()
 	mock.calls.AddSubscription = append(mock.calls.AddSubscription, callInfo)
 	mock.lockAddSubscription.Unlock()
-	return mock.AddSubscriptionFunc(ctx, addr)
+	return mock.AddSubscriptionFunc(ctx, sub)
 }
 
 // AddSubscriptionCalls gets all the calls that were made to AddSubscription.
@@ -89,6 +194,78 @@ func (mock *SubscriptionStoreMock) AddSubscription(ctx context.Context, addr str
 //
 //	len(mockedSubscriptionStore.AddSubscriptionCalls())
 func (mock *SubscriptionStoreMock) AddSubscriptionCalls() []struct {
+	Ctx context.Context
+	Sub *store.Subscription
+} {
+	var calls []struct {
+		Ctx context.Context
+		Sub *store.Subscription
+	}
+	mock.lockAddSubscription.RLock()
+	calls = mock.calls.AddSubscription
+	mock.lockAddSubscription.RUnlock()
+	return calls
+}
+
+// GetEventSubscriptions calls GetEventSubscriptionsFunc.
+func (mock *SubscriptionStoreMock) GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+	if mock.GetEventSubscriptionsFunc == nil {
+		panic("SubscriptionStoreMock.GetEventSubscriptionsFunc: method is nil but SubscriptionStore.GetEventSubscriptions was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Contract string
+	}{
+		Ctx:      ctx,
+		Contract: contract,
+	}
+	mock.lockGetEventSubscriptions.Lock()
+	mock.calls.GetEventSubscriptions = append(mock.calls.GetEventSubscriptions, callInfo)
+	mock.lockGetEventSubscriptions.Unlock()
+	return mock.GetEventSubscriptionsFunc(ctx, contract)
+}
+
+// GetEventSubscriptionsCalls gets all the calls that were made to GetEventSubscriptions.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.GetEventSubscriptionsCalls())
+func (mock *SubscriptionStoreMock) GetEventSubscriptionsCalls() []struct {
+	Ctx      context.Context
+	Contract string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Contract string
+	}
+	mock.lockGetEventSubscriptions.RLock()
+	calls = mock.calls.GetEventSubscriptions
+	mock.lockGetEventSubscriptions.RUnlock()
+	return calls
+}
+
+// GetSubscription calls GetSubscriptionFunc.
+func (mock *SubscriptionStoreMock) GetSubscription(ctx context.Context, addr string) (*store.Subscription, error) {
+	if mock.GetSubscriptionFunc == nil {
+		panic("SubscriptionStoreMock.GetSubscriptionFunc: method is nil but SubscriptionStore.GetSubscription was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Addr string
+	}{
+		Ctx:  ctx,
+		Addr: addr,
+	}
+	mock.lockGetSubscription.Lock()
+	mock.calls.GetSubscription = append(mock.calls.GetSubscription, callInfo)
+	mock.lockGetSubscription.Unlock()
+	return mock.GetSubscriptionFunc(ctx, addr)
+}
+
+// GetSubscriptionCalls gets all the calls that were made to GetSubscription.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.GetSubscriptionCalls())
+func (mock *SubscriptionStoreMock) GetSubscriptionCalls() []struct {
 	Ctx  context.Context
 	Addr string
 } {
@@ -96,9 +273,9 @@ func (mock *SubscriptionStoreMock) AddSubscriptionCalls() []struct {
 		Ctx  context.Context
 		Addr string
 	}
-	mock.lockAddSubscription.RLock()
-	calls = mock.calls.AddSubscription
-	mock.lockAddSubscription.RUnlock()
+	mock.lockGetSubscription.RLock()
+	calls = mock.calls.GetSubscription
+	mock.lockGetSubscription.RUnlock()
 	return calls
 }
 
@@ -134,10 +311,42 @@ func (mock *SubscriptionStoreMock) GetSubscriptionsCalls() []struct {
 	return calls
 }
 
-// IsSubscribed calls IsSubscribedFunc.
-func (mock *SubscriptionStoreMock) IsSubscribed(ctx context.Context, addr string) (bool, error) {
-	if mock.IsSubscribedFunc == nil {
-		panic("SubscriptionStoreMock.IsSubscribedFunc: method is nil but SubscriptionStore.IsSubscribed was just called")
+// ListEventSubscriptions calls ListEventSubscriptionsFunc.
+func (mock *SubscriptionStoreMock) ListEventSubscriptions(ctx context.Context) ([]*store.EventSubscription, error) {
+	if mock.ListEventSubscriptionsFunc == nil {
+		panic("SubscriptionStoreMock.ListEventSubscriptionsFunc: method is nil but SubscriptionStore.ListEventSubscriptions was just called")
+	}
+	callInfo := struct {
+		Ctx context.Context
+	}{
+		Ctx: ctx,
+	}
+	mock.lockListEventSubscriptions.Lock()
+	mock.calls.ListEventSubscriptions = append(mock.calls.ListEventSubscriptions, callInfo)
+	mock.lockListEventSubscriptions.Unlock()
+	return mock.ListEventSubscriptionsFunc(ctx)
+}
+
+// ListEventSubscriptionsCalls gets all the calls that were made to ListEventSubscriptions.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.ListEventSubscriptionsCalls())
+func (mock *SubscriptionStoreMock) ListEventSubscriptionsCalls() []struct {
+	Ctx context.Context
+} {
+	var calls []struct {
+		Ctx context.Context
+	}
+	mock.lockListEventSubscriptions.RLock()
+	calls = mock.calls.ListEventSubscriptions
+	mock.lockListEventSubscriptions.RUnlock()
+	return calls
+}
+
+// RemoveSubscription calls RemoveSubscriptionFunc.
+func (mock *SubscriptionStoreMock) RemoveSubscription(ctx context.Context, addr string) error {
+	if mock.RemoveSubscriptionFunc == nil {
+		panic("SubscriptionStoreMock.RemoveSubscriptionFunc: method is nil but SubscriptionStore.RemoveSubscription was just called")
 	}
 	callInfo := struct {
 		Ctx  context.Context
@@ -146,17 +355,17 @@ func (mock *SubscriptionStoreMock) IsSubscribed(ctx context.Context, addr string
 		Ctx:  ctx,
 		Addr: addr,
 	}
-	mock.lockIsSubscribed.Lock()
-	mock.calls.IsSubscribed = append(mock.calls.IsSubscribed, callInfo)
-	mock.lockIsSubscribed.Unlock()
-	return mock.IsSubscribedFunc(ctx, addr)
+	mock.lockRemoveSubscription.Lock()
+	mock.calls.RemoveSubscription = append(mock.calls.RemoveSubscription, callInfo)
+	mock.lockRemoveSubscription.Unlock()
+	return mock.RemoveSubscriptionFunc(ctx, addr)
 }
 
-// IsSubscribedCalls gets all the calls that were made to IsSubscribed.
+// RemoveSubscriptionCalls gets all the calls that were made to RemoveSubscription.
 // Check the length with:
 //
-//	len(mockedSubscriptionStore.IsSubscribedCalls())
-func (mock *SubscriptionStoreMock) IsSubscribedCalls() []struct {
+//	len(mockedSubscriptionStore.RemoveSubscriptionCalls())
+func (mock *SubscriptionStoreMock) RemoveSubscriptionCalls() []struct {
 	Ctx  context.Context
 	Addr string
 } {
@@ -164,8 +373,44 @@ func (mock *SubscriptionStoreMock) IsSubscribedCalls() []struct {
 		Ctx  context.Context
 		Addr string
 	}
-	mock.lockIsSubscribed.RLock()
-	calls = mock.calls.IsSubscribed
-	mock.lockIsSubscribed.RUnlock()
+	mock.lockRemoveSubscription.RLock()
+	calls = mock.calls.RemoveSubscription
+	mock.lockRemoveSubscription.RUnlock()
+	return calls
+}
+
+// SearchSubscriptions calls SearchSubscriptionsFunc.
+func (mock *SubscriptionStoreMock) SearchSubscriptions(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+	if mock.SearchSubscriptionsFunc == nil {
+		panic("SubscriptionStoreMock.SearchSubscriptionsFunc: method is nil but SubscriptionStore.SearchSubscriptions was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Query *store.SubscriptionQuery
+	}{
+		Ctx:   ctx,
+		Query: query,
+	}
+	mock.lockSearchSubscriptions.Lock()
+	mock.calls.SearchSubscriptions = append(mock.calls.SearchSubscriptions, callInfo)
+	mock.lockSearchSubscriptions.Unlock()
+	return mock.SearchSubscriptionsFunc(ctx, query)
+}
+
+// SearchSubscriptionsCalls gets all the calls that were made to SearchSubscriptions.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.SearchSubscriptionsCalls())
+func (mock *SubscriptionStoreMock) SearchSubscriptionsCalls() []struct {
+	Ctx   context.Context
+	Query *store.SubscriptionQuery
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Query *store.SubscriptionQuery
+	}
+	mock.lockSearchSubscriptions.RLock()
+	calls = mock.calls.SearchSubscriptions
+	mock.lockSearchSubscriptions.RUnlock()
 	return calls
 }
@@ -6,6 +6,7 @@ package mocks
 import (
 	"context"
 	"github.com/hedisam/ethtxparser/internal/store"
+	"math/big"
 	"sync"
 )
 
@@ -15,12 +16,30 @@ import (
 //
 //		// make and configure a mocked rest.TxStore
 //		mockedTxStore := &TxStoreMock{
+//			GetAddressStatsFunc: func(ctx context.Context, addr string) (*store.AddressStats, error) {
+//				panic("mock out the GetAddressStats method")
+//			},
 //			GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
 //				panic("mock out the GetCurrentBlockNumber method")
 //			},
+//			GetEventsFunc: func(ctx context.Context, contract string) ([]*store.EventRecord, error) {
+//				panic("mock out the GetEvents method")
+//			},
+//			GetRawTransactionFunc: func(ctx context.Context, hash string) (string, error) {
+//				panic("mock out the GetRawTransaction method")
+//			},
+//			GetTokenBalanceFunc: func(ctx context.Context, token string, holder string) (*big.Int, error) {
+//				panic("mock out the GetTokenBalance method")
+//			},
+//			GetTokenTransfersFunc: func(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error) {
+//				panic("mock out the GetTokenTransfers method")
+//			},
 //			GetTransactionsFunc: func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
 //				panic("mock out the GetTransactions method")
 //			},
+//			InsertRawTransactionFunc: func(ctx context.Context, hash string, raw string) error {
+//				panic("mock out the InsertRawTransaction method")
+//			},
 //		}
 //
 //		// use mockedTxStore in code that requires rest.TxStore
@@ -28,19 +47,74 @@ import (
 //
 //	}
 type TxStoreMock struct {
+	// GetAddressStatsFunc mocks the GetAddressStats method.
+	GetAddressStatsFunc func(ctx context.Context, addr string) (*store.AddressStats, error)
+
 	// GetCurrentBlockNumberFunc mocks the GetCurrentBlockNumber method.
 	GetCurrentBlockNumberFunc func(ctx context.Context) (int64, error)
 
+	// GetEventsFunc mocks the GetEvents method.
+	GetEventsFunc func(ctx context.Context, contract string) ([]*store.EventRecord, error)
+
+	// GetRawTransactionFunc mocks the GetRawTransaction method.
+	GetRawTransactionFunc func(ctx context.Context, hash string) (string, error)
+
+	// GetTokenBalanceFunc mocks the GetTokenBalance method.
+	GetTokenBalanceFunc func(ctx context.Context, token string, holder string) (*big.Int, error)
+
+	// GetTokenTransfersFunc mocks the GetTokenTransfers method.
+	GetTokenTransfersFunc func(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error)
+
 	// GetTransactionsFunc mocks the GetTransactions method.
 	GetTransactionsFunc func(ctx context.Context, addr string) ([]*store.TxRecord, error)
 
+	// InsertRawTransactionFunc mocks the InsertRawTransaction method.
+	InsertRawTransactionFunc func(ctx context.Context, hash string, raw string) error
+
 	// calls tracks calls to the methods.
 	calls struct {
+		// GetAddressStats holds details about calls to the GetAddressStats method.
+		GetAddressStats []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Addr is the addr argument value.
+			Addr string
+		}
 		// GetCurrentBlockNumber holds details about calls to the GetCurrentBlockNumber method.
 		GetCurrentBlockNumber []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
 		}
+		// GetEvents holds details about calls to the GetEvents method.
+		GetEvents []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Contract is the contract argument value.
+			Contract string
+		}
+		// GetRawTransaction holds details about calls to the GetRawTransaction method.
+		GetRawTransaction []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Hash is the hash argument value.
+			Hash string
+		}
+		// GetTokenBalance holds details about calls to the GetTokenBalance method.
+		GetTokenBalance []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Token is the token argument value.
+			Token string
+			// Holder is the holder argument value.
+			Holder string
+		}
+		// GetTokenTransfers holds details about calls to the GetTokenTransfers method.
+		GetTokenTransfers []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Addr is the addr argument value.
+			Addr string
+		}
 		// GetTransactions holds details about calls to the GetTransactions method.
 		GetTransactions []struct {
 			// Ctx is the ctx argument value.
@@ -48,9 +122,60 @@ type TxStoreMock struct {
 			// Addr is the addr argument value.
 			Addr string
 		}
+		// InsertRawTransaction holds details about calls to the InsertRawTransaction method.
+		InsertRawTransaction []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Hash is the hash argument value.
+			Hash string
+			// Raw is the raw argument value.
+			Raw string
+		}
 	}
+	lockGetAddressStats       sync.RWMutex
 	lockGetCurrentBlockNumber sync.RWMutex
+	lockGetEvents             sync.RWMutex
+	lockGetRawTransaction     sync.RWMutex
+	lockGetTokenBalance       sync.RWMutex
+	lockGetTokenTransfers     sync.RWMutex
 	lockGetTransactions       sync.RWMutex
+	lockInsertRawTransaction  sync.RWMutex
+}
+
+// GetAddressStats calls GetAddressStatsFunc.
+func (mock *TxStoreMock) GetAddressStats(ctx context.Context, addr string) (*store.AddressStats, error) {
+	if mock.GetAddressStatsFunc == nil {
+		panic("TxStoreMock.GetAddressStatsFunc: method is nil but TxStore.GetAddressStats was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Addr string
+	}{
+		Ctx:  ctx,
+		Addr: addr,
+	}
+	mock.lockGetAddressStats.Lock()
+	mock.calls.GetAddressStats = append(mock.calls.GetAddressStats, callInfo)
+	mock.lockGetAddressStats.Unlock()
+	return mock.GetAddressStatsFunc(ctx, addr)
+}
+
+// GetAddressStatsCalls gets all the calls that were made to GetAddressStats.
+// Check the length with:
+//
+//	len(mockedTxStore.GetAddressStatsCalls())
+func (mock *TxStoreMock) GetAddressStatsCalls() []struct {
+	Ctx  context.Context
+	Addr string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Addr string
+	}
+	mock.lockGetAddressStats.RLock()
+	calls = mock.calls.GetAddressStats
+	mock.lockGetAddressStats.RUnlock()
+	return calls
 }
 
 // GetCurrentBlockNumber calls GetCurrentBlockNumberFunc.
@@ -85,6 +210,154 @@ func (mock *TxStoreMock) GetCurrentBlockNumberCalls() []struct {
 	return calls
 }
 
+// GetEvents calls GetEventsFunc.
+func (mock *TxStoreMock) GetEvents(ctx context.Context, contract string) ([]*store.EventRecord, error) {
+	if mock.GetEventsFunc == nil {
+		panic("TxStoreMock.GetEventsFunc: method is nil but TxStore.GetEvents was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Contract string
+	}{
+		Ctx:      ctx,
+		Contract: contract,
+	}
+	mock.lockGetEvents.Lock()
+	mock.calls.GetEvents = append(mock.calls.GetEvents, callInfo)
+	mock.lockGetEvents.Unlock()
+	return mock.GetEventsFunc(ctx, contract)
+}
+
+// GetEventsCalls gets all the calls that were made to GetEvents.
+// Check the length with:
+//
+//	len(mockedTxStore.GetEventsCalls())
+func (mock *TxStoreMock) GetEventsCalls() []struct {
+	Ctx      context.Context
+	Contract string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Contract string
+	}
+	mock.lockGetEvents.RLock()
+	calls = mock.calls.GetEvents
+	mock.lockGetEvents.RUnlock()
+	return calls
+}
+
+// GetRawTransaction calls GetRawTransactionFunc.
+func (mock *TxStoreMock) GetRawTransaction(ctx context.Context, hash string) (string, error) {
+	if mock.GetRawTransactionFunc == nil {
+		panic("TxStoreMock.GetRawTransactionFunc: method is nil but TxStore.GetRawTransaction was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Hash string
+	}{
+		Ctx:  ctx,
+		Hash: hash,
+	}
+	mock.lockGetRawTransaction.Lock()
+	mock.calls.GetRawTransaction = append(mock.calls.GetRawTransaction, callInfo)
+	mock.lockGetRawTransaction.Unlock()
+	return mock.GetRawTransactionFunc(ctx, hash)
+}
+
+// GetRawTransactionCalls gets all the calls that were made to GetRawTransaction.
+// Check the length with:
+//
+//	len(mockedTxStore.GetRawTransactionCalls())
+func (mock *TxStoreMock) GetRawTransactionCalls() []struct {
+	Ctx  context.Context
+	Hash string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Hash string
+	}
+	mock.lockGetRawTransaction.RLock()
+	calls = mock.calls.GetRawTransaction
+	mock.lockGetRawTransaction.RUnlock()
+	return calls
+}
+
+// GetTokenBalance calls GetTokenBalanceFunc.
+func (mock *TxStoreMock) GetTokenBalance(ctx context.Context, token string, holder string) (*big.Int, error) {
+	if mock.GetTokenBalanceFunc == nil {
+		panic("TxStoreMock.GetTokenBalanceFunc: method is nil but TxStore.GetTokenBalance was just called")
+	}
+	callInfo := struct {
+		Ctx    context.Context
+		Token  string
+		Holder string
+	}{
+		Ctx:    ctx,
+		Token:  token,
+		Holder: holder,
+	}
+	mock.lockGetTokenBalance.Lock()
+	mock.calls.GetTokenBalance = append(mock.calls.GetTokenBalance, callInfo)
+	mock.lockGetTokenBalance.Unlock()
+	return mock.GetTokenBalanceFunc(ctx, token, holder)
+}
+
+// GetTokenBalanceCalls gets all the calls that were made to GetTokenBalance.
+// Check the length with:
+//
+//	len(mockedTxStore.GetTokenBalanceCalls())
+func (mock *TxStoreMock) GetTokenBalanceCalls() []struct {
+	Ctx    context.Context
+	Token  string
+	Holder string
+} {
+	var calls []struct {
+		Ctx    context.Context
+		Token  string
+		Holder string
+	}
+	mock.lockGetTokenBalance.RLock()
+	calls = mock.calls.GetTokenBalance
+	mock.lockGetTokenBalance.RUnlock()
+	return calls
+}
+
+// GetTokenTransfers calls GetTokenTransfersFunc.
+func (mock *TxStoreMock) GetTokenTransfers(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error) {
+	if mock.GetTokenTransfersFunc == nil {
+		panic("TxStoreMock.GetTokenTransfersFunc: method is nil but TxStore.GetTokenTransfers was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Addr string
+	}{
+		Ctx:  ctx,
+		Addr: addr,
+	}
+	mock.lockGetTokenTransfers.Lock()
+	mock.calls.GetTokenTransfers = append(mock.calls.GetTokenTransfers, callInfo)
+	mock.lockGetTokenTransfers.Unlock()
+	return mock.GetTokenTransfersFunc(ctx, addr)
+}
+
+// GetTokenTransfersCalls gets all the calls that were made to GetTokenTransfers.
+// Check the length with:
+//
+//	len(mockedTxStore.GetTokenTransfersCalls())
+func (mock *TxStoreMock) GetTokenTransfersCalls() []struct {
+	Ctx  context.Context
+	Addr string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Addr string
+	}
+	mock.lockGetTokenTransfers.RLock()
+	calls = mock.calls.GetTokenTransfers
+	mock.lockGetTokenTransfers.RUnlock()
+	return calls
+}
+
 // GetTransactions calls GetTransactionsFunc.
 func (mock *TxStoreMock) GetTransactions(ctx context.Context, addr string) ([]*store.TxRecord, error) {
 	if mock.GetTransactionsFunc == nil {
@@ -120,3 +393,43 @@ func (mock *TxStoreMock) GetTransactionsCalls() []struct {
 	mock.lockGetTransactions.RUnlock()
 	return calls
 }
+
+// InsertRawTransaction calls InsertRawTransactionFunc.
+func (mock *TxStoreMock) InsertRawTransaction(ctx context.Context, hash string, raw string) error {
+	if mock.InsertRawTransactionFunc == nil {
+		panic("TxStoreMock.InsertRawTransactionFunc: method is nil but TxStore.InsertRawTransaction was just called")
+	}
+	callInfo := struct {
+		Ctx  context.Context
+		Hash string
+		Raw  string
+	}{
+		Ctx:  ctx,
+		Hash: hash,
+		Raw:  raw,
+	}
+	mock.lockInsertRawTransaction.Lock()
+	mock.calls.InsertRawTransaction = append(mock.calls.InsertRawTransaction, callInfo)
+	mock.lockInsertRawTransaction.Unlock()
+	return mock.InsertRawTransactionFunc(ctx, hash, raw)
+}
+
+// InsertRawTransactionCalls gets all the calls that were made to InsertRawTransaction.
+// Check the length with:
+//
+//	len(mockedTxStore.InsertRawTransactionCalls())
+func (mock *TxStoreMock) InsertRawTransactionCalls() []struct {
+	Ctx  context.Context
+	Hash string
+	Raw  string
+} {
+	var calls []struct {
+		Ctx  context.Context
+		Hash string
+		Raw  string
+	}
+	mock.lockInsertRawTransaction.RLock()
+	calls = mock.calls.InsertRawTransaction
+	mock.lockInsertRawTransaction.RUnlock()
+	return calls
+}
@@ -2,20 +2,37 @@ package rest
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"iter"
 	"net/http"
 	"regexp"
 	"slices"
+	"strconv"
+	"strings"
+	"sync"
 
-	"github.com/sirupsen/logrus"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
 )
 
-var (
-	pathParamRegex = regexp.MustCompile(`{([^}]+)}`)
+const (
+	// RequestIDHeader is the header of the request IDs, taken from the requests if set and generated otherwise, and
+	// returned in the responses.
+	RequestIDHeader = "X-Request-Id"
+	// ContentTypeNDJSON is the media type of the newline delimited JSON responses, streamed one item per line to the
+	// requests accepting it, see ItemStreamer.
+	ContentTypeNDJSON = "application/x-ndjson"
+	// LowercaseAddressesParam is the query parameter keeping the addresses of the responses lower-cased, as stored,
+	// instead of EIP-55 checksummed, e.g. "?lowercase=true" for the clients expecting them as they used to be.
+	LowercaseAddressesParam = "lowercase"
 )
 
+var pathParamRegex = regexp.MustCompile(`{([^}]+)}`)
+
 // Err defines an error type that can be enriched with a http status code.
 type Err struct {
 	Message    string
@@ -34,6 +51,26 @@ func NewErrf(status int, msg string, a ...any) *Err {
 	}
 }
 
+// StatusCoder is implemented by the responses served with another status code than 200 OK, e.g. a health report
+// listing failing components along with a 503.
+type StatusCoder interface {
+	StatusCode() int
+}
+
+// ItemStreamer is implemented by the list responses streamed as NDJSON to the requests accepting ContentTypeNDJSON,
+// one item per line, instead of being encoded as a whole. The server Funcs can then return their items lazily, see
+// acceptsNDJSON. The iteration stops at the first error, ending the stream with an {"error": <message>} line.
+type ItemStreamer interface {
+	Items() iter.Seq2[any, error]
+}
+
+// acceptNDJSONKey is the context key set for the requests accepting ContentTypeNDJSON.
+type acceptNDJSONKey struct{}
+
+// lowercaseAddressesKey is the context key set for the requests asking for lower-cased addresses with
+// LowercaseAddressesParam.
+type lowercaseAddressesKey struct{}
+
 // Func defines a server Func that implements an restful api endpoint.
 type Func[Req any, Resp any] func(ctx context.Context, req *Req) (*Resp, error)
 
@@ -41,27 +78,103 @@ type Mux interface {
 	HandleFunc(pattern string, f func(w http.ResponseWriter, r *http.Request))
 }
 
-func RegisterFunc[Req any, Resp any](logger *logrus.Logger, mux Mux, method, endpoint string, f Func[Req, Resp]) {
+// Middleware wraps the handler of a route with a cross-cutting concern, e.g. authentication or rate limiting.
+type Middleware func(next http.Handler) http.Handler
+
+// Chain wraps the handler with the middlewares, in order: the first one is the outermost, seeing the requests first and
+// the responses last.
+func Chain(h http.Handler, mws ...Middleware) http.Handler {
+	for _, mw := range slices.Backward(mws) {
+		h = mw(h)
+	}
+	return h
+}
+
+// Router is a Mux answering the OPTIONS requests of every endpoint registered on it with the methods registered for
+// the endpoint, in the Allow header. The routes are registered on the underlying mux.
+type Router struct {
+	mux Mux
+
+	mu sync.Mutex
+	// methods holds the methods registered per endpoint.
+	methods map[string][]string
+}
+
+func NewRouter(mux Mux) *Router {
+	return &Router{
+		mux:     mux,
+		methods: make(map[string][]string),
+	}
+}
+
+// HandleFunc registers the handler for the pattern on the underlying mux, along with the OPTIONS handler of its
+// endpoint the first time a method is registered for it. Patterns without a method are registered as is.
+func (r *Router) HandleFunc(pattern string, f func(w http.ResponseWriter, r *http.Request)) {
+	r.mux.HandleFunc(pattern, f)
+	method, endpoint, ok := strings.Cut(pattern, " ")
+	if !ok {
+		return
+	}
+
+	r.mu.Lock()
+	first := len(r.methods[endpoint]) == 0
+	r.methods[endpoint] = append(r.methods[endpoint], method)
+	r.mu.Unlock()
+	if first {
+		// preflight and monitoring requests carry no credentials, they're answered without the middlewares
+		r.mux.HandleFunc(fmt.Sprintf("%s %s", http.MethodOptions, endpoint), r.optionsHandler(endpoint))
+	}
+}
+
+// optionsHandler answers the OPTIONS requests of the endpoint with the methods registered for it so far, along with
+// HEAD for GET and OPTIONS itself.
+func (r *Router) optionsHandler(endpoint string) http.HandlerFunc {
+	return func(w http.ResponseWriter, _ *http.Request) {
+		r.mu.Lock()
+		methods := slices.Clone(r.methods[endpoint])
+		r.mu.Unlock()
+
+		if slices.Contains(methods, http.MethodGet) {
+			methods = append(methods, http.MethodHead)
+		}
+		methods = append(methods, http.MethodOptions)
+		slices.Sort(methods)
+		w.Header().Set("Allow", strings.Join(slices.Compact(methods), ", "))
+		w.WriteHeader(http.StatusNoContent)
+	}
+}
+
+// RegisterFunc registers the server Func as the handler of the method and endpoint on the mux, wrapped with the
+// middlewares, see Chain. GET endpoints serve HEAD requests too, and registered on a Router, every endpoint answers
+// OPTIONS requests with the methods registered for it.
+func RegisterFunc[Req any, Resp any](logger logging.Logger, mux Mux, method, endpoint string, f Func[Req, Resp], mws ...Middleware) {
 	var pathParamKeys []string
 	matches := pathParamRegex.FindAllStringSubmatch(endpoint, -1)
 	for match := range slices.Values(matches) {
 		pathParamKeys = append(pathParamKeys, match[1])
 	}
 	pattern := fmt.Sprintf("%s %s", method, endpoint)
-	mux.HandleFunc(pattern, FuncAdapter[Req, Resp](logger, f, pathParamKeys...))
+	handler := Chain(FuncAdapter[Req, Resp](logger, f, pathParamKeys...), mws...)
+	mux.HandleFunc(pattern, handler.ServeHTTP)
 }
 
 // FuncAdapter accepts a generic server Func and returns a http.HandlerFunc that can be used for API endpoint registration.
 // This saves us from explicitly writing http responses or errors each time we need to terminate or return from the
 // function. It gives us the ability to simply return a response and error, just like gRPC server methods.
 // It also makes unit testing easier as it eliminates the need for a mock http server in every test.
-func FuncAdapter[Req any, Resp any](log *logrus.Logger, f Func[Req, Resp], pathParamKeys ...string) http.HandlerFunc {
+func FuncAdapter[Req any, Resp any](log logging.Logger, f Func[Req, Resp], pathParamKeys ...string) http.HandlerFunc {
 	return func(w http.ResponseWriter, r *http.Request) {
-		logger := log.WithFields(logrus.Fields{
-			"method":  r.Method,
-			"path":    r.URL.Path,
-			"pattern": r.Pattern,
-			"query":   r.URL.Query(),
+		requestID := r.Header.Get(RequestIDHeader)
+		if requestID == "" {
+			requestID = newRequestID()
+		}
+		w.Header().Set(RequestIDHeader, requestID)
+		logger := log.WithFields(logging.Fields{
+			"method":               r.Method,
+			"path":                 r.URL.Path,
+			"pattern":              r.Pattern,
+			"query":                r.URL.Query(),
+			logging.RequestIDField: requestID,
 		})
 		logger.Debug("Handling request in FuncAdapter")
 
@@ -109,10 +222,32 @@ func FuncAdapter[Req any, Resp any](log *logrus.Logger, f Func[Req, Resp], pathP
 			return
 		}
 
-		ctx := r.Context()
+		var validationErr *Err
+		if errors.As(Validate(&req), &validationErr) {
+			logger.WithField("reason", validationErr.Message).Warn("Invalid request in FuncAdapter")
+			http.Error(w, validationErr.Message, validationErr.StatusCode)
+			return
+		}
+
+		ctx := logging.WithRequestID(r.Context(), requestID)
 		for k, v := range r.Header {
 			ctx = context.WithValue(ctx, k, v)
 		}
+		ndjson := acceptsMediaType(r.Header.Get("Accept"), ContentTypeNDJSON)
+		if ndjson {
+			ctx = context.WithValue(ctx, acceptNDJSONKey{}, true)
+		}
+		if raw := r.URL.Query().Get(LowercaseAddressesParam); raw != "" {
+			lowercase, err := strconv.ParseBool(raw)
+			if err != nil {
+				logger.WithField("reason", err).Warn("Invalid lowercase query param in FuncAdapter")
+				http.Error(w, fmt.Sprintf("Invalid '%s' query param. Expected true or false.", LowercaseAddressesParam), http.StatusBadRequest)
+				return
+			}
+			if lowercase {
+				ctx = context.WithValue(ctx, lowercaseAddressesKey{}, true)
+			}
+		}
 
 		resp, err := f(ctx, &req)
 		if err != nil {
@@ -127,11 +262,99 @@ func FuncAdapter[Req any, Resp any](log *logrus.Logger, f Func[Req, Resp], pathP
 			return
 		}
 
-		w.WriteHeader(http.StatusOK)
+		if streamer, ok := any(resp).(ItemStreamer); ok && ndjson {
+			streamNDJSON(logger, w, r, streamer)
+			return
+		}
+
+		status := http.StatusOK
+		if coder, ok := any(resp).(StatusCoder); ok {
+			status = coder.StatusCode()
+		}
+		body, err := json.Marshal(resp)
+		if err != nil {
+			logger.WithError(err).Error("Failed to marshal response body in FuncAdapter")
+			http.Error(w, fmt.Sprintf("marshal response body: %q", err.Error()), http.StatusInternalServerError)
+			return
+		}
+		body = append(body, '\n')
 		w.Header().Set("Content-Type", "application/json")
-		err = json.NewEncoder(w).Encode(resp)
+		w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+		w.WriteHeader(status)
+		// the GET routes serve HEAD requests too, with the headers of the GET response only
+		if r.Method == http.MethodHead {
+			return
+		}
+		_, err = w.Write(body)
 		if err != nil {
 			logger.WithError(err).Error("Failed to write response body in FuncAdapter")
 		}
 	}
 }
+
+// streamNDJSON writes the items of the response one per line, as they're iterated over.
+func streamNDJSON(logger logging.Logger, w http.ResponseWriter, r *http.Request, streamer ItemStreamer) {
+	w.Header().Set("Content-Type", ContentTypeNDJSON)
+	w.WriteHeader(http.StatusOK)
+	if r.Method == http.MethodHead {
+		return
+	}
+
+	enc := json.NewEncoder(w)
+	for item, err := range streamer.Items() {
+		if err != nil {
+			logger.WithError(err).Error("Failed to stream response items in FuncAdapter")
+			// the status is already sent, the error is reported as the last line instead
+			msg := "Could not stream response"
+			var stErr *Err
+			if errors.As(err, &stErr) {
+				msg = stErr.Message
+			}
+			_ = enc.Encode(map[string]string{"error": msg})
+			return
+		}
+		err = enc.Encode(item)
+		if err != nil {
+			logger.WithError(err).Error("Failed to write response item in FuncAdapter")
+			return
+		}
+	}
+}
+
+// acceptsNDJSON reports whether the request of the context accepts ContentTypeNDJSON, in which case the responses
+// implementing ItemStreamer are streamed.
+func acceptsNDJSON(ctx context.Context) bool {
+	accepted, _ := ctx.Value(acceptNDJSONKey{}).(bool)
+	return accepted
+}
+
+// formatAddress returns the address EIP-55 checksummed for the responses, or as is if the request of the context asked
+// for lower-cased addresses with LowercaseAddressesParam. Anything but an address, e.g. the empty recipient of a
+// contract creation, is returned as is too.
+func formatAddress(ctx context.Context, addr string) string {
+	if lowercase, _ := ctx.Value(lowercaseAddressesKey{}).(bool); lowercase {
+		return addr
+	}
+	checksummed, ok := ethaddr.Checksum(addr)
+	if !ok || !strings.HasPrefix(addr, "0x") {
+		return addr
+	}
+	return checksummed
+}
+
+// acceptsMediaType reports whether the Accept header lists the media type.
+func acceptsMediaType(accept, mediaType string) bool {
+	for part := range strings.SplitSeq(accept, ",") {
+		mt, _, _ := strings.Cut(part, ";")
+		if strings.EqualFold(strings.TrimSpace(mt), mediaType) {
+			return true
+		}
+	}
+	return false
+}
+
+func newRequestID() string {
+	b := make([]byte, 8)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
@@ -0,0 +1,273 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+func TestFuncAdapterRequestID(t *testing.T) {
+	tests := map[string]struct {
+		requestID string
+	}{
+		"request id of the request": {
+			requestID: "req-1",
+		},
+		"generated request id": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var ctxRequestID string
+			handler := restapi.FuncAdapter(logging.Logrus(logrus.New()), func(ctx context.Context, _ *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+				ctxRequestID, _ = logging.RequestID(ctx)
+				return &restapi.ReadyResponse{Status: "ok"}, nil
+			})
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			if test.requestID != "" {
+				req.Header.Set(restapi.RequestIDHeader, test.requestID)
+			}
+			rec := httptest.NewRecorder()
+			handler(rec, req)
+
+			responseRequestID := rec.Header().Get(restapi.RequestIDHeader)
+			assert.NotEmpty(t, responseRequestID)
+			assert.Equal(t, responseRequestID, ctxRequestID)
+			if test.requestID != "" {
+				assert.Equal(t, test.requestID, responseRequestID)
+			}
+		})
+	}
+}
+
+func TestFuncAdapterStatusCode(t *testing.T) {
+	tests := map[string]struct {
+		status             string
+		expectedStatusCode int
+	}{
+		"ok": {
+			status:             restapi.HealthStatusHealthy,
+			expectedStatusCode: http.StatusOK,
+		},
+		"status code of the response": {
+			status:             restapi.HealthStatusUnhealthy,
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			handler := restapi.FuncAdapter(logging.Logrus(logrus.New()), func(context.Context, *restapi.HealthRequest) (*restapi.HealthResponse, error) {
+				return &restapi.HealthResponse{Status: test.status}, nil
+			})
+
+			rec := httptest.NewRecorder()
+			handler(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+			assert.Contains(t, rec.Body.String(), test.status)
+		})
+	}
+}
+
+func TestRegisterFuncMiddlewares(t *testing.T) {
+	tests := map[string]struct {
+		authorization  string
+		expectedStatus int
+		expectedCalls  []string
+	}{
+		"applied in order": {
+			authorization:  "secret",
+			expectedStatus: http.StatusOK,
+			expectedCalls:  []string{"log", "auth", "handler", "log done"},
+		},
+		"request rejected by a middleware": {
+			expectedStatus: http.StatusUnauthorized,
+			expectedCalls:  []string{"log", "auth", "log done"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls []string
+			logMiddleware := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, "log")
+					next.ServeHTTP(w, r)
+					calls = append(calls, "log done")
+				})
+			}
+			authMiddleware := func(next http.Handler) http.Handler {
+				return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+					calls = append(calls, "auth")
+					if r.Header.Get("Authorization") != "secret" {
+						http.Error(w, "Unauthorized", http.StatusUnauthorized)
+						return
+					}
+					next.ServeHTTP(w, r)
+				})
+			}
+
+			mux := http.NewServeMux()
+			restapi.RegisterFunc(logging.Logrus(logrus.New()), mux, http.MethodGet, "/readyz", func(context.Context, *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+				calls = append(calls, "handler")
+				return &restapi.ReadyResponse{Status: "ready"}, nil
+			}, logMiddleware, authMiddleware)
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			req.Header.Set("Authorization", test.authorization)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedCalls, calls)
+		})
+	}
+}
+
+func TestRouterMethodsPerRouter(t *testing.T) {
+	logger := logging.Logrus(logrus.New())
+	get := func(context.Context, *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+		return &restapi.ReadyResponse{Status: "ready"}, nil
+	}
+	mux, otherMux := http.NewServeMux(), http.NewServeMux()
+	restapi.RegisterFunc(logger, restapi.NewRouter(mux), http.MethodGet, "/readyz", get)
+	restapi.RegisterFunc(logger, restapi.NewRouter(otherMux), http.MethodPost, "/readyz", get)
+	// registered on the mux directly, the endpoint doesn't answer OPTIONS requests
+	plainMux := http.NewServeMux()
+	restapi.RegisterFunc(logger, plainMux, http.MethodGet, "/readyz", get)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/readyz", nil))
+	assert.Equal(t, "GET, HEAD, OPTIONS", rec.Header().Get("Allow"))
+	rec = httptest.NewRecorder()
+	otherMux.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/readyz", nil))
+	assert.Equal(t, "OPTIONS, POST", rec.Header().Get("Allow"))
+	rec = httptest.NewRecorder()
+	plainMux.ServeHTTP(rec, httptest.NewRequest(http.MethodOptions, "/readyz", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+func TestRegisterFuncHeadAndOptions(t *testing.T) {
+	logger := logging.Logrus(logrus.New())
+	mux := http.NewServeMux()
+	router := restapi.NewRouter(mux)
+	restapi.RegisterFunc(logger, router, http.MethodGet, "/subscriptions/{address}", func(context.Context, *restapi.GetBackfillRequest) (*restapi.ReadyResponse, error) {
+		return &restapi.ReadyResponse{Status: "ready"}, nil
+	})
+	restapi.RegisterFunc(logger, router, http.MethodPut, "/subscriptions/{address}", func(context.Context, *restapi.SubscribeRequest) (*restapi.SubscribeResponse, error) {
+		return &restapi.SubscribeResponse{Ok: true}, nil
+	})
+	restapi.RegisterFunc(logger, router, http.MethodPost, "/reload", func(context.Context, *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+		return &restapi.ReadyResponse{Status: "reloaded"}, nil
+	})
+	const path = "/subscriptions/0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+
+	tests := map[string]struct {
+		method         string
+		path           string
+		expectedStatus int
+		expectedAllow  string
+	}{
+		"options of a get and put endpoint": {
+			method:         http.MethodOptions,
+			path:           path,
+			expectedStatus: http.StatusNoContent,
+			expectedAllow:  "GET, HEAD, OPTIONS, PUT",
+		},
+		"options of a post endpoint": {
+			method:         http.MethodOptions,
+			path:           "/reload",
+			expectedStatus: http.StatusNoContent,
+			expectedAllow:  "OPTIONS, POST",
+		},
+		"head of a post endpoint": {
+			method:         http.MethodHead,
+			path:           "/reload",
+			expectedStatus: http.StatusMethodNotAllowed,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(test.method, test.path, nil))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			if test.expectedAllow != "" {
+				assert.Equal(t, test.expectedAllow, rec.Header().Get("Allow"))
+			}
+		})
+	}
+
+	getRec := httptest.NewRecorder()
+	mux.ServeHTTP(getRec, httptest.NewRequest(http.MethodGet, path, nil))
+	headRec := httptest.NewRecorder()
+	mux.ServeHTTP(headRec, httptest.NewRequest(http.MethodHead, path, nil))
+	assert.Equal(t, http.StatusOK, headRec.Code)
+	assert.Empty(t, headRec.Body.String())
+	assert.Equal(t, "application/json", headRec.Header().Get("Content-Type"))
+	assert.Equal(t, strconv.Itoa(getRec.Body.Len()), headRec.Header().Get("Content-Length"))
+	assert.Equal(t, getRec.Header().Get("Content-Length"), headRec.Header().Get("Content-Length"))
+}
+
+func TestInstrument(t *testing.T) {
+	mux := http.NewServeMux()
+	restapi.RegisterFunc(logging.Logrus(logrus.New()), mux, http.MethodGet, "/instrumented/{id}", func(ctx context.Context, _ *restapi.ReadyRequest) (*restapi.ReadyResponse, error) {
+		return &restapi.ReadyResponse{Status: "ready"}, nil
+	})
+	handler := restapi.Instrument(mux)
+
+	tests := map[string]struct {
+		path           string
+		expectedStatus int
+		expectedRoute  string
+	}{
+		"matched route": {
+			path:           "/instrumented/1",
+			expectedStatus: http.StatusOK,
+			expectedRoute:  "GET /instrumented/{id}",
+		},
+		"unmatched route": {
+			path:           "/unknown",
+			expectedStatus: http.StatusNotFound,
+			expectedRoute:  "unmatched",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+			assert.Equal(t, test.expectedStatus, rec.Code)
+
+			families, err := custompromauto.Registry().Gather()
+			require.NoError(t, err)
+			var count float64
+			for family := range slices.Values(families) {
+				if family.GetName() != "ethtxparser_http_requests_total" {
+					continue
+				}
+				for metric := range slices.Values(family.GetMetric()) {
+					labels := make(map[string]string)
+					for label := range slices.Values(metric.GetLabel()) {
+						labels[label.GetName()] = label.GetValue()
+					}
+					if labels["route"] == test.expectedRoute && labels["code"] == strconv.Itoa(test.expectedStatus) {
+						count = metric.GetCounter().GetValue()
+					}
+				}
+			}
+			assert.Equal(t, float64(1), count)
+		})
+	}
+}
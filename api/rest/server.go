@@ -1,47 +1,206 @@
 package rest
 
 import (
+	"cmp"
 	"context"
+	"crypto/rand"
 	"encoding/hex"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"math/big"
 	"net/http"
+	"net/mail"
+	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"sync/atomic"
+	"time"
 
-	"github.com/sirupsen/logrus"
-
+	"github.com/hedisam/ethtxparser/internal/abi"
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/buildinfo"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/keccak"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/notify"
 	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
 )
 
 const (
 	// InvalidAddrMessage is returned when users make a request with an invalid addr.
 	InvalidAddrMessage = "Invalid Ethereum address. Expected a 40-character hex string, with or without '0x' prefix. Example: 0x12ab34cd56ef7890a1234567890abcdef1234567"
+	// InvalidMinValueMessage is returned when users subscribe with an invalid minimum transaction value.
+	InvalidMinValueMessage = "Invalid minimum value. Expected a non-negative amount in wei, in decimal or 0x-prefixed hex. Example: 1000000000000000000"
+	// InvalidModeMessage is returned when users subscribe with an unknown subscription mode.
+	InvalidModeMessage = "Invalid subscription mode. Expected either 'live' or 'history'."
+	// InvalidWebhookURLMessage is returned when users subscribe with an invalid webhook URL.
+	InvalidWebhookURLMessage = "Invalid webhook URL. Expected an absolute http or https URL. Example: https://example.com/hooks/eth"
+	// InvalidChatChannelMessage is returned when users subscribe with an invalid chat channel.
+	InvalidChatChannelMessage = "Invalid chat channel. Expected 'slack:<https webhook url>', 'discord:<https webhook url>' or 'telegram:<bot token>@<chat id>'."
+	// InvalidEmailMessage is returned when users subscribe with an invalid email address.
+	InvalidEmailMessage = "Invalid email address. Expected a bare address. Example: alice@example.com"
+	// InvalidBackfillMessage is returned when users subscribe with an invalid backfill range.
+	InvalidBackfillMessage = "Invalid backfill. Expected either a positive 'backfillBlocks' or a 'backfillFrom' block before the subscription start block, in history mode."
+	// InvalidQueryMessage is returned when users search subscriptions with an invalid address prefix.
+	InvalidQueryMessage = "Invalid query. Expected the hex prefix of an address, with or without '0x' prefix. Example: 0x7a25"
+	// InvalidTxHashMessage is returned when users make a request with an invalid transaction hash.
+	InvalidTxHashMessage = "Invalid transaction hash. Expected a 64-character hex string, with or without '0x' prefix. Example: 0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+	// InvalidTxTypeMessage is returned when users list transactions of an unknown type.
+	InvalidTxTypeMessage = "Invalid transaction type. Expected one of 'legacy', 'eip2930', 'eip1559', 'blob' or 'eip7702'."
+	// InvalidDeliveryMessage is returned when users subscribe with an unknown notification delivery.
+	InvalidDeliveryMessage = "Invalid notification delivery. Expected either 'immediate' or 'digest'."
+	// InvalidGracePeriodMessage is returned when users rotate a webhook secret with an invalid grace period.
+	InvalidGracePeriodMessage = "Invalid grace period. Expected a non-negative duration of at most 168h. Example: 24h"
+	// InvalidTimeRangeMessage is returned when users query transactions or stats by an invalid time range.
+	InvalidTimeRangeMessage = "Invalid time range. Expected RFC3339 'since' and 'until' times, 'since' being before 'until'. Example: 2024-03-01T00:00:00Z"
+	// InvalidTopicMessage is returned when users subscribe to contract events with an invalid topic.
+	InvalidTopicMessage = "Invalid event topic. Expected an empty string as wildcard, a 32-byte hex string, or an event signature. Example: Transfer(address,address,uint256)"
+
+	// HealthStatusHealthy and HealthStatusUnhealthy are the statuses of the health reports.
+	HealthStatusHealthy   = "healthy"
+	HealthStatusUnhealthy = "unhealthy"
+
+	// webhookSecretSize is the size in bytes of the generated webhook secrets.
+	webhookSecretSize = 32
+	// defaultWebhookGracePeriod is how long a rotated webhook secret keeps signing the payloads if not given.
+	defaultWebhookGracePeriod = time.Hour * 24
+	// maxWebhookGracePeriod is the longest a rotated webhook secret can keep signing the payloads.
+	maxWebhookGracePeriod = time.Hour * 24 * 7
 )
 
 type TxStore interface {
 	GetCurrentBlockNumber(ctx context.Context) (int64, error)
 	GetTransactions(ctx context.Context, addr string) ([]*store.TxRecord, error)
+	GetTokenTransfers(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error)
+	GetEvents(ctx context.Context, contract string) ([]*store.EventRecord, error)
+	GetAddressStats(ctx context.Context, addr string) (*store.AddressStats, error)
+	GetRawTransaction(ctx context.Context, hash string) (string, error)
+	InsertRawTransaction(ctx context.Context, hash, raw string) error
+	GetTokenBalance(ctx context.Context, token, holder string) (*big.Int, error)
 }
 
 type SubscriptionStore interface {
-	AddSubscription(ctx context.Context, addr string) error
+	AddSubscription(ctx context.Context, sub *store.Subscription) error
+	RemoveSubscription(ctx context.Context, addr string) error
 	GetSubscriptions(ctx context.Context) ([]string, error)
-	IsSubscribed(ctx context.Context, addr string) (bool, error)
+	SearchSubscriptions(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error)
+	GetSubscription(ctx context.Context, addr string) (*store.Subscription, error)
+	AddEventSubscription(ctx context.Context, sub *store.EventSubscription) error
+	GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error)
+	ListEventSubscriptions(ctx context.Context) ([]*store.EventSubscription, error)
+}
+
+// Backfiller records the past transactions of subscribed addresses.
+type Backfiller interface {
+	Backfill(ctx context.Context, sub *store.Subscription, fromBlock, toBlock int64) error
+	Progress(addr string) (backfill.Progress, bool)
+	MaxBlocks() int64
+}
+
+// ABIRegistry holds the contract ABIs used to decode the input of recorded transactions.
+type ABIRegistry interface {
+	Register(contract string, data []byte) error
+	Contracts() []string
+}
+
+// Indexer is the indexing pipeline, which can be paused through the admin API.
+type Indexer interface {
+	Paused() bool
+}
+
+// Node is the node the pipeline follows, checked by the startup probe until a block is indexed and serving the raw
+// transactions.
+type Node interface {
+	BlockNumber(ctx context.Context) (int64, error)
+	GetRawTransaction(ctx context.Context, hash string) (string, error)
+}
+
+// HealthRegistry holds the health of the components of the pipeline.
+type HealthRegistry interface {
+	Statuses() []health.Status
+}
+
+type config struct {
+	health      HealthRegistry
+	backfiller  Backfiller
+	abiRegistry ABIRegistry
+	indexer     Indexer
+	node        Node
+	indexAll    bool
+}
+
+type Option func(*config)
+
+// WithBackfiller enables backfilling the past transactions of new subscriptions.
+func WithBackfiller(backfiller Backfiller) Option {
+	return func(c *config) {
+		c.backfiller = backfiller
+	}
+}
+
+// WithABIRegistry enables registering contract ABIs through the API.
+func WithABIRegistry(registry ABIRegistry) Option {
+	return func(c *config) {
+		c.abiRegistry = registry
+	}
+}
+
+// WithIndexer reports paused indexing as not ready.
+func WithIndexer(indexer Indexer) Option {
+	return func(c *config) {
+		c.indexer = indexer
+	}
+}
+
+// WithNode reports the service started once the node is reachable, rather than only once a block is indexed, and
+// enables fetching the raw transactions from the node.
+func WithNode(node Node) Option {
+	return func(c *config) {
+		c.node = node
+	}
+}
+
+// WithHealth reports the health of the components of the given registry.
+func WithHealth(registry HealthRegistry) Option {
+	return func(c *config) {
+		c.health = registry
+	}
+}
+
+// WithIndexAll lists the transactions of any address, as every transaction is recorded in full-block indexing mode.
+func WithIndexAll() Option {
+	return func(c *config) {
+		c.indexAll = true
+	}
 }
 
 type Server struct {
-	logger    *logrus.Logger
+	logger    logging.Logger
 	txStore   TxStore
 	subsStore SubscriptionStore
+	cfg       *config
+	// started is set once the startup probe succeeds, which it then always does.
+	started atomic.Bool
+	// draining is set once the service is shutting down, failing the readiness probe.
+	draining atomic.Bool
 }
 
-func NewServer(logger *logrus.Logger, txStore TxStore, subsStore SubscriptionStore) *Server {
+func NewServer(logger logging.Logger, txStore TxStore, subsStore SubscriptionStore, opts ...Option) *Server {
+	cfg := &config{}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
 	return &Server{
 		logger:    logger,
 		txStore:   txStore,
 		subsStore: subsStore,
+		cfg:       cfg,
 	}
 }
 
@@ -67,120 +226,1118 @@ func (s *Server) GetCurrentBlock(ctx context.Context, _ *GetCurrentBlockRequest)
 func (s *Server) Subscribe(ctx context.Context, req *SubscribeRequest) (*SubscribeResponse, error) {
 	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
 
-	addr := strings.TrimSpace(req.Address)
-	if addr == "" {
-		logger.Warn("Address is required to subscribe to")
-		return nil, NewErrf(http.StatusBadRequest, "Missing required field: 'address'")
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	sub := &store.Subscription{
+		Address:      addr,
+		Priority:     req.Priority,
+		SkipFailed:   req.SkipFailed,
+		Label:        strings.TrimSpace(req.Label),
+		TrackHolders: req.TrackHolders,
+		Tenant:       metering.TenantFromContext(ctx),
+	}
+	if minValue := strings.TrimSpace(req.MinValue); minValue != "" {
+		value, ok := new(big.Int).SetString(minValue, 0)
+		if !ok || value.Sign() < 0 {
+			logger.Warn("Invalid minimum value provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidMinValueMessage)
+		}
+		sub.MinValue = value
+	}
+	if chatChannel := strings.TrimSpace(req.ChatChannel); chatChannel != "" {
+		_, err := notify.ParseChatChannel(chatChannel)
+		if err != nil {
+			logger.Warn("Invalid chat channel provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidChatChannelMessage)
+		}
+		sub.ChatChannel = chatChannel
+	}
+	if email := strings.TrimSpace(req.Email); email != "" {
+		parsed, err := mail.ParseAddress(email)
+		if err != nil || parsed.Address != email {
+			logger.Warn("Invalid email address provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidEmailMessage)
+		}
+		sub.Email = email
+	}
+	if req.Preferences != nil {
+		preferences, err := convertPreferences(req.Preferences)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid notification preferences provided to subscribe with")
+			return nil, err
+		}
+		sub.Preferences = preferences
+	}
+	if filter := strings.TrimSpace(req.Filter); filter != "" {
+		_, err := store.CompileFilter(filter)
+		if err != nil {
+			logger.WithError(err).Warn("Invalid filter provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, "Invalid filter: %s", err)
+		}
+		sub.Filter = filter
+	}
+	if webhookURL := strings.TrimSpace(req.WebhookURL); webhookURL != "" {
+		if !validateWebhookURL(webhookURL) {
+			logger.Warn("Invalid webhook URL provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidWebhookURLMessage)
+		}
+		sub.WebhookURL = webhookURL
+		sub.WebhookSecret = strings.TrimSpace(req.WebhookSecret)
+		if sub.WebhookSecret == "" {
+			secret, err := generateWebhookSecret()
+			if err != nil {
+				logger.WithError(err).Error("Failed to generate webhook secret")
+				return nil, NewErrf(http.StatusInternalServerError, "could not generate webhook secret")
+			}
+			sub.WebhookSecret = secret
+		}
+		existing, err := s.subsStore.GetSubscription(ctx, addr)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			logger.WithError(err).Error("Failed to get existing subscription to keep its webhook key")
+			return nil, NewErrf(http.StatusInternalServerError, "could not get existing subscription")
+		}
+		// the key of an unchanged secret is kept along with its rotated one, a new secret gets the next key
+		switch {
+		case existing == nil:
+			sub.WebhookKeyID = nextWebhookKeyID("")
+		case existing.WebhookSecret == sub.WebhookSecret:
+			sub.WebhookKeyID = cmp.Or(existing.WebhookKeyID, nextWebhookKeyID(""))
+			sub.PreviousWebhookKey = existing.PreviousWebhookKey
+		default:
+			sub.WebhookKeyID = nextWebhookKeyID(existing.WebhookKeyID)
+		}
+	}
+
+	backfilling := req.BackfillBlocks != 0 || req.BackfillFrom != nil
+	switch mode := store.SubscriptionMode(strings.TrimSpace(req.Mode)); mode {
+	case "":
+		// backfilled transactions are only listed in history mode
+		sub.Mode = store.SubscriptionModeLive
+		if backfilling {
+			sub.Mode = store.SubscriptionModeHistory
+		}
+	case store.SubscriptionModeLive:
+		sub.Mode = store.SubscriptionModeLive
+	case store.SubscriptionModeHistory:
+		sub.Mode = mode
+	default:
+		logger.Warn("Invalid mode provided to subscribe with")
+		return nil, NewErrf(http.StatusBadRequest, InvalidModeMessage)
 	}
 
-	addr, valid := validateAndNormalizeAddress(addr)
-	if !valid {
-		logger.Warn("Invalid address provided to subscribe to")
-		return nil, NewErrf(http.StatusBadRequest, InvalidAddrMessage)
+	startBlock, err := s.subscriptionStartBlock(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get subscription start block")
+		return nil, NewErrf(http.StatusInternalServerError, "could not get subscription start block")
 	}
+	sub.StartBlock = startBlock
 
-	err := s.subsStore.AddSubscription(ctx, addr)
+	var fromBlock, toBlock int64
+	if backfilling {
+		if s.cfg.backfiller == nil {
+			logger.Warn("Backfill requested while backfilling is disabled")
+			return nil, NewErrf(http.StatusBadRequest, "Backfilling is not enabled")
+		}
+		var ok bool
+		fromBlock, toBlock, ok = backfillRange(req, sub)
+		if !ok {
+			logger.Warn("Invalid backfill provided to subscribe with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidBackfillMessage)
+		}
+		if maxBlocks := s.cfg.backfiller.MaxBlocks(); toBlock-fromBlock+1 > maxBlocks {
+			logger.Warn("Too many blocks requested to backfill")
+			return nil, NewErrf(http.StatusBadRequest, "Too many blocks to backfill, at most %d blocks can be backfilled", maxBlocks)
+		}
+	}
+
+	err = s.subsStore.AddSubscription(ctx, sub)
 	if err != nil {
 		logger.WithError(err).Error("Failed to add address subscription to store")
 		return nil, NewErrf(http.StatusInternalServerError, "could not add address subscription to store")
 	}
 
-	return &SubscribeResponse{
+	resp := &SubscribeResponse{
+		Ok:            true,
+		StartBlock:    sub.StartBlock,
+		WebhookSecret: sub.WebhookSecret,
+		WebhookKeyID:  sub.WebhookKeyID,
+	}
+	if !backfilling {
+		return resp, nil
+	}
+
+	err = s.cfg.backfiller.Backfill(ctx, sub, fromBlock, toBlock)
+	if err != nil {
+		switch {
+		case errors.Is(err, backfill.ErrInProgress):
+			logger.Warn("Backfill requested while one is already in progress")
+			return nil, NewErrf(http.StatusConflict, "A backfill is already in progress for this address")
+		case errors.Is(err, backfill.ErrQueueFull):
+			logger.Warn("Backfill requested while the backfill queue is full")
+			return nil, NewErrf(http.StatusServiceUnavailable, "Too many backfills queued, please retry later")
+		}
+		logger.WithError(err).Error("Failed to queue subscription backfill")
+		return nil, NewErrf(http.StatusInternalServerError, "could not queue subscription backfill")
+	}
+	progress, _ := s.cfg.backfiller.Progress(addr)
+	resp.Backfill = ConvertBackfillProgress(progress)
+
+	return resp, nil
+}
+
+// backfillRange returns the range of blocks to backfill for the given request, ending right before the subscription
+// start block.
+func backfillRange(req *SubscribeRequest, sub *store.Subscription) (int64, int64, bool) {
+	if sub.Mode != store.SubscriptionModeHistory || (req.BackfillBlocks != 0 && req.BackfillFrom != nil) {
+		return 0, 0, false
+	}
+
+	toBlock := sub.StartBlock - 1
+	if req.BackfillFrom != nil {
+		return *req.BackfillFrom, toBlock, *req.BackfillFrom >= 0 && *req.BackfillFrom <= toBlock
+	}
+	if req.BackfillBlocks < 0 || toBlock < 0 {
+		return 0, 0, false
+	}
+	return max(0, toBlock-req.BackfillBlocks+1), toBlock, true
+}
+
+// GetBackfill returns the progress of the last backfill of the subscribed address.
+func (s *Server) GetBackfill(ctx context.Context, req *GetBackfillRequest) (*GetBackfillResponse, error) {
+	addr, _ := ethaddr.Normalize(req.Address)
+	if s.cfg.backfiller == nil {
+		return nil, NewErrf(http.StatusNotFound, "No backfill found for this address")
+	}
+	progress, ok := s.cfg.backfiller.Progress(addr)
+	if !ok {
+		return nil, NewErrf(http.StatusNotFound, "No backfill found for this address")
+	}
+
+	return &GetBackfillResponse{
+		Backfill: ConvertBackfillProgress(progress),
+	}, nil
+}
+
+// Unsubscribe removes the subscription of an address, so its transactions are neither recorded nor notified anymore.
+// The transactions recorded so far are kept until purged through the admin API.
+func (s *Server) Unsubscribe(ctx context.Context, req *UnsubscribeRequest) (*UnsubscribeResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+	err := s.subsStore.RemoveSubscription(ctx, addr)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			logger.Warn("Cannot unsubscribe an address not subscribed")
+			return nil, NewErrf(http.StatusNotFound, "Address not subscribed")
+		}
+		logger.WithError(err).Error("Failed to remove address subscription from store")
+		return nil, NewErrf(http.StatusInternalServerError, "could not remove address subscription from store")
+	}
+
+	return &UnsubscribeResponse{
 		Ok: true,
 	}, nil
 }
 
-func (s *Server) ListSubscriptions(ctx context.Context, _ *ListSubscriptionRequest) (*ListSubscriptionResponse, error) {
+// RotateWebhookSecret replaces the webhook secret of a subscription with a new one. The previous secret keeps signing
+// the payloads alongside the new one during the grace period, so receivers can switch to the new secret without
+// failing to verify any delivery.
+func (s *Server) RotateWebhookSecret(ctx context.Context, req *RotateWebhookSecretRequest) (*RotateWebhookSecretResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	gracePeriod := defaultWebhookGracePeriod
+	if raw := strings.TrimSpace(req.GracePeriod); raw != "" {
+		var err error
+		gracePeriod, err = time.ParseDuration(raw)
+		if err != nil || gracePeriod < 0 || gracePeriod > maxWebhookGracePeriod {
+			logger.Warn("Invalid grace period provided to rotate webhook secret with")
+			return nil, NewErrf(http.StatusBadRequest, InvalidGracePeriodMessage)
+		}
+	}
+
+	sub, err := s.subsStore.GetSubscription(ctx, addr)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			logger.Warn("Cannot rotate webhook secret of an address not subscribed")
+			return nil, NewErrf(http.StatusNotFound, "Address not subscribed")
+		}
+		logger.WithError(err).Error("Failed to get address subscription to rotate webhook secret")
+		return nil, NewErrf(http.StatusInternalServerError, "could not get address subscription from store")
+	}
+	if sub.WebhookURL == "" {
+		logger.Warn("Cannot rotate webhook secret of a subscription without webhook")
+		return nil, NewErrf(http.StatusBadRequest, "No webhook configured for this address")
+	}
+
+	secret := strings.TrimSpace(req.WebhookSecret)
+	if secret == "" {
+		secret, err = generateWebhookSecret()
+		if err != nil {
+			logger.WithError(err).Error("Failed to generate webhook secret")
+			return nil, NewErrf(http.StatusInternalServerError, "could not generate webhook secret")
+		}
+	}
+
+	// subscriptions are shared with their readers, so the rotated one is a copy
+	rotated := *sub
+	rotated.WebhookSecret = secret
+	rotated.WebhookKeyID = nextWebhookKeyID(sub.WebhookKeyID)
+	rotated.PreviousWebhookKey = nil
+	if gracePeriod > 0 {
+		rotated.PreviousWebhookKey = &store.WebhookKey{
+			ID:        cmp.Or(sub.WebhookKeyID, nextWebhookKeyID("")),
+			Secret:    sub.WebhookSecret,
+			ExpiresAt: time.Now().Add(gracePeriod).UTC().Truncate(time.Second),
+		}
+	}
+	err = s.subsStore.AddSubscription(ctx, &rotated)
+	if err != nil {
+		logger.WithError(err).Error("Failed to store subscription with rotated webhook secret")
+		return nil, NewErrf(http.StatusInternalServerError, "could not store rotated webhook secret")
+	}
+
+	resp := &RotateWebhookSecretResponse{
+		WebhookSecret: rotated.WebhookSecret,
+		WebhookKeyID:  rotated.WebhookKeyID,
+	}
+	if previous := rotated.PreviousWebhookKey; previous != nil {
+		resp.PreviousKeyID = previous.ID
+		resp.PreviousExpiresAt = previous.ExpiresAt.Format(time.RFC3339)
+	}
+	return resp, nil
+}
+
+// nextWebhookKeyID returns the ID of the webhook key following the given one, "v1" if there's none or it's not
+// versioned.
+func nextWebhookKeyID(current string) string {
+	number, ok := strings.CutPrefix(current, "v")
+	version, err := strconv.Atoi(number)
+	if !ok || err != nil || version < 1 {
+		return "v1"
+	}
+	return "v" + strconv.Itoa(version+1)
+}
+
+// subscriptionStartBlock returns the start block of the existing subscription to addr, or the block after the current
+// one for new subscriptions.
+func (s *Server) subscriptionStartBlock(ctx context.Context, addr string) (int64, error) {
+	existing, err := s.subsStore.GetSubscription(ctx, addr)
+	if err == nil {
+		return existing.StartBlock, nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return 0, fmt.Errorf("could not get existing subscription: %w", err)
+	}
+
+	current, err := s.txStore.GetCurrentBlockNumber(ctx)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("could not get current block number: %w", err)
+	}
+	return current + 1, nil
+}
+
+// ListSubscriptions lists the subscribed addresses, only the ones starting with the query and whose label contains the
+// label of the request if either is set.
+func (s *Server) ListSubscriptions(ctx context.Context, req *ListSubscriptionRequest) (*ListSubscriptionResponse, error) {
 	logger := s.logger.WithContext(ctx)
 
-	addresses, err := s.subsStore.GetSubscriptions(ctx)
+	query := &store.SubscriptionQuery{
+		AddressPrefix: strings.ToLower(strings.TrimSpace(req.Query)),
+		Label:         strings.TrimSpace(req.Label),
+	}
+	if query.AddressPrefix == "" && query.Label == "" {
+		storedAddresses, err := s.subsStore.GetSubscriptions(ctx)
+		if err != nil {
+			logger.WithError(err).Error("Failed to list subscribed addresses from store")
+			return nil, NewErrf(http.StatusInternalServerError, "could not list subscribed addresses")
+		}
+		addresses := make([]string, 0, len(storedAddresses))
+		for addr := range slices.Values(storedAddresses) {
+			addresses = append(addresses, formatAddress(ctx, addr))
+		}
+		return &ListSubscriptionResponse{
+			Addresses: addresses,
+		}, nil
+	}
+
+	if !strings.HasPrefix(query.AddressPrefix, "0x") {
+		query.AddressPrefix = "0x" + query.AddressPrefix
+	}
+	if !isHexPrefix(query.AddressPrefix[2:]) {
+		logger.WithField("query", req.Query).Warn("Invalid query provided to search subscriptions")
+		return nil, NewErrf(http.StatusBadRequest, InvalidQueryMessage)
+	}
+	subs, err := s.subsStore.SearchSubscriptions(ctx, query)
 	if err != nil {
-		logger.WithError(err).Error("Failed to list subscribed addresses from store")
-		return nil, NewErrf(http.StatusInternalServerError, "could not list subscribed addresses")
+		logger.WithError(err).Error("Failed to search subscriptions in store")
+		return nil, NewErrf(http.StatusInternalServerError, "could not search subscriptions")
 	}
 
+	addresses := make([]string, 0, len(subs))
+	for sub := range slices.Values(subs) {
+		addresses = append(addresses, formatAddress(ctx, sub.Address))
+	}
 	return &ListSubscriptionResponse{
 		Addresses: addresses,
 	}, nil
 }
 
-func (s *Server) ListTransactions(ctx context.Context, req *ListTransactionsRequest) (*ListTransactionsResponse, error) {
-	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+// isHexPrefix reports whether s is made of hex digits only, at most as many as an address has.
+func isHexPrefix(s string) bool {
+	return len(s) <= 40 && strings.Trim(s, "0123456789abcdef") == ""
+}
+
+// GetRawTransaction returns the signed transaction of the given hash RLP-encoded, e.g. to broadcast it again or verify
+// it independently. It's fetched from the node once and then served from the store.
+func (s *Server) GetRawTransaction(ctx context.Context, req *GetRawTransactionRequest) (*GetRawTransactionResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("hash", req.Hash)
 
-	addr := strings.TrimSpace(req.Address)
-	if addr == "" {
-		logger.Warn("Address is required to list transactions")
-		return nil, NewErrf(http.StatusBadRequest, "Missing required field: 'address'")
+	hash, _ := normalizeTxHash(req.Hash)
+	raw, err := s.txStore.GetRawTransaction(ctx, hash)
+	switch {
+	case err == nil:
+		return &GetRawTransactionResponse{Hash: hash, Raw: raw}, nil
+	case !errors.Is(err, store.ErrNotFound):
+		// the store is only a cache of the node, it's still asked
+		logger.WithError(err).Warn("Failed to get raw transaction from store")
 	}
 
-	addr, valid := validateAndNormalizeAddress(addr)
-	if !valid {
-		logger.Warn("Invalid address provided to list transactions")
-		return nil, NewErrf(http.StatusBadRequest, InvalidAddrMessage)
+	if s.cfg.node == nil {
+		logger.Warn("Raw transaction requested while not following a node")
+		return nil, NewErrf(http.StatusNotFound, "Transaction not found")
+	}
+	raw, err = s.cfg.node.GetRawTransaction(ctx, hash)
+	if err != nil {
+		if errors.Is(err, eth.ErrTxNotFound) {
+			return nil, NewErrf(http.StatusNotFound, "Transaction not found")
+		}
+		logger.WithError(err).Error("Failed to get raw transaction from node")
+		return nil, NewErrf(http.StatusBadGateway, "Could not get raw transaction from node")
 	}
 
-	ok, err := s.subsStore.IsSubscribed(ctx, addr)
+	err = s.txStore.InsertRawTransaction(ctx, hash, raw)
 	if err != nil {
-		logger.WithError(err).Error("Failed to check address subscription status while listing transactions")
-		return nil, NewErrf(http.StatusInternalServerError, "Could not check address subscription status")
+		logger.WithError(err).Warn("Failed to cache raw transaction in store")
 	}
-	if !ok {
-		logger.Warn("Cannot get transactions for an address not subscribed")
-		return nil, NewErrf(http.StatusNotFound, "Address not subscribed. You must first subscribe to the requested address to record and retrieve its transactions.")
+	return &GetRawTransactionResponse{Hash: hash, Raw: raw}, nil
+}
+
+func (s *Server) ListTransactions(ctx context.Context, req *ListTransactionsRequest) (*ListTransactionsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+	var txType store.TxType
+	if req.Type != "" {
+		var valid bool
+		txType, valid = store.ParseTxType(req.Type)
+		if !valid {
+			return nil, NewErrf(http.StatusBadRequest, InvalidTxTypeMessage)
+		}
+	}
+	blockTimes, valid := parseTimeRange(req.Since, req.Until)
+	if !valid {
+		return nil, NewErrf(http.StatusBadRequest, InvalidTimeRangeMessage)
+	}
+
+	// every transaction is recorded in full-block indexing mode, listing them doesn't depend on subscriptions
+	includes := func(int64) bool { return true }
+	if !s.cfg.indexAll {
+		sub, err := s.subsStore.GetSubscription(ctx, addr)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			logger.WithError(err).Error("Failed to check address subscription status while listing transactions")
+			return nil, NewErrf(http.StatusInternalServerError, "Could not check address subscription status")
+		}
+		if err != nil {
+			logger.Warn("Cannot get transactions for an address not subscribed")
+			return nil, NewErrf(http.StatusNotFound, "Address not subscribed. You must first subscribe to the requested address to record and retrieve its transactions.")
+		}
+		includes = sub.Includes
 	}
 
-	storedTransactions, err := s.txStore.GetTransactions(ctx, req.Address)
+	storedTransactions, err := s.txStore.GetTransactions(ctx, addr)
 	if err != nil {
 		logger.WithError(err).Error("Failed to get transactions from store")
 		return nil, NewErrf(http.StatusInternalServerError, "Could not list transactions from store")
 	}
 
+	// confirmations are derived from the current block rather than kept up to date on the stored records
+	head, err := s.txStore.GetCurrentBlockNumber(ctx)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		logger.WithError(err).Error("Failed to get current block number to count transaction confirmations")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not get current block number from store")
+	}
+
+	// the transactions are converted one at a time as they're streamed, instead of all at once, for the clients
+	// accepting NDJSON
+	items := func(yield func(*Transaction, error) bool) {
+		for storedTx := range slices.Values(storedTransactions) {
+			if !includes(storedTx.BlockNumber) || (txType != "" && storedTx.Type != txType) || !blockTimes.contains(storedTx.BlockTimestamp) {
+				continue
+			}
+			tx, err := convertStoredToAPITransaction(ctx, storedTx)
+			if err != nil {
+				logger.WithError(err).Error("Failed to unmarshal transaction in ListTransactions")
+				yield(nil, NewErrf(http.StatusInternalServerError, "Could not unmarshal transaction"))
+				return
+			}
+			tx.Confirmations = confirmations(storedTx, head)
+			if !yield(tx, nil) {
+				return
+			}
+		}
+	}
+	if acceptsNDJSON(ctx) {
+		return &ListTransactionsResponse{items: items}, nil
+	}
+
 	var txs []*Transaction
-	for storedTx := range slices.Values(storedTransactions) {
-		tx, err := convertStoredToAPITransaction(storedTx)
+	for tx, err := range items {
 		if err != nil {
-			logger.WithError(err).Error("Failed to unmarshal transaction in ListTransactions")
-			return nil, NewErrf(http.StatusInternalServerError, "Could not unmarshal transaction")
+			return nil, err
 		}
-
 		txs = append(txs, tx)
 	}
-
 	return &ListTransactionsResponse{
 		Transactions: txs,
 	}, nil
 }
 
-func validateAndNormalizeAddress(addr string) (string, bool) {
-	addr = strings.ToLower(strings.TrimSpace(addr))
-	addr = strings.TrimPrefix(addr, "0x")
-	if len(addr) != 40 {
+func (s *Server) ListTokenTransfers(ctx context.Context, req *ListTokenTransfersRequest) (*ListTokenTransfersResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	sub, err := s.subsStore.GetSubscription(ctx, addr)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		logger.WithError(err).Error("Failed to check address subscription status while listing token transfers")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not check address subscription status")
+	}
+	if err != nil {
+		logger.Warn("Cannot get token transfers for an address not subscribed")
+		return nil, NewErrf(http.StatusNotFound, "Address not subscribed. You must first subscribe to the requested address to record and retrieve its token transfers.")
+	}
+
+	storedTransfers, err := s.txStore.GetTokenTransfers(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get token transfers from store")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not list token transfers from store")
+	}
+
+	transfers := make([]*TokenTransfer, 0, len(storedTransfers))
+	for transfer := range slices.Values(storedTransfers) {
+		if !sub.Includes(transfer.BlockNumber) {
+			continue
+		}
+		transfers = append(transfers, convertStoredToAPITokenTransfer(ctx, transfer))
+	}
+
+	return &ListTokenTransfersResponse{
+		Transfers: transfers,
+	}, nil
+}
+
+func (s *Server) SubscribeEvents(ctx context.Context, req *SubscribeEventsRequest) (*SubscribeEventsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithFields(logging.Fields{
+		"addr":   req.Address,
+		"topics": req.Topics,
+	})
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	topics := make([]string, 0, len(req.Topics))
+	for topic := range slices.Values(req.Topics) {
+		topic, valid := validateAndNormalizeTopic(topic)
+		if !valid {
+			logger.Warn("Invalid topic provided to subscribe to events")
+			return nil, NewErrf(http.StatusBadRequest, InvalidTopicMessage)
+		}
+		topics = append(topics, topic)
+	}
+
+	err := s.subsStore.AddEventSubscription(ctx, &store.EventSubscription{
+		Contract: addr,
+		Topics:   topics,
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to add event subscription to store")
+		return nil, NewErrf(http.StatusInternalServerError, "could not add event subscription to store")
+	}
+
+	return &SubscribeEventsResponse{
+		Ok:     true,
+		Topics: topics,
+	}, nil
+}
+
+func (s *Server) ListEventSubscriptions(ctx context.Context, _ *ListEventSubscriptionsRequest) (*ListEventSubscriptionsResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	storedSubs, err := s.subsStore.ListEventSubscriptions(ctx)
+	if err != nil {
+		logger.WithError(err).Error("Failed to list event subscriptions from store")
+		return nil, NewErrf(http.StatusInternalServerError, "could not list event subscriptions")
+	}
+
+	subs := make([]*EventSubscription, 0, len(storedSubs))
+	for sub := range slices.Values(storedSubs) {
+		subs = append(subs, &EventSubscription{
+			Contract: formatAddress(ctx, sub.Contract),
+			Topics:   sub.Topics,
+		})
+	}
+
+	return &ListEventSubscriptionsResponse{
+		Subscriptions: subs,
+	}, nil
+}
+
+// RegisterABI registers the ABI of a contract, replacing any previous one, to decode the input of the transactions
+// sent to it from then on.
+func (s *Server) RegisterABI(ctx context.Context, req *RegisterABIRequest) (*RegisterABIResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	if s.cfg.abiRegistry == nil {
+		logger.Warn("ABI registration requested while input decoding is disabled")
+		return nil, NewErrf(http.StatusBadRequest, "Input decoding is not enabled")
+	}
+
+	err := s.cfg.abiRegistry.Register(addr, req.ABI)
+	if err != nil {
+		if errors.Is(err, abi.ErrInvalidABI) {
+			logger.WithError(err).Warn("Invalid abi provided to register")
+			return nil, NewErrf(http.StatusBadRequest, "Invalid ABI: %s", err)
+		}
+		logger.WithError(err).Error("Failed to register abi")
+		return nil, NewErrf(http.StatusInternalServerError, "could not register abi")
+	}
+
+	return &RegisterABIResponse{
+		Ok: true,
+	}, nil
+}
+
+func (s *Server) ListABIs(ctx context.Context, _ *ListABIsRequest) (*ListABIsResponse, error) {
+	var contracts []string
+	if s.cfg.abiRegistry != nil {
+		for contract := range slices.Values(s.cfg.abiRegistry.Contracts()) {
+			contracts = append(contracts, formatAddress(ctx, contract))
+		}
+	}
+
+	return &ListABIsResponse{
+		Contracts: contracts,
+	}, nil
+}
+
+func (s *Server) ListEvents(ctx context.Context, req *ListEventsRequest) (*ListEventsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+
+	subs, err := s.subsStore.GetEventSubscriptions(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get event subscriptions while listing events")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not check contract event subscriptions")
+	}
+	if len(subs) == 0 {
+		logger.Warn("Cannot get events for a contract not subscribed")
+		return nil, NewErrf(http.StatusNotFound, "Contract not subscribed. You must first subscribe to the contract events to record and retrieve them.")
+	}
+
+	storedEvents, err := s.txStore.GetEvents(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get events from store")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not list events from store")
+	}
+
+	events := make([]*Event, 0, len(storedEvents))
+	for event := range slices.Values(storedEvents) {
+		events = append(events, &Event{
+			Contract:       formatAddress(ctx, event.Contract),
+			TxHash:         event.TxHash,
+			LogIndex:       event.LogIndex,
+			Topics:         event.Topics,
+			DataWords:      event.DataWords,
+			BlockNumber:    fmt.Sprintf("0x%x", event.BlockNumber),
+			BlockNumberInt: event.BlockNumber,
+			BlockHash:      event.BlockHash,
+			Removed:        event.Removed,
+		})
+	}
+
+	return &ListEventsResponse{
+		Events: events,
+	}, nil
+}
+
+// GetAddressStats returns the transaction counters of the address aggregated per day and over the last 24 hours.
+func (s *Server) GetAddressStats(ctx context.Context, req *GetAddressStatsRequest) (*GetAddressStatsResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("addr", req.Address)
+
+	addr, _ := ethaddr.Normalize(req.Address)
+	days, valid := parseTimeRange(req.Since, req.Until)
+	if !valid {
+		return nil, NewErrf(http.StatusBadRequest, InvalidTimeRangeMessage)
+	}
+
+	if !s.cfg.indexAll {
+		_, err := s.subsStore.GetSubscription(ctx, addr)
+		if err != nil && !errors.Is(err, store.ErrNotFound) {
+			logger.WithError(err).Error("Failed to check address subscription status while getting stats")
+			return nil, NewErrf(http.StatusInternalServerError, "Could not check address subscription status")
+		}
+		if err != nil {
+			logger.Warn("Cannot get stats for an address not subscribed")
+			return nil, NewErrf(http.StatusNotFound, "Address not subscribed. You must first subscribe to the requested address to record and aggregate its transactions.")
+		}
+	}
+
+	stats, err := s.txStore.GetAddressStats(ctx, addr)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get address stats from store")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not get address stats from store")
+	}
+
+	daily := make([]*DailyTxCounters, 0, len(stats.Daily))
+	total := store.TxCounters{ValueIn: new(big.Int), ValueOut: new(big.Int)}
+	for day := range slices.Values(stats.Daily) {
+		if !days.overlapsDay(day.Day) {
+			continue
+		}
+		daily = append(daily, &DailyTxCounters{
+			Day:        day.Day.Format(time.DateOnly),
+			TxCounters: convertTxCounters(day.TxCounters),
+		})
+		total.TxCount += day.TxCount
+		if day.ValueIn != nil {
+			total.ValueIn.Add(total.ValueIn, day.ValueIn)
+		}
+		if day.ValueOut != nil {
+			total.ValueOut.Add(total.ValueOut, day.ValueOut)
+		}
+	}
+
+	resp := &GetAddressStatsResponse{
+		Address: formatAddress(ctx, addr),
+		Last24h: convertTxCounters(stats.Rolling),
+		Daily:   daily,
+	}
+	if days.bounded() {
+		counters := convertTxCounters(total)
+		resp.Range = &counters
+	}
+	return resp, nil
+}
+
+// GetTokenHolder returns the balance of a holder of a token contract whose holders are tracked.
+func (s *Server) GetTokenHolder(ctx context.Context, req *GetTokenHolderRequest) (*GetTokenHolderResponse, error) {
+	logger := s.logger.WithContext(ctx).WithField("contract", req.Contract).WithField("addr", req.Address)
+
+	contract, _ := ethaddr.Normalize(req.Contract)
+	holder, _ := ethaddr.Normalize(req.Address)
+
+	sub, err := s.subsStore.GetSubscription(ctx, contract)
+	if err != nil && !errors.Is(err, store.ErrNotFound) {
+		logger.WithError(err).Error("Failed to check contract subscription status while getting token holder")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not check contract subscription status")
+	}
+	if err != nil || !sub.TrackHolders {
+		logger.Warn("Cannot get token holder of a contract whose holders aren't tracked")
+		return nil, NewErrf(http.StatusNotFound, "Token holders not tracked. You must first subscribe to the requested contract with 'trackHolders' to track the balances of its holders.")
+	}
+
+	balance, err := s.txStore.GetTokenBalance(ctx, contract, holder)
+	if err != nil {
+		logger.WithError(err).Error("Failed to get token balance from store")
+		return nil, NewErrf(http.StatusInternalServerError, "Could not get token balance from store")
+	}
+
+	return &GetTokenHolderResponse{
+		Contract: formatAddress(ctx, contract),
+		Address:  formatAddress(ctx, holder),
+		Balance:  balance.String(),
+	}, nil
+}
+
+// Startup reports whether the service has started, i.e. it has indexed a block or the node is reachable, so slow
+// starts aren't mistaken for dead processes. Once it has, it always reports started.
+func (s *Server) Startup(ctx context.Context, _ *StartupRequest) (*StartupResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	if !s.started.Load() {
+		_, err := s.txStore.GetCurrentBlockNumber(ctx)
+		switch {
+		case err == nil:
+		case s.cfg.node == nil:
+			if !errors.Is(err, store.ErrNotFound) {
+				logger.WithError(err).Error("Failed to get current block number from store")
+			}
+			return nil, NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet")
+		default:
+			_, err = s.cfg.node.BlockNumber(ctx)
+			if err != nil {
+				logger.WithError(err).Warn("Node is unreachable")
+				return nil, NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet and the node is unreachable")
+			}
+		}
+		s.started.Store(true)
+	}
+
+	return &StartupResponse{
+		Status: "started",
+	}, nil
+}
+
+// Live reports the service is alive. It doesn't depend on the node or the store, so their outages don't get the
+// process restarted.
+func (s *Server) Live(context.Context, *LiveRequest) (*LiveResponse, error) {
+	return &LiveResponse{
+		Status: "alive",
+	}, nil
+}
+
+// Drain marks the service as shutting down, failing the readiness probe so load balancers stop sending it traffic.
+func (s *Server) Drain() {
+	s.draining.Store(true)
+}
+
+// Health reports the health of every component of the pipeline along with its last error, unhealthy if any of them
+// is. Unlike the probes it's meant for people and dashboards, detailing which component fails and why.
+func (s *Server) Health(context.Context, *HealthRequest) (*HealthResponse, error) {
+	resp := &HealthResponse{
+		Status:     HealthStatusHealthy,
+		Components: []*ComponentHealth{},
+	}
+	if s.cfg.health == nil {
+		return resp, nil
+	}
+
+	for status := range slices.Values(s.cfg.health.Statuses()) {
+		component := &ComponentHealth{
+			Name:      status.Component,
+			Healthy:   status.Healthy,
+			Since:     status.Since.UTC().Format(time.RFC3339),
+			LastError: status.LastError,
+			Provider:  status.Provider,
+		}
+		if !status.LastErrorAt.IsZero() {
+			component.LastErrorAt = status.LastErrorAt.UTC().Format(time.RFC3339)
+		}
+		if !status.Healthy {
+			resp.Status = HealthStatusUnhealthy
+		}
+		resp.Components = append(resp.Components, component)
+	}
+	return resp, nil
+}
+
+// Ready reports whether the service is ready to serve up to date transactions, i.e. it isn't shutting down, it has
+// indexed a block and indexing isn't paused.
+func (s *Server) Ready(ctx context.Context, _ *ReadyRequest) (*ReadyResponse, error) {
+	logger := s.logger.WithContext(ctx)
+
+	if s.draining.Load() {
+		return nil, NewErrf(http.StatusServiceUnavailable, "Shutting down")
+	}
+	if s.cfg.indexer != nil && s.cfg.indexer.Paused() {
+		return nil, NewErrf(http.StatusServiceUnavailable, "Indexing is paused")
+	}
+
+	_, err := s.txStore.GetCurrentBlockNumber(ctx)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet")
+		}
+		logger.WithError(err).Error("Failed to get current block number from store")
+		return nil, NewErrf(http.StatusServiceUnavailable, "Could not get current block number from store")
+	}
+
+	return &ReadyResponse{
+		Status: "ready",
+	}, nil
+}
+
+// validateWebhookURL reports whether the given URL is an absolute http or https URL.
+func validateWebhookURL(rawURL string) bool {
+	u, err := url.Parse(rawURL)
+	if err != nil {
+		return false
+	}
+
+	return (u.Scheme == "http" || u.Scheme == "https") && u.Host != ""
+}
+
+// generateWebhookSecret generates a random hex encoded webhook secret.
+func generateWebhookSecret() (string, error) {
+	secret := make([]byte, webhookSecretSize)
+	_, err := rand.Read(secret)
+	if err != nil {
+		return "", err
+	}
+
+	return hex.EncodeToString(secret), nil
+}
+
+// convertPreferences validates the notification preferences of a subscription and converts them to their store
+// counterpart.
+func convertPreferences(req *NotificationPreferences) (*store.NotificationPreferences, *Err) {
+	preferences := &store.NotificationPreferences{}
+	for channel := range slices.Values(req.Channels) {
+		channel = strings.TrimSpace(channel)
+		if channel == "" {
+			return nil, NewErrf(http.StatusBadRequest, "Invalid notification channel. Expected the name of a notifier, e.g. 'webhook' or 'email'.")
+		}
+		preferences.Channels = append(preferences.Channels, channel)
+	}
+	if minValue := strings.TrimSpace(req.MinValue); minValue != "" {
+		value, ok := new(big.Int).SetString(minValue, 0)
+		if !ok || value.Sign() < 0 {
+			return nil, NewErrf(http.StatusBadRequest, InvalidMinValueMessage)
+		}
+		preferences.MinValue = value
+	}
+	if req.QuietHours != nil {
+		quietHours := &store.QuietHours{
+			Start: strings.TrimSpace(req.QuietHours.Start),
+			End:   strings.TrimSpace(req.QuietHours.End),
+		}
+		err := quietHours.Validate()
+		if err != nil {
+			return nil, NewErrf(http.StatusBadRequest, "Invalid quiet hours: %s", err)
+		}
+		preferences.QuietHours = quietHours
+	}
+	switch delivery := store.NotificationDelivery(strings.TrimSpace(req.Delivery)); delivery {
+	case "", store.NotificationDeliveryImmediate:
+		preferences.Delivery = store.NotificationDeliveryImmediate
+	case store.NotificationDeliveryDigest:
+		preferences.Delivery = delivery
+	default:
+		return nil, NewErrf(http.StatusBadRequest, InvalidDeliveryMessage)
+	}
+	return preferences, nil
+}
+
+// normalizeTxHash returns the transaction hash lower-cased and 0x-prefixed, reporting whether it's a 32-byte hex
+// string.
+func normalizeTxHash(hash string) (string, bool) {
+	hash = strings.TrimPrefix(strings.ToLower(strings.TrimSpace(hash)), "0x")
+	if len(hash) != 64 {
+		return "", false
+	}
+	_, err := hex.DecodeString(hash)
+	if err != nil {
 		return "", false
 	}
+	return "0x" + hash, true
+}
+
+// validateAndNormalizeTopic accepts an empty wildcard topic, a 32-byte hex topic, or an event signature such as
+// `Transfer(address,address,uint256)` which is hashed into its topic.
+func validateAndNormalizeTopic(topic string) (string, bool) {
+	topic = strings.TrimSpace(topic)
+	if topic == "" {
+		return "", true
+	}
 
-	_, err := hex.DecodeString(addr)
+	if strings.Contains(topic, "(") {
+		if !strings.HasSuffix(topic, ")") || strings.ContainsAny(topic, " ") {
+			return "", false
+		}
+		hash := keccak.Sum256([]byte(topic))
+		return "0x" + hex.EncodeToString(hash[:]), true
+	}
+
+	topic = strings.TrimPrefix(strings.ToLower(topic), "0x")
+	if len(topic) != 64 {
+		return "", false
+	}
+	_, err := hex.DecodeString(topic)
 	if err != nil {
 		return "", false
 	}
 
-	addr = "0x" + addr
-	return addr, true
+	return "0x" + topic, true
 }
 
-func convertStoredToAPITransaction(tx *store.TxRecord) (*Transaction, error) {
+// convertStoredToAPITransaction converts a recorded transaction, its addresses being formatted for the request of the
+// context, but the ones of the full transaction, kept as the node returned them.
+func convertStoredToAPITransaction(ctx context.Context, tx *store.TxRecord) (*Transaction, error) {
 	var fullTx map[string]any
 	err := json.Unmarshal(tx.Raw, &fullTx)
 	if err != nil {
 		return nil, fmt.Errorf("unmarshal full stored transaction: %w", err)
 	}
 
+	var value string
+	if tx.Value != nil {
+		value = tx.Value.String()
+	}
+
 	return &Transaction{
 		Hash:           tx.Hash,
-		From:           tx.From,
-		To:             tx.To,
+		From:           formatAddress(ctx, tx.From),
+		To:             formatAddress(ctx, tx.To),
+		Value:          value,
+		ValueUSD:       tx.ValueUSD,
 		BlockNumber:    fmt.Sprintf("0x%x", tx.BlockNumber),
 		BlockNumberInt: tx.BlockNumber,
 		BlockHash:      tx.BlockHash,
+		BlockTimestamp: tx.BlockTimestamp,
 		FullTx:         fullTx,
+		Status:         string(tx.Status),
+		Type:           string(tx.Type),
+		DecodedInput:   convertDecodedInput(ctx, tx.DecodedInput),
+		Removed:        tx.Removed,
+		Labels:         tx.Labels,
+	}, nil
+}
+
+// confirmations returns the number of blocks up to the head, inclusive, built on the block of the transaction, or
+// zero if it was removed or the head is behind it.
+func confirmations(tx *store.TxRecord, head int64) int64 {
+	if tx.Removed || head < tx.BlockNumber {
+		return 0
+	}
+	return head - tx.BlockNumber + 1
+}
+
+// timeRange is a range of block times, from since up to, but excluding, until. A zero bound leaves its side open.
+type timeRange struct {
+	since time.Time
+	until time.Time
+}
+
+// parseTimeRange parses the RFC3339 bounds of a time range, either being optional, or returns false if they're invalid
+// or since isn't before until.
+func parseTimeRange(since, until string) (timeRange, bool) {
+	var r timeRange
+	var err error
+	if since = strings.TrimSpace(since); since != "" {
+		r.since, err = time.Parse(time.RFC3339, since)
+		if err != nil {
+			return timeRange{}, false
+		}
+	}
+	if until = strings.TrimSpace(until); until != "" {
+		r.until, err = time.Parse(time.RFC3339, until)
+		if err != nil {
+			return timeRange{}, false
+		}
+	}
+	if !r.since.IsZero() && !r.until.IsZero() && !r.since.Before(r.until) {
+		return timeRange{}, false
+	}
+	return r, true
+}
+
+// bounded reports whether either bound of the range is set.
+func (r timeRange) bounded() bool {
+	return !r.since.IsZero() || !r.until.IsZero()
+}
+
+// contains reports whether the range contains the given block timestamp, in unix seconds. A bounded range doesn't
+// contain the blocks of unknown time.
+func (r timeRange) contains(timestamp int64) bool {
+	if !r.bounded() {
+		return true
+	}
+	if timestamp <= 0 {
+		return false
+	}
+	t := time.Unix(timestamp, 0)
+	return (r.since.IsZero() || !t.Before(r.since)) && (r.until.IsZero() || t.Before(r.until))
+}
+
+// overlapsDay reports whether any time of the given UTC day is in the range.
+func (r timeRange) overlapsDay(day time.Time) bool {
+	return (r.since.IsZero() || day.AddDate(0, 0, 1).After(r.since)) && (r.until.IsZero() || day.Before(r.until))
+}
+
+func convertTxCounters(counters store.TxCounters) TxCounters {
+	converted := TxCounters{
+		TxCount:  counters.TxCount,
+		ValueIn:  "0",
+		ValueOut: "0",
+	}
+	if counters.ValueIn != nil {
+		converted.ValueIn = counters.ValueIn.String()
+	}
+	if counters.ValueOut != nil {
+		converted.ValueOut = counters.ValueOut.String()
+	}
+	return converted
+}
+
+func convertDecodedInput(ctx context.Context, input *store.DecodedInput) *DecodedInput {
+	if input == nil {
+		return nil
+	}
+
+	args := make([]*DecodedArg, 0, len(input.Args))
+	for arg := range slices.Values(input.Args) {
+		value := arg.Value
+		if addr, ok := value.(string); ok && arg.Type == "address" {
+			value = formatAddress(ctx, addr)
+		}
+		args = append(args, &DecodedArg{
+			Name:  arg.Name,
+			Type:  arg.Type,
+			Value: value,
+		})
+	}
+	return &DecodedInput{
+		Method:    input.Method,
+		Signature: input.Signature,
+		Args:      args,
+	}
+}
+
+func convertStoredToAPITokenTransfer(ctx context.Context, transfer *store.TokenTransferRecord) *TokenTransfer {
+	return &TokenTransfer{
+		TxHash:         transfer.TxHash,
+		LogIndex:       transfer.LogIndex,
+		Token:          formatAddress(ctx, transfer.Token),
+		Standard:       string(transfer.Standard),
+		From:           formatAddress(ctx, transfer.From),
+		To:             formatAddress(ctx, transfer.To),
+		Amount:         transfer.Amount,
+		TokenID:        transfer.TokenID,
+		BlockNumber:    fmt.Sprintf("0x%x", transfer.BlockNumber),
+		BlockNumberInt: transfer.BlockNumber,
+		BlockHash:      transfer.BlockHash,
+		Removed:        transfer.Removed,
+	}
+}
+
+// ConvertBackfillProgress converts the progress of a backfill to its API representation.
+func ConvertBackfillProgress(progress backfill.Progress) *BackfillProgress {
+	return &BackfillProgress{
+		FromBlock:     progress.FromBlock,
+		ToBlock:       progress.ToBlock,
+		NextBlock:     progress.NextBlock,
+		ScannedBlocks: progress.ScannedBlocks,
+		Transactions:  progress.Transactions,
+		Status:        string(progress.Status),
+		Error:         progress.Error,
+		UpdatedAt:     progress.UpdatedAt.UTC().Format(time.RFC3339),
+	}
+}
+
+// GetVersion returns the version, commit and build date of the running binary.
+func (s *Server) GetVersion(_ context.Context, _ *GetVersionRequest) (*VersionResponse, error) {
+	info := buildinfo.Get()
+	return &VersionResponse{
+		Version:   info.Version,
+		Commit:    info.Commit,
+		Date:      info.Date,
+		GoVersion: info.GoVersion,
 	}, nil
 }
@@ -3,9 +3,15 @@ package rest_test
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
 	"net/http"
+	"net/http/httptest"
+	"runtime"
 	"slices"
+	"strings"
 	"testing"
+	"time"
 
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
@@ -13,11 +19,31 @@ import (
 
 	restapi "github.com/hedisam/ethtxparser/api/rest"
 	"github.com/hedisam/ethtxparser/api/rest/mocks"
+	"github.com/hedisam/ethtxparser/internal/abi"
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/buildinfo"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/logging"
 	"github.com/hedisam/ethtxparser/internal/store"
 )
 
 //go:generate moq -out mocks/tx_store.go -pkg mocks -skip-ensure . TxStore
 //go:generate moq -out mocks/subscriptions_store.go -pkg mocks -skip-ensure . SubscriptionStore
+//go:generate moq -out mocks/backfiller.go -pkg mocks -skip-ensure . Backfiller
+//go:generate moq -out mocks/abi_registry.go -pkg mocks -skip-ensure . ABIRegistry
+//go:generate moq -out mocks/indexer.go -pkg mocks -skip-ensure . Indexer
+//go:generate moq -out mocks/node.go -pkg mocks -skip-ensure . Node
+//go:generate moq -out mocks/health_registry.go -pkg mocks -skip-ensure . HealthRegistry
+
+// call calls the server Func with the request validated first, as FuncAdapter does.
+func call[Req, Resp any](ctx context.Context, f restapi.Func[Req, Resp], req *Req) (*Resp, error) {
+	err := restapi.Validate(req)
+	if err != nil {
+		return nil, err
+	}
+	return f(ctx, req)
+}
 
 func TestGetCurrentBlock(t *testing.T) {
 	tests := map[string]struct {
@@ -55,7 +81,7 @@ func TestGetCurrentBlock(t *testing.T) {
 					return *test.currentBlockNumber, nil
 				},
 			}
-			s := restapi.NewServer(logrus.New(), storeMock, nil)
+			s := restapi.NewServer(logging.Logrus(logrus.New()), storeMock, nil)
 			resp, err := s.GetCurrentBlock(context.Background(), test.req)
 			assert.Equal(t, test.expectedStoreCalls, len(storeMock.GetCurrentBlockNumberCalls()))
 			if test.expectedErr != nil {
@@ -77,7 +103,9 @@ func TestGetCurrentBlock(t *testing.T) {
 func TestSubscribe(t *testing.T) {
 	tests := map[string]struct {
 		req                *restapi.SubscribeRequest
+		existingSub        *store.Subscription
 		storeErr           error
+		expectedSub        *store.Subscription
 		expectedStoreCalls int
 		expectedResp       *restapi.SubscribeResponse
 		expectedErr        *restapi.Err
@@ -86,9 +114,360 @@ func TestSubscribe(t *testing.T) {
 			req: &restapi.SubscribeRequest{
 				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
 			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+			},
 			expectedStoreCalls: 1,
 			expectedResp: &restapi.SubscribeResponse{
-				Ok: true,
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"decimal min value": {
+			req: &restapi.SubscribeRequest{
+				Address:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				MinValue: "1000000000000000000",
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				MinValue:   big.NewInt(1000000000000000000),
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"hex min value": {
+			req: &restapi.SubscribeRequest{
+				Address:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				MinValue: "0xde0b6b3a7640000",
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				MinValue:   big.NewInt(1000000000000000000),
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"skip failed": {
+			req: &restapi.SubscribeRequest{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				SkipFailed: true,
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+				SkipFailed: true,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"filter": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Filter:  `value > 1e18 && method == "swapExactETHForTokens"`,
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+				Filter:     `value > 1e18 && method == "swapExactETHForTokens"`,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"chat channel": {
+			req: &restapi.SubscribeRequest{
+				Address:     "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				ChatChannel: "telegram:123456:ABC@-100",
+			},
+			expectedSub: &store.Subscription{
+				Address:     "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock:  42,
+				Mode:        store.SubscriptionModeLive,
+				ChatChannel: "telegram:123456:ABC@-100",
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"invalid chat channel": {
+			req: &restapi.SubscribeRequest{
+				Address:     "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				ChatChannel: "slack:http://hooks.slack.com/x",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidChatChannelMessage,
+			},
+		},
+		"email": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Email:   "alice@example.com",
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+				Email:      "alice@example.com",
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"priority": {
+			req: &restapi.SubscribeRequest{
+				Address:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Priority: true,
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+				Priority:   true,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"invalid email": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Email:   "Alice <alice@example.com>",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidEmailMessage,
+			},
+		},
+		"notification preferences": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Preferences: &restapi.NotificationPreferences{
+					Channels:   []string{"webhook", " email "},
+					MinValue:   "0x10",
+					QuietHours: &restapi.QuietHours{Start: "22:00", End: "07:00"},
+					Delivery:   "digest",
+				},
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+				Preferences: &store.NotificationPreferences{
+					Channels:   []string{"webhook", "email"},
+					MinValue:   big.NewInt(16),
+					QuietHours: &store.QuietHours{Start: "22:00", End: "07:00"},
+					Delivery:   store.NotificationDeliveryDigest,
+				},
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"invalid quiet hours": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Preferences: &restapi.NotificationPreferences{
+					QuietHours: &restapi.QuietHours{Start: "22:00", End: "7pm"},
+				},
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    `Invalid quiet hours: invalid end: expected an HH:MM time: "7pm"`,
+			},
+		},
+		"invalid notification delivery": {
+			req: &restapi.SubscribeRequest{
+				Address:     "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Preferences: &restapi.NotificationPreferences{Delivery: "weekly"},
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidDeliveryMessage,
+			},
+		},
+		"invalid filter": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Filter:  `value > "1"`,
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid filter: invalid expression: cannot compare a number with a string at position 6",
+			},
+		},
+		"negative min value": {
+			req: &restapi.SubscribeRequest{
+				Address:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				MinValue: "-1",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidMinValueMessage,
+			},
+		},
+		"webhook with secret": {
+			req: &restapi.SubscribeRequest{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "s3cret",
+			},
+			expectedSub: &store.Subscription{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "s3cret",
+				WebhookKeyID:  "v1",
+				StartBlock:    42,
+				Mode:          store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:            true,
+				StartBlock:    42,
+				WebhookSecret: "s3cret",
+				WebhookKeyID:  "v1",
+			},
+		},
+		"re-subscribing with the same webhook secret keeps its keys": {
+			req: &restapi.SubscribeRequest{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "s3cret",
+			},
+			existingSub: &store.Subscription{
+				Address:            "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock:         7,
+				WebhookURL:         "https://example.com/hooks/eth",
+				WebhookSecret:      "s3cret",
+				WebhookKeyID:       "v2",
+				PreviousWebhookKey: &store.WebhookKey{ID: "v1", Secret: "old", ExpiresAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+			},
+			expectedSub: &store.Subscription{
+				Address:            "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:         "https://example.com/hooks/eth",
+				WebhookSecret:      "s3cret",
+				WebhookKeyID:       "v2",
+				PreviousWebhookKey: &store.WebhookKey{ID: "v1", Secret: "old", ExpiresAt: time.Date(2030, 1, 1, 0, 0, 0, 0, time.UTC)},
+				StartBlock:         7,
+				Mode:               store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:            true,
+				StartBlock:    7,
+				WebhookSecret: "s3cret",
+				WebhookKeyID:  "v2",
+			},
+		},
+		"re-subscribing with a new webhook secret": {
+			req: &restapi.SubscribeRequest{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "n3w",
+			},
+			existingSub: &store.Subscription{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock:    7,
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "s3cret",
+				WebhookKeyID:  "v2",
+			},
+			expectedSub: &store.Subscription{
+				Address:       "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "n3w",
+				WebhookKeyID:  "v3",
+				StartBlock:    7,
+				Mode:          store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:            true,
+				StartBlock:    7,
+				WebhookSecret: "n3w",
+				WebhookKeyID:  "v3",
+			},
+		},
+		"history mode": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Mode:    "history",
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeHistory,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 42,
+			},
+		},
+		"re-subscribe keeps start block": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			},
+			existingSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 7,
+			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 7,
+				Mode:       store.SubscriptionModeLive,
+			},
+			expectedStoreCalls: 1,
+			expectedResp: &restapi.SubscribeResponse{
+				Ok:         true,
+				StartBlock: 7,
+			},
+		},
+		"invalid mode": {
+			req: &restapi.SubscribeRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Mode:    "backwards",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidModeMessage,
+			},
+		},
+		"invalid webhook url": {
+			req: &restapi.SubscribeRequest{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				WebhookURL: "ftp://example.com/hooks/eth",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidWebhookURLMessage,
 			},
 		},
 		"empty address": {
@@ -122,6 +501,11 @@ func TestSubscribe(t *testing.T) {
 			req: &restapi.SubscribeRequest{
 				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
 			},
+			expectedSub: &store.Subscription{
+				Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				StartBlock: 42,
+				Mode:       store.SubscriptionModeLive,
+			},
 			expectedStoreCalls: 1,
 			storeErr:           errors.New("dummy error"),
 			expectedErr: &restapi.Err{
@@ -134,12 +518,19 @@ func TestSubscribe(t *testing.T) {
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
 			storeMock := &mocks.SubscriptionStoreMock{
-				AddSubscriptionFunc: func(ctx context.Context, addr string) error {
+				AddSubscriptionFunc: func(ctx context.Context, sub *store.Subscription) error {
+					assert.Equal(t, test.expectedSub, sub)
 					return test.storeErr
 				},
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if test.existingSub == nil {
+						return nil, store.ErrNotFound
+					}
+					return test.existingSub, nil
+				},
 			}
-			s := restapi.NewServer(logrus.New(), nil, storeMock)
-			resp, err := s.Subscribe(context.Background(), test.req)
+			s := restapi.NewServer(logging.Logrus(logrus.New()), currentBlockTxStore(41), storeMock)
+			resp, err := call(context.Background(), s.Subscribe, test.req)
 			assert.Equal(t, test.expectedStoreCalls, len(storeMock.AddSubscriptionCalls()))
 			if test.expectedErr != nil {
 				require.Error(t, err)
@@ -157,129 +548,859 @@ func TestSubscribe(t *testing.T) {
 	}
 }
 
-func TestGetTransactions(t *testing.T) {
+func TestListSubscriptions(t *testing.T) {
 	tests := map[string]struct {
-		req                               *restapi.ListTransactionsRequest
-		storeErr                          error
-		storeResp                         []*store.TxRecord
-		subscribedAddresses               []string
-		expectedStoreGetTransactionsCalls int
-		expectedStoreIsSubscribedCalls    int
-		expectedResp                      *restapi.ListTransactionsResponse
-		expectedErr                       *restapi.Err
+		req           *restapi.ListSubscriptionRequest
+		expectedQuery *store.SubscriptionQuery
+		expectedResp  *restapi.ListSubscriptionResponse
+		expectedErr   *restapi.Err
 	}{
-		"success": {
-			req: &restapi.ListTransactionsRequest{
-				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-			},
-			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
-			storeResp: []*store.TxRecord{
-				{
-					Hash:        "hash-1",
-					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-					To:          "to-1",
-					BlockNumber: 1,
-					BlockHash:   "block-hash-1",
-					Raw:         []byte(`{"key": "value-1"}`),
-				},
-				{
-					Hash:        "hash-2",
-					From:        "from-2",
-					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-					BlockNumber: 2,
-					BlockHash:   "block-hash-2",
-					Raw:         []byte(`{"key": "value-2"}`),
-				},
-			},
-			expectedStoreGetTransactionsCalls: 1,
-			expectedStoreIsSubscribedCalls:    1,
-			expectedResp: &restapi.ListTransactionsResponse{
-				Transactions: []*restapi.Transaction{
-					{
-						Hash:           "hash-1",
-						From:           "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-						To:             "to-1",
-						BlockNumber:    "0x1",
-						BlockNumberInt: 1,
-						BlockHash:      "block-hash-1",
-						FullTx:         map[string]any{"key": "value-1"},
-					},
-					{
-						Hash:           "hash-2",
-						From:           "from-2",
-						To:             "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-						BlockNumber:    "0x2",
-						BlockNumberInt: 2,
-						BlockHash:      "block-hash-2",
-						FullTx:         map[string]any{"key": "value-2"},
-					},
-				},
-			},
+		"all subscriptions": {
+			req:          &restapi.ListSubscriptionRequest{},
+			expectedResp: &restapi.ListSubscriptionResponse{Addresses: []string{"0xall"}},
 		},
-		"empty address": {
-			req: &restapi.ListTransactionsRequest{
-				Address: " ",
-			},
-			expectedErr: &restapi.Err{
-				StatusCode: http.StatusBadRequest,
-				Message:    "Missing required field: 'address'",
-			},
+		"search by address prefix without 0x": {
+			req:           &restapi.ListSubscriptionRequest{Query: " 7A25 "},
+			expectedQuery: &store.SubscriptionQuery{AddressPrefix: "0x7a25"},
+			expectedResp:  &restapi.ListSubscriptionResponse{Addresses: []string{"0xfound"}},
 		},
-		"invalid hex address": {
-			req: &restapi.ListTransactionsRequest{
-				Address: "0xZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ",
-			},
-			expectedErr: &restapi.Err{
-				StatusCode: http.StatusBadRequest,
-				Message:    restapi.InvalidAddrMessage,
-			},
+		"search by label": {
+			req:           &restapi.ListSubscriptionRequest{Label: "exchange"},
+			expectedQuery: &store.SubscriptionQuery{AddressPrefix: "0x", Label: "exchange"},
+			expectedResp:  &restapi.ListSubscriptionResponse{Addresses: []string{"0xfound"}},
 		},
-		"store failure": {
-			req: &restapi.ListTransactionsRequest{
-				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
-			},
-			expectedStoreGetTransactionsCalls: 1,
-			expectedStoreIsSubscribedCalls:    1,
-			subscribedAddresses:               []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
-			storeErr:                          errors.New("dummy error"),
+		"invalid address prefix": {
+			req: &restapi.ListSubscriptionRequest{Query: "0x7z"},
 			expectedErr: &restapi.Err{
-				StatusCode: http.StatusInternalServerError,
-				Message:    "Could not list transactions from store",
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidQueryMessage,
 			},
 		},
 	}
 
 	for name, test := range tests {
 		t.Run(name, func(t *testing.T) {
-			txStoreMock := &mocks.TxStoreMock{
-				GetTransactionsFunc: func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
-					assert.Equal(t, test.req.Address, addr)
-					return test.storeResp, test.storeErr
+			storeMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionsFunc: func(ctx context.Context) ([]string, error) {
+					return []string{"0xall"}, nil
 				},
-			}
-			subsStoreMock := &mocks.SubscriptionStoreMock{
-				IsSubscribedFunc: func(ctx context.Context, addr string) (bool, error) {
-					assert.Equal(t, test.req.Address, addr)
-					ok := slices.Contains(test.subscribedAddresses, addr)
-					return ok, nil
+				SearchSubscriptionsFunc: func(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+					assert.Equal(t, test.expectedQuery, query)
+					return []*store.Subscription{{Address: "0xfound"}}, nil
 				},
 			}
-			s := restapi.NewServer(logrus.New(), txStoreMock, subsStoreMock)
-			resp, err := s.ListTransactions(context.Background(), test.req)
-			assert.Equal(t, test.expectedStoreGetTransactionsCalls, len(txStoreMock.GetTransactionsCalls()))
-			assert.Equal(t, test.expectedStoreIsSubscribedCalls, len(subsStoreMock.IsSubscribedCalls()))
+			s := restapi.NewServer(logging.Logrus(logrus.New()), currentBlockTxStore(41), storeMock)
+			resp, err := call(context.Background(), s.ListSubscriptions, test.req)
 			if test.expectedErr != nil {
-				require.Error(t, err)
 				castedErr := &restapi.Err{}
-				if errors.As(err, &castedErr) {
-					assert.Equal(t, test.expectedErr, castedErr)
-					return
-				}
-				assert.Equal(t, test.expectedErr.Message, err.Error())
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
 				return
 			}
 			require.NoError(t, err)
-
+			assert.Equal(t, test.expectedResp, resp)
+			if test.expectedQuery == nil {
+				assert.Empty(t, storeMock.SearchSubscriptionsCalls())
+			}
+		})
+	}
+}
+
+func TestUnsubscribe(t *testing.T) {
+	const addr = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	tests := map[string]struct {
+		req          *restapi.UnsubscribeRequest
+		storeErr     error
+		expectedResp *restapi.UnsubscribeResponse
+		expectedErr  *restapi.Err
+	}{
+		"success": {
+			req:          &restapi.UnsubscribeRequest{Address: "0x7A250D5630B4CF539739DF2C5DACB4C659F2488D"},
+			expectedResp: &restapi.UnsubscribeResponse{Ok: true},
+		},
+		"not subscribed": {
+			req:      &restapi.UnsubscribeRequest{Address: addr},
+			storeErr: store.ErrNotFound,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Address not subscribed",
+			},
+		},
+		"store failure": {
+			req:      &restapi.UnsubscribeRequest{Address: addr},
+			storeErr: errors.New("dummy error"),
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "could not remove address subscription from store",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.SubscriptionStoreMock{
+				RemoveSubscriptionFunc: func(ctx context.Context, addr string) error {
+					return test.storeErr
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), nil, storeMock)
+			resp, err := call(context.Background(), s.Unsubscribe, test.req)
+			require.Len(t, storeMock.RemoveSubscriptionCalls(), 1)
+			assert.Equal(t, addr, storeMock.RemoveSubscriptionCalls()[0].Addr)
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestRotateWebhookSecret(t *testing.T) {
+	const addr = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	subscribed := &store.Subscription{
+		Address:       addr,
+		StartBlock:    7,
+		WebhookURL:    "https://example.com/hooks/eth",
+		WebhookSecret: "s3cret",
+		WebhookKeyID:  "v1",
+	}
+
+	tests := map[string]struct {
+		req                *restapi.RotateWebhookSecretRequest
+		existingSub        *store.Subscription
+		getErr             error
+		expectedSub        *store.Subscription
+		expectedGrace      time.Duration
+		expectedStoreCalls int
+		expectedErr        *restapi.Err
+	}{
+		"default grace period": {
+			req:         &restapi.RotateWebhookSecretRequest{Address: "0x7A250D5630B4CF539739DF2C5DACB4C659F2488D", WebhookSecret: "n3w"},
+			existingSub: subscribed,
+			expectedSub: &store.Subscription{
+				Address:            addr,
+				StartBlock:         7,
+				WebhookURL:         "https://example.com/hooks/eth",
+				WebhookSecret:      "n3w",
+				WebhookKeyID:       "v2",
+				PreviousWebhookKey: &store.WebhookKey{ID: "v1", Secret: "s3cret"},
+			},
+			expectedGrace:      24 * time.Hour,
+			expectedStoreCalls: 1,
+		},
+		"custom grace period": {
+			req:         &restapi.RotateWebhookSecretRequest{Address: addr, WebhookSecret: "n3w", GracePeriod: "1h"},
+			existingSub: subscribed,
+			expectedSub: &store.Subscription{
+				Address:            addr,
+				StartBlock:         7,
+				WebhookURL:         "https://example.com/hooks/eth",
+				WebhookSecret:      "n3w",
+				WebhookKeyID:       "v2",
+				PreviousWebhookKey: &store.WebhookKey{ID: "v1", Secret: "s3cret"},
+			},
+			expectedGrace:      time.Hour,
+			expectedStoreCalls: 1,
+		},
+		"no grace period": {
+			req:         &restapi.RotateWebhookSecretRequest{Address: addr, WebhookSecret: "n3w", GracePeriod: "0s"},
+			existingSub: subscribed,
+			expectedSub: &store.Subscription{
+				Address:       addr,
+				StartBlock:    7,
+				WebhookURL:    "https://example.com/hooks/eth",
+				WebhookSecret: "n3w",
+				WebhookKeyID:  "v2",
+			},
+			expectedStoreCalls: 1,
+		},
+		"invalid grace period": {
+			req:         &restapi.RotateWebhookSecretRequest{Address: addr, GracePeriod: "-1h"},
+			existingSub: subscribed,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidGracePeriodMessage,
+			},
+		},
+		"no webhook": {
+			req:         &restapi.RotateWebhookSecretRequest{Address: addr},
+			existingSub: &store.Subscription{Address: addr},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "No webhook configured for this address",
+			},
+		},
+		"not subscribed": {
+			req:    &restapi.RotateWebhookSecretRequest{Address: addr},
+			getErr: store.ErrNotFound,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Address not subscribed",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, a string) (*store.Subscription, error) {
+					assert.Equal(t, addr, a)
+					return test.existingSub, test.getErr
+				},
+				AddSubscriptionFunc: func(ctx context.Context, sub *store.Subscription) error {
+					return nil
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), nil, storeMock)
+			resp, err := call(context.Background(), s.RotateWebhookSecret, test.req)
+			require.Len(t, storeMock.AddSubscriptionCalls(), test.expectedStoreCalls)
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+			require.NoError(t, err)
+
+			stored := storeMock.AddSubscriptionCalls()[0].Sub
+			expectedResp := &restapi.RotateWebhookSecretResponse{
+				WebhookSecret: test.expectedSub.WebhookSecret,
+				WebhookKeyID:  test.expectedSub.WebhookKeyID,
+			}
+			if previous := stored.PreviousWebhookKey; previous != nil {
+				assert.WithinDuration(t, time.Now().Add(test.expectedGrace), previous.ExpiresAt, 2*time.Second)
+				expectedResp.PreviousKeyID = previous.ID
+				expectedResp.PreviousExpiresAt = previous.ExpiresAt.Format(time.RFC3339)
+				// the expiry is checked above
+				previous.ExpiresAt = time.Time{}
+			}
+			assert.Equal(t, test.expectedSub, stored)
+			assert.Equal(t, expectedResp, resp)
+			assert.Equal(t, "s3cret", test.existingSub.WebhookSecret, "the stored subscription isn't modified in place")
+		})
+	}
+}
+
+func TestSubscribeGeneratesWebhookSecret(t *testing.T) {
+	var stored *store.Subscription
+	storeMock := &mocks.SubscriptionStoreMock{
+		AddSubscriptionFunc: func(ctx context.Context, sub *store.Subscription) error {
+			stored = sub
+			return nil
+		},
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			return nil, store.ErrNotFound
+		},
+	}
+	s := restapi.NewServer(logging.Logrus(logrus.New()), currentBlockTxStore(41), storeMock)
+	resp, err := s.Subscribe(context.Background(), &restapi.SubscribeRequest{
+		Address:    "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+		WebhookURL: "https://example.com/hooks/eth",
+	})
+	require.NoError(t, err)
+	require.NotNil(t, stored)
+	assert.Len(t, resp.WebhookSecret, 64)
+	assert.Equal(t, stored.WebhookSecret, resp.WebhookSecret)
+}
+
+func TestSubscribeBackfill(t *testing.T) {
+	tests := map[string]struct {
+		req                   *restapi.SubscribeRequest
+		backfillErr           error
+		expectedMode          store.SubscriptionMode
+		expectedStoreCalls    int
+		expectedBackfillCalls int
+		expectedFromBlock     int64
+		expectedToBlock       int64
+		expectedErr           *restapi.Err
+	}{
+		"backfill blocks": {
+			req: &restapi.SubscribeRequest{
+				Address:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				BackfillBlocks: 10,
+			},
+			expectedMode:          store.SubscriptionModeHistory,
+			expectedStoreCalls:    1,
+			expectedBackfillCalls: 1,
+			expectedFromBlock:     32,
+			expectedToBlock:       41,
+		},
+		"backfill from block": {
+			req: &restapi.SubscribeRequest{
+				Address:      "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				BackfillFrom: ptr[int64](40),
+			},
+			expectedMode:          store.SubscriptionModeHistory,
+			expectedStoreCalls:    1,
+			expectedBackfillCalls: 1,
+			expectedFromBlock:     40,
+			expectedToBlock:       41,
+		},
+		"backfill from after start block": {
+			req: &restapi.SubscribeRequest{
+				Address:      "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				BackfillFrom: ptr[int64](42),
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidBackfillMessage,
+			},
+		},
+		"backfill in live mode": {
+			req: &restapi.SubscribeRequest{
+				Address:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Mode:           "live",
+				BackfillBlocks: 10,
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidBackfillMessage,
+			},
+		},
+		"too many blocks": {
+			req: &restapi.SubscribeRequest{
+				Address:      "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				BackfillFrom: ptr[int64](0),
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Too many blocks to backfill, at most 20 blocks can be backfilled",
+			},
+		},
+		"backfill in progress": {
+			req: &restapi.SubscribeRequest{
+				Address:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				BackfillBlocks: 10,
+			},
+			backfillErr:           backfill.ErrInProgress,
+			expectedMode:          store.SubscriptionModeHistory,
+			expectedStoreCalls:    1,
+			expectedBackfillCalls: 1,
+			expectedFromBlock:     32,
+			expectedToBlock:       41,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusConflict,
+				Message:    "A backfill is already in progress for this address",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.SubscriptionStoreMock{
+				AddSubscriptionFunc: func(ctx context.Context, sub *store.Subscription) error {
+					assert.Equal(t, test.expectedMode, sub.Mode)
+					return nil
+				},
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					return nil, store.ErrNotFound
+				},
+			}
+			backfillerMock := &mocks.BackfillerMock{
+				BackfillFunc: func(ctx context.Context, sub *store.Subscription, fromBlock, toBlock int64) error {
+					assert.Equal(t, test.expectedFromBlock, fromBlock)
+					assert.Equal(t, test.expectedToBlock, toBlock)
+					return test.backfillErr
+				},
+				MaxBlocksFunc: func() int64 {
+					return 20
+				},
+				ProgressFunc: func(addr string) (backfill.Progress, bool) {
+					return backfill.Progress{
+						FromBlock: test.expectedFromBlock,
+						ToBlock:   test.expectedToBlock,
+						NextBlock: test.expectedToBlock,
+						Status:    backfill.StatusQueued,
+					}, true
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), currentBlockTxStore(41), storeMock, restapi.WithBackfiller(backfillerMock))
+			resp, err := call(context.Background(), s.Subscribe, test.req)
+			assert.Equal(t, test.expectedStoreCalls, len(storeMock.AddSubscriptionCalls()))
+			assert.Equal(t, test.expectedBackfillCalls, len(backfillerMock.BackfillCalls()))
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			require.NotNil(t, resp.Backfill)
+			assert.Equal(t, test.expectedFromBlock, resp.Backfill.FromBlock)
+			assert.Equal(t, test.expectedToBlock, resp.Backfill.ToBlock)
+			assert.Equal(t, string(backfill.StatusQueued), resp.Backfill.Status)
+		})
+	}
+}
+
+func TestSubscribeEvents(t *testing.T) {
+	tests := map[string]struct {
+		req                *restapi.SubscribeEventsRequest
+		expectedStoreCalls int
+		expectedSub        *store.EventSubscription
+		expectedResp       *restapi.SubscribeEventsResponse
+		expectedErr        *restapi.Err
+	}{
+		"event signature and wildcard topics": {
+			req: &restapi.SubscribeEventsRequest{
+				Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+				Topics:  []string{"Transfer(address,address,uint256)", "", "0x0000000000000000000000007A250D5630B4CF539739DF2C5DACB4C659F2488D"},
+			},
+			expectedStoreCalls: 1,
+			expectedSub: &store.EventSubscription{
+				Contract: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Topics: []string{
+					"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+					"",
+					"0x0000000000000000000000007a250d5630b4cf539739df2c5dacb4c659f2488d",
+				},
+			},
+			expectedResp: &restapi.SubscribeEventsResponse{
+				Ok: true,
+				Topics: []string{
+					"0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+					"",
+					"0x0000000000000000000000007a250d5630b4cf539739df2c5dacb4c659f2488d",
+				},
+			},
+		},
+		"all events of a contract": {
+			req: &restapi.SubscribeEventsRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			},
+			expectedStoreCalls: 1,
+			expectedSub: &store.EventSubscription{
+				Contract: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Topics:   []string{},
+			},
+			expectedResp: &restapi.SubscribeEventsResponse{
+				Ok:     true,
+				Topics: []string{},
+			},
+		},
+		"invalid topic": {
+			req: &restapi.SubscribeEventsRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Topics:  []string{"0x1234"},
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTopicMessage,
+			},
+		},
+		"too many topics": {
+			req: &restapi.SubscribeEventsRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Topics:  []string{"", "", "", "", ""},
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid 'topics', it must have at most 4 items",
+			},
+		},
+		"invalid contract address": {
+			req: &restapi.SubscribeEventsRequest{
+				Address: "0x1234",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.SubscriptionStoreMock{
+				AddEventSubscriptionFunc: func(ctx context.Context, sub *store.EventSubscription) error {
+					assert.Equal(t, test.expectedSub, sub)
+					return nil
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), nil, storeMock)
+			resp, err := call(context.Background(), s.SubscribeEvents, test.req)
+			assert.Equal(t, test.expectedStoreCalls, len(storeMock.AddEventSubscriptionCalls()))
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestGetTransactions(t *testing.T) {
+	tests := map[string]struct {
+		req                               *restapi.ListTransactionsRequest
+		storeErr                          error
+		storeResp                         []*store.TxRecord
+		subscribedAddresses               []string
+		startBlock                        int64
+		expectedStoreGetTransactionsCalls int
+		expectedStoreGetSubscriptionCalls int
+		expectedResp                      *restapi.ListTransactionsResponse
+		expectedErr                       *restapi.Err
+	}{
+		"success": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			},
+			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			storeResp: []*store.TxRecord{
+				{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "to-1",
+					BlockNumber: 1,
+					BlockHash:   "block-hash-1",
+					Raw:         []byte(`{"key": "value-1"}`),
+				},
+				{
+					Hash:        "hash-2",
+					From:        "from-2",
+					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber: 2,
+					BlockHash:   "block-hash-2",
+					Raw:         []byte(`{"key": "value-2"}`),
+					DecodedInput: &store.DecodedInput{
+						Method:    "approve",
+						Signature: "approve(address,uint256)",
+						Args: []*store.DecodedArg{
+							{Name: "spender", Type: "address", Value: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+							{Name: "amount", Type: "uint256", Value: "1"},
+						},
+					},
+				},
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			expectedResp: &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{
+					{
+						Hash:           "hash-1",
+						From:           "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						To:             "to-1",
+						BlockNumber:    "0x1",
+						BlockNumberInt: 1,
+						BlockHash:      "block-hash-1",
+						FullTx:         map[string]any{"key": "value-1"},
+						Confirmations:  2,
+					},
+					{
+						Hash:           "hash-2",
+						From:           "from-2",
+						To:             "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						BlockNumber:    "0x2",
+						BlockNumberInt: 2,
+						BlockHash:      "block-hash-2",
+						FullTx:         map[string]any{"key": "value-2"},
+						Confirmations:  1,
+						DecodedInput: &restapi.DecodedInput{
+							Method:    "approve",
+							Signature: "approve(address,uint256)",
+							Args: []*restapi.DecodedArg{
+								{Name: "spender", Type: "address", Value: "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"},
+								{Name: "amount", Type: "uint256", Value: "1"},
+							},
+						},
+					},
+				},
+			},
+		},
+		"transactions before start block": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			},
+			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			startBlock:          2,
+			storeResp: []*store.TxRecord{
+				{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "to-1",
+					BlockNumber: 1,
+					BlockHash:   "block-hash-1",
+					Raw:         []byte(`{"key": "value-1"}`),
+				},
+				{
+					Hash:        "hash-2",
+					From:        "from-2",
+					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber: 2,
+					BlockHash:   "block-hash-2",
+					Raw:         []byte(`{"key": "value-2"}`),
+				},
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			expectedResp: &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{
+					{
+						Hash:           "hash-2",
+						From:           "from-2",
+						To:             "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						BlockNumber:    "0x2",
+						BlockNumberInt: 2,
+						BlockHash:      "block-hash-2",
+						FullTx:         map[string]any{"key": "value-2"},
+						Confirmations:  1,
+					},
+				},
+			},
+		},
+		"removed transactions have no confirmations": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			},
+			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			storeResp: []*store.TxRecord{
+				{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "to-1",
+					BlockNumber: 1,
+					BlockHash:   "block-hash-1",
+					Raw:         []byte(`{"key": "value-1"}`),
+					Removed:     true,
+				},
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			expectedResp: &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{
+					{
+						Hash:           "hash-1",
+						From:           "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						To:             "to-1",
+						BlockNumber:    "0x1",
+						BlockNumberInt: 1,
+						BlockHash:      "block-hash-1",
+						FullTx:         map[string]any{"key": "value-1"},
+						Removed:        true,
+					},
+				},
+			},
+		},
+		"transactions of a type": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Type:    "EIP1559",
+			},
+			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			storeResp: []*store.TxRecord{
+				{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "to-1",
+					BlockNumber: 1,
+					BlockHash:   "block-hash-1",
+					Raw:         []byte(`{"key": "value-1"}`),
+					Type:        store.TxTypeLegacy,
+				},
+				{
+					Hash:        "hash-2",
+					From:        "from-2",
+					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber: 2,
+					BlockHash:   "block-hash-2",
+					Raw:         []byte(`{"key": "value-2"}`),
+					Type:        store.TxTypeDynamicFee,
+				},
+				{
+					Hash:        "hash-3",
+					From:        "from-3",
+					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber: 2,
+					BlockHash:   "block-hash-2",
+					Raw:         []byte(`{"key": "value-3"}`),
+				},
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			expectedResp: &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{
+					{
+						Hash:           "hash-2",
+						From:           "from-2",
+						To:             "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						BlockNumber:    "0x2",
+						BlockNumberInt: 2,
+						BlockHash:      "block-hash-2",
+						FullTx:         map[string]any{"key": "value-2"},
+						Confirmations:  1,
+						Type:           "eip1559",
+					},
+				},
+			},
+		},
+		"transactions in a time range": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Since:   "2024-03-01T00:00:00Z",
+				Until:   "2024-03-08T00:00:00Z",
+			},
+			subscribedAddresses: []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			storeResp: []*store.TxRecord{
+				{
+					Hash:           "hash-1",
+					From:           "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:             "to-1",
+					BlockNumber:    1,
+					BlockHash:      "block-hash-1",
+					BlockTimestamp: time.Date(2024, 2, 29, 23, 59, 59, 0, time.UTC).Unix(),
+					Raw:            []byte(`{"key": "value-1"}`),
+				},
+				{
+					Hash:           "hash-2",
+					From:           "from-2",
+					To:             "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber:    2,
+					BlockHash:      "block-hash-2",
+					BlockTimestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix(),
+					Raw:            []byte(`{"key": "value-2"}`),
+				},
+				{
+					Hash:           "hash-3",
+					From:           "from-3",
+					To:             "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber:    2,
+					BlockHash:      "block-hash-2",
+					BlockTimestamp: time.Date(2024, 3, 8, 0, 0, 0, 0, time.UTC).Unix(),
+					Raw:            []byte(`{"key": "value-3"}`),
+				},
+				{
+					// unknown block time
+					Hash:        "hash-4",
+					From:        "from-4",
+					To:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					BlockNumber: 2,
+					BlockHash:   "block-hash-2",
+					Raw:         []byte(`{"key": "value-4"}`),
+				},
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			expectedResp: &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{
+					{
+						Hash:           "hash-2",
+						From:           "from-2",
+						To:             "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+						BlockNumber:    "0x2",
+						BlockNumberInt: 2,
+						BlockHash:      "block-hash-2",
+						BlockTimestamp: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC).Unix(),
+						FullTx:         map[string]any{"key": "value-2"},
+						Confirmations:  1,
+					},
+				},
+			},
+		},
+		"invalid time range": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Until:   "last week",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTimeRangeMessage,
+			},
+		},
+		"invalid type": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				Type:    "eip4844",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTxTypeMessage,
+			},
+		},
+		"empty address": {
+			req: &restapi.ListTransactionsRequest{
+				Address: " ",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Missing required field: 'address'",
+			},
+		},
+		"invalid hex address": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0xZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZZ",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+		"store failure": {
+			req: &restapi.ListTransactionsRequest{
+				Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			},
+			expectedStoreGetTransactionsCalls: 1,
+			expectedStoreGetSubscriptionCalls: 1,
+			subscribedAddresses:               []string{"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},
+			storeErr:                          errors.New("dummy error"),
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Could not list transactions from store",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := &mocks.TxStoreMock{
+				GetTransactionsFunc: func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
+					assert.Equal(t, test.req.Address, addr)
+					return test.storeResp, test.storeErr
+				},
+				GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+					return 2, nil
+				},
+			}
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					assert.Equal(t, test.req.Address, addr)
+					if !slices.Contains(test.subscribedAddresses, addr) {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr, StartBlock: test.startBlock}, nil
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+			resp, err := call(context.Background(), s.ListTransactions, test.req)
+			assert.Equal(t, test.expectedStoreGetTransactionsCalls, len(txStoreMock.GetTransactionsCalls()))
+			assert.Equal(t, test.expectedStoreGetSubscriptionCalls, len(subsStoreMock.GetSubscriptionCalls()))
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+			require.NoError(t, err)
+
 			require.NotNil(t, resp)
 			assert.Equal(t, len(test.expectedResp.Transactions), len(resp.Transactions))
 			for i, expected := range test.expectedResp.Transactions {
@@ -289,6 +1410,805 @@ func TestGetTransactions(t *testing.T) {
 	}
 }
 
-func ptr[T any](v T) *T {
-	return &v
+func currentBlockTxStore(blockNumber int64) *mocks.TxStoreMock {
+	return &mocks.TxStoreMock{
+		GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+			return blockNumber, nil
+		},
+	}
+}
+
+func ptr[T any](v T) *T {
+	return &v
+}
+
+func TestRegisterABI(t *testing.T) {
+	tests := map[string]struct {
+		req                   *restapi.RegisterABIRequest
+		disabled              bool
+		registryErr           error
+		expectedRegistryCalls int
+		expectedResp          *restapi.RegisterABIResponse
+		expectedErr           *restapi.Err
+	}{
+		"success": {
+			req: &restapi.RegisterABIRequest{
+				Address: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+				ABI:     []byte(`[]`),
+			},
+			expectedRegistryCalls: 1,
+			expectedResp:          &restapi.RegisterABIResponse{Ok: true},
+		},
+		"invalid address": {
+			req: &restapi.RegisterABIRequest{
+				Address: "0x1234",
+				ABI:     []byte(`[]`),
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+		"input decoding disabled": {
+			req: &restapi.RegisterABIRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				ABI:     []byte(`[]`),
+			},
+			disabled: true,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Input decoding is not enabled",
+			},
+		},
+		"missing abi": {
+			req: &restapi.RegisterABIRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+			},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Missing required field: 'abi'",
+			},
+		},
+		"invalid abi": {
+			req: &restapi.RegisterABIRequest{
+				Address: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				ABI:     []byte(`{}`),
+			},
+			registryErr:           fmt.Errorf("%w: unexpected object", abi.ErrInvalidABI),
+			expectedRegistryCalls: 1,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid ABI: invalid abi: unexpected object",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registryMock := &mocks.ABIRegistryMock{
+				RegisterFunc: func(contract string, data []byte) error {
+					assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", contract)
+					return test.registryErr
+				},
+			}
+			var opts []restapi.Option
+			if !test.disabled {
+				opts = append(opts, restapi.WithABIRegistry(registryMock))
+			}
+
+			s := restapi.NewServer(logging.Logrus(logrus.New()), nil, nil, opts...)
+			resp, err := call(context.Background(), s.RegisterABI, test.req)
+			assert.Equal(t, test.expectedRegistryCalls, len(registryMock.RegisterCalls()))
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestListTransactionsIndexAll(t *testing.T) {
+	txStoreMock := &mocks.TxStoreMock{
+		GetTransactionsFunc: func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
+			return []*store.TxRecord{
+				{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "to-1",
+					BlockNumber: 1,
+					BlockHash:   "block-hash-1",
+					Raw:         []byte(`{"key": "value-1"}`),
+				},
+			}, nil
+		},
+		GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+			return 0, store.ErrNotFound
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{}
+
+	s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, restapi.WithIndexAll())
+	resp, err := s.ListTransactions(context.Background(), &restapi.ListTransactionsRequest{
+		Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+	})
+	require.NoError(t, err)
+	// the address isn't subscribed, nor is its subscription looked up
+	assert.Empty(t, subsStoreMock.GetSubscriptionCalls())
+	require.Len(t, resp.Transactions, 1)
+	assert.Equal(t, "hash-1", resp.Transactions[0].Hash)
+}
+
+func TestListTransactionsNDJSON(t *testing.T) {
+	tests := map[string]struct {
+		accept        string
+		raw           []byte
+		expectedType  string
+		expectedLines []string
+	}{
+		"streamed one transaction per line": {
+			accept:       "application/x-ndjson; q=1.0, application/json; q=0.5",
+			raw:          []byte(`{"key": "value-2"}`),
+			expectedType: restapi.ContentTypeNDJSON,
+			expectedLines: []string{
+				`{"hash":"hash-1","blockNumber":"0x1","blockNumberInt":1,"fullTx":{"key":"value-1"},"confirmations":2}`,
+				`{"hash":"hash-2","blockNumber":"0x2","blockNumberInt":2,"fullTx":{"key":"value-2"},"confirmations":1}`,
+			},
+		},
+		"stream ended by an error": {
+			accept:       restapi.ContentTypeNDJSON,
+			raw:          []byte(`not json`),
+			expectedType: restapi.ContentTypeNDJSON,
+			expectedLines: []string{
+				`{"hash":"hash-1","blockNumber":"0x1","blockNumberInt":1,"fullTx":{"key":"value-1"},"confirmations":2}`,
+				`{"error":"Could not unmarshal transaction"}`,
+			},
+		},
+		"json by default": {
+			raw:          []byte(`{"key": "value-2"}`),
+			expectedType: "application/json",
+			expectedLines: []string{
+				`{"transactions":[{"hash":"hash-1","blockNumber":"0x1","blockNumberInt":1,"fullTx":{"key":"value-1"},"confirmations":2},{"hash":"hash-2","blockNumber":"0x2","blockNumberInt":2,"fullTx":{"key":"value-2"},"confirmations":1}]}`,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := currentBlockTxStore(2)
+			txStoreMock.GetTransactionsFunc = func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
+				return []*store.TxRecord{
+					{Hash: "hash-1", BlockNumber: 1, Raw: []byte(`{"key": "value-1"}`)},
+					{Hash: "hash-2", BlockNumber: 2, Raw: test.raw},
+				}, nil
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, &mocks.SubscriptionStoreMock{}, restapi.WithIndexAll())
+			mux := http.NewServeMux()
+			restapi.RegisterFunc(logging.Logrus(logrus.New()), mux, http.MethodGet, "/transactions/{address}", s.ListTransactions)
+
+			req := httptest.NewRequest(http.MethodGet, "/transactions/0x7a250d5630b4cf539739df2c5dacb4c659f2488d", nil)
+			if test.accept != "" {
+				req.Header.Set("Accept", test.accept)
+			}
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, test.expectedType, rec.Header().Get("Content-Type"))
+			assert.Equal(t, test.expectedLines, strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n"))
+		})
+	}
+}
+
+func TestListTransactionsAddressCase(t *testing.T) {
+	tests := map[string]struct {
+		query          string
+		expectedStatus int
+		expectedBody   string
+	}{
+		"checksummed by default": {
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"transactions":[{"hash":"hash-1","from":"0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D","to":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","blockNumber":"0x1","blockNumberInt":1,"fullTx":{"from":"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},"decodedInput":{"method":"approve","signature":"approve(address,uint256)","args":[{"name":"spender","type":"address","value":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48"}]},"confirmations":1}]}`,
+		},
+		"lower-cased": {
+			query:          "?lowercase=true",
+			expectedStatus: http.StatusOK,
+			expectedBody:   `{"transactions":[{"hash":"hash-1","from":"0x7a250d5630b4cf539739df2c5dacb4c659f2488d","to":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48","blockNumber":"0x1","blockNumberInt":1,"fullTx":{"from":"0x7a250d5630b4cf539739df2c5dacb4c659f2488d"},"decodedInput":{"method":"approve","signature":"approve(address,uint256)","args":[{"name":"spender","type":"address","value":"0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"}]},"confirmations":1}]}`,
+		},
+		"invalid flag": {
+			query:          "?lowercase=maybe",
+			expectedStatus: http.StatusBadRequest,
+			expectedBody:   "Invalid 'lowercase' query param. Expected true or false.",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := currentBlockTxStore(1)
+			txStoreMock.GetTransactionsFunc = func(ctx context.Context, addr string) ([]*store.TxRecord, error) {
+				return []*store.TxRecord{{
+					Hash:        "hash-1",
+					From:        "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+					To:          "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+					BlockNumber: 1,
+					Raw:         []byte(`{"from": "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"}`),
+					DecodedInput: &store.DecodedInput{
+						Method:    "approve",
+						Signature: "approve(address,uint256)",
+						Args: []*store.DecodedArg{
+							{Name: "spender", Type: "address", Value: "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"},
+						},
+					},
+				}}, nil
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, &mocks.SubscriptionStoreMock{}, restapi.WithIndexAll())
+			mux := http.NewServeMux()
+			restapi.RegisterFunc(logging.Logrus(logrus.New()), mux, http.MethodGet, "/transactions/{address}", s.ListTransactions)
+
+			req := httptest.NewRequest(http.MethodGet, "/transactions/0x7a250d5630b4cf539739df2c5dacb4c659f2488d"+test.query, nil)
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedBody, strings.TrimSuffix(rec.Body.String(), "\n"))
+		})
+	}
+}
+
+func TestGetRawTransaction(t *testing.T) {
+	const (
+		hash = "0x88df016429689c079f3b2f6ad39fa052532c56795b733da78a91ebe6a713944b"
+		raw  = "0xf86c808504a817c800825208943535353535353535353535353535353535353535880de0b6b3a76400008025a0"
+	)
+	tests := map[string]struct {
+		req                 *restapi.GetRawTransactionRequest
+		cached              bool
+		node                bool
+		nodeErr             error
+		expectedResp        *restapi.GetRawTransactionResponse
+		expectedNodeCalls   int
+		expectedInsertCalls int
+		expectedErr         *restapi.Err
+	}{
+		"served from the store": {
+			req:          &restapi.GetRawTransactionRequest{Hash: hash},
+			cached:       true,
+			node:         true,
+			expectedResp: &restapi.GetRawTransactionResponse{Hash: hash, Raw: raw},
+		},
+		"fetched from the node and cached": {
+			req:                 &restapi.GetRawTransactionRequest{Hash: strings.ToUpper(hash[2:])},
+			node:                true,
+			expectedResp:        &restapi.GetRawTransactionResponse{Hash: hash, Raw: raw},
+			expectedNodeCalls:   1,
+			expectedInsertCalls: 1,
+		},
+		"unknown to the node": {
+			req:               &restapi.GetRawTransactionRequest{Hash: hash},
+			node:              true,
+			nodeErr:           eth.ErrTxNotFound,
+			expectedNodeCalls: 1,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Transaction not found",
+			},
+		},
+		"node failure": {
+			req:               &restapi.GetRawTransactionRequest{Hash: hash},
+			node:              true,
+			nodeErr:           errors.New("connection refused"),
+			expectedNodeCalls: 1,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadGateway,
+				Message:    "Could not get raw transaction from node",
+			},
+		},
+		"not following a node": {
+			req: &restapi.GetRawTransactionRequest{Hash: hash},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Transaction not found",
+			},
+		},
+		"invalid hash": {
+			req:  &restapi.GetRawTransactionRequest{Hash: "0x1234"},
+			node: true,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTxHashMessage,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := &mocks.TxStoreMock{
+				GetRawTransactionFunc: func(ctx context.Context, hash string) (string, error) {
+					if !test.cached {
+						return "", store.ErrNotFound
+					}
+					return raw, nil
+				},
+				InsertRawTransactionFunc: func(ctx context.Context, hash, raw string) error {
+					return nil
+				},
+			}
+			nodeMock := &mocks.NodeMock{
+				GetRawTransactionFunc: func(ctx context.Context, hash string) (string, error) {
+					if test.nodeErr != nil {
+						return "", test.nodeErr
+					}
+					return raw, nil
+				},
+			}
+			var opts []restapi.Option
+			if test.node {
+				opts = append(opts, restapi.WithNode(nodeMock))
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, nil, opts...)
+			resp, err := call(context.Background(), s.GetRawTransaction, test.req)
+			assert.Len(t, nodeMock.GetRawTransactionCalls(), test.expectedNodeCalls)
+			require.Len(t, txStoreMock.InsertRawTransactionCalls(), test.expectedInsertCalls)
+			if test.expectedInsertCalls > 0 {
+				assert.Equal(t, hash, txStoreMock.InsertRawTransactionCalls()[0].Hash)
+			}
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestHealth(t *testing.T) {
+	since := time.Date(2025, 5, 1, 12, 0, 0, 0, time.UTC)
+
+	tests := map[string]struct {
+		statuses           []health.Status
+		expectedResp       *restapi.HealthResponse
+		expectedStatusCode int
+	}{
+		"healthy": {
+			statuses: []health.Status{
+				{Component: health.Indexer, Healthy: true, Since: since},
+				{Component: health.Node, Healthy: true, Since: since, LastError: "timeout", LastErrorAt: since.Add(-time.Minute), Provider: "mainnet.infura.io"},
+			},
+			expectedResp: &restapi.HealthResponse{
+				Status: restapi.HealthStatusHealthy,
+				Components: []*restapi.ComponentHealth{
+					{Name: health.Indexer, Healthy: true, Since: "2025-05-01T12:00:00Z"},
+					{Name: health.Node, Healthy: true, Since: "2025-05-01T12:00:00Z", LastError: "timeout", LastErrorAt: "2025-05-01T11:59:00Z", Provider: "mainnet.infura.io"},
+				},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+		"unhealthy component": {
+			statuses: []health.Status{
+				{Component: health.Indexer, Healthy: true, Since: since},
+				{Component: health.Store, Healthy: false, Since: since, LastError: "deadline exceeded", LastErrorAt: since},
+			},
+			expectedResp: &restapi.HealthResponse{
+				Status: restapi.HealthStatusUnhealthy,
+				Components: []*restapi.ComponentHealth{
+					{Name: health.Indexer, Healthy: true, Since: "2025-05-01T12:00:00Z"},
+					{Name: health.Store, Healthy: false, Since: "2025-05-01T12:00:00Z", LastError: "deadline exceeded", LastErrorAt: "2025-05-01T12:00:00Z"},
+				},
+			},
+			expectedStatusCode: http.StatusServiceUnavailable,
+		},
+		"no components": {
+			expectedResp: &restapi.HealthResponse{
+				Status:     restapi.HealthStatusHealthy,
+				Components: []*restapi.ComponentHealth{},
+			},
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registryMock := &mocks.HealthRegistryMock{
+				StatusesFunc: func() []health.Status {
+					return test.statuses
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), nil, nil, restapi.WithHealth(registryMock))
+			resp, err := s.Health(context.Background(), &restapi.HealthRequest{})
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+			assert.Equal(t, test.expectedStatusCode, resp.StatusCode())
+		})
+	}
+}
+
+func TestReady(t *testing.T) {
+	tests := map[string]struct {
+		paused             bool
+		draining           bool
+		currentBlockNumber *int64
+		expectedResp       *restapi.ReadyResponse
+		expectedErr        *restapi.Err
+	}{
+		"ready": {
+			currentBlockNumber: ptr[int64](1234),
+			expectedResp:       &restapi.ReadyResponse{Status: "ready"},
+		},
+		"no blocks yet": {
+			expectedErr: &restapi.Err{
+				Message:    "No parsed blocks yet",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+		"indexing paused": {
+			paused:             true,
+			currentBlockNumber: ptr[int64](1234),
+			expectedErr: &restapi.Err{
+				Message:    "Indexing is paused",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+		"shutting down": {
+			draining:           true,
+			currentBlockNumber: ptr[int64](1234),
+			expectedErr: &restapi.Err{
+				Message:    "Shutting down",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.TxStoreMock{
+				GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+					if test.currentBlockNumber == nil {
+						return 0, store.ErrNotFound
+					}
+					return *test.currentBlockNumber, nil
+				},
+			}
+			indexerMock := &mocks.IndexerMock{
+				PausedFunc: func() bool {
+					return test.paused
+				},
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), storeMock, nil, restapi.WithIndexer(indexerMock))
+			if test.draining {
+				s.Drain()
+			}
+			resp, err := s.Ready(context.Background(), &restapi.ReadyRequest{})
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestGetAddressStats(t *testing.T) {
+	const (
+		addr        = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+		checksummed = "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D"
+	)
+
+	tests := map[string]struct {
+		req          *restapi.GetAddressStatsRequest
+		subscribed   bool
+		expectedResp *restapi.GetAddressStatsResponse
+		expectedErr  *restapi.Err
+	}{
+		"aggregated stats": {
+			req:        &restapi.GetAddressStatsRequest{Address: "0x7A250D5630B4CF539739DF2C5DACB4C659F2488D"},
+			subscribed: true,
+			expectedResp: &restapi.GetAddressStatsResponse{
+				Address: checksummed,
+				Last24h: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"},
+				Daily: []*restapi.DailyTxCounters{
+					{Day: "2024-03-01", TxCounters: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"}},
+					{Day: "2024-03-05", TxCounters: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"}},
+				},
+			},
+		},
+		"days since": {
+			req:        &restapi.GetAddressStatsRequest{Address: addr, Since: "2024-03-01T12:00:00Z"},
+			subscribed: true,
+			expectedResp: &restapi.GetAddressStatsResponse{
+				Address: checksummed,
+				Last24h: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"},
+				Daily: []*restapi.DailyTxCounters{
+					{Day: "2024-03-01", TxCounters: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"}},
+					{Day: "2024-03-05", TxCounters: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"}},
+				},
+				Range: &restapi.TxCounters{TxCount: 6, ValueIn: "2000", ValueOut: "0"},
+			},
+		},
+		"days until": {
+			req:        &restapi.GetAddressStatsRequest{Address: addr, Until: "2024-03-05T00:00:00Z"},
+			subscribed: true,
+			expectedResp: &restapi.GetAddressStatsResponse{
+				Address: checksummed,
+				Last24h: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"},
+				Daily: []*restapi.DailyTxCounters{
+					{Day: "2024-03-01", TxCounters: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"}},
+				},
+				Range: &restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"},
+			},
+		},
+		"no days in range": {
+			req:        &restapi.GetAddressStatsRequest{Address: addr, Since: "2024-03-02T00:00:00Z", Until: "2024-03-04T00:00:00Z"},
+			subscribed: true,
+			expectedResp: &restapi.GetAddressStatsResponse{
+				Address: checksummed,
+				Last24h: restapi.TxCounters{TxCount: 3, ValueIn: "1000", ValueOut: "0"},
+				Daily:   []*restapi.DailyTxCounters{},
+				Range:   &restapi.TxCounters{TxCount: 0, ValueIn: "0", ValueOut: "0"},
+			},
+		},
+		"invalid time": {
+			req:        &restapi.GetAddressStatsRequest{Address: addr, Since: "2024-03-01"},
+			subscribed: true,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTimeRangeMessage,
+			},
+		},
+		"since not before until": {
+			req:        &restapi.GetAddressStatsRequest{Address: addr, Since: "2024-03-05T00:00:00Z", Until: "2024-03-01T00:00:00Z"},
+			subscribed: true,
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidTimeRangeMessage,
+			},
+		},
+		"not subscribed": {
+			req: &restapi.GetAddressStatsRequest{Address: addr},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Address not subscribed. You must first subscribe to the requested address to record and aggregate its transactions.",
+			},
+		},
+		"invalid address": {
+			req: &restapi.GetAddressStatsRequest{Address: "0x123"},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			counters := store.TxCounters{TxCount: 3, ValueIn: big.NewInt(1000), ValueOut: big.NewInt(0)}
+			txStoreMock := &mocks.TxStoreMock{
+				GetAddressStatsFunc: func(ctx context.Context, a string) (*store.AddressStats, error) {
+					assert.Equal(t, addr, a)
+					return &store.AddressStats{
+						Daily: []*store.DailyTxCounters{
+							{Day: time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC), TxCounters: counters},
+							{Day: time.Date(2024, 3, 5, 0, 0, 0, 0, time.UTC), TxCounters: counters},
+						},
+						Rolling: counters,
+					}, nil
+				},
+			}
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, a string) (*store.Subscription, error) {
+					if !test.subscribed {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: a}, nil
+				},
+			}
+
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+			resp, err := call(context.Background(), s.GetAddressStats, test.req)
+			if test.expectedErr != nil {
+				require.Error(t, err)
+				castedErr := &restapi.Err{}
+				if errors.As(err, &castedErr) {
+					assert.Equal(t, test.expectedErr, castedErr)
+					return
+				}
+				assert.Equal(t, test.expectedErr.Message, err.Error())
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestGetTokenHolder(t *testing.T) {
+	const (
+		contract = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		holder   = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	)
+
+	tests := map[string]struct {
+		req          *restapi.GetTokenHolderRequest
+		sub          *store.Subscription
+		storeErr     error
+		expectedResp *restapi.GetTokenHolderResponse
+		expectedErr  *restapi.Err
+	}{
+		"tracked holder": {
+			req: &restapi.GetTokenHolderRequest{Contract: "0xA0B86991C6218B36C1D19D4A2E9EB0CE3606EB48", Address: holder},
+			sub: &store.Subscription{Address: contract, TrackHolders: true},
+			expectedResp: &restapi.GetTokenHolderResponse{
+				Contract: "0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48",
+				Address:  "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+				Balance:  "1000000",
+			},
+		},
+		"holders not tracked": {
+			req: &restapi.GetTokenHolderRequest{Contract: contract, Address: holder},
+			sub: &store.Subscription{Address: contract},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Token holders not tracked. You must first subscribe to the requested contract with 'trackHolders' to track the balances of its holders.",
+			},
+		},
+		"contract not subscribed": {
+			req: &restapi.GetTokenHolderRequest{Contract: contract, Address: holder},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusNotFound,
+				Message:    "Token holders not tracked. You must first subscribe to the requested contract with 'trackHolders' to track the balances of its holders.",
+			},
+		},
+		"store failure": {
+			req:      &restapi.GetTokenHolderRequest{Contract: contract, Address: holder},
+			sub:      &store.Subscription{Address: contract, TrackHolders: true},
+			storeErr: errors.New("dummy error"),
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusInternalServerError,
+				Message:    "Could not get token balance from store",
+			},
+		},
+		"invalid holder": {
+			req: &restapi.GetTokenHolderRequest{Contract: contract, Address: "0x123"},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := &mocks.TxStoreMock{
+				GetTokenBalanceFunc: func(ctx context.Context, token, h string) (*big.Int, error) {
+					assert.Equal(t, contract, token)
+					assert.Equal(t, holder, h)
+					if test.storeErr != nil {
+						return nil, test.storeErr
+					}
+					return big.NewInt(1_000_000), nil
+				},
+			}
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, a string) (*store.Subscription, error) {
+					if test.sub == nil {
+						return nil, store.ErrNotFound
+					}
+					return test.sub, nil
+				},
+			}
+
+			s := restapi.NewServer(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+			resp, err := call(context.Background(), s.GetTokenHolder, test.req)
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedResp, resp)
+		})
+	}
+}
+
+func TestGetVersion(t *testing.T) {
+	buildinfo.Version, buildinfo.Commit, buildinfo.Date = "v1.2.3", "abc123", "2026-01-02T03:04:05Z"
+	t.Cleanup(func() {
+		buildinfo.Version, buildinfo.Commit, buildinfo.Date = "", "", ""
+	})
+
+	s := restapi.NewServer(logging.Logrus(logrus.New()), nil, nil)
+	resp, err := s.GetVersion(context.Background(), &restapi.GetVersionRequest{})
+	require.NoError(t, err)
+	assert.Equal(t, &restapi.VersionResponse{
+		Version:   "v1.2.3",
+		Commit:    "abc123",
+		Date:      "2026-01-02T03:04:05Z",
+		GoVersion: runtime.Version(),
+	}, resp)
+}
+
+func TestStartup(t *testing.T) {
+	tests := map[string]struct {
+		currentBlockNumber *int64
+		node               bool
+		nodeErr            error
+		expectedErr        *restapi.Err
+	}{
+		"block indexed": {
+			currentBlockNumber: ptr[int64](1234),
+		},
+		"no blocks yet without node": {
+			expectedErr: &restapi.Err{
+				Message:    "No parsed blocks yet",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+		"no blocks yet but node reachable": {
+			node: true,
+		},
+		"no blocks yet and node unreachable": {
+			node:    true,
+			nodeErr: errors.New("connection refused"),
+			expectedErr: &restapi.Err{
+				Message:    "No parsed blocks yet and the node is unreachable",
+				StatusCode: http.StatusServiceUnavailable,
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			storeMock := &mocks.TxStoreMock{
+				GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+					if test.currentBlockNumber == nil {
+						return 0, store.ErrNotFound
+					}
+					return *test.currentBlockNumber, nil
+				},
+			}
+			nodeMock := &mocks.NodeMock{
+				BlockNumberFunc: func(ctx context.Context) (int64, error) {
+					return 1234, test.nodeErr
+				},
+			}
+			var opts []restapi.Option
+			if test.node {
+				opts = append(opts, restapi.WithNode(nodeMock))
+			}
+			s := restapi.NewServer(logging.Logrus(logrus.New()), storeMock, nil, opts...)
+			resp, err := s.Startup(context.Background(), &restapi.StartupRequest{})
+			if test.expectedErr != nil {
+				castedErr := &restapi.Err{}
+				require.ErrorAs(t, err, &castedErr)
+				assert.Equal(t, test.expectedErr, castedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, &restapi.StartupResponse{Status: "started"}, resp)
+
+			// once started, it's no longer checked
+			storeMock.GetCurrentBlockNumberFunc = nil
+			nodeMock.BlockNumberFunc = nil
+			_, err = s.Startup(context.Background(), &restapi.StartupRequest{})
+			assert.NoError(t, err)
+		})
+	}
 }
@@ -1,43 +1,40 @@
 package rest
 
-// request and response types are defined below
-// these types can be defined as protobuf messages in a production system (specifically if using gRPC + gRPC-gateway)
-
-type GetCurrentBlockRequest struct{}
-
-type GetCurrentBlockResponse struct {
-	BlockNumber    string `json:"blockNumber"`
-	BlockNumberInt int64  `json:"blockNumberInt"`
-}
-
-type SubscribeRequest struct {
-	Address string `json:"address"`
-}
-
-type SubscribeResponse struct {
-	Ok bool `json:"ok"`
-}
-
-type ListSubscriptionRequest struct{}
-
-type ListSubscriptionResponse struct {
-	Addresses []string `json:"addresses"`
-}
-
-type ListTransactionsRequest struct {
-	Address string `json:"address"`
-}
-
-type ListTransactionsResponse struct {
-	Transactions []*Transaction `json:"transactions"`
+//go:generate go install ./internal/protoc-gen-go-rest
+//go:generate protoc --go-rest_out=. --go-rest_opt=paths=source_relative types.proto
+
+import (
+	"iter"
+	"net/http"
+	"slices"
+)
+
+// the request and response types are generated into types_rest.pb.go from the messages of types.proto, as plain
+// structs keeping the JSON of the API, see protoc-gen-go-rest; their methods are defined below
+
+// Items implements ItemStreamer.
+func (r *ListTransactionsResponse) Items() iter.Seq2[any, error] {
+	return func(yield func(any, error) bool) {
+		if r.items == nil {
+			for tx := range slices.Values(r.Transactions) {
+				if !yield(tx, nil) {
+					return
+				}
+			}
+			return
+		}
+		for tx, err := range r.items {
+			if !yield(tx, err) {
+				return
+			}
+		}
+	}
 }
 
-type Transaction struct {
-	Hash           string         `json:"hash,omitempty"`
-	From           string         `json:"from,omitempty"`
-	To             string         `json:"to,omitempty"`
-	BlockNumber    string         `json:"blockNumber,omitempty"`
-	BlockNumberInt int64          `json:"blockNumberInt,omitempty"`
-	BlockHash      string         `json:"blockHash,omitempty"`
-	FullTx         map[string]any `json:"fullTx,omitempty"`
+// StatusCode implements StatusCoder, unhealthy reports being served with a 503.
+func (r *HealthResponse) StatusCode() int {
+	if r.Status != HealthStatusHealthy {
+		return http.StatusServiceUnavailable
+	}
+	return http.StatusOK
 }
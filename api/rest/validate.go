@@ -0,0 +1,204 @@
+package rest
+
+import (
+	"fmt"
+	"net/http"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+// ValidateTag is the struct tag of the validation rules of the request fields, see Validate.
+const ValidateTag = "validate"
+
+// fieldRules caches the parsed rules of the request types, by type.
+var fieldRules sync.Map
+
+// fieldRule is a rule of a request field, checked against its value.
+type fieldRule struct {
+	index int
+	// name is the json name of the field, the one errors refer to.
+	name  string
+	check func(name string, v reflect.Value) *Err
+}
+
+// Validate checks the fields of the request, a struct or a pointer to one, against the comma separated rules of their
+// validate tags, returning an *Err with a 400 status code for the first field breaking one:
+//
+//   - required: the field must be set, strings being trimmed of spaces
+//   - hexaddr: the string must be an Ethereum address, with or without 0x prefix, if set
+//   - hexhash: the string must be a 32-byte hex hash, with or without 0x prefix, if set
+//   - max=<n>: the string or slice must have at most n characters or items
+//   - range=<min>:<max>: the integer must be between min and max, inclusive
+//
+// It's called by FuncAdapter on every decoded request, the server Funcs only check what can't be declared, e.g. the
+// validated addresses are normalized without checking them again. Invalid rules panic.
+func Validate(req any) error {
+	v := reflect.ValueOf(req)
+	for v.Kind() == reflect.Pointer {
+		if v.IsNil() {
+			return nil
+		}
+		v = v.Elem()
+	}
+	if v.Kind() != reflect.Struct {
+		return nil
+	}
+
+	for rule := range slices.Values(rulesOf(v.Type())) {
+		err := rule.check(rule.name, v.Field(rule.index))
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// rulesOf returns the rules of the fields of the struct type, parsing its tags once.
+func rulesOf(t reflect.Type) []*fieldRule {
+	if rules, ok := fieldRules.Load(t); ok {
+		return rules.([]*fieldRule)
+	}
+
+	var rules []*fieldRule
+	for i := range t.NumField() {
+		field := t.Field(i)
+		tag := field.Tag.Get(ValidateTag)
+		if tag == "" {
+			continue
+		}
+		name, _, _ := strings.Cut(field.Tag.Get("json"), ",")
+		if name == "" {
+			name = field.Name
+		}
+		for spec := range strings.SplitSeq(tag, ",") {
+			rules = append(rules, &fieldRule{
+				index: i,
+				name:  name,
+				check: parseRule(t, field, spec),
+			})
+		}
+	}
+	fieldRules.Store(t, rules)
+	return rules
+}
+
+func parseRule(t reflect.Type, field reflect.StructField, spec string) func(string, reflect.Value) *Err {
+	rule, arg, _ := strings.Cut(strings.TrimSpace(spec), "=")
+	switch rule {
+	case "required":
+		return checkRequired
+	case "hexaddr":
+		if field.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate rule %q of %s.%s: not a string", spec, t, field.Name))
+		}
+		return checkHexAddr
+	case "hexhash":
+		if field.Type.Kind() != reflect.String {
+			panic(fmt.Sprintf("validate rule %q of %s.%s: not a string", spec, t, field.Name))
+		}
+		return checkHexHash
+	case "max":
+		n, err := strconv.Atoi(arg)
+		switch field.Type.Kind() {
+		case reflect.String, reflect.Slice, reflect.Map:
+		default:
+			err = fmt.Errorf("%s is not a string, slice or map", field.Type)
+		}
+		if err != nil {
+			panic(fmt.Sprintf("validate rule %q of %s.%s: %s", spec, t, field.Name, err))
+		}
+		return func(name string, v reflect.Value) *Err {
+			return checkMax(name, v, n)
+		}
+	case "range":
+		minArg, maxArg, _ := strings.Cut(arg, ":")
+		minValue, err1 := strconv.ParseInt(minArg, 10, 64)
+		maxValue, err2 := strconv.ParseInt(maxArg, 10, 64)
+		if err1 != nil || err2 != nil || minValue > maxValue {
+			panic(fmt.Sprintf("validate rule %q of %s.%s: expected range=<min>:<max>", spec, t, field.Name))
+		}
+		switch field.Type.Kind() {
+		case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64,
+			reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		default:
+			panic(fmt.Sprintf("validate rule %q of %s.%s: not an integer", spec, t, field.Name))
+		}
+		return func(name string, v reflect.Value) *Err {
+			return checkRange(name, v, minValue, maxValue)
+		}
+	default:
+		panic(fmt.Sprintf("validate rule %q of %s.%s: unknown rule", spec, t, field.Name))
+	}
+}
+
+func checkRequired(name string, v reflect.Value) *Err {
+	missing := v.IsZero()
+	switch v.Kind() {
+	case reflect.String:
+		missing = strings.TrimSpace(v.String()) == ""
+	case reflect.Slice, reflect.Map:
+		missing = v.Len() == 0
+	default:
+	}
+	if missing {
+		return NewErrf(http.StatusBadRequest, "Missing required field: '%s'", name)
+	}
+	return nil
+}
+
+func checkHexAddr(_ string, v reflect.Value) *Err {
+	addr := strings.TrimSpace(v.String())
+	if addr == "" {
+		return nil
+	}
+	_, valid := ethaddr.Normalize(addr)
+	if !valid {
+		return NewErrf(http.StatusBadRequest, InvalidAddrMessage)
+	}
+	return nil
+}
+
+func checkHexHash(_ string, v reflect.Value) *Err {
+	hash := strings.TrimSpace(v.String())
+	if hash == "" {
+		return nil
+	}
+	_, valid := normalizeTxHash(hash)
+	if !valid {
+		return NewErrf(http.StatusBadRequest, InvalidTxHashMessage)
+	}
+	return nil
+}
+
+func checkMax(name string, v reflect.Value, n int) *Err {
+	switch v.Kind() {
+	case reflect.String:
+		if utf8.RuneCountInString(v.String()) > n {
+			return NewErrf(http.StatusBadRequest, "Invalid '%s', it must have at most %d characters", name, n)
+		}
+	default:
+		if v.Len() > n {
+			return NewErrf(http.StatusBadRequest, "Invalid '%s', it must have at most %d items", name, n)
+		}
+	}
+	return nil
+}
+
+func checkRange(name string, v reflect.Value, minValue, maxValue int64) *Err {
+	var inRange bool
+	if v.CanInt() {
+		inRange = v.Int() >= minValue && v.Int() <= maxValue
+	} else {
+		inRange = maxValue >= 0 && v.Uint() >= uint64(max(minValue, 0)) && v.Uint() <= uint64(maxValue)
+	}
+	if !inRange {
+		return NewErrf(http.StatusBadRequest, "Invalid '%s', it must be between %d and %d", name, minValue, maxValue)
+	}
+	return nil
+}
@@ -0,0 +1,132 @@
+package rest_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+type validatedRequest struct {
+	Address  string   `json:"address" validate:"required,hexaddr"`
+	Contract string   `json:"contract" validate:"hexaddr"`
+	Name     string   `json:"name" validate:"max=5"`
+	Topics   []string `json:"topics" validate:"max=2"`
+	Depth    uint     `json:"depth" validate:"range=1:10"`
+	Offset   int      `json:"offset" validate:"range=-5:5"`
+}
+
+func TestValidate(t *testing.T) {
+	const addr = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+	tests := map[string]struct {
+		req         *validatedRequest
+		expectedErr *restapi.Err
+	}{
+		"valid": {
+			req: &validatedRequest{Address: addr, Name: "héllo", Topics: []string{"a", "b"}, Depth: 10, Offset: -5},
+		},
+		"missing required field": {
+			req: &validatedRequest{Address: "  ", Depth: 1},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Missing required field: 'address'",
+			},
+		},
+		"invalid address": {
+			req: &validatedRequest{Address: "0x1234", Depth: 1},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+		"invalid optional address": {
+			req: &validatedRequest{Address: addr, Contract: "0xzz", Depth: 1},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    restapi.InvalidAddrMessage,
+			},
+		},
+		"string too long": {
+			req: &validatedRequest{Address: addr, Name: "toolong", Depth: 1},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid 'name', it must have at most 5 characters",
+			},
+		},
+		"too many items": {
+			req: &validatedRequest{Address: addr, Topics: []string{"a", "b", "c"}, Depth: 1},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid 'topics', it must have at most 2 items",
+			},
+		},
+		"unsigned out of range": {
+			req: &validatedRequest{Address: addr},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid 'depth', it must be between 1 and 10",
+			},
+		},
+		"signed out of range": {
+			req: &validatedRequest{Address: addr, Depth: 1, Offset: -6},
+			expectedErr: &restapi.Err{
+				StatusCode: http.StatusBadRequest,
+				Message:    "Invalid 'offset', it must be between -5 and 5",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := restapi.Validate(test.req)
+			if test.expectedErr == nil {
+				assert.NoError(t, err)
+				return
+			}
+			castedErr := &restapi.Err{}
+			require.ErrorAs(t, err, &castedErr)
+			assert.Equal(t, test.expectedErr, castedErr)
+		})
+	}
+}
+
+func TestValidateInvalidRule(t *testing.T) {
+	assert.Panics(t, func() {
+		_ = restapi.Validate(&struct {
+			Depth string `validate:"range=1:10"`
+		}{})
+	})
+	assert.Panics(t, func() {
+		_ = restapi.Validate(&struct {
+			Name string `validate:"unknown"`
+		}{})
+	})
+}
+
+func TestFuncAdapterValidation(t *testing.T) {
+	var calls int
+	handler := restapi.FuncAdapter(logging.Logrus(logrus.New()), func(context.Context, *restapi.ListTransactionsRequest) (*restapi.ListTransactionsResponse, error) {
+		calls++
+		return &restapi.ListTransactionsResponse{}, nil
+	}, "address")
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /transactions/{address}", handler)
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions/0x1234", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), restapi.InvalidAddrMessage)
+	assert.Zero(t, calls)
+
+	rec = httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/transactions/0x7a250d5630b4cf539739df2c5dacb4c659f2488d", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, 1, calls)
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"os"
+	"os/signal"
+	"slices"
+	"strings"
+	"syscall"
+	"text/tabwriter"
+	"time"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+)
+
+// the subcommands of the client command, calling the REST API of a running server
+const (
+	clientSubscribe = "subscribe"
+	clientTxs       = "txs"
+	clientStatus    = "status"
+)
+
+var clientCommands = []struct {
+	name        string
+	args        string
+	description string
+}{
+	{clientSubscribe, "<address>", "Subscribe the address, or update its subscription"},
+	{clientTxs, "<address>", "List the recorded transactions of the subscribed address"},
+	{clientStatus, "", "Show the version, readiness, current block and subscriptions of the server"},
+}
+
+type clientOptions struct {
+	Server         string
+	Chain          string
+	Timeout        time.Duration
+	JSON           bool
+	MinValue       string
+	Mode           string
+	WebhookURL     string
+	ChatChannel    string
+	Email          string
+	BackfillBlocks int64
+	Priority       bool
+	SkipFailed     bool
+	Filter         string
+	Label          string
+	TrackHolders   bool
+}
+
+// runClient runs the client subcommand given as the first of the args, exiting with a non-zero status if it fails.
+func runClient(args []string) {
+	if len(args) == 0 || !isClientCommand(args[0]) {
+		clientUsage(os.Stderr)
+		os.Exit(2)
+	}
+	subcommand := args[0]
+
+	fs := flag.NewFlagSet(fmt.Sprintf("%s %s %s", os.Args[0], commandClient, subcommand), flag.ExitOnError)
+	var opts clientOptions
+	fs.StringVar(&opts.Server, "server", "http://localhost:8080", "URL of the server whose REST API is called")
+	fs.StringVar(&opts.Chain, "chain", "", "Name of the chain whose API is called. The chain followed by the top-level options of the server if empty")
+	fs.DurationVar(&opts.Timeout, "timeout", time.Second*10, "Timeout of every request")
+	fs.BoolVar(&opts.JSON, "json", false, "Print the responses as JSON instead of tables")
+	if subcommand == clientSubscribe {
+		fs.StringVar(&opts.MinValue, "min-value", "", "Minimum transaction value in wei, given in decimal or 0x-prefixed hex")
+		fs.StringVar(&opts.Mode, "mode", "", "Either 'live', the default, or 'history' to list the transactions recorded before the subscription too")
+		fs.StringVar(&opts.WebhookURL, "webhook-url", "", "URL the recorded transactions of the address are posted to")
+		fs.StringVar(&opts.ChatChannel, "chat-channel", "", "Chat channel the recorded transactions of the address are sent to, given as slack:<webhook url>, discord:<webhook url> or telegram:<bot token>@<chat id>")
+		fs.StringVar(&opts.Email, "email", "", "Email address the recorded transactions of the address are sent to")
+		fs.Int64Var(&opts.BackfillBlocks, "backfill-blocks", 0, "Number of blocks before the subscription to scan for past transactions of the address")
+		fs.BoolVar(&opts.Priority, "priority", false, "Notify the transactions of the address ahead of the others")
+		fs.BoolVar(&opts.SkipFailed, "skip-failed", false, "Exclude the reverted transactions of the address")
+		fs.StringVar(&opts.Filter, "filter", "", "Boolean expression the transactions must satisfy to be recorded, e.g. 'value > 1e18'")
+		fs.StringVar(&opts.Label, "label", "", "Label of the address the subscriptions can be searched by, e.g. 'exchange'")
+		fs.BoolVar(&opts.TrackHolders, "track-holders", false, "Track the balances of the holders of the address, an ERC-20 token contract")
+	}
+	_ = fs.Parse(args[1:]) // exits on error
+	if subcommand != clientStatus && fs.NArg() != 1 {
+		_, _ = fmt.Fprintf(os.Stderr, "%s requires exactly one address\n", fs.Name())
+		fs.Usage()
+		os.Exit(2)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+	client := restapi.NewClient(&http.Client{Timeout: opts.Timeout}, opts.Server, opts.Chain)
+
+	var err error
+	switch subcommand {
+	case clientSubscribe:
+		err = clientSubscribeAddress(ctx, os.Stdout, client, fs.Arg(0), opts)
+	case clientTxs:
+		err = clientListTransactions(ctx, os.Stdout, client, fs.Arg(0), opts)
+	case clientStatus:
+		err = clientShowStatus(ctx, os.Stdout, client, opts)
+	}
+	if err != nil {
+		_, _ = fmt.Fprintf(os.Stderr, "Error: %s\n", clientErrMessage(err))
+		os.Exit(1)
+	}
+}
+
+func clientSubscribeAddress(ctx context.Context, w io.Writer, client *restapi.Client, addr string, opts clientOptions) error {
+	resp, err := client.Subscribe(ctx, &restapi.SubscribeRequest{
+		Address:        addr,
+		MinValue:       opts.MinValue,
+		WebhookURL:     opts.WebhookURL,
+		ChatChannel:    opts.ChatChannel,
+		Email:          opts.Email,
+		Mode:           opts.Mode,
+		BackfillBlocks: opts.BackfillBlocks,
+		Priority:       opts.Priority,
+		SkipFailed:     opts.SkipFailed,
+		Filter:         opts.Filter,
+		Label:          opts.Label,
+		TrackHolders:   opts.TrackHolders,
+	})
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(w, resp)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Address:\t%s\n", strings.ToLower(addr))
+	_, _ = fmt.Fprintf(tw, "Start block:\t%d\n", resp.StartBlock)
+	if resp.WebhookSecret != "" {
+		_, _ = fmt.Fprintf(tw, "Webhook secret:\t%s\n", resp.WebhookSecret)
+	}
+	if resp.Backfill != nil {
+		_, _ = fmt.Fprintf(tw, "Backfill:\t%s, blocks %d to %d\n", resp.Backfill.Status, resp.Backfill.FromBlock, resp.Backfill.ToBlock)
+	}
+	return tw.Flush()
+}
+
+func clientListTransactions(ctx context.Context, w io.Writer, client *restapi.Client, addr string, opts clientOptions) error {
+	resp, err := client.ListTransactions(ctx, addr)
+	if err != nil {
+		return err
+	}
+	if opts.JSON {
+		return printJSON(w, resp)
+	}
+	if len(resp.Transactions) == 0 {
+		_, _ = fmt.Fprintln(w, "No transactions recorded")
+		return nil
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintln(tw, "BLOCK\tHASH\tFROM\tTO\tVALUE (ETH)\tSTATUS\tCONFIRMATIONS")
+	for tx := range slices.Values(resp.Transactions) {
+		status := tx.Status
+		if tx.Removed {
+			status = "removed"
+		}
+		if status == "" {
+			status = "-"
+		}
+		_, _ = fmt.Fprintf(tw, "%d\t%s\t%s\t%s\t%s\t%s\t%d\n",
+			tx.BlockNumberInt, tx.Hash, tx.From, tx.To, formatWeiAsEther(tx.Value), status, tx.Confirmations)
+	}
+	return tw.Flush()
+}
+
+func clientShowStatus(ctx context.Context, w io.Writer, client *restapi.Client, opts clientOptions) error {
+	version, err := client.GetVersion(ctx)
+	if err != nil {
+		return err
+	}
+	// not being ready is part of the status rather than an error
+	ready := "ready"
+	_, err = client.Ready(ctx)
+	if err != nil {
+		ready = "not ready: " + clientErrMessage(err)
+	}
+	// nil until the first block is parsed
+	currentBlock, _ := client.GetCurrentBlock(ctx)
+	subs, err := client.ListSubscriptions(ctx)
+	if err != nil {
+		return err
+	}
+
+	if opts.JSON {
+		return printJSON(w, map[string]any{
+			"version":       version,
+			"ready":         ready,
+			"currentBlock":  currentBlock,
+			"subscriptions": subs.Addresses,
+		})
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	_, _ = fmt.Fprintf(tw, "Version:\t%s (commit %s, built %s, %s)\n", version.Version, version.Commit, version.Date, version.GoVersion)
+	_, _ = fmt.Fprintf(tw, "Status:\t%s\n", ready)
+	if currentBlock != nil {
+		_, _ = fmt.Fprintf(tw, "Current block:\t%d\n", currentBlock.BlockNumberInt)
+	} else {
+		_, _ = fmt.Fprintf(tw, "Current block:\t-\n")
+	}
+	_, _ = fmt.Fprintf(tw, "Subscriptions:\t%d\n", len(subs.Addresses))
+	for addr := range slices.Values(subs.Addresses) {
+		_, _ = fmt.Fprintf(tw, "\t%s\n", addr)
+	}
+	return tw.Flush()
+}
+
+func printJSON(w io.Writer, v any) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(v)
+}
+
+// formatWeiAsEther formats the decimal wei value in ether, without trailing zeros, returning it as is if it isn't a
+// number.
+func formatWeiAsEther(wei string) string {
+	value, ok := new(big.Int).SetString(wei, 10)
+	if !ok {
+		return wei
+	}
+	ether := new(big.Rat).SetFrac(value, big.NewInt(1e18)).FloatString(18)
+	ether = strings.TrimRight(ether, "0")
+	return strings.TrimSuffix(ether, ".")
+}
+
+// clientErrMessage returns the message of the error, without the status code of the errors returned by the server.
+func clientErrMessage(err error) string {
+	var restErr *restapi.Err
+	if errors.As(err, &restErr) {
+		return fmt.Sprintf("%s (%s)", restErr.Message, http.StatusText(restErr.StatusCode))
+	}
+	return err.Error()
+}
+
+func isClientCommand(name string) bool {
+	for cmd := range slices.Values(clientCommands) {
+		if cmd.name == name {
+			return true
+		}
+	}
+	return false
+}
+
+func clientUsage(w io.Writer) {
+	_, _ = fmt.Fprintf(w, "Usage of %s %s:\n  %s %s <subcommand> [flags] [address]\n\nSubcommands:\n", os.Args[0], commandClient, os.Args[0], commandClient)
+	for cmd := range slices.Values(clientCommands) {
+		_, _ = fmt.Fprintf(w, "  %-22s %s\n", strings.TrimSpace(cmd.name+" "+cmd.args), cmd.description)
+	}
+	_, _ = fmt.Fprintf(w, "\nRun '%s %s <subcommand> -h' for the flags of a subcommand.\n", os.Args[0], commandClient)
+}
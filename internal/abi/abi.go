@@ -0,0 +1,264 @@
+package abi
+
+import (
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/keccak"
+)
+
+// See https://docs.soliditylang.org/en/latest/abi-spec.html
+
+var (
+	// ErrInvalidABI is returned when an ABI can't be parsed.
+	ErrInvalidABI = errors.New("invalid abi")
+
+	arraySuffixRegex = regexp.MustCompile(`\[(\d*)\]$`)
+)
+
+type kind int
+
+const (
+	kindUint kind = iota
+	kindInt
+	kindAddress
+	kindBool
+	kindFixedBytes
+	kindBytes
+	kindString
+	kindArray
+	kindSlice
+	kindTuple
+)
+
+// abiType is a parsed ABI type.
+type abiType struct {
+	kind kind
+	// size is the number of bits of integers, the number of bytes of fixed size byte arrays, or the length of arrays.
+	size int
+	elem *abiType
+	// components and names hold the component types and names of tuples.
+	components []*abiType
+	names      []string
+	// canonical is the type as written in function signatures.
+	canonical string
+}
+
+// dynamic reports whether values of the type are encoded out of place, with only their offset in the head.
+func (t *abiType) dynamic() bool {
+	switch t.kind {
+	case kindBytes, kindString, kindSlice:
+		return true
+	case kindArray:
+		return t.elem.dynamic()
+	case kindTuple:
+		return slices.ContainsFunc(t.components, (*abiType).dynamic)
+	default:
+		return false
+	}
+}
+
+// headSize returns the number of bytes the type takes in the head of its enclosing tuple.
+func (t *abiType) headSize() int {
+	if t.dynamic() {
+		return wordSize
+	}
+	switch t.kind {
+	case kindArray:
+		return t.size * t.elem.headSize()
+	case kindTuple:
+		var size int
+		for c := range slices.Values(t.components) {
+			size += c.headSize()
+		}
+		return size
+	default:
+		return wordSize
+	}
+}
+
+// argument is an ABI JSON function input.
+type argument struct {
+	Name       string      `json:"name"`
+	Type       string      `json:"type"`
+	Components []*argument `json:"components"`
+}
+
+// entry is an ABI JSON entry, only functions are of interest.
+type entry struct {
+	Type   string      `json:"type"`
+	Name   string      `json:"name"`
+	Inputs []*argument `json:"inputs"`
+}
+
+// function is a parsed ABI function.
+type function struct {
+	name      string
+	signature string
+	inputs    *abiType
+}
+
+// ABI holds the functions of a contract ABI keyed by their selector.
+type ABI struct {
+	functions map[[4]byte]*function
+}
+
+// Parse parses the given contract ABI JSON.
+func Parse(data []byte) (*ABI, error) {
+	var entries []*entry
+	err := json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalidABI, err)
+	}
+
+	a := &ABI{functions: make(map[[4]byte]*function)}
+	for e := range slices.Values(entries) {
+		// the type defaults to function when omitted
+		if e.Type != "function" && e.Type != "" {
+			continue
+		}
+		inputs, err := parseTuple(e.Inputs)
+		if err != nil {
+			return nil, fmt.Errorf("%w: function %q: %w", ErrInvalidABI, e.Name, err)
+		}
+
+		signature := e.Name + inputs.canonical
+		hash := keccak.Sum256([]byte(signature))
+		a.functions[[4]byte(hash[:4])] = &function{
+			name:      e.Name,
+			signature: signature,
+			inputs:    inputs,
+		}
+	}
+
+	return a, nil
+}
+
+// Call is a decoded function call.
+type Call struct {
+	Method    string
+	Signature string
+	Args      []*Arg
+}
+
+// Arg is a decoded function call argument. Integers are decimal strings, addresses and byte arrays 0x-prefixed hex
+// strings, arrays slices and tuples maps keyed by component names.
+type Arg struct {
+	Name  string
+	Type  string
+	Value any
+}
+
+// Decode decodes the given 0x-prefixed hex tx input into the called function and its arguments.
+// It returns false if the input doesn't call any of the ABI functions.
+func (a *ABI) Decode(input string) (*Call, bool, error) {
+	data, err := hex.DecodeString(strings.TrimPrefix(input, "0x"))
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid input: %w", err)
+	}
+	if len(data) < 4 {
+		return nil, false, nil
+	}
+
+	fn, ok := a.functions[[4]byte(data[:4])]
+	if !ok {
+		return nil, false, nil
+	}
+
+	values, err := decodeTuple(fn.inputs.components, data[4:])
+	if err != nil {
+		return nil, false, fmt.Errorf("could not decode %s arguments: %w", fn.signature, err)
+	}
+
+	call := &Call{
+		Method:    fn.name,
+		Signature: fn.signature,
+		Args:      make([]*Arg, 0, len(values)),
+	}
+	for i, value := range values {
+		call.Args = append(call.Args, &Arg{
+			Name:  fn.inputs.names[i],
+			Type:  fn.inputs.components[i].canonical,
+			Value: value,
+		})
+	}
+	return call, true, nil
+}
+
+func parseTuple(args []*argument) (*abiType, error) {
+	t := &abiType{kind: kindTuple}
+	canonical := make([]string, 0, len(args))
+	for i, arg := range args {
+		component, err := parseType(arg.Type, arg.Components)
+		if err != nil {
+			return nil, err
+		}
+		name := arg.Name
+		if name == "" {
+			name = "arg" + strconv.Itoa(i)
+		}
+		t.components = append(t.components, component)
+		t.names = append(t.names, name)
+		canonical = append(canonical, component.canonical)
+	}
+	t.canonical = "(" + strings.Join(canonical, ",") + ")"
+	return t, nil
+}
+
+func parseType(typ string, components []*argument) (*abiType, error) {
+	if match := arraySuffixRegex.FindStringSubmatch(typ); match != nil {
+		elem, err := parseType(strings.TrimSuffix(typ, match[0]), components)
+		if err != nil {
+			return nil, err
+		}
+		if match[1] == "" {
+			return &abiType{kind: kindSlice, elem: elem, canonical: elem.canonical + "[]"}, nil
+		}
+		length, err := strconv.Atoi(match[1])
+		if err != nil || length == 0 {
+			return nil, fmt.Errorf("invalid array length in %q", typ)
+		}
+		return &abiType{kind: kindArray, size: length, elem: elem, canonical: elem.canonical + match[0]}, nil
+	}
+
+	switch {
+	case typ == "tuple":
+		return parseTuple(components)
+	case typ == "address":
+		return &abiType{kind: kindAddress, canonical: typ}, nil
+	case typ == "bool":
+		return &abiType{kind: kindBool, canonical: typ}, nil
+	case typ == "string":
+		return &abiType{kind: kindString, canonical: typ}, nil
+	case typ == "bytes":
+		return &abiType{kind: kindBytes, canonical: typ}, nil
+	case strings.HasPrefix(typ, "bytes"):
+		size, err := strconv.Atoi(strings.TrimPrefix(typ, "bytes"))
+		if err != nil || size < 1 || size > wordSize {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+		return &abiType{kind: kindFixedBytes, size: size, canonical: typ}, nil
+	case strings.HasPrefix(typ, "uint"), strings.HasPrefix(typ, "int"):
+		k, bits := kindInt, strings.TrimPrefix(typ, "int")
+		if strings.HasPrefix(typ, "uint") {
+			k, bits = kindUint, strings.TrimPrefix(typ, "uint")
+		}
+		if bits == "" {
+			// uint and int are aliases of uint256 and int256
+			bits = "256"
+		}
+		size, err := strconv.Atoi(bits)
+		if err != nil || size < 8 || size > 256 || size%8 != 0 {
+			return nil, fmt.Errorf("invalid type %q", typ)
+		}
+		return &abiType{kind: k, size: size, canonical: strings.TrimSuffix(typ, bits) + strconv.Itoa(size)}, nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", typ)
+	}
+}
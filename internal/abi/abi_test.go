@@ -0,0 +1,180 @@
+package abi_test
+
+import (
+	"encoding/hex"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/abi"
+	"github.com/hedisam/ethtxparser/internal/keccak"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	contractAddr = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+	recipient    = "0x00000000000000000000000000000000000000aa"
+
+	testABI = `[
+		{"type":"function","name":"transfer","inputs":[{"name":"to","type":"address"},{"name":"amount","type":"uint256"}]},
+		{"type":"function","name":"setData","inputs":[{"name":"name","type":"string"},{"name":"values","type":"uint256[]"}]},
+		{"type":"function","name":"submit","inputs":[{"name":"order","type":"tuple","components":[{"name":"to","type":"address"},{"name":"delta","type":"int256"}]},{"name":"","type":"bool"}]},
+		{"type":"event","name":"Transfer","inputs":[{"name":"from","type":"address","indexed":true}]}
+	]`
+)
+
+func TestDecode(t *testing.T) {
+	tests := map[string]struct {
+		input        string
+		expectedCall *abi.Call
+		expectedOk   bool
+		errContains  string
+	}{
+		"static arguments": {
+			input: "0xa9059cbb" + word(recipient) + word("0de0b6b3a7640000"),
+			expectedCall: &abi.Call{
+				Method:    "transfer",
+				Signature: "transfer(address,uint256)",
+				Args: []*abi.Arg{
+					{Name: "to", Type: "address", Value: recipient},
+					{Name: "amount", Type: "uint256", Value: "1000000000000000000"},
+				},
+			},
+			expectedOk: true,
+		},
+		"dynamic arguments": {
+			input: selector("setData(string,uint256[])") +
+				word("40") + word("80") +
+				word("05") + rightPadded(hex.EncodeToString([]byte("hello"))) +
+				word("02") + word("01") + word("02"),
+			expectedCall: &abi.Call{
+				Method:    "setData",
+				Signature: "setData(string,uint256[])",
+				Args: []*abi.Arg{
+					{Name: "name", Type: "string", Value: "hello"},
+					{Name: "values", Type: "uint256[]", Value: []any{"1", "2"}},
+				},
+			},
+			expectedOk: true,
+		},
+		"tuple and unnamed arguments": {
+			input: selector("submit((address,int256),bool)") +
+				word(recipient) + strings.Repeat("ff", 32) + word("01"),
+			expectedCall: &abi.Call{
+				Method:    "submit",
+				Signature: "submit((address,int256),bool)",
+				Args: []*abi.Arg{
+					{Name: "order", Type: "(address,int256)", Value: map[string]any{"to": recipient, "delta": "-1"}},
+					{Name: "arg1", Type: "bool", Value: true},
+				},
+			},
+			expectedOk: true,
+		},
+		"unknown selector": {
+			input: "0xdeadbeef" + word("01"),
+		},
+		"plain transfer": {
+			input: "0x",
+		},
+		"short data": {
+			input:       "0xa9059cbb" + word(recipient),
+			errContains: "input data too short",
+		},
+		"out of bounds offset": {
+			input:       selector("setData(string,uint256[])") + word("ff") + word("80"),
+			errContains: "input data too short",
+		},
+		"invalid hex": {
+			input:       "0xzz",
+			errContains: "invalid input",
+		},
+	}
+
+	a, err := abi.Parse([]byte(testABI))
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			call, ok, err := a.Decode(test.input)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOk, ok)
+			assert.Equal(t, test.expectedCall, call)
+		})
+	}
+}
+
+func TestParseInvalidABI(t *testing.T) {
+	tests := map[string]string{
+		"malformed json":   `{"type":"function"}`,
+		"unsupported type": `[{"type":"function","name":"f","inputs":[{"name":"x","type":"fixed128x18"}]}]`,
+		"invalid bytes":    `[{"type":"function","name":"f","inputs":[{"name":"x","type":"bytes33"}]}]`,
+		"invalid int size": `[{"type":"function","name":"f","inputs":[{"name":"x","type":"uint7"}]}]`,
+	}
+
+	for name, data := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := abi.Parse([]byte(data))
+			assert.ErrorIs(t, err, abi.ErrInvalidABI)
+		})
+	}
+}
+
+func TestRegistry(t *testing.T) {
+	dir := t.TempDir()
+	err := os.WriteFile(filepath.Join(dir, contractAddr+".json"), []byte(testABI), 0o600)
+	require.NoError(t, err)
+
+	r := abi.NewRegistry()
+	err = r.LoadDir(dir)
+	require.NoError(t, err)
+	assert.Equal(t, []string{contractAddr}, r.Contracts())
+
+	decoded, err := r.DecodeInput(contractAddr, "0xa9059cbb"+word(recipient)+word("0a"))
+	require.NoError(t, err)
+	assert.Equal(t, &store.DecodedInput{
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Args: []*store.DecodedArg{
+			{Name: "to", Type: "address", Value: recipient},
+			{Name: "amount", Type: "uint256", Value: "10"},
+		},
+	}, decoded)
+
+	decoded, err = r.DecodeInput(recipient, "0xa9059cbb"+word(recipient)+word("0a"))
+	require.NoError(t, err)
+	assert.Nil(t, decoded)
+
+	err = os.WriteFile(filepath.Join(dir, "erc20.json"), []byte(testABI), 0o600)
+	require.NoError(t, err)
+	err = r.LoadDir(dir)
+	assert.ErrorContains(t, err, "not named after a contract address")
+}
+
+// selector returns the 0x-prefixed selector of the given function signature.
+func selector(signature string) string {
+	hash := keccak.Sum256([]byte(signature))
+	return "0x" + hex.EncodeToString(hash[:4])
+}
+
+// word left pads the given hex to an ABI word.
+func word(s string) string {
+	s = strings.TrimPrefix(s, "0x")
+	return strings.Repeat("0", 64-len(s)) + s
+}
+
+// rightPadded right pads the given hex to a multiple of ABI words.
+func rightPadded(s string) string {
+	if len(s)%64 == 0 {
+		return s
+	}
+	return s + strings.Repeat("0", 64-len(s)%64)
+}
@@ -0,0 +1,131 @@
+package abi
+
+import (
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"slices"
+	"unicode/utf8"
+)
+
+const (
+	// wordSize is the size of an ABI encoded word.
+	wordSize = 32
+)
+
+var errShortData = errors.New("input data too short")
+
+// decodeTuple decodes the values of the given types from data starting at the head of their enclosing tuple.
+// Dynamic values are encoded at offsets relative to the start of the tuple.
+func decodeTuple(types []*abiType, data []byte) ([]any, error) {
+	values := make([]any, 0, len(types))
+	var pos int
+	for t := range slices.Values(types) {
+		if !t.dynamic() {
+			if pos+t.headSize() > len(data) {
+				return nil, errShortData
+			}
+			value, err := decodeValue(t, data[pos:])
+			if err != nil {
+				return nil, err
+			}
+			values = append(values, value)
+			pos += t.headSize()
+			continue
+		}
+
+		offset, err := readLength(data, pos)
+		if err != nil {
+			return nil, err
+		}
+		if offset > len(data) {
+			return nil, errShortData
+		}
+		value, err := decodeValue(t, data[offset:])
+		if err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+		pos += wordSize
+	}
+
+	return values, nil
+}
+
+// decodeValue decodes a value of the given type from the start of data.
+func decodeValue(t *abiType, data []byte) (any, error) {
+	switch t.kind {
+	case kindTuple:
+		values, err := decodeTuple(t.components, data)
+		if err != nil {
+			return nil, err
+		}
+		tuple := make(map[string]any, len(values))
+		for i, value := range values {
+			tuple[t.names[i]] = value
+		}
+		return tuple, nil
+	case kindArray:
+		return decodeTuple(slices.Repeat([]*abiType{t.elem}, t.size), data)
+	case kindSlice:
+		length, err := readLength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		// every element takes at least a word, which bounds the length by the data size
+		if length > len(data)/wordSize {
+			return nil, errShortData
+		}
+		return decodeTuple(slices.Repeat([]*abiType{t.elem}, length), data[wordSize:])
+	case kindBytes, kindString:
+		length, err := readLength(data, 0)
+		if err != nil {
+			return nil, err
+		}
+		if wordSize+length > len(data) {
+			return nil, errShortData
+		}
+		content := data[wordSize : wordSize+length]
+		if t.kind == kindString && utf8.Valid(content) {
+			return string(content), nil
+		}
+		return "0x" + hex.EncodeToString(content), nil
+	}
+
+	if len(data) < wordSize {
+		return nil, errShortData
+	}
+	word := data[:wordSize]
+	switch t.kind {
+	case kindUint:
+		return new(big.Int).SetBytes(word).String(), nil
+	case kindInt:
+		n := new(big.Int).SetBytes(word)
+		if word[0]&0x80 != 0 {
+			// two's complement of a negative number
+			n.Sub(n, new(big.Int).Lsh(big.NewInt(1), wordSize*8))
+		}
+		return n.String(), nil
+	case kindAddress:
+		return "0x" + hex.EncodeToString(word[wordSize-20:]), nil
+	case kindBool:
+		return word[wordSize-1] == 1, nil
+	case kindFixedBytes:
+		return "0x" + hex.EncodeToString(word[:t.size]), nil
+	default:
+		return nil, fmt.Errorf("unsupported type %q", t.canonical)
+	}
+}
+
+// readLength reads the word at the given position as a length or offset.
+func readLength(data []byte, pos int) (int, error) {
+	if pos+wordSize > len(data) {
+		return 0, errShortData
+	}
+	n := new(big.Int).SetBytes(data[pos : pos+wordSize])
+	if !n.IsInt64() || n.Int64() > int64(len(data)) {
+		return 0, errShortData
+	}
+	return int(n.Int64()), nil
+}
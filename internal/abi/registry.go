@@ -0,0 +1,104 @@
+package abi
+
+import (
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+// Registry holds the ABIs of contracts, used to decode the input of the transactions sent to them.
+type Registry struct {
+	contractToABI map[string]*ABI
+	mu            sync.RWMutex
+}
+
+func NewRegistry() *Registry {
+	return &Registry{
+		contractToABI: make(map[string]*ABI),
+	}
+}
+
+// Register parses the given ABI JSON and registers it for the given contract, replacing any previous one.
+func (r *Registry) Register(contract string, data []byte) error {
+	a, err := Parse(data)
+	if err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.contractToABI[strings.ToLower(contract)] = a
+	return nil
+}
+
+// LoadDir registers the ABI JSON files of the given directory, each named after its contract address, e.g.
+// 0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48.json.
+func (r *Registry) LoadDir(dir string) error {
+	paths, err := filepath.Glob(filepath.Join(dir, "*.json"))
+	if err != nil {
+		return fmt.Errorf("could not list abi files: %w", err)
+	}
+
+	for path := range slices.Values(paths) {
+		contract := strings.TrimSuffix(filepath.Base(path), ".json")
+		if !ethaddr.IsValid(contract) {
+			return fmt.Errorf("abi file %q is not named after a contract address", path)
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return fmt.Errorf("could not read abi file: %w", err)
+		}
+		err = r.Register(contract, data)
+		if err != nil {
+			return fmt.Errorf("could not register abi file %q: %w", path, err)
+		}
+	}
+
+	return nil
+}
+
+// Contracts returns the contracts with a registered ABI.
+func (r *Registry) Contracts() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return slices.Sorted(maps.Keys(r.contractToABI))
+}
+
+// DecodeInput decodes the input of a transaction sent to the given contract. It returns nil if no ABI is registered
+// for the contract or the input doesn't call any of its functions.
+func (r *Registry) DecodeInput(contract, input string) (*store.DecodedInput, error) {
+	r.mu.RLock()
+	a, ok := r.contractToABI[contract]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, nil
+	}
+
+	call, ok, err := a.Decode(input)
+	if err != nil || !ok {
+		return nil, err
+	}
+
+	decoded := &store.DecodedInput{
+		Method:    call.Method,
+		Signature: call.Signature,
+		Args:      make([]*store.DecodedArg, 0, len(call.Args)),
+	}
+	for arg := range slices.Values(call.Args) {
+		decoded.Args = append(decoded.Args, &store.DecodedArg{
+			Name:  arg.Name,
+			Type:  arg.Type,
+			Value: arg.Value,
+		})
+	}
+	return decoded, nil
+}
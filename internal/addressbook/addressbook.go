@@ -0,0 +1,104 @@
+package addressbook
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+const (
+	// FromLabel is the key of the sender label in the labels of transaction records.
+	FromLabel = "from"
+	// ToLabel is the key of the recipient label in the labels of transaction records.
+	ToLabel = "to"
+)
+
+// Book maps known addresses, such as exchange wallets, bridges and contracts, to their labels.
+type Book struct {
+	mu          sync.RWMutex
+	addrToLabel map[string]string
+}
+
+// Load loads the address book from a JSON file mapping addresses to their labels, e.g.
+// {"0x28c6c06298d514db089934071355e5743bf21d60": "Binance 14"}.
+func Load(path string) (*Book, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read address book: %w", err)
+	}
+
+	var entries map[string]string
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return nil, fmt.Errorf("could not unmarshal address book: %w", err)
+	}
+
+	return New(entries)
+}
+
+// New returns an address book of the given addresses and their labels.
+func New(entries map[string]string) (*Book, error) {
+	b := &Book{addrToLabel: make(map[string]string, len(entries))}
+	err := b.Add(entries)
+	if err != nil {
+		return nil, err
+	}
+
+	return b, nil
+}
+
+// Add adds the given addresses and their labels to the book, replacing the labels of the known ones. Nothing is added
+// if any of them is invalid.
+func (b *Book) Add(entries map[string]string) error {
+	addrToLabel := make(map[string]string, len(entries))
+	for addr, label := range maps.All(entries) {
+		addr = strings.ToLower(addr)
+		if !ethaddr.IsValid(addr) {
+			return fmt.Errorf("invalid address %q in address book", addr)
+		}
+		if label == "" {
+			return fmt.Errorf("empty label of address %q in address book", addr)
+		}
+		addrToLabel[addr] = label
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	maps.Copy(b.addrToLabel, addrToLabel)
+	return nil
+}
+
+// Label returns the label of the given address, if known.
+func (b *Book) Label(addr string) (string, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	label, ok := b.addrToLabel[addr]
+	return label, ok
+}
+
+// HookTx labels the known sender and recipient of the transaction, to be registered as an index.TxHook.
+func (b *Book) HookTx(_ context.Context, record *store.TxRecord) (bool, error) {
+	fromLabel, fromOK := b.Label(record.From)
+	toLabel, toOK := b.Label(record.To)
+	if !fromOK && !toOK {
+		return true, nil
+	}
+
+	if record.Labels == nil {
+		record.Labels = make(map[string]string, 2)
+	}
+	if fromOK {
+		record.Labels[FromLabel] = fromLabel
+	}
+	if toOK {
+		record.Labels[ToLabel] = toLabel
+	}
+	return true, nil
+}
@@ -0,0 +1,121 @@
+package addressbook_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/addressbook"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	exchangeAddr = "0x28c6c06298d514db089934071355e5743bf21d60"
+	bridgeAddr   = "0x3ee18b2214aff97000d974cf647e7c347e8fa585"
+	unknownAddr  = "0x00000000000000000000000000000000000000aa"
+)
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		data        string
+		errContains string
+	}{
+		"valid address book": {
+			data: `{"0x28C6c06298d514Db089934071355E5743bf21d60": "Binance 14", "0x3ee18b2214aff97000d974cf647e7c347e8fa585": "Wormhole Bridge"}`,
+		},
+		"invalid address": {
+			data:        `{"0x1234": "Binance 14"}`,
+			errContains: "invalid address",
+		},
+		"empty label": {
+			data:        `{"0x28c6c06298d514db089934071355e5743bf21d60": ""}`,
+			errContains: "empty label",
+		},
+		"malformed json": {
+			data:        `["0x28c6c06298d514db089934071355e5743bf21d60"]`,
+			errContains: "could not unmarshal address book",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), "address-book.json")
+			err := os.WriteFile(path, []byte(test.data), 0o600)
+			require.NoError(t, err)
+
+			book, err := addressbook.Load(path)
+			if test.errContains != "" {
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			// addresses are normalized to lower case
+			label, ok := book.Label(exchangeAddr)
+			assert.True(t, ok)
+			assert.Equal(t, "Binance 14", label)
+		})
+	}
+}
+
+func TestHookTx(t *testing.T) {
+	tests := map[string]struct {
+		record         *store.TxRecord
+		expectedLabels map[string]string
+	}{
+		"known sender and recipient": {
+			record: &store.TxRecord{From: exchangeAddr, To: bridgeAddr},
+			expectedLabels: map[string]string{
+				addressbook.FromLabel: "Binance 14",
+				addressbook.ToLabel:   "Wormhole Bridge",
+			},
+		},
+		"known recipient and existing labels": {
+			record: &store.TxRecord{From: unknownAddr, To: exchangeAddr, Labels: map[string]string{"price": "3000"}},
+			expectedLabels: map[string]string{
+				"price":             "3000",
+				addressbook.ToLabel: "Binance 14",
+			},
+		},
+		"unknown counterparties": {
+			record: &store.TxRecord{From: unknownAddr, To: unknownAddr},
+		},
+	}
+
+	book, err := addressbook.New(map[string]string{
+		exchangeAddr: "Binance 14",
+		bridgeAddr:   "Wormhole Bridge",
+	})
+	require.NoError(t, err)
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keep, err := book.HookTx(context.Background(), test.record)
+			require.NoError(t, err)
+			assert.True(t, keep)
+			assert.Equal(t, test.expectedLabels, test.record.Labels)
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	book, err := addressbook.New(map[string]string{exchangeAddr: "Binance 14"})
+	require.NoError(t, err)
+
+	err = book.Add(map[string]string{exchangeAddr: "Binance", "0x1234": "Invalid"})
+	assert.ErrorContains(t, err, "invalid address")
+	label, _ := book.Label(exchangeAddr)
+	assert.Equal(t, "Binance 14", label, "nothing is added if any entry is invalid")
+
+	err = book.Add(map[string]string{exchangeAddr: "Binance", bridgeAddr: "Wormhole Bridge"})
+	require.NoError(t, err)
+	label, _ = book.Label(exchangeAddr)
+	assert.Equal(t, "Binance", label)
+	label, ok := book.Label(bridgeAddr)
+	assert.True(t, ok)
+	assert.Equal(t, "Wormhole Bridge", label)
+}
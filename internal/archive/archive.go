@@ -0,0 +1,179 @@
+// Package archive keeps the confirmed raw blocks on disk, so they can be replayed through the indexer.
+package archive
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// fileExt is the extension of archived block files.
+const fileExt = ".gob.gz"
+
+// Archiver writes every confirmed block to a directory, one gzipped gob file per block named after its number, and
+// removes the ones later orphaned so the archive holds the canonical chain.
+type Archiver struct {
+	logger  *logrus.Logger
+	metrics *metrics
+	dir     string
+}
+
+// New returns an Archiver writing to the given directory, created if missing, with its metrics registered with reg.
+func New(logger *logrus.Logger, reg prometheus.Registerer, dir string) (*Archiver, error) {
+	err := os.MkdirAll(dir, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("could not create archive directory: %w", err)
+	}
+
+	return &Archiver{
+		logger:  logger,
+		metrics: newMetrics(reg),
+		dir:     dir,
+	}, nil
+}
+
+// Start archives the received block events until the channel is closed or the context is done. Blocks that can't be
+// archived are logged and skipped.
+func (a *Archiver) Start(ctx context.Context, in <-chan *eth.BlockEvent) {
+	for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+		if event == nil || event.Block == nil {
+			continue
+		}
+		logger := a.logger.WithFields(logrus.Fields{
+			"block_number": event.Block.Number,
+			"block_hash":   event.Block.Hash,
+		})
+
+		if event.Type == eth.BlockOrphaned {
+			err := a.remove(event.Block)
+			if err != nil {
+				logger.WithError(err).Error("Failed to remove orphaned block from the archive")
+			}
+			continue
+		}
+
+		err := a.write(event.Block)
+		a.metrics.archivedBlocks.WithLabelValues(result(err)).Inc()
+		if err != nil {
+			logger.WithError(err).Error("Failed to archive confirmed block")
+		}
+	}
+}
+
+// write writes the block to a temporary file first, so a partially written block is never replayed.
+func (a *Archiver) write(block *eth.Block) error {
+	f, err := os.CreateTemp(a.dir, ".block-*")
+	if err != nil {
+		return fmt.Errorf("could not create block file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	zw := gzip.NewWriter(f)
+	err = gob.NewEncoder(zw).Encode(block)
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not write block file: %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("could not close block file: %w", err)
+	}
+
+	err = os.Rename(f.Name(), a.path(block.Number))
+	if err != nil {
+		return fmt.Errorf("could not move block file: %w", err)
+	}
+	return nil
+}
+
+// remove removes the archived block of the orphaned block's number if it's the orphaned one.
+func (a *Archiver) remove(orphan *eth.Block) error {
+	archived, err := ReadBlock(a.path(orphan.Number))
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil
+		}
+		return err
+	}
+	if archived.Hash != orphan.Hash {
+		return nil
+	}
+
+	err = os.Remove(a.path(orphan.Number))
+	if err != nil {
+		return fmt.Errorf("could not remove block file: %w", err)
+	}
+	return nil
+}
+
+func (a *Archiver) path(number int64) string {
+	return filepath.Join(a.dir, FileName(number))
+}
+
+// FileName returns the name of the archive file of the given block number, zero padded so that files sort by number.
+func FileName(number int64) string {
+	return fmt.Sprintf("%012d", number) + fileExt
+}
+
+// ParseFileName returns the block number of the given archive file name, or false if it isn't one.
+func ParseFileName(name string) (int64, bool) {
+	digits, ok := strings.CutSuffix(name, fileExt)
+	if !ok || len(digits) != 12 {
+		return 0, false
+	}
+	number, err := strconv.ParseInt(digits, 10, 64)
+	if err != nil || number < 0 {
+		return 0, false
+	}
+	return number, true
+}
+
+// ReadBlock reads an archived block file.
+func ReadBlock(path string) (*eth.Block, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open block file: %w", err)
+	}
+	defer f.Close()
+
+	return decodeBlock(f)
+}
+
+func decodeBlock(r io.Reader) (*eth.Block, error) {
+	zr, err := gzip.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("could not read block file: %w", err)
+	}
+	block := &eth.Block{}
+	err = gob.NewDecoder(zr).Decode(block)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode block file: %w", err)
+	}
+	return block, nil
+}
+
+// result returns the result label value of an operation.
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
@@ -0,0 +1,194 @@
+package archive_test
+
+import (
+	"context"
+	"math/big"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/archive"
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+func TestArchiver(t *testing.T) {
+	dir := filepath.Join(t.TempDir(), "archive")
+	archiver, err := archive.New(logrus.New(), custompromauto.Registry(), dir)
+	require.NoError(t, err)
+
+	block := &eth.Block{
+		Number:     2,
+		Hash:       "2a",
+		ParentHash: "1a",
+		Timestamp:  1700000000,
+		Txs:        []*eth.Tx{{Hash: "0x01", From: "0xaa", To: "0xbb", Value: big.NewInt(5), Raw: []byte(`{"hash":"0x01"}`)}},
+		Receipts:   []*eth.Receipt{{TxHash: "0x01", Status: eth.ReceiptStatusSuccess, Logs: []*eth.Log{{Address: "0xcc", LogIndex: 1}}}},
+	}
+	events := []*eth.BlockEvent{
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 1, Hash: "1a"}},
+		{Type: eth.BlockConfirmed, Block: block},
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 3, Hash: "3a", ParentHash: "2a"}},
+		// the orphaned block of another hash is kept, the canonical one was archived since
+		{Type: eth.BlockOrphaned, Block: &eth.Block{Number: 1, Hash: "1b"}},
+		{Type: eth.BlockOrphaned, Block: &eth.Block{Number: 3, Hash: "3a"}},
+	}
+	in := make(chan *eth.BlockEvent, len(events))
+	for event := range slices.Values(events) {
+		in <- event
+	}
+	close(in)
+	archiver.Start(context.Background(), in)
+
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+	var names []string
+	for entry := range slices.Values(entries) {
+		names = append(names, entry.Name())
+	}
+	assert.Equal(t, []string{archive.FileName(1), archive.FileName(2)}, names)
+
+	archived, err := archive.ReadBlock(filepath.Join(dir, archive.FileName(2)))
+	require.NoError(t, err)
+	assert.Equal(t, block, archived)
+}
+
+func TestReplay(t *testing.T) {
+	tests := map[string]struct {
+		fromBlock       int64
+		toBlock         int64
+		expectedNumbers []int64
+	}{
+		"up to the last block": {
+			fromBlock:       2,
+			toBlock:         -1,
+			expectedNumbers: []int64{2, 4},
+		},
+		"range": {
+			fromBlock:       1,
+			toBlock:         2,
+			expectedNumbers: []int64{1, 2},
+		},
+	}
+
+	dir := t.TempDir()
+	archiver, err := archive.New(logrus.New(), custompromauto.Registry(), dir)
+	require.NoError(t, err)
+	in := make(chan *eth.BlockEvent, 3)
+	for number := range slices.Values([]int64{4, 1, 2}) {
+		in <- &eth.BlockEvent{Type: eth.BlockConfirmed, Block: &eth.Block{Number: number}}
+	}
+	close(in)
+	archiver.Start(context.Background(), in)
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "notes.txt"), nil, 0o644))
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var numbers []int64
+			for event := range archive.Replay(context.Background(), logrus.New(), custompromauto.Registry(), archive.NewDirSource(dir), test.fromBlock, test.toBlock) {
+				assert.Equal(t, eth.BlockConfirmed, event.Type)
+				numbers = append(numbers, event.Block.Number)
+			}
+			assert.Equal(t, test.expectedNumbers, numbers)
+		})
+	}
+}
+
+func TestRecordArchive(t *testing.T) {
+	ctx := context.Background()
+	dir := filepath.Join(t.TempDir(), "cold")
+	recordArchive, err := archive.OpenRecordArchive(nil, custompromauto.Registry(), dir)
+	require.NoError(t, err)
+
+	tx1 := &store.TxRecord{
+		Hash:        "0x01",
+		From:        "0xaa",
+		To:          "0xbb",
+		Value:       big.NewInt(5),
+		BlockNumber: 1,
+		DecodedInput: &store.DecodedInput{
+			Method:    "swap",
+			Signature: "swap((address,int256),uint256[])",
+			Args: []*store.DecodedArg{
+				{Name: "order", Type: "(address,int256)", Value: map[string]any{"to": "0xbb", "delta": "-1"}},
+				{Name: "amounts", Type: "uint256[]", Value: []any{"1", "2"}},
+			},
+		},
+	}
+	tx3 := &store.TxRecord{Hash: "0x03", From: "0xaa", To: "0xcc", Value: big.NewInt(1), BlockNumber: 3}
+	transfer := &store.TokenTransferRecord{TxHash: "0x01", Token: "0xdd", Standard: store.TokenStandardERC20, From: "0xaa", To: "0xbb", Amount: "7", BlockNumber: 1}
+	event := &store.EventRecord{Contract: "0xdd", TxHash: "0x02", Topics: []string{"0xtopic"}, BlockNumber: 2}
+
+	_, err = recordArchive.Put(ctx, &store.Records{})
+	require.Error(t, err)
+
+	location, err := recordArchive.Put(ctx, &store.Records{
+		AddrToTxs:            map[string][]*store.TxRecord{"0xaa": {tx1}, "0xbb": {tx1}},
+		AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{"0xaa": {transfer}},
+		ContractToEvents:     map[string][]*store.EventRecord{"0xdd": {event}},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, archive.SegmentFileName(1, 2)), location)
+	_, err = recordArchive.Put(ctx, &store.Records{AddrToTxs: map[string][]*store.TxRecord{"0xaa": {tx3}, "0xcc": {tx3}}})
+	require.NoError(t, err)
+
+	tests := map[string]struct {
+		fromBlock       int64
+		toBlock         int64
+		expectedRecords *store.Records
+	}{
+		"every segment": {
+			fromBlock: 0,
+			toBlock:   10,
+			expectedRecords: &store.Records{
+				AddrToTxs:            map[string][]*store.TxRecord{"0xaa": {tx1, tx3}, "0xbb": {tx1}, "0xcc": {tx3}},
+				AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{"0xaa": {transfer}},
+				ContractToEvents:     map[string][]*store.EventRecord{"0xdd": {event}},
+			},
+		},
+		"records filtered to the range": {
+			fromBlock: 2,
+			toBlock:   2,
+			expectedRecords: &store.Records{
+				AddrToTxs:            map[string][]*store.TxRecord{},
+				AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{},
+				ContractToEvents:     map[string][]*store.EventRecord{"0xdd": {event}},
+			},
+		},
+		"no segment": {
+			fromBlock: 4,
+			toBlock:   10,
+			expectedRecords: &store.Records{
+				AddrToTxs:            map[string][]*store.TxRecord{},
+				AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{},
+				ContractToEvents:     map[string][]*store.EventRecord{},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			records, err := recordArchive.Get(ctx, test.fromBlock, test.toBlock)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRecords, records)
+		})
+	}
+}
+
+func TestParseSegmentFileName(t *testing.T) {
+	fromBlock, toBlock, ok := archive.ParseSegmentFileName(archive.SegmentFileName(12, 345))
+	assert.True(t, ok)
+	assert.Equal(t, int64(12), fromBlock)
+	assert.Equal(t, int64(345), toBlock)
+
+	for name := range slices.Values([]string{archive.FileName(12), "records-000000000012.gob.gz", "records-000000000345-000000000012.gob.gz"}) {
+		_, _, ok = archive.ParseSegmentFileName(name)
+		assert.False(t, ok, name)
+	}
+}
@@ -0,0 +1,32 @@
+package archive
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of the archive, registered with the registerer set by the options of its users.
+type metrics struct {
+	archivedBlocks   *prometheus.CounterVec
+	replayedBlocks   prometheus.Counter
+	archivedSegments *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		archivedBlocks: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_archived_blocks_total",
+			Help: "Total number of confirmed blocks written to the archive by result",
+		}, []string{"result"}),
+		replayedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_replayed_blocks_total",
+			Help: "Total number of archived blocks replayed",
+		}),
+		archivedSegments: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_cold_storage_segments_total",
+			Help: "Total number of record segments written to or read from cold storage by operation and result",
+		}, []string{"operation", "result"}),
+	}
+}
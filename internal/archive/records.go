@@ -0,0 +1,255 @@
+package archive
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/gob"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// segmentFilePrefix is the prefix of archived record segment files.
+const segmentFilePrefix = "records-"
+
+func init() {
+	// the decoded call arguments hold arrays and tuples as interface values
+	gob.Register([]any(nil))
+	gob.Register(map[string]any(nil))
+}
+
+// objectStore is the cold storage record segments are kept in.
+type objectStore interface {
+	// list returns the names of the stored objects.
+	list(ctx context.Context) ([]string, error)
+	read(ctx context.Context, name string) ([]byte, error)
+	write(ctx context.Context, name string, data []byte) error
+	// location returns where the object of the given name is stored, for the operators to find it.
+	location(name string) string
+}
+
+// segment is the records of a range of blocks archived together.
+type segment struct {
+	FromBlock int64
+	ToBlock   int64
+	Records   *store.Records
+}
+
+// RecordArchive keeps the records moved out of the store in cold storage, a directory or an S3 bucket, one gzipped
+// gob file per archived segment named after its block range, so they can be restored on demand.
+type RecordArchive struct {
+	objects objectStore
+	metrics *metrics
+}
+
+// OpenRecordArchive returns a RecordArchive storing to the given location, either s3://<bucket>/<prefix> or a local
+// directory, created if missing, with its metrics registered with reg. The S3 options only apply to S3 locations.
+func OpenRecordArchive(httpClient *http.Client, reg prometheus.Registerer, location string, opts ...S3Option) (*RecordArchive, error) {
+	if strings.HasPrefix(location, "s3://") {
+		bucket, prefix, err := ParseS3URL(location)
+		if err != nil {
+			return nil, err
+		}
+		return &RecordArchive{objects: NewS3Source(httpClient, bucket, prefix, opts...), metrics: newMetrics(reg)}, nil
+	}
+
+	err := os.MkdirAll(location, 0o755)
+	if err != nil {
+		return nil, fmt.Errorf("could not create cold storage directory: %w", err)
+	}
+	return &RecordArchive{objects: &dirObjects{dir: location}, metrics: newMetrics(reg)}, nil
+}
+
+// Put archives the records as a new segment, returning its location.
+func (a *RecordArchive) Put(ctx context.Context, records *store.Records) (string, error) {
+	fromBlock, toBlock, ok := records.BlockRange()
+	if !ok {
+		return "", errors.New("no records to archive")
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	err := gob.NewEncoder(zw).Encode(&segment{
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		Records:   records,
+	})
+	if err == nil {
+		err = zw.Close()
+	}
+	if err != nil {
+		return "", fmt.Errorf("could not encode records segment: %w", err)
+	}
+
+	name := SegmentFileName(fromBlock, toBlock)
+	err = a.objects.write(ctx, name, buf.Bytes())
+	a.metrics.archivedSegments.WithLabelValues("archive", result(err)).Inc()
+	if err != nil {
+		return "", fmt.Errorf("could not write records segment: %w", err)
+	}
+	return a.objects.location(name), nil
+}
+
+// Get returns the archived records of the blocks numbered from fromBlock to toBlock, inclusive, reading every segment
+// overlapping the range. Records archived more than once are returned as many times.
+func (a *RecordArchive) Get(ctx context.Context, fromBlock, toBlock int64) (*store.Records, error) {
+	names, err := a.objects.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list records segments: %w", err)
+	}
+
+	records := &store.Records{
+		AddrToTxs:            make(map[string][]*store.TxRecord),
+		AddrToTokenTransfers: make(map[string][]*store.TokenTransferRecord),
+		ContractToEvents:     make(map[string][]*store.EventRecord),
+	}
+	for name := range slices.Values(names) {
+		from, to, ok := ParseSegmentFileName(name)
+		if !ok || to < fromBlock || from > toBlock {
+			continue
+		}
+
+		seg, err := a.read(ctx, name)
+		a.metrics.archivedSegments.WithLabelValues("restore", result(err)).Inc()
+		if err != nil {
+			return nil, fmt.Errorf("could not read records segment %s: %w", name, err)
+		}
+		inRange := func(number int64) bool {
+			return number >= fromBlock && number <= toBlock
+		}
+		mergeInRange(records.AddrToTxs, seg.Records.AddrToTxs, func(r *store.TxRecord) bool {
+			return inRange(r.BlockNumber)
+		})
+		mergeInRange(records.AddrToTokenTransfers, seg.Records.AddrToTokenTransfers, func(r *store.TokenTransferRecord) bool {
+			return inRange(r.BlockNumber)
+		})
+		mergeInRange(records.ContractToEvents, seg.Records.ContractToEvents, func(r *store.EventRecord) bool {
+			return inRange(r.BlockNumber)
+		})
+	}
+	return records, nil
+}
+
+func (a *RecordArchive) read(ctx context.Context, name string) (*segment, error) {
+	data, err := a.objects.read(ctx, name)
+	if err != nil {
+		return nil, err
+	}
+	zr, err := gzip.NewReader(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("could not read segment file: %w", err)
+	}
+	seg := &segment{}
+	err = gob.NewDecoder(zr).Decode(seg)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode segment file: %w", err)
+	}
+	if seg.Records == nil {
+		seg.Records = &store.Records{}
+	}
+	return seg, nil
+}
+
+// mergeInRange appends the records of src matching the filter to dst, by key.
+func mergeInRange[T any](dst, src map[string][]T, inRange func(T) bool) {
+	for key, list := range src {
+		for record := range slices.Values(list) {
+			if inRange(record) {
+				dst[key] = append(dst[key], record)
+			}
+		}
+	}
+}
+
+// SegmentFileName returns the name of the file of the records segment of the given block range, zero padded so that
+// files sort by range.
+func SegmentFileName(fromBlock, toBlock int64) string {
+	return fmt.Sprintf("%s%012d-%012d", segmentFilePrefix, fromBlock, toBlock) + fileExt
+}
+
+// ParseSegmentFileName returns the block range of the given records segment file name, or false if it isn't one.
+func ParseSegmentFileName(name string) (int64, int64, bool) {
+	name, ok := strings.CutPrefix(name, segmentFilePrefix)
+	if !ok {
+		return 0, 0, false
+	}
+	name, ok = strings.CutSuffix(name, fileExt)
+	if !ok {
+		return 0, 0, false
+	}
+	fromDigits, toDigits, ok := strings.Cut(name, "-")
+	if !ok || len(fromDigits) != 12 || len(toDigits) != 12 {
+		return 0, 0, false
+	}
+	fromBlock, err1 := strconv.ParseInt(fromDigits, 10, 64)
+	toBlock, err2 := strconv.ParseInt(toDigits, 10, 64)
+	if err1 != nil || err2 != nil || fromBlock < 0 || fromBlock > toBlock {
+		return 0, 0, false
+	}
+	return fromBlock, toBlock, true
+}
+
+// dirObjects stores the objects as the files of a local directory.
+type dirObjects struct {
+	dir string
+}
+
+func (d *dirObjects) list(_ context.Context) ([]string, error) {
+	entries, err := os.ReadDir(d.dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list cold storage directory: %w", err)
+	}
+
+	var names []string
+	for entry := range slices.Values(entries) {
+		if entry.Type().IsRegular() && !strings.HasPrefix(entry.Name(), ".") {
+			names = append(names, entry.Name())
+		}
+	}
+	return names, nil
+}
+
+func (d *dirObjects) read(_ context.Context, name string) ([]byte, error) {
+	return os.ReadFile(filepath.Join(d.dir, name))
+}
+
+// write writes the object to a temporary file first, so a partially written object is never read.
+func (d *dirObjects) write(_ context.Context, name string, data []byte) error {
+	f, err := os.CreateTemp(d.dir, ".object-*")
+	if err != nil {
+		return fmt.Errorf("could not create file: %w", err)
+	}
+	defer func() {
+		_ = os.Remove(f.Name())
+	}()
+
+	_, err = f.Write(data)
+	if err != nil {
+		_ = f.Close()
+		return fmt.Errorf("could not write file: %w", err)
+	}
+	err = f.Close()
+	if err != nil {
+		return fmt.Errorf("could not close file: %w", err)
+	}
+
+	err = os.Rename(f.Name(), filepath.Join(d.dir, name))
+	if err != nil {
+		return fmt.Errorf("could not move file: %w", err)
+	}
+	return nil
+}
+
+func (d *dirObjects) location(name string) string {
+	return filepath.Join(d.dir, name)
+}
@@ -0,0 +1,97 @@
+package archive
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// Source is an archive blocks are replayed from.
+type Source interface {
+	// Numbers returns the numbers of the archived blocks in ascending order.
+	Numbers(ctx context.Context) ([]int64, error)
+	ReadBlock(ctx context.Context, number int64) (*eth.Block, error)
+}
+
+// DirSource reads the blocks archived to a local directory.
+type DirSource struct {
+	dir string
+}
+
+func NewDirSource(dir string) *DirSource {
+	return &DirSource{dir: dir}
+}
+
+func (s *DirSource) Numbers(_ context.Context) ([]int64, error) {
+	entries, err := os.ReadDir(s.dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not list archive directory: %w", err)
+	}
+
+	var numbers []int64
+	for entry := range slices.Values(entries) {
+		number, ok := ParseFileName(entry.Name())
+		if ok && entry.Type().IsRegular() {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+	return numbers, nil
+}
+
+func (s *DirSource) ReadBlock(_ context.Context, number int64) (*eth.Block, error) {
+	return ReadBlock(filepath.Join(s.dir, FileName(number)))
+}
+
+// Replay emits the archived blocks numbered from fromBlock to toBlock, inclusive, as confirmed block events in order,
+// closing the returned channel once they're all emitted or one can't be read, since an incomplete replay wouldn't
+// reproduce the store. A negative toBlock replays up to the last archived block. Missing blocks are skipped, the
+// indexer refetching them if gap filling is enabled. The replayed blocks are counted by the metrics registered with reg.
+func Replay(ctx context.Context, logger *logrus.Logger, reg prometheus.Registerer, source Source, fromBlock, toBlock int64) <-chan *eth.BlockEvent {
+	m := newMetrics(reg)
+	out := make(chan *eth.BlockEvent)
+
+	go func() {
+		defer close(out)
+
+		numbers, err := source.Numbers(ctx)
+		if err != nil {
+			logger.WithError(err).Error("Failed to list archived blocks, aborting replay")
+			return
+		}
+		numbers = slices.DeleteFunc(numbers, func(n int64) bool {
+			return n < fromBlock || (toBlock >= 0 && n > toBlock)
+		})
+		logger.WithFields(logrus.Fields{
+			"from_block_number": fromBlock,
+			"to_block_number":   toBlock,
+			"blocks":            len(numbers),
+		}).Info("Replaying archived blocks")
+
+		for number := range slices.Values(numbers) {
+			block, err := source.ReadBlock(ctx, number)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.WithError(err).WithField("block_number", number).Error("Failed to read archived block, aborting replay")
+				return
+			}
+			if !chans.SendOrDone(ctx, out, &eth.BlockEvent{Type: eth.BlockConfirmed, Block: block}) {
+				return
+			}
+			m.replayedBlocks.Inc()
+		}
+		logger.WithField("blocks", len(numbers)).Info("Replayed archived blocks")
+	}()
+
+	return out
+}
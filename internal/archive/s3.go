@@ -0,0 +1,318 @@
+package archive
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+)
+
+// emptyPayloadHash is the SHA-256 of an empty request body.
+const emptyPayloadHash = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+// S3Credentials are the AWS credentials requests are signed with.
+type S3Credentials struct {
+	AccessKeyID     string
+	SecretAccessKey string
+	SessionToken    string
+}
+
+type s3Config struct {
+	endpoint    string
+	region      string
+	credentials *S3Credentials
+}
+
+type S3Option func(*s3Config)
+
+// WithS3Endpoint sets the S3 compatible endpoint, e.g. http://localhost:9000 for MinIO, addressed path-style. It
+// defaults to the AWS endpoint of the region.
+func WithS3Endpoint(endpoint string) S3Option {
+	return func(c *s3Config) {
+		c.endpoint = strings.TrimSuffix(endpoint, "/")
+	}
+}
+
+// WithS3Region sets the region requests are signed for, us-east-1 by default.
+func WithS3Region(region string) S3Option {
+	return func(c *s3Config) {
+		c.region = region
+	}
+}
+
+// WithS3Credentials signs requests with the given credentials, requests being anonymous otherwise.
+func WithS3Credentials(credentials *S3Credentials) S3Option {
+	return func(c *s3Config) {
+		c.credentials = credentials
+	}
+}
+
+// S3Source reads the blocks archived to an S3 bucket, e.g. an archive directory synced to it, under a key prefix.
+type S3Source struct {
+	httpClient *http.Client
+	bucket     string
+	prefix     string
+	cfg        *s3Config
+	now        func() time.Time
+}
+
+// ParseS3URL parses an archive location given as s3://<bucket>/<prefix>.
+func ParseS3URL(location string) (bucket, prefix string, err error) {
+	u, err := url.Parse(location)
+	if err != nil || u.Scheme != "s3" || u.Host == "" {
+		return "", "", fmt.Errorf("invalid S3 location %q: expected s3://<bucket>/<prefix>", location)
+	}
+	prefix = strings.TrimPrefix(u.Path, "/")
+	if prefix != "" && !strings.HasSuffix(prefix, "/") {
+		prefix += "/"
+	}
+	return u.Host, prefix, nil
+}
+
+func NewS3Source(httpClient *http.Client, bucket, prefix string, opts ...S3Option) *S3Source {
+	cfg := &s3Config{region: "us-east-1"}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	if cfg.endpoint == "" {
+		cfg.endpoint = "https://s3." + cfg.region + ".amazonaws.com"
+	}
+
+	return &S3Source{
+		httpClient: httpClient,
+		bucket:     bucket,
+		prefix:     prefix,
+		cfg:        cfg,
+		now:        time.Now,
+	}
+}
+
+type listBucketResult struct {
+	Contents []struct {
+		Key string `xml:"Key"`
+	} `xml:"Contents"`
+	IsTruncated           bool   `xml:"IsTruncated"`
+	NextContinuationToken string `xml:"NextContinuationToken"`
+}
+
+// Numbers lists the archived blocks with ListObjectsV2, page by page.
+func (s *S3Source) Numbers(ctx context.Context) ([]int64, error) {
+	names, err := s.list(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("could not list archived blocks: %w", err)
+	}
+
+	var numbers []int64
+	for name := range slices.Values(names) {
+		number, ok := ParseFileName(name)
+		if ok {
+			numbers = append(numbers, number)
+		}
+	}
+	slices.Sort(numbers)
+	return numbers, nil
+}
+
+func (s *S3Source) ReadBlock(ctx context.Context, number int64) (*eth.Block, error) {
+	body, err := s.read(ctx, FileName(number))
+	if err != nil {
+		return nil, fmt.Errorf("could not get archived block %d: %w", number, err)
+	}
+	return decodeBlock(bytes.NewReader(body))
+}
+
+// list returns the names of the objects right under the key prefix with ListObjectsV2, page by page.
+func (s *S3Source) list(ctx context.Context) ([]string, error) {
+	var names []string
+	token := ""
+	for {
+		query := url.Values{"list-type": {"2"}, "prefix": {s.prefix}}
+		if token != "" {
+			query.Set("continuation-token", token)
+		}
+		body, err := s.do(ctx, http.MethodGet, "", query, nil)
+		if err != nil {
+			return nil, err
+		}
+		var result listBucketResult
+		err = xml.Unmarshal(body, &result)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode objects list: %w", err)
+		}
+
+		for object := range slices.Values(result.Contents) {
+			name := strings.TrimPrefix(object.Key, s.prefix)
+			if !strings.Contains(name, "/") {
+				names = append(names, name)
+			}
+		}
+		if !result.IsTruncated || result.NextContinuationToken == "" {
+			return names, nil
+		}
+		token = result.NextContinuationToken
+	}
+}
+
+// read gets the object of the given name under the key prefix.
+func (s *S3Source) read(ctx context.Context, name string) ([]byte, error) {
+	return s.do(ctx, http.MethodGet, s.prefix+name, nil, nil)
+}
+
+// write puts the object of the given name under the key prefix.
+func (s *S3Source) write(ctx context.Context, name string, data []byte) error {
+	_, err := s.do(ctx, http.MethodPut, s.prefix+name, nil, data)
+	return err
+}
+
+// location returns the s3:// URL of the object of the given name under the key prefix.
+func (s *S3Source) location(name string) string {
+	return "s3://" + s.bucket + "/" + s.prefix + name
+}
+
+// do makes a request to the object of the given key, or the bucket if empty, addressed path-style.
+func (s *S3Source) do(ctx context.Context, method, key string, query url.Values, body []byte) ([]byte, error) {
+	endpoint := s.cfg.endpoint + "/" + uriEncode(s.bucket, true)
+	if key != "" {
+		endpoint += "/" + uriEncode(key, false)
+	}
+	if len(query) > 0 {
+		endpoint += "?" + canonicalQuery(query)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	if body == nil {
+		req.Body = http.NoBody
+	} else {
+		sum := sha256.Sum256(body)
+		req.Header.Set("X-Amz-Content-Sha256", hex.EncodeToString(sum[:]))
+	}
+	if s.cfg.credentials != nil {
+		signV4(req, s.cfg.credentials, s.cfg.region, s.now())
+	}
+
+	resp, err := s.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not make http call: %w", err)
+	}
+	defer resp.Body.Close()
+
+	respBody, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read response body: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	}
+	return respBody, nil
+}
+
+// signV4 signs the request with AWS Signature Version 4, signing its host and every header set on it. The payload is
+// signed by the hash of its X-Amz-Content-Sha256 header, set to the hash of an empty payload if missing.
+func signV4(req *http.Request, credentials *S3Credentials, region string, now time.Time) {
+	amzDate := now.UTC().Format("20060102T150405Z")
+	date := amzDate[:8]
+	req.Header.Set("X-Amz-Date", amzDate)
+	payloadHash := req.Header.Get("X-Amz-Content-Sha256")
+	if payloadHash == "" {
+		payloadHash = emptyPayloadHash
+		req.Header.Set("X-Amz-Content-Sha256", payloadHash)
+	}
+	if credentials.SessionToken != "" {
+		req.Header.Set("X-Amz-Security-Token", credentials.SessionToken)
+	}
+
+	headers := map[string]string{"host": req.URL.Host}
+	for name, values := range req.Header {
+		headers[strings.ToLower(name)] = strings.TrimSpace(strings.Join(values, ","))
+	}
+	names := make([]string, 0, len(headers))
+	for name := range headers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var canonicalHeaders strings.Builder
+	for name := range slices.Values(names) {
+		canonicalHeaders.WriteString(name + ":" + headers[name] + "\n")
+	}
+	signedHeaders := strings.Join(names, ";")
+
+	canonicalRequest := strings.Join([]string{
+		req.Method,
+		uriEncode(req.URL.Path, false),
+		canonicalQuery(req.URL.Query()),
+		canonicalHeaders.String(),
+		signedHeaders,
+		payloadHash,
+	}, "\n")
+	scope := date + "/" + region + "/s3/aws4_request"
+	stringToSign := "AWS4-HMAC-SHA256\n" + amzDate + "\n" + scope + "\n" + sha256Hex(canonicalRequest)
+
+	key := hmacSHA256([]byte("AWS4"+credentials.SecretAccessKey), date)
+	for part := range slices.Values([]string{region, "s3", "aws4_request"}) {
+		key = hmacSHA256(key, part)
+	}
+	signature := hex.EncodeToString(hmacSHA256(key, stringToSign))
+
+	req.Header.Set("Authorization", fmt.Sprintf("AWS4-HMAC-SHA256 Credential=%s/%s, SignedHeaders=%s, Signature=%s",
+		credentials.AccessKeyID, scope, signedHeaders, signature))
+}
+
+// canonicalQuery encodes the query sorted by key, as signed.
+func canonicalQuery(query url.Values) string {
+	keys := make([]string, 0, len(query))
+	for key := range query {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var pairs []string
+	for key := range slices.Values(keys) {
+		values := slices.Clone(query[key])
+		sort.Strings(values)
+		for value := range slices.Values(values) {
+			pairs = append(pairs, uriEncode(key, true)+"="+uriEncode(value, true))
+		}
+	}
+	return strings.Join(pairs, "&")
+}
+
+// uriEncode percent-encodes every byte but the unreserved characters, and the slashes unless encodeSlash is set.
+func uriEncode(s string, encodeSlash bool) string {
+	var b strings.Builder
+	for c := range slices.Values([]byte(s)) {
+		switch {
+		case 'A' <= c && c <= 'Z', 'a' <= c && c <= 'z', '0' <= c && c <= '9', c == '-', c == '_', c == '.', c == '~':
+			b.WriteByte(c)
+		case c == '/' && !encodeSlash:
+			b.WriteByte(c)
+		default:
+			fmt.Fprintf(&b, "%%%02X", c)
+		}
+	}
+	return b.String()
+}
+
+func sha256Hex(s string) string {
+	sum := sha256.Sum256([]byte(s))
+	return hex.EncodeToString(sum[:])
+}
+
+func hmacSHA256(key []byte, data string) []byte {
+	h := hmac.New(sha256.New, key)
+	h.Write([]byte(data))
+	return h.Sum(nil)
+}
@@ -0,0 +1,269 @@
+package backfill
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/pipeline/chans"
+)
+
+var (
+	// ErrInvalidRange is returned when a backfill is requested for an empty or negative block range.
+	ErrInvalidRange = errors.New("invalid block range")
+	// ErrTooManyBlocks is returned when a backfill is requested for more blocks than allowed.
+	ErrTooManyBlocks = errors.New("too many blocks to backfill")
+	// ErrInProgress is returned when a backfill is requested for an address that's already being backfilled.
+	ErrInProgress = errors.New("backfill already in progress")
+	// ErrQueueFull is returned when too many backfills are already waiting to be run.
+	ErrQueueFull = errors.New("backfill queue is full")
+)
+
+// Status is the status of a backfill.
+type Status string
+
+const (
+	StatusQueued  Status = "queued"
+	StatusRunning Status = "running"
+	StatusDone    Status = "done"
+	StatusFailed  Status = "failed"
+)
+
+// BlockFetcher fetches past blocks by number in batches.
+type BlockFetcher interface {
+	GetBlocks(ctx context.Context, numbers []int64) ([]*eth.Block, error)
+}
+
+type TxStore interface {
+	InsertTransactions(ctx context.Context, addr string, txs []*store.TxRecord) error
+}
+
+// Progress is the progress of the backfill of an address. Blocks are scanned backwards, from ToBlock down to
+// FromBlock, so the most recent transactions are recorded first.
+type Progress struct {
+	Address   string
+	FromBlock int64
+	ToBlock   int64
+	// NextBlock is the next block to scan, FromBlock-1 once the backfill is done.
+	NextBlock     int64
+	ScannedBlocks int64
+	Transactions  int
+	Status        Status
+	Error         string
+	UpdatedAt     time.Time
+}
+
+type job struct {
+	sub       *store.Subscription
+	fromBlock int64
+	toBlock   int64
+}
+
+// Backfiller records the past transactions of subscribed addresses. Backfills are run one at a time, each fetching
+// its blocks in rate limited batches, so that live indexing isn't starved.
+type Backfiller struct {
+	logger   *logrus.Logger
+	fetcher  BlockFetcher
+	txStore  TxStore
+	cfg      *config
+	metrics  *metrics
+	jobs     chan *job
+	mu       sync.RWMutex
+	progress map[string]*Progress
+}
+
+func New(logger *logrus.Logger, fetcher BlockFetcher, txStore TxStore, opts ...Option) *Backfiller {
+	cfg := &config{
+		batchSize:  DefaultBatchSize,
+		interval:   DefaultInterval,
+		maxBlocks:  DefaultMaxBlocks,
+		queueSize:  DefaultQueueSize,
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Backfiller{
+		logger:   logger,
+		fetcher:  fetcher,
+		txStore:  txStore,
+		cfg:      cfg,
+		metrics:  newMetrics(cfg.registerer),
+		jobs:     make(chan *job, cfg.queueSize),
+		progress: make(map[string]*Progress),
+	}
+}
+
+// MaxBlocks returns the maximum number of blocks a single backfill can scan.
+func (b *Backfiller) MaxBlocks() int64 {
+	return b.cfg.maxBlocks
+}
+
+// Backfill queues the backfill of the transactions of the subscribed address from fromBlock to toBlock, inclusive.
+func (b *Backfiller) Backfill(_ context.Context, sub *store.Subscription, fromBlock, toBlock int64) error {
+	if fromBlock < 0 || fromBlock > toBlock {
+		return ErrInvalidRange
+	}
+	if toBlock-fromBlock+1 > b.cfg.maxBlocks {
+		return ErrTooManyBlocks
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if p, ok := b.progress[sub.Address]; ok && (p.Status == StatusQueued || p.Status == StatusRunning) {
+		return ErrInProgress
+	}
+
+	select {
+	case b.jobs <- &job{sub: sub, fromBlock: fromBlock, toBlock: toBlock}:
+	default:
+		return ErrQueueFull
+	}
+	b.progress[sub.Address] = &Progress{
+		Address:   sub.Address,
+		FromBlock: fromBlock,
+		ToBlock:   toBlock,
+		NextBlock: toBlock,
+		Status:    StatusQueued,
+		UpdatedAt: time.Now(),
+	}
+
+	return nil
+}
+
+// Progress returns the progress of the last backfill of the given address, if any.
+func (b *Backfiller) Progress(addr string) (Progress, bool) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+
+	p, ok := b.progress[addr]
+	if !ok {
+		return Progress{}, false
+	}
+	return *p, true
+}
+
+// Start runs the queued backfills one at a time until the context is cancelled.
+func (b *Backfiller) Start(ctx context.Context) {
+	// the ticker is shared between the backfills so the rate limit applies to all of them
+	ticker := time.NewTicker(b.cfg.interval)
+	defer ticker.Stop()
+
+	for j := range chans.ReceiveOrDoneSeq(ctx, b.jobs) {
+		err := b.run(ctx, ticker.C, j)
+		if err != nil {
+			if ctx.Err() != nil {
+				return
+			}
+			b.logger.WithContext(ctx).WithFields(logrus.Fields{
+				"addr":              j.sub.Address,
+				"from_block_number": j.fromBlock,
+				"to_block_number":   j.toBlock,
+			}).WithError(err).Error("Failed to backfill subscription")
+			b.update(j.sub.Address, func(p *Progress) {
+				p.Status = StatusFailed
+				p.Error = err.Error()
+			})
+			b.metrics.backfills.WithLabelValues(string(StatusFailed)).Inc()
+			continue
+		}
+
+		b.update(j.sub.Address, func(p *Progress) {
+			p.Status = StatusDone
+		})
+		b.metrics.backfills.WithLabelValues(string(StatusDone)).Inc()
+	}
+}
+
+// run scans the blocks of the given job backwards in batches, waiting for a tick before fetching each batch.
+func (b *Backfiller) run(ctx context.Context, tick <-chan time.Time, j *job) error {
+	b.update(j.sub.Address, func(p *Progress) {
+		p.Status = StatusRunning
+	})
+
+	for next := j.toBlock; next >= j.fromBlock; {
+		_, ok := chans.ReceiveOrDone(ctx, tick)
+		if !ok {
+			return ctx.Err()
+		}
+
+		first := max(j.fromBlock, next-int64(b.cfg.batchSize)+1)
+		numbers := make([]int64, 0, next-first+1)
+		for number := next; number >= first; number-- {
+			numbers = append(numbers, number)
+		}
+
+		blocks, err := b.fetcher.GetBlocks(ctx, numbers)
+		if err != nil {
+			return fmt.Errorf("could not get blocks %d to %d: %w", first, next, err)
+		}
+
+		txs := match(j.sub, blocks)
+		if len(txs) > 0 {
+			err = b.txStore.InsertTransactions(ctx, j.sub.Address, txs)
+			if err != nil {
+				return fmt.Errorf("could not insert backfilled transactions: %w", err)
+			}
+		}
+
+		b.metrics.scannedBlocks.Add(float64(len(numbers)))
+		b.metrics.backfilledTransactions.Add(float64(len(txs)))
+		b.update(j.sub.Address, func(p *Progress) {
+			p.NextBlock = first - 1
+			p.ScannedBlocks += int64(len(numbers))
+			p.Transactions += len(txs)
+		})
+		next = first - 1
+	}
+
+	return nil
+}
+
+func (b *Backfiller) update(addr string, fn func(p *Progress)) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	p := b.progress[addr]
+	fn(p)
+	p.UpdatedAt = time.Now()
+}
+
+// match returns the transactions of the given blocks sent from or to the subscribed address that pass its filters.
+func match(sub *store.Subscription, blocks []*eth.Block) []*store.TxRecord {
+	var records []*store.TxRecord
+	for block := range slices.Values(blocks) {
+		for tx := range slices.Values(block.Txs) {
+			if tx.From != sub.Address && tx.To != sub.Address {
+				continue
+			}
+			// receipts aren't fetched so the status of backfilled transactions isn't known
+			record := &store.TxRecord{
+				Hash:           tx.Hash,
+				From:           tx.From,
+				To:             tx.To,
+				Value:          tx.Value,
+				BlockNumber:    block.Number,
+				BlockHash:      block.Hash,
+				Raw:            tx.Raw,
+				BlockTimestamp: block.Timestamp,
+				Type:           store.TxTypeOf(tx.Type),
+			}
+			if !sub.Accepts(record) {
+				continue
+			}
+			records = append(records, record)
+		}
+	}
+
+	return records
+}
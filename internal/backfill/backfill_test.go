@@ -0,0 +1,254 @@
+package backfill_test
+
+import (
+	"context"
+	"errors"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/backfill/mocks"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+//go:generate moq -out mocks/block_fetcher.go -pkg mocks -skip-ensure . BlockFetcher
+//go:generate moq -out mocks/tx_store.go -pkg mocks -skip-ensure . TxStore
+
+const (
+	subscribedAddr = "0x00000000000000000000000000000000000000aa"
+	otherAddr      = "0x00000000000000000000000000000000000000bb"
+)
+
+func TestBackfill(t *testing.T) {
+	tests := map[string]struct {
+		fromBlock   int64
+		toBlock     int64
+		progress    bool
+		expectedErr error
+	}{
+		"negative from block": {
+			fromBlock:   -1,
+			toBlock:     10,
+			expectedErr: backfill.ErrInvalidRange,
+		},
+		"from block after to block": {
+			fromBlock:   11,
+			toBlock:     10,
+			expectedErr: backfill.ErrInvalidRange,
+		},
+		"too many blocks": {
+			fromBlock:   0,
+			toBlock:     100,
+			expectedErr: backfill.ErrTooManyBlocks,
+		},
+		"max blocks": {
+			fromBlock: 1,
+			toBlock:   100,
+			progress:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			b := backfill.New(logrus.New(), nil, nil, backfill.WithMaxBlocks(100))
+			sub := &store.Subscription{Address: subscribedAddr}
+			err := b.Backfill(context.Background(), sub, test.fromBlock, test.toBlock)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+			} else {
+				require.NoError(t, err)
+			}
+
+			progress, ok := b.Progress(subscribedAddr)
+			require.Equal(t, test.progress, ok)
+			if ok {
+				assert.Equal(t, backfill.StatusQueued, progress.Status)
+				assert.Equal(t, test.toBlock, progress.NextBlock)
+			}
+		})
+	}
+}
+
+func TestBackfillRejectsInProgressAndFullQueue(t *testing.T) {
+	b := backfill.New(logrus.New(), nil, nil, backfill.WithQueueSize(1))
+	ctx := context.Background()
+
+	err := b.Backfill(ctx, &store.Subscription{Address: subscribedAddr}, 1, 10)
+	require.NoError(t, err)
+
+	err = b.Backfill(ctx, &store.Subscription{Address: subscribedAddr}, 1, 10)
+	assert.ErrorIs(t, err, backfill.ErrInProgress)
+
+	err = b.Backfill(ctx, &store.Subscription{Address: otherAddr}, 1, 10)
+	assert.ErrorIs(t, err, backfill.ErrQueueFull)
+	_, ok := b.Progress(otherAddr)
+	assert.False(t, ok)
+}
+
+func TestStartBackfillsTransactions(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fetcher := &mocks.BlockFetcherMock{
+		GetBlocksFunc: func(ctx context.Context, numbers []int64) ([]*eth.Block, error) {
+			blocks := make([]*eth.Block, 0, len(numbers))
+			for _, number := range numbers {
+				blocks = append(blocks, testBlock(number))
+			}
+			return blocks, nil
+		},
+	}
+	txStore := memdb.NewTxStore()
+	// a live transaction recorded before the backfill is kept after the backfilled ones
+	err := txStore.InsertBlock(ctx, &store.Block{
+		Number:    8,
+		AddrToTxs: map[string][]*store.TxRecord{subscribedAddr: {{Hash: "0x8", BlockNumber: 8}}},
+	})
+	require.NoError(t, err)
+
+	b := backfill.New(logrus.New(), fetcher, txStore,
+		backfill.WithBatchSize(2),
+		backfill.WithInterval(time.Millisecond),
+	)
+	go b.Start(ctx)
+
+	sub := &store.Subscription{Address: subscribedAddr, MinValue: big.NewInt(10)}
+	err = b.Backfill(ctx, sub, 3, 7)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		progress, _ := b.Progress(subscribedAddr)
+		return progress.Status == backfill.StatusDone
+	}, time.Second, time.Millisecond)
+
+	progress, _ := b.Progress(subscribedAddr)
+	assert.Equal(t, int64(2), progress.NextBlock)
+	assert.Equal(t, int64(5), progress.ScannedBlocks)
+	assert.Equal(t, 5, progress.Transactions)
+
+	calls := fetcher.GetBlocksCalls()
+	require.Len(t, calls, 3)
+	assert.Equal(t, []int64{7, 6}, calls[0].Numbers)
+	assert.Equal(t, []int64{5, 4}, calls[1].Numbers)
+	assert.Equal(t, []int64{3}, calls[2].Numbers)
+
+	txs, err := txStore.GetTransactions(ctx, subscribedAddr)
+	require.NoError(t, err)
+	var hashes []string
+	for _, tx := range txs {
+		hashes = append(hashes, tx.Hash)
+	}
+	assert.Equal(t, []string{"0x3", "0x4", "0x5", "0x6", "0x7", "0x8"}, hashes)
+}
+
+func TestStartRecordsFailedBackfills(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	fetcher := &mocks.BlockFetcherMock{
+		GetBlocksFunc: func(ctx context.Context, numbers []int64) ([]*eth.Block, error) {
+			return []*eth.Block{testBlock(numbers[0])}, nil
+		},
+	}
+	txStore := &mocks.TxStoreMock{
+		InsertTransactionsFunc: func(ctx context.Context, addr string, txs []*store.TxRecord) error {
+			return errors.New("store is down")
+		},
+	}
+
+	b := backfill.New(logrus.New(), fetcher, txStore, backfill.WithInterval(time.Millisecond))
+	go b.Start(ctx)
+
+	err := b.Backfill(ctx, &store.Subscription{Address: subscribedAddr}, 5, 5)
+	require.NoError(t, err)
+
+	require.Eventually(t, func() bool {
+		progress, _ := b.Progress(subscribedAddr)
+		return progress.Status == backfill.StatusFailed
+	}, time.Second, time.Millisecond)
+
+	progress, _ := b.Progress(subscribedAddr)
+	assert.Contains(t, progress.Error, "store is down")
+	assert.Equal(t, int64(5), progress.NextBlock)
+
+	// failed backfills can be requested again
+	err = b.Backfill(ctx, &store.Subscription{Address: subscribedAddr}, 5, 5)
+	assert.NoError(t, err)
+}
+
+// testBlock returns a block holding a transaction of the subscribed address that passes its filters, one that
+// doesn't, and one of another address.
+func testBlock(number int64) *eth.Block {
+	hash := "0x" + big.NewInt(number).Text(16)
+	return &eth.Block{
+		Number: number,
+		Hash:   "0xblock" + hash,
+		Txs: []*eth.Tx{
+			{Hash: hash, From: otherAddr, To: subscribedAddr, Value: big.NewInt(10)},
+			{Hash: hash + "ff", From: subscribedAddr, To: otherAddr, Value: big.NewInt(1)},
+			{Hash: hash + "ee", From: otherAddr, To: otherAddr, Value: big.NewInt(100)},
+		},
+	}
+}
+
+func TestBlocks(t *testing.T) {
+	tests := map[string]struct {
+		failAt          int64
+		expectedNumbers []int64
+		expectedCalls   [][]int64
+	}{
+		"all blocks in order": {
+			expectedNumbers: []int64{3, 4, 5, 6, 7},
+			expectedCalls:   [][]int64{{3, 4}, {5, 6}, {7}},
+		},
+		"aborted on fetch failure": {
+			failAt:          5,
+			expectedNumbers: []int64{3, 4},
+			expectedCalls:   [][]int64{{3, 4}, {5, 6}},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+
+			fetcher := &mocks.BlockFetcherMock{
+				GetBlocksFunc: func(ctx context.Context, numbers []int64) ([]*eth.Block, error) {
+					blocks := make([]*eth.Block, 0, len(numbers))
+					for _, number := range numbers {
+						if number == test.failAt {
+							return nil, errors.New("node unavailable")
+						}
+						blocks = append(blocks, testBlock(number))
+					}
+					return blocks, nil
+				},
+			}
+
+			out := backfill.Blocks(ctx, logrus.New(), fetcher, 3, 7,
+				backfill.WithBatchSize(2),
+				backfill.WithInterval(time.Millisecond),
+			)
+			var numbers []int64
+			for event := range out {
+				assert.Equal(t, eth.BlockConfirmed, event.Type)
+				numbers = append(numbers, event.Block.Number)
+			}
+			assert.Equal(t, test.expectedNumbers, numbers)
+
+			var calls [][]int64
+			for _, call := range fetcher.GetBlocksCalls() {
+				calls = append(calls, call.Numbers)
+			}
+			assert.Equal(t, test.expectedCalls, calls)
+		})
+	}
+}
@@ -0,0 +1,71 @@
+package backfill
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// Blocks emits the blocks numbered from fromBlock to toBlock, inclusive, as confirmed block events in chain order,
+// fetching them in batches rate limited the same way as the subscription backfills. The returned channel is closed
+// once they're all emitted or a batch can't be fetched. Receipts aren't fetched.
+func Blocks(ctx context.Context, logger *logrus.Logger, fetcher BlockFetcher, fromBlock, toBlock int64, opts ...Option) <-chan *eth.BlockEvent {
+	cfg := &config{
+		batchSize: DefaultBatchSize,
+		interval:  DefaultInterval,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	out := make(chan *eth.BlockEvent)
+
+	go func() {
+		defer close(out)
+
+		ticker := time.NewTicker(cfg.interval)
+		defer ticker.Stop()
+
+		logger.WithFields(logrus.Fields{
+			"from_block_number": fromBlock,
+			"to_block_number":   toBlock,
+		}).Info("Fetching blocks")
+		for next := fromBlock; next <= toBlock; {
+			_, ok := chans.ReceiveOrDone(ctx, ticker.C)
+			if !ok {
+				return
+			}
+
+			last := min(toBlock, next+int64(cfg.batchSize)-1)
+			numbers := make([]int64, 0, last-next+1)
+			for number := next; number <= last; number++ {
+				numbers = append(numbers, number)
+			}
+
+			blocks, err := fetcher.GetBlocks(ctx, numbers)
+			if err != nil {
+				if ctx.Err() != nil {
+					return
+				}
+				logger.WithError(err).WithFields(logrus.Fields{
+					"from_block_number": next,
+					"to_block_number":   last,
+				}).Error("Failed to fetch blocks, aborting")
+				return
+			}
+			for block := range slices.Values(blocks) {
+				if !chans.SendOrDone(ctx, out, &eth.BlockEvent{Type: eth.BlockConfirmed, Block: block}) {
+					return
+				}
+			}
+			next = last + 1
+		}
+		logger.WithField("blocks", toBlock-fromBlock+1).Info("Fetched blocks")
+	}()
+
+	return out
+}
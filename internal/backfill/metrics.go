@@ -0,0 +1,32 @@
+package backfill
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of a Backfiller, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	scannedBlocks          prometheus.Counter
+	backfilledTransactions prometheus.Counter
+	backfills              *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		scannedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_backfill_scanned_blocks_total",
+			Help: "Total number of past blocks scanned by subscription backfills",
+		}),
+		backfilledTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_backfill_transactions_total",
+			Help: "Total number of past transactions recorded by subscription backfills",
+		}),
+		backfills: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_backfills_total",
+			Help: "Total number of finished subscription backfills, by result",
+		}, []string{"result"}),
+	}
+}
@@ -0,0 +1,73 @@
+package backfill
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	// DefaultBatchSize is the default number of blocks fetched in a single batched call.
+	DefaultBatchSize = 10
+	// DefaultInterval is the default minimum time between two batched calls.
+	DefaultInterval = 500 * time.Millisecond
+	// DefaultMaxBlocks is the default maximum number of blocks a single backfill can scan.
+	DefaultMaxBlocks = 10_000
+	// DefaultQueueSize is the default number of backfills waiting to be run.
+	DefaultQueueSize = 100
+)
+
+type config struct {
+	batchSize  int
+	interval   time.Duration
+	maxBlocks  int64
+	queueSize  int
+	registerer prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithBatchSize sets the number of blocks fetched in a single batched call.
+func WithBatchSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.batchSize = size
+		}
+	}
+}
+
+// WithInterval sets the minimum time between two batched calls, rate limiting the backfills so the node isn't
+// overwhelmed and live indexing isn't starved.
+func WithInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.interval = interval
+		}
+	}
+}
+
+// WithMaxBlocks sets the maximum number of blocks a single backfill can scan.
+func WithMaxBlocks(maxBlocks int64) Option {
+	return func(c *config) {
+		if maxBlocks > 0 {
+			c.maxBlocks = maxBlocks
+		}
+	}
+}
+
+// WithQueueSize sets the number of backfills waiting to be run, more are rejected.
+func WithQueueSize(size int) Option {
+	return func(c *config) {
+		if size > 0 {
+			c.queueSize = size
+		}
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the backfiller are registered with, the custom registry by
+// default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
@@ -0,0 +1,82 @@
+package bloom
+
+import (
+	"hash/maphash"
+	"iter"
+	"math"
+	"slices"
+)
+
+// Filter is a bloom filter of strings. It reports false positives at about the rate it's created with, but never
+// false negatives. It's not safe for concurrent writes; build it first, then share it for reads only.
+type Filter struct {
+	bits   []uint64
+	m      uint64
+	hashes uint64
+	seed   maphash.Seed
+}
+
+// New creates a Filter sized for the given number of items and false positive rate.
+// At least one item and a rate in (0, 1) are assumed for the sizing.
+func New(items int, falsePositiveRate float64) *Filter {
+	n := float64(max(1, items))
+	if falsePositiveRate <= 0 || falsePositiveRate >= 1 {
+		falsePositiveRate = 0.01
+	}
+
+	// optimal number of bits and hash functions for n items at the given rate
+	m := math.Ceil(-n * math.Log(falsePositiveRate) / (math.Ln2 * math.Ln2))
+	k := math.Max(1, math.Round(m/n*math.Ln2))
+	words := (uint64(m) + 63) / 64
+	return &Filter{
+		bits:   make([]uint64, words),
+		m:      words * 64,
+		hashes: uint64(k),
+		seed:   maphash.MakeSeed(),
+	}
+}
+
+// Clone returns a copy of the filter, to add items to while the filter is shared for reads.
+func (f *Filter) Clone() *Filter {
+	clone := *f
+	clone.bits = slices.Clone(f.bits)
+	return &clone
+}
+
+// Add adds the given item to the filter.
+func (f *Filter) Add(item string) {
+	for bit := range f.probes(item) {
+		f.bits[bit/64] |= 1 << (bit % 64)
+	}
+}
+
+// MayContain reports whether the item may have been added to the filter. A false result means it was definitely not.
+func (f *Filter) MayContain(item string) bool {
+	for bit := range f.probes(item) {
+		if f.bits[bit/64]&(1<<(bit%64)) == 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// probes returns the bits of the item, one per hash function, each drawn from a SplitMix64 generator seeded with the
+// hash of the item. Deriving them from two hashes as h1 + i*h2 instead leaves small filters, whose sizes are a few
+// multiples of 64, only as many probe sequences as the low bits of the hashes allow, so different items collide far
+// more often than the false positive rate. The seed is random per filter, so filters are only meaningful within the
+// process.
+func (f *Filter) probes(item string) iter.Seq[uint64] {
+	return func(yield func(uint64) bool) {
+		state := maphash.String(f.seed, item)
+		for range f.hashes {
+			state += 0x9e3779b97f4a7c15
+			z := state
+			z = (z ^ (z >> 30)) * 0xbf58476d1ce4e5b9
+			z = (z ^ (z >> 27)) * 0x94d049bb133111eb
+			z ^= z >> 31
+			if !yield(z % f.m) {
+				return
+			}
+		}
+	}
+}
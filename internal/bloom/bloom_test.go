@@ -0,0 +1,87 @@
+package bloom_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/bloom"
+)
+
+func TestFilter(t *testing.T) {
+	tests := map[string]struct {
+		items             int
+		falsePositiveRate float64
+	}{
+		"one percent": {
+			items:             1000,
+			falsePositiveRate: 0.01,
+		},
+		"one in a thousand": {
+			items:             1000,
+			falsePositiveRate: 0.001,
+		},
+		"invalid rate falls back to the default": {
+			items:             1000,
+			falsePositiveRate: 2,
+		},
+		"no items": {
+			items:             0,
+			falsePositiveRate: 0.01,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			f := bloom.New(test.items, test.falsePositiveRate)
+			for i := range test.items {
+				f.Add(fmt.Sprintf("0x%040x", i))
+			}
+
+			for i := range test.items {
+				assert.True(t, f.MayContain(fmt.Sprintf("0x%040x", i)), "added items must always be reported")
+			}
+
+			if test.items == 0 {
+				assert.False(t, f.MayContain("0x00000000000000000000000000000000000000aa"))
+				return
+			}
+
+			rate := test.falsePositiveRate
+			if rate >= 1 {
+				rate = 0.01
+			}
+			const lookups = 100_000
+			falsePositives := 0
+			for i := range lookups {
+				if f.MayContain(fmt.Sprintf("0x%040x", test.items+i)) {
+					falsePositives++
+				}
+			}
+			// allow for some variance over the expected rate
+			assert.Less(t, float64(falsePositives)/lookups, rate*2)
+		})
+	}
+}
+
+func TestFilterSmall(t *testing.T) {
+	// different items must not share their probes in filters of a few bits, whatever their seeds
+	for range 10_000 {
+		f := bloom.New(1, 1e-9)
+		f.Add("0x00000000000000000000000000000000000000aa")
+		require.False(t, f.MayContain("0x00000000000000000000000000000000000000bb"))
+	}
+}
+
+func TestFilterClone(t *testing.T) {
+	f := bloom.New(10, 1e-9)
+	f.Add("0x00000000000000000000000000000000000000aa")
+	clone := f.Clone()
+	clone.Add("0x00000000000000000000000000000000000000bb")
+
+	assert.True(t, clone.MayContain("0x00000000000000000000000000000000000000aa"))
+	assert.True(t, clone.MayContain("0x00000000000000000000000000000000000000bb"))
+	assert.False(t, f.MayContain("0x00000000000000000000000000000000000000bb"), "the original isn't updated")
+}
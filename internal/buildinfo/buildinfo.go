@@ -0,0 +1,73 @@
+// Package buildinfo reports the version, commit and build date of the binary.
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+	"slices"
+)
+
+// Set at build time with
+// -ldflags "-X github.com/hedisam/ethtxparser/internal/buildinfo.Version=... -X ...Commit=... -X ...Date=...",
+// otherwise they're read from the build info embedded by the Go toolchain where available.
+var (
+	Version string
+	Commit  string
+	Date    string
+)
+
+// Info describes the build of the binary.
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"goVersion"`
+}
+
+// String returns the info as a single line, as printed by --version.
+func (i Info) String() string {
+	return fmt.Sprintf("ethtxparser %s (commit %s, built %s, %s)", i.Version, i.Commit, i.Date, i.GoVersion)
+}
+
+// Get returns the build info of the binary, the values set with ldflags taking precedence over the ones embedded by
+// the Go toolchain. Unknown values are reported as "unknown".
+func Get() Info {
+	info := Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if info.Version == "" && bi.Main.Version != "(devel)" {
+			info.Version = bi.Main.Version
+		}
+		for setting := range slices.Values(bi.Settings) {
+			switch {
+			case setting.Key == "vcs.revision" && info.Commit == "":
+				info.Commit = setting.Value
+			case setting.Key == "vcs.time" && info.Date == "":
+				info.Date = setting.Value
+			}
+		}
+	}
+
+	info.Version = orUnknown(info.Version)
+	info.Commit = orUnknown(info.Commit)
+	info.Date = orUnknown(info.Date)
+	return info
+}
+
+func orUnknown(s string) string {
+	if s == "" {
+		return "unknown"
+	}
+	return s
+}
+
+// ExportMetric exports the build info as the labels of the ethtxparser_build_info metric, always set to 1.
+func ExportMetric() {
+	info := Get()
+	buildInfo.WithLabelValues(info.Version, info.Commit, info.Date, info.GoVersion).Set(1)
+}
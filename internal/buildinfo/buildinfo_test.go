@@ -0,0 +1,49 @@
+package buildinfo_test
+
+import (
+	"runtime"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedisam/ethtxparser/internal/buildinfo"
+)
+
+func TestGet(t *testing.T) {
+	tests := map[string]struct {
+		version, commit, date string
+		expectedInfo          buildinfo.Info
+	}{
+		"set with ldflags": {
+			version: "v1.2.3",
+			commit:  "abc123",
+			date:    "2026-01-02T03:04:05Z",
+			expectedInfo: buildinfo.Info{
+				Version:   "v1.2.3",
+				Commit:    "abc123",
+				Date:      "2026-01-02T03:04:05Z",
+				GoVersion: runtime.Version(),
+			},
+		},
+		"unknown": {
+			// test binaries embed neither a module version nor vcs settings
+			expectedInfo: buildinfo.Info{
+				Version:   "unknown",
+				Commit:    "unknown",
+				Date:      "unknown",
+				GoVersion: runtime.Version(),
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			buildinfo.Version, buildinfo.Commit, buildinfo.Date = test.version, test.commit, test.date
+			t.Cleanup(func() {
+				buildinfo.Version, buildinfo.Commit, buildinfo.Date = "", "", ""
+			})
+
+			assert.Equal(t, test.expectedInfo, buildinfo.Get())
+		})
+	}
+}
@@ -0,0 +1,12 @@
+package buildinfo
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+var buildInfo = custompromauto.Auto().NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ethtxparser_build_info",
+	Help: "Build info of the binary, always 1, labelled by version, commit, build date and Go version",
+}, []string{"version", "commit", "date", "go_version"})
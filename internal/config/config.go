@@ -0,0 +1,330 @@
+// Package config loads flag values from a YAML file and environment variables.
+package config
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+const (
+	// ChainsKey is the key of the chain sections of the config file.
+	ChainsKey = "chains"
+	// FeaturesKey is the key of the features block of the config file and its chain sections.
+	FeaturesKey = "features"
+)
+
+var chainNameRegex = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+
+// Features maps the names of the optional features of the features block to the boolean flags toggling them.
+type Features map[string][]string
+
+type config struct {
+	features Features
+}
+
+type Option func(*config)
+
+// WithFeatures sets the features the features block can toggle, none by default.
+func WithFeatures(features Features) Option {
+	return func(c *config) {
+		c.features = features
+	}
+}
+
+// Chain is a chain section of the config file, whose options override the top-level ones for the pipeline of that
+// chain.
+type Chain struct {
+	Name string
+	// options is the mapping of the chain's option names to values, name included.
+	options  *yaml.Node
+	features Features
+}
+
+// Apply sets the flags of the set from the features block and the options of the chain, every option overriding the
+// value already set, the features' included.
+func (c *Chain) Apply(fs *flag.FlagSet) error {
+	err := applyFeatures(fs, c.options, c.features, func(string) bool {
+		return false
+	})
+	if err == nil {
+		err = apply(fs, c.options, func(name string) bool {
+			return name == "name" || name == FeaturesKey
+		})
+	}
+	if err != nil {
+		return fmt.Errorf("chain %q: %w", c.Name, err)
+	}
+	return nil
+}
+
+// LoadFile sets the flags of the set from the YAML file at the given path and returns its chain sections, see Load.
+func LoadFile(fs *flag.FlagSet, path string, opts ...Option) ([]*Chain, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not read config file: %w", err)
+	}
+
+	chains, err := Load(fs, data, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return chains, nil
+}
+
+// Load sets the flags of the set from a YAML mapping of flag names to values, skipping the flags already set on the
+// command line so they override the file. Lists are joined with commas, for the flags taking comma separated values.
+// Every unknown option and invalid value is reported along with its line.
+// The features block under FeaturesKey maps the names of the features given WithFeatures to whether they're enabled,
+// setting their flags to true or false, the options of the file overriding them.
+// The chain sections listed under ChainsKey, each a mapping of options with a unique name, are returned to be applied
+// to the flag sets of their own pipelines.
+func Load(fs *flag.FlagSet, data []byte, opts ...Option) ([]*Chain, error) {
+	cfg := &config{}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	var doc yaml.Node
+	err := yaml.Unmarshal(data, &doc)
+	if err != nil {
+		return nil, fmt.Errorf("invalid YAML: %w", err)
+	}
+	if len(doc.Content) == 0 {
+		return nil, nil
+	}
+	root := doc.Content[0]
+	if root.Kind != yaml.MappingNode {
+		return nil, fmt.Errorf("line %d: expected a mapping of option names to values", root.Line)
+	}
+
+	setOnCommandLine := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		setOnCommandLine[f.Name] = true
+	})
+	err = applyFeatures(fs, root, cfg.features, func(name string) bool {
+		return setOnCommandLine[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+	err = apply(fs, root, func(name string) bool {
+		return name == ChainsKey || name == FeaturesKey || setOnCommandLine[name]
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := 0; i < len(root.Content); i += 2 {
+		if root.Content[i].Value == ChainsKey {
+			return parseChains(root.Content[i+1], cfg.features)
+		}
+	}
+	return nil, nil
+}
+
+// applyFeatures sets the flags of the features of the features block of the given mapping, if any, except the skipped
+// flags.
+func applyFeatures(fs *flag.FlagSet, mapping *yaml.Node, features Features, skip func(name string) bool) error {
+	var block *yaml.Node
+	for i := 0; i < len(mapping.Content); i += 2 {
+		if mapping.Content[i].Value == FeaturesKey {
+			block = mapping.Content[i+1]
+		}
+	}
+	if block == nil {
+		return nil
+	}
+	if block.Kind != yaml.MappingNode {
+		return fmt.Errorf("line %d: expected a mapping of feature names to whether they're enabled", block.Line)
+	}
+
+	var errs []error
+	for i := 0; i < len(block.Content); i += 2 {
+		key, node := block.Content[i], block.Content[i+1]
+		flags, ok := features[key.Value]
+		if !ok {
+			errs = append(errs, fmt.Errorf("line %d: unknown feature %q%s", key.Line, key.Value, suggestion(slices.Collect(maps.Keys(features)), key.Value)))
+			continue
+		}
+		enabled, err := strconv.ParseBool(node.Value)
+		if err != nil || node.Kind != yaml.ScalarNode {
+			errs = append(errs, fmt.Errorf("line %d: invalid value of feature %q: expected true or false", node.Line, key.Value))
+			continue
+		}
+		for name := range slices.Values(flags) {
+			if skip(name) {
+				continue
+			}
+			err = fs.Set(name, strconv.FormatBool(enabled))
+			if err != nil {
+				errs = append(errs, fmt.Errorf("line %d: feature %q: %w", node.Line, key.Value, err))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// apply sets the flags of the set from a YAML mapping of flag names to values, except the skipped ones.
+func apply(fs *flag.FlagSet, mapping *yaml.Node, skip func(name string) bool) error {
+	var errs []error
+	for i := 0; i < len(mapping.Content); i += 2 {
+		key, node := mapping.Content[i], mapping.Content[i+1]
+		if skip(key.Value) {
+			continue
+		}
+		f := fs.Lookup(key.Value)
+		if f == nil {
+			errs = append(errs, fmt.Errorf("line %d: unknown option %q%s", key.Line, key.Value, suggestion(flagNames(fs), key.Value)))
+			continue
+		}
+
+		value, err := scalarValue(node)
+		if err == nil {
+			err = fs.Set(f.Name, value)
+		}
+		if err != nil {
+			errs = append(errs, fmt.Errorf("line %d: invalid value of option %q: %w", node.Line, key.Value, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// parseChains returns the chain sections of the given list, whose features blocks can toggle the given features.
+func parseChains(node *yaml.Node, features Features) ([]*Chain, error) {
+	if node.Kind != yaml.SequenceNode {
+		return nil, fmt.Errorf("line %d: expected a list of chains", node.Line)
+	}
+
+	chains := make([]*Chain, 0, len(node.Content))
+	names := make(map[string]bool, len(node.Content))
+	var errs []error
+	for item := range slices.Values(node.Content) {
+		if item.Kind != yaml.MappingNode {
+			errs = append(errs, fmt.Errorf("line %d: expected a mapping of the chain's option names to values", item.Line))
+			continue
+		}
+		var name string
+		for i := 0; i < len(item.Content); i += 2 {
+			if item.Content[i].Value == "name" {
+				name = item.Content[i+1].Value
+			}
+		}
+		switch {
+		case !chainNameRegex.MatchString(name):
+			errs = append(errs, fmt.Errorf("line %d: chain name %q must be lower case letters, digits and dashes", item.Line, name))
+			continue
+		case names[name]:
+			errs = append(errs, fmt.Errorf("line %d: duplicate chain %q", item.Line, name))
+			continue
+		}
+		names[name] = true
+		chains = append(chains, &Chain{Name: name, options: item, features: features})
+	}
+	return chains, errors.Join(errs...)
+}
+
+// scalarValue returns the value of a scalar node, or the comma joined values of a list of scalars.
+func scalarValue(node *yaml.Node) (string, error) {
+	switch node.Kind {
+	case yaml.ScalarNode:
+		return node.Value, nil
+	case yaml.SequenceNode:
+		values := make([]string, 0, len(node.Content))
+		for item := range slices.Values(node.Content) {
+			if item.Kind != yaml.ScalarNode {
+				return "", errors.New("expected a list of values")
+			}
+			values = append(values, item.Value)
+		}
+		return strings.Join(values, ","), nil
+	default:
+		return "", errors.New("expected a value or a list of values")
+	}
+}
+
+// flagNames returns the names of the flags of the set.
+func flagNames(fs *flag.FlagSet) []string {
+	var names []string
+	fs.VisitAll(func(f *flag.Flag) {
+		names = append(names, f.Name)
+	})
+	return names
+}
+
+// suggestion returns a hint naming the known name closest to the unknown one, if it's a likely typo.
+func suggestion(known []string, name string) string {
+	best, bestDistance := "", 3
+	for candidate := range slices.Values(slices.Sorted(slices.Values(known))) {
+		d := distance(name, candidate)
+		if d < bestDistance {
+			best, bestDistance = candidate, d
+		}
+	}
+	if best == "" {
+		return ""
+	}
+	return fmt.Sprintf(", did you mean %q?", best)
+}
+
+// distance returns the Levenshtein distance between two strings.
+func distance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		curr := make([]int, len(b)+1)
+		curr[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			curr[j] = min(prev[j]+1, curr[j-1]+1, prev[j-1]+cost)
+		}
+		prev = curr
+	}
+	return prev[len(b)]
+}
+
+// EnvName returns the name of the environment variable of a flag, the upper-cased flag name with dashes replaced by
+// underscores, after the prefix.
+func EnvName(prefix, flagName string) string {
+	return prefix + strings.ToUpper(strings.ReplaceAll(flagName, "-", "_"))
+}
+
+// LoadEnv sets the flags of the set from the environment variables named by EnvName, skipping the flags already set,
+// on the command line or from a file, so they take precedence over the environment. The lookup is usually
+// os.LookupEnv.
+func LoadEnv(fs *flag.FlagSet, prefix string, lookup func(string) (string, bool)) error {
+	alreadySet := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		alreadySet[f.Name] = true
+	})
+
+	var errs []error
+	fs.VisitAll(func(f *flag.Flag) {
+		if alreadySet[f.Name] {
+			return
+		}
+		name := EnvName(prefix, f.Name)
+		value, ok := lookup(name)
+		if !ok {
+			return
+		}
+		err := fs.Set(f.Name, value)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("invalid value of %s: %w", name, err))
+		}
+	})
+	return errors.Join(errs...)
+}
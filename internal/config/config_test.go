@@ -0,0 +1,284 @@
+package config_test
+
+import (
+	"flag"
+	"io"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/config"
+)
+
+type options struct {
+	ServerAddr   string
+	PollInterval time.Duration
+	Depth        uint
+	Webhooks     bool
+	Chat         bool
+	Channels     string
+}
+
+var features = config.Features{
+	"notifications": {"webhooks", "chat-notifications"},
+	"webhooks":      {"webhooks"},
+}
+
+func newFlagSet(opts *options) *flag.FlagSet {
+	fs := flag.NewFlagSet("test", flag.ContinueOnError)
+	fs.SetOutput(io.Discard)
+	fs.StringVar(&opts.ServerAddr, "server-addr", "localhost:8080", "")
+	fs.DurationVar(&opts.PollInterval, "poll-interval", 10*time.Second, "")
+	fs.UintVar(&opts.Depth, "reorg-confirmation-depth", 3, "")
+	fs.BoolVar(&opts.Webhooks, "webhooks", false, "")
+	fs.BoolVar(&opts.Chat, "chat-notifications", false, "")
+	fs.StringVar(&opts.Channels, "chat-channels", "", "")
+	return fs
+}
+
+func TestLoad(t *testing.T) {
+	tests := map[string]struct {
+		args            []string
+		yaml            string
+		expectedOptions options
+		expectedErr     string
+	}{
+		"file values": {
+			yaml: `
+server-addr: 0.0.0.0:9090
+poll-interval: 12s
+reorg-confirmation-depth: 5
+webhooks: true
+chat-channels:
+  - slack:https://hooks.slack.com/a
+  - discord:https://discord.com/b
+`,
+			expectedOptions: options{
+				ServerAddr:   "0.0.0.0:9090",
+				PollInterval: 12 * time.Second,
+				Depth:        5,
+				Webhooks:     true,
+				Channels:     "slack:https://hooks.slack.com/a,discord:https://discord.com/b",
+			},
+		},
+		"command line overrides the file": {
+			args: []string{"--reorg-confirmation-depth", "7"},
+			yaml: "reorg-confirmation-depth: 5\nserver-addr: 0.0.0.0:9090\n",
+			expectedOptions: options{
+				ServerAddr:   "0.0.0.0:9090",
+				PollInterval: 10 * time.Second,
+				Depth:        7,
+			},
+		},
+		"empty file": {
+			expectedOptions: options{
+				ServerAddr:   "localhost:8080",
+				PollInterval: 10 * time.Second,
+				Depth:        3,
+			},
+		},
+		"invalid values and unknown options": {
+			yaml:        "poll-interval: soon\nsever-addr: 0.0.0.0:9090\nwebhooks:\n  enabled: true\n",
+			expectedErr: "line 1: invalid value of option \"poll-interval\": parse error\nline 2: unknown option \"sever-addr\", did you mean \"server-addr\"?\nline 4: invalid value of option \"webhooks\": expected a value or a list of values",
+		},
+		"not a mapping": {
+			yaml:        "- server-addr\n",
+			expectedErr: "line 1: expected a mapping of option names to values",
+		},
+		"features toggle their flags": {
+			yaml: "features:\n  notifications: true\n",
+			expectedOptions: options{
+				ServerAddr:   "localhost:8080",
+				PollInterval: 10 * time.Second,
+				Depth:        3,
+				Webhooks:     true,
+				Chat:         true,
+			},
+		},
+		"options and command line override the features": {
+			args: []string{"--chat-notifications=false"},
+			yaml: "webhooks: false\nfeatures:\n  notifications: true\n",
+			expectedOptions: options{
+				ServerAddr:   "localhost:8080",
+				PollInterval: 10 * time.Second,
+				Depth:        3,
+			},
+		},
+		"unknown and invalid features": {
+			yaml:        "features:\n  notifcations: true\n  webhooks: maybe\n",
+			expectedErr: "line 2: unknown feature \"notifcations\", did you mean \"notifications\"?\nline 3: invalid value of feature \"webhooks\": expected true or false",
+		},
+		"features not a mapping": {
+			yaml:        "features:\n  - webhooks\n",
+			expectedErr: "line 2: expected a mapping of feature names to whether they're enabled",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var opts options
+			fs := newFlagSet(&opts)
+			require.NoError(t, fs.Parse(test.args))
+
+			_, err := config.Load(fs, []byte(test.yaml), config.WithFeatures(features))
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOptions, opts)
+		})
+	}
+}
+
+func TestLoadEnv(t *testing.T) {
+	tests := map[string]struct {
+		args            []string
+		yaml            string
+		env             map[string]string
+		expectedOptions options
+		expectedErr     string
+	}{
+		"env values": {
+			env: map[string]string{
+				"TEST_SERVER_ADDR":              "0.0.0.0:9090",
+				"TEST_REORG_CONFIRMATION_DEPTH": "5",
+				"TEST_WEBHOOKS":                 "true",
+				"SERVER_ADDR":                   "ignored:80",
+			},
+			expectedOptions: options{
+				ServerAddr:   "0.0.0.0:9090",
+				PollInterval: 10 * time.Second,
+				Depth:        5,
+				Webhooks:     true,
+			},
+		},
+		"file and command line override env": {
+			args: []string{"--server-addr", "cli:8080"},
+			yaml: "reorg-confirmation-depth: 5\n",
+			env: map[string]string{
+				"TEST_SERVER_ADDR":              "env:8080",
+				"TEST_REORG_CONFIRMATION_DEPTH": "6",
+				"TEST_POLL_INTERVAL":            "12s",
+			},
+			expectedOptions: options{
+				ServerAddr:   "cli:8080",
+				PollInterval: 12 * time.Second,
+				Depth:        5,
+			},
+		},
+		"invalid values": {
+			env: map[string]string{
+				"TEST_POLL_INTERVAL": "soon",
+				"TEST_WEBHOOKS":      "maybe",
+			},
+			expectedErr: "invalid value of TEST_POLL_INTERVAL: parse error\ninvalid value of TEST_WEBHOOKS: parse error",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var opts options
+			fs := newFlagSet(&opts)
+			require.NoError(t, fs.Parse(test.args))
+			_, err := config.Load(fs, []byte(test.yaml))
+			require.NoError(t, err)
+
+			err = config.LoadEnv(fs, "TEST_", func(name string) (string, bool) {
+				value, ok := test.env[name]
+				return value, ok
+			})
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOptions, opts)
+		})
+	}
+}
+
+func TestLoadChains(t *testing.T) {
+	tests := map[string]struct {
+		yaml            string
+		expectedChains  map[string]options
+		expectedErr     string
+		expectedOptions options
+	}{
+		"chains override the top-level options": {
+			yaml: `
+server-addr: 0.0.0.0:9090
+reorg-confirmation-depth: 5
+chains:
+  - name: sepolia
+    reorg-confirmation-depth: 2
+    webhooks: true
+    features:
+      notifications: true
+  - name: holesky
+`,
+			expectedOptions: options{ServerAddr: "0.0.0.0:9090", PollInterval: 10 * time.Second, Depth: 5},
+			expectedChains: map[string]options{
+				"sepolia": {ServerAddr: "0.0.0.0:9090", PollInterval: 10 * time.Second, Depth: 2, Webhooks: true, Chat: true},
+				"holesky": {ServerAddr: "0.0.0.0:9090", PollInterval: 10 * time.Second, Depth: 5},
+			},
+		},
+		"invalid chains": {
+			yaml: `
+chains:
+  - name: Sepolia
+  - name: holesky
+  - name: holesky
+  - sepolia
+`,
+			expectedErr: "line 3: chain name \"Sepolia\" must be lower case letters, digits and dashes\nline 5: duplicate chain \"holesky\"\nline 6: expected a mapping of the chain's option names to values",
+		},
+		"not a list": {
+			yaml:        "chains:\n  sepolia: {}\n",
+			expectedErr: "line 2: expected a list of chains",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var opts options
+			fs := newFlagSet(&opts)
+			require.NoError(t, fs.Parse(nil))
+
+			chains, err := config.Load(fs, []byte(test.yaml), config.WithFeatures(features))
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOptions, opts)
+
+			chainOpts := make(map[string]options, len(chains))
+			for _, chain := range chains {
+				var c options
+				chainFS := newFlagSet(&c)
+				c = opts
+				require.NoError(t, chain.Apply(chainFS))
+				chainOpts[chain.Name] = c
+			}
+			assert.Equal(t, test.expectedChains, chainOpts)
+		})
+	}
+}
+
+func TestChainApplyUnknownOption(t *testing.T) {
+	var opts options
+	fs := newFlagSet(&opts)
+	chains, err := config.Load(fs, []byte("chains:\n  - name: sepolia\n    pol-interval: 12s\n"))
+	require.NoError(t, err)
+	require.Len(t, chains, 1)
+
+	err = chains[0].Apply(newFlagSet(&options{}))
+	require.Error(t, err)
+	assert.Equal(t, "chain \"sepolia\": line 3: unknown option \"pol-interval\", did you mean \"poll-interval\"?", err.Error())
+}
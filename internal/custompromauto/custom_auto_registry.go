@@ -1,10 +1,15 @@
 package custompromauto
 
 import (
+	"errors"
+
 	"github.com/prometheus/client_golang/prometheus"
 	"github.com/prometheus/client_golang/prometheus/promauto"
 )
 
+// ChainLabel is the constant label the metrics of a chain's pipeline are labelled with, see ForChain.
+const ChainLabel = "chain"
+
 var registry *prometheus.Registry
 var auto promauto.Factory
 
@@ -20,3 +25,70 @@ func Auto() promauto.Factory {
 func Registry() *prometheus.Registry {
 	return registry
 }
+
+// ForChain returns the registerer of the metrics of the given chain's pipeline, registering them with the custom
+// registry labelled with the chain, so the pipelines of several chains export their own series.
+func ForChain(chain string) prometheus.Registerer {
+	return prometheus.WrapRegistererWith(prometheus.Labels{ChainLabel: chain}, registry)
+}
+
+// Factory creates metrics registered with its registerer. Unlike promauto's, a metric already registered under the
+// same name and constant labels is returned rather than panicking, so the components created more than once with the
+// same registerer, e.g. in tests, share their metrics.
+type Factory struct {
+	reg prometheus.Registerer
+}
+
+// With returns the Factory of the metrics registered with reg, e.g. Registry or ForChain.
+func With(reg prometheus.Registerer) Factory {
+	return Factory{reg: reg}
+}
+
+func (f Factory) NewCounter(opts prometheus.CounterOpts) prometheus.Counter {
+	return register(f.reg, prometheus.NewCounter(opts))
+}
+
+func (f Factory) NewCounterVec(opts prometheus.CounterOpts, labelNames []string) *prometheus.CounterVec {
+	return register(f.reg, prometheus.NewCounterVec(opts, labelNames))
+}
+
+func (f Factory) NewGauge(opts prometheus.GaugeOpts) prometheus.Gauge {
+	return register(f.reg, prometheus.NewGauge(opts))
+}
+
+func (f Factory) NewGaugeVec(opts prometheus.GaugeOpts, labelNames []string) *prometheus.GaugeVec {
+	return register(f.reg, prometheus.NewGaugeVec(opts, labelNames))
+}
+
+func (f Factory) NewHistogram(opts prometheus.HistogramOpts) prometheus.Histogram {
+	return register(f.reg, prometheus.NewHistogram(opts))
+}
+
+func (f Factory) NewHistogramVec(opts prometheus.HistogramOpts, labelNames []string) *prometheus.HistogramVec {
+	return register(f.reg, prometheus.NewHistogramVec(opts, labelNames))
+}
+
+func register[T prometheus.Collector](reg prometheus.Registerer, c T) T {
+	err := reg.Register(c)
+	if err == nil {
+		return c
+	}
+	var registered prometheus.AlreadyRegisteredError
+	if errors.As(err, &registered) {
+		if existing, ok := registered.ExistingCollector.(T); ok {
+			return existing
+		}
+	}
+	panic(err)
+}
+
+// RegisterRuntimeCollectors registers the Go runtime collector, exporting the go_* metrics such as the GC pauses and
+// the number of goroutines, and the process collector, exporting the process_* metrics such as the resident memory
+// and open file descriptors, which the custom registry skips by default. It must be called once.
+func RegisterRuntimeCollectors() {
+	// the collectors package isn't vendored, its constructors wrap these ones
+	registry.MustRegister(
+		prometheus.NewGoCollector(),
+		prometheus.NewProcessCollector(prometheus.ProcessCollectorOpts{}),
+	)
+}
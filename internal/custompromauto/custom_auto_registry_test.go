@@ -0,0 +1,53 @@
+package custompromauto_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+func TestForChain(t *testing.T) {
+	opts := prometheus.CounterOpts{
+		Name: "ethtxparser_test_for_chain_total",
+		Help: "Counter of the ForChain test",
+	}
+	custompromauto.With(custompromauto.ForChain("ethereum")).NewCounter(opts).Add(2)
+	custompromauto.With(custompromauto.ForChain("base")).NewCounter(opts).Inc()
+	// created again with the same chain, e.g. by another component of its pipeline, the counter is shared
+	custompromauto.With(custompromauto.ForChain("ethereum")).NewCounter(opts).Inc()
+
+	families, err := custompromauto.Registry().Gather()
+	require.NoError(t, err)
+	counts := make(map[string]float64)
+	for family := range slices.Values(families) {
+		if family.GetName() != opts.Name {
+			continue
+		}
+		for metric := range slices.Values(family.GetMetric()) {
+			require.Len(t, metric.GetLabel(), 1)
+			assert.Equal(t, custompromauto.ChainLabel, metric.GetLabel()[0].GetName())
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{"ethereum": 3, "base": 1}, counts)
+}
+
+func TestFactoryPanicsOnConflict(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	custompromauto.With(registry).NewCounter(prometheus.CounterOpts{
+		Name: "ethtxparser_test_conflict_total",
+		Help: "Counter of the conflict test",
+	})
+
+	assert.Panics(t, func() {
+		custompromauto.With(registry).NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_test_conflict_total",
+			Help: "Gauge of the conflict test",
+		})
+	})
+}
@@ -0,0 +1,61 @@
+// Package debug serves the pprof profiles and a snapshot of the goroutines and pipeline backlogs, for diagnosing
+// stalls of the poll and index pipeline.
+package debug
+
+import (
+	"encoding/json"
+	"maps"
+	"net/http"
+	"net/http/pprof"
+	"runtime"
+	"slices"
+)
+
+// Snapshot is the state of the pipeline served by the pipeline endpoint.
+type Snapshot struct {
+	Goroutines int `json:"goroutines"`
+	// Backlogs holds the number of values waiting in every channel or buffer of a pipeline part, by part and stage.
+	Backlogs map[string]map[string]int `json:"backlogs"`
+}
+
+type config struct {
+	backlogs map[string]func() map[string]int
+}
+
+type Option func(*config)
+
+// WithBacklogs adds the backlogs reported by fn, by stage, to the snapshot under the given pipeline part.
+func WithBacklogs(part string, fn func() map[string]int) Option {
+	return func(c *config) {
+		c.backlogs[part] = fn
+	}
+}
+
+// Register mounts the net/http/pprof handlers under /debug/pprof/ and the pipeline snapshot at /debug/pipeline.
+func Register(mux *http.ServeMux, opts ...Option) {
+	cfg := &config{
+		backlogs: make(map[string]func() map[string]int),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	mux.HandleFunc("GET /debug/pprof/", pprof.Index)
+	mux.HandleFunc("GET /debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("GET /debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("GET /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("POST /debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("GET /debug/pprof/trace", pprof.Trace)
+	mux.HandleFunc("GET /debug/pipeline", func(w http.ResponseWriter, _ *http.Request) {
+		snapshot := &Snapshot{
+			Goroutines: runtime.NumGoroutine(),
+			Backlogs:   make(map[string]map[string]int, len(cfg.backlogs)),
+		}
+		for part := range maps.Keys(cfg.backlogs) {
+			snapshot.Backlogs[part] = cfg.backlogs[part]()
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		_ = json.NewEncoder(w).Encode(snapshot)
+	})
+}
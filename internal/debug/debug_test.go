@@ -0,0 +1,61 @@
+package debug_test
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/debug"
+)
+
+func TestRegister(t *testing.T) {
+	mux := http.NewServeMux()
+	debug.Register(mux,
+		debug.WithBacklogs("pipeline_buffers", func() map[string]int {
+			return map[string]int{"blocks": 3}
+		}),
+		debug.WithBacklogs("notifiers", func() map[string]int {
+			return map[string]int{"webhook": 7}
+		}),
+	)
+
+	tests := map[string]struct {
+		path               string
+		expectedStatusCode int
+	}{
+		"pprof index": {
+			path:               "/debug/pprof/",
+			expectedStatusCode: http.StatusOK,
+		},
+		"pprof goroutine profile": {
+			path:               "/debug/pprof/goroutine?debug=1",
+			expectedStatusCode: http.StatusOK,
+		},
+		"pipeline snapshot": {
+			path:               "/debug/pipeline",
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, test.path, nil))
+			assert.Equal(t, test.expectedStatusCode, rec.Code)
+		})
+	}
+
+	rec := httptest.NewRecorder()
+	mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/debug/pipeline", nil))
+	var snapshot debug.Snapshot
+	require.NoError(t, json.NewDecoder(rec.Body).Decode(&snapshot))
+	assert.Positive(t, snapshot.Goroutines)
+	assert.Equal(t, map[string]map[string]int{
+		"pipeline_buffers": {"blocks": 3},
+		"notifiers":        {"webhook": 7},
+	}, snapshot.Backlogs)
+}
@@ -0,0 +1,203 @@
+// Package errreport reports errors and panics to Sentry, or any service accepting its store API, so they're noticed
+// without watching the logs.
+package errreport
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"maps"
+	"net/http"
+	"net/url"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/buildinfo"
+	"github.com/hedisam/pipeline/chans"
+)
+
+const (
+	// queueSize is the number of reports queued for sending, new reports are dropped once it's full.
+	queueSize = 64
+
+	// panicTimeout bounds the time spent reporting a panic before the process crashes.
+	panicTimeout = time.Second * 5
+)
+
+// Reporter sends error reports to the project of a Sentry DSN, e.g. https://<key>@o0.ingest.sentry.io/<project id>.
+// Reports are sent asynchronously, a panic synchronously before it crashes the process.
+type Reporter struct {
+	logger     *logrus.Logger
+	httpClient *http.Client
+	storeURL   string
+	auth       string
+	queue      chan *event
+	tags       map[string]string
+}
+
+type config struct {
+	tags map[string]string
+}
+
+type Option func(*config)
+
+// WithTag tags every report with the given key and value, e.g. the chain it's reported for.
+func WithTag(key, value string) Option {
+	return func(c *config) {
+		c.tags[key] = value
+	}
+}
+
+// New returns a Reporter sending to the project of the given DSN.
+func New(logger *logrus.Logger, httpClient *http.Client, dsn string, opts ...Option) (*Reporter, error) {
+	cfg := &config{
+		tags: make(map[string]string),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	u, err := url.Parse(dsn)
+	if err != nil {
+		return nil, fmt.Errorf("invalid DSN: %w", err)
+	}
+	path, projectID, _ := strings.Cut(strings.TrimPrefix(u.Path, "/"), "/")
+	if projectID == "" {
+		path, projectID = "", path
+	}
+	if u.Scheme == "" || u.Host == "" || u.User == nil || u.User.Username() == "" || projectID == "" {
+		return nil, fmt.Errorf("invalid DSN %q: expected <scheme>://<public key>@<host>/<project id>", u.Redacted())
+	}
+
+	auth := fmt.Sprintf("Sentry sentry_version=7, sentry_client=ethtxparser/%s, sentry_key=%s", buildinfo.Get().Version, u.User.Username())
+	if secret, ok := u.User.Password(); ok {
+		auth += ", sentry_secret=" + secret
+	}
+	storeURL := fmt.Sprintf("%s://%s/", u.Scheme, u.Host)
+	if path != "" {
+		storeURL += path + "/"
+	}
+	storeURL += "api/" + projectID + "/store/"
+
+	return &Reporter{
+		logger:     logger,
+		httpClient: httpClient,
+		storeURL:   storeURL,
+		auth:       auth,
+		queue:      make(chan *event, queueSize),
+		tags:       cfg.tags,
+	}, nil
+}
+
+// event is the body of a report, in the format of the Sentry store API.
+type event struct {
+	EventID   string            `json:"event_id"`
+	Timestamp string            `json:"timestamp"`
+	Level     string            `json:"level"`
+	Platform  string            `json:"platform"`
+	Logger    string            `json:"logger"`
+	Release   string            `json:"release"`
+	Message   string            `json:"message"`
+	Tags      map[string]string `json:"tags,omitempty"`
+	Extra     map[string]any    `json:"extra,omitempty"`
+}
+
+// Start sends the queued reports until the context is done.
+func (r *Reporter) Start(ctx context.Context) {
+	for e := range chans.ReceiveOrDoneSeq(ctx, r.queue) {
+		r.send(ctx, e)
+	}
+}
+
+// ReportError queues a report of the error along with the given context, such as the number and hash of the block it
+// occurred for, without blocking. The report is dropped if the queue is full.
+func (r *Reporter) ReportError(err error, fields map[string]any) {
+	select {
+	case r.queue <- r.newEvent("error", err.Error(), fields):
+	default:
+		reports.WithLabelValues("dropped").Inc()
+		r.logger.WithError(err).Warn("Error report queue is full, dropping report")
+	}
+}
+
+// Recover reports the panic the goroutine is recovering from, if any, then panics again with it, so the process still
+// crashes. It must be deferred directly. A nil Reporter doesn't recover.
+func (r *Reporter) Recover() {
+	if r == nil {
+		return
+	}
+	p := recover()
+	if p == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), panicTimeout)
+	defer cancel()
+	r.send(ctx, r.newEvent("fatal", fmt.Sprintf("panic: %v", p), map[string]any{
+		"stack": string(debug.Stack()),
+	}))
+	panic(p)
+}
+
+func (r *Reporter) newEvent(level, message string, fields map[string]any) *event {
+	id := make([]byte, 16)
+	_, _ = rand.Read(id)
+	e := &event{
+		EventID:   hex.EncodeToString(id),
+		Timestamp: time.Now().UTC().Format(time.RFC3339),
+		Level:     level,
+		Platform:  "go",
+		Logger:    "ethtxparser",
+		Release:   buildinfo.Get().Version,
+		Message:   message,
+		Tags:      maps.Clone(r.tags),
+		Extra:     maps.Clone(fields),
+	}
+	// the block is a tag too, so reports can be searched by it
+	for key, value := range fields {
+		if strings.HasPrefix(key, "block_") {
+			e.Tags[key] = fmt.Sprint(value)
+		}
+	}
+	return e
+}
+
+func (r *Reporter) send(ctx context.Context, e *event) {
+	err := r.post(ctx, e)
+	if err != nil {
+		reports.WithLabelValues("failure").Inc()
+		r.logger.WithError(err).WithField("event_id", e.EventID).Error("Failed to send error report")
+		return
+	}
+	reports.WithLabelValues("success").Inc()
+}
+
+func (r *Reporter) post(ctx context.Context, e *event) error {
+	body, err := json.Marshal(e)
+	if err != nil {
+		return fmt.Errorf("could not marshal report: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, r.storeURL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("X-Sentry-Auth", r.auth)
+
+	resp, err := r.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not send report: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return fmt.Errorf("received unexpected status: %s", resp.Status)
+	}
+	return nil
+}
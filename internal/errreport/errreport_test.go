@@ -0,0 +1,134 @@
+package errreport_test
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/errreport"
+)
+
+type request struct {
+	path  string
+	auth  string
+	event map[string]any
+}
+
+func newServer(t *testing.T) (*httptest.Server, <-chan *request) {
+	t.Helper()
+	requests := make(chan *request, 1)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		req := &request{
+			path: r.URL.Path,
+			auth: r.Header.Get("X-Sentry-Auth"),
+		}
+		err := json.NewDecoder(r.Body).Decode(&req.event)
+		assert.NoError(t, err)
+		requests <- req
+	}))
+	t.Cleanup(srv.Close)
+	return srv, requests
+}
+
+func receive(t *testing.T, requests <-chan *request) *request {
+	t.Helper()
+	select {
+	case req := <-requests:
+		return req
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for report")
+		return nil
+	}
+}
+
+func TestReportError(t *testing.T) {
+	tests := map[string]struct {
+		dsn          string
+		expectedPath string
+		expectedAuth string
+		errContains  string
+	}{
+		"public key": {
+			dsn:          "http://public@{host}/42",
+			expectedPath: "/api/42/store/",
+			expectedAuth: "sentry_key=public",
+		},
+		"secret key and path": {
+			dsn:          "http://public:secret@{host}/sentry/42",
+			expectedPath: "/sentry/api/42/store/",
+			expectedAuth: "sentry_key=public, sentry_secret=secret",
+		},
+		"no key": {
+			dsn:         "http://{host}/42",
+			errContains: "invalid DSN",
+		},
+		"no project": {
+			dsn:         "http://public@{host}",
+			errContains: "invalid DSN",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv, requests := newServer(t)
+			dsn := strings.Replace(test.dsn, "{host}", strings.TrimPrefix(srv.URL, "http://"), 1)
+			reporter, err := errreport.New(logrus.New(), srv.Client(), dsn, errreport.WithTag("chain", "ethereum"))
+			if test.errContains != "" {
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			go reporter.Start(ctx)
+			reporter.ReportError(errors.New("dummy error"), map[string]any{
+				"block_number": 42,
+				"attempts":     5,
+			})
+
+			req := receive(t, requests)
+			assert.Equal(t, test.expectedPath, req.path)
+			assert.True(t, strings.HasPrefix(req.auth, "Sentry sentry_version=7"))
+			assert.True(t, strings.HasSuffix(req.auth, test.expectedAuth))
+			assert.Len(t, req.event["event_id"], 32)
+			assert.Equal(t, "error", req.event["level"])
+			assert.Equal(t, "dummy error", req.event["message"])
+			assert.Equal(t, map[string]any{"chain": "ethereum", "block_number": "42"}, req.event["tags"])
+			assert.Equal(t, map[string]any{"block_number": float64(42), "attempts": float64(5)}, req.event["extra"])
+		})
+	}
+}
+
+func TestRecover(t *testing.T) {
+	srv, requests := newServer(t)
+	reporter, err := errreport.New(logrus.New(), srv.Client(), strings.Replace(srv.URL, "http://", "http://public@", 1)+"/42")
+	require.NoError(t, err)
+
+	assert.PanicsWithValue(t, "dummy panic", func() {
+		defer reporter.Recover()
+		panic("dummy panic")
+	})
+
+	req := receive(t, requests)
+	assert.Equal(t, "fatal", req.event["level"])
+	assert.Equal(t, "panic: dummy panic", req.event["message"])
+	require.IsType(t, map[string]any{}, req.event["extra"])
+	assert.Contains(t, req.event["extra"].(map[string]any)["stack"], "TestRecover")
+
+	// a nil reporter doesn't recover
+	var nilReporter *errreport.Reporter
+	assert.PanicsWithValue(t, "dummy panic", func() {
+		defer nilReporter.Recover()
+		panic("dummy panic")
+	})
+}
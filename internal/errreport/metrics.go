@@ -0,0 +1,12 @@
+package errreport
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+var reports = custompromauto.Auto().NewCounterVec(prometheus.CounterOpts{
+	Name: "ethtxparser_error_reports_total",
+	Help: "Number of error reports sent to the error reporting service, by result",
+}, []string{"result"})
@@ -8,113 +8,459 @@ import (
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
+	"slices"
 	"strconv"
 	"time"
 
 	"github.com/cenkalti/backoff/v4"
-	"github.com/sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/tracing"
 	"github.com/hedisam/pipeline/chans"
 )
 
 const (
 	getCurrentBlockNumber rpcMethod = "eth_blockNumber"
 	getBlockByNumberID    rpcMethod = "eth_getBlockByNumber"
+	getBlockByHashID      rpcMethod = "eth_getBlockByHash"
+	getBlockReceipts      rpcMethod = "eth_getBlockReceipts"
+	getRawTxByHash        rpcMethod = "eth_getRawTransactionByHash"
+)
+
+const (
+	// maxThrottle is the factor the poll interval of Stream is stretched up to while downstream is saturated.
+	maxThrottle = 16
+	// maxCatchUpBlocks is the number of blocks Stream fetches in a single batched call once downstream drains.
+	maxCatchUpBlocks = 32
 )
 
 var (
 	// ErrNotFound is returned when we request a block by number that hasn't been minted yet
 	ErrNotFound = errors.New("block is not minted")
+	// ErrTxNotFound is returned when we request a transaction the node doesn't know.
+	ErrTxNotFound = errors.New("transaction not found")
 )
 
+// RPCError is the error object of a failed JSON-RPC call, e.g. -32005 for the rate limited calls of most providers.
+type RPCError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+func (e *RPCError) Error() string {
+	return fmt.Sprintf("json-rpc error %d: %s", e.Code, e.Message)
+}
+
+type config struct {
+	fetchReceipts      bool
+	prefetchedReceipts bool
+	recordDir          string
+	health             *health.Component
+	registerer         prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithReceipts makes the client fetch the receipts of every streamed block, which are needed for log based indexing
+// such as token transfers.
+func WithReceipts() Option {
+	return func(c *config) {
+		c.fetchReceipts = true
+	}
+}
+
+// WithPrefetchedReceipts leaves the receipts of the streamed blocks to the PrefetchReceipts stage, which fetches them
+// in batches. The receipts of the blocks fetched by number or hash are still fetched if WithReceipts is set.
+func WithPrefetchedReceipts() Option {
+	return func(c *config) {
+		c.prefetchedReceipts = true
+	}
+}
+
+// WithHealth reports the health of the node to the given component, failing on the calls the node fails.
+func WithHealth(component *health.Component) Option {
+	return func(c *config) {
+		c.health = component
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the client are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
+
 type Client struct {
-	logger     *logrus.Logger
+	logger     logging.Logger
 	httpClient *http.Client
 	nodeAddr   string
+	// provider labels the RPC metrics and the logs of the node, its host only so no API key in its path or userinfo
+	// leaks.
+	provider string
+	cfg      *config
+	metrics  *metrics
 }
 
-func New(logger *logrus.Logger, httpClient *http.Client, nodeAddr string) *Client {
+func New(logger logging.Logger, httpClient *http.Client, nodeAddr string, opts ...Option) *Client {
+	cfg := &config{
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	provider := providerLabel(nodeAddr)
+	cfg.health.SetProvider(provider)
+
 	return &Client{
-		logger:     logger,
+		logger:     logger.WithField("provider", provider),
 		httpClient: httpClient,
 		nodeAddr:   nodeAddr,
+		provider:   provider,
+		cfg:        cfg,
+		metrics:    newMetrics(cfg.registerer),
+	}
+}
+
+// providerLabel returns the host of the node address, or "unknown" if it has none.
+func providerLabel(nodeAddr string) string {
+	u, err := url.Parse(nodeAddr)
+	if err != nil || u.Host == "" {
+		return "unknown"
 	}
+	return u.Host
 }
 
+// Stream polls the node for the next block every pollTick and streams the blocks in order. A block waiting a whole poll
+// interval to be received means downstream is saturated, so the poll interval is doubled, up to maxThrottle times
+// pollTick, rather than fetching blocks only to block on them. Once downstream drains, the poll interval is restored
+// and the blocks minted meanwhile are fetched in batches of up to maxCatchUpBlocks until the head is reached.
 func (c *Client) Stream(ctx context.Context, pollTick time.Duration) <-chan *Block {
 	out := make(chan *Block)
 
 	go func() {
 		defer close(out)
 
-		t := time.NewTicker(pollTick)
+		interval := pollTick
+		t := time.NewTicker(interval)
 		defer t.Stop()
+		c.metrics.streamThrottle.WithLabelValues(c.provider).Set(1)
 
 		currentBlockNumber := int64(-2) // first time it'll be mapped to the 'latest' block number
+		var behind bool
 		for range chans.ReceiveOrDoneSeq(ctx, t.C) {
-			block, err := c.getFullBlock(ctx, currentBlockNumber+1)
-			if err != nil {
-				if errors.Is(err, ErrNotFound) {
+			var blocks []*Block
+			if behind {
+				blocks, behind = c.catchUp(ctx, currentBlockNumber)
+			}
+			if len(blocks) == 0 {
+				block, ok := c.nextBlock(ctx, currentBlockNumber)
+				if !ok {
 					continue
 				}
-				c.logger.WithError(err).Error("Failed to get latest full block")
-				failedBlockRetrievals.Inc()
-				continue
+				blocks = []*Block{block}
 			}
 
-			if block.Number == currentBlockNumber {
-				c.logger.WithField("current_block_number", block.Number).Debug("No new block yet")
-				continue
+			var waited time.Duration
+			for block := range slices.Values(blocks) {
+				sent := time.Now()
+				if !chans.SendOrDone(ctx, out, block) {
+					return
+				}
+				waited += time.Since(sent)
+				currentBlockNumber = block.Number
+				c.metrics.retrievedBlocks.WithLabelValues(c.provider).Inc()
 			}
 
-			c.logger.WithFields(logrus.Fields{
-				"number": block.Number,
-				"hash":   block.Hash,
-			}).Debug("Received block")
-			if !chans.SendOrDone(ctx, out, block) {
-				return
+			throttled := pollTick
+			if waited >= interval {
+				// fetching more blocks would only block on downstream
+				throttled = min(interval*2, pollTick*maxThrottle)
+				behind = true
+				c.metrics.streamBackpressureWaits.WithLabelValues(c.provider).Inc()
+			}
+			if throttled != interval {
+				c.logger.WithFields(logging.Fields{
+					"poll_interval": throttled.String(),
+					"waited":        waited.String(),
+				}).Info("Adjusted poll interval to downstream backpressure")
+				interval = throttled
+				t.Reset(interval)
+				c.metrics.streamThrottle.WithLabelValues(c.provider).Set(float64(interval) / float64(pollTick))
 			}
-			currentBlockNumber = block.Number
-			retrievedBlocks.Inc()
 		}
 	}()
 
 	return out
 }
 
-func (c *Client) getFullBlock(ctx context.Context, blockNum int64) (*Block, error) {
-	var requestedBlockNumber string
-	switch blockNum {
-	case -1:
-		requestedBlockNumber = "latest"
-	default:
-		requestedBlockNumber = "0x" + strconv.FormatInt(blockNum, 16)
+// nextBlock fetches the block after the current one, the latest one if there's no current block yet, along with its
+// receipts if enabled. It returns false if there's no new block or it couldn't be fetched.
+func (c *Client) nextBlock(ctx context.Context, currentBlockNumber int64) (*Block, bool) {
+	start := time.Now()
+	traceID := tracing.NewTraceID()
+	blockCtx := tracing.ContextWithTraceID(ctx, traceID)
+	block, err := c.getFullBlock(blockCtx, currentBlockNumber+1)
+	if err != nil {
+		if errors.Is(err, ErrNotFound) {
+			return nil, false
+		}
+		c.logger.WithError(err).Error("Failed to get latest full block")
+		c.metrics.failedBlockRetrievals.WithLabelValues(c.provider).Inc()
+		return nil, false
+	}
+
+	if block.Number == currentBlockNumber {
+		c.logger.WithField("current_block_number", block.Number).Debug("No new block yet")
+		return nil, false
 	}
 
+	if c.cfg.fetchReceipts && !c.cfg.prefetchedReceipts {
+		block.Receipts, err = c.getBlockReceipts(blockCtx, "0x"+strconv.FormatInt(block.Number, 16))
+		if err != nil {
+			c.logger.WithError(err).WithField("block_number", block.Number).Error("Failed to get block receipts")
+			c.metrics.failedBlockRetrievals.WithLabelValues(c.provider).Inc()
+			return nil, false
+		}
+	}
+	block.TraceID = traceID
+	tracing.Observe(c.metrics.blockFetchDuration.WithLabelValues(c.provider), time.Since(start).Seconds(), traceID)
+	c.received(block)
+
+	return block, true
+}
+
+// catchUp fetches the blocks after the current one up to the head of the chain, at most maxCatchUpBlocks of them in a
+// single batched call, along with their receipts if enabled. It returns whether there are more blocks to catch up
+// with, and no blocks if the head is reached or they couldn't be fetched, leaving it to nextBlock.
+func (c *Client) catchUp(ctx context.Context, currentBlockNumber int64) ([]*Block, bool) {
+	if currentBlockNumber < 0 {
+		return nil, false
+	}
+	head, err := c.BlockNumber(ctx)
+	if err != nil {
+		c.logger.WithError(err).Error("Failed to get head block number to catch up with")
+		return nil, false
+	}
+	if head <= currentBlockNumber+1 {
+		return nil, false
+	}
+
+	last := min(head, currentBlockNumber+maxCatchUpBlocks)
+	numbers := make([]int64, 0, last-currentBlockNumber)
+	for number := currentBlockNumber + 1; number <= last; number++ {
+		numbers = append(numbers, number)
+	}
+	start := time.Now()
+	blocks, err := c.GetBlocks(ctx, numbers)
+	if err != nil {
+		c.logger.WithError(err).WithField("blocks", len(numbers)).Error("Failed to get blocks to catch up with")
+		c.metrics.failedBlockRetrievals.WithLabelValues(c.provider).Inc()
+		return nil, false
+	}
+
+	for idx, block := range blocks {
+		traceID := tracing.NewTraceID()
+		if c.cfg.fetchReceipts && !c.cfg.prefetchedReceipts {
+			blockCtx := tracing.ContextWithTraceID(ctx, traceID)
+			block.Receipts, err = c.getBlockReceipts(blockCtx, "0x"+strconv.FormatInt(block.Number, 16))
+			if err != nil {
+				c.logger.WithError(err).WithField("block_number", block.Number).Error("Failed to get block receipts")
+				c.metrics.failedBlockRetrievals.WithLabelValues(c.provider).Inc()
+				// the blocks before it are still streamed, the rest fetched again on the next poll
+				return blocks[:idx], true
+			}
+		}
+		block.TraceID = traceID
+		tracing.Observe(c.metrics.blockFetchDuration.WithLabelValues(c.provider), time.Since(start).Seconds()/float64(len(blocks)), traceID)
+		c.received(block)
+	}
+	c.metrics.catchUpBlocks.WithLabelValues(c.provider).Add(float64(len(blocks)))
+
+	return blocks, last < head
+}
+
+// received logs the fetched block and records it as a fixture if enabled.
+func (c *Client) received(block *Block) {
+	c.logger.WithFields(logging.Fields{
+		"block_number": block.Number,
+		"block_hash":   block.Hash,
+		"trace_id":     block.TraceID,
+	}).Debug("Received block")
+	if c.cfg.recordDir != "" {
+		err := c.record(block, time.Now())
+		if err != nil {
+			c.logger.WithError(err).WithField("block_number", block.Number).Error("Failed to record block")
+		}
+	}
+}
+
+// GetBlock returns the full block with the given number, along with its receipts if enabled.
+// ErrNotFound is returned if the block hasn't been minted yet.
+func (c *Client) GetBlock(ctx context.Context, number int64) (*Block, error) {
+	traceID := tracing.NewTraceID()
+	ctx = tracing.ContextWithTraceID(ctx, traceID)
+	block, err := c.getFullBlock(ctx, number)
+	if err != nil {
+		return nil, err
+	}
+	block.TraceID = traceID
+
+	if c.cfg.fetchReceipts {
+		block.Receipts, err = c.getBlockReceipts(ctx, "0x"+strconv.FormatInt(block.Number, 16))
+		if err != nil {
+			return nil, fmt.Errorf("could not get block receipts: %w", err)
+		}
+	}
+
+	return block, nil
+}
+
+// BlockNumber returns the number of the latest block of the node, a cheap call to check it's reachable.
+func (c *Client) BlockNumber(ctx context.Context) (int64, error) {
+	type Response struct {
+		Number string `json:"result"`
+	}
+	var response Response
+	err := c.call(ctx, getCurrentBlockNumber, &response)
+	if err != nil {
+		return 0, fmt.Errorf("call %s: %w", getCurrentBlockNumber, err)
+	}
+
+	number, err := hexToInt64(response.Number)
+	if err != nil {
+		return 0, fmt.Errorf("could not parse block number %q: %w", response.Number, err)
+	}
+	return number, nil
+}
+
+// GetBlockByHash returns the full block with the given hash, along with its receipts if enabled, whether it's on the
+// canonical chain or not as long as the node knows it. ErrNotFound is returned if the node doesn't know the block.
+func (c *Client) GetBlockByHash(ctx context.Context, hash string) (*Block, error) {
+	type Response struct {
+		Block *Block `json:"result"`
+	}
+	var response Response
 	// last param is 'true' to request full block details
-	req, err := c.newRequest(ctx, getBlockByNumberID, requestedBlockNumber, true)
+	err := c.call(ctx, getBlockByHashID, &response, hash, true)
 	if err != nil {
-		return nil, fmt.Errorf("create new http request: %w", err)
+		return nil, fmt.Errorf("call %s: %w", getBlockByHashID, err)
+	}
+	if response.Block == nil {
+		return nil, ErrNotFound
 	}
 
-	resp, err := c.doRequestWithRetry(req, "getFullBlock")
+	block := response.Block
+	if c.cfg.fetchReceipts {
+		block.Receipts, err = c.getBlockReceipts(ctx, hash)
+		if err != nil {
+			return nil, fmt.Errorf("could not get block receipts: %w", err)
+		}
+	}
+
+	return block, nil
+}
+
+// GetBlocks returns the full blocks with the given numbers, in the same order, fetched using a single batched call.
+// Receipts aren't fetched. ErrNotFound is returned if any of the blocks hasn't been minted yet.
+func (c *Client) GetBlocks(ctx context.Context, numbers []int64) ([]*Block, error) {
+	params := make([][]any, 0, len(numbers))
+	for number := range slices.Values(numbers) {
+		// last param is 'true' to request full block details
+		params = append(params, []any{"0x" + strconv.FormatInt(number, 16), true})
+	}
+
+	results, err := c.callBatch(ctx, getBlockByNumberID, params)
 	if err != nil {
-		return nil, fmt.Errorf("do request with retry: %w", err)
+		return nil, fmt.Errorf("batch call %s: %w", getBlockByNumberID, err)
 	}
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		c.logger.WithField("response", string(body)).Error("Failed to get full block from eth node with unexpected status code")
-		return nil, fmt.Errorf("received unexpected status: %s", resp.Status)
+	blocks := make([]*Block, 0, len(results))
+	for idx, result := range results {
+		if result == nil {
+			return nil, fmt.Errorf("block %d: %w", numbers[idx], ErrNotFound)
+		}
+		var block Block
+		err = json.Unmarshal(result, &block)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode block %d: %w", numbers[idx], err)
+		}
+		blocks = append(blocks, &block)
+	}
+
+	return blocks, nil
+}
+
+// GetBlocksReceipts returns the receipts of the blocks with the given hashes, in the same order, fetched using a single
+// batched call. ErrNotFound is returned if the node doesn't know any of the blocks.
+func (c *Client) GetBlocksReceipts(ctx context.Context, hashes []string) ([][]*Receipt, error) {
+	params := make([][]any, 0, len(hashes))
+	for hash := range slices.Values(hashes) {
+		params = append(params, []any{hash})
+	}
+
+	results, err := c.callBatch(ctx, getBlockReceipts, params)
+	if err != nil {
+		return nil, fmt.Errorf("batch call %s: %w", getBlockReceipts, err)
+	}
+
+	receipts := make([][]*Receipt, 0, len(results))
+	for idx, result := range results {
+		if result == nil {
+			return nil, fmt.Errorf("block %s: %w", hashes[idx], ErrNotFound)
+		}
+		var blockReceipts []*Receipt
+		err = json.Unmarshal(result, &blockReceipts)
+		if err != nil {
+			return nil, fmt.Errorf("could not decode receipts of block %s: %w", hashes[idx], err)
+		}
+		receipts = append(receipts, blockReceipts)
+	}
+
+	return receipts, nil
+}
+
+// GetRawTransaction returns the 0x-prefixed hex RLP encoding of the signed transaction with the given hash.
+// ErrTxNotFound is returned if the node doesn't know the transaction.
+func (c *Client) GetRawTransaction(ctx context.Context, hash string) (string, error) {
+	type Response struct {
+		Raw *string `json:"result"`
+	}
+	var response Response
+	err := c.call(ctx, getRawTxByHash, &response, hash)
+	if err != nil {
+		return "", fmt.Errorf("call %s: %w", getRawTxByHash, err)
+	}
+	// nodes return a null result or, for some of them, an empty one for unknown transactions
+	if response.Raw == nil || *response.Raw == "" || *response.Raw == "0x" {
+		return "", ErrTxNotFound
+	}
+
+	return *response.Raw, nil
+}
+
+func (c *Client) getFullBlock(ctx context.Context, blockNum int64) (*Block, error) {
+	var requestedBlockNumber string
+	switch blockNum {
+	case -1:
+		requestedBlockNumber = "latest"
+	default:
+		requestedBlockNumber = "0x" + strconv.FormatInt(blockNum, 16)
 	}
 
 	type Response struct {
 		Block *Block `json:"result"`
 	}
 	var response Response
-	err = json.NewDecoder(resp.Body).Decode(&response)
+	// last param is 'true' to request full block details
+	err := c.call(ctx, getBlockByNumberID, &response, requestedBlockNumber, true)
 	if err != nil {
-		return nil, fmt.Errorf("decode response body: %w", err)
+		return nil, fmt.Errorf("call %s: %w", getBlockByNumberID, err)
 	}
 
 	if response.Block == nil {
@@ -124,13 +470,148 @@ func (c *Client) getFullBlock(ctx context.Context, blockNum int64) (*Block, erro
 	return response.Block, nil
 }
 
-func (c *Client) newRequest(ctx context.Context, method rpcMethod, rpcParams ...any) (*http.Request, error) {
+// getBlockReceipts returns the receipts of the block of the given 0x-prefixed hex number or hash.
+func (c *Client) getBlockReceipts(ctx context.Context, blockNumberOrHash string) ([]*Receipt, error) {
+	type Response struct {
+		Receipts []*Receipt `json:"result"`
+	}
+	var response Response
+	err := c.call(ctx, getBlockReceipts, &response, blockNumberOrHash)
+	if err != nil {
+		return nil, fmt.Errorf("call %s: %w", getBlockReceipts, err)
+	}
+
+	return response.Receipts, nil
+}
+
+// call makes a json-rpc call and decodes the response body into the given response.
+func (c *Client) call(ctx context.Context, method rpcMethod, response any, rpcParams ...any) error {
 	payload := map[string]any{
 		"jsonrpc": "2.0",
 		"method":  method,
 		"params":  rpcParams,
 		"id":      method.ID(),
 	}
+
+	return c.post(ctx, string(method), payload, response)
+}
+
+// callBatch makes a single json-rpc batch call of the given method, once per params, and returns the results in the
+// order of the given params. A nil result is returned for every call the node returned no result for.
+func (c *Client) callBatch(ctx context.Context, method rpcMethod, params [][]any) ([]json.RawMessage, error) {
+	payload := make([]map[string]any, 0, len(params))
+	for id, rpcParams := range params {
+		payload = append(payload, map[string]any{
+			"jsonrpc": "2.0",
+			"method":  method,
+			"params":  rpcParams,
+			"id":      id,
+		})
+	}
+
+	var responses []struct {
+		ID     int             `json:"id"`
+		Result json.RawMessage `json:"result"`
+		Error  *RPCError       `json:"error"`
+	}
+	err := c.post(ctx, "batch "+string(method), payload, &responses)
+	if err != nil {
+		return nil, err
+	}
+
+	// responses of a batch can be returned in any order
+	results := make([]json.RawMessage, len(params))
+	for resp := range slices.Values(responses) {
+		if resp.ID < 0 || resp.ID >= len(params) {
+			return nil, fmt.Errorf("received unexpected response id %d", resp.ID)
+		}
+		if resp.Error != nil {
+			c.metrics.rpcErrors.WithLabelValues("batch "+string(method), c.provider, rpcErrorJSONRPC, strconv.Itoa(resp.Error.Code)).Inc()
+			return nil, fmt.Errorf("call %d failed: %w", resp.ID, resp.Error)
+		}
+		if string(resp.Result) != "null" {
+			results[resp.ID] = resp.Result
+		}
+	}
+
+	return results, nil
+}
+
+// post sends the given json-rpc payload and decodes the response body into the given response.
+func (c *Client) post(ctx context.Context, method string, payload, response any) (err error) {
+	defer func() {
+		c.cfg.health.Report(err)
+	}()
+	req, err := c.newRequest(ctx, payload)
+	if err != nil {
+		return fmt.Errorf("create new http request: %w", err)
+	}
+	c.metrics.rpcRequestSize.WithLabelValues(method, c.provider).Observe(float64(req.ContentLength))
+	start := time.Now()
+	defer func() {
+		tracing.Observe(c.metrics.rpcCallDuration.WithLabelValues(method, c.provider), time.Since(start).Seconds(), tracing.TraceIDFromContext(ctx))
+	}()
+
+	resp, err := c.doRequestWithRetry(req, method)
+	if err != nil {
+		if ctx.Err() == nil {
+			c.metrics.rpcErrors.WithLabelValues(method, c.provider, rpcErrorTransport, "").Inc()
+		}
+		return fmt.Errorf("do request with retry: %w", err)
+	}
+	defer resp.Body.Close()
+	body := &countingReader{r: resp.Body}
+	defer func() {
+		c.metrics.rpcResponseSize.WithLabelValues(method, c.provider).Observe(float64(body.n))
+	}()
+
+	if resp.StatusCode != http.StatusOK {
+		c.metrics.rpcErrors.WithLabelValues(method, c.provider, rpcErrorHTTP, strconv.Itoa(resp.StatusCode)).Inc()
+		body, _ := io.ReadAll(body)
+		c.logger.WithFields(logging.Fields{
+			"response": string(body),
+			"method":   method,
+		}).Error("Received unexpected status code from eth node")
+		return fmt.Errorf("received unexpected status: %s", resp.Status)
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		return fmt.Errorf("read response body: %w", err)
+	}
+	// the errors of batch calls are returned per call, along with their results
+	if trimmed := bytes.TrimSpace(data); len(trimmed) > 0 && trimmed[0] == '{' {
+		var envelope struct {
+			Error *RPCError `json:"error"`
+		}
+		err = json.Unmarshal(trimmed, &envelope)
+		if err == nil && envelope.Error != nil {
+			c.metrics.rpcErrors.WithLabelValues(method, c.provider, rpcErrorJSONRPC, strconv.Itoa(envelope.Error.Code)).Inc()
+			return envelope.Error
+		}
+	}
+
+	err = json.Unmarshal(data, response)
+	if err != nil {
+		return fmt.Errorf("decode response body: %w", err)
+	}
+
+	return nil
+}
+
+// countingReader counts the bytes read through it.
+type countingReader struct {
+	r io.Reader
+	n int64
+}
+
+func (cr *countingReader) Read(p []byte) (int, error) {
+	n, err := cr.r.Read(p)
+	cr.n += int64(n)
+	return n, err
+}
+
+func (c *Client) newRequest(ctx context.Context, payload any) (*http.Request, error) {
 	data, err := json.Marshal(payload)
 	if err != nil {
 		return nil, fmt.Errorf("could not marshal payload: %w", err)
@@ -142,6 +623,9 @@ func (c *Client) newRequest(ctx context.Context, method rpcMethod, rpcParams ...
 	}
 	req.Header.Set("Content-Type", "application/json")
 	req.Header.Set("Content-Length", strconv.Itoa(len(data)))
+	if traceID := tracing.TraceIDFromContext(ctx); traceID != "" {
+		req.Header.Set("traceparent", tracing.Traceparent(traceID))
+	}
 
 	return req, nil
 }
@@ -0,0 +1,186 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// fixtureExt is the extension of recorded block fixtures.
+const fixtureExt = ".json"
+
+// WithRecording makes the client record every streamed block, along with its receipts, to the given directory as a
+// JSON fixture named after the time it was received, to be replayed later with StreamFixtures. The directory must
+// exist. Blocks that can't be recorded are logged and streamed all the same.
+func WithRecording(dir string) Option {
+	return func(c *config) {
+		c.recordDir = dir
+	}
+}
+
+// fixture is a recorded block, encoded the way the node returns it so it's decoded the same way.
+type fixture struct {
+	ReceivedAt time.Time         `json:"receivedAt"`
+	Block      *fixtureBlock     `json:"block"`
+	Receipts   []*fixtureReceipt `json:"receipts,omitempty"`
+}
+
+type fixtureBlock struct {
+	Hash       string            `json:"hash"`
+	Number     string            `json:"number"`
+	ParentHash string            `json:"parentHash"`
+	Timestamp  string            `json:"timestamp"`
+	Txs        []json.RawMessage `json:"transactions"`
+}
+
+type fixtureReceipt struct {
+	TxHash string        `json:"transactionHash"`
+	Status string        `json:"status,omitempty"`
+	Logs   []*fixtureLog `json:"logs"`
+}
+
+type fixtureLog struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+	LogIndex string   `json:"logIndex"`
+	TxHash   string   `json:"transactionHash"`
+}
+
+// record writes the block to the recording directory.
+func (c *Client) record(block *Block, receivedAt time.Time) error {
+	f := &fixture{
+		ReceivedAt: receivedAt,
+		Block: &fixtureBlock{
+			Hash:       block.Hash,
+			Number:     toHex(block.Number),
+			ParentHash: block.ParentHash,
+			Timestamp:  toHex(block.Timestamp),
+			Txs:        make([]json.RawMessage, 0, len(block.Txs)),
+		},
+	}
+	for tx := range slices.Values(block.Txs) {
+		f.Block.Txs = append(f.Block.Txs, tx.Raw)
+	}
+	for receipt := range slices.Values(block.Receipts) {
+		r := &fixtureReceipt{
+			TxHash: receipt.TxHash,
+			Logs:   make([]*fixtureLog, 0, len(receipt.Logs)),
+		}
+		if receipt.Status != ReceiptStatusUnknown {
+			r.Status = toHex(receipt.Status)
+		}
+		for log := range slices.Values(receipt.Logs) {
+			r.Logs = append(r.Logs, &fixtureLog{
+				Address:  log.Address,
+				Topics:   log.Topics,
+				Data:     log.Data,
+				LogIndex: toHex(log.LogIndex),
+				TxHash:   log.TxHash,
+			})
+		}
+		f.Receipts = append(f.Receipts, r)
+	}
+
+	data, err := json.Marshal(f)
+	if err != nil {
+		return fmt.Errorf("could not marshal fixture: %w", err)
+	}
+	// zero-padded so the fixtures sort in the order they were received
+	name := fmt.Sprintf("%020d%s", receivedAt.UnixNano(), fixtureExt)
+	err = os.WriteFile(filepath.Join(c.cfg.recordDir, name), data, 0o644)
+	if err != nil {
+		return fmt.Errorf("could not write fixture: %w", err)
+	}
+	return nil
+}
+
+// ReadFixture reads the recorded block fixture at the given path, returning the block along with its receipts and the
+// time it was received.
+func ReadFixture(path string) (*Block, time.Time, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("could not read fixture: %w", err)
+	}
+
+	var f struct {
+		ReceivedAt time.Time  `json:"receivedAt"`
+		Block      *Block     `json:"block"`
+		Receipts   []*Receipt `json:"receipts"`
+	}
+	err = json.Unmarshal(data, &f)
+	if err != nil {
+		return nil, time.Time{}, fmt.Errorf("could not unmarshal fixture %s: %w", filepath.Base(path), err)
+	}
+	if f.Block == nil {
+		return nil, time.Time{}, fmt.Errorf("fixture %s has no block", filepath.Base(path))
+	}
+	f.Block.Receipts = f.Receipts
+
+	return f.Block, f.ReceivedAt, nil
+}
+
+// StreamFixtures streams the blocks recorded to the given directory in the order they were received, as Client.Stream
+// would, until they're all streamed or the context is done. The time between two blocks is the time between their
+// receipt divided by the speed, they're streamed as fast as they're consumed if the speed is zero. Fixtures that
+// can't be read are logged and skipped. The streamed fixtures are counted by the metrics registered with reg.
+func StreamFixtures(ctx context.Context, logger logging.Logger, reg prometheus.Registerer, dir string, speed float64) (<-chan *Block, error) {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("could not read fixtures directory: %w", err)
+	}
+	var paths []string
+	for entry := range slices.Values(entries) {
+		if !entry.IsDir() && strings.HasSuffix(entry.Name(), fixtureExt) {
+			paths = append(paths, filepath.Join(dir, entry.Name()))
+		}
+	}
+	slices.Sort(paths)
+
+	m := newMetrics(reg)
+	out := make(chan *Block)
+	go func() {
+		defer close(out)
+
+		var last time.Time
+		for path := range slices.Values(paths) {
+			block, receivedAt, err := ReadFixture(path)
+			if err != nil {
+				logger.WithError(err).Error("Failed to read block fixture, skipping")
+				continue
+			}
+			if speed > 0 && !last.IsZero() && receivedAt.After(last) {
+				wait := time.Duration(float64(receivedAt.Sub(last)) / speed)
+				select {
+				case <-time.After(wait):
+				case <-ctx.Done():
+					return
+				}
+			}
+			last = receivedAt
+
+			if !chans.SendOrDone(ctx, out, block) {
+				return
+			}
+			m.replayedFixtures.Inc()
+		}
+		logger.WithField("fixtures", len(paths)).Info("Streamed every block fixture")
+	}()
+
+	return out, nil
+}
+
+func toHex(n int64) string {
+	return "0x" + strconv.FormatInt(n, 16)
+}
@@ -0,0 +1,66 @@
+package eth
+
+import (
+	"context"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+func TestRecordAndStreamFixtures(t *testing.T) {
+	dir := t.TempDir()
+	c := New(logging.Logrus(logrus.New()), nil, "", WithRecording(dir))
+
+	var tx Tx
+	err := json.Unmarshal([]byte(`{"hash":"0xabc","from":"0x01","to":"0x02","input":"0x","value":"0xde0b6b3a7640000"}`), &tx)
+	require.NoError(t, err)
+	blocks := []*Block{
+		{
+			Hash:       "0xb1",
+			Number:     100,
+			ParentHash: "0xb0",
+			Timestamp:  1700000000,
+			Txs:        []*Tx{&tx},
+			Receipts: []*Receipt{{
+				TxHash: "0xabc",
+				Status: ReceiptStatusSuccess,
+				Logs: []*Log{{
+					Address:  "0x03",
+					Topics:   []string{"0xddf2"},
+					Data:     "0x",
+					LogIndex: 2,
+					TxHash:   "0xabc",
+				}},
+			}},
+		},
+		// a sibling of the first block, replaced by a reorg
+		{Hash: "0xb1b", Number: 100, ParentHash: "0xb0", Timestamp: 1700000001, Txs: []*Tx{}},
+	}
+	receivedAt := time.Now()
+	for idx, block := range blocks {
+		err = c.record(block, receivedAt.Add(time.Duration(idx)*time.Millisecond*20))
+		require.NoError(t, err)
+	}
+
+	start := time.Now()
+	stream, err := StreamFixtures(context.Background(), c.logger, custompromauto.Registry(), dir, 1)
+	require.NoError(t, err)
+	var streamed []*Block
+	for block := range stream {
+		streamed = append(streamed, block)
+	}
+	// the blocks are streamed as far apart as they were received
+	assert.GreaterOrEqual(t, time.Since(start), time.Millisecond*20)
+	require.Len(t, streamed, 2)
+
+	assert.Equal(t, blocks[0], streamed[0])
+	assert.Equal(t, "0xb1b", streamed[1].Hash)
+	assert.Empty(t, streamed[1].Txs)
+}
@@ -0,0 +1,87 @@
+package eth
+
+import (
+	"context"
+	"sync/atomic"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// LagTracker exports how far behind the head of the chain, as polled from the node, the last confirmed and indexed
+// blocks are, and how far behind the wall clock the timestamp of the last confirmed block is.
+type LagTracker struct {
+	logger  logging.Logger
+	metrics *metrics
+	node    *Client
+	// indexed returns the number of the last indexed block, or an error if none is indexed yet.
+	indexed func(ctx context.Context) (int64, error)
+	// confirmed is the number of the last confirmed block, -1 until a block is confirmed.
+	confirmed atomic.Int64
+}
+
+// NewLagTracker returns a tracker exporting the lag of the given node's chain with the metrics registered with reg.
+func NewLagTracker(logger logging.Logger, reg prometheus.Registerer, node *Client, indexed func(ctx context.Context) (int64, error)) *LagTracker {
+	t := &LagTracker{
+		logger:  logger,
+		metrics: newMetrics(reg),
+		node:    node,
+		indexed: indexed,
+	}
+	t.confirmed.Store(-1)
+	return t
+}
+
+// Track exports the number of the last confirmed block of the given event stream and how far behind the wall clock
+// its timestamp is, until the channel is closed or the context is done.
+func (t *LagTracker) Track(ctx context.Context, in <-chan *BlockEvent) {
+	for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+		if event == nil || event.Block == nil || event.Type != BlockConfirmed {
+			continue
+		}
+		t.confirmed.Store(event.Block.Number)
+		t.metrics.confirmedBlockNumber.WithLabelValues().Set(float64(event.Block.Number))
+		if event.Block.Timestamp > 0 {
+			t.metrics.confirmedBlockLag.WithLabelValues().Set(time.Since(time.Unix(event.Block.Timestamp, 0)).Seconds())
+		}
+	}
+}
+
+// PollHead polls the head of the chain from the node every interval until the context is done, exporting it along
+// with the last indexed block and how many blocks behind the head the last confirmed and indexed blocks are.
+func (t *LagTracker) PollHead(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		t.updateHead(ctx)
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (t *LagTracker) updateHead(ctx context.Context) {
+	head, err := t.node.BlockNumber(ctx)
+	if err != nil {
+		t.logger.WithError(err).Debug("Failed to get the head of the chain")
+		return
+	}
+	t.metrics.headBlockNumber.WithLabelValues().Set(float64(head))
+	if confirmed := t.confirmed.Load(); confirmed >= 0 {
+		t.metrics.confirmedBlocksBehindHead.WithLabelValues().Set(float64(max(head-confirmed, 0)))
+	}
+
+	indexed, err := t.indexed(ctx)
+	if err != nil {
+		// nothing indexed yet
+		return
+	}
+	t.metrics.indexedBlockNumber.WithLabelValues().Set(float64(indexed))
+	t.metrics.indexedBlocksBehindHead.WithLabelValues().Set(float64(max(head-indexed, 0)))
+}
@@ -0,0 +1,94 @@
+package eth
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+func TestLagTracker(t *testing.T) {
+	tests := map[string]struct {
+		confirmed             *Block
+		indexed               int64
+		indexedErr            error
+		expectedGauges        map[string]float64
+		expectedMissingGauges []string
+	}{
+		"behind head": {
+			confirmed: &Block{Number: 97},
+			indexed:   95,
+			expectedGauges: map[string]float64{
+				"ethtxparser_head_block_number":            100,
+				"ethtxparser_confirmed_block_number":       97,
+				"ethtxparser_indexed_block_number":         95,
+				"ethtxparser_confirmed_blocks_behind_head": 3,
+				"ethtxparser_indexed_blocks_behind_head":   5,
+			},
+		},
+		"nothing confirmed or indexed yet": {
+			indexedErr: errors.New("not found"),
+			expectedGauges: map[string]float64{
+				"ethtxparser_head_block_number": 100,
+			},
+			expectedMissingGauges: []string{
+				"ethtxparser_confirmed_blocks_behind_head",
+				"ethtxparser_indexed_blocks_behind_head",
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(`{"jsonrpc":"2.0","id":1,"result":"0x64"}`))
+			}))
+			defer srv.Close()
+
+			registry := prometheus.NewRegistry()
+			node := New(logging.Logrus(logrus.New()), srv.Client(), srv.URL)
+			tracker := NewLagTracker(logging.Logrus(logrus.New()), registry, node, func(ctx context.Context) (int64, error) {
+				return test.indexed, test.indexedErr
+			})
+			if test.confirmed != nil {
+				in := make(chan *BlockEvent, 1)
+				in <- &BlockEvent{Type: BlockConfirmed, Block: test.confirmed}
+				close(in)
+				tracker.Track(context.Background(), in)
+			}
+			tracker.updateHead(context.Background())
+
+			gauges := registryGauges(t, registry)
+			for name, value := range test.expectedGauges {
+				assert.Equal(t, value, gauges[name], name)
+			}
+			for name := range slices.Values(test.expectedMissingGauges) {
+				assert.NotContains(t, gauges, name)
+			}
+		})
+	}
+}
+
+// registryGauges returns the values of the gauges of the registry by name.
+func registryGauges(t *testing.T, registry *prometheus.Registry) map[string]float64 {
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	gauges := make(map[string]float64)
+	for family := range slices.Values(families) {
+		for metric := range slices.Values(family.GetMetric()) {
+			if metric.GetGauge() != nil {
+				gauges[family.GetName()] = metric.GetGauge().GetValue()
+			}
+		}
+	}
+	return gauges
+}
@@ -1,21 +1,167 @@
 package eth
 
 import (
-	"github.com/hedisam/ethtxparser/internal/custompromauto"
 	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
 )
 
-var failedBlockRetrievals = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-	Name: "ethtxparser_failed_block_retrievals_total",
-	Help: "Number of failed full block retrievals",
-})
+// metrics holds the metrics of the client and the stages of the pipeline built on it, registered with the registerer
+// set by their options.
+type metrics struct {
+	failedBlockRetrievals   *prometheus.CounterVec
+	retrievedBlocks         *prometheus.CounterVec
+	streamThrottle          *prometheus.GaugeVec
+	streamBackpressureWaits *prometheus.CounterVec
+	catchUpBlocks           *prometheus.CounterVec
+	reorgDroppedBlocks      prometheus.Counter
+	reorgOrphanedBlocks     prometheus.Counter
+	deepReorgRecoveries     *prometheus.CounterVec
+	reorgConfirmationDepth  prometheus.Gauge
+	reorgAlerts             *prometheus.CounterVec
+	// the lag gauges are vectors without labels, so they're only exported once the lag tracker sets them
+	confirmedBlockNumber      *prometheus.GaugeVec
+	confirmedBlockLag         *prometheus.GaugeVec
+	headBlockNumber           *prometheus.GaugeVec
+	indexedBlockNumber        *prometheus.GaugeVec
+	confirmedBlocksBehindHead *prometheus.GaugeVec
+	indexedBlocksBehindHead   *prometheus.GaugeVec
+	replayedFixtures          prometheus.Counter
+	rpcCallDuration           *prometheus.HistogramVec
+	rpcErrors                 *prometheus.CounterVec
+	rpcRequestSize            *prometheus.HistogramVec
+	rpcResponseSize           *prometheus.HistogramVec
+	blockFetchDuration        *prometheus.HistogramVec
+	reorgWaitDuration         prometheus.Histogram
+	reorgBufferedBlocks       prometheus.Gauge
+	prefetchedReceiptsBlocks  prometheus.Counter
+	failedReceiptsPrefetches  prometheus.Counter
+	receiptsPrefetchDuration  prometheus.Histogram
+}
 
-var retrievedBlocks = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-	Name: "ethtxparser_block_retrievals_total",
-	Help: "Number of successful full block retrievals",
-})
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		failedBlockRetrievals: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_failed_block_retrievals_total",
+			Help: "Number of failed full block retrievals, by provider",
+		}, []string{"provider"}),
+		retrievedBlocks: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_block_retrievals_total",
+			Help: "Number of successful full block retrievals, by provider",
+		}, []string{"provider"}),
+		streamThrottle: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_stream_backpressure_throttle",
+			Help: "Factor the poll interval of the block stream is stretched by while downstream is saturated, 1 if it keeps up, by provider",
+		}, []string{"provider"}),
+		streamBackpressureWaits: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_stream_backpressure_waits_total",
+			Help: "Number of polls whose blocks waited a whole poll interval to be received downstream, by provider",
+		}, []string{"provider"}),
+		catchUpBlocks: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_stream_catch_up_blocks_total",
+			Help: "Number of blocks fetched in batches by the block stream catching up once downstream drained, by provider",
+		}, []string{"provider"}),
+		reorgDroppedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_reorg_dropped_blocks_total",
+			Help: "Number of blocks dropped from buffer due to chain reorganization",
+		}),
+		reorgOrphanedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_reorg_orphaned_blocks_total",
+			Help: "Number of confirmed blocks orphaned by chain reorganizations deeper than the confirmation depth",
+		}),
+		deepReorgRecoveries: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_deep_reorg_recoveries_total",
+			Help: "Number of chain reorganizations deeper than the confirmation depth recovered by walking back the canonical chain, by result",
+		}, []string{"result"}),
+		reorgConfirmationDepth: auto.NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_reorg_confirmation_depth",
+			Help: "Current confirmation depth of the reorg filter",
+		}),
+		reorgAlerts: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_reorg_alerts_total",
+			Help: "Number of alerts raised of deep or repeated chain reorganizations, by reason",
+		}, []string{"reason"}),
+		confirmedBlockNumber: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_confirmed_block_number",
+			Help: "Number of the last block confirmed by the reorg filter",
+		}, nil),
+		confirmedBlockLag: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_confirmed_block_lag_seconds",
+			Help: "Time between the timestamp of the last confirmed block and its confirmation",
+		}, nil),
+		replayedFixtures: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_replayed_fixtures_total",
+			Help: "Number of recorded block fixtures streamed by the replay command",
+		}),
+		rpcCallDuration: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethtxparser_rpc_call_duration_seconds",
+			Help:    "Time taken by the JSON-RPC calls to the node, including retries, by method and provider",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"method", "provider"}),
+		rpcErrors: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_rpc_errors_total",
+			Help: "Number of failed JSON-RPC calls to the node, by method, provider, type (transport, http or jsonrpc) and code, the HTTP status or JSON-RPC error code",
+		}, []string{"method", "provider", "type", "code"}),
+		rpcRequestSize: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethtxparser_rpc_request_size_bytes",
+			Help:    "Size of the JSON-RPC request payloads sent to the node, by method and provider",
+			Buckets: prometheus.ExponentialBuckets(64, 4, 8), // 64B to 1MiB
+		}, []string{"method", "provider"}),
+		rpcResponseSize: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethtxparser_rpc_response_size_bytes",
+			Help:    "Size of the JSON-RPC response payloads received from the node, by method and provider",
+			Buckets: prometheus.ExponentialBuckets(256, 4, 10), // 256B to 64MiB
+		}, []string{"method", "provider"}),
+		headBlockNumber: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_head_block_number",
+			Help: "Number of the head block of the chain, as last polled from the node",
+		}, nil),
+		indexedBlockNumber: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_indexed_block_number",
+			Help: "Number of the last indexed block, as last polled",
+		}, nil),
+		confirmedBlocksBehindHead: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_confirmed_blocks_behind_head",
+			Help: "Number of blocks the last confirmed block is behind the head of the chain",
+		}, nil),
+		indexedBlocksBehindHead: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_indexed_blocks_behind_head",
+			Help: "Number of blocks the last indexed block is behind the head of the chain",
+		}, nil),
+		blockFetchDuration: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethtxparser_block_fetch_duration_seconds",
+			Help:    "Time taken to fetch each new block from the node, its receipts included if enabled, by provider",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"provider"}),
+		reorgWaitDuration: auto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ethtxparser_block_reorg_wait_duration_seconds",
+			Help:    "Time blocks are held back by the reorg filter until they're confirmed",
+			Buckets: prometheus.ExponentialBuckets(1, 2, 10), // 1s to 8.5m
+		}),
+		reorgBufferedBlocks: auto.NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_reorg_buffered_blocks",
+			Help: "Number of blocks held back by the reorg filter waiting to be confirmed",
+		}),
+		prefetchedReceiptsBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_prefetched_receipts_blocks_total",
+			Help: "Number of confirmed blocks enriched with their receipts by the prefetch stage",
+		}),
+		failedReceiptsPrefetches: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_failed_receipts_prefetches_total",
+			Help: "Number of failed batched receipts fetches of the prefetch stage, retried",
+		}),
+		receiptsPrefetchDuration: auto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ethtxparser_receipts_prefetch_duration_seconds",
+			Help:    "Time taken by the batched receipts fetches of the prefetch stage",
+			Buckets: prometheus.DefBuckets,
+		}),
+	}
+}
 
-var reorgDroppedBlocks = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-	Name: "ethtxparser_reorg_dropped_blocks_total",
-	Help: "Number of blocks dropped from buffer due to chain reorganization",
-})
+// The types of the errors of the calls to the node.
+const (
+	rpcErrorTransport = "transport"
+	rpcErrorHTTP      = "http"
+	rpcErrorJSONRPC   = "jsonrpc"
+)
@@ -0,0 +1,161 @@
+package eth
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/pipeline/chans"
+)
+
+const (
+	// DefaultPrefetchBatchSize is the default maximum number of blocks whose receipts are fetched in a single batch.
+	DefaultPrefetchBatchSize = 16
+	// DefaultPrefetchRetryInterval is the default interval between the attempts to fetch the receipts of a batch.
+	DefaultPrefetchRetryInterval = time.Second
+)
+
+// ReceiptsFetcher fetches the receipts of blocks in batches.
+type ReceiptsFetcher interface {
+	GetBlocksReceipts(ctx context.Context, hashes []string) ([][]*Receipt, error)
+}
+
+type prefetchConfig struct {
+	batchSize     int
+	retryInterval time.Duration
+	registerer    prometheus.Registerer
+}
+
+type PrefetchOption func(*prefetchConfig)
+
+// WithPrefetchBatchSize sets the maximum number of blocks whose receipts are fetched in a single batch, defaulting to
+// DefaultPrefetchBatchSize if not positive.
+func WithPrefetchBatchSize(size int) PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.batchSize = size
+	}
+}
+
+// WithPrefetchRetryInterval sets the interval between the attempts to fetch the receipts of a batch, defaulting to
+// DefaultPrefetchRetryInterval if not positive.
+func WithPrefetchRetryInterval(interval time.Duration) PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.retryInterval = interval
+	}
+}
+
+// WithPrefetchRegisterer sets the registerer the metrics of the stage are registered with, the custom registry by
+// default.
+func WithPrefetchRegisterer(reg prometheus.Registerer) PrefetchOption {
+	return func(c *prefetchConfig) {
+		c.registerer = reg
+	}
+}
+
+// PrefetchReceipts enriches the confirmed blocks of the stream with their receipts, so the stages downstream see
+// complete blocks without fetching them one by one. The blocks already received when one arrives are batched with it,
+// up to the batch size, and their receipts fetched in a single call retried until it succeeds, since the blocks
+// can't be emitted without them. Blocks that already hold their receipts, e.g. replayed fixtures, and orphaned ones
+// are emitted as received, in order. The returned channel is closed once the input one is or the context is done.
+func PrefetchReceipts(ctx context.Context, logger logging.Logger, in <-chan *BlockEvent, fetcher ReceiptsFetcher, opts ...PrefetchOption) <-chan *BlockEvent {
+	cfg := &prefetchConfig{
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	if cfg.batchSize <= 0 {
+		cfg.batchSize = DefaultPrefetchBatchSize
+	}
+	if cfg.retryInterval <= 0 {
+		cfg.retryInterval = DefaultPrefetchRetryInterval
+	}
+
+	m := newMetrics(cfg.registerer)
+
+	out := make(chan *BlockEvent)
+
+	go func() {
+		defer close(out)
+
+		for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+			batch := receiveBatch(in, event, cfg.batchSize)
+			if !prefetch(ctx, logger, m, fetcher, batch, cfg.retryInterval) {
+				return
+			}
+			for event := range slices.Values(batch) {
+				if !chans.SendOrDone(ctx, out, event) {
+					return
+				}
+			}
+		}
+	}()
+
+	return out
+}
+
+// receiveBatch returns the first event along with the ones already received, up to the batch size, without blocking.
+func receiveBatch(in <-chan *BlockEvent, first *BlockEvent, size int) []*BlockEvent {
+	batch := []*BlockEvent{first}
+	for len(batch) < size {
+		select {
+		case event, ok := <-in:
+			if !ok {
+				return batch
+			}
+			batch = append(batch, event)
+		default:
+			return batch
+		}
+	}
+	return batch
+}
+
+// prefetch fetches the receipts of the confirmed blocks of the batch missing them, retrying until it succeeds or the
+// context is done, reporting whether they were fetched.
+func prefetch(ctx context.Context, logger logging.Logger, m *metrics, fetcher ReceiptsFetcher, batch []*BlockEvent, retryInterval time.Duration) bool {
+	var blocks []*Block
+	var hashes []string
+	for event := range slices.Values(batch) {
+		if event == nil || event.Block == nil || event.Type != BlockConfirmed || event.Block.Receipts != nil {
+			continue
+		}
+		blocks = append(blocks, event.Block)
+		hashes = append(hashes, event.Block.Hash)
+	}
+	if len(blocks) == 0 {
+		return true
+	}
+
+	for {
+		start := time.Now()
+		receipts, err := fetcher.GetBlocksReceipts(ctx, hashes)
+		if err == nil {
+			m.receiptsPrefetchDuration.Observe(time.Since(start).Seconds())
+			m.prefetchedReceiptsBlocks.Add(float64(len(blocks)))
+			for idx, block := range blocks {
+				block.Receipts = receipts[idx]
+				if block.Receipts == nil {
+					// told apart from the blocks whose receipts weren't fetched
+					block.Receipts = []*Receipt{}
+				}
+			}
+			return true
+		}
+		m.failedReceiptsPrefetches.Inc()
+		logger.WithError(err).WithFields(logging.Fields{
+			"from_block_number": blocks[0].Number,
+			"to_block_number":   blocks[len(blocks)-1].Number,
+		}).Error("Failed to prefetch block receipts, retrying")
+
+		select {
+		case <-ctx.Done():
+			return false
+		case <-time.After(retryInterval):
+		}
+	}
+}
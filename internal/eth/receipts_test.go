@@ -0,0 +1,133 @@
+package eth_test
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/eth/mocks"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+//go:generate moq -out mocks/receipts_fetcher.go -pkg mocks -skip-ensure . ReceiptsFetcher
+
+func TestPrefetchReceipts(t *testing.T) {
+	held := []*eth.Receipt{{TxHash: "0x0c"}}
+	events := []*eth.BlockEvent{
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 1, Hash: "1a"}},
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 2, Hash: "2a"}},
+		{Type: eth.BlockOrphaned, Block: &eth.Block{Number: 2, Hash: "2a"}},
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 2, Hash: "2b", Receipts: held}},
+		{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 3, Hash: "3b"}},
+	}
+	in := make(chan *eth.BlockEvent, len(events))
+	for event := range slices.Values(events) {
+		in <- event
+	}
+	close(in)
+
+	var failed bool
+	fetcherMock := &mocks.ReceiptsFetcherMock{
+		GetBlocksReceiptsFunc: func(ctx context.Context, hashes []string) ([][]*eth.Receipt, error) {
+			if !failed {
+				failed = true
+				return nil, errors.New("dummy error")
+			}
+			receipts := make([][]*eth.Receipt, 0, len(hashes))
+			for hash := range slices.Values(hashes) {
+				if hash == "3b" {
+					// an empty block
+					receipts = append(receipts, nil)
+					continue
+				}
+				receipts = append(receipts, []*eth.Receipt{{TxHash: "0x" + hash}})
+			}
+			return receipts, nil
+		},
+	}
+
+	out := eth.PrefetchReceipts(context.Background(), logging.Logrus(logrus.New()), in, fetcherMock,
+		eth.WithPrefetchBatchSize(3),
+		eth.WithPrefetchRetryInterval(time.Millisecond),
+	)
+	var received []*eth.BlockEvent
+	for event := range out {
+		received = append(received, event)
+	}
+
+	assert.Equal(t, events, received)
+	assert.Equal(t, []*eth.Receipt{{TxHash: "0x1a"}}, received[0].Block.Receipts)
+	assert.Equal(t, []*eth.Receipt{{TxHash: "0x2a"}}, received[1].Block.Receipts)
+	assert.Equal(t, held, received[3].Block.Receipts)
+	assert.Equal(t, []*eth.Receipt{}, received[4].Block.Receipts)
+
+	var batches [][]string
+	for call := range slices.Values(fetcherMock.GetBlocksReceiptsCalls()) {
+		batches = append(batches, call.Hashes)
+	}
+	// the first batch is retried, the orphaned block and the one holding its receipts skipped
+	assert.Equal(t, [][]string{{"1a", "2a"}, {"1a", "2a"}, {"3b"}}, batches)
+}
+
+func TestPrefetchReceiptsStopsOnDone(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 1)
+	in <- &eth.BlockEvent{Type: eth.BlockConfirmed, Block: &eth.Block{Number: 1, Hash: "1a"}}
+	ctx, cancel := context.WithCancel(context.Background())
+	fetcherMock := &mocks.ReceiptsFetcherMock{
+		GetBlocksReceiptsFunc: func(ctx context.Context, hashes []string) ([][]*eth.Receipt, error) {
+			cancel()
+			return nil, errors.New("dummy error")
+		},
+	}
+
+	out := eth.PrefetchReceipts(ctx, logging.Logrus(logrus.New()), in, fetcherMock, eth.WithPrefetchRetryInterval(time.Hour))
+	_, ok := <-out
+	assert.False(t, ok)
+}
+
+func TestGetBlocksReceipts(t *testing.T) {
+	tests := map[string]struct {
+		response         string
+		expectedReceipts [][]*eth.Receipt
+		expectedErr      error
+	}{
+		"receipts in the order of the hashes": {
+			response: `[{"jsonrpc":"2.0","id":1,"result":[]},{"jsonrpc":"2.0","id":0,"result":[{"transactionHash":"0x01","status":"0x1","logs":[]}]}]`,
+			expectedReceipts: [][]*eth.Receipt{
+				{{TxHash: "0x01", Status: eth.ReceiptStatusSuccess, Logs: []*eth.Log{}}},
+				{},
+			},
+		},
+		"unknown block": {
+			response:    `[{"jsonrpc":"2.0","id":0,"result":[]},{"jsonrpc":"2.0","id":1,"result":null}]`,
+			expectedErr: eth.ErrNotFound,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(test.response))
+			}))
+			defer srv.Close()
+
+			client := eth.New(logging.Logrus(logrus.New()), srv.Client(), srv.URL)
+			receipts, err := client.GetBlocksReceipts(context.Background(), []string{"0xaa", "0xbb"})
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedReceipts, receipts)
+		})
+	}
+}
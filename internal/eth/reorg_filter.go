@@ -2,25 +2,252 @@ package eth
 
 import (
 	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
 
-	"github.com/sirupsen/logrus"
+	"github.com/prometheus/client_golang/prometheus"
 
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/logging"
 	"github.com/hedisam/ethtxparser/internal/ringbuffer"
 	"github.com/hedisam/pipeline/chans"
 )
 
-func ReorgFilter(ctx context.Context, logger *logrus.Logger, in <-chan *Block, confirmationDepth uint) <-chan *Block {
-	out := make(chan *Block)
+// BlockEventType is the type of a BlockEvent.
+type BlockEventType int
+
+const (
+	// BlockConfirmed is emitted for blocks buried under the confirmation depth, in chain order.
+	BlockConfirmed BlockEventType = iota + 1
+	// BlockOrphaned is emitted for previously confirmed blocks orphaned by a reorganisation deeper than the
+	// confirmation depth, newest first and before the blocks replacing them are confirmed.
+	BlockOrphaned
+)
+
+func (t BlockEventType) String() string {
+	switch t {
+	case BlockConfirmed:
+		return "confirmed"
+	case BlockOrphaned:
+		return "orphaned"
+	default:
+		return "unknown"
+	}
+}
+
+// BlockEvent is an event of the stream of confirmed blocks.
+type BlockEvent struct {
+	Type  BlockEventType
+	Block *Block
+}
+
+// AncestorFetcher fetches blocks by hash, used to walk back the canonical chain on deep reorganisations.
+type AncestorFetcher interface {
+	GetBlockByHash(ctx context.Context, hash string) (*Block, error)
+}
+
+// ConfirmationDepth is a confirmation depth that can be changed while the reorg filter is running.
+type ConfirmationDepth struct {
+	depth atomic.Uint64
+}
+
+// NewConfirmationDepth returns a ConfirmationDepth set to the given depth.
+func NewConfirmationDepth(depth uint) *ConfirmationDepth {
+	d := &ConfirmationDepth{}
+	d.Set(depth)
+	return d
+}
+
+// Get returns the current confirmation depth.
+func (d *ConfirmationDepth) Get() uint {
+	return uint(d.depth.Load())
+}
+
+// Set changes the confirmation depth, applied by the reorg filter before the next received block. A depth of 1 is
+// used if the given value is zero.
+func (d *ConfirmationDepth) Set(depth uint) {
+	d.depth.Store(uint64(max(1, depth)))
+}
+
+// ReorgAlertReason is the reason a ReorgAlert is raised for.
+type ReorgAlertReason string
+
+const (
+	// ReorgAlertDeep is raised for a reorganisation replacing at least the alert depth threshold of blocks.
+	ReorgAlertDeep ReorgAlertReason = "deep"
+	// ReorgAlertRepeated is raised once the alert count threshold of reorganisations happen within the alert window.
+	ReorgAlertRepeated ReorgAlertReason = "repeated"
+)
+
+// ReorgAlert reports chain reorganisations that usually indicate node provider issues or a chain incident.
+type ReorgAlert struct {
+	Reason ReorgAlertReason
+	// Depth is the number of blocks, buffered or confirmed, replaced by the last reorganisation.
+	Depth int
+	// BlockNumber and BlockHash identify the new block of the last reorganisation.
+	BlockNumber int64
+	BlockHash   string
+	// Reorgs is the number of reorganisations within the window, including the last one.
+	Reorgs int
+	Window time.Duration
+}
+
+// ReorgAlerter is alerted of deep or repeated reorganisations.
+type ReorgAlerter interface {
+	AlertReorg(ctx context.Context, alert *ReorgAlert) error
+}
+
+// ReorgAlertThresholds sets when reorganisations are alerted of.
+type ReorgAlertThresholds struct {
+	// Depth alerts of every reorganisation replacing at least that many blocks. Disabled if zero.
+	Depth uint
+	// Count alerts once that many reorganisations happen within Window, the count restarting after each alert.
+	// Disabled if zero.
+	Count  int
+	Window time.Duration
+}
+
+type reorgConfig struct {
+	fetcher    AncestorFetcher
+	maxDepth   uint
+	depth      *ConfirmationDepth
+	alerter    ReorgAlerter
+	thresholds ReorgAlertThresholds
+	health     *health.Component
+	registerer prometheus.Registerer
+}
+
+type ReorgOption func(*reorgConfig)
+
+// WithDeepReorgRecovery makes the filter recover from reorganisations deeper than the confirmation depth, up to
+// maxDepth blocks deep, by fetching the ancestors of the new block until it finds the common ancestor with the
+// confirmed blocks. The confirmed blocks above the common ancestor are orphaned and the fetched canonical ones are
+// buffered, and confirmed, before the new block.
+func WithDeepReorgRecovery(fetcher AncestorFetcher, maxDepth uint) ReorgOption {
+	return func(c *reorgConfig) {
+		c.fetcher = fetcher
+		c.maxDepth = maxDepth
+	}
+}
+
+// WithAdjustableDepth makes the filter follow the given confirmation depth instead of the fixed one, resizing its
+// buffer when it changes. Lowering the depth confirms the oldest buffered blocks that no longer fit rather than
+// dropping them; raising it holds the next blocks back until the buffer fills up again.
+func WithAdjustableDepth(depth *ConfirmationDepth) ReorgOption {
+	return func(c *reorgConfig) {
+		c.depth = depth
+	}
+}
+
+// WithReorgAlerts alerts the given alerter, without blocking the filter, of the reorganisations reaching the given
+// thresholds.
+func WithReorgAlerts(alerter ReorgAlerter, thresholds ReorgAlertThresholds) ReorgOption {
+	return func(c *reorgConfig) {
+		c.alerter = alerter
+		c.thresholds = thresholds
+	}
+}
+
+// WithReorgHealth reports the health of the filter to the given component, failing on the deep reorganisations it
+// fails to recover from.
+func WithReorgHealth(component *health.Component) ReorgOption {
+	return func(c *reorgConfig) {
+		c.health = component
+	}
+}
+
+// WithReorgRegisterer sets the registerer the metrics of the filter are registered with, the custom registry by
+// default.
+func WithReorgRegisterer(reg prometheus.Registerer) ReorgOption {
+	return func(c *reorgConfig) {
+		c.registerer = reg
+	}
+}
+
+// ReorgFilter buffers the received blocks until they're confirmationDepth deep, dropping the ones orphaned by
+// reorganisations while buffered, and emits a BlockConfirmed event for each block leaving the buffer. The last
+// confirmationDepth confirmed blocks are kept so that, if a reorganisation reaches below the buffer, BlockOrphaned
+// events are emitted for the confirmed blocks at or above the height of the new block, and for its confirmed parent
+// if their hashes differ. Orphans deeper than that can't be told apart unless deep reorg recovery is enabled.
+func ReorgFilter(ctx context.Context, logger logging.Logger, in <-chan *Block, confirmationDepth uint, opts ...ReorgOption) <-chan *BlockEvent {
+	cfg := &reorgConfig{
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	if cfg.depth == nil {
+		cfg.depth = NewConfirmationDepth(confirmationDepth)
+	}
+	m := newMetrics(cfg.registerer)
+
+	out := make(chan *BlockEvent)
 
 	go func() {
 		defer close(out)
 
-		rb := ringbuffer.New[*Block](confirmationDepth)
+		depth := cfg.depth.Get()
+		m.reorgConfirmationDepth.Set(float64(depth))
+		rb := ringbuffer.New[*Block](depth)
+		// the confirmed blocks kept for deep reorg recovery grow up to the max depth as they're confirmed
+		confirmed := ringbuffer.NewGrowable[*Block](depth, max(depth, cfg.maxDepth))
+		// confirm pops the oldest buffered block and emits it as confirmed
+		confirm := func() bool {
+			first, _ := rb.Pop()
+			m.reorgWaitDuration.Observe(time.Since(first.bufferedAt).Seconds())
+			if confirmed.IsFull() {
+				confirmed.Pop()
+			}
+			_ = confirmed.Push(first)
+			return chans.SendOrDone(ctx, out, &BlockEvent{Type: BlockConfirmed, Block: first})
+		}
+		// replaced counts the blocks, buffered or confirmed, replaced by the received block
+		var replaced int
+		orphan := func(orphan *Block) bool {
+			logger.WithField("orphaned_block_number", orphan.Number).Warn("Block reorganisation deeper than confirmation depth, orphaning confirmed block")
+			m.reorgOrphanedBlocks.Inc()
+			replaced++
+			return chans.SendOrDone(ctx, out, &BlockEvent{Type: BlockOrphaned, Block: orphan})
+		}
+		alerts := newReorgAlertTracker(logger, cfg, m)
+		// buffered is the number of buffered blocks last added to the gauge, summed up across the filters sharing it
+		var buffered int
+		defer func() {
+			m.reorgBufferedBlocks.Sub(float64(buffered))
+		}()
+
 		for block := range chans.ReceiveOrDoneSeq(ctx, in) {
-			logger := logger.WithFields(logrus.Fields{
+			if newDepth := cfg.depth.Get(); newDepth != depth {
+				logger.WithFields(logging.Fields{
+					"old_depth": depth,
+					"new_depth": newDepth,
+				}).Info("Confirmation depth changed, resizing reorg buffer")
+				// the buffered blocks beyond the new depth are confirmed, the next block being pushed when full
+				for rb.Size() > int(newDepth) {
+					if !confirm() {
+						return
+					}
+				}
+				depth = newDepth
+				rb.Resize(depth)
+				confirmed.Resize(max(depth, cfg.maxDepth))
+				m.reorgConfirmationDepth.Set(float64(depth))
+			}
+
+			logger := logger.WithFields(logging.Fields{
 				"block_hash":  block.Hash,
 				"parent_hash": block.ParentHash,
 			})
+			// tip is the number of the last received block, buffered or confirmed
+			tip := int64(-1)
+			if last, ok := rb.Back(); ok {
+				tip = last.Number
+			} else if last, ok := confirmed.Back(); ok {
+				tip = last.Number
+			}
 			// check if reorg has happened
 			for rb.Size() > 0 {
 				tail, _ := rb.Back()
@@ -33,20 +260,171 @@ func ReorgFilter(ctx context.Context, logger *logrus.Logger, in <-chan *Block, c
 				// end up with this newly received block as the only one in the queue.
 				logger.WithField("tail_hash", tail.Hash).Warn("Block reorganisation detected, dropping last queued non matching block")
 				rb.DropBack()
-				reorgDroppedBlocks.Inc()
+				m.reorgDroppedBlocks.Inc()
+				replaced++
 			}
 
+			// recoveryErr is set if the block is forked below the buffer and the canonical chain can't be walked back
+			var recoveryErr error
+			if rb.Size() == 0 && cfg.fetcher != nil && reorged(confirmed, block, tip) {
+				// the reorg reaches below the buffer, the canonical chain is walked back to the confirmed blocks
+				ancestors, err := canonicalAncestors(ctx, cfg, confirmed, block)
+				if err != nil {
+					logger.WithError(err).Error("Failed to fetch the canonical ancestors of block after deep reorganisation")
+					m.deepReorgRecoveries.WithLabelValues("failure").Inc()
+					recoveryErr = fmt.Errorf("could not recover from deep reorganisation at block %d: %w", block.Number, err)
+				} else {
+					m.deepReorgRecoveries.WithLabelValues("success").Inc()
+				}
+				for orphaned := range orphanedBlocks(confirmed, ancestors[0]) {
+					if !orphan(orphaned) {
+						return
+					}
+				}
+				for ancestor := range slices.Values(ancestors[:len(ancestors)-1]) {
+					if rb.IsFull() && !confirm() {
+						return
+					}
+					ancestor.bufferedAt = time.Now()
+					_ = rb.Push(ancestor)
+				}
+			}
+
+			if rb.Size() == 0 {
+				// the reorg may reach the confirmed blocks already emitted downstream
+				for orphaned := range orphanedBlocks(confirmed, block) {
+					if !orphan(orphaned) {
+						return
+					}
+				}
+			}
+
+			cfg.health.Report(recoveryErr)
+
+			if replaced > 0 && block.Number <= tip+1 {
+				// blocks dropped because of a gap aren't replaced by a reorganisation
+				alerts.observe(ctx, block, replaced)
+			}
+			replaced = 0
+
 			if rb.IsFull() {
 				// pop the oldest block and send it to the output channel before pushing this new block
-				first, _ := rb.Pop()
-				if !chans.SendOrDone(ctx, out, first) {
+				if !confirm() {
 					return
 				}
 			}
 
+			block.bufferedAt = time.Now()
 			_ = rb.Push(block)
+			m.reorgBufferedBlocks.Add(float64(rb.Size() - buffered))
+			buffered = rb.Size()
 		}
 	}()
 
 	return out
 }
+
+// reorgAlertTracker raises alerts of deep and repeated reorganisations.
+type reorgAlertTracker struct {
+	logger  logging.Logger
+	cfg     *reorgConfig
+	metrics *metrics
+	// times holds the times of the reorganisations within the alert window.
+	times []time.Time
+}
+
+func newReorgAlertTracker(logger logging.Logger, cfg *reorgConfig, m *metrics) *reorgAlertTracker {
+	return &reorgAlertTracker{
+		logger:  logger,
+		cfg:     cfg,
+		metrics: m,
+	}
+}
+
+// observe records a reorganisation replacing the given number of blocks by the given block, and alerts of it if it
+// reaches a threshold.
+func (a *reorgAlertTracker) observe(ctx context.Context, block *Block, depth int) {
+	if a.cfg.alerter == nil {
+		return
+	}
+
+	now := time.Now()
+	a.times = slices.DeleteFunc(append(a.times, now), func(t time.Time) bool {
+		return now.Sub(t) > a.cfg.thresholds.Window
+	})
+	alert := &ReorgAlert{
+		Depth:       depth,
+		BlockNumber: block.Number,
+		BlockHash:   block.Hash,
+		Reorgs:      len(a.times),
+		Window:      a.cfg.thresholds.Window,
+	}
+	switch {
+	case a.cfg.thresholds.Depth > 0 && depth >= int(a.cfg.thresholds.Depth):
+		alert.Reason = ReorgAlertDeep
+	case a.cfg.thresholds.Count > 0 && len(a.times) >= a.cfg.thresholds.Count:
+		alert.Reason = ReorgAlertRepeated
+		a.times = nil
+	default:
+		return
+	}
+
+	a.metrics.reorgAlerts.WithLabelValues(string(alert.Reason)).Inc()
+	go func() {
+		err := a.cfg.alerter.AlertReorg(ctx, alert)
+		if err != nil {
+			a.logger.WithError(err).WithFields(logging.Fields{
+				"reason":       alert.Reason,
+				"block_number": alert.BlockNumber,
+			}).Error("Failed to alert of chain reorganisation")
+		}
+	}()
+}
+
+// reorged reports whether the given block, received with an empty buffer, isn't the child of the last confirmed block
+// while following or replacing the last received block, the tip, i.e. it isn't past a gap.
+func reorged(confirmed *ringbuffer.RingBuffer[*Block], block *Block, tip int64) bool {
+	last, ok := confirmed.Back()
+	return ok && block.Number <= tip+1 && block.ParentHash != last.Hash
+}
+
+// canonicalAncestors fetches the ancestors of the given block until the parent of the oldest one is a confirmed block,
+// or the maximum reorg depth is reached, and returns them oldest first followed by the block. The fetched ancestors
+// are returned along with the error if one can't be fetched.
+func canonicalAncestors(ctx context.Context, cfg *reorgConfig, confirmed *ringbuffer.RingBuffer[*Block], block *Block) ([]*Block, error) {
+	hashes := make(map[string]struct{}, confirmed.Size())
+	for b := range confirmed.All() {
+		hashes[b.Hash] = struct{}{}
+	}
+
+	chain := []*Block{block}
+	for range cfg.maxDepth {
+		if _, ok := hashes[chain[0].ParentHash]; ok {
+			break
+		}
+		parent, err := cfg.fetcher.GetBlockByHash(ctx, chain[0].ParentHash)
+		if err != nil {
+			return chain, fmt.Errorf("could not get block %s: %w", chain[0].ParentHash, err)
+		}
+		chain = slices.Insert(chain, 0, parent)
+	}
+	return chain, nil
+}
+
+// orphanedBlocks drops and yields the newest confirmed blocks the given block replaces: the ones at or above its
+// height, and its parent if its hash doesn't match. Confirmed blocks below its parent's height are left as they are,
+// as are all of them if the block is past a gap.
+func orphanedBlocks(confirmed *ringbuffer.RingBuffer[*Block], block *Block) func(yield func(*Block) bool) {
+	return func(yield func(*Block) bool) {
+		for confirmed.Size() > 0 {
+			last, _ := confirmed.Back()
+			if last.Number < block.Number-1 || (last.Number == block.Number-1 && last.Hash == block.ParentHash) {
+				return
+			}
+			confirmed.DropBack()
+			if !yield(last) {
+				return
+			}
+		}
+	}
+}
@@ -0,0 +1,315 @@
+package eth_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/eth/mocks"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+//go:generate moq -out mocks/ancestor_fetcher.go -pkg mocks -skip-ensure . AncestorFetcher
+//go:generate moq -out mocks/reorg_alerter.go -pkg mocks -skip-ensure . ReorgAlerter
+
+func TestReorgFilter(t *testing.T) {
+	tests := map[string]struct {
+		blocks []*eth.Block
+		// canonical enables deep reorg recovery, fetching ancestors from these blocks
+		canonical      []*eth.Block
+		expectedEvents []string
+	}{
+		"linked blocks are confirmed": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 4, Hash: "4a", ParentHash: "3a"},
+			},
+			expectedEvents: []string{"confirmed 1a", "confirmed 2a"},
+		},
+		"reorg within the buffer drops the unconfirmed blocks": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 3, Hash: "3b", ParentHash: "2a"},
+				{Number: 4, Hash: "4b", ParentHash: "3b"},
+				{Number: 5, Hash: "5b", ParentHash: "4b"},
+			},
+			expectedEvents: []string{"confirmed 1a", "confirmed 2a", "confirmed 3b"},
+		},
+		"reorg below the buffer orphans the confirmed blocks": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 4, Hash: "4a", ParentHash: "3a"},
+				{Number: 5, Hash: "5a", ParentHash: "4a"},
+				{Number: 3, Hash: "3b", ParentHash: "2b"},
+				{Number: 4, Hash: "4b", ParentHash: "3b"},
+				{Number: 5, Hash: "5b", ParentHash: "4b"},
+			},
+			expectedEvents: []string{
+				"confirmed 1a", "confirmed 2a", "confirmed 3a",
+				"orphaned 3a", "orphaned 2a",
+				"confirmed 3b",
+			},
+		},
+		"gap isn't a reorg of the confirmed blocks": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 9, Hash: "9a", ParentHash: "8a"},
+				{Number: 10, Hash: "10a", ParentHash: "9a"},
+				{Number: 11, Hash: "11a", ParentHash: "10a"},
+			},
+			expectedEvents: []string{"confirmed 1a", "confirmed 9a"},
+		},
+		"deep reorg recovery refetches the canonical chain": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 4, Hash: "4a", ParentHash: "3a"},
+				{Number: 5, Hash: "5a", ParentHash: "4a"},
+				{Number: 6, Hash: "6a", ParentHash: "5a"},
+				{Number: 7, Hash: "7b", ParentHash: "6b"},
+			},
+			canonical: []*eth.Block{
+				{Number: 4, Hash: "4b", ParentHash: "3a"},
+				{Number: 5, Hash: "5b", ParentHash: "4b"},
+				{Number: 6, Hash: "6b", ParentHash: "5b"},
+			},
+			expectedEvents: []string{
+				"confirmed 1a", "confirmed 2a", "confirmed 3a", "confirmed 4a",
+				"orphaned 4a",
+				"confirmed 4b", "confirmed 5b",
+			},
+		},
+		"failed deep reorg recovery keeps the fetched ancestors": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 4, Hash: "4b", ParentHash: "3b"},
+				{Number: 5, Hash: "5b", ParentHash: "4b"},
+			},
+			canonical: []*eth.Block{
+				{Number: 3, Hash: "3b", ParentHash: "2b"},
+			},
+			expectedEvents: []string{"confirmed 1a", "confirmed 3b"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			in := make(chan *eth.Block, len(test.blocks))
+			for block := range slices.Values(test.blocks) {
+				in <- block
+			}
+			close(in)
+
+			var opts []eth.ReorgOption
+			if test.canonical != nil {
+				fetcher := &mocks.AncestorFetcherMock{
+					GetBlockByHashFunc: func(ctx context.Context, hash string) (*eth.Block, error) {
+						i := slices.IndexFunc(test.canonical, func(b *eth.Block) bool { return b.Hash == hash })
+						if i < 0 {
+							return nil, errors.New("not found")
+						}
+						return test.canonical[i], nil
+					},
+				}
+				opts = append(opts, eth.WithDeepReorgRecovery(fetcher, 8))
+			}
+
+			var events []string
+			for event := range eth.ReorgFilter(context.Background(), logging.Logrus(logrus.New()), in, 2, opts...) {
+				events = append(events, event.Type.String()+" "+event.Block.Hash)
+			}
+			assert.Equal(t, test.expectedEvents, events)
+		})
+	}
+}
+
+func TestReorgFilterAdjustableDepth(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	depth := eth.NewConfirmationDepth(2)
+	in := make(chan *eth.Block)
+	events := make(chan string, 10)
+	go func() {
+		defer close(events)
+		for event := range eth.ReorgFilter(ctx, logging.Logrus(logrus.New()), in, 2, eth.WithAdjustableDepth(depth)) {
+			events <- event.Type.String() + " " + event.Block.Hash
+		}
+	}()
+
+	send := func(numbers ...int64) {
+		for number := range slices.Values(numbers) {
+			in <- &eth.Block{Number: number, Hash: fmt.Sprintf("%da", number), ParentHash: fmt.Sprintf("%da", number-1)}
+		}
+	}
+	receive := func(n int) []string {
+		var received []string
+		for range n {
+			received = append(received, <-events)
+		}
+		return received
+	}
+
+	send(1, 2, 3, 4)
+	assert.Equal(t, []string{"confirmed 1a", "confirmed 2a"}, receive(2))
+
+	// lowering the depth confirms the buffered blocks that no longer fit
+	depth.Set(1)
+	send(5)
+	assert.Equal(t, []string{"confirmed 3a", "confirmed 4a"}, receive(2))
+
+	// raising it holds the next blocks back until the buffer fills up
+	depth.Set(3)
+	send(6, 7, 8)
+	close(in)
+	assert.Equal(t, []string{"confirmed 5a"}, receive(1))
+	_, ok := <-events
+	assert.False(t, ok)
+}
+
+func TestReorgFilterMetrics(t *testing.T) {
+	waits := histogramCount(t, "ethtxparser_block_reorg_wait_duration_seconds")
+
+	in := make(chan *eth.Block)
+	out := eth.ReorgFilter(context.Background(), logging.Logrus(logrus.New()), in, 2)
+	for number := range int64(3) {
+		in <- &eth.Block{Number: number, Hash: fmt.Sprintf("%da", number), ParentHash: fmt.Sprintf("%da", number-1)}
+	}
+	// the third block confirms the first, the other two are held back
+	event := <-out
+	assert.Equal(t, "0a", event.Block.Hash)
+	require.Eventually(t, func() bool {
+		return gaugeValue(t, "ethtxparser_reorg_buffered_blocks") == 2
+	}, time.Second, time.Millisecond*10)
+	assert.Equal(t, waits+1, histogramCount(t, "ethtxparser_block_reorg_wait_duration_seconds"))
+
+	close(in)
+	for range out {
+	}
+	assert.Zero(t, gaugeValue(t, "ethtxparser_reorg_buffered_blocks"))
+}
+
+func TestReorgFilterAlerts(t *testing.T) {
+	tests := map[string]struct {
+		blocks         []*eth.Block
+		thresholds     eth.ReorgAlertThresholds
+		expectedAlerts []*eth.ReorgAlert
+	}{
+		"deep reorg": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 3, Hash: "3a", ParentHash: "2a"},
+				{Number: 4, Hash: "4a", ParentHash: "3a"},
+				{Number: 3, Hash: "3b", ParentHash: "2b"},
+			},
+			thresholds: eth.ReorgAlertThresholds{Depth: 3},
+			expectedAlerts: []*eth.ReorgAlert{
+				{Reason: eth.ReorgAlertDeep, Depth: 3, BlockNumber: 3, BlockHash: "3b", Reorgs: 1},
+			},
+		},
+		"shallow reorg": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 2, Hash: "2b", ParentHash: "1a"},
+			},
+			thresholds: eth.ReorgAlertThresholds{Depth: 3, Count: 2, Window: time.Hour},
+		},
+		"repeated reorgs": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 2, Hash: "2b", ParentHash: "1a"},
+				{Number: 3, Hash: "3b", ParentHash: "2b"},
+				{Number: 3, Hash: "3c", ParentHash: "2b"},
+			},
+			thresholds: eth.ReorgAlertThresholds{Count: 2, Window: time.Hour},
+			expectedAlerts: []*eth.ReorgAlert{
+				{Reason: eth.ReorgAlertRepeated, Depth: 1, BlockNumber: 3, BlockHash: "3c", Reorgs: 2, Window: time.Hour},
+			},
+		},
+		"gap isn't a reorg": {
+			blocks: []*eth.Block{
+				{Number: 1, Hash: "1a"},
+				{Number: 2, Hash: "2a", ParentHash: "1a"},
+				{Number: 9, Hash: "9a", ParentHash: "8a"},
+			},
+			thresholds: eth.ReorgAlertThresholds{Depth: 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			in := make(chan *eth.Block, len(test.blocks))
+			for block := range slices.Values(test.blocks) {
+				in <- block
+			}
+			close(in)
+
+			alerted := make(chan *eth.ReorgAlert, 10)
+			alerter := &mocks.ReorgAlerterMock{
+				AlertReorgFunc: func(ctx context.Context, alert *eth.ReorgAlert) error {
+					alerted <- alert
+					return nil
+				},
+			}
+			for range eth.ReorgFilter(context.Background(), logging.Logrus(logrus.New()), in, 2, eth.WithReorgAlerts(alerter, test.thresholds)) {
+			}
+
+			for expected := range slices.Values(test.expectedAlerts) {
+				select {
+				case alert := <-alerted:
+					assert.Equal(t, expected, alert)
+				case <-time.After(time.Second):
+					require.Fail(t, "alert not raised")
+				}
+			}
+			assert.Len(t, alerter.AlertReorgCalls(), len(test.expectedAlerts))
+		})
+	}
+}
+
+// gaugeValue returns the value of the unlabelled gauge of the custom registry.
+func gaugeValue(t *testing.T, name string) float64 {
+	families, err := custompromauto.Registry().Gather()
+	require.NoError(t, err)
+	for family := range slices.Values(families) {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetGauge().GetValue()
+		}
+	}
+	return 0
+}
+
+// histogramCount returns the number of observations of the unlabelled histogram of the custom registry.
+func histogramCount(t *testing.T, name string) uint64 {
+	families, err := custompromauto.Registry().Gather()
+	require.NoError(t, err)
+	for family := range slices.Values(families) {
+		if family.GetName() == name {
+			return family.GetMetric()[0].GetHistogram().GetSampleCount()
+		}
+	}
+	return 0
+}
@@ -3,8 +3,10 @@ package eth
 import (
 	"encoding/json"
 	"fmt"
+	"math/big"
 	"strconv"
 	"strings"
+	"time"
 )
 
 type rpcMethod string
@@ -16,6 +18,12 @@ func (rm rpcMethod) ID() int {
 		return 1
 	case getBlockByNumberID:
 		return 2
+	case getBlockReceipts:
+		return 3
+	case getBlockByHashID:
+		return 4
+	case getRawTxByHash:
+		return 5
 	default:
 		return -1
 	}
@@ -25,16 +33,26 @@ type Block struct {
 	Hash       string `json:"hash"`
 	Number     int64  `json:"number"`
 	ParentHash string `json:"parentHash"`
-	Txs        []*Tx  `json:"transactions"`
+	// Timestamp is the unix time in seconds the block was minted at.
+	Timestamp int64 `json:"timestamp"`
+	Txs       []*Tx `json:"transactions"`
+	// Receipts holds the block's transaction receipts, only populated if the client is configured to fetch them.
+	Receipts []*Receipt `json:"-"`
+	// TraceID is the W3C trace ID the block was fetched under, attached as an exemplar to the latency of its
+	// processing stages.
+	TraceID string `json:"-"`
+	// bufferedAt is the time the reorg filter buffered the block at, timing its wait for confirmation.
+	bufferedAt time.Time
 }
 
-// UnmarshalJSON customizes Block decoding to parse the hex block number.
+// UnmarshalJSON customizes Block decoding to parse the hex block number and timestamp.
 func (b *Block) UnmarshalJSON(data []byte) error {
 	// alias to avoid infinite recursion
 	type blockAlias Block
 	aux := &struct {
 		*blockAlias
-		Number string `json:"number"`
+		Number    string `json:"number"`
+		Timestamp string `json:"timestamp"`
 	}{
 		blockAlias: (*blockAlias)(b),
 	}
@@ -44,29 +62,44 @@ func (b *Block) UnmarshalJSON(data []byte) error {
 		return fmt.Errorf("error unmarshalling Block: %w", err)
 	}
 
-	blockNumStr := strings.TrimPrefix(aux.Number, "0x")
-	blockNum, err := strconv.ParseInt(blockNumStr, 16, 64)
+	blockNum, err := hexToInt64(aux.Number)
 	if err != nil {
 		return fmt.Errorf("invalid block number %q: %w", aux.Number, err)
 	}
 	b.Number = blockNum
 
+	if aux.Timestamp != "" {
+		b.Timestamp, err = hexToInt64(aux.Timestamp)
+		if err != nil {
+			return fmt.Errorf("invalid block timestamp %q: %w", aux.Timestamp, err)
+		}
+	}
+
 	return nil
 }
 
 type Tx struct {
-	Hash string `json:"hash"`
-	From string `json:"from"`
-	To   string `json:"to"`
+	Hash  string   `json:"hash"`
+	From  string   `json:"from"`
+	To    string   `json:"to"`
+	Input string   `json:"input"`
+	Value *big.Int `json:"-"`
+	// Type is the EIP-2718 type of the transaction, 0 for legacy transactions including the ones of nodes not
+	// returning it.
+	Type int64  `json:"-"`
 	Raw  []byte `json:"-"`
 }
 
-// UnmarshalJSON ensures Hash, From, and To are parsed and the full raw JSON is stored.
+// UnmarshalJSON ensures Hash, From, To, and Value are parsed and the full raw JSON is stored. From and To are
+// normalized to lower case, as addresses are everywhere past decoding.
 func (t *Tx) UnmarshalJSON(data []byte) error {
 	var aux struct {
-		Hash string `json:"hash"`
-		From string `json:"from"`
-		To   string `json:"to"`
+		Hash  string `json:"hash"`
+		From  string `json:"from"`
+		To    string `json:"to"`
+		Input string `json:"input"`
+		Value string `json:"value"`
+		Type  string `json:"type"`
 	}
 	err := json.Unmarshal(data, &aux)
 	if err != nil {
@@ -74,9 +107,102 @@ func (t *Tx) UnmarshalJSON(data []byte) error {
 	}
 
 	t.Hash = aux.Hash
-	t.From = aux.From
-	t.To = aux.To
+	t.From = strings.ToLower(aux.From)
+	t.To = strings.ToLower(aux.To)
+	t.Input = aux.Input
+	if aux.Value != "" {
+		value, ok := new(big.Int).SetString(strings.TrimPrefix(aux.Value, "0x"), 16)
+		if !ok {
+			return fmt.Errorf("invalid tx value %q", aux.Value)
+		}
+		t.Value = value
+	}
+	if aux.Type != "" {
+		t.Type, err = hexToInt64(aux.Type)
+		if err != nil {
+			return fmt.Errorf("invalid tx type %q: %w", aux.Type, err)
+		}
+	}
 	t.Raw = append([]byte(nil), data...) // make a copy; safe against mutations
 
 	return nil
 }
+
+const (
+	// ReceiptStatusUnknown is the status of pre-byzantium receipts, which don't record whether the tx succeeded.
+	ReceiptStatusUnknown int64 = -1
+	ReceiptStatusFailed  int64 = 0
+	ReceiptStatusSuccess int64 = 1
+)
+
+// Receipt holds the parts of a transaction receipt we're interested in.
+type Receipt struct {
+	TxHash string `json:"transactionHash"`
+	// Status is one of ReceiptStatusUnknown, ReceiptStatusFailed and ReceiptStatusSuccess.
+	Status int64  `json:"status"`
+	Logs   []*Log `json:"logs"`
+}
+
+// UnmarshalJSON customizes Receipt decoding to parse the hex status.
+func (r *Receipt) UnmarshalJSON(data []byte) error {
+	type receiptAlias Receipt
+	aux := &struct {
+		*receiptAlias
+		Status string `json:"status"`
+	}{
+		receiptAlias: (*receiptAlias)(r),
+	}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling Receipt: %w", err)
+	}
+
+	// pre-byzantium receipts have no status field
+	r.Status = ReceiptStatusUnknown
+	if aux.Status != "" {
+		r.Status, err = hexToInt64(aux.Status)
+		if err != nil {
+			return fmt.Errorf("invalid receipt status %q: %w", aux.Status, err)
+		}
+	}
+
+	return nil
+}
+
+// Log is an event log emitted by a transaction.
+type Log struct {
+	Address  string   `json:"address"`
+	Topics   []string `json:"topics"`
+	Data     string   `json:"data"`
+	LogIndex int64    `json:"logIndex"`
+	TxHash   string   `json:"transactionHash"`
+}
+
+// UnmarshalJSON customizes Log decoding to parse the hex log index and normalize the address to lower case.
+func (l *Log) UnmarshalJSON(data []byte) error {
+	type logAlias Log
+	aux := &struct {
+		*logAlias
+		LogIndex string `json:"logIndex"`
+	}{
+		logAlias: (*logAlias)(l),
+	}
+
+	err := json.Unmarshal(data, &aux)
+	if err != nil {
+		return fmt.Errorf("error unmarshalling Log: %w", err)
+	}
+
+	l.Address = strings.ToLower(l.Address)
+	l.LogIndex, err = hexToInt64(aux.LogIndex)
+	if err != nil {
+		return fmt.Errorf("invalid log index %q: %w", aux.LogIndex, err)
+	}
+
+	return nil
+}
+
+func hexToInt64(s string) (int64, error) {
+	return strconv.ParseInt(strings.TrimPrefix(s, "0x"), 16, 64)
+}
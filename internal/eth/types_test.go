@@ -0,0 +1,117 @@
+package eth_test
+
+import (
+	"encoding/json"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+)
+
+func TestTxUnmarshalJSON(t *testing.T) {
+	tests := map[string]struct {
+		data        string
+		expectedTx  *eth.Tx
+		errContains string
+	}{
+		"mixed case addresses are normalized": {
+			data: `{"hash":"0xabc","from":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","to":"0xBC4CA0EdA7647A8aB7C2061c2E118A18a936f13D","input":"0xa9059cbb","value":"0xde0b6b3a7640000"}`,
+			expectedTx: &eth.Tx{
+				Hash:  "0xabc",
+				From:  "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				To:    "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+				Input: "0xa9059cbb",
+				Value: big.NewInt(1000000000000000000),
+			},
+		},
+		"contract creation without recipient": {
+			data: `{"hash":"0xabc","from":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","to":null,"value":"0x1"}`,
+			expectedTx: &eth.Tx{
+				Hash:  "0xabc",
+				From:  "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Value: big.NewInt(1),
+			},
+		},
+		"typed transaction": {
+			data: `{"hash":"0xabc","from":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","value":"0x1","type":"0x2"}`,
+			expectedTx: &eth.Tx{
+				Hash:  "0xabc",
+				From:  "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+				Value: big.NewInt(1),
+				Type:  2,
+			},
+		},
+		"invalid type": {
+			data:        `{"hash":"0xabc","type":"0xzz"}`,
+			errContains: "invalid tx type",
+		},
+		"invalid value": {
+			data:        `{"hash":"0xabc","value":"0xzz"}`,
+			errContains: "invalid tx value",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var tx eth.Tx
+			err := json.Unmarshal([]byte(test.data), &tx)
+			if test.errContains != "" {
+				require.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+
+			test.expectedTx.Raw = []byte(test.data)
+			assert.Equal(t, test.expectedTx, &tx)
+		})
+	}
+}
+
+func TestLogUnmarshalJSON(t *testing.T) {
+	var log eth.Log
+	err := json.Unmarshal([]byte(`{"address":"0xA0b86991c6218b36c1d19D4a2e9Eb0cE3606eB48","topics":[],"data":"0x","logIndex":"0x1f","transactionHash":"0xabc"}`), &log)
+	require.NoError(t, err)
+	assert.Equal(t, "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48", log.Address)
+	assert.Equal(t, int64(31), log.LogIndex)
+}
+
+func TestReceiptUnmarshalJSON(t *testing.T) {
+	tests := map[string]struct {
+		data           string
+		expectedStatus int64
+	}{
+		"successful tx": {
+			data:           `{"transactionHash":"0xabc","status":"0x1","logs":[]}`,
+			expectedStatus: eth.ReceiptStatusSuccess,
+		},
+		"reverted tx": {
+			data:           `{"transactionHash":"0xabc","status":"0x0","logs":[]}`,
+			expectedStatus: eth.ReceiptStatusFailed,
+		},
+		"pre-byzantium receipt": {
+			data:           `{"transactionHash":"0xabc","root":"0x01","logs":[]}`,
+			expectedStatus: eth.ReceiptStatusUnknown,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var receipt eth.Receipt
+			err := json.Unmarshal([]byte(test.data), &receipt)
+			require.NoError(t, err)
+			assert.Equal(t, "0xabc", receipt.TxHash)
+			assert.Equal(t, test.expectedStatus, receipt.Status)
+		})
+	}
+}
+
+func TestBlockUnmarshalJSON(t *testing.T) {
+	var block eth.Block
+	err := json.Unmarshal([]byte(`{"number":"0x10","hash":"0xabc","parentHash":"0xdef","timestamp":"0x6553f100","transactions":[]}`), &block)
+	require.NoError(t, err)
+	assert.Equal(t, int64(16), block.Number)
+	assert.Equal(t, int64(1700000000), block.Timestamp)
+}
@@ -0,0 +1,143 @@
+// Package export writes the transactions of a stream of blocks out as JSON lines or CSV.
+package export
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// Format is the format the transactions are written in.
+type Format string
+
+const (
+	// FormatJSONL writes a JSON object per line per transaction.
+	FormatJSONL Format = "jsonl"
+	// FormatCSV writes a header line followed by a CSV line per transaction.
+	FormatCSV Format = "csv"
+)
+
+// ErrUnknownFormat is returned when parsing a format that isn't supported.
+var ErrUnknownFormat = errors.New("unknown export format")
+
+// ParseFormat parses the name of an export format.
+func ParseFormat(s string) (Format, error) {
+	switch f := Format(s); f {
+	case FormatJSONL, FormatCSV:
+		return f, nil
+	default:
+		return "", fmt.Errorf("%w %q, expected %q or %q", ErrUnknownFormat, s, FormatJSONL, FormatCSV)
+	}
+}
+
+// Transaction is an exported transaction.
+type Transaction struct {
+	BlockNumber    int64  `json:"blockNumber"`
+	BlockHash      string `json:"blockHash"`
+	BlockTimestamp int64  `json:"blockTimestamp"`
+	Hash           string `json:"hash"`
+	From           string `json:"from"`
+	To             string `json:"to"`
+	// Value is the transferred value in wei, in decimal.
+	Value string `json:"value"`
+	Input string `json:"input"`
+}
+
+var csvHeader = []string{"block_number", "block_hash", "block_timestamp", "hash", "from", "to", "value", "input"}
+
+func (tx *Transaction) csvRecord() []string {
+	return []string{
+		strconv.FormatInt(tx.BlockNumber, 10),
+		tx.BlockHash,
+		strconv.FormatInt(tx.BlockTimestamp, 10),
+		tx.Hash,
+		tx.From,
+		tx.To,
+		tx.Value,
+		tx.Input,
+	}
+}
+
+// Transactions writes the transactions of the confirmed blocks received until the channel is closed, skipping the
+// orphaned ones, and returns the number of transactions written. If addresses are given, only the transactions sent
+// from or to one of them are written.
+func Transactions(ctx context.Context, w io.Writer, format Format, in <-chan *eth.BlockEvent, addresses ...string) (int, error) {
+	var write func(tx *Transaction) error
+	var flush func() error
+	switch format {
+	case FormatJSONL:
+		encoder := json.NewEncoder(w)
+		write = func(tx *Transaction) error {
+			return encoder.Encode(tx)
+		}
+		flush = func() error {
+			return nil
+		}
+	case FormatCSV:
+		csvWriter := csv.NewWriter(w)
+		err := csvWriter.Write(csvHeader)
+		if err != nil {
+			return 0, fmt.Errorf("could not write csv header: %w", err)
+		}
+		write = func(tx *Transaction) error {
+			return csvWriter.Write(tx.csvRecord())
+		}
+		flush = func() error {
+			csvWriter.Flush()
+			return csvWriter.Error()
+		}
+	default:
+		return 0, fmt.Errorf("%w %q", ErrUnknownFormat, format)
+	}
+
+	wanted := make(map[string]bool, len(addresses))
+	for addr := range slices.Values(addresses) {
+		wanted[strings.ToLower(addr)] = true
+	}
+
+	var written int
+	for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+		if event == nil || event.Block == nil || event.Type != eth.BlockConfirmed {
+			continue
+		}
+		block := event.Block
+		for tx := range slices.Values(block.Txs) {
+			if len(wanted) > 0 && !wanted[tx.From] && !wanted[tx.To] {
+				continue
+			}
+			value := "0"
+			if tx.Value != nil {
+				value = tx.Value.String()
+			}
+			err := write(&Transaction{
+				BlockNumber:    block.Number,
+				BlockHash:      block.Hash,
+				BlockTimestamp: block.Timestamp,
+				Hash:           tx.Hash,
+				From:           tx.From,
+				To:             tx.To,
+				Value:          value,
+				Input:          tx.Input,
+			})
+			if err != nil {
+				return written, fmt.Errorf("could not write transaction %s: %w", tx.Hash, err)
+			}
+			written++
+		}
+	}
+
+	err := flush()
+	if err != nil {
+		return written, fmt.Errorf("could not flush exported transactions: %w", err)
+	}
+	return written, ctx.Err()
+}
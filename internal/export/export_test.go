@@ -0,0 +1,75 @@
+package export_test
+
+import (
+	"bytes"
+	"context"
+	"math/big"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/export"
+)
+
+func TestTransactions(t *testing.T) {
+	tests := map[string]struct {
+		format          export.Format
+		addresses       []string
+		expectedWritten int
+		expectedOutput  string
+		expectedErr     error
+	}{
+		"jsonl": {
+			format:          export.FormatJSONL,
+			expectedWritten: 2,
+			expectedOutput: `{"blockNumber":5,"blockHash":"0x5","blockTimestamp":1700000000,"hash":"0x51","from":"0xaa","to":"0xbb","value":"1000","input":"0x"}
+{"blockNumber":5,"blockHash":"0x5","blockTimestamp":1700000000,"hash":"0x52","from":"0xcc","to":"","value":"0","input":"0x60"}
+`,
+		},
+		"csv of the given addresses": {
+			format:          export.FormatCSV,
+			addresses:       []string{"0xBB"},
+			expectedWritten: 1,
+			expectedOutput: `block_number,block_hash,block_timestamp,hash,from,to,value,input
+5,0x5,1700000000,0x51,0xaa,0xbb,1000,0x
+`,
+		},
+		"unknown format": {
+			format:      "xml",
+			expectedErr: export.ErrUnknownFormat,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			in := make(chan *eth.BlockEvent, 2)
+			in <- &eth.BlockEvent{Type: eth.BlockConfirmed, Block: &eth.Block{
+				Number:    5,
+				Hash:      "0x5",
+				Timestamp: 1700000000,
+				Txs: []*eth.Tx{
+					{Hash: "0x51", From: "0xaa", To: "0xbb", Value: big.NewInt(1000), Input: "0x"},
+					{Hash: "0x52", From: "0xcc", Input: "0x60"},
+				},
+			}}
+			in <- &eth.BlockEvent{Type: eth.BlockOrphaned, Block: &eth.Block{
+				Number: 4,
+				Hash:   "0x4",
+				Txs:    []*eth.Tx{{Hash: "0x41", From: "0xaa", To: "0xbb"}},
+			}}
+			close(in)
+
+			var out bytes.Buffer
+			written, err := export.Transactions(context.Background(), &out, test.format, in, test.addresses...)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedWritten, written)
+			assert.Equal(t, test.expectedOutput, out.String())
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package expr
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// node is a type checked expression node. Values are bools, strings and *big.Rat numbers.
+type node interface {
+	typ() Type
+	eval(lookup Lookup) any
+}
+
+type literalNode struct {
+	value any
+	t     Type
+}
+
+func (n *literalNode) typ() Type {
+	return n.t
+}
+
+func (n *literalNode) eval(Lookup) any {
+	return n.value
+}
+
+type variableNode struct {
+	name string
+	t    Type
+}
+
+func (n *variableNode) typ() Type {
+	return n.t
+}
+
+// eval converts the looked up value to the declared type of the variable, falling back to its zero value.
+func (n *variableNode) eval(lookup Lookup) any {
+	value := lookup(n.name)
+	switch n.t {
+	case Bool:
+		b, _ := value.(bool)
+		return b
+	case String:
+		s, _ := value.(string)
+		return s
+	default:
+		switch v := value.(type) {
+		case int64:
+			return new(big.Rat).SetInt64(v)
+		case *big.Int:
+			if v != nil {
+				return new(big.Rat).SetInt(v)
+			}
+		}
+		return new(big.Rat)
+	}
+}
+
+type notNode struct {
+	operand node
+}
+
+func (n *notNode) typ() Type {
+	return Bool
+}
+
+func (n *notNode) eval(lookup Lookup) any {
+	return !n.operand.eval(lookup).(bool)
+}
+
+type logicalNode struct {
+	and         bool
+	left, right node
+}
+
+func newLogical(tok token, left, right node) (node, error) {
+	if left.typ() != Bool || right.typ() != Bool {
+		return nil, fmt.Errorf("cannot apply %s to a %s and a %s at position %d", tok.text, left.typ(), right.typ(), tok.pos)
+	}
+	return &logicalNode{and: tok.text == "&&", left: left, right: right}, nil
+}
+
+func (n *logicalNode) typ() Type {
+	return Bool
+}
+
+func (n *logicalNode) eval(lookup Lookup) any {
+	left := n.left.eval(lookup).(bool)
+	if left != n.and {
+		// short circuit: false && ..., true || ...
+		return left
+	}
+	return n.right.eval(lookup).(bool)
+}
+
+type comparisonNode struct {
+	op          string
+	left, right node
+}
+
+func newComparison(tok token, left, right node) (node, error) {
+	if left.typ() != right.typ() {
+		return nil, fmt.Errorf("cannot compare a %s with a %s at position %d", left.typ(), right.typ(), tok.pos)
+	}
+	if left.typ() != Number && tok.text != "==" && tok.text != "!=" {
+		return nil, fmt.Errorf("cannot order %ss with %s at position %d", left.typ(), tok.text, tok.pos)
+	}
+	return &comparisonNode{op: tok.text, left: left, right: right}, nil
+}
+
+func (n *comparisonNode) typ() Type {
+	return Bool
+}
+
+// eval compares numbers by value, strings case-insensitively as they're mostly hex addresses and hashes, and bools.
+func (n *comparisonNode) eval(lookup Lookup) any {
+	var cmp int
+	switch left := n.left.eval(lookup).(type) {
+	case *big.Rat:
+		cmp = left.Cmp(n.right.eval(lookup).(*big.Rat))
+	case string:
+		if !strings.EqualFold(left, n.right.eval(lookup).(string)) {
+			cmp = 1
+		}
+	case bool:
+		if left != n.right.eval(lookup).(bool) {
+			cmp = 1
+		}
+	}
+
+	switch n.op {
+	case "==":
+		return cmp == 0
+	case "!=":
+		return cmp != 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	default:
+		return cmp >= 0
+	}
+}
@@ -0,0 +1,241 @@
+// Package expr implements a small, side effect free boolean expression language used to filter records, e.g.
+// `value > 1e18 && to == "0xabc..."`. Expressions combine declared variables, number, string and bool literals with
+// the ||, &&, !, ==, !=, <, <=, > and >= operators and parentheses. They're type checked when compiled and can't loop
+// or call functions, so evaluating one is bounded by its size.
+package expr
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strconv"
+)
+
+const (
+	// MaxLength is the maximum length of an expression source.
+	MaxLength = 1024
+	// maxDepth is the maximum nesting depth of an expression.
+	maxDepth = 32
+)
+
+// ErrInvalid is returned when an expression can't be compiled.
+var ErrInvalid = errors.New("invalid expression")
+
+// Type is the type of a variable or expression.
+type Type int
+
+const (
+	Bool Type = iota + 1
+	Number
+	String
+)
+
+func (t Type) String() string {
+	switch t {
+	case Bool:
+		return "bool"
+	case Number:
+		return "number"
+	case String:
+		return "string"
+	default:
+		return "unknown"
+	}
+}
+
+// Vars declares the variables expressions can refer to, by name.
+type Vars map[string]Type
+
+// Lookup returns the value of the given declared variable: a bool, a string, an int64 or *big.Int number, or nil for
+// the zero value of its type.
+type Lookup func(name string) any
+
+// Expr is a compiled boolean expression.
+type Expr struct {
+	src  string
+	root node
+}
+
+// Compile parses and type checks the given boolean expression, referring only to the given variables.
+func Compile(src string, vars Vars) (*Expr, error) {
+	if len(src) > MaxLength {
+		return nil, fmt.Errorf("%w: longer than %d characters", ErrInvalid, MaxLength)
+	}
+	tokens, err := lex(src)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+
+	p := &parser{tokens: tokens, vars: vars}
+	root, err := p.parseOr(0)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %w", ErrInvalid, err)
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, fmt.Errorf("%w: unexpected %q at position %d", ErrInvalid, tok.text, tok.pos)
+	}
+	if root.typ() != Bool {
+		return nil, fmt.Errorf("%w: expression is a %s, not a bool", ErrInvalid, root.typ())
+	}
+
+	return &Expr{src: src, root: root}, nil
+}
+
+// String returns the source of the expression.
+func (e *Expr) String() string {
+	return e.src
+}
+
+// Eval evaluates the expression with the variable values returned by lookup.
+func (e *Expr) Eval(lookup Lookup) bool {
+	return e.root.eval(lookup).(bool)
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+	vars   Vars
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+func (p *parser) parseOr(depth int) (node, error) {
+	if depth > maxDepth {
+		return nil, fmt.Errorf("nested deeper than %d levels", maxDepth)
+	}
+	left, err := p.parseAnd(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "||" {
+		tok := p.next()
+		right, err := p.parseAnd(depth)
+		if err != nil {
+			return nil, err
+		}
+		left, err = newLogical(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseAnd(depth int) (node, error) {
+	left, err := p.parseNot(depth)
+	if err != nil {
+		return nil, err
+	}
+	for p.peek().text == "&&" {
+		tok := p.next()
+		right, err := p.parseNot(depth)
+		if err != nil {
+			return nil, err
+		}
+		left, err = newLogical(tok, left, right)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot(depth int) (node, error) {
+	if p.peek().kind != tokenOp || p.peek().text != "!" {
+		return p.parseComparison(depth)
+	}
+	tok := p.next()
+	if depth+1 > maxDepth {
+		return nil, fmt.Errorf("nested deeper than %d levels", maxDepth)
+	}
+	operand, err := p.parseNot(depth + 1)
+	if err != nil {
+		return nil, err
+	}
+	if operand.typ() != Bool {
+		return nil, fmt.Errorf("cannot negate a %s at position %d", operand.typ(), tok.pos)
+	}
+	return &notNode{operand: operand}, nil
+}
+
+func (p *parser) parseComparison(depth int) (node, error) {
+	left, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	tok := p.peek()
+	switch tok.text {
+	case "==", "!=", "<", "<=", ">", ">=":
+	default:
+		return left, nil
+	}
+	p.next()
+	right, err := p.parseOperand(depth)
+	if err != nil {
+		return nil, err
+	}
+	return newComparison(tok, left, right)
+}
+
+func (p *parser) parseOperand(depth int) (node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenLParen:
+		inner, err := p.parseOr(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, fmt.Errorf("missing closing parenthesis at position %d", closing.pos)
+		}
+		return inner, nil
+	case tokenNumber:
+		n, ok := parseNumber(tok.text)
+		if !ok {
+			return nil, fmt.Errorf("invalid number %q at position %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: n, t: Number}, nil
+	case tokenString:
+		s, err := strconv.Unquote(tok.text)
+		if err != nil {
+			return nil, fmt.Errorf("invalid string %s at position %d", tok.text, tok.pos)
+		}
+		return &literalNode{value: s, t: String}, nil
+	case tokenIdent:
+		switch tok.text {
+		case "true", "false":
+			return &literalNode{value: tok.text == "true", t: Bool}, nil
+		}
+		t, ok := p.vars[tok.text]
+		if !ok {
+			return nil, fmt.Errorf("unknown variable %q at position %d", tok.text, tok.pos)
+		}
+		return &variableNode{name: tok.text, t: t}, nil
+	case tokenEOF:
+		return nil, errors.New("unexpected end of expression")
+	default:
+		return nil, fmt.Errorf("unexpected %q at position %d", tok.text, tok.pos)
+	}
+}
+
+// parseNumber parses a 0x-prefixed hex integer or a decimal number with an optional exponent.
+func parseNumber(s string) (*big.Rat, bool) {
+	if len(s) > 2 && (s[:2] == "0x" || s[:2] == "0X") {
+		n, ok := new(big.Int).SetString(s[2:], 16)
+		if !ok {
+			return nil, false
+		}
+		return new(big.Rat).SetInt(n), true
+	}
+	return new(big.Rat).SetString(s)
+}
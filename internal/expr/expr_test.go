@@ -0,0 +1,85 @@
+package expr_test
+
+import (
+	"math/big"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/expr"
+)
+
+var vars = expr.Vars{
+	"to":      expr.String,
+	"value":   expr.Number,
+	"block":   expr.Number,
+	"failed":  expr.Bool,
+	"missing": expr.Number,
+}
+
+func TestEval(t *testing.T) {
+	values := map[string]any{
+		"to":     "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+		"value":  new(big.Int).Mul(big.NewInt(2), new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil)),
+		"block":  int64(100),
+		"failed": false,
+	}
+	lookup := func(name string) any {
+		return values[name]
+	}
+
+	tests := map[string]bool{
+		`value > 1e18`:                   true,
+		`value >= 2e18 && value <= 2e18`: true,
+		`value < 1.5e18`:                 false,
+		`block == 0x64`:                  true,
+		`block != 100 || value > 1e18`:   true,
+		`to == "0x7A250D5630B4CF539739DF2C5DACB4C659F2488D"`: true,
+		`to != "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"`: false,
+		`!failed && (block > 99 || false)`:                   true,
+		`!(value > 1e18)`:                                    false,
+		`missing == 0`:                                       true,
+		`failed == false`:                                    true,
+	}
+
+	for src, expected := range tests {
+		t.Run(src, func(t *testing.T) {
+			e, err := expr.Compile(src, vars)
+			require.NoError(t, err)
+			assert.Equal(t, expected, e.Eval(lookup))
+			assert.Equal(t, src, e.String())
+		})
+	}
+}
+
+func TestCompileInvalid(t *testing.T) {
+	tests := map[string]struct {
+		src         string
+		errContains string
+	}{
+		"not a bool":          {src: `value`, errContains: "expression is a number, not a bool"},
+		"unknown variable":    {src: `gas > 1`, errContains: `unknown variable "gas"`},
+		"mismatched types":    {src: `to == 1`, errContains: "cannot compare a string with a number"},
+		"ordered strings":     {src: `to < "0x1"`, errContains: "cannot order strings with <"},
+		"logical on numbers":  {src: `value && failed`, errContains: "cannot apply && to a number and a bool"},
+		"negated number":      {src: `!value`, errContains: "cannot negate a number"},
+		"unterminated string": {src: `to == "0x1`, errContains: "unterminated string"},
+		"unclosed paren":      {src: `(failed`, errContains: "missing closing parenthesis"},
+		"trailing tokens":     {src: `failed failed`, errContains: `unexpected "failed"`},
+		"unexpected char":     {src: `value > 1 + 2`, errContains: `unexpected character '+'`},
+		"empty":               {src: ``, errContains: "unexpected end of expression"},
+		"invalid number":      {src: `value > 1.2.3`, errContains: `invalid number "1.2.3"`},
+		"too deep":            {src: strings.Repeat("(", 40) + "failed" + strings.Repeat(")", 40), errContains: "nested deeper than"},
+		"too long":            {src: strings.Repeat(" ", expr.MaxLength) + "failed", errContains: "longer than"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := expr.Compile(test.src, vars)
+			require.ErrorIs(t, err, expr.ErrInvalid)
+			assert.ErrorContains(t, err, test.errContains)
+		})
+	}
+}
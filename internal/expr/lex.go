@@ -0,0 +1,139 @@
+package expr
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenIdent
+	tokenNumber
+	tokenString
+	tokenOp
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int
+}
+
+// operators are the supported operators, two character ones first so they're matched before their prefixes.
+var operators = []string{"||", "&&", "==", "!=", "<=", ">=", "<", ">", "!"}
+
+// lex splits the source into tokens, ending with a tokenEOF.
+func lex(src string) ([]token, error) {
+	var tokens []token
+	for pos := 0; pos < len(src); {
+		c := rune(src[pos])
+		switch {
+		case unicode.IsSpace(c):
+			pos++
+		case c == '(':
+			tokens = append(tokens, token{kind: tokenLParen, text: "(", pos: pos})
+			pos++
+		case c == ')':
+			tokens = append(tokens, token{kind: tokenRParen, text: ")", pos: pos})
+			pos++
+		case c == '"':
+			end, err := stringEnd(src, pos)
+			if err != nil {
+				return nil, err
+			}
+			tokens = append(tokens, token{kind: tokenString, text: src[pos:end], pos: pos})
+			pos = end
+		case isDigit(c):
+			end := numberEnd(src, pos)
+			tokens = append(tokens, token{kind: tokenNumber, text: src[pos:end], pos: pos})
+			pos = end
+		case isIdentStart(c):
+			end := pos + 1
+			for end < len(src) && isIdentPart(rune(src[end])) {
+				end++
+			}
+			tokens = append(tokens, token{kind: tokenIdent, text: src[pos:end], pos: pos})
+			pos = end
+		default:
+			op, ok := operatorAt(src, pos)
+			if !ok {
+				return nil, fmt.Errorf("unexpected character %q at position %d", c, pos)
+			}
+			tokens = append(tokens, token{kind: tokenOp, text: op, pos: pos})
+			pos += len(op)
+		}
+	}
+
+	return append(tokens, token{kind: tokenEOF, pos: len(src)}), nil
+}
+
+// stringEnd returns the position after the closing quote of the string starting at the given position.
+func stringEnd(src string, start int) (int, error) {
+	for pos := start + 1; pos < len(src); pos++ {
+		switch src[pos] {
+		case '\\':
+			pos++
+		case '"':
+			return pos + 1, nil
+		}
+	}
+	return 0, fmt.Errorf("unterminated string at position %d", start)
+}
+
+// numberEnd returns the position after the number starting at the given position, either a 0x-prefixed hex integer
+// or a decimal with an optional fraction and exponent, e.g. 1.5e18.
+func numberEnd(src string, start int) int {
+	if strings.HasPrefix(src[start:], "0x") || strings.HasPrefix(src[start:], "0X") {
+		end := start + 2
+		for end < len(src) && isHexDigit(rune(src[end])) {
+			end++
+		}
+		return end
+	}
+
+	end := start
+	for end < len(src) && (isDigit(rune(src[end])) || src[end] == '.') {
+		end++
+	}
+	if end < len(src) && (src[end] == 'e' || src[end] == 'E') {
+		end++
+		if end < len(src) && (src[end] == '+' || src[end] == '-') {
+			end++
+		}
+		for end < len(src) && isDigit(rune(src[end])) {
+			end++
+		}
+	}
+	return end
+}
+
+func operatorAt(src string, pos int) (string, bool) {
+	for op := range slices.Values(operators) {
+		if strings.HasPrefix(src[pos:], op) {
+			return op, true
+		}
+	}
+	return "", false
+}
+
+func isDigit(c rune) bool {
+	return c >= '0' && c <= '9'
+}
+
+func isHexDigit(c rune) bool {
+	return isDigit(c) || (c >= 'a' && c <= 'f') || (c >= 'A' && c <= 'F')
+}
+
+func isIdentStart(c rune) bool {
+	return c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z')
+}
+
+func isIdentPart(c rune) bool {
+	return isIdentStart(c) || isDigit(c)
+}
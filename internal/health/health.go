@@ -0,0 +1,158 @@
+// Package health tracks the health of the components of a chain's pipeline, the node client, the reorg filter, the
+// indexer, the store and the notifiers, each reporting its status and last error as it goes.
+package health
+
+import (
+	"cmp"
+	"context"
+	"errors"
+	"maps"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// the components of a pipeline, the notifiers being named after theirs, see Notifier
+const (
+	Node        = "node"
+	ReorgFilter = "reorg_filter"
+	Indexer     = "indexer"
+	Store       = "store"
+)
+
+// Notifier returns the component of the named notifier, e.g. notifier_webhook.
+func Notifier(name string) string {
+	return "notifier_" + name
+}
+
+// Registry holds the components of a chain's pipeline.
+type Registry struct {
+	chain      string
+	mu         sync.Mutex
+	components map[string]*Component
+}
+
+func NewRegistry(chain string) *Registry {
+	return &Registry{
+		chain:      chain,
+		components: make(map[string]*Component),
+	}
+}
+
+// Component returns the named component, registering it as healthy the first time. A nil registry returns a nil
+// component, which reports nothing.
+func (r *Registry) Component(name string) *Component {
+	if r == nil {
+		return nil
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	c, ok := r.components[name]
+	if !ok {
+		c = &Component{
+			gauge: componentHealthy.WithLabelValues(r.chain, name),
+			status: Status{
+				Component: name,
+				Healthy:   true,
+				Since:     time.Now(),
+			},
+		}
+		c.gauge.Set(1)
+		r.components[name] = c
+	}
+	return c
+}
+
+// Statuses returns the status of every component, sorted by component.
+func (r *Registry) Statuses() []Status {
+	r.mu.Lock()
+	components := slices.Collect(maps.Values(r.components))
+	r.mu.Unlock()
+
+	statuses := make([]Status, 0, len(components))
+	for c := range slices.Values(components) {
+		statuses = append(statuses, c.Status())
+	}
+	slices.SortFunc(statuses, func(a, b Status) int {
+		return cmp.Compare(a.Component, b.Component)
+	})
+	return statuses
+}
+
+// Healthy reports whether every component is healthy.
+func (r *Registry) Healthy() bool {
+	for status := range slices.Values(r.Statuses()) {
+		if !status.Healthy {
+			return false
+		}
+	}
+	return true
+}
+
+// Status is the health of a component.
+type Status struct {
+	Component string
+	Healthy   bool
+	// Since is when the component last turned healthy or unhealthy, or was registered.
+	Since time.Time
+	// LastError is the last error reported, kept once healthy again. Empty if none was.
+	LastError   string
+	LastErrorAt time.Time
+	// Provider is the provider serving the component, e.g. the host of the node, to attribute its errors. Empty if
+	// not applicable.
+	Provider string
+}
+
+// Component reports the health of a subsystem of the pipeline.
+type Component struct {
+	gauge  prometheus.Gauge
+	mu     sync.Mutex
+	status Status
+}
+
+// Report marks the component unhealthy if err is set and healthy otherwise, e.g. after every call to the node.
+// Cancellations aren't reported, as they're made by the callers on shutdown or when they go away.
+func (c *Component) Report(err error) {
+	if c == nil || errors.Is(err, context.Canceled) {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	now := time.Now()
+	healthy := err == nil
+	if healthy != c.status.Healthy {
+		c.status.Healthy = healthy
+		c.status.Since = now
+	}
+	if err != nil {
+		c.status.LastError = err.Error()
+		c.status.LastErrorAt = now
+	}
+	if healthy {
+		c.gauge.Set(1)
+	} else {
+		c.gauge.Set(0)
+	}
+}
+
+// SetProvider sets the provider serving the component, reported along with its status.
+func (c *Component) SetProvider(provider string) {
+	if c == nil {
+		return
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.status.Provider = provider
+}
+
+// Status returns the current health of the component.
+func (c *Component) Status() Status {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.status
+}
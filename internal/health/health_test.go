@@ -0,0 +1,120 @@
+package health_test
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/health"
+)
+
+func TestComponentReport(t *testing.T) {
+	tests := map[string]struct {
+		reports           []error
+		expectedHealthy   bool
+		expectedLastError string
+	}{
+		"healthy once registered": {
+			expectedHealthy: true,
+		},
+		"unhealthy on error": {
+			reports:           []error{nil, errors.New("connection refused")},
+			expectedLastError: "connection refused",
+		},
+		"healthy again keeps the last error": {
+			reports:           []error{errors.New("connection refused"), nil},
+			expectedHealthy:   true,
+			expectedLastError: "connection refused",
+		},
+		"cancellations not reported": {
+			reports:         []error{fmt.Errorf("call: %w", context.Canceled)},
+			expectedHealthy: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			chain := t.Name()
+			registry := health.NewRegistry(chain)
+			component := registry.Component(health.Node)
+			for err := range slices.Values(test.reports) {
+				component.Report(err)
+			}
+
+			status := component.Status()
+			assert.Equal(t, health.Node, status.Component)
+			assert.Equal(t, test.expectedHealthy, status.Healthy)
+			assert.Equal(t, test.expectedLastError, status.LastError)
+			assert.Equal(t, test.expectedLastError != "", !status.LastErrorAt.IsZero())
+			assert.Equal(t, test.expectedHealthy, registry.Healthy())
+
+			expectedGauge := 0.0
+			if test.expectedHealthy {
+				expectedGauge = 1
+			}
+			assert.Equal(t, expectedGauge, componentGauge(t, chain, health.Node))
+		})
+	}
+}
+
+func TestRegistryStatuses(t *testing.T) {
+	registry := health.NewRegistry(t.Name())
+	registry.Component(health.Store).Report(errors.New("deadline exceeded"))
+	registry.Component(health.Notifier("webhook"))
+	registry.Component(health.Indexer)
+	// the same component is returned for the same name
+	registry.Component(health.Store).Report(nil)
+
+	var components []string
+	for status := range slices.Values(registry.Statuses()) {
+		components = append(components, status.Component)
+	}
+	assert.Equal(t, []string{health.Indexer, "notifier_webhook", health.Store}, components)
+	assert.True(t, registry.Healthy())
+
+	// a nil registry reports nothing
+	var nilRegistry *health.Registry
+	nilRegistry.Component(health.Node).Report(errors.New("ignored"))
+	nilRegistry.Component(health.Node).SetProvider("ignored")
+}
+
+func TestComponentProvider(t *testing.T) {
+	registry := health.NewRegistry(t.Name())
+	component := registry.Component(health.Node)
+	assert.Empty(t, component.Status().Provider)
+
+	component.SetProvider("mainnet.infura.io")
+	component.Report(errors.New("connection refused"))
+	status := component.Status()
+	assert.Equal(t, "mainnet.infura.io", status.Provider)
+	assert.False(t, status.Healthy)
+	assert.Equal(t, []health.Status{status}, registry.Statuses())
+}
+
+// componentGauge returns the value of the health gauge of the component of the chain.
+func componentGauge(t *testing.T, chain, component string) float64 {
+	families, err := custompromauto.Registry().Gather()
+	require.NoError(t, err)
+	for family := range slices.Values(families) {
+		if family.GetName() != "ethtxparser_component_healthy" {
+			continue
+		}
+		for metric := range slices.Values(family.GetMetric()) {
+			labels := make(map[string]string)
+			for label := range slices.Values(metric.GetLabel()) {
+				labels[label.GetName()] = label.GetValue()
+			}
+			if labels["chain"] == chain && labels["component"] == component {
+				return metric.GetGauge().GetValue()
+			}
+		}
+	}
+	t.Fatalf("no health gauge of component %q", component)
+	return 0
+}
@@ -0,0 +1,12 @@
+package health
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+var componentHealthy = custompromauto.Auto().NewGaugeVec(prometheus.GaugeOpts{
+	Name: "ethtxparser_component_healthy",
+	Help: "1 while the component of the chain's pipeline is healthy, 0 since it last reported an error",
+}, []string{"chain", "component"})
@@ -0,0 +1,51 @@
+package index
+
+import (
+	"sync"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// otherAddresses labels the transactions of the addresses past the limit of the address metrics.
+const otherAddresses = "other"
+
+// addressMetrics exports the transactions matched per address, labelled by the first addresses matched up to the
+// limit, the others being aggregated under otherAddresses so the cardinality of the metrics stays bounded.
+type addressMetrics struct {
+	limit    int
+	mu       sync.Mutex
+	labelled map[string]struct{}
+	metrics  *metrics
+}
+
+func newAddressMetrics(limit int, m *metrics) *addressMetrics {
+	return &addressMetrics{
+		limit:    limit,
+		labelled: make(map[string]struct{}, limit),
+		metrics:  m,
+	}
+}
+
+// record exports the transactions of the committed block per address.
+func (m *addressMetrics) record(block *store.Block) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := float64(time.Now().Unix())
+	for addr, txs := range block.AddrToTxs {
+		if len(txs) == 0 {
+			continue
+		}
+		label := addr
+		if _, ok := m.labelled[addr]; !ok {
+			if len(m.labelled) >= m.limit {
+				label = otherAddresses
+			} else {
+				m.labelled[addr] = struct{}{}
+			}
+		}
+		m.metrics.addressTransactions.WithLabelValues(label).Add(float64(len(txs)))
+		m.metrics.addressLastMatched.WithLabelValues(label).Set(now)
+	}
+}
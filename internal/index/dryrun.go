@@ -0,0 +1,55 @@
+package index
+
+import (
+	"context"
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+// dryRunSummary holds the number of items that would have been indexed and notified in dry run mode.
+type dryRunSummary struct {
+	mu             sync.Mutex
+	blocks         int
+	txs            int
+	tokenTransfers int
+	events         int
+	notifications  int
+}
+
+// reportDryRun logs what would have been indexed and notified of the matched block, instead of committing it.
+func (i *Index) reportDryRun(ctx context.Context, matched *matchedBlock) {
+	stats := matched.stats
+	notifications := len(matched.records) * len(i.notifierQueues)
+	i.dryRun.mu.Lock()
+	i.dryRun.blocks++
+	i.dryRun.txs += stats.txs
+	i.dryRun.tokenTransfers += stats.tokenTransfers
+	i.dryRun.events += stats.events
+	i.dryRun.notifications += notifications
+	i.dryRun.mu.Unlock()
+	i.metrics.processedBlocks.Inc()
+	i.metrics.dryRunTransactions.Add(float64(stats.txs))
+
+	i.logger.WithContext(ctx).WithFields(logging.Fields{
+		"block_number":            matched.storeBlock.Number,
+		"block_hash":              matched.storeBlock.Hash,
+		"matched_txs":             stats.txs,
+		"matched_token_transfers": stats.tokenTransfers,
+		"matched_events":          stats.events,
+		"notifications":           notifications,
+	}).Info("Dry run: block would have been indexed")
+}
+
+// logDryRunSummary logs the totals of what would have been indexed and notified since the index started.
+func (i *Index) logDryRunSummary() {
+	i.dryRun.mu.Lock()
+	defer i.dryRun.mu.Unlock()
+	i.logger.WithFields(logging.Fields{
+		"blocks":                  i.dryRun.blocks,
+		"matched_txs":             i.dryRun.txs,
+		"matched_token_transfers": i.dryRun.tokenTransfers,
+		"matched_events":          i.dryRun.events,
+		"notifications":           i.dryRun.notifications,
+	}).Info("Dry run summary")
+}
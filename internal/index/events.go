@@ -0,0 +1,64 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// matchEvents scans the block receipts for logs matching the contract event subscriptions.
+// It returns the number of matched events along with the per contract records.
+func (i *Index) matchEvents(ctx context.Context, block *eth.Block) (map[string][]*store.EventRecord, int, error) {
+	contractToEvents := make(map[string][]*store.EventRecord)
+	// a contract usually emits several logs per block; cache its subscriptions to look them up only once.
+	contractToSubs := make(map[string][]*store.EventSubscription)
+	var totalEvents int
+	for receipt := range slices.Values(block.Receipts) {
+		for log := range slices.Values(receipt.Logs) {
+			contract := log.Address
+			subs, ok := contractToSubs[contract]
+			if !ok {
+				var err error
+				subs, err = i.subscriptionStore.GetEventSubscriptions(ctx, contract)
+				if err != nil {
+					return nil, 0, fmt.Errorf("could not get event subscriptions for contract %q: %w", contract, err)
+				}
+				contractToSubs[contract] = subs
+			}
+
+			if !slices.ContainsFunc(subs, func(sub *store.EventSubscription) bool {
+				return sub.Matches(log.Topics)
+			}) {
+				continue
+			}
+
+			contractToEvents[contract] = append(contractToEvents[contract], &store.EventRecord{
+				Contract:    contract,
+				TxHash:      log.TxHash,
+				LogIndex:    log.LogIndex,
+				Topics:      log.Topics,
+				DataWords:   splitDataWords(log.Data),
+				BlockNumber: block.Number,
+				BlockHash:   block.Hash,
+			})
+			totalEvents++
+		}
+	}
+
+	return contractToEvents, totalEvents, nil
+}
+
+// splitDataWords splits the hex encoded event data into its 32 bytes words, each of which is a non-indexed argument
+// for static abi types.
+func splitDataWords(data string) []string {
+	data = strings.TrimPrefix(data, "0x")
+	words := make([]string, 0, len(data)/64)
+	for chunk := range slices.Chunk([]byte(data), 64) {
+		words = append(words, "0x"+string(chunk))
+	}
+	return words
+}
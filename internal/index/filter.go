@@ -0,0 +1,127 @@
+package index
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/hedisam/ethtxparser/internal/bloom"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// SubscriptionWatcher lists the subscribed addresses and watches the changes made to them, used to keep the
+// subscription filter up to date.
+type SubscriptionWatcher interface {
+	GetSubscriptions(ctx context.Context) ([]string, error)
+	WatchSubscriptions(ctx context.Context) <-chan []*store.SubscriptionChange
+	// OnSubscribe registers f to be called with the address of every subscription added, before it's reported added.
+	OnSubscribe(f func(addr string))
+}
+
+// startSubscriptionFilter builds the subscription filter and keeps rebuilding it in the background whenever the
+// subscriptions change. A bloom filter can't forget the removed addresses and is sized for the ones it's built from,
+// so it's rebuilt once per batch of changes rather than updated. The added addresses are added to the current filter
+// straight away though, so none is missed until it's rebuilt, or if it can't be; the rebuild is then retried with
+// backoff.
+func (i *Index) startSubscriptionFilter(ctx context.Context) {
+	// added before the subscription is reported added, so the blocks indexed from then on never skip it
+	i.cfg.subsWatcher.OnSubscribe(func(addr string) {
+		i.addToSubscriptionFilter([]string{addr})
+	})
+	// start watching before the first build so no change made in between is missed
+	changes := i.cfg.subsWatcher.WatchSubscriptions(ctx)
+	bo := newFilterBackoffConfig()
+	var retry <-chan time.Time
+	if !i.rebuildSubscriptionFilter(ctx) {
+		retry = time.After(bo.NextBackOff())
+	}
+
+	go func() {
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case batch, ok := <-changes:
+				if !ok {
+					return
+				}
+				var added []string
+				for change := range slices.Values(batch) {
+					if change.Type == store.SubscriptionAdded {
+						added = append(added, change.Address)
+					}
+				}
+				i.addToSubscriptionFilter(added)
+			case <-retry:
+			}
+
+			if i.rebuildSubscriptionFilter(ctx) {
+				bo.Reset()
+				retry = nil
+				continue
+			}
+			retry = time.After(bo.NextBackOff())
+		}
+	}()
+}
+
+// rebuildSubscriptionFilter replaces the subscription filter with one built from the current subscriptions, reporting
+// whether it did. The previous filter, if any, is kept if they can't be listed; without a filter every address is
+// looked up.
+func (i *Index) rebuildSubscriptionFilter(ctx context.Context) bool {
+	// held while listing, so the addresses added in the meantime are added to the rebuilt filter rather than the
+	// replaced one
+	i.filterMu.Lock()
+	defer i.filterMu.Unlock()
+
+	addresses, err := i.cfg.subsWatcher.GetSubscriptions(ctx)
+	if err != nil {
+		if ctx.Err() == nil {
+			i.logger.WithContext(ctx).WithError(err).Error("Failed to list subscriptions to rebuild the subscription filter, retrying...")
+		}
+		return false
+	}
+
+	filter := bloom.New(len(addresses), i.cfg.filterFPRate)
+	for addr := range slices.Values(addresses) {
+		filter.Add(addr)
+	}
+	i.subscriptionFilter.Store(filter)
+	i.metrics.subscriptionFilterRebuilds.Inc()
+	return true
+}
+
+// addToSubscriptionFilter adds the given addresses to a copy of the subscription filter replacing it, if it's built.
+// The filter is sized for the addresses it was built from, so its false positive rate rises until it's rebuilt.
+func (i *Index) addToSubscriptionFilter(addrs []string) {
+	i.filterMu.Lock()
+	defer i.filterMu.Unlock()
+
+	current := i.subscriptionFilter.Load()
+	if current == nil || len(addrs) == 0 {
+		return
+	}
+	filter := current.Clone()
+	for addr := range slices.Values(addrs) {
+		filter.Add(addr)
+	}
+	i.subscriptionFilter.Store(filter)
+}
+
+// maySubscribe reports whether the given address may be subscribed according to the subscription filter.
+func (i *Index) maySubscribe(addr string) bool {
+	filter := i.subscriptionFilter.Load()
+	return filter == nil || filter.MayContain(addr)
+}
+
+func newFilterBackoffConfig() *backoff.ExponentialBackOff {
+	return backoff.NewExponentialBackOff(
+		backoff.WithMaxElapsedTime(0),
+		backoff.WithMaxInterval(time.Second*30),
+		backoff.WithInitialInterval(time.Millisecond*500),
+		backoff.WithMultiplier(2),
+		backoff.WithRandomizationFactor(0.2),
+	)
+}
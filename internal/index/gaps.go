@@ -0,0 +1,75 @@
+package index
+
+import (
+	"context"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// BlockFetcher fetches blocks by number, used to refetch the blocks missing from the stream.
+type BlockFetcher interface {
+	GetBlock(ctx context.Context, number int64) (*eth.Block, error)
+}
+
+// fillGaps forwards the block events received from in, fetching and forwarding first any confirmed blocks missing
+// between the last confirmed block and the received one. Blocks that can't be fetched are dead-lettered. An orphaned
+// block moves the last confirmed block back below it, the blocks replacing it being expected next.
+func (i *Index) fillGaps(ctx context.Context, in <-chan *eth.BlockEvent) <-chan *eth.BlockEvent {
+	out := make(chan *eth.BlockEvent)
+
+	go func() {
+		defer close(out)
+
+		last := int64(-1)
+		for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+			if event == nil || event.Block == nil {
+				continue
+			}
+			block := event.Block
+			if event.Type == eth.BlockOrphaned {
+				if !chans.SendOrDone(ctx, out, event) {
+					return
+				}
+				last = min(last, block.Number-1)
+				continue
+			}
+
+			if last >= 0 && block.Number > last+1 {
+				i.metrics.blockGaps.Inc()
+				i.logger.WithFields(logging.Fields{
+					"from_block_number": last + 1,
+					"to_block_number":   block.Number - 1,
+				}).Warn("Detected gap in received blocks, refetching missing blocks")
+
+				for number := last + 1; number < block.Number; number++ {
+					missing, err := i.cfg.blockFetcher.GetBlock(ctx, number)
+					if err != nil {
+						if ctx.Err() != nil {
+							return
+						}
+						i.deadLetter(ctx, &failedBlock{
+							block:    &eth.Block{Number: number},
+							attempts: 1,
+							err:      err,
+						})
+						continue
+					}
+
+					i.metrics.refetchedBlocks.Inc()
+					if !chans.SendOrDone(ctx, out, &eth.BlockEvent{Type: eth.BlockConfirmed, Block: missing}) {
+						return
+					}
+				}
+			}
+
+			if !chans.SendOrDone(ctx, out, event) {
+				return
+			}
+			last = max(last, block.Number)
+		}
+	}()
+
+	return out
+}
@@ -0,0 +1,83 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// TxHook is called with every matched transaction record before it's stored, in the order hooks are registered. It
+// can enrich or transform the record, e.g. adding labels such as fiat prices or compliance tags, or veto it by
+// returning false, in which case the record is neither stored nor notified. Returning an error fails the block.
+// Hooks are called concurrently when matching blocks with multiple workers.
+type TxHook interface {
+	HookTx(ctx context.Context, record *store.TxRecord) (bool, error)
+}
+
+// BlockHook is called with every matched block before it's stored, after the tx hooks. It can enrich, transform or
+// remove any of the block records; removed transaction records aren't notified. Returning an error fails the block.
+// Hooks are called concurrently when matching blocks with multiple workers.
+type BlockHook interface {
+	HookBlock(ctx context.Context, block *store.Block) error
+}
+
+// hookTx runs the tx hooks on the record, reporting whether it's kept.
+func (i *Index) hookTx(ctx context.Context, record *store.TxRecord) (bool, error) {
+	for hook := range slices.Values(i.cfg.txHooks) {
+		keep, err := hook.HookTx(ctx, record)
+		if err != nil {
+			return false, fmt.Errorf("tx hook failed for %q: %w", record.Hash, err)
+		}
+		if !keep {
+			i.metrics.vetoedTransactions.Inc()
+			return false, nil
+		}
+	}
+	return true, nil
+}
+
+// hookBlock runs the block hooks on the matched block, then drops the records they removed from the ones to notify
+// and recounts the block stats.
+func (i *Index) hookBlock(ctx context.Context, matched *matchedBlock) error {
+	for hook := range slices.Values(i.cfg.blockHooks) {
+		err := hook.HookBlock(ctx, matched.storeBlock)
+		if err != nil {
+			return fmt.Errorf("block hook failed: %w", err)
+		}
+	}
+
+	block := matched.storeBlock
+	kept := make(map[*store.TxRecord]struct{}, len(matched.records))
+	for txs := range maps.Values(block.AddrToTxs) {
+		for tx := range slices.Values(txs) {
+			kept[tx] = struct{}{}
+		}
+	}
+	matched.records = slices.DeleteFunc(matched.records, func(record *store.TxRecord) bool {
+		_, ok := kept[record]
+		if !ok {
+			i.metrics.vetoedTransactions.Inc()
+		}
+		return !ok
+	})
+	matched.stats = matchStats{
+		txs:            len(kept),
+		tokenTransfers: countUnique(block.AddrToTokenTransfers),
+		events:         countUnique(block.ContractToEvents),
+	}
+	return nil
+}
+
+// countUnique returns the number of unique records of the lists, records being shared between lists.
+func countUnique[T comparable](lists map[string][]T) int {
+	unique := make(map[T]struct{})
+	for list := range maps.Values(lists) {
+		for record := range slices.Values(list) {
+			unique[record] = struct{}{}
+		}
+	}
+	return len(unique)
+}
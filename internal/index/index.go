@@ -4,48 +4,277 @@ import (
 	"context"
 	"fmt"
 	"slices"
-	"strings"
-
-	"github.com/sirupsen/logrus"
+	"sync"
+	"sync/atomic"
+	"time"
 
+	"github.com/hedisam/ethtxparser/internal/bloom"
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
 	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/logging"
 	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/tracing"
 	"github.com/hedisam/pipeline/chans"
 )
 
 type SubscriptionStore interface {
-	IsSubscribed(ctx context.Context, addr string) (bool, error)
+	GetSubscriptionsBatch(ctx context.Context, addrs []string) (map[string]*store.Subscription, error)
+	GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error)
+}
+
+// InputDecoder decodes the input of transactions sent to the given contract, returning nil if it can't be decoded.
+type InputDecoder interface {
+	DecodeInput(contract, input string) (*store.DecodedInput, error)
+}
+
+// ErrorReporter reports errors to an external service, along with context such as the block they occurred for.
+type ErrorReporter interface {
+	ReportError(err error, fields map[string]any)
 }
 
 type TxStore interface {
 	InsertBlock(ctx context.Context, block *store.Block) error
+	InsertDeadLetter(ctx context.Context, deadLetter *store.DeadLetter) error
+	RemoveBlock(ctx context.Context, block *store.Block) error
 }
 
 type Index struct {
-	logger            *logrus.Logger
+	logger            logging.Logger
 	txStore           TxStore
 	subscriptionStore SubscriptionStore
+	cfg               *config
+	metrics           *metrics
+	notifierQueues    []*notifierQueue
+	retries           chan *failedBlock
+	// subscriptionFilter is nil unless enabled and built. It's replaced rather than updated under filterMu, being read
+	// concurrently.
+	subscriptionFilter atomic.Pointer[bloom.Filter]
+	filterMu           sync.Mutex
+	// recent is nil unless orphaned block removal is enabled.
+	recent *recentBlocks
+	pause  pauseGate
+	// addressMetrics is nil unless enabled.
+	addressMetrics *addressMetrics
+	// health is nil unless health reporting is enabled.
+	health *health.Component
+	// handlingSince is the unix time in nanoseconds the indexing loop started handling its current block at, zero
+	// while it's idle.
+	handlingSince atomic.Int64
+	// dryRun sums up what would have been indexed in dry run mode.
+	dryRun dryRunSummary
 }
 
-func New(logger *logrus.Logger, txStore TxStore, subscriptionStore SubscriptionStore) *Index {
-	return &Index{
+func New(logger logging.Logger, txStore TxStore, subscriptionStore SubscriptionStore, opts ...Option) *Index {
+	cfg := &config{
+		workers:            DefaultWorkers,
+		notificationBuffer: DefaultNotificationBuffer,
+		digestInterval:     DefaultDigestInterval,
+		outboxMaxAttempts:  DefaultOutboxMaxAttempts,
+		retryAttempts:      DefaultRetryAttempts,
+		retryQueueSize:     DefaultRetryQueueSize,
+		filterFPRate:       DefaultSubscriptionFilterFPRate,
+		registerer:         custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	m := newMetrics(cfg.registerer)
+	i := &Index{
 		logger:            logger,
 		txStore:           txStore,
 		subscriptionStore: subscriptionStore,
+		cfg:               cfg,
+		metrics:           m,
+		notifierQueues:    newNotifierQueues(cfg.notifiers, cfg.notificationBuffer, cfg.health, m),
+		retries:           make(chan *failedBlock, cfg.retryQueueSize),
+		health:            cfg.health.Component(health.Indexer),
+	}
+	if subscriptionStore != nil {
+		for q := range slices.Values(i.notifierQueues) {
+			q.route = i.route
+		}
+	}
+	if cfg.reorgWindow > 0 {
+		i.recent = newRecentBlocks(cfg.reorgWindow)
+	}
+	if cfg.addressMetrics > 0 {
+		i.addressMetrics = newAddressMetrics(cfg.addressMetrics, m)
+	}
+	return i
+}
+
+// Start indexes the confirmed blocks of the given event stream, and removes the records of the orphaned ones, until
+// the context is cancelled.
+func (i *Index) Start(ctx context.Context, in <-chan *eth.BlockEvent) {
+	if i.cfg.dryRun {
+		defer i.logDryRunSummary()
+	}
+	for q := range slices.Values(i.notifierQueues) {
+		if i.cfg.outbox != nil {
+			go q.drainOutbox(ctx, i.logger, i.cfg.outbox, i.cfg.outboxMaxAttempts)
+			continue
+		}
+		go q.dispatch(ctx, i.logger)
+	}
+	go i.retryFailedBlocks(ctx)
+	if i.cfg.subsWatcher != nil {
+		i.startSubscriptionFilter(ctx)
 	}
+
+	if i.cfg.blockFetcher != nil {
+		in = i.fillGaps(ctx, in)
+	}
+
+	if i.cfg.workers > 1 {
+		i.startConcurrent(ctx, in)
+		return
+	}
+
+	for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+		if event == nil {
+			continue
+		}
+		if !i.waitResumed(ctx) {
+			return
+		}
+		done := i.handling()
+		if event.Type == eth.BlockOrphaned {
+			i.orphan(ctx, event.Block)
+			done()
+			continue
+		}
+		err := i.index(ctx, event.Block)
+		if err != nil {
+			i.handleFailure(ctx, event.Block, err)
+		}
+		done()
+	}
+}
+
+// matchStats holds the number of items matched in a single block.
+type matchStats struct {
+	txs            int
+	tokenTransfers int
+	events         int
+}
+
+// matchedBlock holds the outcome of matching a single block against the subscriptions.
+type matchedBlock struct {
+	storeBlock *store.Block
+	// records holds the unique transaction records matched for subscribed addresses, to be notified, in the order
+	// they appear in the block.
+	records []*store.TxRecord
+	// priority holds the records matched for high priority subscriptions, notified as soon as they're matched.
+	priority map[*store.TxRecord]struct{}
+	stats    matchStats
+	// traceID is the trace ID of the block, see eth.Block.TraceID.
+	traceID string
+}
+
+// matchResult is a matched block, or the error that occurred while matching it, sent from the workers to the
+// committer. Orphaned blocks aren't matched, they're passed through to the committer as is.
+type matchResult struct {
+	block    *eth.Block
+	matched  *matchedBlock
+	err      error
+	orphaned bool
 }
 
-func (i *Index) Start(ctx context.Context, in <-chan *eth.Block) {
-	for block := range chans.ReceiveOrDoneSeq(ctx, in) {
-		err := i.index(ctx, block)
+// startConcurrent matches blocks using a pool of workers while committing them to the store strictly in the order
+// they're received, so that the current block number never goes backwards.
+func (i *Index) startConcurrent(ctx context.Context, in <-chan *eth.BlockEvent) {
+	type job struct {
+		block  *eth.Block
+		result chan<- *matchResult
+	}
+
+	jobs := make(chan job)
+	// pending holds the result channels in the order the blocks were received; its capacity bounds how far ahead
+	// the workers can get of the committer.
+	pending := make(chan (<-chan *matchResult), i.cfg.workers)
+
+	var wg sync.WaitGroup
+	for range i.cfg.workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := range jobs {
+				matched, err := i.match(ctx, j.block)
+				if err == nil {
+					// high priority records needn't wait for the blocks ahead to be committed
+					i.notifyPriority(matched)
+				}
+				j.result <- &matchResult{
+					block:   j.block,
+					matched: matched,
+					err:     err,
+				}
+			}
+		}()
+	}
+
+	go func() {
+		defer close(pending)
+		defer close(jobs)
+		for event := range chans.ReceiveOrDoneSeq(ctx, in) {
+			if event == nil || event.Block == nil {
+				continue
+			}
+			if !i.waitResumed(ctx) {
+				return
+			}
+			result := make(chan *matchResult, 1)
+			if !chans.SendOrDone(ctx, pending, result) {
+				return
+			}
+			i.metrics.pendingBlocks.Inc()
+			if event.Type == eth.BlockOrphaned {
+				result <- &matchResult{block: event.Block, orphaned: true}
+				continue
+			}
+			if !chans.SendOrDone(ctx, jobs, job{block: event.Block, result: result}) {
+				return
+			}
+		}
+	}()
+
+	for result := range pending {
+		// the block is handled from the moment it's pending, its matching included
+		done := i.handling()
+		res, ok := chans.ReceiveOrDone(ctx, result)
+		if !ok {
+			done()
+			break
+		}
+		if res.orphaned {
+			i.orphan(ctx, res.block)
+			i.metrics.pendingBlocks.Dec()
+			done()
+			continue
+		}
+		err := res.err
+		if err == nil {
+			err = i.commit(ctx, res.matched)
+		}
 		if err != nil {
-			i.logger.WithFields(logrus.Fields{
-				"block_hash":   block.Hash,
-				"block_number": block.Number,
-			}).WithError(err).Error("Failed to index block")
-			blocksFailedProcessing.Inc()
+			i.handleFailure(ctx, res.block, err)
 		}
+		i.metrics.pendingBlocks.Dec()
+		done()
 	}
+
+	wg.Wait()
+}
+
+func (i *Index) logFailure(block *eth.Block, err error) {
+	i.logger.WithFields(logging.Fields{
+		"block_hash":   block.Hash,
+		"block_number": block.Number,
+	}).WithError(err).Error("Failed to index block")
+	i.metrics.blocksFailedProcessing.Inc()
+	i.health.Report(err)
 }
 
 func (i *Index) index(ctx context.Context, block *eth.Block) error {
@@ -53,62 +282,310 @@ func (i *Index) index(ctx context.Context, block *eth.Block) error {
 		return nil
 	}
 
-	logger := i.logger.WithContext(ctx).WithFields(logrus.Fields{
-		"block_number": block.Number,
-		"total_txs":    len(block.Txs),
-	})
+	matched, err := i.match(ctx, block)
+	if err != nil {
+		return err
+	}
+	i.notifyPriority(matched)
+
+	return i.commit(ctx, matched)
+}
+
+// match builds the store block holding the block's transactions and token transfers matched against the subscribed
+// addresses.
+func (i *Index) match(ctx context.Context, block *eth.Block) (*matchedBlock, error) {
+	start := time.Now()
+	defer func() {
+		tracing.Observe(i.metrics.blockMatchDuration, time.Since(start).Seconds(), block.TraceID)
+	}()
+	statuses := txStatuses(block)
+	subs, err := i.blockSubscriptions(ctx, block, statuses)
+	if err != nil {
+		return nil, fmt.Errorf("could not get subscriptions of block addresses: %w", err)
+	}
 
 	addrToTxs := make(map[string][]*store.TxRecord, len(block.Txs))
-	var totalIndexedTxs int
+	var records []*store.TxRecord
+	priority := make(map[*store.TxRecord]struct{})
+	var recordedTxs int
 	for tx := range slices.Values(block.Txs) {
-		subscribedAddresses, err := i.subscribedAddresses(ctx, tx)
+		status := statuses[tx.Hash]
+		if i.skipped(status) {
+			continue
+		}
+		if !i.cfg.indexAll && !subscribed(subs, tx) {
+			continue
+		}
+
+		// a single immutable record is shared between every subscribed address the tx is matched for
+		record := &store.TxRecord{
+			Hash:           tx.Hash,
+			From:           tx.From,
+			To:             tx.To,
+			Value:          tx.Value,
+			BlockNumber:    block.Number,
+			BlockHash:      block.Hash,
+			Raw:            tx.Raw,
+			BlockTimestamp: block.Timestamp,
+			Status:         status,
+			Type:           store.TxTypeOf(tx.Type),
+		}
+		if i.cfg.inputDecoder != nil {
+			record.DecodedInput = i.decodeInput(tx)
+		}
+		subscribedAddresses := i.subscribedAddresses(subs, record)
+		recordedAddresses := subscribedAddresses
+		if i.cfg.indexAll {
+			// subscriptions only control notifications, every tx is recorded under its addresses
+			recordedAddresses = txAddresses(tx)
+		}
+		if len(recordedAddresses) == 0 {
+			continue
+		}
+
+		keep, err := i.hookTx(ctx, record)
 		if err != nil {
-			return fmt.Errorf("could not check for subscribed addresses for tx %q: %w", tx.Hash, err)
-		}
-		for addr := range slices.Values(subscribedAddresses) {
-			addrToTxs[addr] = append(addrToTxs[addr], &store.TxRecord{
-				Hash:        tx.Hash,
-				From:        tx.From,
-				To:          tx.To,
-				BlockNumber: block.Number,
-				BlockHash:   block.Hash,
-				Raw:         tx.Raw,
-			})
+			return nil, err
 		}
+		if !keep {
+			continue
+		}
+		for addr := range slices.Values(recordedAddresses) {
+			addrToTxs[addr] = append(addrToTxs[addr], record)
+		}
+		recordedTxs++
 		if len(subscribedAddresses) > 0 {
-			totalIndexedTxs++
+			records = append(records, record)
+		}
+		if prioritized(subs, subscribedAddresses) {
+			priority[record] = struct{}{}
 		}
 	}
 
-	err := i.txStore.InsertBlock(ctx, &store.Block{
-		Number:     block.Number,
-		Hash:       block.Hash,
-		ParentHash: block.ParentHash,
-		AddrToTxs:  addrToTxs,
-	})
+	matched := &matchedBlock{
+		storeBlock: &store.Block{
+			Number:     block.Number,
+			Hash:       block.Hash,
+			ParentHash: block.ParentHash,
+			AddrToTxs:  addrToTxs,
+		},
+		records:  records,
+		priority: priority,
+		traceID:  block.TraceID,
+		stats:    matchStats{txs: recordedTxs},
+	}
+
+	if i.cfg.tokenTransfers {
+		matched.storeBlock.AddrToTokenTransfers, matched.stats.tokenTransfers = matchTokenTransfers(subs, block)
+		matched.storeBlock.TokenBalanceChanges = tokenBalanceChanges(subs, block)
+	}
+
+	if i.cfg.events {
+		matched.storeBlock.ContractToEvents, matched.stats.events, err = i.matchEvents(ctx, block)
+		if err != nil {
+			return nil, fmt.Errorf("could not match contract events: %w", err)
+		}
+	}
+
+	if len(i.cfg.blockHooks) > 0 {
+		err = i.hookBlock(ctx, matched)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return matched, nil
+}
+
+// commit inserts the matched block into the store and queues it along with its recorded transactions for
+// notification, either in memory or in the store outbox within the same insert.
+func (i *Index) commit(ctx context.Context, matched *matchedBlock) error {
+	if i.cfg.dryRun {
+		i.reportDryRun(ctx, matched)
+		return nil
+	}
+
+	block, stats := matched.storeBlock, matched.stats
+	if i.recent != nil {
+		err := i.removeOrphans(ctx, block)
+		if err != nil {
+			return fmt.Errorf("could not remove orphaned blocks: %w", err)
+		}
+	}
+	if i.cfg.outbox != nil {
+		block.Outbox = i.outboxEntries(matched)
+	}
+	start := time.Now()
+	err := i.txStore.InsertBlock(ctx, block)
+	tracing.Observe(i.metrics.blockStoreInsertDuration, time.Since(start).Seconds(), matched.traceID)
 	if err != nil {
 		return fmt.Errorf("could not insert block into store: %w", err)
 	}
+	if i.recent != nil {
+		i.recent.add(matched)
+	}
 
-	processedBlocks.Inc()
-	indexedTransactions.Add(float64(totalIndexedTxs))
+	if i.cfg.outbox != nil {
+		i.wakeOutboxDrainers()
+	} else {
+		i.enqueueNotifications(matched)
+	}
 
-	logger.WithField("indexed_txs", totalIndexedTxs).Debug("Successfully processed block")
+	i.metrics.processedBlocks.Inc()
+	i.health.Report(nil)
+	i.metrics.indexedTransactions.Add(float64(stats.txs))
+	i.metrics.indexedTokenTransfers.Add(float64(stats.tokenTransfers))
+	i.metrics.indexedEvents.Add(float64(stats.events))
+	if i.addressMetrics != nil {
+		i.addressMetrics.record(block)
+	}
+
+	i.logger.WithContext(ctx).WithFields(logging.Fields{
+		"block_number":            block.Number,
+		"indexed_txs":             stats.txs,
+		"indexed_token_transfers": stats.tokenTransfers,
+		"indexed_events":          stats.events,
+	}).Debug("Successfully processed block")
 
 	return nil
 }
 
-func (i *Index) subscribedAddresses(ctx context.Context, tx *eth.Tx) ([]string, error) {
-	var subscribedAddresses []string
-	for addr := range slices.Values([]string{tx.To, tx.From}) {
-		ok, err := i.subscriptionStore.IsSubscribed(ctx, addr)
-		if err != nil {
-			return nil, fmt.Errorf("could not check subscription existence for tx addr %q: %w", addr, err)
+// decodeInput decodes the input of the given tx, if it calls a contract. Failures are logged rather than failing the
+// block, the tx is recorded without its decoded input.
+func (i *Index) decodeInput(tx *eth.Tx) *store.DecodedInput {
+	if tx.To == "" || len(tx.Input) <= len("0x") {
+		return nil
+	}
+
+	decoded, err := i.cfg.inputDecoder.DecodeInput(tx.To, tx.Input)
+	if err != nil {
+		i.logger.WithField("tx_hash", tx.Hash).WithError(err).Warn("Failed to decode transaction input")
+		i.metrics.inputDecodings.WithLabelValues("failure").Inc()
+		return nil
+	}
+	if decoded != nil {
+		i.metrics.inputDecodings.WithLabelValues("success").Inc()
+	}
+	return decoded
+}
+
+// subscribed reports whether the sender or recipient of the tx is subscribed to.
+func subscribed(subs map[string]*store.Subscription, tx *eth.Tx) bool {
+	_, to := subs[tx.To]
+	_, from := subs[tx.From]
+	return to || from
+}
+
+// subscribedAddresses returns the unique addresses of the tx that are subscribed to and whose filters the tx passes,
+// so that a self-transfer is recorded only once under its address.
+func (i *Index) subscribedAddresses(subs map[string]*store.Subscription, tx *store.TxRecord) []string {
+	subscribedAddresses := make([]string, 0, 2)
+	for addr := range slices.Values(uniqueAddresses(tx.To, tx.From)) {
+		sub, ok := subs[addr]
+		if !ok {
+			continue
+		}
+		if !sub.Accepts(tx) {
+			i.metrics.filteredTransactions.Inc()
+			continue
+		}
+		subscribedAddresses = append(subscribedAddresses, addr)
+	}
+
+	return subscribedAddresses
+}
+
+// prioritized reports whether any of the given subscribed addresses has a high priority subscription.
+func prioritized(subs map[string]*store.Subscription, addrs []string) bool {
+	return slices.ContainsFunc(addrs, func(addr string) bool {
+		return subs[addr].Priority
+	})
+}
+
+// txAddresses returns the unique non-empty addresses of the tx, contract creations have no recipient.
+func txAddresses(tx *eth.Tx) []string {
+	return slices.DeleteFunc(uniqueAddresses(tx.To, tx.From), func(addr string) bool {
+		return addr == ""
+	})
+}
+
+// txStatuses returns the status of the block's transactions by hash, empty if the block receipts weren't fetched.
+func txStatuses(block *eth.Block) map[string]store.TxStatus {
+	statuses := make(map[string]store.TxStatus, len(block.Receipts))
+	for receipt := range slices.Values(block.Receipts) {
+		switch receipt.Status {
+		case eth.ReceiptStatusSuccess:
+			statuses[receipt.TxHash] = store.TxStatusSuccess
+		case eth.ReceiptStatusFailed:
+			statuses[receipt.TxHash] = store.TxStatusFailed
+		}
+	}
+	return statuses
+}
+
+// skipped reports whether transactions with the given status are excluded from indexing altogether.
+func (i *Index) skipped(status store.TxStatus) bool {
+	return i.cfg.skipFailed && status == store.TxStatusFailed
+}
+
+// uniqueAddresses returns the given to and from addresses, or only one of them if they're the same.
+func uniqueAddresses(to, from string) []string {
+	if to == from {
+		return []string{to}
+	}
+	return []string{to, from}
+}
+
+// blockSubscriptions returns the subscriptions of the addresses sending or receiving the transactions and, if
+// enabled, the token transfers of the block, looked up with a single batch call. Addresses the subscription filter
+// rules out, and those of skipped failed transactions, aren't looked up.
+func (i *Index) blockSubscriptions(ctx context.Context, block *eth.Block, statuses map[string]store.TxStatus) (map[string]*store.Subscription, error) {
+	seen := make(map[string]struct{}, 2*len(block.Txs))
+	var addrs []string
+	add := func(addr string) {
+		if _, ok := seen[addr]; ok {
+			return
 		}
-		if ok {
-			subscribedAddresses = append(subscribedAddresses, strings.ToLower(addr))
+		seen[addr] = struct{}{}
+		if !i.maySubscribe(addr) {
+			i.metrics.filteredLookups.Inc()
+			return
 		}
+		addrs = append(addrs, addr)
+	}
+
+	for tx := range slices.Values(block.Txs) {
+		if i.skipped(statuses[tx.Hash]) {
+			i.metrics.skippedFailedTransactions.Inc()
+			continue
+		}
+		add(tx.To)
+		add(tx.From)
+	}
+	if i.cfg.tokenTransfers {
+		for receipt := range slices.Values(block.Receipts) {
+			for log := range slices.Values(receipt.Logs) {
+				record, ok := parseTokenTransfer(log)
+				if ok {
+					add(record.From)
+					add(record.To)
+					// the token is looked up too, in case its holders are tracked
+					add(record.Token)
+				}
+			}
+		}
+	}
+	if len(addrs) == 0 {
+		return nil, nil
+	}
+
+	subs, err := i.subscriptionStore.GetSubscriptionsBatch(ctx, addrs)
+	if err != nil {
+		return nil, err
+	}
+	if i.subscriptionFilter.Load() != nil {
+		i.metrics.subscriptionFilterFalsePositives.Add(float64(len(addrs) - len(subs)))
 	}
 
-	return subscribedAddresses, nil
+	return subs, nil
 }
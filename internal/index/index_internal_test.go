@@ -3,30 +3,49 @@ package index
 import (
 	"context"
 	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
 	"slices"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/sirupsen/logrus"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 
 	"github.com/hedisam/ethtxparser/internal/eth"
 	"github.com/hedisam/ethtxparser/internal/index/mocks"
+	"github.com/hedisam/ethtxparser/internal/logging"
 	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
 )
 
 //go:generate moq -out mocks/tx_store.go -pkg mocks -skip-ensure . TxStore
 //go:generate moq -out mocks/subscriptions_store.go -pkg mocks -skip-ensure . SubscriptionStore
+//go:generate moq -out mocks/notifier.go -pkg mocks -skip-ensure . Notifier
+//go:generate moq -out mocks/block_fetcher.go -pkg mocks -skip-ensure . BlockFetcher
+//go:generate moq -out mocks/input_decoder.go -pkg mocks -skip-ensure . InputDecoder
+//go:generate moq -out mocks/tx_hook.go -pkg mocks -skip-ensure . TxHook
+//go:generate moq -out mocks/block_hook.go -pkg mocks -skip-ensure . BlockHook
+//go:generate moq -out mocks/error_reporter.go -pkg mocks -skip-ensure . ErrorReporter
 
 func TestIndex(t *testing.T) {
 	tests := map[string]struct {
-		block                          *eth.Block
-		subscribedAddresses            []string
-		storeInsertErr                 error
-		expectedStoreIsSubscribedCalls int
-		expectedStoreInsertCalls       int
-		expectedIndexedBlock           *store.Block
-		errContains                    string
+		block                    *eth.Block
+		opts                     []Option
+		subscribedAddresses      []string
+		minValues                map[string]*big.Int
+		filters                  map[string]string
+		eventSubscriptions       []*store.EventSubscription
+		storeInsertErr           error
+		expectedStoreBatchCalls  int
+		expectedStoreInsertCalls int
+		expectedIndexedBlock     *store.Block
+		errContains              string
 	}{
 		"block with subscribed addresses": {
 			block: &eth.Block{
@@ -60,9 +79,9 @@ func TestIndex(t *testing.T) {
 					},
 				},
 			},
-			subscribedAddresses:            []string{"addr-1", "addr-3"},
-			expectedStoreInsertCalls:       1,
-			expectedStoreIsSubscribedCalls: 8,
+			subscribedAddresses:      []string{"addr-1", "addr-3"},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
 			expectedIndexedBlock: &store.Block{
 				Number:     1,
 				Hash:       "hash-1",
@@ -76,6 +95,7 @@ func TestIndex(t *testing.T) {
 							BlockNumber: 1,
 							BlockHash:   "hash-1",
 							Raw:         []byte("raw-1"),
+							Type:        store.TxTypeLegacy,
 						},
 						{
 							Hash:        "tx-2",
@@ -84,6 +104,7 @@ func TestIndex(t *testing.T) {
 							BlockNumber: 1,
 							BlockHash:   "hash-1",
 							Raw:         []byte("raw-1"),
+							Type:        store.TxTypeLegacy,
 						},
 					},
 					"addr-3": {
@@ -94,6 +115,7 @@ func TestIndex(t *testing.T) {
 							BlockNumber: 1,
 							BlockHash:   "hash-1",
 							Raw:         []byte("raw-1"),
+							Type:        store.TxTypeLegacy,
 						},
 					},
 				},
@@ -106,9 +128,9 @@ func TestIndex(t *testing.T) {
 				ParentHash: "0x0",
 				Txs:        nil,
 			},
-			subscribedAddresses:            []string{"addr-1", "addr-3"},
-			expectedStoreInsertCalls:       1,
-			expectedStoreIsSubscribedCalls: 0,
+			subscribedAddresses:      []string{"addr-1", "addr-3"},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  0,
 			expectedIndexedBlock: &store.Block{
 				Number:     1,
 				Hash:       "hash-1",
@@ -116,6 +138,279 @@ func TestIndex(t *testing.T) {
 				AddrToTxs:  map[string][]*store.TxRecord{},
 			},
 		},
+		"self transfer is recorded once": {
+			block: &eth.Block{
+				Hash:       "hash-1",
+				Number:     1,
+				ParentHash: "0x0",
+				Txs: []*eth.Tx{
+					{
+						Hash: "tx-1",
+						From: "addr-1",
+						To:   "addr-1",
+						Raw:  []byte("raw-1"),
+					},
+				},
+			},
+			subscribedAddresses:      []string{"addr-1"},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
+			expectedIndexedBlock: &store.Block{
+				Number:     1,
+				Hash:       "hash-1",
+				ParentHash: "0x0",
+				AddrToTxs: map[string][]*store.TxRecord{
+					"addr-1": {
+						{
+							Hash:        "tx-1",
+							From:        "addr-1",
+							To:          "addr-1",
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+							Raw:         []byte("raw-1"),
+							Type:        store.TxTypeLegacy,
+						},
+					},
+				},
+			},
+		},
+		"subscription with min value filter": {
+			block: &eth.Block{
+				Hash:       "hash-1",
+				Number:     1,
+				ParentHash: "0x0",
+				Txs: []*eth.Tx{
+					{
+						Hash:  "tx-1",
+						From:  "addr-1",
+						To:    "addr-2",
+						Value: big.NewInt(500),
+					},
+					{
+						Hash:  "tx-2",
+						From:  "addr-1",
+						To:    "addr-2",
+						Value: big.NewInt(2000),
+					},
+					{
+						Hash: "tx-3",
+						From: "addr-1",
+						To:   "addr-2",
+					},
+				},
+			},
+			subscribedAddresses:      []string{"addr-1"},
+			minValues:                map[string]*big.Int{"addr-1": big.NewInt(1000)},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
+			expectedIndexedBlock: &store.Block{
+				Number:     1,
+				Hash:       "hash-1",
+				ParentHash: "0x0",
+				AddrToTxs: map[string][]*store.TxRecord{
+					"addr-1": {
+						{
+							Hash:        "tx-2",
+							From:        "addr-1",
+							To:          "addr-2",
+							Value:       big.NewInt(2000),
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+							Type:        store.TxTypeLegacy,
+						},
+					},
+				},
+			},
+		},
+		"block with filtered transactions": {
+			block: &eth.Block{
+				Hash:       "hash-1",
+				Number:     1,
+				ParentHash: "0x0",
+				Txs: []*eth.Tx{
+					{
+						Hash:  "tx-1",
+						From:  "addr-1",
+						To:    "addr-2",
+						Value: big.NewInt(2000),
+					},
+					{
+						Hash:  "tx-2",
+						From:  "addr-1",
+						To:    "addr-3",
+						Value: big.NewInt(2000),
+					},
+					{
+						Hash:  "tx-3",
+						From:  "addr-1",
+						To:    "addr-3",
+						Value: big.NewInt(10),
+					},
+				},
+			},
+			subscribedAddresses:      []string{"addr-1"},
+			filters:                  map[string]string{"addr-1": `value > 1e3 && to != "ADDR-2"`},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
+			expectedIndexedBlock: &store.Block{
+				Number:     1,
+				Hash:       "hash-1",
+				ParentHash: "0x0",
+				AddrToTxs: map[string][]*store.TxRecord{
+					"addr-1": {
+						{
+							Hash:        "tx-2",
+							From:        "addr-1",
+							To:          "addr-3",
+							Value:       big.NewInt(2000),
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+							Type:        store.TxTypeLegacy,
+						},
+					},
+				},
+			},
+		},
+		"block with token transfers": {
+			opts: []Option{WithTokenTransfers()},
+			block: &eth.Block{
+				Hash:       "hash-1",
+				Number:     1,
+				ParentHash: "0x0",
+				Receipts: []*eth.Receipt{
+					{
+						TxHash: "tx-1",
+						Status: 1,
+						Logs: []*eth.Log{
+							{
+								// erc-20 transfer of 1000 units from addr-1
+								Address:  "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+								Topics:   []string{transferEventTopic, "0x0000000000000000000000001111111111111111111111111111111111111111", "0x0000000000000000000000002222222222222222222222222222222222222222"},
+								Data:     "0x00000000000000000000000000000000000000000000000000000000000003e8",
+								LogIndex: 0,
+								TxHash:   "tx-1",
+							},
+							{
+								// erc-721 transfer of token id 42 to addr-3
+								Address:  "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+								Topics:   []string{transferEventTopic, "0x0000000000000000000000002222222222222222222222222222222222222222", "0x0000000000000000000000003333333333333333333333333333333333333333", "0x000000000000000000000000000000000000000000000000000000000000002a"},
+								LogIndex: 1,
+								TxHash:   "tx-1",
+							},
+							{
+								// not a transfer event
+								Address:  "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+								Topics:   []string{"0x8c5be1e5ebec7d5bd14f71427d1e84f3dd0314c0f7b2291e5b200ac8c7c3b925"},
+								LogIndex: 2,
+								TxHash:   "tx-1",
+							},
+						},
+					},
+				},
+			},
+			subscribedAddresses:      []string{"0x1111111111111111111111111111111111111111", "0x3333333333333333333333333333333333333333"},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
+			expectedIndexedBlock: &store.Block{
+				Number:     1,
+				Hash:       "hash-1",
+				ParentHash: "0x0",
+				AddrToTxs:  map[string][]*store.TxRecord{},
+				AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{
+					"0x1111111111111111111111111111111111111111": {
+						{
+							TxHash:      "tx-1",
+							LogIndex:    0,
+							Token:       "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48",
+							Standard:    store.TokenStandardERC20,
+							From:        "0x1111111111111111111111111111111111111111",
+							To:          "0x2222222222222222222222222222222222222222",
+							Amount:      "1000",
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+						},
+					},
+					"0x3333333333333333333333333333333333333333": {
+						{
+							TxHash:      "tx-1",
+							LogIndex:    1,
+							Token:       "0xbc4ca0eda7647a8ab7c2061c2e118a18a936f13d",
+							Standard:    store.TokenStandardERC721,
+							From:        "0x2222222222222222222222222222222222222222",
+							To:          "0x3333333333333333333333333333333333333333",
+							TokenID:     "42",
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+						},
+					},
+				},
+			},
+		},
+		"block with subscribed contract events": {
+			opts: []Option{WithEvents()},
+			block: &eth.Block{
+				Hash:       "hash-1",
+				Number:     1,
+				ParentHash: "0x0",
+				Receipts: []*eth.Receipt{
+					{
+						TxHash: "tx-1",
+						Logs: []*eth.Log{
+							{
+								Address:  "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+								Topics:   []string{"0xtopic-a", "0xtopic-b"},
+								Data:     "0x00000000000000000000000000000000000000000000000000000000000000010000000000000000000000000000000000000000000000000000000000000002",
+								LogIndex: 0,
+								TxHash:   "tx-1",
+							},
+							{
+								// same contract, but a topic not subscribed to
+								Address:  "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+								Topics:   []string{"0xtopic-c"},
+								LogIndex: 1,
+								TxHash:   "tx-1",
+							},
+							{
+								// contract not subscribed to
+								Address:  "0xbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb",
+								Topics:   []string{"0xtopic-a"},
+								LogIndex: 2,
+								TxHash:   "tx-1",
+							},
+						},
+					},
+				},
+			},
+			eventSubscriptions: []*store.EventSubscription{
+				{
+					Contract: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+					Topics:   []string{"0xtopic-a", ""},
+				},
+			},
+			expectedStoreInsertCalls: 1,
+			expectedIndexedBlock: &store.Block{
+				Number:     1,
+				Hash:       "hash-1",
+				ParentHash: "0x0",
+				AddrToTxs:  map[string][]*store.TxRecord{},
+				ContractToEvents: map[string][]*store.EventRecord{
+					"0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa": {
+						{
+							Contract: "0xaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+							TxHash:   "tx-1",
+							LogIndex: 0,
+							Topics:   []string{"0xtopic-a", "0xtopic-b"},
+							DataWords: []string{
+								"0x0000000000000000000000000000000000000000000000000000000000000001",
+								"0x0000000000000000000000000000000000000000000000000000000000000002",
+							},
+							BlockNumber: 1,
+							BlockHash:   "hash-1",
+						},
+					},
+				},
+			},
+		},
 		"store error": {
 			block: &eth.Block{
 				Hash:       "hash-1",
@@ -130,11 +425,11 @@ func TestIndex(t *testing.T) {
 					},
 				},
 			},
-			subscribedAddresses:            []string{"addr-1", "addr-3"},
-			expectedStoreInsertCalls:       1,
-			expectedStoreIsSubscribedCalls: 2,
-			storeInsertErr:                 errors.New("internal error"),
-			errContains:                    "internal error",
+			subscribedAddresses:      []string{"addr-1", "addr-3"},
+			expectedStoreInsertCalls: 1,
+			expectedStoreBatchCalls:  1,
+			storeInsertErr:           errors.New("internal error"),
+			errContains:              "internal error",
 		},
 	}
 
@@ -145,19 +440,34 @@ func TestIndex(t *testing.T) {
 					for addr := range block.AddrToTxs {
 						assert.Contains(t, test.subscribedAddresses, addr)
 					}
+					for addr := range block.AddrToTokenTransfers {
+						assert.Contains(t, test.subscribedAddresses, addr)
+					}
 					return test.storeInsertErr
 				},
 			}
 			subsStoreMock := &mocks.SubscriptionStoreMock{
-				IsSubscribedFunc: func(ctx context.Context, addr string) (bool, error) {
-					return slices.Contains(test.subscribedAddresses, addr), nil
+				GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if !slices.Contains(test.subscribedAddresses, addr) {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr, MinValue: test.minValues[addr], Filter: test.filters[addr]}, nil
+				}),
+				GetEventSubscriptionsFunc: func(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+					var subs []*store.EventSubscription
+					for sub := range slices.Values(test.eventSubscriptions) {
+						if sub.Contract == contract {
+							subs = append(subs, sub)
+						}
+					}
+					return subs, nil
 				},
 			}
 
-			idx := New(logrus.New(), txStoreMock, subsStoreMock)
+			idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, test.opts...)
 			err := idx.index(context.Background(), test.block)
 			assert.Equal(t, test.expectedStoreInsertCalls, len(txStoreMock.InsertBlockCalls()))
-			assert.Equal(t, test.expectedStoreIsSubscribedCalls, len(subsStoreMock.IsSubscribedCalls()))
+			assert.Equal(t, test.expectedStoreBatchCalls, len(subsStoreMock.GetSubscriptionsBatchCalls()))
 			if test.errContains != "" {
 				require.Error(t, err)
 				assert.ErrorContains(t, err, test.errContains)
@@ -168,3 +478,1333 @@ func TestIndex(t *testing.T) {
 		})
 	}
 }
+
+func TestIndexSharesTxRecord(t *testing.T) {
+	block := &eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{
+				Hash: "tx-1",
+				From: "addr-1",
+				To:   "addr-2",
+				Raw:  []byte("raw-1"),
+			},
+		},
+	}
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+	err := idx.index(context.Background(), block)
+	require.NoError(t, err)
+	require.Equal(t, 1, len(txStoreMock.InsertBlockCalls()))
+
+	addrToTxs := txStoreMock.InsertBlockCalls()[0].Block.AddrToTxs
+	require.Len(t, addrToTxs["addr-1"], 1)
+	require.Len(t, addrToTxs["addr-2"], 1)
+	assert.Same(t, addrToTxs["addr-1"][0], addrToTxs["addr-2"][0])
+}
+
+func TestIndexTxStatus(t *testing.T) {
+	tests := map[string]struct {
+		opts            []Option
+		skipFailed      bool
+		expectedRecords []*store.TxRecord
+	}{
+		"statuses are recorded": {
+			expectedRecords: []*store.TxRecord{
+				{Hash: "tx-1", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Status: store.TxStatusSuccess, Type: store.TxTypeLegacy},
+				{Hash: "tx-2", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Status: store.TxStatusFailed, Type: store.TxTypeLegacy},
+				{Hash: "tx-3", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Type: store.TxTypeLegacy},
+			},
+		},
+		"failed txs are skipped for every subscription": {
+			opts: []Option{WithSkipFailed()},
+			expectedRecords: []*store.TxRecord{
+				{Hash: "tx-1", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Status: store.TxStatusSuccess, Type: store.TxTypeLegacy},
+				{Hash: "tx-3", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Type: store.TxTypeLegacy},
+			},
+		},
+		"failed txs are skipped for the subscription": {
+			skipFailed: true,
+			expectedRecords: []*store.TxRecord{
+				{Hash: "tx-1", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Status: store.TxStatusSuccess, Type: store.TxTypeLegacy},
+				{Hash: "tx-3", From: "addr-1", To: "addr-2", BlockNumber: 1, BlockHash: "hash-1", Type: store.TxTypeLegacy},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			// tx-3 has no receipt, e.g. pre-byzantium, so its status isn't known
+			block := &eth.Block{
+				Hash:   "hash-1",
+				Number: 1,
+				Txs: []*eth.Tx{
+					{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+					{Hash: "tx-2", From: "addr-1", To: "addr-2"},
+					{Hash: "tx-3", From: "addr-1", To: "addr-2"},
+				},
+				Receipts: []*eth.Receipt{
+					{TxHash: "tx-1", Status: eth.ReceiptStatusSuccess},
+					{TxHash: "tx-2", Status: eth.ReceiptStatusFailed},
+					{TxHash: "tx-3", Status: eth.ReceiptStatusUnknown},
+				},
+			}
+			txStoreMock := &mocks.TxStoreMock{
+				InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+					return nil
+				},
+			}
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != "addr-1" {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr, SkipFailed: test.skipFailed}, nil
+				}),
+			}
+
+			idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, test.opts...)
+			err := idx.index(context.Background(), block)
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedRecords, txStoreMock.InsertBlockCalls()[0].Block.AddrToTxs["addr-1"])
+		})
+	}
+}
+
+func TestIndexAll(t *testing.T) {
+	block := &eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-2", From: "addr-3", To: "addr-4"},
+			// contract creation
+			{Hash: "tx-3", From: "addr-3"},
+		},
+	}
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, WithIndexAll(), WithNotifier("mock", &mocks.NotifierMock{}))
+	err := idx.index(context.Background(), block)
+	require.NoError(t, err)
+
+	recorded := make(map[string][]string)
+	for addr, txs := range txStoreMock.InsertBlockCalls()[0].Block.AddrToTxs {
+		for tx := range slices.Values(txs) {
+			recorded[addr] = append(recorded[addr], tx.Hash)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"addr-1": {"tx-1"},
+		"addr-2": {"tx-1"},
+		"addr-3": {"tx-2", "tx-3"},
+		"addr-4": {"tx-2"},
+	}, recorded)
+
+	// only the transactions of subscribed addresses are notified
+	assert.Equal(t, map[string]int{"mock": 1}, idx.NotificationBacklogs())
+	queue := idx.notifierQueues[0].notifications
+	require.Len(t, queue, 1)
+	assert.Equal(t, "tx-1", (<-queue).tx.Hash)
+}
+
+func TestIndexHooks(t *testing.T) {
+	block := &eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-2", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-3", From: "addr-1", To: "addr-2"},
+		},
+	}
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+	// the tx hook labels the records and vetoes tx-2, the block hook removes tx-3 from addr-2 only
+	txHook := &mocks.TxHookMock{
+		HookTxFunc: func(ctx context.Context, record *store.TxRecord) (bool, error) {
+			record.Labels = map[string]string{"tag": "checked"}
+			return record.Hash != "tx-2", nil
+		},
+	}
+	blockHook := &mocks.BlockHookMock{
+		HookBlockFunc: func(ctx context.Context, block *store.Block) error {
+			block.AddrToTxs["addr-2"] = slices.DeleteFunc(block.AddrToTxs["addr-2"], func(tx *store.TxRecord) bool {
+				return tx.Hash == "tx-3"
+			})
+			return nil
+		},
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock,
+		WithTxHook(txHook),
+		WithBlockHook(blockHook),
+		WithNotifier("mock", &mocks.NotifierMock{}),
+	)
+	err := idx.index(context.Background(), block)
+	require.NoError(t, err)
+	assert.Len(t, txHook.HookTxCalls(), 3)
+
+	recorded := make(map[string][]string)
+	for addr, txs := range txStoreMock.InsertBlockCalls()[0].Block.AddrToTxs {
+		for tx := range slices.Values(txs) {
+			assert.Equal(t, map[string]string{"tag": "checked"}, tx.Labels)
+			recorded[addr] = append(recorded[addr], tx.Hash)
+		}
+	}
+	assert.Equal(t, map[string][]string{
+		"addr-1": {"tx-1", "tx-3"},
+		"addr-2": {"tx-1"},
+	}, recorded)
+
+	queue := idx.notifierQueues[0].notifications
+	require.Len(t, queue, 2)
+	assert.Equal(t, "tx-1", (<-queue).tx.Hash)
+	assert.Equal(t, "tx-3", (<-queue).tx.Hash)
+
+	// a failing hook fails the block so it's retried
+	blockHook.HookBlockFunc = func(ctx context.Context, block *store.Block) error {
+		return errors.New("price feed is down")
+	}
+	err = idx.index(context.Background(), block)
+	assert.ErrorContains(t, err, "price feed is down")
+}
+
+func TestIndexDecodesInput(t *testing.T) {
+	transfer := &store.DecodedInput{
+		Method:    "transfer",
+		Signature: "transfer(address,uint256)",
+		Args: []*store.DecodedArg{
+			{Name: "to", Type: "address", Value: "addr-3"},
+			{Name: "amount", Type: "uint256", Value: "10"},
+		},
+	}
+	block := &eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "contract-1", Input: "0xa9059cbb"},
+			{Hash: "tx-2", From: "addr-1", To: "contract-2", Input: "0xdeadbeef"},
+			{Hash: "tx-3", From: "addr-1", To: "addr-2", Input: "0x"},
+		},
+	}
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+	decoderMock := &mocks.InputDecoderMock{
+		DecodeInputFunc: func(contract string, input string) (*store.DecodedInput, error) {
+			if contract == "contract-1" {
+				return transfer, nil
+			}
+			return nil, errors.New("could not decode arguments")
+		},
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, WithInputDecoder(decoderMock))
+	err := idx.index(context.Background(), block)
+	require.NoError(t, err)
+
+	// txs without input aren't decoded and decoding failures don't fail the block
+	require.Len(t, decoderMock.DecodeInputCalls(), 2)
+	records := txStoreMock.InsertBlockCalls()[0].Block.AddrToTxs["addr-1"]
+	require.Len(t, records, 3)
+	assert.Equal(t, transfer, records[0].DecodedInput)
+	assert.Nil(t, records[1].DecodedInput)
+	assert.Nil(t, records[2].DecodedInput)
+}
+
+func TestStartConcurrentCommitsInOrder(t *testing.T) {
+	const totalBlocks = 50
+
+	in := make(chan *eth.BlockEvent)
+	go func() {
+		defer close(in)
+		for n := range totalBlocks {
+			in <- confirmed(&eth.Block{
+				Hash:   fmt.Sprintf("hash-%d", n),
+				Number: int64(n),
+				Txs: []*eth.Tx{
+					{
+						Hash: fmt.Sprintf("tx-%d", n),
+						From: "addr-1",
+						To:   fmt.Sprintf("to-%d", n),
+					},
+				},
+			})
+		}
+	}()
+
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			// make workers finish out of order
+			time.Sleep(time.Duration(rand.IntN(1000)) * time.Microsecond)
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, WithWorkers(8))
+	idx.Start(context.Background(), in)
+
+	calls := txStoreMock.InsertBlockCalls()
+	require.Len(t, calls, totalBlocks)
+	for n, call := range calls {
+		assert.Equal(t, int64(n), call.Block.Number)
+		assert.Len(t, call.Block.AddrToTxs["addr-1"], 1)
+	}
+}
+
+func TestStartNotifiesRecordedTransactions(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 1)
+	in <- confirmed(&eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-2", From: "addr-3", To: "addr-4"},
+			{Hash: "tx-3", From: "addr-2", To: "addr-1"},
+		},
+	})
+	close(in)
+
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" && addr != "addr-2" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+	notified := make(chan *store.TxRecord, 3)
+	notifierMock := &mocks.NotifierMock{
+		NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+			notified <- tx
+			return nil
+		},
+	}
+	// a stuck notifier must not hold up the others
+	stuckNotifierMock := &mocks.NotifierMock{
+		NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+			<-ctx.Done()
+			return ctx.Err()
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock,
+		WithNotifier("stuck", stuckNotifierMock),
+		WithNotifier("mock", notifierMock),
+	)
+	idx.Start(ctx, in)
+
+	// transactions matched for multiple addresses are notified once, in block order
+	for _, hash := range []string{"tx-1", "tx-3"} {
+		select {
+		case tx := <-notified:
+			assert.Equal(t, hash, tx.Hash)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s to be notified", hash)
+		}
+	}
+	select {
+	case tx := <-notified:
+		t.Fatalf("unexpected notification for %s", tx.Hash)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestStartNotifiesPriorityTransactionsFirst(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 1)
+	in <- confirmed(&eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-3"},
+			{Hash: "tx-2", From: "addr-3", To: "addr-2"},
+			{Hash: "tx-3", From: "addr-1", To: "addr-3"},
+		},
+	})
+	close(in)
+
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" && addr != "addr-2" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr, Priority: addr == "addr-2"}, nil
+		}),
+	}
+	notified := make(chan *store.TxRecord, 3)
+	notifierMock := &mocks.NotifierMock{
+		NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+			notified <- tx
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, WithNotifier("mock", notifierMock))
+	idx.Start(ctx, in)
+
+	// the transaction of the high priority subscription is notified ahead of the block order
+	for _, hash := range []string{"tx-2", "tx-1", "tx-3"} {
+		select {
+		case tx := <-notified:
+			assert.Equal(t, hash, tx.Hash)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s to be notified", hash)
+		}
+	}
+	select {
+	case tx := <-notified:
+		t.Fatalf("unexpected notification for %s", tx.Hash)
+	case <-time.After(time.Millisecond * 50):
+	}
+}
+
+func TestStartDrainsOutbox(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 1)
+	in <- confirmed(&eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-2", From: "addr-3", To: "addr-1"},
+		},
+	})
+	close(in)
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr}, nil
+		}),
+	}
+	notified := make(chan string, 2)
+	var failed bool
+	notifierMock := &mocks.NotifierMock{
+		NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+			// the first delivery fails and must be retried before moving on to the next entry
+			if !failed {
+				failed = true
+				return errors.New("dummy error")
+			}
+			notified <- tx.Hash
+			return nil
+		},
+	}
+
+	txStore := memdb.NewTxStore()
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idx := New(logging.Logrus(logrus.New()), txStore, subsStoreMock, WithNotifier("mock", notifierMock), WithOutbox(txStore))
+	idx.Start(ctx, in)
+
+	for _, hash := range []string{"tx-1", "tx-2"} {
+		select {
+		case got := <-notified:
+			assert.Equal(t, hash, got)
+		case <-time.After(time.Second * 5):
+			t.Fatalf("timed out waiting for %s to be notified", hash)
+		}
+	}
+	assert.Eventually(t, func() bool {
+		entries, err := txStore.GetOutboxEntries(ctx, "mock", 10)
+		return err == nil && len(entries) == 0
+	}, time.Second, time.Millisecond*10)
+	assert.Len(t, notifierMock.NotifyCalls(), 3)
+}
+
+func TestStartRetriesFailedBlocks(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 2)
+	in <- confirmed(&eth.Block{Hash: "hash-1", Number: 1})
+	in <- confirmed(&eth.Block{Hash: "hash-2", Number: 2, ParentHash: "hash-1"})
+	close(in)
+
+	var mu sync.Mutex
+	inserts := make(map[int64]int)
+	deadLetters := make(chan *store.DeadLetter, 1)
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			mu.Lock()
+			defer mu.Unlock()
+			inserts[block.Number]++
+			// block 1 succeeds on its first retry while block 2 keeps failing
+			if block.Number == 1 && inserts[block.Number] > 1 {
+				return nil
+			}
+			return errors.New("dummy error")
+		},
+		InsertDeadLetterFunc: func(ctx context.Context, deadLetter *store.DeadLetter) error {
+			deadLetters <- deadLetter
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	reporterMock := &mocks.ErrorReporterMock{
+		ReportErrorFunc: func(err error, fields map[string]any) {},
+	}
+	idx := New(
+		logging.Logrus(logrus.New()),
+		txStoreMock,
+		&mocks.SubscriptionStoreMock{},
+		WithRetryAttempts(2),
+		WithErrorReporter(reporterMock),
+	)
+	idx.Start(ctx, in)
+
+	select {
+	case deadLetter := <-deadLetters:
+		assert.Equal(t, int64(2), deadLetter.BlockNumber)
+		assert.Equal(t, "hash-2", deadLetter.BlockHash)
+		assert.Equal(t, "hash-1", deadLetter.ParentHash)
+		assert.Equal(t, 2, deadLetter.Attempts)
+		assert.Contains(t, deadLetter.Error, "dummy error")
+	case <-time.After(time.Second * 5):
+		t.Fatal("timed out waiting for dead letter")
+	}
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, map[int64]int{1: 2, 2: 2}, inserts)
+	assert.Len(t, txStoreMock.InsertDeadLetterCalls(), 1)
+	require.Len(t, reporterMock.ReportErrorCalls(), 1)
+	assert.ErrorContains(t, reporterMock.ReportErrorCalls()[0].Err, "dummy error")
+	assert.Equal(t, map[string]any{
+		"block_hash":   "hash-2",
+		"block_number": int64(2),
+		"attempts":     2,
+	}, reporterMock.ReportErrorCalls()[0].Fields)
+}
+
+func TestStartFillsGaps(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 3)
+	in <- confirmed(&eth.Block{Hash: "hash-1", Number: 1})
+	in <- confirmed(&eth.Block{Hash: "hash-4", Number: 4})
+	in <- confirmed(&eth.Block{Hash: "hash-5", Number: 5})
+	close(in)
+
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			return nil
+		},
+		InsertDeadLetterFunc: func(ctx context.Context, deadLetter *store.DeadLetter) error {
+			return nil
+		},
+	}
+	fetcherMock := &mocks.BlockFetcherMock{
+		GetBlockFunc: func(ctx context.Context, number int64) (*eth.Block, error) {
+			if number == 3 {
+				return nil, errors.New("dummy error")
+			}
+			return &eth.Block{Hash: fmt.Sprintf("hash-%d", number), Number: number}, nil
+		},
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, &mocks.SubscriptionStoreMock{}, WithGapFill(fetcherMock))
+	idx.Start(context.Background(), in)
+
+	var inserted []int64
+	for call := range slices.Values(txStoreMock.InsertBlockCalls()) {
+		inserted = append(inserted, call.Block.Number)
+	}
+	assert.Equal(t, []int64{1, 2, 4, 5}, inserted)
+	require.Len(t, fetcherMock.GetBlockCalls(), 2)
+	require.Len(t, txStoreMock.InsertDeadLetterCalls(), 1)
+	assert.Equal(t, int64(3), txStoreMock.InsertDeadLetterCalls()[0].DeadLetter.BlockNumber)
+}
+
+func TestIndexRemovesOrphanedBlocks(t *testing.T) {
+	tests := map[string]struct {
+		gapFill               bool
+		expectedTxs           []string
+		expectedNotifications []string
+	}{
+		"canonical block is indexed with gap filling": {
+			gapFill:               true,
+			expectedTxs:           []string{"tx-1", "tx-2 removed", "tx-2b", "tx-3"},
+			expectedNotifications: []string{"tx-1", "tx-2", "tx-2 removed", "tx-2b", "tx-3"},
+		},
+		"orphaned block is removed without gap filling": {
+			expectedTxs:           []string{"tx-1", "tx-2 removed", "tx-3"},
+			expectedNotifications: []string{"tx-1", "tx-2", "tx-2 removed", "tx-3"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			newBlock := func(number int64, hash, parentHash, txHash string) *eth.Block {
+				return &eth.Block{
+					Number:     number,
+					Hash:       hash,
+					ParentHash: parentHash,
+					Txs:        []*eth.Tx{{Hash: txHash, From: "addr-1", To: "addr-2", Raw: []byte("{}")}},
+				}
+			}
+			// block 2 is orphaned by the canonical block 2b, which is the parent of block 3
+			blocks := []*eth.Block{
+				newBlock(1, "hash-1", "hash-0", "tx-1"),
+				newBlock(2, "hash-2", "hash-1", "tx-2"),
+				newBlock(3, "hash-3", "hash-2b", "tx-3"),
+			}
+			fetcherMock := &mocks.BlockFetcherMock{
+				GetBlockFunc: func(ctx context.Context, number int64) (*eth.Block, error) {
+					return newBlock(2, "hash-2b", "hash-1", "tx-2b"), nil
+				},
+			}
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != "addr-1" {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr}, nil
+				}),
+			}
+			opts := []Option{
+				WithReorgRemovals(8),
+				WithNotifier("mock", &mocks.NotifierMock{}),
+			}
+			if test.gapFill {
+				opts = append(opts, WithGapFill(fetcherMock))
+			}
+			txStore := memdb.NewTxStore()
+
+			idx := New(logging.Logrus(logrus.New()), txStore, subsStoreMock, opts...)
+			for block := range slices.Values(blocks) {
+				err := idx.index(context.Background(), block)
+				require.NoError(t, err)
+			}
+
+			describe := func(tx *store.TxRecord) string {
+				if tx.Removed {
+					return tx.Hash + " removed"
+				}
+				return tx.Hash
+			}
+			txs, err := txStore.GetTransactions(context.Background(), "addr-1")
+			require.NoError(t, err)
+			var recorded []string
+			for tx := range slices.Values(txs) {
+				recorded = append(recorded, describe(tx))
+			}
+			assert.Equal(t, test.expectedTxs, recorded)
+
+			queue := idx.notifierQueues[0].notifications
+			var notified []string
+			for len(queue) > 0 {
+				notified = append(notified, describe((<-queue).tx))
+			}
+			assert.Equal(t, test.expectedNotifications, notified)
+		})
+	}
+}
+
+func TestStartRemovesOrphanedBlocks(t *testing.T) {
+	tests := map[string]struct {
+		workers     int
+		reorgWindow int
+		expectedTxs []string
+	}{
+		"sequential": {
+			workers:     1,
+			reorgWindow: 8,
+			expectedTxs: []string{"tx-1", "tx-2 removed", "tx-3 removed", "tx-2b", "tx-3b"},
+		},
+		"concurrent": {
+			workers:     4,
+			reorgWindow: 8,
+			expectedTxs: []string{"tx-1", "tx-2 removed", "tx-3 removed", "tx-2b", "tx-3b"},
+		},
+		"records are kept without reorg window": {
+			workers:     1,
+			expectedTxs: []string{"tx-1", "tx-2", "tx-3", "tx-2b", "tx-3b"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			newBlock := func(number int64, hash, parentHash, txHash string) *eth.Block {
+				return &eth.Block{
+					Number:     number,
+					Hash:       hash,
+					ParentHash: parentHash,
+					Txs:        []*eth.Tx{{Hash: txHash, From: "addr-1", To: "addr-2", Raw: []byte("{}")}},
+				}
+			}
+			// blocks 2 and 3 are orphaned after being confirmed, replaced by blocks 2b and 3b
+			events := []*eth.BlockEvent{
+				confirmed(newBlock(1, "hash-1", "hash-0", "tx-1")),
+				confirmed(newBlock(2, "hash-2", "hash-1", "tx-2")),
+				confirmed(newBlock(3, "hash-3", "hash-2", "tx-3")),
+				{Type: eth.BlockOrphaned, Block: newBlock(3, "hash-3", "hash-2", "tx-3")},
+				{Type: eth.BlockOrphaned, Block: newBlock(2, "hash-2", "hash-1", "tx-2")},
+				confirmed(newBlock(2, "hash-2b", "hash-1", "tx-2b")),
+				confirmed(newBlock(3, "hash-3b", "hash-2b", "tx-3b")),
+			}
+			in := make(chan *eth.BlockEvent, len(events))
+			for event := range slices.Values(events) {
+				in <- event
+			}
+			close(in)
+
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != "addr-1" {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr}, nil
+				}),
+			}
+			opts := []Option{WithWorkers(test.workers)}
+			if test.reorgWindow > 0 {
+				opts = append(opts, WithReorgRemovals(test.reorgWindow))
+			}
+			txStore := memdb.NewTxStore()
+
+			idx := New(logging.Logrus(logrus.New()), txStore, subsStoreMock, opts...)
+			idx.Start(context.Background(), in)
+
+			txs, err := txStore.GetTransactions(context.Background(), "addr-1")
+			require.NoError(t, err)
+			var recorded []string
+			for tx := range slices.Values(txs) {
+				if tx.Removed {
+					recorded = append(recorded, tx.Hash+" removed")
+					continue
+				}
+				recorded = append(recorded, tx.Hash)
+			}
+			assert.Equal(t, test.expectedTxs, recorded)
+		})
+	}
+}
+
+func TestStartFiltersSubscriptionLookups(t *testing.T) {
+	const (
+		subscribed      = "0x00000000000000000000000000000000000000aa"
+		lateSubscribed  = "0x00000000000000000000000000000000000000bb"
+		neverSubscribed = "0x00000000000000000000000000000000000000cc"
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subsStore := memdb.NewSubscriptionStore()
+	require.NoError(t, subsStore.AddSubscription(ctx, &store.Subscription{Address: subscribed}))
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subsStore.GetSubscriptionsBatch,
+	}
+	inserted := make(chan *store.Block)
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			inserted <- block
+			return nil
+		},
+	}
+
+	in := make(chan *eth.BlockEvent)
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock, WithSubscriptionFilter(subsStore, 1e-9))
+	go idx.Start(ctx, in)
+
+	in <- confirmed(&eth.Block{Number: 1, Txs: []*eth.Tx{
+		{Hash: "0x1", From: neverSubscribed, To: subscribed},
+		{Hash: "0x2", From: neverSubscribed, To: lateSubscribed},
+	}})
+	block := <-inserted
+	assert.Len(t, block.AddrToTxs[subscribed], 1)
+	require.Len(t, subsStoreMock.GetSubscriptionsBatchCalls(), 1)
+	assert.Equal(t, []string{subscribed}, subsStoreMock.GetSubscriptionsBatchCalls()[0].Addrs)
+
+	// an address is added to the filter before it's reported subscribed, the filter being rebuilt afterwards
+	require.NoError(t, subsStore.AddSubscription(ctx, &store.Subscription{Address: lateSubscribed}))
+	assert.True(t, idx.maySubscribe(lateSubscribed))
+
+	in <- confirmed(&eth.Block{Number: 2, Txs: []*eth.Tx{
+		{Hash: "0x3", From: neverSubscribed, To: lateSubscribed},
+	}})
+	block = <-inserted
+	assert.Len(t, block.AddrToTxs[lateSubscribed], 1)
+	require.Len(t, subsStoreMock.GetSubscriptionsBatchCalls(), 2)
+	assert.Equal(t, []string{lateSubscribed}, subsStoreMock.GetSubscriptionsBatchCalls()[1].Addrs)
+	assert.False(t, idx.maySubscribe(neverSubscribed))
+
+	// and once an address is unsubscribed
+	require.NoError(t, subsStore.RemoveSubscription(ctx, subscribed))
+	require.Eventually(t, func() bool {
+		return !idx.maySubscribe(subscribed)
+	}, time.Second, time.Millisecond)
+	assert.True(t, idx.maySubscribe(lateSubscribed))
+}
+
+// failingSubscriptionWatcher fails to list the subscriptions of its store once failing is set.
+type failingSubscriptionWatcher struct {
+	*memdb.SubscriptionStore
+	failing atomic.Bool
+	listed  atomic.Int32
+}
+
+func (w *failingSubscriptionWatcher) GetSubscriptions(ctx context.Context) ([]string, error) {
+	w.listed.Add(1)
+	if w.failing.Load() {
+		return nil, errors.New("dummy error")
+	}
+	return w.SubscriptionStore.GetSubscriptions(ctx)
+}
+
+func TestSubscriptionFilterRebuildFailure(t *testing.T) {
+	const (
+		subscribed     = "0x00000000000000000000000000000000000000aa"
+		lateSubscribed = "0x00000000000000000000000000000000000000bb"
+	)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := &failingSubscriptionWatcher{SubscriptionStore: memdb.NewSubscriptionStore()}
+	require.NoError(t, watcher.AddSubscription(ctx, &store.Subscription{Address: subscribed}))
+	idx := New(logging.Logrus(logrus.New()), &mocks.TxStoreMock{}, &mocks.SubscriptionStoreMock{}, WithSubscriptionFilter(watcher, 1e-9))
+	idx.startSubscriptionFilter(ctx)
+	require.Equal(t, int32(1), watcher.listed.Load())
+
+	// the addresses added while the filter can't be rebuilt are added to the current one, and the rebuild is retried
+	watcher.failing.Store(true)
+	require.NoError(t, watcher.AddSubscription(ctx, &store.Subscription{Address: lateSubscribed}))
+	require.Eventually(t, func() bool {
+		return watcher.listed.Load() >= 3
+	}, time.Second*5, time.Millisecond*10)
+	assert.True(t, idx.maySubscribe(subscribed))
+	assert.True(t, idx.maySubscribe(lateSubscribed))
+
+	// the retried rebuild drops the removed addresses once it succeeds
+	require.NoError(t, watcher.RemoveSubscription(ctx, subscribed))
+	watcher.failing.Store(false)
+	require.Eventually(t, func() bool {
+		return !idx.maySubscribe(subscribed)
+	}, time.Second*5, time.Millisecond*10)
+	assert.True(t, idx.maySubscribe(lateSubscribed))
+}
+
+func TestStartPausesAndResumes(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	inserted := make(chan *store.Block, 1)
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			inserted <- block
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: func(ctx context.Context, addrs []string) (map[string]*store.Subscription, error) {
+			return nil, nil
+		},
+	}
+
+	in := make(chan *eth.BlockEvent)
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+	go idx.Start(ctx, in)
+
+	in <- confirmed(&eth.Block{Number: 1})
+	assert.Equal(t, int64(1), (<-inserted).Number)
+
+	assert.True(t, idx.Pause())
+	assert.False(t, idx.Pause())
+	assert.True(t, idx.Paused())
+
+	// the received block is held back until resumed
+	in <- confirmed(&eth.Block{Number: 2})
+	select {
+	case block := <-inserted:
+		t.Fatalf("block %d indexed while paused", block.Number)
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.True(t, idx.Resume())
+	assert.False(t, idx.Resume())
+	assert.False(t, idx.Paused())
+	assert.Equal(t, int64(2), (<-inserted).Number)
+}
+
+// subscriptionsBatch adapts a single address look-up, returning store.ErrNotFound for unsubscribed addresses, into a
+// GetSubscriptionsBatch implementation.
+func subscriptionsBatch(get func(ctx context.Context, addr string) (*store.Subscription, error)) func(context.Context, []string) (map[string]*store.Subscription, error) {
+	return func(ctx context.Context, addrs []string) (map[string]*store.Subscription, error) {
+		subs := make(map[string]*store.Subscription)
+		for addr := range slices.Values(addrs) {
+			sub, err := get(ctx, addr)
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			subs[addr] = sub
+		}
+		return subs, nil
+	}
+}
+
+func confirmed(block *eth.Block) *eth.BlockEvent {
+	return &eth.BlockEvent{Type: eth.BlockConfirmed, Block: block}
+}
+
+func TestFlushNotifications(t *testing.T) {
+	tests := map[string]struct {
+		deliveryTime time.Duration
+		timeout      time.Duration
+		expectedErr  error
+	}{
+		"delivered before the timeout": {
+			deliveryTime: time.Millisecond * 20,
+			timeout:      time.Second,
+		},
+		"timed out": {
+			deliveryTime: time.Second,
+			timeout:      time.Millisecond * 50,
+			expectedErr:  context.DeadlineExceeded,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var delivered atomic.Int32
+			notifierMock := &mocks.NotifierMock{
+				NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+					select {
+					case <-time.After(test.deliveryTime):
+						delivered.Add(1)
+						return nil
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				},
+			}
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			idx := New(logging.Logrus(logrus.New()), nil, nil, WithNotifier("mock", notifierMock))
+			idx.enqueueNotifications(&matchedBlock{
+				records: []*store.TxRecord{{Hash: "tx-1"}, {Hash: "tx-2"}},
+			})
+			go idx.notifierQueues[0].dispatch(ctx, idx.logger)
+
+			flushCtx, cancelFlush := context.WithTimeout(context.Background(), test.timeout)
+			defer cancelFlush()
+			err := idx.FlushNotifications(flushCtx)
+			if test.expectedErr != nil {
+				require.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.EqualValues(t, 2, delivered.Load())
+		})
+	}
+}
+
+// flushingNotifier is a notifier queueing its deliveries, flushed once the given channel is closed.
+type flushingNotifier struct {
+	*mocks.NotifierMock
+	flushed chan struct{}
+}
+
+func (n *flushingNotifier) Flush(ctx context.Context) error {
+	select {
+	case <-n.flushed:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func TestFlushNotificationsFlushesNotifiers(t *testing.T) {
+	notifier := &flushingNotifier{
+		NotifierMock: &mocks.NotifierMock{
+			NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+				return nil
+			},
+		},
+		flushed: make(chan struct{}),
+	}
+	idx := New(logging.Logrus(logrus.New()), nil, nil, WithNotifier("mock", notifier))
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancelFlush()
+	err := idx.FlushNotifications(flushCtx)
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+
+	close(notifier.flushed)
+	require.NoError(t, idx.FlushNotifications(context.Background()))
+}
+
+func TestStartDryRun(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 2)
+	in <- confirmed(&eth.Block{
+		Hash:   "hash-1",
+		Number: 1,
+		Txs: []*eth.Tx{
+			{Hash: "tx-1", From: "addr-1", To: "addr-2"},
+			{Hash: "tx-2", From: "addr-3", To: "addr-4"},
+		},
+	})
+	in <- confirmed(&eth.Block{
+		Hash:   "hash-2",
+		Number: 2,
+		Txs:    []*eth.Tx{{Hash: "tx-3", From: "addr-2", To: "addr-1"}},
+	})
+	close(in)
+
+	// nothing is written to the store, the mock panics otherwise
+	txStoreMock := &mocks.TxStoreMock{}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr, Priority: true}, nil
+		}),
+	}
+	notifierMock := &mocks.NotifierMock{}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock,
+		WithNotifier("mock", notifierMock),
+		WithDryRun(),
+	)
+	idx.Start(context.Background(), in)
+
+	assert.Equal(t, 2, idx.dryRun.blocks)
+	assert.Equal(t, 2, idx.dryRun.txs)
+	assert.Equal(t, 2, idx.dryRun.notifications)
+	assert.Empty(t, idx.NotificationBacklogs()["mock"])
+	assert.Empty(t, notifierMock.NotifyCalls())
+}
+
+func TestStuck(t *testing.T) {
+	in := make(chan *eth.BlockEvent, 1)
+	in <- confirmed(&eth.Block{Hash: "hash-1", Number: 1})
+	inserting := make(chan struct{})
+	release := make(chan struct{})
+	txStoreMock := &mocks.TxStoreMock{
+		InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+			close(inserting)
+			<-release
+			return nil
+		},
+	}
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			return nil, store.ErrNotFound
+		}),
+	}
+
+	idx := New(logging.Logrus(logrus.New()), txStoreMock, subsStoreMock)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	assert.False(t, idx.Stuck(0), "idle loop reported stuck")
+	go idx.Start(ctx, in)
+
+	<-inserting
+	time.Sleep(time.Millisecond * 20)
+	assert.True(t, idx.Stuck(time.Millisecond*10))
+	assert.False(t, idx.Stuck(time.Minute))
+
+	close(release)
+	assert.Eventually(t, func() bool {
+		return !idx.Stuck(0)
+	}, time.Second, time.Millisecond, "loop waiting for blocks reported stuck")
+}
+
+func TestAddressMetrics(t *testing.T) {
+	registry := prometheus.NewRegistry()
+	m := newAddressMetrics(2, newMetrics(registry))
+	records := func(n int) []*store.TxRecord {
+		return make([]*store.TxRecord, n)
+	}
+	m.record(&store.Block{AddrToTxs: map[string][]*store.TxRecord{"0xmetrics-a": records(2)}})
+	m.record(&store.Block{AddrToTxs: map[string][]*store.TxRecord{"0xmetrics-b": records(1), "0xmetrics-empty": nil}})
+	m.record(&store.Block{AddrToTxs: map[string][]*store.TxRecord{"0xmetrics-c": records(3), "0xmetrics-a": records(1)}})
+
+	counts := make(map[string]float64)
+	families, err := registry.Gather()
+	require.NoError(t, err)
+	for family := range slices.Values(families) {
+		if family.GetName() != "ethtxparser_address_transactions_total" {
+			continue
+		}
+		for metric := range slices.Values(family.GetMetric()) {
+			counts[metric.GetLabel()[0].GetValue()] = metric.GetCounter().GetValue()
+		}
+	}
+	assert.Equal(t, map[string]float64{
+		"0xmetrics-a": 3,
+		"0xmetrics-b": 1,
+		// past the limit
+		otherAddresses: 3,
+	}, counts)
+}
+
+func TestTokenBalanceChanges(t *testing.T) {
+	const (
+		token   = "0xa0b86991c6218b36c1d19d4a2e9eb0ce3606eb48"
+		holderA = "0x1111111111111111111111111111111111111111"
+		holderB = "0x2222222222222222222222222222222222222222"
+	)
+	topic := func(addr string) string {
+		return "0x000000000000000000000000" + addr[2:]
+	}
+	transfer := func(from, to string, amount int64) *eth.Log {
+		return &eth.Log{
+			Address: token,
+			Topics:  []string{transferEventTopic, topic(from), topic(to)},
+			Data:    fmt.Sprintf("0x%064x", amount),
+		}
+	}
+
+	tests := map[string]struct {
+		sub             *store.Subscription
+		logs            []*eth.Log
+		expectedChanges []*store.TokenBalanceChange
+	}{
+		"net changes per holder": {
+			sub: &store.Subscription{Address: token, TrackHolders: true},
+			logs: []*eth.Log{
+				transfer(holderA, holderB, 1000),
+				transfer(holderB, holderA, 300),
+			},
+			expectedChanges: []*store.TokenBalanceChange{
+				{Token: token, Holder: holderA, Delta: big.NewInt(-700)},
+				{Token: token, Holder: holderB, Delta: big.NewInt(700)},
+			},
+		},
+		"mint and burn skip the zero address": {
+			sub: &store.Subscription{Address: token, TrackHolders: true},
+			logs: []*eth.Log{
+				transfer(zeroAddress, holderA, 500),
+				transfer(holderB, zeroAddress, 200),
+			},
+			expectedChanges: []*store.TokenBalanceChange{
+				{Token: token, Holder: holderA, Delta: big.NewInt(500)},
+				{Token: token, Holder: holderB, Delta: big.NewInt(-200)},
+			},
+		},
+		"self transfer": {
+			sub:  &store.Subscription{Address: token, TrackHolders: true},
+			logs: []*eth.Log{transfer(holderA, holderA, 100)},
+		},
+		"holders not tracked": {
+			sub:  &store.Subscription{Address: token},
+			logs: []*eth.Log{transfer(holderA, holderB, 1000)},
+		},
+		"token not subscribed": {
+			logs: []*eth.Log{transfer(holderA, holderB, 1000)},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subs := make(map[string]*store.Subscription)
+			if test.sub != nil {
+				subs[test.sub.Address] = test.sub
+			}
+			block := &eth.Block{Receipts: []*eth.Receipt{{Logs: test.logs}}}
+			assert.Equal(t, test.expectedChanges, tokenBalanceChanges(subs, block))
+		})
+	}
+}
+
+func TestNotificationPreferences(t *testing.T) {
+	now := time.Date(2026, 1, 1, 12, 30, 0, 0, time.UTC)
+	tests := map[string]struct {
+		preferences       map[string]*store.NotificationPreferences
+		expectedHeld      bool
+		expectedReleaseAt time.Time
+		expectedDelivered bool
+	}{
+		"no preferences": {
+			preferences:       map[string]*store.NotificationPreferences{"addr-1": nil},
+			expectedDelivered: true,
+		},
+		"no subscription": {
+			expectedDelivered: true,
+		},
+		"other channels": {
+			preferences: map[string]*store.NotificationPreferences{"addr-1": {Channels: []string{"email"}}},
+		},
+		"below min value": {
+			preferences: map[string]*store.NotificationPreferences{"addr-1": {MinValue: big.NewInt(11)}},
+		},
+		"digest": {
+			preferences: map[string]*store.NotificationPreferences{
+				"addr-1": {Channels: []string{"mock"}, Delivery: store.NotificationDeliveryDigest},
+			},
+			expectedHeld:      true,
+			expectedReleaseAt: time.Date(2026, 1, 1, 13, 0, 0, 0, time.UTC),
+		},
+		"quiet hours": {
+			preferences: map[string]*store.NotificationPreferences{
+				"addr-1": {QuietHours: &store.QuietHours{Start: "12:00", End: "14:00"}},
+			},
+			expectedHeld:      true,
+			expectedReleaseAt: time.Date(2026, 1, 1, 14, 0, 0, 0, time.UTC),
+		},
+		"digest during quiet hours wrapping around midnight": {
+			preferences: map[string]*store.NotificationPreferences{
+				"addr-1": {QuietHours: &store.QuietHours{Start: "13:00", End: "01:00"}, Delivery: store.NotificationDeliveryDigest},
+			},
+			expectedHeld:      true,
+			expectedReleaseAt: time.Date(2026, 1, 2, 1, 0, 0, 0, time.UTC),
+		},
+		"outside quiet hours": {
+			preferences: map[string]*store.NotificationPreferences{
+				"addr-1": {QuietHours: &store.QuietHours{Start: "22:00", End: "07:00"}},
+			},
+			expectedDelivered: true,
+		},
+		"most eager subscription": {
+			preferences: map[string]*store.NotificationPreferences{
+				"addr-1": {Delivery: store.NotificationDeliveryDigest},
+				"addr-2": {QuietHours: &store.QuietHours{Start: "12:00", End: "12:45"}},
+			},
+			expectedHeld:      true,
+			expectedReleaseAt: time.Date(2026, 1, 1, 12, 45, 0, 0, time.UTC),
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+					preferences, ok := test.preferences[addr]
+					if !ok {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr, Preferences: preferences}, nil
+				}),
+			}
+			idx := New(logging.Logrus(logrus.New()), nil, subsStoreMock, WithNotifier("mock", &mocks.NotifierMock{}))
+			q := idx.notifierQueues[0]
+			q.now = func() time.Time { return now }
+
+			n := notification{tx: &store.TxRecord{Hash: "tx-1", From: "addr-1", To: "addr-2", Value: big.NewInt(10)}}
+			assert.Equal(t, test.expectedDelivered, !q.hold(context.Background(), n))
+			if !test.expectedHeld {
+				assert.Empty(t, q.held)
+				return
+			}
+			require.Len(t, q.held, 1)
+			assert.Equal(t, test.expectedReleaseAt, q.held[0].releaseAt)
+
+			_, ok := q.release()
+			assert.False(t, ok, "released before it's due")
+			q.now = func() time.Time { return test.expectedReleaseAt }
+			released, ok := q.release()
+			require.True(t, ok)
+			assert.True(t, released.released)
+			assert.Equal(t, n.tx, released.tx)
+			assert.False(t, q.hold(context.Background(), released), "released notifications aren't held again")
+		})
+	}
+}
+
+func TestDispatchReleasesHeldNotifications(t *testing.T) {
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionsBatchFunc: subscriptionsBatch(func(ctx context.Context, addr string) (*store.Subscription, error) {
+			return &store.Subscription{Address: addr, Preferences: &store.NotificationPreferences{
+				Delivery: store.NotificationDeliveryDigest,
+			}}, nil
+		}),
+	}
+	notified := make(chan string, 2)
+	notifierMock := &mocks.NotifierMock{
+		NotifyFunc: func(ctx context.Context, tx *store.TxRecord) error {
+			notified <- tx.Hash
+			return nil
+		},
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	idx := New(logging.Logrus(logrus.New()), nil, subsStoreMock,
+		WithNotifier("mock", notifierMock),
+		WithDigestInterval(time.Millisecond*100),
+	)
+	idx.enqueueNotifications(&matchedBlock{
+		records: []*store.TxRecord{{Hash: "tx-1", From: "addr-1"}, {Hash: "tx-2", From: "addr-1"}},
+	})
+	go idx.notifierQueues[0].dispatch(ctx, idx.logger)
+
+	// held notifications aren't waited for by the flush, they're delivered together at the next digest
+	require.NoError(t, idx.FlushNotifications(ctx))
+	for _, hash := range []string{"tx-1", "tx-2"} {
+		select {
+		case got := <-notified:
+			assert.Equal(t, hash, got)
+		case <-time.After(time.Second):
+			t.Fatalf("timed out waiting for %s to be notified", hash)
+		}
+	}
+}
@@ -6,18 +6,165 @@ import (
 	"github.com/hedisam/ethtxparser/internal/custompromauto"
 )
 
-var (
-	blocksFailedProcessing = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-		Name: "ethtxparser_blocks_failed_processing_total",
-		Help: "Total number of blocks that failed processing during indexing",
-	})
+// metrics holds the metrics of an Index, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	blocksFailedProcessing           prometheus.Counter
+	indexingPaused                   prometheus.Gauge
+	processedBlocks                  prometheus.Counter
+	indexedTransactions              prometheus.Counter
+	filteredTransactions             prometheus.Counter
+	indexedTokenTransfers            prometheus.Counter
+	blockRetries                     prometheus.Counter
+	dryRunTransactions               prometheus.Counter
+	deadLetterBlocks                 prometheus.Counter
+	blockGaps                        prometheus.Counter
+	refetchedBlocks                  prometheus.Counter
+	droppedNotifications             *prometheus.CounterVec
+	skippedNotifications             *prometheus.CounterVec
+	heldNotifications                *prometheus.GaugeVec
+	priorityNotifications            prometheus.Counter
+	filteredLookups                  prometheus.Counter
+	subscriptionFilterFalsePositives prometheus.Counter
+	subscriptionFilterRebuilds       prometheus.Counter
+	skippedFailedTransactions        prometheus.Counter
+	orphanedBlocks                   prometheus.Counter
+	removedTransactions              prometheus.Counter
+	vetoedTransactions               prometheus.Counter
+	inputDecodings                   *prometheus.CounterVec
+	indexedEvents                    prometheus.Counter
+	addressTransactions              *prometheus.CounterVec
+	addressLastMatched               *prometheus.GaugeVec
+	blockMatchDuration               prometheus.Histogram
+	blockStoreInsertDuration         prometheus.Histogram
+	notificationDeliveryDuration     *prometheus.HistogramVec
+	pendingBlocks                    prometheus.Gauge
+}
 
-	processedBlocks = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-		Name: "ethtxparser_blocks_processed_total",
-		Help: "Total number of blocks consumed for indexing",
-	})
-	indexedTransactions = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
-		Name: "ethtxparser_indexed_transactions_total",
-		Help: "Total number of transactions successfully indexed",
-	})
-)
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		blocksFailedProcessing: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_blocks_failed_processing_total",
+			Help: "Total number of blocks that failed processing during indexing",
+		}),
+		indexingPaused: auto.NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_indexing_paused",
+			Help: "Whether indexing is paused by an admin, 1 if paused and 0 otherwise",
+		}),
+		processedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_blocks_processed_total",
+			Help: "Total number of blocks consumed for indexing",
+		}),
+		indexedTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_indexed_transactions_total",
+			Help: "Total number of transactions successfully indexed",
+		}),
+		filteredTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_filtered_transactions_total",
+			Help: "Total number of transactions of subscribed addresses skipped by the subscription filters",
+		}),
+		indexedTokenTransfers: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_indexed_token_transfers_total",
+			Help: "Total number of ERC-20 and ERC-721 token transfers matched for indexing",
+		}),
+		blockRetries: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_block_retries_total",
+			Help: "Total number of attempts to index previously failed blocks",
+		}),
+		dryRunTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_dry_run_transactions_total",
+			Help: "Total number of transactions matched in dry run mode, which would have been indexed",
+		}),
+		deadLetterBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_dead_letter_blocks_total",
+			Help: "Total number of blocks given up on after exhausting their retries",
+		}),
+		blockGaps: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_block_gaps_total",
+			Help: "Total number of gaps detected in the sequence of received blocks",
+		}),
+		refetchedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_refetched_blocks_total",
+			Help: "Total number of blocks missing from the stream fetched again to fill gaps",
+		}),
+		droppedNotifications: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_dropped_notifications_total",
+			Help: "Total number of notifications dropped because the notifier queue was full",
+		}, []string{"notifier"}),
+		skippedNotifications: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_skipped_notifications_total",
+			Help: "Total number of transaction notifications skipped by the notification preferences of their subscriptions, by notifier",
+		}, []string{"notifier"}),
+		heldNotifications: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_held_notifications",
+			Help: "Number of transaction notifications held back by quiet hours or digest delivery, by notifier",
+		}, []string{"notifier"}),
+		priorityNotifications: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_priority_notifications_total",
+			Help: "Total number of transactions of high priority subscriptions notified ahead of the commit of their block",
+		}),
+		filteredLookups: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_subscription_filter_skipped_lookups_total",
+			Help: "Total number of subscription store look-ups skipped by the subscription filter",
+		}),
+		subscriptionFilterFalsePositives: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_subscription_filter_false_positives_total",
+			Help: "Total number of addresses passed by the subscription filter that weren't subscribed",
+		}),
+		subscriptionFilterRebuilds: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_subscription_filter_rebuilds_total",
+			Help: "Total number of times the subscription filter was rebuilt after the subscriptions changed",
+		}),
+		skippedFailedTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_skipped_failed_transactions_total",
+			Help: "Total number of reverted transactions skipped by the indexer",
+		}),
+		orphanedBlocks: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_orphaned_blocks_total",
+			Help: "Total number of indexed blocks later orphaned by chain reorganisations whose records were removed",
+		}),
+		removedTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_removed_transactions_total",
+			Help: "Total number of recorded transactions marked as removed because their block was orphaned",
+		}),
+		vetoedTransactions: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_vetoed_transactions_total",
+			Help: "Total number of matched transactions vetoed by the indexing hooks",
+		}),
+		inputDecodings: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_input_decodings_total",
+			Help: "Total number of recorded transaction inputs decoded using the contract ABIs, by result",
+		}, []string{"result"}),
+		indexedEvents: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_indexed_events_total",
+			Help: "Total number of contract events matched by event subscriptions",
+		}),
+		addressTransactions: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_address_transactions_total",
+			Help: "Total number of transactions recorded per address, up to the address metrics limit, the others aggregated under 'other'",
+		}, []string{"address"}),
+		addressLastMatched: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_address_last_matched_timestamp_seconds",
+			Help: "Unix time a transaction was last recorded for the address, up to the address metrics limit, the others aggregated under 'other'",
+		}, []string{"address"}),
+		blockMatchDuration: auto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ethtxparser_block_match_duration_seconds",
+			Help:    "Time taken to match the transactions of each block against the subscriptions",
+			Buckets: prometheus.DefBuckets,
+		}),
+		blockStoreInsertDuration: auto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ethtxparser_block_store_insert_duration_seconds",
+			Help:    "Time taken to insert each matched block into the store",
+			Buckets: prometheus.DefBuckets,
+		}),
+		notificationDeliveryDuration: auto.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "ethtxparser_notification_delivery_duration_seconds",
+			Help:    "Time taken to deliver each transaction and block notification, by notifier",
+			Buckets: prometheus.DefBuckets,
+		}, []string{"notifier"}),
+		pendingBlocks: auto.NewGauge(prometheus.GaugeOpts{
+			Name: "ethtxparser_index_pending_blocks",
+			Help: "Number of blocks received by the concurrent indexer waiting to be matched or committed in order",
+		}),
+	}
+}
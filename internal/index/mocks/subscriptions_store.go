@@ -5,6 +5,7 @@ package mocks
 
 import (
 	"context"
+	"github.com/hedisam/ethtxparser/internal/store"
 	"sync"
 )
 
@@ -14,8 +15,11 @@ import (
 //
 //		// make and configure a mocked index.SubscriptionStore
 //		mockedSubscriptionStore := &SubscriptionStoreMock{
-//			IsSubscribedFunc: func(ctx context.Context, addr string) (bool, error) {
-//				panic("mock out the IsSubscribed method")
+//			GetEventSubscriptionsFunc: func(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+//				panic("mock out the GetEventSubscriptions method")
+//			},
+//			GetSubscriptionsBatchFunc: func(ctx context.Context, addrs []string) (map[string]*store.Subscription, error) {
+//				panic("mock out the GetSubscriptionsBatch method")
 //			},
 //		}
 //
@@ -24,54 +28,101 @@ import (
 //
 //	}
 type SubscriptionStoreMock struct {
-	// IsSubscribedFunc mocks the IsSubscribed method.
-	IsSubscribedFunc func(ctx context.Context, addr string) (bool, error)
+	// GetEventSubscriptionsFunc mocks the GetEventSubscriptions method.
+	GetEventSubscriptionsFunc func(ctx context.Context, contract string) ([]*store.EventSubscription, error)
+
+	// GetSubscriptionsBatchFunc mocks the GetSubscriptionsBatch method.
+	GetSubscriptionsBatchFunc func(ctx context.Context, addrs []string) (map[string]*store.Subscription, error)
 
 	// calls tracks calls to the methods.
 	calls struct {
-		// IsSubscribed holds details about calls to the IsSubscribed method.
-		IsSubscribed []struct {
+		// GetEventSubscriptions holds details about calls to the GetEventSubscriptions method.
+		GetEventSubscriptions []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Contract is the contract argument value.
+			Contract string
+		}
+		// GetSubscriptionsBatch holds details about calls to the GetSubscriptionsBatch method.
+		GetSubscriptionsBatch []struct {
 			// Ctx is the ctx argument value.
 			Ctx context.Context
-			// Addr is the addr argument value.
-			Addr string
+			// Addrs is the addrs argument value.
+			Addrs []string
 		}
 	}
-	lockIsSubscribed sync.RWMutex
+	lockGetEventSubscriptions sync.RWMutex
+	lockGetSubscriptionsBatch sync.RWMutex
+}
+
+// GetEventSubscriptions calls GetEventSubscriptionsFunc.
+func (mock *SubscriptionStoreMock) GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+	if mock.GetEventSubscriptionsFunc == nil {
+		panic("SubscriptionStoreMock.GetEventSubscriptionsFunc: method is nil but SubscriptionStore.GetEventSubscriptions was just called")
+	}
+	callInfo := struct {
+		Ctx      context.Context
+		Contract string
+	}{
+		Ctx:      ctx,
+		Contract: contract,
+	}
+	mock.lockGetEventSubscriptions.Lock()
+	mock.calls.GetEventSubscriptions = append(mock.calls.GetEventSubscriptions, callInfo)
+	mock.lockGetEventSubscriptions.Unlock()
+	return mock.GetEventSubscriptionsFunc(ctx, contract)
+}
+
+// GetEventSubscriptionsCalls gets all the calls that were made to GetEventSubscriptions.
+// Check the length with:
+//
+//	len(mockedSubscriptionStore.GetEventSubscriptionsCalls())
+func (mock *SubscriptionStoreMock) GetEventSubscriptionsCalls() []struct {
+	Ctx      context.Context
+	Contract string
+} {
+	var calls []struct {
+		Ctx      context.Context
+		Contract string
+	}
+	mock.lockGetEventSubscriptions.RLock()
+	calls = mock.calls.GetEventSubscriptions
+	mock.lockGetEventSubscriptions.RUnlock()
+	return calls
 }
 
-// IsSubscribed calls IsSubscribedFunc.
-func (mock *SubscriptionStoreMock) IsSubscribed(ctx context.Context, addr string) (bool, error) {
-	if mock.IsSubscribedFunc == nil {
-		panic("SubscriptionStoreMock.IsSubscribedFunc: method is nil but SubscriptionStore.IsSubscribed was just called")
+// GetSubscriptionsBatch calls GetSubscriptionsBatchFunc.
+func (mock *SubscriptionStoreMock) GetSubscriptionsBatch(ctx context.Context, addrs []string) (map[string]*store.Subscription, error) {
+	if mock.GetSubscriptionsBatchFunc == nil {
+		panic("SubscriptionStoreMock.GetSubscriptionsBatchFunc: method is nil but SubscriptionStore.GetSubscriptionsBatch was just called")
 	}
 	callInfo := struct {
-		Ctx  context.Context
-		Addr string
+		Ctx   context.Context
+		Addrs []string
 	}{
-		Ctx:  ctx,
-		Addr: addr,
+		Ctx:   ctx,
+		Addrs: addrs,
 	}
-	mock.lockIsSubscribed.Lock()
-	mock.calls.IsSubscribed = append(mock.calls.IsSubscribed, callInfo)
-	mock.lockIsSubscribed.Unlock()
-	return mock.IsSubscribedFunc(ctx, addr)
+	mock.lockGetSubscriptionsBatch.Lock()
+	mock.calls.GetSubscriptionsBatch = append(mock.calls.GetSubscriptionsBatch, callInfo)
+	mock.lockGetSubscriptionsBatch.Unlock()
+	return mock.GetSubscriptionsBatchFunc(ctx, addrs)
 }
 
-// IsSubscribedCalls gets all the calls that were made to IsSubscribed.
+// GetSubscriptionsBatchCalls gets all the calls that were made to GetSubscriptionsBatch.
 // Check the length with:
 //
-//	len(mockedSubscriptionStore.IsSubscribedCalls())
-func (mock *SubscriptionStoreMock) IsSubscribedCalls() []struct {
-	Ctx  context.Context
-	Addr string
+//	len(mockedSubscriptionStore.GetSubscriptionsBatchCalls())
+func (mock *SubscriptionStoreMock) GetSubscriptionsBatchCalls() []struct {
+	Ctx   context.Context
+	Addrs []string
 } {
 	var calls []struct {
-		Ctx  context.Context
-		Addr string
+		Ctx   context.Context
+		Addrs []string
 	}
-	mock.lockIsSubscribed.RLock()
-	calls = mock.calls.IsSubscribed
-	mock.lockIsSubscribed.RUnlock()
+	mock.lockGetSubscriptionsBatch.RLock()
+	calls = mock.calls.GetSubscriptionsBatch
+	mock.lockGetSubscriptionsBatch.RUnlock()
 	return calls
 }
@@ -18,6 +18,12 @@ import (
 //			InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
 //				panic("mock out the InsertBlock method")
 //			},
+//			InsertDeadLetterFunc: func(ctx context.Context, deadLetter *store.DeadLetter) error {
+//				panic("mock out the InsertDeadLetter method")
+//			},
+//			RemoveBlockFunc: func(ctx context.Context, block *store.Block) error {
+//				panic("mock out the RemoveBlock method")
+//			},
 //		}
 //
 //		// use mockedTxStore in code that requires index.TxStore
@@ -28,6 +34,12 @@ type TxStoreMock struct {
 	// InsertBlockFunc mocks the InsertBlock method.
 	InsertBlockFunc func(ctx context.Context, block *store.Block) error
 
+	// InsertDeadLetterFunc mocks the InsertDeadLetter method.
+	InsertDeadLetterFunc func(ctx context.Context, deadLetter *store.DeadLetter) error
+
+	// RemoveBlockFunc mocks the RemoveBlock method.
+	RemoveBlockFunc func(ctx context.Context, block *store.Block) error
+
 	// calls tracks calls to the methods.
 	calls struct {
 		// InsertBlock holds details about calls to the InsertBlock method.
@@ -37,8 +49,24 @@ type TxStoreMock struct {
 			// Block is the block argument value.
 			Block *store.Block
 		}
+		// InsertDeadLetter holds details about calls to the InsertDeadLetter method.
+		InsertDeadLetter []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// DeadLetter is the deadLetter argument value.
+			DeadLetter *store.DeadLetter
+		}
+		// RemoveBlock holds details about calls to the RemoveBlock method.
+		RemoveBlock []struct {
+			// Ctx is the ctx argument value.
+			Ctx context.Context
+			// Block is the block argument value.
+			Block *store.Block
+		}
 	}
-	lockInsertBlock sync.RWMutex
+	lockInsertBlock      sync.RWMutex
+	lockInsertDeadLetter sync.RWMutex
+	lockRemoveBlock      sync.RWMutex
 }
 
 // InsertBlock calls InsertBlockFunc.
@@ -76,3 +104,75 @@ func (mock *TxStoreMock) InsertBlockCalls() []struct {
 	mock.lockInsertBlock.RUnlock()
 	return calls
 }
+
+// InsertDeadLetter calls InsertDeadLetterFunc.
+func (mock *TxStoreMock) InsertDeadLetter(ctx context.Context, deadLetter *store.DeadLetter) error {
+	if mock.InsertDeadLetterFunc == nil {
+		panic("TxStoreMock.InsertDeadLetterFunc: method is nil but TxStore.InsertDeadLetter was just called")
+	}
+	callInfo := struct {
+		Ctx        context.Context
+		DeadLetter *store.DeadLetter
+	}{
+		Ctx:        ctx,
+		DeadLetter: deadLetter,
+	}
+	mock.lockInsertDeadLetter.Lock()
+	mock.calls.InsertDeadLetter = append(mock.calls.InsertDeadLetter, callInfo)
+	mock.lockInsertDeadLetter.Unlock()
+	return mock.InsertDeadLetterFunc(ctx, deadLetter)
+}
+
+// InsertDeadLetterCalls gets all the calls that were made to InsertDeadLetter.
+// Check the length with:
+//
+//	len(mockedTxStore.InsertDeadLetterCalls())
+func (mock *TxStoreMock) InsertDeadLetterCalls() []struct {
+	Ctx        context.Context
+	DeadLetter *store.DeadLetter
+} {
+	var calls []struct {
+		Ctx        context.Context
+		DeadLetter *store.DeadLetter
+	}
+	mock.lockInsertDeadLetter.RLock()
+	calls = mock.calls.InsertDeadLetter
+	mock.lockInsertDeadLetter.RUnlock()
+	return calls
+}
+
+// RemoveBlock calls RemoveBlockFunc.
+func (mock *TxStoreMock) RemoveBlock(ctx context.Context, block *store.Block) error {
+	if mock.RemoveBlockFunc == nil {
+		panic("TxStoreMock.RemoveBlockFunc: method is nil but TxStore.RemoveBlock was just called")
+	}
+	callInfo := struct {
+		Ctx   context.Context
+		Block *store.Block
+	}{
+		Ctx:   ctx,
+		Block: block,
+	}
+	mock.lockRemoveBlock.Lock()
+	mock.calls.RemoveBlock = append(mock.calls.RemoveBlock, callInfo)
+	mock.lockRemoveBlock.Unlock()
+	return mock.RemoveBlockFunc(ctx, block)
+}
+
+// RemoveBlockCalls gets all the calls that were made to RemoveBlock.
+// Check the length with:
+//
+//	len(mockedTxStore.RemoveBlockCalls())
+func (mock *TxStoreMock) RemoveBlockCalls() []struct {
+	Ctx   context.Context
+	Block *store.Block
+} {
+	var calls []struct {
+		Ctx   context.Context
+		Block *store.Block
+	}
+	mock.lockRemoveBlock.RLock()
+	calls = mock.calls.RemoveBlock
+	mock.lockRemoveBlock.RUnlock()
+	return calls
+}
@@ -0,0 +1,249 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync/atomic"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// Notifier is notified of every transaction recorded in the store for the subscribed addresses.
+type Notifier interface {
+	Notify(ctx context.Context, tx *store.TxRecord) error
+}
+
+// BlockNotifier is optionally implemented by notifiers that also want to be notified of every committed block,
+// after its transactions.
+type BlockNotifier interface {
+	NotifyBlock(ctx context.Context, block *store.Block) error
+}
+
+// Flusher is optionally implemented by notifiers queueing their deliveries, so that flushing the notifications waits
+// for them to be delivered too.
+type Flusher interface {
+	Flush(ctx context.Context) error
+}
+
+// flushPollInterval is how often the notifier queues are checked while flushing them.
+const flushPollInterval = 10 * time.Millisecond
+
+// notification is a queued transaction or block notification, only one of tx and block is set.
+type notification struct {
+	tx    *store.TxRecord
+	block *store.Block
+	// released is set once a notification held back by the notification preferences is due.
+	released bool
+}
+
+// notifierQueue feeds a single notifier from its own queue, so a slow or failing notifier never delays the others.
+type notifierQueue struct {
+	name          string
+	notifier      Notifier
+	notifications chan notification
+	// priority holds the notifications of high priority subscriptions, delivered ahead of the queued ones.
+	priority chan notification
+	// wake is signalled when new entries are added to the outbox of the notifier.
+	wake chan struct{}
+	// pending counts the queued notifications, including the one being delivered.
+	pending atomic.Int64
+	// health is nil unless health reporting is enabled.
+	health  *health.Component
+	metrics *metrics
+	// route returns the time a transaction is notified at according to the notification preferences of its
+	// subscriptions, and false if it isn't notified by this notifier, see Index.route.
+	route func(ctx context.Context, notifier string, tx *store.TxRecord, now time.Time) (time.Time, bool)
+	now   func() time.Time
+	// held holds the notifications held back by the notification preferences, ordered by release time. It's only
+	// accessed by the goroutine delivering the notifications.
+	held []heldNotification
+}
+
+func newNotifierQueues(notifiers []namedNotifier, size int, registry *health.Registry, m *metrics) []*notifierQueue {
+	queues := make([]*notifierQueue, 0, len(notifiers))
+	for n := range slices.Values(notifiers) {
+		queues = append(queues, &notifierQueue{
+			name:          n.name,
+			notifier:      n.notifier,
+			notifications: make(chan notification, size),
+			priority:      make(chan notification, size),
+			wake:          make(chan struct{}, 1),
+			health:        registry.Component(health.Notifier(n.name)),
+			metrics:       m,
+			now:           time.Now,
+		})
+	}
+	return queues
+}
+
+// enqueueNotifications queues the committed block and its records on every notifier queue without blocking;
+// notifications are dropped from the queues that are full. The records of high priority subscriptions are skipped,
+// they're queued as soon as they're matched.
+func (i *Index) enqueueNotifications(matched *matchedBlock) {
+	for q := range slices.Values(i.notifierQueues) {
+		for record := range slices.Values(matched.records) {
+			if _, ok := matched.priority[record]; ok {
+				continue
+			}
+			q.enqueue(q.notifications, notification{tx: record})
+		}
+		if _, ok := q.notifier.(BlockNotifier); ok {
+			q.enqueue(q.notifications, notification{block: matched.storeBlock})
+		}
+	}
+}
+
+// notifyPriority queues the records of high priority subscriptions on the priority lane of every notifier queue,
+// ahead of the commit of their block. With the outbox, they're notified from the outbox along with the others.
+func (i *Index) notifyPriority(matched *matchedBlock) {
+	if i.cfg.dryRun {
+		return
+	}
+	if i.cfg.outbox != nil || len(matched.priority) == 0 {
+		return
+	}
+	for record := range slices.Values(matched.records) {
+		if _, ok := matched.priority[record]; !ok {
+			continue
+		}
+		for q := range slices.Values(i.notifierQueues) {
+			q.enqueue(q.priority, notification{tx: record})
+		}
+		i.metrics.priorityNotifications.Inc()
+	}
+}
+
+func (q *notifierQueue) enqueue(lane chan<- notification, n notification) {
+	select {
+	case lane <- n:
+		q.pending.Add(1)
+	default:
+		q.metrics.droppedNotifications.WithLabelValues(q.name).Inc()
+	}
+}
+
+// dispatch delivers the queued notifications to the notifier until the context is cancelled, the held ones once due
+// and the priority ones first. Notifications still held back once cancelled are dropped.
+func (q *notifierQueue) dispatch(ctx context.Context, logger logging.Logger) {
+	for {
+		n, ok := q.next(ctx)
+		if !ok {
+			return
+		}
+		if !q.hold(ctx, n) {
+			err := q.deliver(ctx, n)
+			if err != nil {
+				q.logFailure(logger, n, err)
+			}
+		}
+		// held notifications aren't pending, the flush doesn't wait for them
+		if !n.released {
+			q.pending.Add(-1)
+		}
+	}
+}
+
+// next returns the next notification to deliver, preferring the due held ones and then the priority lane, or false
+// once the context is done.
+func (q *notifierQueue) next(ctx context.Context) (notification, bool) {
+	for {
+		n, ok := q.release()
+		if ok {
+			return n, true
+		}
+		select {
+		case n := <-q.priority:
+			return n, true
+		default:
+		}
+
+		select {
+		case <-ctx.Done():
+			return notification{}, false
+		case n := <-q.priority:
+			return n, true
+		case n := <-q.notifications:
+			return n, true
+		case <-q.releaseTimer():
+		}
+	}
+}
+
+func (q *notifierQueue) deliver(ctx context.Context, n notification) error {
+	start := time.Now()
+	defer func() {
+		q.metrics.notificationDeliveryDuration.WithLabelValues(q.name).Observe(time.Since(start).Seconds())
+	}()
+	var err error
+	if n.block != nil {
+		err = q.notifier.(BlockNotifier).NotifyBlock(ctx, n.block)
+	} else {
+		err = q.notifier.Notify(ctx, n.tx)
+	}
+	q.health.Report(err)
+	return err
+}
+
+func (q *notifierQueue) logFailure(logger logging.Logger, n notification, err error) {
+	if n.block != nil {
+		logger.WithFields(logging.Fields{
+			"notifier":     q.name,
+			"block_number": n.block.Number,
+		}).WithError(err).Error("Failed to notify committed block")
+		return
+	}
+
+	logger.WithFields(logging.Fields{
+		"notifier":     q.name,
+		"tx_hash":      n.tx.Hash,
+		"block_number": n.tx.BlockNumber,
+	}).WithError(err).Error("Failed to notify recorded transaction")
+}
+
+// NotificationBacklogs returns the number of notifications queued for delivery by notifier, priority ones included.
+// Notifications waiting in the outbox aren't included.
+func (i *Index) NotificationBacklogs() map[string]int {
+	backlogs := make(map[string]int, len(i.notifierQueues))
+	for q := range slices.Values(i.notifierQueues) {
+		backlogs[q.name] = len(q.notifications) + len(q.priority)
+	}
+	return backlogs
+}
+
+// FlushNotifications waits for the queued notifications to be delivered, or given up on, until the context is done,
+// along with the deliveries queued by the notifiers implementing Flusher. Notifications waiting in the outbox are left
+// there, to be delivered once restarted.
+func (i *Index) FlushNotifications(ctx context.Context) error {
+	ticker := time.NewTicker(flushPollInterval)
+	defer ticker.Stop()
+
+	for {
+		pending := slices.ContainsFunc(i.notifierQueues, func(q *notifierQueue) bool {
+			return q.pending.Load() > 0
+		})
+		if !pending {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+
+	for q := range slices.Values(i.notifierQueues) {
+		flusher, ok := q.notifier.(Flusher)
+		if !ok {
+			continue
+		}
+		err := flusher.Flush(ctx)
+		if err != nil {
+			return fmt.Errorf("could not flush notifier %q: %w", q.name, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,261 @@
+package index
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/health"
+)
+
+const (
+	// DefaultWorkers is the default number of blocks matched concurrently.
+	DefaultWorkers = 1
+	// DefaultNotificationBuffer is the default number of notifications queued for each notifier.
+	DefaultNotificationBuffer = 1024
+	// DefaultOutboxMaxAttempts is the default number of times an outbox entry is delivered before it's dropped.
+	DefaultOutboxMaxAttempts = 10
+	// DefaultRetryAttempts is the default number of times a block is indexed before it's dead-lettered.
+	DefaultRetryAttempts = 5
+	// DefaultRetryQueueSize is the default number of failed blocks waiting to be retried.
+	DefaultRetryQueueSize = 64
+	// DefaultSubscriptionFilterFPRate is the default false positive rate of the subscription filter.
+	DefaultSubscriptionFilterFPRate = 0.01
+	// DefaultDigestInterval is the default interval the notifications of digest subscriptions are delivered at.
+	DefaultDigestInterval = time.Hour
+)
+
+type config struct {
+	workers            int
+	tokenTransfers     bool
+	events             bool
+	notifiers          []namedNotifier
+	notificationBuffer int
+	digestInterval     time.Duration
+	outbox             Outbox
+	outboxMaxAttempts  int
+	retryAttempts      int
+	retryQueueSize     int
+	blockFetcher       BlockFetcher
+	subsWatcher        SubscriptionWatcher
+	inputDecoder       InputDecoder
+	skipFailed         bool
+	reorgWindow        int
+	indexAll           bool
+	txHooks            []TxHook
+	blockHooks         []BlockHook
+	filterFPRate       float64
+	dryRun             bool
+	errorReporter      ErrorReporter
+	addressMetrics     int
+	health             *health.Registry
+	registerer         prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithWorkers sets the number of workers matching blocks concurrently. Blocks are still committed to the store
+// in the order they're received regardless of the number of workers.
+func WithWorkers(workers int) Option {
+	return func(c *config) {
+		if workers > 0 {
+			c.workers = workers
+		}
+	}
+}
+
+// WithTokenTransfers enables indexing ERC-20 and ERC-721 transfers of subscribed addresses.
+// Blocks must come with their receipts for transfers to be found.
+func WithTokenTransfers() Option {
+	return func(c *config) {
+		c.tokenTransfers = true
+	}
+}
+
+// WithEvents enables indexing contract events matching the event subscriptions.
+// Blocks must come with their receipts for events to be found.
+func WithEvents() Option {
+	return func(c *config) {
+		c.events = true
+	}
+}
+
+type namedNotifier struct {
+	name     string
+	notifier Notifier
+}
+
+// WithNotifier registers a notifier called for every transaction recorded in the store. If it implements
+// BlockNotifier, it is also called for every committed block. It can be given multiple times, every notifier is
+// fed asynchronously from its own queue; the name identifies it in logs and metrics.
+func WithNotifier(name string, notifier Notifier) Option {
+	return func(c *config) {
+		c.notifiers = append(c.notifiers, namedNotifier{name: name, notifier: notifier})
+	}
+}
+
+// WithNotificationBuffer sets the number of notifications that can be queued for each notifier before new ones
+// are dropped, so that a slow notifier never stalls indexing.
+func WithNotificationBuffer(size int) Option {
+	return func(c *config) {
+		if size >= 0 {
+			c.notificationBuffer = size
+		}
+	}
+}
+
+// WithDigestInterval sets the interval the notifications of the subscriptions preferring digest delivery are held
+// back and then delivered together at, aligned on the multiples of the interval since the unix epoch.
+func WithDigestInterval(interval time.Duration) Option {
+	return func(c *config) {
+		if interval > 0 {
+			c.digestInterval = interval
+		}
+	}
+}
+
+// WithOutbox persists the notifications in the store along with the block they belong to, instead of queueing them
+// in memory. The store must persist the Outbox of the inserted blocks and be given here, every notifier then
+// drains its own outbox with retries so notifications survive delivery failures and restarts.
+func WithOutbox(outbox Outbox) Option {
+	return func(c *config) {
+		c.outbox = outbox
+	}
+}
+
+// WithOutboxMaxAttempts sets the number of times an outbox entry is delivered before it's dropped.
+func WithOutboxMaxAttempts(attempts int) Option {
+	return func(c *config) {
+		if attempts > 0 {
+			c.outboxMaxAttempts = attempts
+		}
+	}
+}
+
+// WithRetryAttempts sets the number of times a block is indexed, retrying with exponential backoff, before it's
+// recorded as a dead letter. One disables retries.
+func WithRetryAttempts(attempts int) Option {
+	return func(c *config) {
+		if attempts > 0 {
+			c.retryAttempts = attempts
+		}
+	}
+}
+
+// WithRetryQueueSize sets the number of failed blocks that can wait to be retried, blocks failing while the queue
+// is full are dead-lettered straight away.
+func WithRetryQueueSize(size int) Option {
+	return func(c *config) {
+		if size >= 0 {
+			c.retryQueueSize = size
+		}
+	}
+}
+
+// WithGapFill makes the indexer detect gaps in the sequence of received blocks, e.g. after a missed poll, and
+// fetch the missing blocks using the given fetcher to index them before proceeding.
+func WithGapFill(fetcher BlockFetcher) Option {
+	return func(c *config) {
+		c.blockFetcher = fetcher
+	}
+}
+
+// WithSubscriptionFilter keeps a bloom filter of the subscribed addresses listed by the given watcher, rebuilt
+// whenever they change, so that the subscription store is only looked up for addresses that may be subscribed.
+func WithSubscriptionFilter(watcher SubscriptionWatcher, falsePositiveRate float64) Option {
+	return func(c *config) {
+		c.subsWatcher = watcher
+		if falsePositiveRate > 0 && falsePositiveRate < 1 {
+			c.filterFPRate = falsePositiveRate
+		}
+	}
+}
+
+// WithInputDecoder decodes the input of the recorded transactions using the given decoder, typically backed by the
+// ABIs of the called contracts.
+func WithInputDecoder(decoder InputDecoder) Option {
+	return func(c *config) {
+		c.inputDecoder = decoder
+	}
+}
+
+// WithSkipFailed excludes reverted transactions from indexing for every subscription. The status of transactions is
+// only known if the block receipts are fetched, transactions of blocks without receipts are indexed regardless.
+func WithSkipFailed() Option {
+	return func(c *config) {
+		c.skipFailed = true
+	}
+}
+
+// WithReorgRemovals keeps the last window committed blocks to detect the ones later orphaned by reorganisations
+// deeper than the confirmation depth. The records of orphaned blocks are marked as removed in the store and notified
+// again as removed. With gap filling enabled, the canonical blocks replacing them are indexed too.
+func WithReorgRemovals(window int) Option {
+	return func(c *config) {
+		c.reorgWindow = window
+	}
+}
+
+// WithIndexAll records every transaction of every block under its addresses regardless of the subscriptions, which
+// then only control notifications.
+func WithIndexAll() Option {
+	return func(c *config) {
+		c.indexAll = true
+	}
+}
+
+// WithTxHook registers a hook called with every matched transaction record before it's stored.
+func WithTxHook(hook TxHook) Option {
+	return func(c *config) {
+		c.txHooks = append(c.txHooks, hook)
+	}
+}
+
+// WithBlockHook registers a hook called with every matched block before it's stored.
+func WithBlockHook(hook BlockHook) Option {
+	return func(c *config) {
+		c.blockHooks = append(c.blockHooks, hook)
+	}
+}
+
+// WithDryRun makes the index match the blocks without writing anything to the store or notifying, logging what would
+// have been indexed instead.
+func WithDryRun() Option {
+	return func(c *config) {
+		c.dryRun = true
+	}
+}
+
+// WithAddressMetrics exports the transactions recorded per address for up to the given number of addresses, the first
+// ones recorded, aggregating the others so the cardinality of the metrics stays bounded.
+func WithAddressMetrics(limit int) Option {
+	return func(c *config) {
+		if limit > 0 {
+			c.addressMetrics = limit
+		}
+	}
+}
+
+// WithErrorReporter reports the blocks given up on after running out of retry attempts to the given reporter, along
+// with their number and hash.
+func WithErrorReporter(reporter ErrorReporter) Option {
+	return func(c *config) {
+		c.errorReporter = reporter
+	}
+}
+
+// WithHealth reports the health of the indexer, failing on the blocks it fails to index, and of every notifier,
+// failing on the notifications it fails to deliver, to the given registry.
+func WithHealth(registry *health.Registry) Option {
+	return func(c *config) {
+		c.health = registry
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the indexer and its notifier queues are registered with, the
+// custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
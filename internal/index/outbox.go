@@ -0,0 +1,131 @@
+package index
+
+import (
+	"context"
+	"slices"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// outboxBatchSize is the maximum number of outbox entries fetched at once.
+	outboxBatchSize = 100
+	// outboxPollInterval is how often an empty outbox is checked for entries regardless of new commits.
+	outboxPollInterval = time.Second
+)
+
+// Outbox reads and acknowledges the notifications persisted along with the committed blocks.
+type Outbox interface {
+	GetOutboxEntries(ctx context.Context, notifier string, limit int) ([]*store.OutboxEntry, error)
+	AckOutboxEntries(ctx context.Context, notifier string, ids []uint64) error
+}
+
+// outboxEntries returns the outbox entries of the matched block for every notifier.
+func (i *Index) outboxEntries(matched *matchedBlock) []*store.OutboxEntry {
+	var entries []*store.OutboxEntry
+	for q := range slices.Values(i.notifierQueues) {
+		for record := range slices.Values(matched.records) {
+			entries = append(entries, &store.OutboxEntry{Notifier: q.name, Tx: record})
+		}
+		if _, ok := q.notifier.(BlockNotifier); ok {
+			block := *matched.storeBlock
+			block.Outbox = nil
+			entries = append(entries, &store.OutboxEntry{Notifier: q.name, Block: &block})
+		}
+	}
+	return entries
+}
+
+// wakeOutboxDrainers signals the notifiers that new entries were committed to their outbox.
+func (i *Index) wakeOutboxDrainers() {
+	for q := range slices.Values(i.notifierQueues) {
+		select {
+		case q.wake <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// drainOutbox delivers the outbox entries of the notifier in order until the context is cancelled. Entries are
+// acknowledged once delivered, or once they fail maxAttempts times in which case they're dropped. Entries that
+// were delivered but not acknowledged, e.g. because of a crash, are delivered again. Entries held back by the
+// notification preferences are acknowledged once held, they're held in memory only.
+func (q *notifierQueue) drainOutbox(ctx context.Context, logger logging.Logger, outbox Outbox, maxAttempts int) {
+	for {
+		q.deliverReleased(ctx, logger, maxAttempts)
+		entries, err := outbox.GetOutboxEntries(ctx, q.name, outboxBatchSize)
+		if err != nil && ctx.Err() == nil {
+			logger.WithField("notifier", q.name).WithError(err).Error("Failed to get outbox entries")
+		}
+		if len(entries) == 0 {
+			select {
+			case <-ctx.Done():
+				return
+			case <-q.wake:
+			case <-q.releaseTimer():
+			case <-time.After(outboxPollInterval):
+			}
+			continue
+		}
+
+		ids := make([]uint64, 0, len(entries))
+		for entry := range slices.Values(entries) {
+			n := notification{tx: entry.Tx, block: entry.Block}
+			if !q.hold(ctx, n) {
+				q.deliverWithRetry(ctx, logger, n, maxAttempts)
+			}
+			if ctx.Err() != nil {
+				break
+			}
+			ids = append(ids, entry.ID)
+		}
+
+		if len(ids) > 0 {
+			// acknowledge what's been delivered even if we're shutting down, so it isn't delivered again
+			err = outbox.AckOutboxEntries(context.WithoutCancel(ctx), q.name, ids)
+			if err != nil {
+				logger.WithField("notifier", q.name).WithError(err).Error("Failed to acknowledge outbox entries")
+			}
+		}
+		if ctx.Err() != nil {
+			return
+		}
+	}
+}
+
+// deliverReleased delivers the due held notifications, retrying them like the outbox entries.
+func (q *notifierQueue) deliverReleased(ctx context.Context, logger logging.Logger, maxAttempts int) {
+	for ctx.Err() == nil {
+		n, ok := q.release()
+		if !ok {
+			return
+		}
+		q.deliverWithRetry(ctx, logger, n, maxAttempts)
+	}
+}
+
+// deliverWithRetry delivers the notification, retrying up to maxAttempts times before dropping it.
+func (q *notifierQueue) deliverWithRetry(ctx context.Context, logger logging.Logger, n notification, maxAttempts int) {
+	bo := backoff.WithContext(backoff.WithMaxRetries(newOutboxBackoffConfig(), uint64(maxAttempts-1)), ctx)
+	err := backoff.Retry(func() error {
+		return q.deliver(ctx, n)
+	}, bo)
+	if err != nil && ctx.Err() == nil {
+		q.logFailure(logger, n, err)
+		q.metrics.droppedNotifications.WithLabelValues(q.name).Inc()
+	}
+}
+
+func newOutboxBackoffConfig() *backoff.ExponentialBackOff {
+	return backoff.NewExponentialBackOff(
+		backoff.WithMaxElapsedTime(0),
+		backoff.WithMaxInterval(time.Second*30),
+		backoff.WithInitialInterval(time.Millisecond*500),
+		backoff.WithMultiplier(2),
+		backoff.WithRandomizationFactor(0.2),
+	)
+}
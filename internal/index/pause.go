@@ -0,0 +1,68 @@
+package index
+
+import (
+	"context"
+	"sync"
+)
+
+// pauseGate holds back indexing between blocks while paused.
+type pauseGate struct {
+	mu sync.Mutex
+	// resumed is nil unless paused, and closed on resume.
+	resumed chan struct{}
+}
+
+// Pause pauses indexing once the blocks being indexed are committed, until resumed. Received blocks are held back
+// rather than dropped, so indexing carries on from where it stopped. It reports whether indexing was running.
+func (i *Index) Pause() bool {
+	i.pause.mu.Lock()
+	defer i.pause.mu.Unlock()
+
+	if i.pause.resumed != nil {
+		return false
+	}
+	i.pause.resumed = make(chan struct{})
+	i.metrics.indexingPaused.Set(1)
+	i.logger.Info("Indexing paused")
+	return true
+}
+
+// Resume resumes paused indexing. It reports whether indexing was paused.
+func (i *Index) Resume() bool {
+	i.pause.mu.Lock()
+	defer i.pause.mu.Unlock()
+
+	if i.pause.resumed == nil {
+		return false
+	}
+	close(i.pause.resumed)
+	i.pause.resumed = nil
+	i.metrics.indexingPaused.Set(0)
+	i.logger.Info("Indexing resumed")
+	return true
+}
+
+// Paused reports whether indexing is paused.
+func (i *Index) Paused() bool {
+	i.pause.mu.Lock()
+	defer i.pause.mu.Unlock()
+
+	return i.pause.resumed != nil
+}
+
+// waitResumed blocks while indexing is paused. It returns false if the context is done first.
+func (i *Index) waitResumed(ctx context.Context) bool {
+	i.pause.mu.Lock()
+	resumed := i.pause.resumed
+	i.pause.mu.Unlock()
+
+	if resumed == nil {
+		return true
+	}
+	select {
+	case <-ctx.Done():
+		return false
+	case <-resumed:
+		return true
+	}
+}
@@ -0,0 +1,105 @@
+package index
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// maxHeldNotifications is the number of notifications each notifier holds back, new ones being dropped past it.
+const maxHeldNotifications = 10_000
+
+// heldNotification is a transaction notification held back by the notification preferences of its subscriptions
+// until its release time.
+type heldNotification struct {
+	notification
+	releaseAt time.Time
+}
+
+// route returns the time the transaction is notified by the given notifier at, according to the notification
+// preferences of the subscriptions of its addresses, and false if none of them wants it notified by the notifier.
+// The most eager subscription wins, so a transaction between two subscribed addresses is notified as soon as either
+// of them wants it. Transactions without subscriptions, recorded in full-block indexing mode, are notified at once.
+func (i *Index) route(ctx context.Context, notifier string, tx *store.TxRecord, now time.Time) (time.Time, bool) {
+	subs, err := i.subscriptionStore.GetSubscriptionsBatch(ctx, uniqueAddresses(tx.To, tx.From))
+	if err != nil {
+		// better notified against the preferences than not at all
+		i.logger.WithFields(logging.Fields{
+			"notifier": notifier,
+			"tx_hash":  tx.Hash,
+		}).WithError(err).Warn("Failed to get subscriptions for notification preferences, notifying at once")
+		return now, true
+	}
+	if len(subs) == 0 {
+		return now, true
+	}
+
+	var releaseAt time.Time
+	var notifies bool
+	for sub := range maps.Values(subs) {
+		if !sub.Preferences.Notifies(notifier, tx) {
+			continue
+		}
+		at := sub.Preferences.ReleaseAt(now, i.cfg.digestInterval)
+		if !notifies || at.Before(releaseAt) {
+			releaseAt = at
+		}
+		notifies = true
+	}
+	return releaseAt, notifies
+}
+
+// hold holds the transaction notification back, or skips it, as the notification preferences of its subscriptions
+// want, reporting whether it did. Released notifications and block notifications are never held.
+func (q *notifierQueue) hold(ctx context.Context, n notification) bool {
+	if n.tx == nil || n.released || q.route == nil {
+		return false
+	}
+	now := q.now()
+	releaseAt, notifies := q.route(ctx, q.name, n.tx, now)
+	switch {
+	case !notifies:
+		q.metrics.skippedNotifications.WithLabelValues(q.name).Inc()
+		return true
+	case !releaseAt.After(now):
+		return false
+	case len(q.held) >= maxHeldNotifications:
+		q.metrics.droppedNotifications.WithLabelValues(q.name).Inc()
+		return true
+	}
+
+	// held notifications are kept ordered by release time, the ones released at the same time in the order held
+	idx, _ := slices.BinarySearchFunc(q.held, releaseAt, func(h heldNotification, t time.Time) int {
+		if h.releaseAt.After(t) {
+			return 1
+		}
+		return -1
+	})
+	q.held = slices.Insert(q.held, idx, heldNotification{notification: n, releaseAt: releaseAt})
+	q.metrics.heldNotifications.WithLabelValues(q.name).Inc()
+	return true
+}
+
+// release returns the earliest held notification if it's due.
+func (q *notifierQueue) release() (notification, bool) {
+	if len(q.held) == 0 || q.held[0].releaseAt.After(q.now()) {
+		return notification{}, false
+	}
+	n := q.held[0].notification
+	n.released = true
+	q.held = slices.Delete(q.held, 0, 1)
+	q.metrics.heldNotifications.WithLabelValues(q.name).Dec()
+	return n, true
+}
+
+// releaseTimer returns a channel receiving once the earliest held notification is due, nil if none is held.
+func (q *notifierQueue) releaseTimer() <-chan time.Time {
+	if len(q.held) == 0 {
+		return nil
+	}
+	return time.After(q.held[0].releaseAt.Sub(q.now()))
+}
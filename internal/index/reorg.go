@@ -0,0 +1,266 @@
+package index
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// recentBlocks holds the last committed blocks by number, to detect the ones orphaned by reorganisations deeper than
+// the confirmation depth.
+type recentBlocks struct {
+	mu     sync.Mutex
+	size   int64
+	head   int64
+	blocks map[int64]*matchedBlock
+}
+
+func newRecentBlocks(size int) *recentBlocks {
+	return &recentBlocks{
+		size:   int64(size),
+		head:   -1,
+		blocks: make(map[int64]*matchedBlock, size),
+	}
+}
+
+func (r *recentBlocks) add(matched *matchedBlock) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	number := matched.storeBlock.Number
+	if number <= r.head-r.size {
+		// a retried block older than the window
+		return
+	}
+	r.blocks[number] = matched
+	if number > r.head {
+		for n := r.head - r.size + 1; n <= number-r.size; n++ {
+			delete(r.blocks, n)
+		}
+		r.head = number
+	}
+}
+
+func (r *recentBlocks) get(number int64) (*matchedBlock, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	matched, ok := r.blocks[number]
+	return matched, ok
+}
+
+// rewind returns the committed block of the given number and hash, if any, and moves the head below it so that the
+// blocks replacing it are checked against their committed parent.
+func (r *recentBlocks) rewind(number int64, hash string) (*matchedBlock, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if number <= r.head {
+		r.head = number - 1
+	}
+	matched, ok := r.blocks[number]
+	if !ok || matched.storeBlock.Hash != hash {
+		return nil, false
+	}
+	return matched, true
+}
+
+func (r *recentBlocks) remove(number int64) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	delete(r.blocks, number)
+}
+
+// orphaned returns the committed parent of the given block if the block doesn't descend from it. Only blocks
+// following the head are checked, retried blocks are committed out of order.
+func (r *recentBlocks) orphaned(block *store.Block) (*matchedBlock, bool) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if block.Number != r.head+1 || block.ParentHash == "" {
+		return nil, false
+	}
+	parent, ok := r.blocks[r.head]
+	if !ok || parent.storeBlock.Hash == block.ParentHash {
+		return nil, false
+	}
+	return parent, true
+}
+
+// removeOrphans removes the committed blocks orphaned by the given block before it's committed. If gap filling is
+// enabled, the canonical blocks replacing them are fetched, walking back until the chains meet, and indexed.
+func (i *Index) removeOrphans(ctx context.Context, block *store.Block) error {
+	parent, ok := i.recent.orphaned(block)
+	if !ok {
+		return nil
+	}
+
+	orphans := []*matchedBlock{parent}
+	var canonical []*eth.Block
+	if i.cfg.blockFetcher != nil {
+		for number := block.Number - 1; ; number-- {
+			fetched, err := i.cfg.blockFetcher.GetBlock(ctx, number)
+			if err != nil {
+				return fmt.Errorf("could not get canonical block %d: %w", number, err)
+			}
+			canonical = append(canonical, fetched)
+
+			previous, ok := i.recent.get(number - 1)
+			if !ok || previous.storeBlock.Hash == fetched.ParentHash {
+				break
+			}
+			orphans = append(orphans, previous)
+		}
+	}
+
+	i.logger.WithContext(ctx).WithFields(logging.Fields{
+		"block_number":   block.Number,
+		"orphaned_from":  orphans[len(orphans)-1].storeBlock.Number,
+		"orphaned_count": len(orphans),
+	}).Warn("Previously indexed blocks orphaned, removing their records")
+
+	for orphan := range slices.Values(orphans) {
+		err := i.removeBlock(ctx, orphan)
+		if err != nil {
+			return err
+		}
+	}
+
+	if i.cfg.blockFetcher == nil {
+		i.logger.WithContext(ctx).WithField("block_number", parent.storeBlock.Number).
+			Warn("Gap filling is disabled, the canonical block replacing the orphaned one isn't indexed")
+		return nil
+	}
+	for _, replacement := range slices.Backward(canonical) {
+		err := i.index(ctx, replacement)
+		if err != nil {
+			i.handleFailure(ctx, replacement, err)
+		}
+	}
+	return nil
+}
+
+// orphan removes the records of a committed block the reorg filter reports orphaned, if it's within the reorg window.
+func (i *Index) orphan(ctx context.Context, block *eth.Block) {
+	logger := i.logger.WithContext(ctx).WithFields(logging.Fields{
+		"block_number": block.Number,
+		"block_hash":   block.Hash,
+	})
+	if i.recent == nil {
+		logger.Warn("Confirmed block orphaned while the reorg window is disabled, its records are kept")
+		return
+	}
+
+	orphan, ok := i.recent.rewind(block.Number, block.Hash)
+	if !ok {
+		logger.Warn("Orphaned block isn't within the reorg window, its records are kept")
+		return
+	}
+	logger.Warn("Previously indexed block orphaned, removing its records")
+	err := i.removeBlock(ctx, orphan)
+	if err != nil {
+		logger.WithError(err).Error("Failed to remove orphaned block")
+	}
+}
+
+// removeBlock marks the records of the orphaned block as removed in the store and notifies them as removed.
+func (i *Index) removeBlock(ctx context.Context, orphan *matchedBlock) error {
+	removed := removedBlock(orphan)
+	if i.cfg.outbox != nil {
+		for q := range slices.Values(i.notifierQueues) {
+			for record := range slices.Values(removed.records) {
+				removed.storeBlock.Outbox = append(removed.storeBlock.Outbox, &store.OutboxEntry{Notifier: q.name, Tx: record})
+			}
+		}
+	}
+
+	err := i.txStore.RemoveBlock(ctx, removed.storeBlock)
+	if err != nil {
+		return fmt.Errorf("could not remove orphaned block %d: %w", orphan.storeBlock.Number, err)
+	}
+	i.recent.remove(orphan.storeBlock.Number)
+
+	if i.cfg.outbox != nil {
+		i.wakeOutboxDrainers()
+	} else {
+		for q := range slices.Values(i.notifierQueues) {
+			for record := range slices.Values(removed.records) {
+				q.enqueue(q.notifications, notification{tx: record})
+			}
+		}
+	}
+
+	i.metrics.orphanedBlocks.Inc()
+	i.metrics.removedTransactions.Add(float64(len(removed.records)))
+	return nil
+}
+
+// removedBlock returns a copy of the matched block holding removed copies of its records, each record copied once
+// however many addresses share it.
+func removedBlock(matched *matchedBlock) *matchedBlock {
+	txs := make(map[*store.TxRecord]*store.TxRecord, len(matched.records))
+	removedTx := func(record *store.TxRecord) *store.TxRecord {
+		copied, ok := txs[record]
+		if !ok {
+			c := *record
+			c.Removed = true
+			copied = &c
+			txs[record] = copied
+		}
+		return copied
+	}
+	records := make([]*store.TxRecord, 0, len(matched.records))
+	for record := range slices.Values(matched.records) {
+		records = append(records, removedTx(record))
+	}
+
+	block := matched.storeBlock
+	removed := &store.Block{
+		Number:               block.Number,
+		Hash:                 block.Hash,
+		ParentHash:           block.ParentHash,
+		AddrToTxs:            make(map[string][]*store.TxRecord, len(block.AddrToTxs)),
+		AddrToTokenTransfers: make(map[string][]*store.TokenTransferRecord, len(block.AddrToTokenTransfers)),
+		ContractToEvents:     make(map[string][]*store.EventRecord, len(block.ContractToEvents)),
+		// reverted by the store once the block is removed
+		TokenBalanceChanges: block.TokenBalanceChanges,
+	}
+	for addr, addrTxs := range block.AddrToTxs {
+		for tx := range slices.Values(addrTxs) {
+			removed.AddrToTxs[addr] = append(removed.AddrToTxs[addr], removedTx(tx))
+		}
+	}
+
+	transfers := make(map[*store.TokenTransferRecord]*store.TokenTransferRecord)
+	for addr, addrTransfers := range block.AddrToTokenTransfers {
+		for transfer := range slices.Values(addrTransfers) {
+			copied, ok := transfers[transfer]
+			if !ok {
+				c := *transfer
+				c.Removed = true
+				copied = &c
+				transfers[transfer] = copied
+			}
+			removed.AddrToTokenTransfers[addr] = append(removed.AddrToTokenTransfers[addr], copied)
+		}
+	}
+
+	for contract, events := range block.ContractToEvents {
+		for event := range slices.Values(events) {
+			c := *event
+			c.Removed = true
+			removed.ContractToEvents[contract] = append(removed.ContractToEvents[contract], &c)
+		}
+	}
+
+	return &matchedBlock{
+		storeBlock: removed,
+		records:    records,
+	}
+}
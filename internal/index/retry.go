@@ -0,0 +1,115 @@
+package index
+
+import (
+	"context"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	retryInitialInterval = time.Second
+	retryMaxInterval     = time.Minute
+)
+
+// failedBlock is a block waiting in the retry queue.
+type failedBlock struct {
+	block    *eth.Block
+	attempts int
+	err      error
+	retryAt  time.Time
+}
+
+// handleFailure logs the failed block and queues it for retry.
+func (i *Index) handleFailure(ctx context.Context, block *eth.Block, err error) {
+	i.logFailure(block, err)
+	i.scheduleRetry(ctx, &failedBlock{block: block, attempts: 1, err: err})
+}
+
+// scheduleRetry queues the failed block to be indexed again after a backoff, or dead-letters it if it has run out
+// of attempts or the retry queue is full.
+func (i *Index) scheduleRetry(ctx context.Context, failed *failedBlock) {
+	if failed.attempts >= i.cfg.retryAttempts {
+		i.deadLetter(ctx, failed)
+		return
+	}
+
+	failed.retryAt = time.Now().Add(min(retryInitialInterval<<(failed.attempts-1), retryMaxInterval))
+	select {
+	case i.retries <- failed:
+	default:
+		i.logger.WithField("block_number", failed.block.Number).Warn("Block retry queue is full")
+		i.deadLetter(ctx, failed)
+	}
+}
+
+// retryFailedBlocks indexes the queued failed blocks again once their backoff has elapsed, until the context is
+// cancelled.
+func (i *Index) retryFailedBlocks(ctx context.Context) {
+	for {
+		var failed *failedBlock
+		select {
+		case <-ctx.Done():
+			return
+		case failed = <-i.retries:
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(time.Until(failed.retryAt)):
+		}
+		if !i.waitResumed(ctx) {
+			return
+		}
+
+		i.metrics.blockRetries.Inc()
+		err := i.index(ctx, failed.block)
+		if err != nil {
+			i.logFailure(failed.block, err)
+			failed.attempts++
+			failed.err = err
+			i.scheduleRetry(ctx, failed)
+			continue
+		}
+		i.logger.WithFields(logging.Fields{
+			"block_number": failed.block.Number,
+			"attempts":     failed.attempts + 1,
+		}).Info("Indexed previously failed block")
+	}
+}
+
+// deadLetter records the block that could not be indexed so the gap can be repaired.
+func (i *Index) deadLetter(ctx context.Context, failed *failedBlock) {
+	i.metrics.deadLetterBlocks.Inc()
+	logger := i.logger.WithFields(logging.Fields{
+		"block_hash":   failed.block.Hash,
+		"block_number": failed.block.Number,
+		"attempts":     failed.attempts,
+	})
+	logger.WithError(failed.err).Error("Giving up on indexing block")
+	if i.cfg.errorReporter != nil {
+		i.cfg.errorReporter.ReportError(failed.err, map[string]any{
+			"block_hash":   failed.block.Hash,
+			"block_number": failed.block.Number,
+			"attempts":     failed.attempts,
+		})
+	}
+	if i.cfg.dryRun {
+		return
+	}
+
+	err := i.txStore.InsertDeadLetter(ctx, &store.DeadLetter{
+		BlockNumber: failed.block.Number,
+		BlockHash:   failed.block.Hash,
+		ParentHash:  failed.block.ParentHash,
+		Attempts:    failed.attempts,
+		Error:       failed.err.Error(),
+		FailedAt:    time.Now(),
+	})
+	if err != nil {
+		logger.WithError(err).Error("Failed to record dead letter block")
+	}
+}
@@ -0,0 +1,21 @@
+package index
+
+import (
+	"time"
+)
+
+// handling marks the indexing loop as handling a block, returning the func marking it idle again, so a hung loop can
+// be told apart from an idle one.
+func (i *Index) handling() func() {
+	i.handlingSince.Store(time.Now().UnixNano())
+	return func() {
+		i.handlingSince.Store(0)
+	}
+}
+
+// Stuck reports whether the indexing loop has been handling the same block for longer than the given duration, e.g.
+// waiting on a store write that never returns. An idle loop, waiting for blocks or to be resumed, isn't stuck.
+func (i *Index) Stuck(d time.Duration) bool {
+	since := i.handlingSince.Load()
+	return since != 0 && time.Since(time.Unix(0, since)) > d
+}
@@ -0,0 +1,170 @@
+package index
+
+import (
+	"cmp"
+	"math/big"
+	"slices"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+const (
+	// transferEventTopic is the keccak256 hash of the `Transfer(address,address,uint256)` event signature shared by
+	// ERC-20 and ERC-721 tokens. The two are told apart by the number of indexed topics: ERC-721 indexes the tokenId.
+	transferEventTopic = "0xddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef"
+	// zeroAddress is the sender of minted tokens and the recipient of burnt ones, holding none of them.
+	zeroAddress = "0x0000000000000000000000000000000000000000"
+)
+
+// parseTokenTransfer decodes the given log into a token transfer record. It returns false if the log is not a
+// standard ERC-20 or ERC-721 Transfer event.
+func parseTokenTransfer(log *eth.Log) (*store.TokenTransferRecord, bool) {
+	if len(log.Topics) < 3 || !strings.EqualFold(log.Topics[0], transferEventTopic) {
+		return nil, false
+	}
+
+	from, ok := topicToAddress(log.Topics[1])
+	if !ok {
+		return nil, false
+	}
+	to, ok := topicToAddress(log.Topics[2])
+	if !ok {
+		return nil, false
+	}
+
+	record := &store.TokenTransferRecord{
+		TxHash:   log.TxHash,
+		LogIndex: log.LogIndex,
+		Token:    log.Address,
+		From:     from,
+		To:       to,
+	}
+	switch len(log.Topics) {
+	case 3:
+		amount, ok := hexToDecimal(log.Data)
+		if !ok {
+			return nil, false
+		}
+		record.Standard = store.TokenStandardERC20
+		record.Amount = amount
+	case 4:
+		tokenID, ok := hexToDecimal(log.Topics[3])
+		if !ok {
+			return nil, false
+		}
+		record.Standard = store.TokenStandardERC721
+		record.TokenID = tokenID
+	default:
+		return nil, false
+	}
+
+	return record, true
+}
+
+// topicToAddress extracts the address from a 32 bytes left-padded topic.
+func topicToAddress(topic string) (string, bool) {
+	topic = strings.TrimPrefix(topic, "0x")
+	if len(topic) != 64 {
+		return "", false
+	}
+	return ethaddr.Normalize(topic[24:])
+}
+
+func hexToDecimal(s string) (string, bool) {
+	s = strings.TrimPrefix(s, "0x")
+	if s == "" {
+		return "", false
+	}
+	n, ok := new(big.Int).SetString(s, 16)
+	if !ok {
+		return "", false
+	}
+	return n.String(), true
+}
+
+// matchTokenTransfers scans the block receipts for token transfers involving the subscribed addresses.
+// It returns the number of matched transfers along with the per address records.
+func matchTokenTransfers(subs map[string]*store.Subscription, block *eth.Block) (map[string][]*store.TokenTransferRecord, int) {
+	addrToTransfers := make(map[string][]*store.TokenTransferRecord)
+	var totalTransfers int
+	for receipt := range slices.Values(block.Receipts) {
+		for log := range slices.Values(receipt.Logs) {
+			record, ok := parseTokenTransfer(log)
+			if !ok {
+				continue
+			}
+
+			var matched bool
+			for addr := range slices.Values(uniqueAddresses(record.To, record.From)) {
+				if _, ok := subs[addr]; ok {
+					addrToTransfers[addr] = append(addrToTransfers[addr], record)
+					matched = true
+				}
+			}
+			if matched {
+				record.BlockNumber = block.Number
+				record.BlockHash = block.Hash
+				totalTransfers++
+			}
+		}
+	}
+
+	return addrToTransfers, totalTransfers
+}
+
+// tokenBalanceChanges returns the net balance changes made by the ERC-20 transfers of the block to the holders of the
+// subscribed tokens whose holders are tracked, ordered by token and holder.
+func tokenBalanceChanges(subs map[string]*store.Subscription, block *eth.Block) []*store.TokenBalanceChange {
+	type holding struct {
+		token  string
+		holder string
+	}
+	deltas := make(map[holding]*big.Int)
+	add := func(token, holder string, amount *big.Int) {
+		if holder == zeroAddress {
+			return
+		}
+		key := holding{token: token, holder: holder}
+		if deltas[key] == nil {
+			deltas[key] = new(big.Int)
+		}
+		deltas[key].Add(deltas[key], amount)
+	}
+	for receipt := range slices.Values(block.Receipts) {
+		for log := range slices.Values(receipt.Logs) {
+			record, ok := parseTokenTransfer(log)
+			if !ok || record.Standard != store.TokenStandardERC20 {
+				continue
+			}
+			if sub, ok := subs[record.Token]; !ok || !sub.TrackHolders {
+				continue
+			}
+			amount, ok := new(big.Int).SetString(record.Amount, 10)
+			if !ok {
+				continue
+			}
+			add(record.Token, record.From, new(big.Int).Neg(amount))
+			add(record.Token, record.To, amount)
+		}
+	}
+
+	var changes []*store.TokenBalanceChange
+	for key, delta := range deltas {
+		// e.g. a holder sending tokens to itself
+		if delta.Sign() == 0 {
+			continue
+		}
+		changes = append(changes, &store.TokenBalanceChange{
+			Token:  key.token,
+			Holder: key.holder,
+			Delta:  delta,
+		})
+	}
+	slices.SortFunc(changes, func(a, b *store.TokenBalanceChange) int {
+		return cmp.Or(strings.Compare(a.Token, b.Token), strings.Compare(a.Holder, b.Holder))
+	})
+	return changes
+}
@@ -0,0 +1,96 @@
+// Package keccak implements the legacy Keccak-256 hash used by Ethereum, which differs from the standardised SHA3-256
+// only in its padding.
+package keccak
+
+import (
+	"encoding/binary"
+	"math/bits"
+)
+
+const (
+	// rate is the number of bytes absorbed per permutation for a 256 bits output.
+	rate = 136
+)
+
+var roundConstants = [24]uint64{
+	0x0000000000000001, 0x0000000000008082, 0x800000000000808a, 0x8000000080008000,
+	0x000000000000808b, 0x0000000080000001, 0x8000000080008081, 0x8000000000008009,
+	0x000000000000008a, 0x0000000000000088, 0x0000000080008009, 0x000000008000000a,
+	0x000000008000808b, 0x800000000000008b, 0x8000000000008089, 0x8000000000008003,
+	0x8000000000008002, 0x8000000000000080, 0x000000000000800a, 0x800000008000000a,
+	0x8000000080008081, 0x8000000000008080, 0x0000000080000001, 0x8000000080008008,
+}
+
+// rotationOffsets are indexed by x + 5*y.
+var rotationOffsets = [25]int{
+	0, 1, 62, 28, 27,
+	36, 44, 6, 55, 20,
+	3, 10, 43, 25, 39,
+	41, 45, 15, 21, 8,
+	18, 2, 61, 56, 14,
+}
+
+// Sum256 returns the Keccak-256 digest of the data.
+func Sum256(data []byte) [32]byte {
+	var state [25]uint64
+
+	for len(data) >= rate {
+		absorb(&state, data[:rate])
+		data = data[rate:]
+	}
+
+	// pad the final block using the original keccak multi-rate padding (0x01 ... 0x80)
+	var last [rate]byte
+	copy(last[:], data)
+	last[len(data)] ^= 0x01
+	last[rate-1] ^= 0x80
+	absorb(&state, last[:])
+
+	var out [32]byte
+	for i := range 4 {
+		binary.LittleEndian.PutUint64(out[i*8:], state[i])
+	}
+	return out
+}
+
+func absorb(state *[25]uint64, block []byte) {
+	for i := range rate / 8 {
+		state[i] ^= binary.LittleEndian.Uint64(block[i*8:])
+	}
+	permute(state)
+}
+
+// permute applies the Keccak-f[1600] permutation.
+func permute(a *[25]uint64) {
+	var c, d [5]uint64
+	var b [25]uint64
+	for round := range 24 {
+		// θ step
+		for x := range 5 {
+			c[x] = a[x] ^ a[x+5] ^ a[x+10] ^ a[x+15] ^ a[x+20]
+		}
+		for x := range 5 {
+			d[x] = c[(x+4)%5] ^ bits.RotateLeft64(c[(x+1)%5], 1)
+		}
+		for i := range 25 {
+			a[i] ^= d[i%5]
+		}
+
+		// ρ and π steps
+		for x := range 5 {
+			for y := range 5 {
+				b[y+5*((2*x+3*y)%5)] = bits.RotateLeft64(a[x+5*y], rotationOffsets[x+5*y])
+			}
+		}
+
+		// χ step
+		for y := range 5 {
+			for x := range 5 {
+				a[x+5*y] = b[x+5*y] ^ (^b[(x+1)%5+5*y] & b[(x+2)%5+5*y])
+			}
+		}
+
+		// ι step
+		a[0] ^= roundConstants[round]
+	}
+}
@@ -0,0 +1,42 @@
+package keccak_test
+
+import (
+	"encoding/hex"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedisam/ethtxparser/internal/keccak"
+)
+
+func TestSum256(t *testing.T) {
+	tests := map[string]struct {
+		input    string
+		expected string
+	}{
+		"empty input": {
+			input:    "",
+			expected: "c5d2460186f7233c927e7db2dcc703c0e500b653ca82273b7bfad8045d85a470",
+		},
+		"short input": {
+			input:    "abc",
+			expected: "4e03657aea45a94fc7d47ba826c8d667c0d1e6e33a64a036ec44f58fa12d6c45",
+		},
+		"erc20 transfer event signature": {
+			input:    "Transfer(address,address,uint256)",
+			expected: "ddf252ad1be2c89b69c2b068fc378daa952ba7f163c4a11628f55a4df523b3ef",
+		},
+		"input longer than the rate": {
+			input:    strings.Repeat("a", 200),
+			expected: "96ea54061def936c4be90b518992fdc6f12f535068a256229aca54267b4d084d",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			sum := keccak.Sum256([]byte(test.input))
+			assert.Equal(t, test.expected, hex.EncodeToString(sum[:]))
+		})
+	}
+}
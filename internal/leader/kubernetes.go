@@ -0,0 +1,230 @@
+package leader
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+const (
+	// serviceAccountDir holds the credentials of the pod's service account.
+	serviceAccountDir = "/var/run/secrets/kubernetes.io/serviceaccount"
+	// microTimeFormat is the format of the lease times.
+	microTimeFormat = "2006-01-02T15:04:05.000000Z07:00"
+)
+
+// KubernetesLease is a Lease backed by a coordination.k8s.io/v1 Lease object, updated with optimistic concurrency
+// so only one replica acquires it.
+type KubernetesLease struct {
+	httpClient *http.Client
+	url        string
+	tokenPath  string
+	namespace  string
+	name       string
+	identity   string
+}
+
+// NewKubernetesLease returns the lease of the given name, in the given namespace of the cluster whose API server is at
+// apiURL, held by the given identity. The bearer token is read from tokenPath on every request, as it's rotated.
+func NewKubernetesLease(httpClient *http.Client, apiURL, tokenPath, namespace, name, identity string) *KubernetesLease {
+	return &KubernetesLease{
+		httpClient: httpClient,
+		url:        fmt.Sprintf("%s/apis/coordination.k8s.io/v1/namespaces/%s/leases", strings.TrimSuffix(apiURL, "/"), namespace),
+		tokenPath:  tokenPath,
+		namespace:  namespace,
+		name:       name,
+		identity:   identity,
+	}
+}
+
+// NewInClusterLease returns the lease of the given name held by the given identity, in the cluster the process runs
+// in, authenticated as the pod's service account. The namespace of the pod is used if namespace is empty.
+func NewInClusterLease(namespace, name, identity string) (*KubernetesLease, error) {
+	host, port := os.Getenv("KUBERNETES_SERVICE_HOST"), os.Getenv("KUBERNETES_SERVICE_PORT")
+	if host == "" || port == "" {
+		return nil, errors.New("not running in a Kubernetes cluster, KUBERNETES_SERVICE_HOST and KUBERNETES_SERVICE_PORT aren't set")
+	}
+	if namespace == "" {
+		data, err := os.ReadFile(filepath.Join(serviceAccountDir, "namespace"))
+		if err != nil {
+			return nil, fmt.Errorf("could not read the pod's namespace: %w", err)
+		}
+		namespace = strings.TrimSpace(string(data))
+	}
+	ca, err := os.ReadFile(filepath.Join(serviceAccountDir, "ca.crt"))
+	if err != nil {
+		return nil, fmt.Errorf("could not read the cluster's CA certificate: %w", err)
+	}
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(ca) {
+		return nil, errors.New("invalid cluster CA certificate")
+	}
+
+	httpClient := &http.Client{
+		Timeout: time.Second * 10,
+		Transport: &http.Transport{
+			TLSClientConfig: &tls.Config{RootCAs: pool},
+		},
+	}
+	apiURL := "https://" + net.JoinHostPort(host, port)
+	return NewKubernetesLease(httpClient, apiURL, filepath.Join(serviceAccountDir, "token"), namespace, name, identity), nil
+}
+
+type lease struct {
+	APIVersion string        `json:"apiVersion"`
+	Kind       string        `json:"kind"`
+	Metadata   leaseMetadata `json:"metadata"`
+	Spec       leaseSpec     `json:"spec"`
+}
+
+type leaseMetadata struct {
+	Name            string `json:"name"`
+	Namespace       string `json:"namespace"`
+	ResourceVersion string `json:"resourceVersion,omitempty"`
+}
+
+type leaseSpec struct {
+	HolderIdentity       string `json:"holderIdentity,omitempty"`
+	LeaseDurationSeconds int    `json:"leaseDurationSeconds,omitempty"`
+	AcquireTime          string `json:"acquireTime,omitempty"`
+	RenewTime            string `json:"renewTime,omitempty"`
+	LeaseTransitions     int    `json:"leaseTransitions,omitempty"`
+}
+
+// TryAcquire creates the lease if it doesn't exist, renews it if it's held by this replica, or takes it over if it's
+// expired or released. Losing a race with another replica isn't an error, the lease just isn't held.
+func (l *KubernetesLease) TryAcquire(ctx context.Context, duration time.Duration) (bool, error) {
+	current, err := l.get(ctx)
+	if err != nil {
+		return false, err
+	}
+	now := time.Now()
+	if current == nil {
+		return l.write(ctx, http.MethodPost, l.url, &lease{
+			APIVersion: "coordination.k8s.io/v1",
+			Kind:       "Lease",
+			Metadata:   leaseMetadata{Name: l.name, Namespace: l.namespace},
+			Spec: leaseSpec{
+				HolderIdentity:       l.identity,
+				LeaseDurationSeconds: int(duration.Seconds()),
+				AcquireTime:          now.Format(microTimeFormat),
+				RenewTime:            now.Format(microTimeFormat),
+			},
+		})
+	}
+
+	spec := &current.Spec
+	if spec.HolderIdentity != l.identity {
+		if spec.HolderIdentity != "" && !expired(spec, now) {
+			return false, nil
+		}
+		spec.HolderIdentity = l.identity
+		spec.AcquireTime = now.Format(microTimeFormat)
+		spec.LeaseTransitions++
+	}
+	spec.LeaseDurationSeconds = int(duration.Seconds())
+	spec.RenewTime = now.Format(microTimeFormat)
+	return l.write(ctx, http.MethodPut, l.url+"/"+l.name, current)
+}
+
+// Release clears the holder of the lease if it's held by this replica.
+func (l *KubernetesLease) Release(ctx context.Context) error {
+	current, err := l.get(ctx)
+	if err != nil {
+		return err
+	}
+	if current == nil || current.Spec.HolderIdentity != l.identity {
+		return nil
+	}
+	current.Spec.HolderIdentity = ""
+	current.Spec.AcquireTime = ""
+	current.Spec.RenewTime = ""
+	_, err = l.write(ctx, http.MethodPut, l.url+"/"+l.name, current)
+	return err
+}
+
+// get returns the lease, or nil if it doesn't exist.
+func (l *KubernetesLease) get(ctx context.Context) (*lease, error) {
+	resp, err := l.do(ctx, http.MethodGet, l.url+"/"+l.name, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("could not get lease: received unexpected status: %s", resp.Status)
+	}
+
+	var current lease
+	err = json.NewDecoder(resp.Body).Decode(&current)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode lease: %w", err)
+	}
+	return &current, nil
+}
+
+// write creates or updates the lease, reporting false if another replica changed it first.
+func (l *KubernetesLease) write(ctx context.Context, method, url string, updated *lease) (bool, error) {
+	body, err := json.Marshal(updated)
+	if err != nil {
+		return false, fmt.Errorf("could not marshal lease: %w", err)
+	}
+	resp, err := l.do(ctx, method, url, body)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusCreated:
+		return true, nil
+	case http.StatusConflict:
+		// created or updated by another replica since it was read
+		return false, nil
+	default:
+		return false, fmt.Errorf("could not write lease: received unexpected status: %s", resp.Status)
+	}
+}
+
+func (l *KubernetesLease) do(ctx context.Context, method, url string, body []byte) (*http.Response, error) {
+	token, err := os.ReadFile(l.tokenPath)
+	if err != nil {
+		return nil, fmt.Errorf("could not read service account token: %w", err)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+strings.TrimSpace(string(token)))
+	req.Header.Set("Accept", "application/json")
+	if body != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+
+	resp, err := l.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not send request: %w", err)
+	}
+	return resp, nil
+}
+
+// expired reports whether the lease hasn't been renewed within its duration.
+func expired(spec *leaseSpec, now time.Time) bool {
+	renewed, err := time.Parse(microTimeFormat, spec.RenewTime)
+	if err != nil {
+		return true
+	}
+	return now.After(renewed.Add(time.Duration(spec.LeaseDurationSeconds) * time.Second))
+}
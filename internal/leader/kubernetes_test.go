@@ -0,0 +1,113 @@
+package leader_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/leader"
+)
+
+// fakeLeases serves a single lease the way the Kubernetes API server does, rejecting stale updates.
+type fakeLeases struct {
+	mu      sync.Mutex
+	lease   map[string]any
+	version int
+}
+
+func (f *fakeLeases) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	if r.Header.Get("Authorization") != "Bearer dummy-token" {
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+
+	switch r.Method {
+	case http.MethodGet:
+		if f.lease == nil {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		_ = json.NewEncoder(w).Encode(f.lease)
+		return
+	case http.MethodPost:
+		if f.lease != nil {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		_ = json.NewDecoder(r.Body).Decode(&f.lease)
+	case http.MethodPut:
+		var updated map[string]any
+		_ = json.NewDecoder(r.Body).Decode(&updated)
+		if f.lease == nil || updated["metadata"].(map[string]any)["resourceVersion"] != strconv.Itoa(f.version) {
+			w.WriteHeader(http.StatusConflict)
+			return
+		}
+		f.lease = updated
+	}
+	f.version++
+	f.lease["metadata"].(map[string]any)["resourceVersion"] = strconv.Itoa(f.version)
+	_ = json.NewEncoder(w).Encode(f.lease)
+}
+
+func (f *fakeLeases) holder() any {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.lease["spec"].(map[string]any)["holderIdentity"]
+}
+
+func TestKubernetesLease(t *testing.T) {
+	fake := &fakeLeases{}
+	srv := httptest.NewServer(fake)
+	defer srv.Close()
+	tokenPath := filepath.Join(t.TempDir(), "token")
+	err := os.WriteFile(tokenPath, []byte("dummy-token\n"), 0o600)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	replica1 := leader.NewKubernetesLease(srv.Client(), srv.URL, tokenPath, "default", "ethtxparser", "replica-1")
+	replica2 := leader.NewKubernetesLease(srv.Client(), srv.URL, tokenPath, "default", "ethtxparser", "replica-2")
+
+	// the first replica creates the lease, the second can't take it over until it's released
+	held, err := replica1.TryAcquire(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+	held, err = replica2.TryAcquire(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.False(t, held)
+	held, err = replica1.TryAcquire(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held, "lease not renewed")
+	assert.Equal(t, "replica-1", fake.holder())
+
+	err = replica2.Release(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, "replica-1", fake.holder(), "lease released by a replica not holding it")
+	err = replica1.Release(ctx)
+	require.NoError(t, err)
+	assert.Nil(t, fake.holder())
+
+	held, err = replica2.TryAcquire(ctx, time.Second)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, "replica-2", fake.holder())
+
+	// an expired lease is taken over
+	time.Sleep(time.Millisecond * 1100)
+	held, err = replica1.TryAcquire(ctx, time.Minute)
+	require.NoError(t, err)
+	assert.True(t, held)
+	assert.Equal(t, "replica-1", fake.holder())
+	assert.EqualValues(t, 2, fake.lease["spec"].(map[string]any)["leaseTransitions"])
+}
@@ -0,0 +1,191 @@
+// Package leader elects the replica following the node and indexing its blocks among the replicas of a horizontally
+// scaled deployment, the others only serving the API until they take over from it.
+package leader
+
+import (
+	"context"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultLeaseDuration is the default time a lease is held for without being renewed, before the followers take it
+	// over.
+	DefaultLeaseDuration = time.Second * 15
+
+	// releaseTimeout bounds the time spent releasing the lease on shutdown.
+	releaseTimeout = time.Second * 5
+)
+
+// Lease is a lock held by a single replica at a time, until it expires unless renewed.
+type Lease interface {
+	// TryAcquire acquires the lease for the given duration, or renews it if it's already held by this replica,
+	// reporting whether it's held.
+	TryAcquire(ctx context.Context, duration time.Duration) (bool, error)
+	// Release gives up the lease if it's held by this replica, so it can be taken over straight away.
+	Release(ctx context.Context) error
+}
+
+// Elector acquires and keeps renewing a lease for as long as its replica leads.
+type Elector struct {
+	logger *logrus.Logger
+	lease  Lease
+	cfg    *config
+}
+
+type config struct {
+	leaseDuration time.Duration
+}
+
+// renewDeadline is the time the leader has to renew the lease since the start of its last successful renewal, after
+// which it stops leading. It's a third short of the lease duration, like the RenewDeadline of client-go, so the leader
+// stops before the lease may be taken over even though its clock and the followers' may drift.
+func (c *config) renewDeadline() time.Duration {
+	return c.leaseDuration * 2 / 3
+}
+
+// retryPeriod is how often the leader renews the lease and the followers try to acquire it.
+func (c *config) retryPeriod() time.Duration {
+	return c.leaseDuration / 6
+}
+
+type Option func(*config)
+
+// WithLeaseDuration sets the time the lease is held for without being renewed. It's renewed every sixth of it, and
+// retried as often by the followers. The leader stops leading once it's not renewed within two thirds of it.
+func WithLeaseDuration(d time.Duration) Option {
+	return func(c *config) {
+		if d > 0 {
+			c.leaseDuration = d
+		}
+	}
+}
+
+func NewElector(logger *logrus.Logger, lease Lease, opts ...Option) *Elector {
+	cfg := &config{
+		leaseDuration: DefaultLeaseDuration,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Elector{
+		logger: logger,
+		lease:  lease,
+		cfg:    cfg,
+	}
+}
+
+// Lead blocks until the lease is acquired, then keeps renewing it in the background until the context is done, when
+// it's released. It returns a channel closed once the lease is lost, because it couldn't be renewed within the renew
+// deadline, or nil if the context is done before the lease is acquired. The channel is closed as soon as the deadline
+// passes, even while a renewal is still in flight.
+func (e *Elector) Lead(ctx context.Context) <-chan struct{} {
+	retry := time.NewTicker(e.cfg.retryPeriod())
+	defer retry.Stop()
+	var acquired time.Time
+	for {
+		acquired = time.Now()
+		if e.tryAcquire(ctx) {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-retry.C:
+		}
+	}
+	leading.Set(1)
+	e.logger.Info("Acquired the leader lease, following the node")
+
+	lost := make(chan struct{})
+	var once sync.Once
+	lose := func() {
+		once.Do(func() {
+			leading.Set(0)
+			close(lost)
+		})
+	}
+	expiry := time.AfterFunc(time.Until(acquired.Add(e.cfg.renewDeadline())), lose)
+	go func() {
+		defer lose()
+		defer expiry.Stop()
+		e.renew(ctx, acquired, expiry, lost)
+	}()
+	return lost
+}
+
+// renew renews the lease until the context is done or it's lost, the expiry timer closing lost once the renew
+// deadline passes. Renewals are timed from the start of their request, the latest time this replica can be sure the
+// lease was renewed at, and bounded by the deadline.
+func (e *Elector) renew(ctx context.Context, renewed time.Time, expiry *time.Timer, lost <-chan struct{}) {
+	t := time.NewTicker(e.cfg.retryPeriod())
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			select {
+			case <-lost:
+				// lost before shutting down, it's no longer ours to release
+			default:
+				e.release()
+			}
+			return
+		case <-lost:
+			e.logger.Error("Lost the leader lease")
+			return
+		case <-t.C:
+		}
+
+		deadline := renewed.Add(e.cfg.renewDeadline())
+		start := time.Now()
+		reqCtx, cancel := context.WithDeadline(ctx, deadline)
+		held, err := e.lease.TryAcquire(reqCtx, e.cfg.leaseDuration)
+		cancel()
+		if err == nil && held && expiry.Stop() {
+			renewed = start
+			expiry.Reset(time.Until(renewed.Add(e.cfg.renewDeadline())))
+			continue
+		}
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			leaseErrors.Inc()
+			e.logger.WithError(err).Warn("Failed to renew the leader lease")
+			if time.Now().Before(deadline) {
+				// may still be renewed before the deadline
+				continue
+			}
+		}
+		e.logger.Error("Lost the leader lease")
+		return
+	}
+}
+
+// release gives up the lease on shutdown, so a follower can take it over straight away.
+func (e *Elector) release() {
+	releaseCtx, cancel := context.WithTimeout(context.Background(), releaseTimeout)
+	defer cancel()
+	err := e.lease.Release(releaseCtx)
+	if err != nil {
+		e.logger.WithError(err).Warn("Failed to release the leader lease")
+		return
+	}
+	e.logger.Info("Released the leader lease")
+}
+
+func (e *Elector) tryAcquire(ctx context.Context) bool {
+	ctx, cancel := context.WithTimeout(ctx, e.cfg.renewDeadline())
+	defer cancel()
+	held, err := e.lease.TryAcquire(ctx, e.cfg.leaseDuration)
+	if err != nil {
+		leaseErrors.Inc()
+		e.logger.WithError(err).Warn("Failed to acquire the leader lease")
+		return false
+	}
+	return held
+}
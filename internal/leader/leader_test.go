@@ -0,0 +1,160 @@
+package leader_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/leader"
+	"github.com/hedisam/ethtxparser/internal/leader/mocks"
+)
+
+//go:generate moq -out mocks/lease.go -pkg mocks -skip-ensure . Lease
+
+func TestLead(t *testing.T) {
+	tests := map[string]struct {
+		// acquire returns the result of the attempt of the given number, starting from 1
+		acquire         func(attempt int32) (bool, error)
+		expectedLost    bool
+		expectedRelease bool
+	}{
+		"acquired after a failure and released on shutdown": {
+			acquire: func(attempt int32) (bool, error) {
+				if attempt == 1 {
+					return false, errors.New("dummy error")
+				}
+				return true, nil
+			},
+			expectedRelease: true,
+		},
+		"taken over by another replica": {
+			acquire: func(attempt int32) (bool, error) {
+				return attempt < 3, nil
+			},
+			expectedLost: true,
+		},
+		"not renewed before expiring": {
+			acquire: func(attempt int32) (bool, error) {
+				if attempt > 1 {
+					return false, errors.New("dummy error")
+				}
+				return true, nil
+			},
+			expectedLost: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts atomic.Int32
+			leaseMock := &mocks.LeaseMock{
+				TryAcquireFunc: func(ctx context.Context, duration time.Duration) (bool, error) {
+					return test.acquire(attempts.Add(1))
+				},
+				ReleaseFunc: func(ctx context.Context) error {
+					return nil
+				},
+			}
+			elector := leader.NewElector(logrus.New(), leaseMock, leader.WithLeaseDuration(time.Millisecond*60))
+
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			lost := elector.Lead(ctx)
+			require.NotNil(t, lost)
+
+			select {
+			case <-lost:
+				assert.True(t, test.expectedLost, "lease lost")
+			case <-time.After(time.Millisecond * 200):
+				assert.False(t, test.expectedLost, "lease not lost")
+				cancel()
+				<-lost
+			}
+			assert.Equal(t, test.expectedRelease, len(leaseMock.ReleaseCalls()) == 1)
+		})
+	}
+}
+
+func TestLeadCancelled(t *testing.T) {
+	leaseMock := &mocks.LeaseMock{
+		TryAcquireFunc: func(ctx context.Context, duration time.Duration) (bool, error) {
+			return false, nil
+		},
+	}
+	elector := leader.NewElector(logrus.New(), leaseMock, leader.WithLeaseDuration(time.Millisecond*30))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	assert.Nil(t, elector.Lead(ctx))
+	assert.GreaterOrEqual(t, len(leaseMock.TryAcquireCalls()), 2)
+}
+
+func TestLeadLostWhileRenewing(t *testing.T) {
+	const leaseDuration = time.Millisecond * 300
+	release := make(chan struct{})
+	defer close(release)
+	var attempts atomic.Int32
+	leaseMock := &mocks.LeaseMock{
+		TryAcquireFunc: func(ctx context.Context, duration time.Duration) (bool, error) {
+			if attempts.Add(1) == 1 {
+				return true, nil
+			}
+			// simulate a renewal hanging regardless of its deadline
+			<-release
+			return true, nil
+		},
+	}
+	elector := leader.NewElector(logrus.New(), leaseMock, leader.WithLeaseDuration(leaseDuration))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	lost := elector.Lead(ctx)
+	require.NotNil(t, lost)
+
+	// the lease is lost at the renew deadline, before it expires and may be taken over
+	select {
+	case <-lost:
+		assert.Less(t, time.Since(start), leaseDuration)
+	case <-time.After(leaseDuration):
+		t.Fatal("lease not lost before expiring")
+	}
+}
+
+func TestLeadBoundsRenewals(t *testing.T) {
+	var attempts atomic.Int32
+	deadlines := make(chan time.Time, 1)
+	leaseMock := &mocks.LeaseMock{
+		TryAcquireFunc: func(ctx context.Context, duration time.Duration) (bool, error) {
+			if attempts.Add(1) == 1 {
+				return true, nil
+			}
+			deadline, ok := ctx.Deadline()
+			require.True(t, ok)
+			select {
+			case deadlines <- deadline:
+			default:
+			}
+			<-ctx.Done()
+			return false, ctx.Err()
+		},
+	}
+	elector := leader.NewElector(logrus.New(), leaseMock, leader.WithLeaseDuration(time.Millisecond*300))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	start := time.Now()
+	lost := elector.Lead(ctx)
+	require.NotNil(t, lost)
+
+	// the renewal is given up on at the renew deadline, timed from the start of the acquisition
+	deadline := <-deadlines
+	assert.WithinDuration(t, start.Add(time.Millisecond*200), deadline, time.Millisecond*20)
+	<-lost
+}
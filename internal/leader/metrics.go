@@ -0,0 +1,18 @@
+package leader
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+var (
+	leading = custompromauto.Auto().NewGauge(prometheus.GaugeOpts{
+		Name: "ethtxparser_leader",
+		Help: "1 while this replica holds the leader lease, following the node and indexing, 0 otherwise",
+	})
+	leaseErrors = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
+		Name: "ethtxparser_leader_lease_errors_total",
+		Help: "Number of failed attempts to acquire or renew the leader lease",
+	})
+)
@@ -0,0 +1,107 @@
+package logging
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Fields are the structured fields of a log entry.
+type Fields map[string]any
+
+// Logger is the minimal structured logger the eth, index and rest packages log through, so embedders can plug their
+// own. Logrus and slog loggers are adapted with Logrus and Slog.
+type Logger interface {
+	WithField(key string, value any) Logger
+	WithFields(fields Fields) Logger
+	WithError(err error) Logger
+	// WithContext returns a logger whose entries carry the context, logging its request ID if any.
+	WithContext(ctx context.Context) Logger
+	Debug(args ...any)
+	Info(args ...any)
+	Warn(args ...any)
+	Error(args ...any)
+}
+
+// Logrus adapts a logrus logger, or entry, to Logger.
+func Logrus(logger logrus.FieldLogger) Logger {
+	switch l := logger.(type) {
+	case *logrus.Logger:
+		return &logrusLogger{entry: logrus.NewEntry(l)}
+	case *logrus.Entry:
+		return &logrusLogger{entry: l}
+	default:
+		return &logrusLogger{entry: logger.WithFields(nil)}
+	}
+}
+
+type logrusLogger struct {
+	entry *logrus.Entry
+}
+
+func (l *logrusLogger) WithField(key string, value any) Logger {
+	return &logrusLogger{entry: l.entry.WithField(key, value)}
+}
+
+func (l *logrusLogger) WithFields(fields Fields) Logger {
+	return &logrusLogger{entry: l.entry.WithFields(logrus.Fields(fields))}
+}
+
+func (l *logrusLogger) WithError(err error) Logger {
+	return &logrusLogger{entry: l.entry.WithError(err)}
+}
+
+func (l *logrusLogger) WithContext(ctx context.Context) Logger {
+	return &logrusLogger{entry: l.entry.WithContext(ctx)}
+}
+
+func (l *logrusLogger) Debug(args ...any) { l.entry.Debug(args...) }
+func (l *logrusLogger) Info(args ...any)  { l.entry.Info(args...) }
+func (l *logrusLogger) Warn(args ...any)  { l.entry.Warn(args...) }
+func (l *logrusLogger) Error(args ...any) { l.entry.Error(args...) }
+
+// Slog adapts a slog logger to Logger. Errors are logged under the "error" attribute, and the request IDs of the
+// contexts under RequestIDField.
+func Slog(logger *slog.Logger) Logger {
+	return &slogLogger{logger: logger, ctx: context.Background()}
+}
+
+type slogLogger struct {
+	logger *slog.Logger
+	ctx    context.Context
+}
+
+func (l *slogLogger) WithField(key string, value any) Logger {
+	return &slogLogger{logger: l.logger.With(key, value), ctx: l.ctx}
+}
+
+func (l *slogLogger) WithFields(fields Fields) Logger {
+	args := make([]any, 0, len(fields)*2)
+	for key, value := range fields {
+		args = append(args, key, value)
+	}
+	return &slogLogger{logger: l.logger.With(args...), ctx: l.ctx}
+}
+
+func (l *slogLogger) WithError(err error) Logger {
+	return l.WithField("error", err)
+}
+
+func (l *slogLogger) WithContext(ctx context.Context) Logger {
+	logger := l.logger
+	if id, ok := RequestID(ctx); ok {
+		logger = logger.With(RequestIDField, id)
+	}
+	return &slogLogger{logger: logger, ctx: ctx}
+}
+
+func (l *slogLogger) Debug(args ...any) { l.log(slog.LevelDebug, args) }
+func (l *slogLogger) Info(args ...any)  { l.log(slog.LevelInfo, args) }
+func (l *slogLogger) Warn(args ...any)  { l.log(slog.LevelWarn, args) }
+func (l *slogLogger) Error(args ...any) { l.log(slog.LevelError, args) }
+
+func (l *slogLogger) log(level slog.Level, args []any) {
+	l.logger.Log(l.ctx, level, fmt.Sprint(args...))
+}
@@ -0,0 +1,77 @@
+// Package logging configures the logger, and carries request IDs in contexts down to the log entries.
+package logging
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+// Format is the format log entries are written in.
+type Format string
+
+const (
+	FormatText Format = "text"
+	FormatJSON Format = "json"
+)
+
+// RequestIDField is the field request IDs are logged under.
+const RequestIDField = "request_id"
+
+// Configure sets the format and level of the logger, and adds the request IDs of the entries logged with a context to
+// their fields.
+func Configure(logger *logrus.Logger, format, level string) error {
+	switch Format(format) {
+	case FormatText:
+		logger.SetFormatter(&logrus.TextFormatter{})
+	case FormatJSON:
+		logger.SetFormatter(&logrus.JSONFormatter{TimestampFormat: time.RFC3339Nano})
+	default:
+		return fmt.Errorf("unknown log format %q, expected %q or %q", format, FormatText, FormatJSON)
+	}
+
+	lvl, err := logrus.ParseLevel(level)
+	if err != nil {
+		return fmt.Errorf("invalid log level: %w", err)
+	}
+	logger.SetLevel(lvl)
+	logger.AddHook(requestIDHook{})
+
+	return nil
+}
+
+type requestIDKey struct{}
+
+// WithRequestID returns a copy of the context carrying the request ID.
+func WithRequestID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, id)
+}
+
+// RequestID returns the request ID carried by the context, if any.
+func RequestID(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(requestIDKey{}).(string)
+	return id, ok
+}
+
+// requestIDHook adds the request ID of the entries logged with a context carrying one.
+type requestIDHook struct{}
+
+func (requestIDHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (requestIDHook) Fire(entry *logrus.Entry) error {
+	if entry.Context == nil {
+		return nil
+	}
+	id, ok := RequestID(entry.Context)
+	if !ok {
+		return nil
+	}
+	if _, set := entry.Data[RequestIDField]; !set {
+		entry.Data[RequestIDField] = id
+	}
+	return nil
+}
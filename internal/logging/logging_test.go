@@ -0,0 +1,124 @@
+package logging_test
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+func TestConfigure(t *testing.T) {
+	tests := map[string]struct {
+		format        string
+		level         string
+		expectedLevel logrus.Level
+		expectedErr   string
+	}{
+		"json": {
+			format:        "json",
+			level:         "warn",
+			expectedLevel: logrus.WarnLevel,
+		},
+		"text": {
+			format:        "text",
+			level:         "debug",
+			expectedLevel: logrus.DebugLevel,
+		},
+		"unknown format": {
+			format:      "logfmt",
+			level:       "info",
+			expectedErr: `unknown log format "logfmt", expected "text" or "json"`,
+		},
+		"invalid level": {
+			format:      "json",
+			level:       "loud",
+			expectedErr: "invalid log level: not a valid logrus Level: \"loud\"",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			logger := logrus.New()
+			err := logging.Configure(logger, test.format, test.level)
+			if test.expectedErr != "" {
+				require.Error(t, err)
+				assert.Equal(t, test.expectedErr, err.Error())
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedLevel, logger.GetLevel())
+		})
+	}
+}
+
+func TestRequestIDField(t *testing.T) {
+	var out bytes.Buffer
+	logger := logrus.New()
+	logger.SetOutput(&out)
+	require.NoError(t, logging.Configure(logger, "json", "info"))
+
+	ctx := logging.WithRequestID(context.Background(), "req-1")
+	logger.WithContext(ctx).WithField("addr", "0xaa").Info("Subscribed")
+	logger.WithContext(context.Background()).Info("No request")
+
+	decoder := json.NewDecoder(&out)
+	var entry map[string]any
+	require.NoError(t, decoder.Decode(&entry))
+	assert.Equal(t, "req-1", entry[logging.RequestIDField])
+	assert.Equal(t, "0xaa", entry["addr"])
+	assert.Equal(t, "Subscribed", entry["msg"])
+
+	entry = nil
+	require.NoError(t, decoder.Decode(&entry))
+	assert.NotContains(t, entry, logging.RequestIDField)
+}
+
+func TestLoggerAdapters(t *testing.T) {
+	tests := map[string]struct {
+		newLogger func(out *bytes.Buffer) logging.Logger
+	}{
+		"logrus": {
+			newLogger: func(out *bytes.Buffer) logging.Logger {
+				logger := logrus.New()
+				logger.SetOutput(out)
+				require.NoError(t, logging.Configure(logger, "json", "debug"))
+				return logging.Logrus(logger)
+			},
+		},
+		"slog": {
+			newLogger: func(out *bytes.Buffer) logging.Logger {
+				return logging.Slog(slog.New(slog.NewJSONHandler(out, &slog.HandlerOptions{Level: slog.LevelDebug})))
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var out bytes.Buffer
+			logger := test.newLogger(&out)
+
+			ctx := logging.WithRequestID(context.Background(), "req-1")
+			logger.WithContext(ctx).
+				WithFields(logging.Fields{"block_number": 7}).
+				WithField("addr", "0xaa").
+				WithError(errors.New("store unavailable")).
+				Warn("Failed to index block")
+
+			var entry map[string]any
+			require.NoError(t, json.NewDecoder(&out).Decode(&entry))
+			assert.Equal(t, "req-1", entry[logging.RequestIDField])
+			assert.EqualValues(t, 7, entry["block_number"])
+			assert.Equal(t, "0xaa", entry["addr"])
+			assert.Equal(t, "store unavailable", entry["error"])
+			assert.Equal(t, "Failed to index block", entry["msg"])
+		})
+	}
+}
@@ -0,0 +1,201 @@
+// Package membudget applies a soft memory budget to the process: it sets the runtime's memory limit, sizes the
+// in-memory stores and pipeline buffers to fit within it, and warns when the memory in use approaches it.
+package membudget
+
+import (
+	"context"
+	"fmt"
+	"runtime/debug"
+	"runtime/metrics"
+	"slices"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	DefaultWarningRatio = 0.9
+	DefaultInterval     = time.Second * 5
+
+	// storeShare and bufferShare are the shares of the budget the in-memory stores and the pipeline buffers are sized
+	// to, the rest being left to the notifier queues, the caches and the runtime itself.
+	storeShare  = 0.5
+	bufferShare = 0.2
+	// recordSize is the estimated size of a stored record, its raw JSON included.
+	recordSize = 2 << 10
+	// blockSize is the estimated size of a full mainnet block, decoded along with its raw transactions.
+	blockSize = 1 << 20
+	// bufferStages is the number of pipeline buffers of a chain a block can be held in at once.
+	bufferStages = 3
+)
+
+// the runtime metrics the memory in use is derived from, the same the memory limit applies to
+const (
+	totalMemoryMetric    = "/memory/classes/total:bytes"
+	releasedMemoryMetric = "/memory/classes/heap/released:bytes"
+)
+
+// Size is a number of bytes, given as a flag in bytes or with a KB, MB, GB, KiB, MiB or GiB unit.
+type Size int64
+
+var units = []struct {
+	suffix string
+	size   int64
+}{
+	// bytes last, as every other unit ends with B
+	{"KiB", 1 << 10}, {"MiB", 1 << 20}, {"GiB", 1 << 30},
+	{"KB", 1e3}, {"MB", 1e6}, {"GB", 1e9},
+	{"B", 1},
+}
+
+// String implements flag.Value.
+func (s *Size) String() string {
+	if s == nil || *s == 0 {
+		return ""
+	}
+	// formatted in the largest binary unit it's a multiple of
+	for _, unit := range slices.Backward(units[:3]) {
+		if int64(*s)%unit.size == 0 {
+			return strconv.FormatInt(int64(*s)/unit.size, 10) + unit.suffix
+		}
+	}
+	return strconv.FormatInt(int64(*s), 10)
+}
+
+// Set implements flag.Value.
+func (s *Size) Set(value string) error {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		*s = 0
+		return nil
+	}
+	multiplier := int64(1)
+	for unit := range slices.Values(units) {
+		if strings.HasSuffix(value, unit.suffix) {
+			value = strings.TrimSpace(strings.TrimSuffix(value, unit.suffix))
+			multiplier = unit.size
+			break
+		}
+	}
+	n, err := strconv.ParseFloat(value, 64)
+	if err != nil || n < 0 {
+		return fmt.Errorf("invalid size %q, expected a number of bytes optionally followed by a unit such as MiB", value)
+	}
+	*s = Size(n * float64(multiplier))
+	return nil
+}
+
+type config struct {
+	warningRatio float64
+	interval     time.Duration
+}
+
+type Option func(*config)
+
+// WithWarningRatio sets the ratio of the budget in use above which it's reported as approached.
+func WithWarningRatio(ratio float64) Option {
+	return func(c *config) {
+		c.warningRatio = ratio
+	}
+}
+
+// WithInterval sets the interval the memory in use is checked at.
+func WithInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.interval = d
+	}
+}
+
+// Budget is the soft memory budget of the process.
+type Budget struct {
+	logger *logrus.Logger
+	limit  int64
+	cfg    *config
+	// approached is set while the memory in use is above the warning ratio of the budget.
+	approached atomic.Bool
+}
+
+func New(logger *logrus.Logger, limit Size, opts ...Option) *Budget {
+	cfg := &config{
+		warningRatio: DefaultWarningRatio,
+		interval:     DefaultInterval,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Budget{
+		logger: logger,
+		limit:  int64(limit),
+		cfg:    cfg,
+	}
+}
+
+// Apply sets the memory limit of the runtime to the budget, overriding GOMEMLIMIT, so the garbage collector runs
+// harder as the budget is approached rather than letting the heap grow past it.
+func (b *Budget) Apply() {
+	previous := debug.SetMemoryLimit(b.limit)
+	budgetBytes.Set(float64(b.limit))
+	b.logger.WithFields(logrus.Fields{
+		"limit":          b.limit,
+		"previous_limit": previous,
+	}).Info("Applied memory budget")
+}
+
+// StoreRecords returns the number of records the in-memory stores of each of the given number of pipelines can hold
+// within the budget.
+func (b *Budget) StoreRecords(pipelines int) int {
+	return max(1, int(float64(b.limit)*storeShare/recordSize)/max(pipelines, 1))
+}
+
+// PipelineBlocks returns the number of blocks each pipeline buffer of the given number of pipelines can hold within
+// the budget.
+func (b *Budget) PipelineBlocks(pipelines int) uint {
+	return uint(max(1, int(float64(b.limit)*bufferShare/blockSize)/bufferStages/max(pipelines, 1)))
+}
+
+// Approached reports whether the memory in use was above the warning ratio of the budget when last checked.
+func (b *Budget) Approached() bool {
+	return b.approached.Load()
+}
+
+// Start checks the memory in use every interval until the context is done, warning once every time it crosses the
+// warning ratio of the budget.
+func (b *Budget) Start(ctx context.Context) {
+	t := time.NewTicker(b.cfg.interval)
+	defer t.Stop()
+
+	samples := []metrics.Sample{
+		{Name: totalMemoryMetric},
+		{Name: releasedMemoryMetric},
+	}
+	for {
+		metrics.Read(samples)
+		used := int64(samples[0].Value.Uint64() - samples[1].Value.Uint64())
+		usedBytes.Set(float64(used))
+
+		nowApproached := float64(used) >= float64(b.limit)*b.cfg.warningRatio
+		if nowApproached && !b.approached.Load() {
+			budgetWarnings.Inc()
+			b.logger.WithFields(logrus.Fields{
+				"used":  used,
+				"limit": b.limit,
+			}).Warn("Memory in use is approaching the memory budget")
+		}
+		b.approached.Store(nowApproached)
+		if nowApproached {
+			budgetApproached.Set(1)
+		} else {
+			budgetApproached.Set(0)
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+	}
+}
@@ -0,0 +1,86 @@
+package membudget_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/membudget"
+)
+
+func TestSize(t *testing.T) {
+	tests := map[string]struct {
+		value          string
+		expectedSize   membudget.Size
+		expectedString string
+		expectErr      bool
+	}{
+		"bytes": {
+			value:          "1500",
+			expectedSize:   1500,
+			expectedString: "1500",
+		},
+		"binary unit": {
+			value:          "512MiB",
+			expectedSize:   512 << 20,
+			expectedString: "512MiB",
+		},
+		"fractional binary unit": {
+			value:          "1.5 GiB",
+			expectedSize:   3 << 29,
+			expectedString: "1536MiB",
+		},
+		"decimal unit": {
+			value:          "2GB",
+			expectedSize:   2e9,
+			expectedString: "1953125KiB",
+		},
+		"empty": {
+			value: "",
+		},
+		"negative": {
+			value:     "-1MiB",
+			expectErr: true,
+		},
+		"unknown unit": {
+			value:     "1TiB",
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var size membudget.Size
+			err := size.Set(test.value)
+			if test.expectErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedSize, size)
+			assert.Equal(t, test.expectedString, size.String())
+		})
+	}
+}
+
+func TestBudgetSizing(t *testing.T) {
+	budget := membudget.New(logrus.New(), 512<<20)
+	assert.Equal(t, 131072, budget.StoreRecords(1))
+	assert.Equal(t, 65536, budget.StoreRecords(2))
+	assert.Equal(t, uint(34), budget.PipelineBlocks(1))
+	assert.Equal(t, uint(1), membudget.New(logrus.New(), 1<<20).PipelineBlocks(1))
+}
+
+func TestBudgetStart(t *testing.T) {
+	// any process is above the warning ratio of a 1KiB budget
+	budget := membudget.New(logrus.New(), 1<<10, membudget.WithInterval(time.Millisecond*10))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	budget.Start(ctx)
+
+	assert.True(t, budget.Approached())
+}
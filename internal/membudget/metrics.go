@@ -0,0 +1,26 @@
+package membudget
+
+import (
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var budgetBytes = custompromauto.Auto().NewGauge(prometheus.GaugeOpts{
+	Name: "ethtxparser_memory_budget_bytes",
+	Help: "Soft memory budget of the process, set as the memory limit of the runtime",
+})
+
+var usedBytes = custompromauto.Auto().NewGauge(prometheus.GaugeOpts{
+	Name: "ethtxparser_memory_used_bytes",
+	Help: "Memory in use counted against the memory budget",
+})
+
+var budgetApproached = custompromauto.Auto().NewGauge(prometheus.GaugeOpts{
+	Name: "ethtxparser_memory_budget_approached",
+	Help: "1 while the memory in use is above the warning ratio of the memory budget, 0 otherwise",
+})
+
+var budgetWarnings = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
+	Name: "ethtxparser_memory_budget_warnings_total",
+	Help: "Number of times the memory in use crossed the warning ratio of the memory budget",
+})
@@ -0,0 +1,118 @@
+// Package metering attributes the use of a shared deployment to its tenants, the holders of its API keys, for chargeback
+// or quota decisions.
+package metering
+
+import (
+	"context"
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+// The channels the transactions are delivered to the tenants by.
+const (
+	ChannelWebhook = "webhook"
+	ChannelGRPC    = "grpc"
+)
+
+// Recorder records the usage of the tenants.
+type Recorder interface {
+	IncrementRequests(ctx context.Context, tenant string) error
+	IncrementDeliveries(ctx context.Context, tenant, channel string, n int64) error
+}
+
+type tenantKey struct{}
+
+// ContextWithTenant returns a copy of the context carrying the tenant.
+func ContextWithTenant(ctx context.Context, tenant string) context.Context {
+	return context.WithValue(ctx, tenantKey{}, tenant)
+}
+
+// TenantFromContext returns the tenant carried by the context, empty if none.
+func TenantFromContext(ctx context.Context) string {
+	tenant, _ := ctx.Value(tenantKey{}).(string)
+	return tenant
+}
+
+// apiKey is an API key and the tenant holding it.
+type apiKey struct {
+	tenant string
+	key    []byte
+}
+
+// Keys are the API keys of the tenants.
+type Keys struct {
+	keys []apiKey
+}
+
+// ParseKeys parses comma separated "<tenant>:<key>" pairs, e.g. "acme:k1,globex:k2". A tenant can hold several keys,
+// e.g. while rotating them, but a key can't be held twice.
+func ParseKeys(s string) (*Keys, error) {
+	keys := &Keys{}
+	for pair := range strings.SplitSeq(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		tenant, key, ok := strings.Cut(pair, ":")
+		tenant, key = strings.TrimSpace(tenant), strings.TrimSpace(key)
+		if !ok || tenant == "" || key == "" {
+			return nil, fmt.Errorf("invalid API key %q, expected <tenant>:<key>", tenant)
+		}
+		if slices.ContainsFunc(keys.keys, func(k apiKey) bool { return string(k.key) == key }) {
+			return nil, fmt.Errorf("API key of tenant %q is held twice", tenant)
+		}
+		keys.keys = append(keys.keys, apiKey{tenant: tenant, key: []byte(key)})
+	}
+	return keys, nil
+}
+
+// Enabled reports whether any key is set, the API being open otherwise.
+func (k *Keys) Enabled() bool {
+	return k != nil && len(k.keys) > 0
+}
+
+// Tenant returns the tenant holding the bearer token of the Authorization header of the request, or false if it's not
+// a known key.
+func (k *Keys) Tenant(r *http.Request) (string, bool) {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return "", false
+	}
+	// every key is compared, in constant time, so the timing doesn't tell how many of them were tried
+	var tenant string
+	for key := range slices.Values(k.keys) {
+		if subtle.ConstantTimeCompare([]byte(token), key.key) == 1 {
+			tenant = key.tenant
+		}
+	}
+	return tenant, tenant != ""
+}
+
+// RequireKey wraps the handler to reject the requests without a known API key as their bearer token, recording the
+// requests served for the tenants holding the keys. The handlers find the tenant in the context of the requests, see
+// TenantFromContext. The handler is returned as is if no key is set.
+func RequireKey(logger logging.Logger, keys *Keys, recorder Recorder, next http.Handler) http.Handler {
+	if !keys.Enabled() {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		tenant, ok := keys.Tenant(r)
+		if !ok {
+			w.Header().Set("WWW-Authenticate", `Bearer realm="ethtxparser"`)
+			http.Error(w, "Unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		ctx := ContextWithTenant(r.Context(), tenant)
+		next.ServeHTTP(w, r.WithContext(ctx))
+		err := recorder.IncrementRequests(ctx, tenant)
+		if err != nil {
+			logger.WithContext(ctx).WithError(err).WithField("tenant", tenant).Warn("Failed to record request usage")
+		}
+	})
+}
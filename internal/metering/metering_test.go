@@ -0,0 +1,117 @@
+package metering_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+func TestParseKeys(t *testing.T) {
+	tests := map[string]struct {
+		keys          string
+		expectEnabled bool
+		expectErr     bool
+	}{
+		"empty": {},
+		"keys": {
+			keys:          "acme:k1, acme:k2,globex:k3",
+			expectEnabled: true,
+		},
+		"missing key": {
+			keys:      "acme:",
+			expectErr: true,
+		},
+		"missing tenant": {
+			keys:      ":k1",
+			expectErr: true,
+		},
+		"missing separator": {
+			keys:      "acme",
+			expectErr: true,
+		},
+		"key held twice": {
+			keys:      "acme:k1,globex:k1",
+			expectErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys, err := metering.ParseKeys(test.keys)
+			if test.expectErr {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectEnabled, keys.Enabled())
+		})
+	}
+}
+
+func TestRequireKey(t *testing.T) {
+	tests := map[string]struct {
+		keys           string
+		authorization  string
+		expectedStatus int
+		expectedTenant string
+	}{
+		"known key": {
+			keys:           "acme:k1,globex:k2",
+			authorization:  "Bearer k2",
+			expectedStatus: http.StatusOK,
+			expectedTenant: "globex",
+		},
+		"unknown key": {
+			keys:           "acme:k1",
+			authorization:  "Bearer guess",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"missing key": {
+			keys:           "acme:k1",
+			expectedStatus: http.StatusUnauthorized,
+		},
+		"no key required": {
+			expectedStatus: http.StatusOK,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			keys, err := metering.ParseKeys(test.keys)
+			require.NoError(t, err)
+			usageStore := memdb.NewUsageStore()
+			var tenant string
+			handler := metering.RequireKey(logging.Logrus(logrus.New()), keys, usageStore, http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				tenant = metering.TenantFromContext(r.Context())
+				w.WriteHeader(http.StatusOK)
+			}))
+			req := httptest.NewRequest(http.MethodGet, "/api/v1/blocks/current", nil)
+			if test.authorization != "" {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, test.expectedStatus, rec.Code)
+			assert.Equal(t, test.expectedTenant, tenant)
+
+			usages, err := usageStore.GetUsage(context.Background())
+			require.NoError(t, err)
+			if test.expectedTenant == "" {
+				assert.Empty(t, usages)
+				return
+			}
+			require.Len(t, usages, 1)
+			assert.Equal(t, test.expectedTenant, usages[0].Tenant)
+			assert.Equal(t, int64(1), usages[0].Requests)
+		})
+	}
+}
@@ -0,0 +1,21 @@
+package mocknode
+
+import (
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var mintedBlocks = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
+	Name: "ethtxparser_mocknode_minted_blocks_total",
+	Help: "Number of blocks minted by the mock node, the ones replacing reorganised blocks included",
+})
+
+var reorgs = custompromauto.Auto().NewCounter(prometheus.CounterOpts{
+	Name: "ethtxparser_mocknode_reorgs_total",
+	Help: "Number of chain reorganisations made by the mock node",
+})
+
+var rpcCalls = custompromauto.Auto().NewCounterVec(prometheus.CounterOpts{
+	Name: "ethtxparser_mocknode_rpc_calls_total",
+	Help: "Number of JSON-RPC calls served by the mock node, by method",
+}, []string{"method"})
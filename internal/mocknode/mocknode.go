@@ -0,0 +1,315 @@
+// Package mocknode implements a fake Ethereum node minting deterministic blocks, served over the subset of the JSON-RPC
+// API the parser uses, so it can be demoed, load tested and tested end to end without a real provider.
+package mocknode
+
+import (
+	"context"
+	"encoding/binary"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"math/big"
+	"math/rand/v2"
+	"slices"
+	"sync"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/keccak"
+	"github.com/hedisam/ethtxparser/internal/logging"
+)
+
+const (
+	DefaultBlockTime   = time.Second * 2
+	DefaultTxsPerBlock = 20
+	DefaultAccounts    = 10
+	DefaultReorgDepth  = 2
+	// retainedBlocks is the number of blocks below the head kept to be served, orphaned ones included.
+	retainedBlocks = 1024
+	// failedTxRate is the rate of the minted transactions that revert.
+	failedTxRate = 0.05
+)
+
+var (
+	// ErrInvalidDepth is returned when a reorg is deeper than the minted chain, or the blocks retained.
+	ErrInvalidDepth = errors.New("invalid reorg depth")
+)
+
+type config struct {
+	seed        uint64
+	startBlock  int64
+	blockTime   time.Duration
+	txsPerBlock int
+	accounts    int
+	reorgEvery  int
+	reorgDepth  int
+}
+
+type Option func(*config)
+
+// WithSeed sets the seed the blocks are derived from, the same seed minting the same blocks.
+func WithSeed(seed uint64) Option {
+	return func(c *config) {
+		c.seed = seed
+	}
+}
+
+// WithStartBlock sets the number of the first minted block.
+func WithStartBlock(number int64) Option {
+	return func(c *config) {
+		c.startBlock = number
+	}
+}
+
+// WithBlockTime sets the interval blocks are minted at.
+func WithBlockTime(d time.Duration) Option {
+	return func(c *config) {
+		c.blockTime = d
+	}
+}
+
+// WithTxsPerBlock sets the number of transactions of every block.
+func WithTxsPerBlock(n int) Option {
+	return func(c *config) {
+		c.txsPerBlock = n
+	}
+}
+
+// WithAccounts sets the number of accounts the transactions are sent from and to.
+func WithAccounts(n int) Option {
+	return func(c *config) {
+		c.accounts = n
+	}
+}
+
+// WithReorgs makes the node replace its last depth blocks with a sibling branch every n minted blocks.
+func WithReorgs(every, depth int) Option {
+	return func(c *config) {
+		c.reorgEvery = every
+		c.reorgDepth = depth
+	}
+}
+
+type block struct {
+	number     int64
+	hash       string
+	parentHash string
+	timestamp  int64
+	txs        []*tx
+}
+
+type tx struct {
+	index  int
+	hash   string
+	from   string
+	to     string
+	value  *big.Int
+	failed bool
+}
+
+// Node is the fake node. Its chain grows by a block every block time once started, the content of each block being
+// derived from the seed, its number and the branch it's minted on, so only the timestamps depend on the start time.
+type Node struct {
+	logger   logging.Logger
+	cfg      *config
+	accounts []string
+
+	mu sync.RWMutex
+	// chain is the canonical chain, from its oldest retained block to the head.
+	chain []*block
+	// byHash holds the retained blocks, orphaned ones included.
+	byHash map[string]*block
+	// branch is the number of reorgs so far, every reorg minting its blocks on a new branch.
+	branch  uint64
+	minted  int
+	genesis time.Time
+}
+
+func New(logger logging.Logger, opts ...Option) *Node {
+	cfg := &config{
+		seed:        1,
+		blockTime:   DefaultBlockTime,
+		txsPerBlock: DefaultTxsPerBlock,
+		accounts:    DefaultAccounts,
+		reorgDepth:  DefaultReorgDepth,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	accounts := make([]string, 0, cfg.accounts)
+	for i := range cfg.accounts {
+		accounts = append(accounts, deriveAddress(cfg.seed, uint64(i)))
+	}
+
+	n := &Node{
+		logger:   logger,
+		cfg:      cfg,
+		accounts: accounts,
+		byHash:   make(map[string]*block),
+		genesis:  time.Now(),
+	}
+	n.append(n.mint(cfg.startBlock, deriveHash("parent", cfg.seed, uint64(cfg.startBlock), 0)))
+	return n
+}
+
+// Accounts returns the addresses of the accounts the transactions are sent from and to, to subscribe to.
+func (n *Node) Accounts() []string {
+	return slices.Clone(n.accounts)
+}
+
+// Start mints a block every block time, and reorgs the chain if configured, until the context is done.
+func (n *Node) Start(ctx context.Context) {
+	t := time.NewTicker(n.cfg.blockTime)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		n.mu.Lock()
+		n.minted++
+		reorg := n.cfg.reorgEvery > 0 && n.minted%n.cfg.reorgEvery == 0
+		n.mu.Unlock()
+		if reorg {
+			err := n.Reorg(n.cfg.reorgDepth)
+			if err != nil {
+				n.logger.WithError(err).Warn("Failed to reorg mock chain")
+			}
+		}
+		n.MintBlock()
+	}
+}
+
+// MintBlock appends a new block to the head of the chain, returning its number.
+func (n *Node) MintBlock() int64 {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	head := n.chain[len(n.chain)-1]
+	b := n.mint(head.number+1, head.hash)
+	n.append(b)
+	n.logger.WithFields(logging.Fields{
+		"block_number": b.number,
+		"block_hash":   b.hash,
+	}).Debug("Minted mock block")
+	return b.number
+}
+
+// Reorg replaces the last depth blocks of the chain with the same number of blocks of a new branch, keeping the
+// replaced blocks retrievable by hash as orphans.
+func (n *Node) Reorg(depth int) error {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+
+	if depth < 1 || depth >= len(n.chain) {
+		return fmt.Errorf("%w: %d, the chain has %d blocks", ErrInvalidDepth, depth, len(n.chain))
+	}
+	n.branch++
+	forkPoint := len(n.chain) - depth
+	replaced := n.chain[forkPoint:]
+	n.chain = n.chain[:forkPoint]
+	for old := range slices.Values(replaced) {
+		parent := n.chain[len(n.chain)-1]
+		n.append(n.mint(old.number, parent.hash))
+	}
+	reorgs.Inc()
+	n.logger.WithFields(logging.Fields{
+		"depth":    depth,
+		"head":     n.chain[len(n.chain)-1].number,
+		"old_head": replaced[len(replaced)-1].hash,
+		"new_head": n.chain[len(n.chain)-1].hash,
+	}).Info("Reorganised mock chain")
+	return nil
+}
+
+// mint derives the block of the given number on the current branch.
+func (n *Node) mint(number int64, parentHash string) *block {
+	seed := n.cfg.seed
+	b := &block{
+		number:     number,
+		hash:       deriveHash("block", seed, uint64(number), n.branch),
+		parentHash: parentHash,
+		timestamp:  n.genesis.Add(time.Duration(number-n.cfg.startBlock) * n.cfg.blockTime).Unix(),
+		txs:        make([]*tx, 0, n.cfg.txsPerBlock),
+	}
+
+	rng := rand.New(rand.NewChaCha8(deriveSum("rng", seed, uint64(number), n.branch)))
+	for i := range n.cfg.txsPerBlock {
+		from := rng.IntN(len(n.accounts))
+		to := rng.IntN(len(n.accounts))
+		b.txs = append(b.txs, &tx{
+			index: i,
+			hash:  deriveHash("tx", seed, uint64(number)<<16|uint64(i), n.branch),
+			from:  n.accounts[from],
+			to:    n.accounts[to],
+			// up to 10 ether
+			value:  new(big.Int).Mul(big.NewInt(rng.Int64N(10_000_000)), big.NewInt(1e12)),
+			failed: rng.Float64() < failedTxRate,
+		})
+	}
+	mintedBlocks.Inc()
+	return b
+}
+
+// append appends the block to the chain, pruning the blocks that are no longer retained.
+func (n *Node) append(b *block) {
+	n.chain = append(n.chain, b)
+	n.byHash[b.hash] = b
+	// pruned in batches rather than on every block
+	if len(n.chain) < retainedBlocks*2 {
+		return
+	}
+
+	oldest := n.chain[len(n.chain)-retainedBlocks].number
+	n.chain = slices.Clone(n.chain[len(n.chain)-retainedBlocks:])
+	for hash, retained := range n.byHash {
+		if retained.number < oldest {
+			delete(n.byHash, hash)
+		}
+	}
+}
+
+func (n *Node) head() *block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.chain[len(n.chain)-1]
+}
+
+// blockByNumber returns the canonical block of the given number, or nil if it isn't minted or retained.
+func (n *Node) blockByNumber(number int64) *block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	idx := number - n.chain[0].number
+	if idx < 0 || idx >= int64(len(n.chain)) {
+		return nil
+	}
+	return n.chain[idx]
+}
+
+// blockByHash returns the block of the given hash, canonical or orphaned, or nil if it isn't known.
+func (n *Node) blockByHash(hash string) *block {
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	return n.byHash[hash]
+}
+
+// deriveHash derives a hex encoded hash of the given kind from the seed, the number and the branch.
+func deriveHash(kind string, seed, number, branch uint64) string {
+	sum := deriveSum(kind, seed, number, branch)
+	return "0x" + hex.EncodeToString(sum[:])
+}
+
+func deriveSum(kind string, seed, number, branch uint64) [32]byte {
+	data := binary.BigEndian.AppendUint64([]byte(kind), seed)
+	data = binary.BigEndian.AppendUint64(data, number)
+	data = binary.BigEndian.AppendUint64(data, branch)
+	return keccak.Sum256(data)
+}
+
+// deriveAddress derives the address of the account of the given index from the seed.
+func deriveAddress(seed, index uint64) string {
+	return "0x" + deriveHash("account", seed, index, 0)[26:]
+}
@@ -0,0 +1,114 @@
+package mocknode_test
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/mocknode"
+)
+
+func TestNodeBlocks(t *testing.T) {
+	tests := map[string]struct {
+		seed         uint64
+		otherSeed    uint64
+		expectedSame bool
+	}{
+		"same seed mints the same blocks": {
+			seed:         1,
+			otherSeed:    1,
+			expectedSame: true,
+		},
+		"different seeds mint different blocks": {
+			seed:      1,
+			otherSeed: 2,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			logger := logging.Logrus(logrus.New())
+			node := mocknode.New(logger, mocknode.WithSeed(test.seed), mocknode.WithStartBlock(100), mocknode.WithTxsPerBlock(5))
+			other := mocknode.New(logger, mocknode.WithSeed(test.otherSeed), mocknode.WithStartBlock(100), mocknode.WithTxsPerBlock(5))
+			assert.Equal(t, int64(101), node.MintBlock())
+			other.MintBlock()
+
+			client := newClient(t, node)
+			otherClient := newClient(t, other)
+			blocks, err := client.GetBlocks(ctx, []int64{100, 101})
+			require.NoError(t, err)
+			otherBlocks, err := otherClient.GetBlocks(ctx, []int64{100, 101})
+			require.NoError(t, err)
+
+			assert.Equal(t, blocks[0].Hash, blocks[1].ParentHash)
+			require.Len(t, blocks[1].Txs, 5)
+			assert.Contains(t, node.Accounts(), blocks[1].Txs[0].From)
+			assert.Contains(t, node.Accounts(), blocks[1].Txs[0].To)
+			assert.Equal(t, test.expectedSame, blocks[1].Hash == otherBlocks[1].Hash)
+			assert.Equal(t, test.expectedSame, blocks[1].Txs[0].Hash == otherBlocks[1].Txs[0].Hash)
+
+			_, err = client.GetBlock(ctx, 102)
+			assert.ErrorIs(t, err, eth.ErrNotFound)
+		})
+	}
+}
+
+func TestNodeReorg(t *testing.T) {
+	ctx := context.Background()
+	node := mocknode.New(logging.Logrus(logrus.New()), mocknode.WithTxsPerBlock(3))
+	for range 4 {
+		node.MintBlock()
+	}
+	client := newClient(t, node, eth.WithReceipts())
+
+	orphaned, err := client.GetBlock(ctx, 4)
+	require.NoError(t, err)
+	require.Len(t, orphaned.Receipts, 3)
+
+	err = node.Reorg(2)
+	require.NoError(t, err)
+	err = node.Reorg(5)
+	assert.ErrorIs(t, err, mocknode.ErrInvalidDepth)
+
+	blocks, err := client.GetBlocks(ctx, []int64{2, 3, 4})
+	require.NoError(t, err)
+	assert.Equal(t, blocks[0].Hash, blocks[1].ParentHash, "replaced blocks not forked from the common ancestor")
+	assert.Equal(t, blocks[1].Hash, blocks[2].ParentHash)
+	assert.NotEqual(t, orphaned.Hash, blocks[2].Hash)
+
+	// the orphaned blocks are still known by hash, as the reorg filter walks back their ancestors
+	byHash, err := client.GetBlockByHash(ctx, orphaned.Hash)
+	require.NoError(t, err)
+	assert.Equal(t, orphaned.Number, byHash.Number)
+}
+
+func TestNodeStart(t *testing.T) {
+	node := mocknode.New(logging.Logrus(logrus.New()), mocknode.WithBlockTime(time.Millisecond*10), mocknode.WithReorgs(3, 1))
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*100)
+	defer cancel()
+	node.Start(ctx)
+
+	req := httptest.NewRequest(http.MethodPost, "/reorg?depth=invalid", nil)
+	rec := httptest.NewRecorder()
+	node.Handler().ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	block, err := newClient(t, node).GetBlock(context.Background(), -1)
+	require.NoError(t, err)
+	assert.Greater(t, block.Number, int64(3))
+}
+
+func newClient(t *testing.T, node *mocknode.Node, opts ...eth.Option) *eth.Client {
+	srv := httptest.NewServer(node.Handler())
+	t.Cleanup(srv.Close)
+	return eth.New(logging.Logrus(logrus.New()), srv.Client(), srv.URL, opts...)
+}
@@ -0,0 +1,257 @@
+package mocknode
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// the JSON-RPC error codes returned by the node
+const (
+	codeParseError     = -32700
+	codeMethodNotFound = -32601
+	codeInvalidParams  = -32602
+)
+
+// chainID is the chain ID reported by the node, the one of the mainnet.
+const chainID = 1
+
+type rpcRequest struct {
+	ID     json.RawMessage   `json:"id"`
+	Method string            `json:"method"`
+	Params []json.RawMessage `json:"params"`
+}
+
+type rpcResponse struct {
+	JSONRPC string          `json:"jsonrpc"`
+	ID      json.RawMessage `json:"id"`
+	Result  any             `json:"result"`
+	Error   *rpcError       `json:"error,omitempty"`
+}
+
+type rpcError struct {
+	Code    int    `json:"code"`
+	Message string `json:"message"`
+}
+
+// Handler returns the handler serving the JSON-RPC API on POST /, single and batch calls alike, along with POST /mint
+// minting a block right away and POST /reorg?depth=<n> reorganising the last n blocks, to inject reorgs on demand.
+func (n *Node) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /{$}", n.serveRPC)
+	mux.HandleFunc("POST /mint", n.serveMint)
+	mux.HandleFunc("POST /reorg", n.serveReorg)
+	return mux
+}
+
+func (n *Node) serveRPC(w http.ResponseWriter, r *http.Request) {
+	var body json.RawMessage
+	err := json.NewDecoder(r.Body).Decode(&body)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+
+	if !bytes.HasPrefix(bytes.TrimSpace(body), []byte("[")) {
+		var req rpcRequest
+		err = json.Unmarshal(body, &req)
+		if err != nil {
+			writeParseError(w, err)
+			return
+		}
+		writeJSON(w, n.call(&req))
+		return
+	}
+
+	var reqs []*rpcRequest
+	err = json.Unmarshal(body, &reqs)
+	if err != nil {
+		writeParseError(w, err)
+		return
+	}
+	resps := make([]*rpcResponse, 0, len(reqs))
+	for req := range slices.Values(reqs) {
+		resps = append(resps, n.call(req))
+	}
+	writeJSON(w, resps)
+}
+
+func (n *Node) serveMint(w http.ResponseWriter, _ *http.Request) {
+	number := n.MintBlock()
+	writeJSON(w, map[string]any{
+		"head": number,
+	})
+}
+
+func (n *Node) serveReorg(w http.ResponseWriter, r *http.Request) {
+	depth := n.cfg.reorgDepth
+	if d := r.URL.Query().Get("depth"); d != "" {
+		var err error
+		depth, err = strconv.Atoi(d)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("invalid depth %q", d), http.StatusBadRequest)
+			return
+		}
+	}
+
+	err := n.Reorg(depth)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	head := n.head()
+	writeJSON(w, map[string]any{
+		"head":     head.number,
+		"headHash": head.hash,
+	})
+}
+
+// call serves a single JSON-RPC call.
+func (n *Node) call(req *rpcRequest) *rpcResponse {
+	resp := &rpcResponse{
+		JSONRPC: "2.0",
+		ID:      req.ID,
+	}
+	if resp.ID == nil {
+		resp.ID = json.RawMessage("null")
+	}
+
+	switch req.Method {
+	case "eth_chainId":
+		resp.Result = hexInt(chainID)
+	case "net_version":
+		resp.Result = strconv.Itoa(chainID)
+	case "eth_blockNumber":
+		resp.Result = hexInt(n.head().number)
+	case "eth_getBlockByNumber", "eth_getBlockByHash", "eth_getBlockReceipts":
+		b, full, err := n.blockOfParams(req.Method, req.Params)
+		if err != nil {
+			resp.Error = &rpcError{Code: codeInvalidParams, Message: err.Error()}
+			break
+		}
+		switch {
+		case b == nil:
+			// unknown blocks are a null result, like the real nodes return them
+		case req.Method == "eth_getBlockReceipts":
+			resp.Result = renderReceipts(b)
+		default:
+			resp.Result = renderBlock(b, full)
+		}
+	default:
+		resp.Error = &rpcError{Code: codeMethodNotFound, Message: fmt.Sprintf("the method %s does not exist/is not available", req.Method)}
+		rpcCalls.WithLabelValues("unsupported").Inc()
+		return resp
+	}
+	rpcCalls.WithLabelValues(req.Method).Inc()
+	return resp
+}
+
+// blockOfParams returns the block the params of the given method refer to, or nil if it isn't known, and whether the
+// full transactions are requested.
+func (n *Node) blockOfParams(method string, params []json.RawMessage) (*block, bool, error) {
+	if len(params) == 0 {
+		return nil, false, fmt.Errorf("missing block parameter")
+	}
+	var ref string
+	err := json.Unmarshal(params[0], &ref)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid block parameter: %w", err)
+	}
+	var full bool
+	if len(params) > 1 {
+		err = json.Unmarshal(params[1], &full)
+		if err != nil {
+			return nil, false, fmt.Errorf("invalid full transactions parameter: %w", err)
+		}
+	}
+
+	switch {
+	case method == "eth_getBlockByHash", len(ref) == 66:
+		return n.blockByHash(strings.ToLower(ref)), full, nil
+	case ref == "latest", ref == "safe", ref == "finalized", ref == "pending":
+		return n.head(), full, nil
+	case ref == "earliest":
+		return n.blockByNumber(n.cfg.startBlock), full, nil
+	}
+	number, err := strconv.ParseInt(strings.TrimPrefix(ref, "0x"), 16, 64)
+	if err != nil {
+		return nil, false, fmt.Errorf("invalid block number %q: %w", ref, err)
+	}
+	return n.blockByNumber(number), full, nil
+}
+
+func renderBlock(b *block, full bool) map[string]any {
+	txs := make([]any, 0, len(b.txs))
+	for t := range slices.Values(b.txs) {
+		if !full {
+			txs = append(txs, t.hash)
+			continue
+		}
+		txs = append(txs, map[string]any{
+			"hash":             t.hash,
+			"from":             t.from,
+			"to":               t.to,
+			"value":            "0x" + t.value.Text(16),
+			"input":            "0x",
+			"gas":              hexInt(21000),
+			"gasPrice":         hexInt(1_000_000_000),
+			"type":             "0x0",
+			"blockHash":        b.hash,
+			"blockNumber":      hexInt(b.number),
+			"transactionIndex": hexInt(int64(t.index)),
+		})
+	}
+
+	return map[string]any{
+		"hash":         b.hash,
+		"number":       hexInt(b.number),
+		"parentHash":   b.parentHash,
+		"timestamp":    hexInt(b.timestamp),
+		"gasLimit":     hexInt(30_000_000),
+		"gasUsed":      hexInt(int64(21000 * len(b.txs))),
+		"transactions": txs,
+	}
+}
+
+func renderReceipts(b *block) []map[string]any {
+	receipts := make([]map[string]any, 0, len(b.txs))
+	for t := range slices.Values(b.txs) {
+		status := "0x1"
+		if t.failed {
+			status = "0x0"
+		}
+		receipts = append(receipts, map[string]any{
+			"transactionHash":  t.hash,
+			"transactionIndex": hexInt(int64(t.index)),
+			"blockHash":        b.hash,
+			"blockNumber":      hexInt(b.number),
+			"from":             t.from,
+			"to":               t.to,
+			"gasUsed":          hexInt(21000),
+			"status":           status,
+			"logs":             []any{},
+		})
+	}
+	return receipts
+}
+
+func hexInt(v int64) string {
+	return "0x" + strconv.FormatInt(v, 16)
+}
+
+func writeParseError(w http.ResponseWriter, err error) {
+	writeJSON(w, &rpcResponse{
+		JSONRPC: "2.0",
+		ID:      json.RawMessage("null"),
+		Error:   &rpcError{Code: codeParseError, Message: err.Error()},
+	})
+}
+
+func writeJSON(w http.ResponseWriter, v any) {
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(v)
+}
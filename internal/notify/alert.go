@@ -0,0 +1,136 @@
+package notify
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/eth"
+)
+
+type reorgAlertsConfig struct {
+	webhookURLs []string
+	channels    []*ChatChannel
+	registerer  prometheus.Registerer
+}
+
+type ReorgAlertsOption func(*reorgAlertsConfig)
+
+// WithAlertWebhooks posts the alerts as JSON to the given URLs.
+func WithAlertWebhooks(urls ...string) ReorgAlertsOption {
+	return func(c *reorgAlertsConfig) {
+		c.webhookURLs = append(c.webhookURLs, urls...)
+	}
+}
+
+// WithAlertChatChannels sends the alerts to the given chat channels.
+func WithAlertChatChannels(channels ...*ChatChannel) ReorgAlertsOption {
+	return func(c *reorgAlertsConfig) {
+		c.channels = append(c.channels, channels...)
+	}
+}
+
+// WithAlertRegisterer sets the registerer the metrics of the reorg alerts are registered with, the custom registry by
+// default.
+func WithAlertRegisterer(reg prometheus.Registerer) ReorgAlertsOption {
+	return func(c *reorgAlertsConfig) {
+		c.registerer = reg
+	}
+}
+
+// ReorgAlerts alerts webhooks and chat channels of deep or repeated chain reorganisations, which usually indicate node
+// provider issues or a chain incident.
+type ReorgAlerts struct {
+	logger  *logrus.Logger
+	cfg     *reorgAlertsConfig
+	metrics *metrics
+	// chat delivers the alerts, to the chat channels and webhooks alike, without the rate limiting of transactions.
+	chat *Chat
+}
+
+func NewReorgAlerts(logger *logrus.Logger, httpClient *http.Client, opts ...ReorgAlertsOption) *ReorgAlerts {
+	cfg := &reorgAlertsConfig{
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &ReorgAlerts{
+		logger:  logger,
+		cfg:     cfg,
+		metrics: newMetrics(cfg.registerer),
+		chat:    NewChat(logger, httpClient, nil, WithChatRegisterer(cfg.registerer)),
+	}
+}
+
+// ReorgAlertPayload is the body posted to alert webhooks.
+type ReorgAlertPayload struct {
+	Type        string `json:"type"`
+	Reason      string `json:"reason"`
+	Depth       int    `json:"depth"`
+	BlockNumber int64  `json:"blockNumber"`
+	BlockHash   string `json:"blockHash"`
+	Reorgs      int    `json:"reorgs"`
+	// Window is the time the reorganisations were counted within, e.g. "10m0s".
+	Window string `json:"window,omitempty"`
+}
+
+// AlertReorg sends the alert to every configured webhook and chat channel.
+func (a *ReorgAlerts) AlertReorg(ctx context.Context, alert *eth.ReorgAlert) error {
+	payload := &ReorgAlertPayload{
+		Type:        "reorg",
+		Reason:      string(alert.Reason),
+		Depth:       alert.Depth,
+		BlockNumber: alert.BlockNumber,
+		BlockHash:   alert.BlockHash,
+		Reorgs:      alert.Reorgs,
+	}
+	if alert.Reason == eth.ReorgAlertRepeated {
+		payload.Window = alert.Window.String()
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal reorg alert: %w", err)
+	}
+
+	var errs []error
+	for url := range slices.Values(a.cfg.webhookURLs) {
+		bo := backoff.WithContext(newExponentialBackoffConfig(a.chat.cfg.maxElapsedTime), ctx)
+		err := backoff.Retry(func() error {
+			return a.chat.post(ctx, url, body)
+		}, bo)
+		a.metrics.reorgAlerts.WithLabelValues("webhook", result(err)).Inc()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not post reorg alert to webhook: %w", err))
+		}
+	}
+
+	text := reorgAlertMessage(alert)
+	for channel := range slices.Values(a.cfg.channels) {
+		err := a.chat.send(ctx, channel, text)
+		a.metrics.reorgAlerts.WithLabelValues(string(channel.Platform), result(err)).Inc()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not send reorg alert to %s: %w", channel.Platform, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// reorgAlertMessage describes the alert, e.g. "Chain reorganisation alert: 5 blocks replaced by block 123 (0xabc)".
+func reorgAlertMessage(alert *eth.ReorgAlert) string {
+	if alert.Reason == eth.ReorgAlertRepeated {
+		return fmt.Sprintf("Chain reorganisation alert: %d reorganisations within %s, the last one replacing %d blocks by block %d (%s)",
+			alert.Reorgs, alert.Window, alert.Depth, alert.BlockNumber, alert.BlockHash)
+	}
+	return fmt.Sprintf("Chain reorganisation alert: %d blocks replaced by block %d (%s)", alert.Depth, alert.BlockNumber, alert.BlockHash)
+}
@@ -0,0 +1,60 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/notify"
+)
+
+func TestReorgAlerts(t *testing.T) {
+	var mu sync.Mutex
+	received := make(map[string]map[string]any)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		received[r.URL.Path] = body
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	alerts := notify.NewReorgAlerts(logrus.New(), srv.Client(),
+		notify.WithAlertWebhooks(srv.URL+"/webhook"),
+		notify.WithAlertChatChannels(&notify.ChatChannel{Platform: notify.ChatPlatformSlack, URL: srv.URL + "/slack"}),
+	)
+	err := alerts.AlertReorg(context.Background(), &eth.ReorgAlert{
+		Reason:      eth.ReorgAlertRepeated,
+		Depth:       2,
+		BlockNumber: 123,
+		BlockHash:   "0xabc",
+		Reorgs:      3,
+		Window:      10 * time.Minute,
+	})
+	require.NoError(t, err)
+
+	assert.Equal(t, map[string]map[string]any{
+		"/webhook": {
+			"type":        "reorg",
+			"reason":      "repeated",
+			"depth":       float64(2),
+			"blockNumber": float64(123),
+			"blockHash":   "0xabc",
+			"reorgs":      float64(3),
+			"window":      "10m0s",
+		},
+		"/slack": {
+			"text": "Chain reorganisation alert: 3 reorganisations within 10m0s, the last one replacing 2 blocks by block 123 (0xabc)",
+		},
+	}, received)
+}
@@ -0,0 +1,218 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"slices"
+	"strings"
+)
+
+// AMQP 0-9-1 framing, limited to what a publisher needs.
+// See https://www.rabbitmq.com/resources/specs/amqp0-9-1.pdf
+
+const (
+	amqpFrameMethod    = 1
+	amqpFrameHeader    = 2
+	amqpFrameBody      = 3
+	amqpFrameHeartbeat = 8
+	amqpFrameEnd       = 0xCE
+
+	// amqpFrameOverhead is the size of a frame header and its frame-end octet.
+	amqpFrameOverhead = 8
+	// amqpDefaultFrameMax is the frame size used when the server doesn't impose a limit.
+	amqpDefaultFrameMax = 131072
+)
+
+var amqpProtocolHeader = []byte{'A', 'M', 'Q', 'P', 0, 0, 9, 1}
+
+// amqpMethodID identifies a method by its class and method ids.
+type amqpMethodID struct {
+	class  uint16
+	method uint16
+}
+
+var (
+	amqpConnectionStart   = amqpMethodID{10, 10}
+	amqpConnectionStartOk = amqpMethodID{10, 11}
+	amqpConnectionTune    = amqpMethodID{10, 30}
+	amqpConnectionTuneOk  = amqpMethodID{10, 31}
+	amqpConnectionOpen    = amqpMethodID{10, 40}
+	amqpConnectionOpenOk  = amqpMethodID{10, 41}
+	amqpConnectionClose   = amqpMethodID{10, 50}
+	amqpConnectionCloseOk = amqpMethodID{10, 51}
+	amqpChannelOpen       = amqpMethodID{20, 10}
+	amqpChannelOpenOk     = amqpMethodID{20, 11}
+	amqpChannelClose      = amqpMethodID{20, 40}
+	amqpChannelCloseOk    = amqpMethodID{20, 41}
+	amqpBasicPublish      = amqpMethodID{60, 40}
+	amqpBasicAck          = amqpMethodID{60, 80}
+	amqpBasicNack         = amqpMethodID{60, 120}
+	amqpConfirmSelect     = amqpMethodID{85, 10}
+	amqpConfirmSelectOk   = amqpMethodID{85, 11}
+)
+
+type amqpFrame struct {
+	typ     byte
+	channel uint16
+	payload []byte
+}
+
+// method returns the method id and arguments of a method frame.
+func (f *amqpFrame) method() (amqpMethodID, *amqpReader, error) {
+	if f.typ != amqpFrameMethod || len(f.payload) < 4 {
+		return amqpMethodID{}, nil, fmt.Errorf("expected method frame, got frame type %d", f.typ)
+	}
+	id := amqpMethodID{
+		class:  binary.BigEndian.Uint16(f.payload[0:2]),
+		method: binary.BigEndian.Uint16(f.payload[2:4]),
+	}
+	return id, &amqpReader{buf: f.payload[4:]}, nil
+}
+
+func readAMQPFrame(reader *bufio.Reader) (*amqpFrame, error) {
+	var header [7]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[3:7])
+	payload := make([]byte, size+1)
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return nil, err
+	}
+	if payload[size] != amqpFrameEnd {
+		return nil, errors.New("malformed frame: missing frame end")
+	}
+
+	return &amqpFrame{
+		typ:     header[0],
+		channel: binary.BigEndian.Uint16(header[1:3]),
+		payload: payload[:size],
+	}, nil
+}
+
+func writeAMQPFrame(w io.Writer, typ byte, channel uint16, payload []byte) error {
+	var header [7]byte
+	header[0] = typ
+	binary.BigEndian.PutUint16(header[1:3], channel)
+	binary.BigEndian.PutUint32(header[3:7], uint32(len(payload)))
+
+	_, err := w.Write(slices.Concat(header[:], payload, []byte{amqpFrameEnd}))
+	return err
+}
+
+// amqpWriter encodes method arguments.
+type amqpWriter struct {
+	bytes.Buffer
+}
+
+func newAMQPMethod(id amqpMethodID) *amqpWriter {
+	w := &amqpWriter{}
+	w.short(id.class)
+	w.short(id.method)
+	return w
+}
+
+func (w *amqpWriter) octet(v byte) *amqpWriter {
+	w.WriteByte(v)
+	return w
+}
+
+func (w *amqpWriter) short(v uint16) *amqpWriter {
+	w.Write(binary.BigEndian.AppendUint16(nil, v))
+	return w
+}
+
+func (w *amqpWriter) long(v uint32) *amqpWriter {
+	w.Write(binary.BigEndian.AppendUint32(nil, v))
+	return w
+}
+
+func (w *amqpWriter) longLong(v uint64) *amqpWriter {
+	w.Write(binary.BigEndian.AppendUint64(nil, v))
+	return w
+}
+
+func (w *amqpWriter) shortStr(s string) *amqpWriter {
+	w.WriteByte(byte(len(s)))
+	w.WriteString(s)
+	return w
+}
+
+func (w *amqpWriter) longStr(s string) *amqpWriter {
+	w.long(uint32(len(s)))
+	w.WriteString(s)
+	return w
+}
+
+// stringTable encodes a field table holding string values only.
+func (w *amqpWriter) stringTable(table map[string]string) *amqpWriter {
+	entries := &amqpWriter{}
+	keys := make([]string, 0, len(table))
+	for k := range table {
+		keys = append(keys, k)
+	}
+	slices.Sort(keys)
+	for k := range slices.Values(keys) {
+		entries.shortStr(k).octet('S').longStr(table[k])
+	}
+	w.long(uint32(entries.Len()))
+	w.Write(entries.Bytes())
+	return w
+}
+
+// amqpReader decodes method arguments.
+type amqpReader struct {
+	buf []byte
+	err error
+}
+
+func (r *amqpReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, n)
+	}
+	if len(r.buf) < n {
+		r.err = errors.New("malformed method arguments")
+		return make([]byte, n)
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *amqpReader) octet() byte {
+	return r.next(1)[0]
+}
+
+func (r *amqpReader) short() uint16 {
+	return binary.BigEndian.Uint16(r.next(2))
+}
+
+func (r *amqpReader) long() uint32 {
+	return binary.BigEndian.Uint32(r.next(4))
+}
+
+func (r *amqpReader) longLong() uint64 {
+	return binary.BigEndian.Uint64(r.next(8))
+}
+
+func (r *amqpReader) shortStr() string {
+	return string(r.next(int(r.octet())))
+}
+
+func (r *amqpReader) longStr() string {
+	return string(r.next(int(r.long())))
+}
+
+// amqpCloseReason formats the arguments of a Connection.Close or Channel.Close method.
+func amqpCloseReason(args *amqpReader) string {
+	code := args.short()
+	text := args.shortStr()
+	return fmt.Sprintf("%d %s", code, strings.TrimSpace(text))
+}
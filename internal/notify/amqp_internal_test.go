@@ -0,0 +1,227 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type amqpPublished struct {
+	exchange   string
+	routingKey string
+	body       []byte
+}
+
+// fakeAMQPBroker accepts connections, records the published messages and, in confirm mode, acks or nacks each one of
+// them. The accepted connections are sent on the returned channel, to be dropped by the tests.
+func fakeAMQPBroker(t *testing.T, frameMax uint32, nack bool) (string, <-chan amqpPublished, <-chan net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	published := make(chan amqpPublished, 10)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go serveAMQP(t, conn, frameMax, nack, published)
+		}
+	}()
+
+	return ln.Addr().String(), published, conns
+}
+
+func serveAMQP(t *testing.T, conn net.Conn, frameMax uint32, nack bool, published chan<- amqpPublished) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	send := func(channel uint16, method *amqpWriter) {
+		_ = writeAMQPFrame(conn, amqpFrameMethod, channel, method.Bytes())
+	}
+	expect := func(id amqpMethodID) *amqpReader {
+		args, err := expectAMQPMethod(reader, id)
+		assert.NoError(t, err)
+		return args
+	}
+
+	header := make([]byte, len(amqpProtocolHeader))
+	_, _ = reader.Read(header)
+	assert.Equal(t, amqpProtocolHeader, header)
+
+	send(0, newAMQPMethod(amqpConnectionStart).octet(0).octet(9).stringTable(nil).longStr("AMQPLAIN PLAIN").longStr("en_US"))
+	args := expect(amqpConnectionStartOk)
+	_, _ = args.longStr(), args.shortStr()
+	assert.Equal(t, "\x00user\x00pass", args.longStr())
+
+	send(0, newAMQPMethod(amqpConnectionTune).short(2047).long(frameMax).short(60))
+	expect(amqpConnectionTuneOk)
+	assert.Equal(t, "vhost", expect(amqpConnectionOpen).shortStr())
+	send(0, newAMQPMethod(amqpConnectionOpenOk).shortStr(""))
+	expect(amqpChannelOpen)
+	send(amqpChannel, newAMQPMethod(amqpChannelOpenOk).longStr(""))
+
+	frame, err := readAMQPFrame(reader)
+	if err != nil {
+		return
+	}
+	var confirms bool
+	var tag uint64
+	if id, _, _ := frame.method(); id == amqpConfirmSelect {
+		confirms = true
+		send(amqpChannel, newAMQPMethod(amqpConfirmSelectOk))
+		frame, err = readAMQPFrame(reader)
+	}
+	for ; err == nil; frame, err = readAMQPFrame(reader) {
+		id, args, _ := frame.method()
+		if id != amqpBasicPublish {
+			return
+		}
+		args.short()
+		pub := amqpPublished{exchange: args.shortStr(), routingKey: args.shortStr()}
+
+		contentHeader, err := readAMQPFrame(reader)
+		if !assert.NoError(t, err) {
+			return
+		}
+		size := binary.BigEndian.Uint64(contentHeader.payload[4:12])
+		for uint64(len(pub.body)) < size {
+			body, err := readAMQPFrame(reader)
+			if !assert.NoError(t, err) {
+				return
+			}
+			assert.LessOrEqual(t, len(body.payload)+amqpFrameOverhead, int(frameMax))
+			pub.body = append(pub.body, body.payload...)
+		}
+		published <- pub
+
+		if confirms {
+			tag++
+			if nack {
+				send(amqpChannel, newAMQPMethod(amqpBasicNack).longLong(tag).octet(0))
+				continue
+			}
+			send(amqpChannel, newAMQPMethod(amqpBasicAck).longLong(tag).octet(0))
+		}
+	}
+}
+
+func TestAMQPNotify(t *testing.T) {
+	tests := map[string]struct {
+		opts               []AMQPOption
+		frameMax           uint32
+		nack               bool
+		expectedExchange   string
+		expectedRoutingKey string
+		errContains        string
+	}{
+		"default templates": {
+			expectedExchange:   "amq.topic",
+			expectedRoutingKey: "ethtx.addr-1",
+		},
+		"custom templates with confirms": {
+			opts: []AMQPOption{
+				WithAMQPExchange("eth-{{.BlockNumber}}"),
+				WithAMQPRoutingKey("tx.{{.Address}}.{{.Hash}}"),
+				WithPublisherConfirms(),
+			},
+			expectedExchange:   "eth-1",
+			expectedRoutingKey: "tx.addr-1.tx-1",
+		},
+		"body split across frames": {
+			frameMax:           32,
+			expectedExchange:   "amq.topic",
+			expectedRoutingKey: "ethtx.addr-1",
+		},
+		"nacked by broker": {
+			opts:               []AMQPOption{WithPublisherConfirms()},
+			nack:               true,
+			expectedExchange:   "amq.topic",
+			expectedRoutingKey: "ethtx.addr-1",
+			errContains:        "broker rejected message",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			frameMax := test.frameMax
+			if frameMax == 0 {
+				frameMax = amqpDefaultFrameMax
+			}
+			addr, published, _ := fakeAMQPBroker(t, frameMax, test.nack)
+			a, err := DialAMQP(context.Background(), logrus.New(), "amqp://user:pass@"+addr+"/vhost", test.opts...)
+			require.NoError(t, err)
+			defer a.Close()
+
+			err = a.Notify(context.Background(), &store.TxRecord{
+				Hash:        "tx-1",
+				From:        "addr-1",
+				To:          "addr-1",
+				Value:       big.NewInt(100),
+				BlockNumber: 1,
+				BlockHash:   "hash-1",
+			})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+
+			select {
+			case pub := <-published:
+				assert.Equal(t, test.expectedExchange, pub.exchange)
+				assert.Equal(t, test.expectedRoutingKey, pub.routingKey)
+				var payload Payload
+				require.NoError(t, json.Unmarshal(pub.body, &payload))
+				assert.Equal(t, "tx-1", payload.Hash)
+				assert.Equal(t, "100", payload.Value)
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for publish")
+			}
+		})
+	}
+}
+
+func TestAMQPReconnects(t *testing.T) {
+	addr, published, conns := fakeAMQPBroker(t, amqpDefaultFrameMax, false)
+	a, err := DialAMQP(context.Background(), logrus.New(), "amqp://user:pass@"+addr+"/vhost", WithPublisherConfirms())
+	require.NoError(t, err)
+	defer a.Close()
+
+	tx := &store.TxRecord{Hash: "tx-1", From: "addr-1"}
+	require.NoError(t, a.Notify(context.Background(), tx))
+	<-published
+
+	// the lost connection is redialled and its channel opened again, the publishes failing until it's reconnected
+	conn := <-conns
+	require.NoError(t, conn.Close())
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, a.Notify(context.Background(), tx))
+	}, 5*time.Second, 50*time.Millisecond)
+	select {
+	case pub := <-published:
+		assert.Equal(t, "ethtx.addr-1", pub.routingKey)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for publish")
+	}
+	require.Len(t, conns, 1)
+
+	require.NoError(t, a.Close())
+	assert.ErrorIs(t, a.Notify(context.Background(), tx), ErrAMQPClosed)
+}
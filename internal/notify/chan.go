@@ -0,0 +1,25 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/pipeline/chans"
+)
+
+// Chan sends every recorded transaction to a channel, for embedding the indexer in-process.
+type Chan struct {
+	out chan<- *store.TxRecord
+}
+
+func NewChan(out chan<- *store.TxRecord) *Chan {
+	return &Chan{out: out}
+}
+
+// Notify blocks until the transaction is received from the channel or the context is cancelled.
+func (c *Chan) Notify(ctx context.Context, tx *store.TxRecord) error {
+	if !chans.SendOrDone(ctx, c.out, tx) {
+		return ctx.Err()
+	}
+	return nil
+}
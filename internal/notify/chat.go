@@ -0,0 +1,378 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/addressbook"
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// ChatPlatform is a chat service messages can be sent to.
+type ChatPlatform string
+
+const (
+	ChatPlatformSlack    ChatPlatform = "slack"
+	ChatPlatformDiscord  ChatPlatform = "discord"
+	ChatPlatformTelegram ChatPlatform = "telegram"
+
+	// ChatNotifier is the name the chat notifier is registered under, the channel the notification preferences of the
+	// subscriptions refer to it by.
+	ChatNotifier = "chat"
+
+	// DefaultChatRateLimit is the default number of messages sent per minute to a single chat channel.
+	DefaultChatRateLimit = 20
+	// DefaultExplorerURL is the default block explorer transactions are linked to.
+	DefaultExplorerURL = "https://etherscan.io"
+	// DefaultTelegramAPIURL is the Telegram bot API messages are sent through.
+	DefaultTelegramAPIURL = "https://api.telegram.org"
+)
+
+var (
+	// ErrInvalidChatChannel is returned when a chat channel can't be parsed.
+	ErrInvalidChatChannel = errors.New("invalid chat channel")
+
+	weiPerEther = new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+)
+
+// ChatChannel is a chat channel messages are sent to.
+type ChatChannel struct {
+	Platform ChatPlatform
+	// URL is the incoming webhook URL of Slack and Discord channels.
+	URL string
+	// BotToken and ChatID identify Telegram chats.
+	BotToken string
+	ChatID   string
+}
+
+// ParseChatChannel parses a chat channel given as "slack:<incoming webhook url>", "discord:<webhook url>" or
+// "telegram:<bot token>@<chat id>".
+func ParseChatChannel(spec string) (*ChatChannel, error) {
+	platform, target, ok := strings.Cut(strings.TrimSpace(spec), ":")
+	if !ok || target == "" {
+		return nil, fmt.Errorf("%w: expected <platform>:<target>", ErrInvalidChatChannel)
+	}
+
+	channel := &ChatChannel{Platform: ChatPlatform(platform)}
+	switch channel.Platform {
+	case ChatPlatformSlack, ChatPlatformDiscord:
+		u, err := url.Parse(target)
+		if err != nil || u.Scheme != "https" || u.Host == "" {
+			return nil, fmt.Errorf("%w: expected an https webhook URL", ErrInvalidChatChannel)
+		}
+		channel.URL = target
+		return channel, nil
+	case ChatPlatformTelegram:
+		idx := strings.LastIndex(target, "@")
+		if idx <= 0 || idx == len(target)-1 {
+			return nil, fmt.Errorf("%w: expected <bot token>@<chat id>", ErrInvalidChatChannel)
+		}
+		channel.BotToken, channel.ChatID = target[:idx], target[idx+1:]
+		return channel, nil
+	default:
+		return nil, fmt.Errorf("%w: unknown platform %q", ErrInvalidChatChannel, platform)
+	}
+}
+
+// key identifies the channel for rate limiting.
+func (c *ChatChannel) key() string {
+	if c.Platform == ChatPlatformTelegram {
+		return string(c.Platform) + ":" + c.BotToken + "@" + c.ChatID
+	}
+	return string(c.Platform) + ":" + c.URL
+}
+
+type chatConfig struct {
+	channels       []*ChatChannel
+	rateLimit      int
+	explorerURL    string
+	telegramAPIURL string
+	maxElapsedTime time.Duration
+	registerer     prometheus.Registerer
+}
+
+type ChatOption func(*chatConfig)
+
+// WithChatChannels sends the transactions of every subscribed address to the given channels, besides the channels
+// of their subscriptions.
+func WithChatChannels(channels ...*ChatChannel) ChatOption {
+	return func(c *chatConfig) {
+		c.channels = append(c.channels, channels...)
+	}
+}
+
+// WithChatRateLimit sets the number of messages sent per minute to a single channel, messages over the limit being
+// dropped.
+func WithChatRateLimit(perMinute int) ChatOption {
+	return func(c *chatConfig) {
+		if perMinute > 0 {
+			c.rateLimit = perMinute
+		}
+	}
+}
+
+// WithExplorerURL sets the block explorer transactions are linked to, e.g. https://sepolia.etherscan.io.
+func WithExplorerURL(explorerURL string) ChatOption {
+	return func(c *chatConfig) {
+		c.explorerURL = strings.TrimSuffix(explorerURL, "/")
+	}
+}
+
+// WithTelegramAPIURL sets the Telegram bot API messages are sent through.
+func WithTelegramAPIURL(apiURL string) ChatOption {
+	return func(c *chatConfig) {
+		c.telegramAPIURL = strings.TrimSuffix(apiURL, "/")
+	}
+}
+
+// WithChatRegisterer sets the registerer the metrics of the chat notifier are registered with, the custom registry by
+// default.
+func WithChatRegisterer(reg prometheus.Registerer) ChatOption {
+	return func(c *chatConfig) {
+		c.registerer = reg
+	}
+}
+
+// Chat sends a message to Slack, Discord or Telegram channels when a subscribed address transacts.
+type Chat struct {
+	logger     *logrus.Logger
+	httpClient *http.Client
+	subsStore  SubscriptionStore
+	cfg        *chatConfig
+	metrics    *metrics
+	limiter    *rateLimiter
+}
+
+func NewChat(logger *logrus.Logger, httpClient *http.Client, subsStore SubscriptionStore, opts ...ChatOption) *Chat {
+	cfg := &chatConfig{
+		rateLimit:      DefaultChatRateLimit,
+		explorerURL:    DefaultExplorerURL,
+		telegramAPIURL: DefaultTelegramAPIURL,
+		maxElapsedTime: DefaultMaxElapsedTime,
+		registerer:     custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Chat{
+		logger:     logger,
+		httpClient: httpClient,
+		subsStore:  subsStore,
+		cfg:        cfg,
+		metrics:    newMetrics(cfg.registerer),
+		limiter:    newRateLimiter(cfg.rateLimit, time.Minute),
+	}
+}
+
+// Notify sends a message about the transaction, for each of its subscribed sender and recipient, to the configured
+// channels and the channel of their subscription.
+func (c *Chat) Notify(ctx context.Context, tx *store.TxRecord) error {
+	var errs []error
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		sub, err := c.subsStore.GetSubscription(ctx, addr)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("could not get subscription for %q: %w", addr, err))
+			continue
+		}
+		if !sub.Accepts(tx) || !sub.Preferences.Notifies(ChatNotifier, tx) {
+			continue
+		}
+
+		channels := c.cfg.channels
+		if sub.ChatChannel != "" {
+			channel, err := ParseChatChannel(sub.ChatChannel)
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not parse chat channel of %q: %w", addr, err))
+			} else {
+				channels = append(slices.Clip(channels), channel)
+			}
+		}
+
+		text := chatMessage(addr, tx, c.cfg.explorerURL)
+		for channel := range slices.Values(channels) {
+			if !c.limiter.allow(channel.key(), time.Now()) {
+				c.logger.WithContext(ctx).WithFields(logrus.Fields{
+					"addr":     addr,
+					"tx_hash":  tx.Hash,
+					"platform": channel.Platform,
+				}).Warn("Chat channel rate limit exceeded, dropping message")
+				c.metrics.chatMessages.WithLabelValues(string(channel.Platform), "rate_limited").Inc()
+				continue
+			}
+			err = c.send(ctx, channel, text)
+			c.metrics.chatMessages.WithLabelValues(string(channel.Platform), result(err)).Inc()
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not send %s message for %q: %w", channel.Platform, addr, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// send posts the message to the channel, retrying on rate limiting and server errors.
+func (c *Chat) send(ctx context.Context, channel *ChatChannel, text string) error {
+	endpoint := channel.URL
+	var payload any
+	switch channel.Platform {
+	case ChatPlatformSlack:
+		payload = map[string]any{"text": text}
+	case ChatPlatformDiscord:
+		payload = map[string]any{"content": text}
+	default:
+		endpoint = c.cfg.telegramAPIURL + "/bot" + channel.BotToken + "/sendMessage"
+		payload = map[string]any{"chat_id": channel.ChatID, "text": text, "disable_web_page_preview": true}
+	}
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal chat message: %w", err)
+	}
+
+	bo := backoff.WithContext(newExponentialBackoffConfig(c.cfg.maxElapsedTime), ctx)
+	return backoff.Retry(func() error {
+		return c.post(ctx, endpoint, body)
+	}, bo)
+}
+
+func (c *Chat) post(ctx context.Context, endpoint string, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, endpoint, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("could not create chat request: %w", err))
+	}
+	req.Header.Set("Content-Type", "application/json")
+
+	resp, err := c.httpClient.Do(req)
+	if err != nil {
+		if errors.Is(err, context.DeadlineExceeded) || errors.Is(err, context.Canceled) {
+			return backoff.Permanent(fmt.Errorf("could not make http call: %w", err))
+		}
+		// the error may hold the url and its bot token
+		return errors.New("http request failed")
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		return backoff.Permanent(fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+}
+
+// chatMessage formats the transaction from the point of view of the given address, e.g.
+// "0xabc… received 1.5 ETH from 0xdef… (Binance 14) in block 123" followed by a link to the transaction.
+func chatMessage(addr string, tx *store.TxRecord, explorerURL string) string {
+	summary := txSummary(addr, tx)
+	if tx.Removed {
+		summary = "[removed] " + summary
+	}
+	return summary + "\n" + txLink(explorerURL, tx.Hash)
+}
+
+// txSummary describes the transaction from the point of view of the given address.
+func txSummary(addr string, tx *store.TxRecord) string {
+	var b strings.Builder
+	value := formatEther(tx.Value)
+	switch {
+	case tx.From == addr && tx.To == addr:
+		fmt.Fprintf(&b, "%s sent %s ETH to itself", addr, value)
+	case tx.From == addr && tx.To == "":
+		fmt.Fprintf(&b, "%s created a contract with %s ETH", addr, value)
+	case tx.From == addr:
+		fmt.Fprintf(&b, "%s sent %s ETH to %s", addr, value, labelled(tx.To, tx.Labels[addressbook.ToLabel]))
+	default:
+		fmt.Fprintf(&b, "%s received %s ETH from %s", addr, value, labelled(tx.From, tx.Labels[addressbook.FromLabel]))
+	}
+	if tx.ValueUSD != "" {
+		fmt.Fprintf(&b, " (~$%s)", tx.ValueUSD)
+	}
+	fmt.Fprintf(&b, " in block %d", tx.BlockNumber)
+	return b.String()
+}
+
+func txLink(explorerURL, hash string) string {
+	return explorerURL + "/tx/" + hash
+}
+
+func labelled(addr, label string) string {
+	if label == "" {
+		return addr
+	}
+	return addr + " (" + label + ")"
+}
+
+// formatEther formats the wei value in ether, without trailing zeros.
+func formatEther(wei *big.Int) string {
+	if wei == nil {
+		return "0"
+	}
+	ether := new(big.Rat).SetInt(wei)
+	ether.Quo(ether, weiPerEther)
+	s := strings.TrimRight(ether.FloatString(18), "0")
+	return strings.TrimSuffix(s, ".")
+}
+
+// rateLimiter is a token bucket rate limiter per key.
+type rateLimiter struct {
+	mu       sync.Mutex
+	capacity float64
+	perSec   float64
+	buckets  map[string]*tokenBucket
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter allows n events per interval for every key, in bursts of up to n.
+func newRateLimiter(n int, interval time.Duration) *rateLimiter {
+	return &rateLimiter{
+		capacity: float64(n),
+		perSec:   float64(n) / interval.Seconds(),
+		buckets:  make(map[string]*tokenBucket),
+	}
+}
+
+func (l *rateLimiter) allow(key string, now time.Time) bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	bucket, ok := l.buckets[key]
+	if !ok {
+		bucket = &tokenBucket{tokens: l.capacity, last: now}
+		l.buckets[key] = bucket
+	}
+	bucket.tokens = min(l.capacity, bucket.tokens+now.Sub(bucket.last).Seconds()*l.perSec)
+	bucket.last = now
+	if bucket.tokens < 1 {
+		return false
+	}
+	bucket.tokens--
+	return true
+}
@@ -0,0 +1,115 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/notify"
+	"github.com/hedisam/ethtxparser/internal/notify/mocks"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+func TestParseChatChannel(t *testing.T) {
+	tests := map[string]struct {
+		spec            string
+		expectedChannel *notify.ChatChannel
+	}{
+		"slack": {
+			spec:            "slack:https://hooks.slack.com/services/T0/B0/x",
+			expectedChannel: &notify.ChatChannel{Platform: notify.ChatPlatformSlack, URL: "https://hooks.slack.com/services/T0/B0/x"},
+		},
+		"discord": {
+			spec:            "discord:https://discord.com/api/webhooks/1/x",
+			expectedChannel: &notify.ChatChannel{Platform: notify.ChatPlatformDiscord, URL: "https://discord.com/api/webhooks/1/x"},
+		},
+		"telegram": {
+			spec:            "telegram:123456:ABC-def@-1001234",
+			expectedChannel: &notify.ChatChannel{Platform: notify.ChatPlatformTelegram, BotToken: "123456:ABC-def", ChatID: "-1001234"},
+		},
+		"plain http webhook":  {spec: "slack:http://hooks.slack.com/services/x"},
+		"missing chat id":     {spec: "telegram:123456:ABC-def@"},
+		"unknown platform":    {spec: "teams:https://example.com/hook"},
+		"missing platform":    {spec: "https//example.com"},
+		"missing webhook url": {spec: "discord:"},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			channel, err := notify.ParseChatChannel(test.spec)
+			if test.expectedChannel == nil {
+				assert.ErrorIs(t, err, notify.ErrInvalidChatChannel)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedChannel, channel)
+		})
+	}
+}
+
+func TestChatNotify(t *testing.T) {
+	const (
+		subscribed = "0x00000000000000000000000000000000000000aa"
+		sender     = "0x00000000000000000000000000000000000000bb"
+	)
+
+	var mu sync.Mutex
+	received := make(map[string][]map[string]any)
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var body map[string]any
+		require.NoError(t, json.NewDecoder(r.Body).Decode(&body))
+		mu.Lock()
+		received[r.URL.Path] = append(received[r.URL.Path], body)
+		mu.Unlock()
+	}))
+	defer srv.Close()
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != subscribed {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr, ChatChannel: "discord:" + srv.URL + "/discord"}, nil
+		},
+	}
+	slack, err := notify.ParseChatChannel("slack:" + srv.URL + "/slack")
+	require.NoError(t, err)
+	telegram, err := notify.ParseChatChannel("telegram:42:token@-100")
+	require.NoError(t, err)
+
+	chat := notify.NewChat(logrus.New(), srv.Client(), subsStoreMock,
+		notify.WithChatChannels(slack, telegram),
+		notify.WithChatRateLimit(2),
+		notify.WithTelegramAPIURL(srv.URL),
+	)
+
+	value, _ := new(big.Int).SetString("1500000000000000000", 10)
+	tx := &store.TxRecord{
+		Hash:        "0xabc",
+		From:        sender,
+		To:          subscribed,
+		Value:       value,
+		BlockNumber: 123,
+		Labels:      map[string]string{"from": "Binance 14"},
+	}
+	for range 3 {
+		err = chat.Notify(context.Background(), tx)
+		require.NoError(t, err)
+	}
+
+	text := subscribed + " received 1.5 ETH from " + sender + " (Binance 14) in block 123\nhttps://etherscan.io/tx/0xabc"
+	// the third message to every channel is dropped by the rate limit
+	assert.Equal(t, []map[string]any{{"text": text}, {"text": text}}, received["/slack"])
+	assert.Equal(t, []map[string]any{{"content": text}, {"content": text}}, received["/discord"])
+	require.Len(t, received["/bot42:token/sendMessage"], 2)
+	assert.Equal(t, "-100", received["/bot42:token/sendMessage"][0]["chat_id"])
+	assert.Equal(t, text, received["/bot42:token/sendMessage"][0]["text"])
+}
@@ -0,0 +1,328 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"mime"
+	"net"
+	"net/smtp"
+	"slices"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// EmailNotifier is the name the email notifier is registered under, the channel the notification preferences of
+	// the subscriptions refer to it by.
+	EmailNotifier = "email"
+
+	// DefaultEmailSubjectTemplate is the default template of email subjects.
+	DefaultEmailSubjectTemplate = `{{if .Removed}}[reorg] {{end}}{{len .Transactions}} transaction{{if gt (len .Transactions) 1}}s{{end}} of watched addresses`
+	// DefaultEmailBodyTemplate is the default template of email bodies.
+	DefaultEmailBodyTemplate = `{{range .Transactions}}{{if .Removed}}[removed by a chain reorganisation] {{end}}{{.Summary}}
+{{.Link}}
+
+{{end}}`
+)
+
+// EmailTransaction is a transaction listed in an email, from the point of view of the watched address.
+type EmailTransaction struct {
+	Address     string
+	Hash        string
+	From        string
+	To          string
+	Value       string
+	ValueUSD    string
+	BlockNumber int64
+	Removed     bool
+	// Summary describes the transaction, e.g. "0xabc… received 1.5 ETH from 0xdef… in block 123".
+	Summary string
+	// Link is the URL of the transaction on the block explorer.
+	Link string
+}
+
+// EmailData is the data email subject and body templates are executed with.
+type EmailData struct {
+	Transactions []*EmailTransaction
+	// Removed is set if any of the transactions was removed by a chain reorganisation.
+	Removed bool
+}
+
+type emailConfig struct {
+	username        string
+	password        string
+	recipients      []string
+	digestInterval  time.Duration
+	subjectTemplate string
+	bodyTemplate    string
+	explorerURL     string
+	registerer      prometheus.Registerer
+}
+
+type EmailOption func(*emailConfig)
+
+// WithEmailAuth authenticates to the SMTP server with the given credentials, using PLAIN auth which requires TLS
+// unless the server is on localhost.
+func WithEmailAuth(username, password string) EmailOption {
+	return func(c *emailConfig) {
+		c.username = username
+		c.password = password
+	}
+}
+
+// WithEmailRecipients emails the transactions of every subscribed address to the given recipients, besides the email
+// addresses of their subscriptions.
+func WithEmailRecipients(recipients ...string) EmailOption {
+	return func(c *emailConfig) {
+		c.recipients = append(c.recipients, recipients...)
+	}
+}
+
+// WithDigestInterval batches the transactions of each recipient into a single digest email sent every interval,
+// instead of an email per transaction.
+func WithDigestInterval(interval time.Duration) EmailOption {
+	return func(c *emailConfig) {
+		c.digestInterval = interval
+	}
+}
+
+// WithEmailTemplates sets the text/template subject and body of emails, executed with an EmailData. Empty templates
+// keep the default ones.
+func WithEmailTemplates(subject, body string) EmailOption {
+	return func(c *emailConfig) {
+		if subject != "" {
+			c.subjectTemplate = subject
+		}
+		if body != "" {
+			c.bodyTemplate = body
+		}
+	}
+}
+
+// WithEmailExplorerURL sets the block explorer transactions are linked to.
+func WithEmailExplorerURL(explorerURL string) EmailOption {
+	return func(c *emailConfig) {
+		c.explorerURL = strings.TrimSuffix(explorerURL, "/")
+	}
+}
+
+// WithEmailRegisterer sets the registerer the metrics of the email notifier are registered with, the custom registry by
+// default.
+func WithEmailRegisterer(reg prometheus.Registerer) EmailOption {
+	return func(c *emailConfig) {
+		c.registerer = reg
+	}
+}
+
+// Email sends emails about the transactions of subscribed addresses through an SMTP server, either one per
+// transaction or batched into periodic digests.
+type Email struct {
+	logger   *logrus.Logger
+	smtpAddr string
+	from     string
+	subs     SubscriptionStore
+	cfg      *emailConfig
+	metrics  *metrics
+	auth     smtp.Auth
+	subject  *template.Template
+	body     *template.Template
+	// sendMail is smtp.SendMail, replaced in tests.
+	sendMail func(addr string, a smtp.Auth, from string, to []string, msg []byte) error
+
+	mu sync.Mutex
+	// pending holds the transactions of the next digest by recipient.
+	pending map[string][]*EmailTransaction
+}
+
+// NewEmail returns an email notifier sending from the given address through the SMTP server at smtpAddr, given as
+// host:port.
+func NewEmail(logger *logrus.Logger, smtpAddr, from string, subsStore SubscriptionStore, opts ...EmailOption) (*Email, error) {
+	cfg := &emailConfig{
+		subjectTemplate: DefaultEmailSubjectTemplate,
+		bodyTemplate:    DefaultEmailBodyTemplate,
+		explorerURL:     DefaultExplorerURL,
+		registerer:      custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	subject, err := template.New("subject").Parse(cfg.subjectTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse email subject template: %w", err)
+	}
+	body, err := template.New("body").Parse(cfg.bodyTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("could not parse email body template: %w", err)
+	}
+
+	var auth smtp.Auth
+	if cfg.username != "" {
+		host, _, err := net.SplitHostPort(smtpAddr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid smtp server address %q: %w", smtpAddr, err)
+		}
+		auth = smtp.PlainAuth("", cfg.username, cfg.password, host)
+	}
+
+	return &Email{
+		logger:   logger,
+		smtpAddr: smtpAddr,
+		from:     from,
+		subs:     subsStore,
+		cfg:      cfg,
+		metrics:  newMetrics(cfg.registerer),
+		auth:     auth,
+		subject:  subject,
+		body:     body,
+		sendMail: smtp.SendMail,
+		pending:  make(map[string][]*EmailTransaction),
+	}, nil
+}
+
+// Start sends the pending digests every digest interval until the context is done, then sends the last ones. It
+// returns straight away if digests are disabled.
+func (e *Email) Start(ctx context.Context) {
+	if e.cfg.digestInterval <= 0 {
+		return
+	}
+
+	ticker := time.NewTicker(e.cfg.digestInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			// the context is done, the last digests are sent on a fresh one
+			e.flush(context.WithoutCancel(ctx))
+			return
+		case <-ticker.C:
+			e.flush(ctx)
+		}
+	}
+}
+
+// Notify emails the transaction, or adds it to the next digest, for each of its subscribed sender and recipient.
+// Removed transactions are sent as reorg alerts.
+func (e *Email) Notify(ctx context.Context, tx *store.TxRecord) error {
+	var errs []error
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		sub, err := e.subs.GetSubscription(ctx, addr)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("could not get subscription for %q: %w", addr, err))
+			continue
+		}
+		if !sub.Accepts(tx) || !sub.Preferences.Notifies(EmailNotifier, tx) {
+			continue
+		}
+
+		recipients := e.cfg.recipients
+		if sub.Email != "" {
+			recipients = append(slices.Clip(recipients), sub.Email)
+		}
+		item := e.emailTransaction(addr, tx)
+		for recipient := range slices.Values(recipients) {
+			if e.cfg.digestInterval > 0 {
+				e.mu.Lock()
+				e.pending[recipient] = append(e.pending[recipient], item)
+				e.mu.Unlock()
+				continue
+			}
+			err = e.send(recipient, []*EmailTransaction{item})
+			if err != nil {
+				errs = append(errs, fmt.Errorf("could not email %q: %w", addr, err))
+			}
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// flush sends the pending digests, one email per recipient. Digests that fail to send are dropped.
+func (e *Email) flush(ctx context.Context) {
+	e.mu.Lock()
+	pending := e.pending
+	e.pending = make(map[string][]*EmailTransaction)
+	e.mu.Unlock()
+
+	for recipient := range maps.Keys(pending) {
+		err := e.send(recipient, pending[recipient])
+		if err != nil {
+			e.logger.WithContext(ctx).WithError(err).WithField("transactions", len(pending[recipient])).
+				Error("Failed to send email digest")
+		}
+	}
+}
+
+func (e *Email) send(recipient string, txs []*EmailTransaction) error {
+	msg, err := e.message(recipient, txs)
+	if err != nil {
+		e.metrics.emails.WithLabelValues("failure").Inc()
+		return err
+	}
+
+	err = e.sendMail(e.smtpAddr, e.auth, e.from, []string{recipient}, msg)
+	e.metrics.emails.WithLabelValues(result(err)).Inc()
+	if err != nil {
+		return fmt.Errorf("could not send email: %w", err)
+	}
+	return nil
+}
+
+// message renders the email listing the given transactions.
+func (e *Email) message(recipient string, txs []*EmailTransaction) ([]byte, error) {
+	data := &EmailData{
+		Transactions: txs,
+		Removed: slices.ContainsFunc(txs, func(tx *EmailTransaction) bool {
+			return tx.Removed
+		}),
+	}
+
+	var subject, body bytes.Buffer
+	err := e.subject.Execute(&subject, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute email subject template: %w", err)
+	}
+	err = e.body.Execute(&body, data)
+	if err != nil {
+		return nil, fmt.Errorf("could not execute email body template: %w", err)
+	}
+
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", e.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", recipient)
+	fmt.Fprintf(&msg, "Subject: %s\r\n", mime.QEncoding.Encode("utf-8", strings.TrimSpace(subject.String())))
+	fmt.Fprintf(&msg, "Date: %s\r\n", time.Now().Format(time.RFC1123Z))
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=utf-8\r\n\r\n")
+	msg.WriteString(strings.ReplaceAll(body.String(), "\n", "\r\n"))
+	return msg.Bytes(), nil
+}
+
+func (e *Email) emailTransaction(addr string, tx *store.TxRecord) *EmailTransaction {
+	return &EmailTransaction{
+		Address:     addr,
+		Hash:        tx.Hash,
+		From:        tx.From,
+		To:          tx.To,
+		Value:       formatEther(tx.Value),
+		ValueUSD:    tx.ValueUSD,
+		BlockNumber: tx.BlockNumber,
+		Removed:     tx.Removed,
+		Summary:     txSummary(addr, tx),
+		Link:        txLink(e.cfg.explorerURL, tx.Hash),
+	}
+}
@@ -0,0 +1,145 @@
+package notify
+
+import (
+	"context"
+	"math/big"
+	"net/smtp"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/notify/mocks"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type sentEmail struct {
+	to  []string
+	msg string
+}
+
+func TestEmailNotify(t *testing.T) {
+	const (
+		subscribed = "0x00000000000000000000000000000000000000aa"
+		sender     = "0x00000000000000000000000000000000000000bb"
+	)
+	tx := &store.TxRecord{
+		Hash:        "0x01",
+		From:        sender,
+		To:          subscribed,
+		Value:       big.NewInt(1500000000000000000),
+		BlockNumber: 10,
+	}
+	removed := &store.TxRecord{
+		Hash:        "0x02",
+		From:        subscribed,
+		To:          sender,
+		Value:       big.NewInt(1),
+		BlockNumber: 11,
+		Removed:     true,
+	}
+
+	tests := map[string]struct {
+		opts           []EmailOption
+		txs            []*store.TxRecord
+		expectedEmails map[string][]string
+	}{
+		"email per transaction": {
+			opts: []EmailOption{WithEmailRecipients("ops@example.com")},
+			txs:  []*store.TxRecord{tx, removed},
+			expectedEmails: map[string][]string{
+				"alice@example.com": {
+					"Subject: 1 transaction of watched addresses",
+					"Subject: [reorg] 1 transaction of watched addresses",
+				},
+				"ops@example.com": {
+					"Subject: 1 transaction of watched addresses",
+					"Subject: [reorg] 1 transaction of watched addresses",
+				},
+			},
+		},
+		"digest": {
+			opts: []EmailOption{WithDigestInterval(time.Hour)},
+			txs:  []*store.TxRecord{tx, removed},
+			expectedEmails: map[string][]string{
+				"alice@example.com": {"Subject: [reorg] 2 transactions of watched addresses"},
+			},
+		},
+		"custom templates": {
+			opts: []EmailOption{WithEmailTemplates(
+				`{{range .Transactions}}{{.Hash}}{{end}}`,
+				`{{range .Transactions}}{{.Value}} ETH in block {{.BlockNumber}}{{end}}`,
+			)},
+			txs: []*store.TxRecord{tx},
+			expectedEmails: map[string][]string{
+				"alice@example.com": {"Subject: 0x01", "1.5 ETH in block 10"},
+			},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != subscribed {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{Address: addr, Email: "alice@example.com"}, nil
+				},
+			}
+			email, err := NewEmail(logrus.New(), "localhost:25", "ethtxparser@example.com", subsStoreMock, test.opts...)
+			require.NoError(t, err)
+
+			var mu sync.Mutex
+			var sent []sentEmail
+			email.sendMail = func(addr string, _ smtp.Auth, from string, to []string, msg []byte) error {
+				assert.Equal(t, "localhost:25", addr)
+				assert.Equal(t, "ethtxparser@example.com", from)
+				mu.Lock()
+				defer mu.Unlock()
+				sent = append(sent, sentEmail{to: to, msg: string(msg)})
+				return nil
+			}
+
+			for tx := range slices.Values(test.txs) {
+				require.NoError(t, email.Notify(context.Background(), tx))
+			}
+			ctx, cancel := context.WithCancel(context.Background())
+			cancel()
+			// returns straight away without digests, sends the pending ones otherwise
+			email.Start(ctx)
+
+			mu.Lock()
+			defer mu.Unlock()
+			byRecipient := make(map[string][]string)
+			for e := range slices.Values(sent) {
+				require.Len(t, e.to, 1)
+				byRecipient[e.to[0]] = append(byRecipient[e.to[0]], e.msg)
+			}
+			require.Len(t, byRecipient, len(test.expectedEmails))
+			for recipient, expected := range test.expectedEmails {
+				msgs := byRecipient[recipient]
+				for want := range slices.Values(expected) {
+					assert.True(t, slices.ContainsFunc(msgs, func(msg string) bool {
+						return strings.Contains(msg, want)
+					}), "no email to %s contains %q", recipient, want)
+				}
+				for msg := range slices.Values(msgs) {
+					assert.Contains(t, msg, "To: "+recipient+"\r\n")
+					assert.Contains(t, msg, "Content-Type: text/plain; charset=utf-8\r\n")
+				}
+			}
+		})
+	}
+}
+
+func TestNewEmailInvalidTemplate(t *testing.T) {
+	_, err := NewEmail(logrus.New(), "localhost:25", "ethtxparser@example.com", &mocks.SubscriptionStoreMock{},
+		WithEmailTemplates("{{.Transactions", ""))
+	require.Error(t, err)
+}
@@ -0,0 +1,420 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"maps"
+	"net"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// DefaultKafkaTopic is the default topic transactions are produced to.
+	DefaultKafkaTopic = "ethtx"
+	// DefaultKafkaAckTimeout is the default time to wait for the in-sync replicas to acknowledge a produced message.
+	DefaultKafkaAckTimeout = time.Second * 5
+
+	kafkaDialTimeout = time.Second * 5
+	kafkaDefaultPort = "9092"
+)
+
+var (
+	// ErrKafkaClosed is returned when producing on a closed Kafka producer.
+	ErrKafkaClosed = errors.New("kafka producer closed")
+	// ErrKafkaDisconnected is returned when producing while the metadata of the topic is refreshed, or when the
+	// connection to the leader of the partition is lost.
+	ErrKafkaDisconnected = errors.New("kafka partition leader lost, refreshing metadata")
+)
+
+type kafkaConfig struct {
+	topic      string
+	ackTimeout time.Duration
+	registerer prometheus.Registerer
+}
+
+type KafkaOption func(*kafkaConfig)
+
+// WithKafkaTopic sets the topic transactions are produced to.
+func WithKafkaTopic(topic string) KafkaOption {
+	return func(c *kafkaConfig) {
+		if topic != "" {
+			c.topic = topic
+		}
+	}
+}
+
+// WithKafkaAckTimeout sets the time to wait for the in-sync replicas to acknowledge a produced message.
+func WithKafkaAckTimeout(d time.Duration) KafkaOption {
+	return func(c *kafkaConfig) {
+		if d > 0 {
+			c.ackTimeout = d
+		}
+	}
+}
+
+// WithKafkaRegisterer sets the registerer the metrics of the Kafka notifier are registered with, the custom registry
+// by default.
+func WithKafkaRegisterer(reg prometheus.Registerer) KafkaOption {
+	return func(c *kafkaConfig) {
+		c.registerer = reg
+	}
+}
+
+// Kafka produces recorded transactions to a Kafka topic, once per subscribed sender and recipient, keyed by the
+// address so the transactions of an address keep their order within its partition. It speaks the Kafka protocol
+// directly, producing every message to the leader of its partition and waiting for all the in-sync replicas to
+// acknowledge it. Once a leader moves or can't be dialled, the metadata of the topic is fetched again from the
+// bootstrap brokers with backoff, until refreshed or closed, every publish failing with ErrKafkaDisconnected
+// meanwhile.
+type Kafka struct {
+	logger  *logrus.Logger
+	cfg     *kafkaConfig
+	metrics *metrics
+	brokers []string
+
+	// ctx is cancelled once closed, stopping the metadata refreshes.
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	// partitions are the partitions of the topic and their leaders, nil while the metadata is refreshed.
+	partitions []kafkaPartition
+	// conns are the connections to the partition leaders by address, dialled on their first publish.
+	conns map[string]*kafkaConn
+
+	closeOnce sync.Once
+}
+
+// kafkaConn is a connection to a partition leader.
+type kafkaConn struct {
+	addr string
+	conn net.Conn
+
+	// writeMu serialises the requests so correlation ids follow the order they're written in.
+	writeMu           sync.Mutex
+	writer            *bufio.Writer
+	nextCorrelationID int32
+
+	pendingMu sync.Mutex
+	pending   map[int32]chan *kafkaReader
+
+	// lost is closed once the connection is lost or closed, when its read loop returns.
+	lost chan struct{}
+}
+
+// DialKafka fetches the metadata of the topic from the given comma separated bootstrap brokers, e.g.
+// localhost:9092,localhost:9093.
+func DialKafka(ctx context.Context, logger *logrus.Logger, brokers string, opts ...KafkaOption) (*Kafka, error) {
+	cfg := &kafkaConfig{
+		topic:      DefaultKafkaTopic,
+		ackTimeout: DefaultKafkaAckTimeout,
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	var addrs []string
+	for broker := range strings.SplitSeq(brokers, ",") {
+		broker = strings.TrimSpace(broker)
+		if broker == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(broker); err != nil {
+			broker = net.JoinHostPort(broker, kafkaDefaultPort)
+		}
+		addrs = append(addrs, broker)
+	}
+	if len(addrs) == 0 {
+		return nil, errors.New("no kafka bootstrap brokers given")
+	}
+
+	k := &Kafka{
+		logger:  logger,
+		cfg:     cfg,
+		metrics: newMetrics(cfg.registerer),
+		brokers: addrs,
+		conns:   make(map[string]*kafkaConn),
+	}
+	k.ctx, k.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	var err error
+	k.partitions, err = k.fetchMetadata(ctx)
+	if err != nil {
+		k.cancel()
+		return nil, err
+	}
+	return k, nil
+}
+
+// fetchMetadata fetches the partitions of the topic and their leaders from the first bootstrap broker answering.
+func (k *Kafka) fetchMetadata(ctx context.Context) ([]kafkaPartition, error) {
+	var errs []error
+	for addr := range slices.Values(k.brokers) {
+		partitions, err := fetchKafkaMetadata(ctx, addr, k.cfg.topic)
+		if err == nil {
+			return partitions, nil
+		}
+		errs = append(errs, fmt.Errorf("%s: %w", addr, err))
+	}
+	return nil, fmt.Errorf("could not fetch kafka metadata: %w", errors.Join(errs...))
+}
+
+// refresh fetches the metadata of the topic again with backoff, once a partition leader moved or couldn't be
+// dialled, until refreshed or closed. The connections to the previous leaders are closed.
+func (k *Kafka) refresh() {
+	k.mu.Lock()
+	if k.partitions == nil || k.ctx.Err() != nil {
+		// already refreshing, or closed
+		k.mu.Unlock()
+		return
+	}
+	k.partitions = nil
+	conns := k.conns
+	k.conns = make(map[string]*kafkaConn)
+	k.mu.Unlock()
+	for c := range maps.Values(conns) {
+		_ = c.conn.Close()
+	}
+
+	var partitions []kafkaPartition
+	bo := backoff.WithContext(newExponentialBackoffConfig(0), k.ctx)
+	err := backoff.RetryNotify(func() error {
+		var err error
+		partitions, err = k.fetchMetadata(k.ctx)
+		return err
+	}, bo, func(err error, next time.Duration) {
+		k.logger.WithError(err).WithField("retry_in", next).Warn("Failed to refresh Kafka metadata, retrying...")
+	})
+	if err != nil {
+		return
+	}
+
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.ctx.Err() != nil {
+		return
+	}
+	k.partitions = partitions
+	k.metrics.kafkaMetadataRefreshes.Inc()
+	k.logger.Info("Refreshed Kafka metadata")
+}
+
+// leader returns the partition of the key and the connection to its leader, dialling it on its first publish.
+func (k *Kafka) leader(ctx context.Context, key string) (*kafkaConn, int32, error) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	if k.ctx.Err() != nil {
+		return nil, 0, ErrKafkaClosed
+	}
+	if k.partitions == nil {
+		return nil, 0, ErrKafkaDisconnected
+	}
+
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	p := k.partitions[hash.Sum32()%uint32(len(k.partitions))]
+	if p.leader == "" {
+		go k.refresh()
+		return nil, 0, fmt.Errorf("no leader for partition %d: %w", p.id, ErrKafkaDisconnected)
+	}
+	c, ok := k.conns[p.leader]
+	if ok {
+		return c, p.id, nil
+	}
+
+	conn, err := dialKafka(ctx, p.leader)
+	if err != nil {
+		go k.refresh()
+		return nil, 0, err
+	}
+	c = &kafkaConn{
+		addr:    p.leader,
+		conn:    conn,
+		writer:  bufio.NewWriter(conn),
+		pending: make(map[int32]chan *kafkaReader),
+		lost:    make(chan struct{}),
+	}
+	k.conns[p.leader] = c
+	go k.readLoop(c, bufio.NewReader(conn))
+	return c, p.id, nil
+}
+
+// Notify produces the transaction once for each of its unique sender and recipient addresses, keyed by the address.
+func (k *Kafka) Notify(ctx context.Context, tx *store.TxRecord) error {
+	var errs []error
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		data, err := json.Marshal(newPayload(addr, tx))
+		if err != nil {
+			return fmt.Errorf("could not marshal transaction payload: %w", err)
+		}
+
+		err = k.publish(ctx, addr, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not produce transaction for %q: %w", addr, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// Close closes the connections to the partition leaders, stopping the metadata refreshes.
+func (k *Kafka) Close() error {
+	var errs []error
+	k.closeOnce.Do(func() {
+		k.mu.Lock()
+		k.cancel()
+		conns := k.conns
+		k.conns = make(map[string]*kafkaConn)
+		k.mu.Unlock()
+
+		for c := range maps.Values(conns) {
+			errs = append(errs, c.conn.Close())
+		}
+	})
+	return errors.Join(errs...)
+}
+
+func (k *Kafka) publish(ctx context.Context, key string, value []byte) error {
+	c, partition, err := k.leader(ctx, key)
+	if err != nil {
+		k.metrics.kafkaPublishes.WithLabelValues(result(err)).Inc()
+		return err
+	}
+
+	err = c.produce(ctx, k.cfg, partition, key, value)
+	var kafkaErr kafkaError
+	if errors.As(err, &kafkaErr) && kafkaErr.stale() {
+		go k.refresh()
+	}
+	k.metrics.kafkaPublishes.WithLabelValues(result(err)).Inc()
+	return err
+}
+
+// produce produces the record to the partition and waits for its acknowledgement.
+func (c *kafkaConn) produce(ctx context.Context, cfg *kafkaConfig, partition int32, key string, value []byte) error {
+	batch := kafkaRecordBatch([]byte(key), value, time.Now())
+	ack := make(chan *kafkaReader, 1)
+
+	c.writeMu.Lock()
+	c.nextCorrelationID++
+	correlationID := c.nextCorrelationID
+	c.pendingMu.Lock()
+	c.pending[correlationID] = ack
+	c.pendingMu.Unlock()
+	err := c.write(kafkaProduceRequest(correlationID, cfg.topic, partition, cfg.ackTimeout, batch))
+	c.writeMu.Unlock()
+	defer func() {
+		c.pendingMu.Lock()
+		delete(c.pending, correlationID)
+		c.pendingMu.Unlock()
+	}()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, cfg.ackTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		return fmt.Errorf("waiting for kafka ack: %w", ctx.Err())
+	case <-c.lost:
+		return ErrKafkaDisconnected
+	case resp := <-ack:
+		return parseKafkaProduceResponse(resp)
+	}
+}
+
+// write writes the request. The caller must hold writeMu.
+func (c *kafkaConn) write(request []byte) error {
+	select {
+	case <-c.lost:
+		return ErrKafkaDisconnected
+	default:
+	}
+
+	_, _ = c.writer.Write(request)
+	err := c.writer.Flush()
+	if err != nil {
+		return fmt.Errorf("could not write to kafka connection: %w", err)
+	}
+	return nil
+}
+
+// readLoop reads the responses of the partition leader until its connection is lost, the leader being dialled again
+// on the next publish.
+func (k *Kafka) readLoop(c *kafkaConn, reader *bufio.Reader) {
+	defer func() {
+		close(c.lost)
+		_ = c.conn.Close()
+		k.mu.Lock()
+		if k.conns[c.addr] == c {
+			delete(k.conns, c.addr)
+		}
+		k.mu.Unlock()
+	}()
+	for {
+		correlationID, resp, err := readKafkaResponse(reader)
+		if err != nil {
+			logger := k.logger.WithError(err).WithField("broker", c.addr)
+			switch {
+			case errors.Is(err, net.ErrClosed):
+			case errors.Is(err, io.EOF):
+				// brokers close the connections idle for longer than connections.max.idle.ms
+				logger.Debug("Kafka connection closed by the broker")
+			default:
+				logger.Error("Kafka connection lost")
+			}
+			return
+		}
+
+		c.pendingMu.Lock()
+		ack, ok := c.pending[correlationID]
+		c.pendingMu.Unlock()
+		if ok {
+			ack <- resp
+		}
+	}
+}
+
+// fetchKafkaMetadata fetches the partitions of the topic and their leaders from the broker.
+func fetchKafkaMetadata(ctx context.Context, addr, topic string) ([]kafkaPartition, error) {
+	conn, err := dialKafka(ctx, addr)
+	if err != nil {
+		return nil, err
+	}
+	defer conn.Close()
+
+	_ = conn.SetDeadline(time.Now().Add(kafkaDialTimeout))
+	_, err = conn.Write(kafkaMetadataRequest(0, topic))
+	if err != nil {
+		return nil, fmt.Errorf("could not write metadata request: %w", err)
+	}
+	_, resp, err := readKafkaResponse(bufio.NewReader(conn))
+	if err != nil {
+		return nil, fmt.Errorf("could not read metadata response: %w", err)
+	}
+	return parseKafkaMetadata(resp, topic)
+}
+
+func dialKafka(ctx context.Context, addr string) (net.Conn, error) {
+	dialer := &net.Dialer{Timeout: kafkaDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial kafka broker: %w", err)
+	}
+	return conn, nil
+}
@@ -0,0 +1,289 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"hash/crc32"
+	"hash/fnv"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type kafkaProduced struct {
+	topic     string
+	partition int32
+	acks      int16
+	key       string
+	value     []byte
+}
+
+// fakeKafkaBroker is a single broker leading every partition of its topics. It records the produced messages,
+// rejecting them with the given error codes in turn before accepting them. The accepted connections are sent on
+// conns, to be dropped by the tests.
+type fakeKafkaBroker struct {
+	addr             string
+	partitions       int32
+	produced         chan kafkaProduced
+	conns            chan net.Conn
+	metadataRequests atomic.Int32
+
+	mu         sync.Mutex
+	rejections []kafkaError
+}
+
+func newFakeKafkaBroker(t *testing.T, partitions int32, rejections ...kafkaError) *fakeKafkaBroker {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	b := &fakeKafkaBroker{
+		addr:       ln.Addr().String(),
+		partitions: partitions,
+		produced:   make(chan kafkaProduced, 10),
+		conns:      make(chan net.Conn, 10),
+		rejections: rejections,
+	}
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			b.conns <- conn
+			go b.serve(t, conn)
+		}
+	}()
+	return b
+}
+
+func (b *fakeKafkaBroker) serve(t *testing.T, conn net.Conn) {
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	host, rawPort, _ := net.SplitHostPort(b.addr)
+	port, _ := strconv.Atoi(rawPort)
+
+	for {
+		apiKey, version, correlationID, req, err := readKafkaRequest(reader)
+		if err != nil {
+			return
+		}
+		resp := &kafkaWriter{}
+		resp.int32(0).int32(correlationID)
+		switch apiKey {
+		case kafkaMetadataKey:
+			assert.Equal(t, int16(kafkaMetadataVersion), version)
+			b.metadataRequests.Add(1)
+			req.count()
+			topic := req.str()
+			resp.int32(1).int32(1).str(host).int32(int32(port)).int16(-1) // a single broker without rack
+			resp.int32(1)                                                 // controller id
+			resp.int32(1).int16(0).str(topic).int8(0).int32(b.partitions)
+			for partition := range b.partitions {
+				resp.int16(0).int32(partition).int32(1).int32(1).int32(1).int32(1).int32(1)
+			}
+		case kafkaProduceKey:
+			assert.Equal(t, int16(kafkaProduceVersion), version)
+			_ = req.str() // transactional id
+			produced := kafkaProduced{acks: req.int16()}
+			_ = req.int32() // timeout
+			req.count()
+			produced.topic = req.str()
+			req.count()
+			produced.partition = req.int32()
+			produced.key, produced.value = parseKafkaRecordBatch(t, req.next(int(req.int32())))
+			b.produced <- produced
+
+			code := b.reject()
+			resp.int32(1).str(produced.topic).int32(1).int32(produced.partition).int16(int16(code)).int64(0).int64(-1)
+			resp.int32(0) // throttle time
+		default:
+			t.Errorf("unexpected kafka api key %d", apiKey)
+			return
+		}
+		_, _ = conn.Write(resp.frame())
+	}
+}
+
+func (b *fakeKafkaBroker) reject() kafkaError {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if len(b.rejections) == 0 {
+		return 0
+	}
+	code := b.rejections[0]
+	b.rejections = b.rejections[1:]
+	return code
+}
+
+func readKafkaRequest(reader *bufio.Reader) (int16, int16, int32, *kafkaReader, error) {
+	var size [4]byte
+	_, err := io.ReadFull(reader, size[:])
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+	payload := make([]byte, binary.BigEndian.Uint32(size[:]))
+	_, err = io.ReadFull(reader, payload)
+	if err != nil {
+		return 0, 0, 0, nil, err
+	}
+
+	r := &kafkaReader{buf: payload}
+	apiKey, version, correlationID := r.int16(), r.int16(), r.int32()
+	_ = r.str() // client id
+	return apiKey, version, correlationID, r, r.err
+}
+
+// parseKafkaRecordBatch returns the key and value of the single record of a batch, checking its length and crc.
+func parseKafkaRecordBatch(t *testing.T, batch []byte) (string, []byte) {
+	r := &kafkaReader{buf: batch}
+	_ = r.int64() // base offset
+	assert.Equal(t, int32(len(batch)-12), r.int32())
+	_ = r.int32() // partition leader epoch
+	assert.Equal(t, int8(kafkaMagic), r.int8())
+	crc := uint32(r.int32())
+	assert.Equal(t, crc32.Checksum(r.buf, kafkaCRCTable), crc)
+	r.next(2 + 4 + 8 + 8 + 8 + 2 + 4) // attributes up to the base sequence
+	assert.Equal(t, int32(1), r.int32())
+
+	varint := func(r *kafkaReader) int {
+		v, n := binary.Varint(r.buf)
+		r.next(n)
+		return int(v)
+	}
+	record := &kafkaReader{buf: r.next(varint(r))}
+	_, _, _ = record.int8(), varint(record), varint(record) // attributes, timestamp delta and offset delta
+	key := string(record.next(varint(record)))
+	value := record.next(varint(record))
+	assert.Zero(t, varint(record)) // headers
+	assert.NoError(t, record.err)
+	assert.Empty(t, record.buf)
+	return key, value
+}
+
+func kafkaPartitionOf(key string, partitions int32) int32 {
+	hash := fnv.New32a()
+	_, _ = hash.Write([]byte(key))
+	return int32(hash.Sum32() % uint32(partitions))
+}
+
+func TestKafkaNotify(t *testing.T) {
+	tests := map[string]struct {
+		opts          []KafkaOption
+		rejections    []kafkaError
+		expectedTopic string
+		errContains   string
+	}{
+		"default topic": {
+			expectedTopic: "ethtx",
+		},
+		"custom topic": {
+			opts:          []KafkaOption{WithKafkaTopic("transactions")},
+			expectedTopic: "transactions",
+		},
+		"rejected by broker": {
+			rejections:    []kafkaError{10},
+			expectedTopic: "ethtx",
+			errContains:   "MESSAGE_TOO_LARGE",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			broker := newFakeKafkaBroker(t, 4, test.rejections...)
+			k, err := DialKafka(context.Background(), logrus.New(), " ,"+broker.addr, test.opts...)
+			require.NoError(t, err)
+			defer k.Close()
+
+			err = k.Notify(context.Background(), &store.TxRecord{
+				Hash:        "tx-1",
+				From:        "addr-1",
+				To:          "addr-1",
+				Value:       big.NewInt(100),
+				BlockNumber: 1,
+				BlockHash:   "hash-1",
+			})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+
+			select {
+			case produced := <-broker.produced:
+				assert.Equal(t, test.expectedTopic, produced.topic)
+				assert.Equal(t, kafkaPartitionOf("addr-1", 4), produced.partition)
+				assert.Equal(t, int16(kafkaAcksAll), produced.acks)
+				assert.Equal(t, "addr-1", produced.key)
+				var payload Payload
+				require.NoError(t, json.Unmarshal(produced.value, &payload))
+				assert.Equal(t, "tx-1", payload.Hash)
+				assert.Equal(t, "100", payload.Value)
+			case <-time.After(time.Second):
+				t.Fatal("timed out waiting for produce")
+			}
+			assert.Empty(t, broker.produced)
+		})
+	}
+}
+
+func TestKafkaRefreshesMetadata(t *testing.T) {
+	// the leader moved away, the metadata is fetched again before producing to the new one
+	broker := newFakeKafkaBroker(t, 1, 6)
+	k, err := DialKafka(context.Background(), logrus.New(), broker.addr)
+	require.NoError(t, err)
+	defer k.Close()
+
+	tx := &store.TxRecord{Hash: "tx-1", From: "addr-1"}
+	err = k.Notify(context.Background(), tx)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "NOT_LEADER_OR_FOLLOWER")
+	<-broker.produced
+
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, k.Notify(context.Background(), tx))
+	}, 5*time.Second, 50*time.Millisecond)
+	<-broker.produced
+	assert.Equal(t, int32(2), broker.metadataRequests.Load())
+}
+
+func TestKafkaRedialsLeader(t *testing.T) {
+	broker := newFakeKafkaBroker(t, 1)
+	k, err := DialKafka(context.Background(), logrus.New(), broker.addr)
+	require.NoError(t, err)
+	defer k.Close()
+
+	tx := &store.TxRecord{Hash: "tx-1", From: "addr-1"}
+	require.NoError(t, k.Notify(context.Background(), tx))
+	<-broker.produced
+
+	// the connection of the metadata request, then the one to the leader, which is dialled again once lost
+	<-broker.conns
+	conn := <-broker.conns
+	require.NoError(t, conn.Close())
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, k.Notify(context.Background(), tx))
+	}, 5*time.Second, 50*time.Millisecond)
+	<-broker.produced
+	require.Len(t, broker.conns, 1)
+	assert.Equal(t, int32(1), broker.metadataRequests.Load())
+
+	require.NoError(t, k.Close())
+	assert.ErrorIs(t, k.Notify(context.Background(), tx), ErrKafkaClosed)
+}
@@ -0,0 +1,318 @@
+package notify
+
+import (
+	"bufio"
+	"bytes"
+	"cmp"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash/crc32"
+	"io"
+	"net"
+	"slices"
+	"strconv"
+	"time"
+)
+
+// Kafka protocol, limited to what a producer needs: Metadata v1 to find the leaders of the partitions of a topic and
+// Produce v3 carrying v2 record batches.
+// See https://kafka.apache.org/protocol
+
+const (
+	kafkaProduceKey      = 0
+	kafkaMetadataKey     = 3
+	kafkaProduceVersion  = 3
+	kafkaMetadataVersion = 1
+	kafkaClientID        = "ethtxparser"
+	kafkaMagic           = 2
+	kafkaAcksAll         = -1
+
+	// kafkaMaxResponseSize bounds the size of the responses read, a larger one being taken as a corrupt stream.
+	kafkaMaxResponseSize = 64 << 20
+)
+
+var kafkaCRCTable = crc32.MakeTable(crc32.Castagnoli)
+
+// kafkaError is an error code returned by a broker.
+// See https://kafka.apache.org/protocol#protocol_error_codes
+type kafkaError int16
+
+var kafkaErrorNames = map[kafkaError]string{
+	2:  "CORRUPT_MESSAGE",
+	3:  "UNKNOWN_TOPIC_OR_PARTITION",
+	5:  "LEADER_NOT_AVAILABLE",
+	6:  "NOT_LEADER_OR_FOLLOWER",
+	7:  "REQUEST_TIMED_OUT",
+	10: "MESSAGE_TOO_LARGE",
+	19: "NOT_ENOUGH_REPLICAS",
+	20: "NOT_ENOUGH_REPLICAS_AFTER_APPEND",
+	29: "TOPIC_AUTHORIZATION_FAILED",
+}
+
+func (e kafkaError) Error() string {
+	name, ok := kafkaErrorNames[e]
+	if !ok {
+		name = "UNKNOWN"
+	}
+	return fmt.Sprintf("kafka error %d (%s)", int16(e), name)
+}
+
+// stale reports whether the error means the leader of the partition moved, so the metadata must be fetched again.
+func (e kafkaError) stale() bool {
+	return e == 3 || e == 5 || e == 6
+}
+
+// kafkaPartition is a partition of a topic and the address of its leader, empty while it has none.
+type kafkaPartition struct {
+	id     int32
+	leader string
+}
+
+// kafkaWriter encodes requests.
+type kafkaWriter struct {
+	bytes.Buffer
+}
+
+// newKafkaRequest starts a request with its size, set by frame, and its header.
+func newKafkaRequest(apiKey, version int16, correlationID int32) *kafkaWriter {
+	w := &kafkaWriter{}
+	w.int32(0).int16(apiKey).int16(version).int32(correlationID).str(kafkaClientID)
+	return w
+}
+
+// frame returns the encoded request, prefixed with its size.
+func (w *kafkaWriter) frame() []byte {
+	b := w.Bytes()
+	binary.BigEndian.PutUint32(b[:4], uint32(len(b)-4))
+	return b
+}
+
+func (w *kafkaWriter) int8(v int8) *kafkaWriter {
+	w.WriteByte(byte(v))
+	return w
+}
+
+func (w *kafkaWriter) int16(v int16) *kafkaWriter {
+	w.Write(binary.BigEndian.AppendUint16(nil, uint16(v)))
+	return w
+}
+
+func (w *kafkaWriter) int32(v int32) *kafkaWriter {
+	w.Write(binary.BigEndian.AppendUint32(nil, uint32(v)))
+	return w
+}
+
+func (w *kafkaWriter) int64(v int64) *kafkaWriter {
+	w.Write(binary.BigEndian.AppendUint64(nil, uint64(v)))
+	return w
+}
+
+func (w *kafkaWriter) str(s string) *kafkaWriter {
+	w.int16(int16(len(s)))
+	w.WriteString(s)
+	return w
+}
+
+func (w *kafkaWriter) bytes(b []byte) *kafkaWriter {
+	w.int32(int32(len(b)))
+	w.Write(b)
+	return w
+}
+
+// varint writes a zigzag encoded varint, as used by the records of a batch.
+func (w *kafkaWriter) varint(v int64) *kafkaWriter {
+	w.Write(binary.AppendVarint(nil, v))
+	return w
+}
+
+// kafkaRecordBatch encodes a v2 record batch holding a single record.
+func kafkaRecordBatch(key, value []byte, timestamp time.Time) []byte {
+	record := &kafkaWriter{}
+	record.int8(0).varint(0).varint(0) // attributes, timestamp delta and offset delta
+	record.varint(int64(len(key)))
+	record.Write(key)
+	record.varint(int64(len(value)))
+	record.Write(value)
+	record.varint(0) // headers
+
+	// the crc covers the batch from its attributes on
+	ts := timestamp.UnixMilli()
+	body := &kafkaWriter{}
+	body.int16(0).int32(0).int64(ts).int64(ts) // attributes, last offset delta, first and max timestamps
+	body.int64(-1).int16(-1).int32(-1)         // no producer id, epoch nor base sequence
+	body.int32(1).varint(int64(record.Len()))
+	body.Write(record.Bytes())
+
+	batch := &kafkaWriter{}
+	batch.int64(0).int32(int32(4 + 1 + 4 + body.Len())) // base offset and length of the batch after it
+	batch.int32(-1).int8(kafkaMagic)                    // partition leader epoch and magic
+	batch.int32(int32(crc32.Checksum(body.Bytes(), kafkaCRCTable)))
+	batch.Write(body.Bytes())
+	return batch.Bytes()
+}
+
+// kafkaProduceRequest encodes a request producing the batch to the partition, acknowledged by all the in-sync
+// replicas within the timeout.
+func kafkaProduceRequest(correlationID int32, topic string, partition int32, timeout time.Duration, batch []byte) []byte {
+	w := newKafkaRequest(kafkaProduceKey, kafkaProduceVersion, correlationID)
+	w.int16(-1) // no transactional id
+	w.int16(kafkaAcksAll).int32(int32(timeout.Milliseconds()))
+	w.int32(1).str(topic).int32(1).int32(partition).bytes(batch)
+	return w.frame()
+}
+
+// kafkaMetadataRequest encodes a request for the metadata of the topic.
+func kafkaMetadataRequest(correlationID int32, topic string) []byte {
+	return newKafkaRequest(kafkaMetadataKey, kafkaMetadataVersion, correlationID).int32(1).str(topic).frame()
+}
+
+// readKafkaResponse reads a response, returning its correlation id and body.
+func readKafkaResponse(reader *bufio.Reader) (int32, *kafkaReader, error) {
+	var header [8]byte
+	_, err := io.ReadFull(reader, header[:])
+	if err != nil {
+		return 0, nil, err
+	}
+
+	size := binary.BigEndian.Uint32(header[0:4])
+	if size < 4 || size > kafkaMaxResponseSize {
+		return 0, nil, fmt.Errorf("malformed response size: %d", size)
+	}
+	body := make([]byte, size-4)
+	_, err = io.ReadFull(reader, body)
+	if err != nil {
+		return 0, nil, err
+	}
+	return int32(binary.BigEndian.Uint32(header[4:8])), &kafkaReader{buf: body}, nil
+}
+
+// parseKafkaMetadata returns the partitions of the topic in a Metadata v1 response, ordered by id.
+func parseKafkaMetadata(r *kafkaReader, topic string) ([]kafkaPartition, error) {
+	brokers := make(map[int32]string)
+	for range r.count() {
+		id, host, port := r.int32(), r.str(), r.int32()
+		_ = r.str() // rack
+		brokers[id] = net.JoinHostPort(host, strconv.Itoa(int(port)))
+	}
+	_ = r.int32() // controller id
+
+	var (
+		partitions []kafkaPartition
+		topicErr   kafkaError
+	)
+	for range r.count() {
+		code, name := kafkaError(r.int16()), r.str()
+		_ = r.int8() // is internal
+		for range r.count() {
+			// a partition without a leader reports LEADER_NOT_AVAILABLE, which its missing leader already tells
+			_ = r.int16()
+			p := kafkaPartition{id: r.int32()}
+			p.leader = brokers[r.int32()]
+			for range r.count() {
+				_ = r.int32() // replicas
+			}
+			for range r.count() {
+				_ = r.int32() // in-sync replicas
+			}
+			if name == topic {
+				partitions = append(partitions, p)
+			}
+		}
+		if name == topic {
+			topicErr = code
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	if topicErr != 0 {
+		return nil, fmt.Errorf("could not get metadata of topic %q: %w", topic, topicErr)
+	}
+	if len(partitions) == 0 {
+		return nil, fmt.Errorf("topic %q has no partitions", topic)
+	}
+
+	slices.SortFunc(partitions, func(a, b kafkaPartition) int {
+		return cmp.Compare(a.id, b.id)
+	})
+	return partitions, nil
+}
+
+// parseKafkaProduceResponse returns the error of the partition produced to in a Produce v3 response, if any.
+func parseKafkaProduceResponse(r *kafkaReader) error {
+	var code kafkaError
+	for range r.count() {
+		_ = r.str() // topic
+		for range r.count() {
+			_ = r.int32() // partition
+			code = kafkaError(r.int16())
+			_, _ = r.int64(), r.int64() // base offset and log append time
+		}
+	}
+	if r.err != nil {
+		return r.err
+	}
+	if code != 0 {
+		return code
+	}
+	return nil
+}
+
+// kafkaReader decodes responses.
+type kafkaReader struct {
+	buf []byte
+	err error
+}
+
+func (r *kafkaReader) next(n int) []byte {
+	if r.err != nil {
+		return make([]byte, max(n, 0))
+	}
+	if n < 0 || len(r.buf) < n {
+		r.err = errors.New("malformed kafka response")
+		return make([]byte, max(n, 0))
+	}
+	b := r.buf[:n]
+	r.buf = r.buf[n:]
+	return b
+}
+
+func (r *kafkaReader) int8() int8 {
+	return int8(r.next(1)[0])
+}
+
+func (r *kafkaReader) int16() int16 {
+	return int16(binary.BigEndian.Uint16(r.next(2)))
+}
+
+func (r *kafkaReader) int32() int32 {
+	return int32(binary.BigEndian.Uint32(r.next(4)))
+}
+
+func (r *kafkaReader) int64() int64 {
+	return int64(binary.BigEndian.Uint64(r.next(8)))
+}
+
+// str reads a string, a null one being read as empty.
+func (r *kafkaReader) str() string {
+	n := r.int16()
+	if n < 0 {
+		return ""
+	}
+	return string(r.next(int(n)))
+}
+
+// count reads the length of an array, a null one being read as empty.
+func (r *kafkaReader) count() int {
+	n := int(r.int32())
+	if r.err != nil || n < 0 {
+		return 0
+	}
+	// every element takes at least a byte, so a larger count can only come from a corrupt response
+	if n > len(r.buf) {
+		r.err = errors.New("malformed kafka response")
+		return 0
+	}
+	return n
+}
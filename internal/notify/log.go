@@ -0,0 +1,45 @@
+package notify
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// Log logs every recorded or removed transaction and committed block at info level.
+type Log struct {
+	logger *logrus.Logger
+}
+
+func NewLog(logger *logrus.Logger) *Log {
+	return &Log{logger: logger}
+}
+
+func (l *Log) Notify(ctx context.Context, tx *store.TxRecord) error {
+	fields := logrus.Fields{
+		"tx_hash":      tx.Hash,
+		"from":         tx.From,
+		"to":           tx.To,
+		"block_number": tx.BlockNumber,
+	}
+	if tx.Value != nil {
+		fields["value"] = tx.Value.String()
+	}
+	if tx.Removed {
+		l.logger.WithContext(ctx).WithFields(fields).Info("Removed transaction of orphaned block")
+		return nil
+	}
+	l.logger.WithContext(ctx).WithFields(fields).Info("Recorded transaction")
+	return nil
+}
+
+func (l *Log) NotifyBlock(ctx context.Context, block *store.Block) error {
+	l.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"block_number": block.Number,
+		"block_hash":   block.Hash,
+		"addresses":    len(block.AddrToTxs),
+	}).Info("Committed block")
+	return nil
+}
@@ -0,0 +1,86 @@
+package notify
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of the notifiers, registered with the registerer set by their options.
+type metrics struct {
+	webhookDeliveries       *prometheus.CounterVec
+	webhookAttempts         prometheus.Counter
+	webhookDeliveryDuration prometheus.Histogram
+	chatMessages            *prometheus.CounterVec
+	reorgAlerts             *prometheus.CounterVec
+	emails                  *prometheus.CounterVec
+	natsPublishes           *prometheus.CounterVec
+	natsReconnects          prometheus.Counter
+	amqpPublishes           *prometheus.CounterVec
+	amqpReconnects          prometheus.Counter
+	kafkaPublishes          *prometheus.CounterVec
+	kafkaMetadataRefreshes  prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		webhookDeliveries: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_webhook_deliveries_total",
+			Help: "Total number of webhook deliveries by result",
+		}, []string{"result"}),
+		webhookAttempts: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_webhook_attempts_total",
+			Help: "Total number of webhook http requests made, including retries",
+		}),
+		webhookDeliveryDuration: auto.NewHistogram(prometheus.HistogramOpts{
+			Name:    "ethtxparser_webhook_delivery_duration_seconds",
+			Help:    "Time taken to deliver a webhook, including retries",
+			Buckets: prometheus.DefBuckets,
+		}),
+		chatMessages: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_chat_messages_total",
+			Help: "Total number of chat messages by platform and result, including the ones dropped by rate limiting",
+		}, []string{"platform", "result"}),
+		reorgAlerts: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_reorg_alert_deliveries_total",
+			Help: "Total number of chain reorganisation alerts delivered by target, webhook or chat platform, and result",
+		}, []string{"target", "result"}),
+		emails: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_emails_total",
+			Help: "Total number of emails sent by result, a digest counting as one",
+		}, []string{"result"}),
+		natsPublishes: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_nats_publishes_total",
+			Help: "Total number of messages published to NATS by result",
+		}, []string{"result"}),
+		natsReconnects: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_nats_reconnects_total",
+			Help: "Total number of times the lost NATS connection was reconnected",
+		}),
+		amqpPublishes: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_amqp_publishes_total",
+			Help: "Total number of messages published to the AMQP broker by result",
+		}, []string{"result"}),
+		amqpReconnects: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_amqp_reconnects_total",
+			Help: "Total number of times the lost AMQP connection was reconnected",
+		}),
+		kafkaPublishes: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_kafka_publishes_total",
+			Help: "Total number of messages produced to Kafka by result",
+		}, []string{"result"}),
+		kafkaMetadataRefreshes: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_kafka_metadata_refreshes_total",
+			Help: "Total number of times the metadata of the Kafka topic was refreshed after a partition leader moved or couldn't be dialled",
+		}),
+	}
+}
+
+// result returns the result label value of an operation.
+func result(err error) string {
+	if err != nil {
+		return "failure"
+	}
+	return "success"
+}
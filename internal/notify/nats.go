@@ -0,0 +1,502 @@
+package notify
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/url"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// DefaultNATSSubjectPrefix is the default prefix of the subjects transactions and blocks are published to.
+	DefaultNATSSubjectPrefix = "ethtx"
+	// DefaultNATSAckTimeout is the default time to wait for JetStream to acknowledge a published message.
+	DefaultNATSAckTimeout = time.Second * 5
+
+	natsBlocksSubject = "blocks"
+	natsDialTimeout   = time.Second * 5
+	natsDefaultPort   = "4222"
+)
+
+var (
+	// ErrNATSClosed is returned when publishing on a closed NATS connection.
+	ErrNATSClosed = errors.New("nats connection closed")
+	// ErrNATSDisconnected is returned when publishing while the connection is lost, until it's reconnected.
+	ErrNATSDisconnected = errors.New("nats connection lost, reconnecting")
+)
+
+type natsConfig struct {
+	subjectPrefix string
+	jetStream     bool
+	ackTimeout    time.Duration
+	registerer    prometheus.Registerer
+}
+
+type NATSOption func(*natsConfig)
+
+// WithNATSSubjectPrefix sets the prefix of the subjects transactions and blocks are published to.
+func WithNATSSubjectPrefix(prefix string) NATSOption {
+	return func(c *natsConfig) {
+		if prefix != "" {
+			c.subjectPrefix = prefix
+		}
+	}
+}
+
+// WithJetStream makes every publish wait for a JetStream acknowledgement, so messages are persisted by a stream
+// bound to the subjects before being considered delivered.
+func WithJetStream() NATSOption {
+	return func(c *natsConfig) {
+		c.jetStream = true
+	}
+}
+
+// WithNATSAckTimeout sets the time to wait for JetStream to acknowledge a published message.
+func WithNATSAckTimeout(d time.Duration) NATSOption {
+	return func(c *natsConfig) {
+		if d > 0 {
+			c.ackTimeout = d
+		}
+	}
+}
+
+// WithNATSRegisterer sets the registerer the metrics of the NATS notifier are registered with, the custom registry by
+// default.
+func WithNATSRegisterer(reg prometheus.Registerer) NATSOption {
+	return func(c *natsConfig) {
+		c.registerer = reg
+	}
+}
+
+// NATS publishes recorded transactions to `<prefix>.<address>` and committed blocks to `<prefix>.blocks`.
+// It speaks the NATS client protocol directly. Once the connection is lost, it's redialled with backoff until
+// reconnected or closed, every publish failing with ErrNATSDisconnected meanwhile.
+type NATS struct {
+	logger   *logrus.Logger
+	cfg      *natsConfig
+	metrics  *metrics
+	hostPort string
+
+	// ctx is cancelled once closed, stopping the reconnection attempts.
+	ctx    context.Context
+	cancel context.CancelFunc
+	mu     sync.Mutex
+	conn   *natsConn
+
+	// inbox is the subject prefix JetStream acknowledgements are received on.
+	inbox     string
+	nextAckID atomic.Uint64
+	pendingMu sync.Mutex
+	pending   map[string]chan []byte
+
+	closeOnce sync.Once
+}
+
+// natsConn is a connection to the NATS server.
+type natsConn struct {
+	conn    net.Conn
+	writeMu sync.Mutex
+	writer  *bufio.Writer
+	// lost is closed once the connection is lost or closed, when its read loop returns.
+	lost chan struct{}
+}
+
+// DialNATS connects to the NATS server at the given address, e.g. nats://localhost:4222.
+func DialNATS(ctx context.Context, logger *logrus.Logger, addr string, opts ...NATSOption) (*NATS, error) {
+	cfg := &natsConfig{
+		subjectPrefix: DefaultNATSSubjectPrefix,
+		ackTimeout:    DefaultNATSAckTimeout,
+		registerer:    custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	hostPort, err := natsHostPort(addr)
+	if err != nil {
+		return nil, err
+	}
+	inbox, err := newNATSInbox()
+	if err != nil {
+		return nil, err
+	}
+
+	n := &NATS{
+		logger:   logger,
+		cfg:      cfg,
+		metrics:  newMetrics(cfg.registerer),
+		hostPort: hostPort,
+		inbox:    inbox,
+		pending:  make(map[string]chan []byte),
+	}
+	n.ctx, n.cancel = context.WithCancel(context.WithoutCancel(ctx))
+	n.conn, err = n.connect(ctx)
+	if err != nil {
+		n.cancel()
+		return nil, err
+	}
+	return n, nil
+}
+
+// connect dials the server and makes the handshake, reading from the new connection until it's lost.
+func (n *NATS) connect(ctx context.Context) (*natsConn, error) {
+	dialer := &net.Dialer{Timeout: natsDialTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.hostPort)
+	if err != nil {
+		return nil, fmt.Errorf("could not dial nats server: %w", err)
+	}
+
+	c := &natsConn{
+		conn:   conn,
+		writer: bufio.NewWriter(conn),
+		lost:   make(chan struct{}),
+	}
+	reader := bufio.NewReader(conn)
+	err = n.handshake(c, reader)
+	if err != nil {
+		_ = conn.Close()
+		return nil, err
+	}
+
+	go n.readLoop(c, reader)
+	return c, nil
+}
+
+// reconnect redials the server with backoff once the connection is lost, until reconnected or closed.
+func (n *NATS) reconnect() {
+	var c *natsConn
+	bo := backoff.WithContext(newExponentialBackoffConfig(0), n.ctx)
+	err := backoff.RetryNotify(func() error {
+		var err error
+		c, err = n.connect(n.ctx)
+		return err
+	}, bo, func(err error, next time.Duration) {
+		n.logger.WithError(err).WithField("retry_in", next).Warn("Failed to reconnect to NATS, retrying...")
+	})
+	if err != nil {
+		return
+	}
+
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ctx.Err() != nil {
+		_ = c.conn.Close()
+		return
+	}
+	n.conn = c
+	n.metrics.natsReconnects.Inc()
+	n.logger.Info("Reconnected to NATS")
+}
+
+// current returns the current connection, or an error if it's lost or closed.
+func (n *NATS) current() (*natsConn, error) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.ctx.Err() != nil {
+		return nil, ErrNATSClosed
+	}
+	select {
+	case <-n.conn.lost:
+		return nil, ErrNATSDisconnected
+	default:
+		return n.conn, nil
+	}
+}
+
+// Notify publishes the transaction to the subjects of its sender and recipient.
+func (n *NATS) Notify(ctx context.Context, tx *store.TxRecord) error {
+	var errs []error
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		data, err := json.Marshal(newPayload(addr, tx))
+		if err != nil {
+			return fmt.Errorf("could not marshal transaction payload: %w", err)
+		}
+
+		err = n.publish(ctx, n.cfg.subjectPrefix+"."+addr, data)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not publish transaction for %q: %w", addr, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// BlockPayload is the message published for every committed block.
+type BlockPayload struct {
+	Number     int64  `json:"number"`
+	Hash       string `json:"hash"`
+	ParentHash string `json:"parentHash"`
+	Addresses  int    `json:"addresses"`
+}
+
+// NotifyBlock publishes the committed block to the blocks subject.
+func (n *NATS) NotifyBlock(ctx context.Context, block *store.Block) error {
+	data, err := json.Marshal(&BlockPayload{
+		Number:     block.Number,
+		Hash:       block.Hash,
+		ParentHash: block.ParentHash,
+		Addresses:  len(block.AddrToTxs),
+	})
+	if err != nil {
+		return fmt.Errorf("could not marshal block payload: %w", err)
+	}
+
+	err = n.publish(ctx, n.cfg.subjectPrefix+"."+natsBlocksSubject, data)
+	if err != nil {
+		return fmt.Errorf("could not publish block: %w", err)
+	}
+	return nil
+}
+
+// Close closes the connection to the NATS server, stopping the reconnection attempts.
+func (n *NATS) Close() error {
+	var err error
+	n.closeOnce.Do(func() {
+		n.mu.Lock()
+		n.cancel()
+		c := n.conn
+		n.mu.Unlock()
+
+		c.writeMu.Lock()
+		_ = c.writer.Flush()
+		c.writeMu.Unlock()
+		err = c.conn.Close()
+	})
+	return err
+}
+
+func (n *NATS) publish(ctx context.Context, subject string, data []byte) error {
+	c, err := n.current()
+	if err != nil {
+		n.metrics.natsPublishes.WithLabelValues(result(err)).Inc()
+		return err
+	}
+	if !n.cfg.jetStream {
+		err := c.write(fmt.Sprintf("PUB %s %d\r\n", subject, len(data)), data)
+		n.metrics.natsPublishes.WithLabelValues(result(err)).Inc()
+		return err
+	}
+
+	replyTo := n.inbox + "." + strconv.FormatUint(n.nextAckID.Add(1), 10)
+	ack := make(chan []byte, 1)
+	n.pendingMu.Lock()
+	n.pending[replyTo] = ack
+	n.pendingMu.Unlock()
+	defer func() {
+		n.pendingMu.Lock()
+		delete(n.pending, replyTo)
+		n.pendingMu.Unlock()
+	}()
+
+	err = c.write(fmt.Sprintf("PUB %s %s %d\r\n", subject, replyTo, len(data)), data)
+	if err != nil {
+		n.metrics.natsPublishes.WithLabelValues(result(err)).Inc()
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, n.cfg.ackTimeout)
+	defer cancel()
+	select {
+	case <-ctx.Done():
+		err = fmt.Errorf("waiting for jetstream ack: %w", ctx.Err())
+	case <-c.lost:
+		err = ErrNATSDisconnected
+	case data := <-ack:
+		err = parsePubAck(data)
+	}
+	n.metrics.natsPublishes.WithLabelValues(result(err)).Inc()
+	return err
+}
+
+func (c *natsConn) write(header string, payload []byte) error {
+	select {
+	case <-c.lost:
+		return ErrNATSDisconnected
+	default:
+	}
+
+	c.writeMu.Lock()
+	defer c.writeMu.Unlock()
+
+	_, _ = c.writer.WriteString(header)
+	if payload != nil {
+		_, _ = c.writer.Write(payload)
+		_, _ = c.writer.WriteString("\r\n")
+	}
+	err := c.writer.Flush()
+	if err != nil {
+		return fmt.Errorf("could not write to nats connection: %w", err)
+	}
+	return nil
+}
+
+// handshake reads the server INFO, sends CONNECT and waits for the PONG confirming the connection was accepted.
+func (n *NATS) handshake(c *natsConn, reader *bufio.Reader) error {
+	_ = c.conn.SetDeadline(time.Now().Add(natsDialTimeout))
+	defer func() {
+		_ = c.conn.SetDeadline(time.Time{})
+	}()
+
+	line, err := readNATSLine(reader)
+	if err != nil {
+		return fmt.Errorf("could not read nats server info: %w", err)
+	}
+	if !strings.HasPrefix(line, "INFO") {
+		return fmt.Errorf("unexpected nats server greeting: %q", line)
+	}
+
+	commands := `CONNECT {"verbose":false,"pedantic":false,"name":"ethtxparser","lang":"go","protocol":1}` + "\r\n"
+	if n.cfg.jetStream {
+		commands += fmt.Sprintf("SUB %s.* 1\r\n", n.inbox)
+	}
+	err = c.write(commands+"PING\r\n", nil)
+	if err != nil {
+		return err
+	}
+
+	for {
+		line, err = readNATSLine(reader)
+		if err != nil {
+			return fmt.Errorf("could not read nats connect response: %w", err)
+		}
+		switch {
+		case line == "PONG":
+			return nil
+		case strings.HasPrefix(line, "-ERR"):
+			return fmt.Errorf("nats server rejected connection: %s", strings.TrimSpace(strings.TrimPrefix(line, "-ERR")))
+		}
+	}
+}
+
+// readLoop reads from the connection until it's lost, then reconnects unless it was closed.
+func (n *NATS) readLoop(c *natsConn, reader *bufio.Reader) {
+	defer func() {
+		close(c.lost)
+		_ = c.conn.Close()
+		if n.ctx.Err() == nil {
+			n.reconnect()
+		}
+	}()
+	for {
+		line, err := readNATSLine(reader)
+		if err != nil {
+			if !errors.Is(err, net.ErrClosed) {
+				n.logger.WithError(err).Error("NATS connection lost")
+			}
+			return
+		}
+
+		switch {
+		case line == "PING":
+			err = c.write("PONG\r\n", nil)
+			if err != nil {
+				n.logger.WithError(err).Warn("Failed to reply to nats ping")
+			}
+		case strings.HasPrefix(line, "MSG "):
+			subject, data, err := readNATSMsg(reader, line)
+			if err != nil {
+				n.logger.WithError(err).Error("Failed to read nats message")
+				return
+			}
+			n.pendingMu.Lock()
+			ack, ok := n.pending[subject]
+			n.pendingMu.Unlock()
+			if ok {
+				ack <- data
+			}
+		case strings.HasPrefix(line, "-ERR"):
+			n.logger.WithField("err", strings.TrimSpace(strings.TrimPrefix(line, "-ERR"))).Error("NATS server error")
+		}
+	}
+}
+
+func readNATSLine(reader *bufio.Reader) (string, error) {
+	line, err := reader.ReadString('\n')
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// readNATSMsg reads the payload of a `MSG <subject> <sid> [reply-to] <#bytes>` message.
+func readNATSMsg(reader *bufio.Reader, line string) (string, []byte, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 && len(fields) != 5 {
+		return "", nil, fmt.Errorf("malformed message line: %q", line)
+	}
+	size, err := strconv.Atoi(fields[len(fields)-1])
+	if err != nil || size < 0 {
+		return "", nil, fmt.Errorf("malformed message size: %q", line)
+	}
+
+	data := make([]byte, size+2)
+	_, err = io.ReadFull(reader, data)
+	if err != nil {
+		return "", nil, err
+	}
+	return fields[1], data[:size], nil
+}
+
+// parsePubAck returns the error reported by a JetStream publish acknowledgement, if any.
+func parsePubAck(data []byte) error {
+	var ack struct {
+		Stream string `json:"stream"`
+		Error  *struct {
+			Code        int    `json:"code"`
+			Description string `json:"description"`
+		} `json:"error"`
+	}
+	err := json.Unmarshal(data, &ack)
+	if err != nil {
+		return fmt.Errorf("could not unmarshal jetstream ack: %w", err)
+	}
+	if ack.Error != nil {
+		return fmt.Errorf("jetstream rejected message: %s (%d)", ack.Error.Description, ack.Error.Code)
+	}
+	if ack.Stream == "" {
+		return errors.New("no jetstream stream bound to subject")
+	}
+	return nil
+}
+
+func natsHostPort(addr string) (string, error) {
+	if !strings.Contains(addr, "://") {
+		addr = "nats://" + addr
+	}
+	u, err := url.Parse(addr)
+	if err != nil {
+		return "", fmt.Errorf("invalid nats address: %w", err)
+	}
+	if u.Scheme != "nats" && u.Scheme != "tcp" {
+		return "", fmt.Errorf("unsupported nats address scheme: %q", u.Scheme)
+	}
+	return hostPort(u, natsDefaultPort), nil
+}
+
+func newNATSInbox() (string, error) {
+	id := make([]byte, 8)
+	_, err := rand.Read(id)
+	if err != nil {
+		return "", fmt.Errorf("could not generate inbox id: %w", err)
+	}
+	return "_INBOX." + hex.EncodeToString(id), nil
+}
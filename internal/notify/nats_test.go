@@ -0,0 +1,192 @@
+package notify_test
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/notify"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type natsPub struct {
+	subject string
+	replyTo string
+	data    []byte
+}
+
+// fakeNATSServer accepts connections, records the published messages and acks them with the given response when
+// they have a reply subject. The accepted connections are sent on the returned channel, to be dropped by the tests.
+func fakeNATSServer(t *testing.T, ack string) (string, <-chan natsPub, <-chan net.Conn) {
+	t.Helper()
+
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = ln.Close() })
+
+	pubs := make(chan natsPub, 10)
+	conns := make(chan net.Conn, 10)
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			conns <- conn
+			go serveNATS(conn, ack, pubs)
+		}
+	}()
+
+	return ln.Addr().String(), pubs, conns
+}
+
+func serveNATS(conn net.Conn, ack string, pubs chan<- natsPub) {
+	defer conn.Close()
+
+	_, _ = conn.Write([]byte(`INFO {"server_id":"test","max_payload":1048576}` + "\r\n"))
+	reader := bufio.NewReader(conn)
+	for {
+		line, err := reader.ReadString('\n')
+		if err != nil {
+			return
+		}
+		fields := strings.Fields(line)
+		switch fields[0] {
+		case "PING":
+			_, _ = conn.Write([]byte("PONG\r\n"))
+		case "PUB":
+			size, _ := strconv.Atoi(fields[len(fields)-1])
+			data := make([]byte, size+2)
+			_, _ = io.ReadFull(reader, data)
+			pub := natsPub{subject: fields[1], data: data[:size]}
+			if len(fields) == 4 {
+				pub.replyTo = fields[2]
+				_, _ = fmt.Fprintf(conn, "MSG %s 1 %d\r\n%s\r\n", pub.replyTo, len(ack), ack)
+			}
+			pubs <- pub
+		}
+	}
+}
+
+func TestNATSNotify(t *testing.T) {
+	tests := map[string]struct {
+		opts        []notify.NATSOption
+		ack         string
+		expectReply bool
+		errContains string
+	}{
+		"core nats": {},
+		"jetstream acked": {
+			opts:        []notify.NATSOption{notify.WithJetStream()},
+			ack:         `{"stream":"ETHTX","seq":1}`,
+			expectReply: true,
+		},
+		"jetstream error": {
+			opts:        []notify.NATSOption{notify.WithJetStream()},
+			ack:         `{"error":{"code":503,"description":"no responders"}}`,
+			expectReply: true,
+			errContains: "jetstream rejected message: no responders (503)",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, pubs, _ := fakeNATSServer(t, test.ack)
+			n, err := notify.DialNATS(context.Background(), logrus.New(), "nats://"+addr, test.opts...)
+			require.NoError(t, err)
+			defer n.Close()
+
+			err = n.Notify(context.Background(), &store.TxRecord{
+				Hash:        "tx-1",
+				From:        "addr-1",
+				To:          "addr-2",
+				Value:       big.NewInt(100),
+				BlockNumber: 1,
+				BlockHash:   "hash-1",
+			})
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.errContains)
+			} else {
+				require.NoError(t, err)
+			}
+
+			for _, addr := range []string{"addr-1", "addr-2"} {
+				select {
+				case pub := <-pubs:
+					assert.Equal(t, "ethtx."+addr, pub.subject)
+					assert.Equal(t, test.expectReply, pub.replyTo != "")
+					var payload notify.Payload
+					require.NoError(t, json.Unmarshal(pub.data, &payload))
+					assert.Equal(t, addr, payload.Address)
+					assert.Equal(t, "tx-1", payload.Hash)
+				case <-time.After(time.Second):
+					t.Fatalf("timed out waiting for publish to %s", addr)
+				}
+			}
+		})
+	}
+}
+
+func TestNATSNotifyBlock(t *testing.T) {
+	addr, pubs, _ := fakeNATSServer(t, "")
+	n, err := notify.DialNATS(context.Background(), logrus.New(), addr, notify.WithNATSSubjectPrefix("eth"))
+	require.NoError(t, err)
+	defer n.Close()
+
+	err = n.NotifyBlock(context.Background(), &store.Block{
+		Number:     7,
+		Hash:       "hash-7",
+		ParentHash: "hash-6",
+		AddrToTxs:  map[string][]*store.TxRecord{"addr-1": nil},
+	})
+	require.NoError(t, err)
+
+	select {
+	case pub := <-pubs:
+		assert.Equal(t, "eth.blocks", pub.subject)
+		assert.JSONEq(t, `{"number":7,"hash":"hash-7","parentHash":"hash-6","addresses":1}`, string(pub.data))
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for block publish")
+	}
+}
+
+func TestNATSReconnects(t *testing.T) {
+	addr, pubs, conns := fakeNATSServer(t, `{"stream":"ETHTX","seq":1}`)
+	n, err := notify.DialNATS(context.Background(), logrus.New(), addr, notify.WithJetStream())
+	require.NoError(t, err)
+	defer n.Close()
+
+	block := &store.Block{Number: 7, Hash: "hash-7"}
+	require.NoError(t, n.NotifyBlock(context.Background(), block))
+	<-pubs
+
+	// the lost connection is redialled, the publishes failing until it's reconnected
+	conn := <-conns
+	require.NoError(t, conn.Close())
+	require.EventuallyWithT(t, func(c *assert.CollectT) {
+		assert.NoError(c, n.NotifyBlock(context.Background(), block))
+	}, 5*time.Second, 50*time.Millisecond)
+	select {
+	case pub := <-pubs:
+		assert.Equal(t, "ethtx.blocks", pub.subject)
+	case <-time.After(time.Second):
+		t.Fatal("timed out waiting for block publish")
+	}
+	require.Len(t, conns, 1)
+
+	require.NoError(t, n.Close())
+	assert.ErrorIs(t, n.NotifyBlock(context.Background(), block), notify.ErrNATSClosed)
+}
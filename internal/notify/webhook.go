@@ -0,0 +1,474 @@
+package notify
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// TimestampHeader holds the unix timestamp the webhook payload was signed at.
+	TimestampHeader = "X-Webhook-Timestamp"
+	// SignatureHeader holds the hex encoded HMAC-SHA256 signature of "<timestamp>.<body>" prefixed with "sha256=".
+	SignatureHeader = "X-Webhook-Signature"
+	// PreviousSignatureHeader holds the signature made with the rotated secret of the subscription, in the same
+	// format, during its grace window.
+	PreviousSignatureHeader = "X-Webhook-Previous-Signature"
+
+	// WebhookNotifier is the name the webhook notifier is registered under, the channel the notification preferences
+	// of the subscriptions refer to it by.
+	WebhookNotifier = "webhook"
+
+	// DefaultMaxElapsedTime is the default maximum time spent retrying a single webhook delivery.
+	DefaultMaxElapsedTime = time.Second * 30
+	// DefaultWebhookBacklog is the default number of deliveries queued per subscription.
+	DefaultWebhookBacklog = 256
+
+	// webhookFlushPollInterval is how often the queued deliveries are checked while flushing them.
+	webhookFlushPollInterval = 10 * time.Millisecond
+)
+
+var (
+	// ErrWebhookBacklogFull is returned when a delivery is dropped because the queue of its subscription is full.
+	ErrWebhookBacklogFull = errors.New("webhook backlog is full")
+	// ErrWebhookClosed is returned when notifying a closed webhook notifier.
+	ErrWebhookClosed = errors.New("webhook notifier is closed")
+)
+
+type SubscriptionStore interface {
+	GetSubscription(ctx context.Context, addr string) (*store.Subscription, error)
+}
+
+type webhookConfig struct {
+	maxElapsedTime time.Duration
+	backlog        int
+	usage          metering.Recorder
+	await          bool
+	registerer     prometheus.Registerer
+}
+
+type WebhookOption func(*webhookConfig)
+
+// WithMaxElapsedTime sets the maximum time spent retrying a single webhook delivery before giving up.
+func WithMaxElapsedTime(d time.Duration) WebhookOption {
+	return func(c *webhookConfig) {
+		if d > 0 {
+			c.maxElapsedTime = d
+		}
+	}
+}
+
+// WithWebhookBacklog sets the number of deliveries queued per subscription, the ones notified once it's full being
+// dropped.
+func WithWebhookBacklog(n int) WebhookOption {
+	return func(c *webhookConfig) {
+		if n > 0 {
+			c.backlog = n
+		}
+	}
+}
+
+// WithWebhookUsage records the transactions delivered to the webhooks of the subscriptions of tenants as their usage.
+func WithWebhookUsage(recorder metering.Recorder) WebhookOption {
+	return func(c *webhookConfig) {
+		c.usage = recorder
+	}
+}
+
+// WithWebhookAwait makes Notify wait for the deliveries of the transaction and return their errors, instead of
+// returning once they're queued. It's meant for the notification outbox, whose entries mustn't be acknowledged before
+// they're delivered.
+func WithWebhookAwait() WebhookOption {
+	return func(c *webhookConfig) {
+		c.await = true
+	}
+}
+
+// WithWebhookRegisterer sets the registerer the metrics of the webhook notifier are registered with, the custom
+// registry by default.
+func WithWebhookRegisterer(reg prometheus.Registerer) WebhookOption {
+	return func(c *webhookConfig) {
+		c.registerer = reg
+	}
+}
+
+// Webhook posts the recorded transactions to the webhook URLs of the subscribed addresses. The deliveries are queued
+// per subscription and delivered in order, each subscription by its own goroutine, so the retries of a failing
+// webhook only delay the deliveries of its own subscription.
+type Webhook struct {
+	logger     *logrus.Logger
+	httpClient *http.Client
+	subsStore  SubscriptionStore
+	cfg        *webhookConfig
+	metrics    *metrics
+
+	// ctx is cancelled once the notifier is closed, giving up on the queued deliveries.
+	ctx    context.Context
+	cancel context.CancelFunc
+	wg     sync.WaitGroup
+	// pending counts the queued deliveries, including the ones being delivered.
+	pending atomic.Int64
+
+	mu sync.Mutex
+	// lanes holds the queued deliveries by subscribed address, a lane and its goroutine being stopped once emptied.
+	lanes map[string]chan *delivery
+}
+
+// delivery is a payload queued for delivery to the webhook of a subscription.
+type delivery struct {
+	// ctx is the context of the notification, without its cancellation unless the notification awaits the delivery.
+	ctx     context.Context
+	sub     *store.Subscription
+	payload *Payload
+	// done receives the result of the delivery when it's awaited.
+	done chan error
+}
+
+func NewWebhook(logger *logrus.Logger, httpClient *http.Client, subsStore SubscriptionStore, opts ...WebhookOption) *Webhook {
+	cfg := &webhookConfig{
+		maxElapsedTime: DefaultMaxElapsedTime,
+		backlog:        DefaultWebhookBacklog,
+		registerer:     custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	return &Webhook{
+		logger:     logger,
+		httpClient: httpClient,
+		subsStore:  subsStore,
+		cfg:        cfg,
+		metrics:    newMetrics(cfg.registerer),
+		ctx:        ctx,
+		cancel:     cancel,
+		lanes:      make(map[string]chan *delivery),
+	}
+}
+
+// Payload is the body posted to webhooks.
+type Payload struct {
+	Address     string `json:"address"`
+	Hash        string `json:"hash"`
+	From        string `json:"from"`
+	To          string `json:"to"`
+	Value       string `json:"value,omitempty"`
+	BlockNumber int64  `json:"blockNumber"`
+	BlockHash   string `json:"blockHash"`
+	// ValueUSD is the approximate value of the transaction in USD at the time of its block, if a price is known.
+	ValueUSD string `json:"valueUsd,omitempty"`
+	// Removed is set when a previously notified transaction is removed because its block was orphaned.
+	Removed bool `json:"removed,omitempty"`
+	// Labels holds the annotations added to the transaction by indexing hooks.
+	Labels map[string]string `json:"labels,omitempty"`
+	// KeyID identifies the secret of the subscription SignatureHeader is signed with, so receivers holding both the
+	// old and the new secret during a rotation know which one to verify it with.
+	KeyID string `json:"keyId,omitempty"`
+	// PreviousKeyID identifies the rotated secret PreviousSignatureHeader is signed with, set during its grace window.
+	PreviousKeyID string `json:"previousKeyId,omitempty"`
+}
+
+// Notify queues the transaction for delivery to the webhooks of its subscribed sender and recipient. An error is only
+// returned for the deliveries that couldn't be queued; the ones failing are logged and counted. With WithWebhookAwait
+// it waits for the deliveries instead, returning the errors of the failed ones too.
+func (w *Webhook) Notify(ctx context.Context, tx *store.TxRecord) error {
+	var errs []error
+	var queued []*delivery
+	for addr := range slices.Values(uniqueAddresses(tx)) {
+		sub, err := w.subsStore.GetSubscription(ctx, addr)
+		if err != nil {
+			if errors.Is(err, store.ErrNotFound) {
+				continue
+			}
+			errs = append(errs, fmt.Errorf("could not get subscription for %q: %w", addr, err))
+			continue
+		}
+		if sub.WebhookURL == "" || !sub.Accepts(tx) || !sub.Preferences.Notifies(WebhookNotifier, tx) {
+			continue
+		}
+
+		payload := newPayload(addr, tx)
+		payload.KeyID = sub.WebhookKeyID
+		if sub.PreviousWebhookKey.Active(time.Now()) {
+			payload.PreviousKeyID = sub.PreviousWebhookKey.ID
+		}
+		d := &delivery{ctx: context.WithoutCancel(ctx), sub: sub, payload: payload}
+		if w.cfg.await {
+			// the awaited deliveries are given up on along with the notification, to be notified again
+			d.ctx = ctx
+			d.done = make(chan error, 1)
+		}
+		err = w.enqueue(d)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("could not queue webhook for %q: %w", addr, err))
+			continue
+		}
+		queued = append(queued, d)
+	}
+
+	if w.cfg.await {
+		for d := range slices.Values(queued) {
+			select {
+			case err := <-d.done:
+				if err != nil {
+					errs = append(errs, fmt.Errorf("could not deliver webhook for %q: %w", d.sub.Address, err))
+				}
+			case <-ctx.Done():
+				errs = append(errs, fmt.Errorf("could not deliver webhook for %q: %w", d.sub.Address, ctx.Err()))
+			}
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// Flush waits for the queued deliveries to be delivered, or given up on, until the context is done.
+func (w *Webhook) Flush(ctx context.Context) error {
+	ticker := time.NewTicker(webhookFlushPollInterval)
+	defer ticker.Stop()
+
+	for w.pending.Load() > 0 {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+	}
+	return nil
+}
+
+// Close gives up on the queued deliveries and waits for the ones in progress to return.
+func (w *Webhook) Close() error {
+	w.mu.Lock()
+	w.cancel()
+	w.mu.Unlock()
+	w.wg.Wait()
+	return nil
+}
+
+// enqueue queues the delivery on the lane of its subscription, starting the goroutine of the lane if it has none.
+func (w *Webhook) enqueue(d *delivery) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.ctx.Err() != nil {
+		return ErrWebhookClosed
+	}
+
+	lane, ok := w.lanes[d.sub.Address]
+	if !ok {
+		lane = make(chan *delivery, w.cfg.backlog)
+		w.lanes[d.sub.Address] = lane
+		w.wg.Add(1)
+		go w.drain(d.sub.Address, lane)
+	}
+	select {
+	case lane <- d:
+		w.pending.Add(1)
+		return nil
+	default:
+		w.metrics.webhookDeliveries.WithLabelValues("dropped").Inc()
+		return ErrWebhookBacklogFull
+	}
+}
+
+// drain delivers the deliveries queued on the lane of the address in order, until it's empty. The lane is then
+// removed and its goroutine returns, a new one being started by the next delivery queued for the address.
+func (w *Webhook) drain(addr string, lane chan *delivery) {
+	defer w.wg.Done()
+	for {
+		// the lane is removed under the lock once empty, so nothing is queued on it afterwards
+		var d *delivery
+		w.mu.Lock()
+		select {
+		case d = <-lane:
+		default:
+			delete(w.lanes, addr)
+		}
+		w.mu.Unlock()
+		if d == nil {
+			return
+		}
+
+		var err error
+		switch {
+		case w.ctx.Err() != nil:
+			err = ErrWebhookClosed
+		case d.ctx.Err() != nil:
+			// the awaited notification was given up on while queued
+			err = d.ctx.Err()
+		default:
+			err = w.deliverQueued(d)
+		}
+		if d.done != nil {
+			d.done <- err
+		}
+		w.pending.Add(-1)
+	}
+}
+
+// deliverQueued delivers the queued payload until delivered, given up on or the notifier is closed, and records the
+// usage of the tenant of the subscription once delivered.
+func (w *Webhook) deliverQueued(d *delivery) error {
+	ctx, cancel := context.WithCancel(d.ctx)
+	defer cancel()
+	stop := context.AfterFunc(w.ctx, cancel)
+	defer stop()
+
+	logger := w.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"addr":    d.sub.Address,
+		"tx_hash": d.payload.Hash,
+	})
+	err := w.deliver(ctx, d.sub, d.payload)
+	if err != nil {
+		if w.ctx.Err() == nil && d.ctx.Err() == nil {
+			logger.WithError(err).Error("Failed to deliver webhook")
+		}
+		return err
+	}
+	if w.cfg.usage != nil && d.sub.Tenant != "" {
+		err = w.cfg.usage.IncrementDeliveries(ctx, d.sub.Tenant, metering.ChannelWebhook, 1)
+		if err != nil {
+			logger.WithError(err).WithField("tenant", d.sub.Tenant).Warn("Failed to record webhook delivery usage")
+		}
+	}
+	return nil
+}
+
+func (w *Webhook) deliver(ctx context.Context, sub *store.Subscription, payload *Payload) error {
+	body, err := json.Marshal(payload)
+	if err != nil {
+		return fmt.Errorf("could not marshal webhook payload: %w", err)
+	}
+
+	logger := w.logger.WithContext(ctx).WithFields(logrus.Fields{
+		"addr":    sub.Address,
+		"tx_hash": payload.Hash,
+	})
+
+	start := time.Now()
+	bo := backoff.WithContext(newExponentialBackoffConfig(w.cfg.maxElapsedTime), ctx)
+	err = backoff.Retry(func() error {
+		w.metrics.webhookAttempts.Inc()
+		err := w.post(ctx, sub, payload, body)
+		if err != nil && !isPermanent(err) {
+			logger.WithError(err).Warn("Failed to deliver webhook, retrying...")
+		}
+		return err
+	}, bo)
+	w.metrics.webhookDeliveryDuration.Observe(time.Since(start).Seconds())
+	w.metrics.webhookDeliveries.WithLabelValues(result(err)).Inc()
+	return err
+}
+
+func (w *Webhook) post(ctx context.Context, sub *store.Subscription, payload *Payload, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, sub.WebhookURL, bytes.NewReader(body))
+	if err != nil {
+		return backoff.Permanent(fmt.Errorf("could not create webhook request: %w", err))
+	}
+
+	timestamp := strconv.FormatInt(time.Now().Unix(), 10)
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(TimestampHeader, timestamp)
+	req.Header.Set(SignatureHeader, "sha256="+Sign(sub.WebhookSecret, timestamp, body))
+	// the payload names the previous key only during its grace window, even if it expires while retrying
+	if payload.PreviousKeyID != "" {
+		req.Header.Set(PreviousSignatureHeader, "sha256="+Sign(sub.PreviousWebhookKey.Secret, timestamp, body))
+	}
+
+	resp, err := w.httpClient.Do(req)
+	if err != nil {
+		// timeouts of the http client are retried like the other network errors, only the delivery being cancelled
+		// or timing out is given up on
+		if ctx.Err() != nil {
+			return backoff.Permanent(fmt.Errorf("could not make http call: %w", err))
+		}
+		return fmt.Errorf("http request failed: %w", err)
+	}
+	defer func() {
+		_, _ = io.Copy(io.Discard, resp.Body)
+		_ = resp.Body.Close()
+	}()
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500:
+		return fmt.Errorf("unexpected status code: %d", resp.StatusCode)
+	default:
+		return backoff.Permanent(fmt.Errorf("unexpected status code: %d", resp.StatusCode))
+	}
+}
+
+// Sign returns the hex encoded HMAC-SHA256 signature of the body signed at the given timestamp.
+// Receivers verify a delivery by recomputing it from the timestamp header and the raw body.
+func Sign(secret, timestamp string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(timestamp))
+	mac.Write([]byte("."))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func newPayload(addr string, tx *store.TxRecord) *Payload {
+	payload := &Payload{
+		Address:     addr,
+		Hash:        tx.Hash,
+		From:        tx.From,
+		To:          tx.To,
+		BlockNumber: tx.BlockNumber,
+		BlockHash:   tx.BlockHash,
+		ValueUSD:    tx.ValueUSD,
+		Removed:     tx.Removed,
+		Labels:      tx.Labels,
+	}
+	if tx.Value != nil {
+		payload.Value = tx.Value.String()
+	}
+	return payload
+}
+
+func uniqueAddresses(tx *store.TxRecord) []string {
+	addrs := make([]string, 0, 2)
+	if tx.From != "" {
+		addrs = append(addrs, tx.From)
+	}
+	if tx.To != "" && tx.To != tx.From {
+		addrs = append(addrs, tx.To)
+	}
+	return addrs
+}
+
+func isPermanent(err error) bool {
+	var permanent *backoff.PermanentError
+	return errors.As(err, &permanent)
+}
+
+func newExponentialBackoffConfig(maxElapsedTime time.Duration) *backoff.ExponentialBackOff {
+	return backoff.NewExponentialBackOff(
+		backoff.WithMaxElapsedTime(maxElapsedTime),
+		backoff.WithMaxInterval(time.Second*5),
+		backoff.WithInitialInterval(time.Millisecond*200),
+		backoff.WithMultiplier(2),
+		backoff.WithRandomizationFactor(0.2),
+	)
+}
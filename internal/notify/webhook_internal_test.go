@@ -0,0 +1,55 @@
+package notify
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type subscriptionStoreFunc func(ctx context.Context, addr string) (*store.Subscription, error)
+
+func (f subscriptionStoreFunc) GetSubscription(ctx context.Context, addr string) (*store.Subscription, error) {
+	return f(ctx, addr)
+}
+
+func TestWebhookStopsIdleLanes(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	subsStore := subscriptionStoreFunc(func(ctx context.Context, addr string) (*store.Subscription, error) {
+		return &store.Subscription{Address: addr, WebhookURL: srv.URL}, nil
+	})
+	webhook := NewWebhook(logrus.New(), srv.Client(), subsStore)
+	defer webhook.Close()
+
+	for n := range 10 {
+		err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-" + strconv.Itoa(n), From: "addr-" + strconv.Itoa(n)})
+		require.NoError(t, err)
+	}
+	require.NoError(t, webhook.Flush(context.Background()))
+
+	// the lanes are removed and their goroutines return once the lanes are emptied
+	require.Eventually(t, func() bool {
+		webhook.mu.Lock()
+		defer webhook.mu.Unlock()
+		return len(webhook.lanes) == 0
+	}, time.Second, time.Millisecond*10)
+	done := make(chan struct{})
+	go func() {
+		webhook.wg.Wait()
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(time.Second):
+		t.Fatal("lane goroutines are still running")
+	}
+}
@@ -0,0 +1,365 @@
+package notify_test
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/notify"
+	"github.com/hedisam/ethtxparser/internal/notify/mocks"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+//go:generate moq -out mocks/subscriptions_store.go -pkg mocks -skip-ensure . SubscriptionStore
+
+func TestWebhookNotifyRotatedSecret(t *testing.T) {
+	tests := map[string]struct {
+		previous                  *store.WebhookKey
+		expectedPreviousKeyID     string
+		expectedPreviousSignature bool
+	}{
+		"within grace period": {
+			previous:                  &store.WebhookKey{ID: "v1", Secret: "old", ExpiresAt: time.Now().Add(time.Hour)},
+			expectedPreviousKeyID:     "v1",
+			expectedPreviousSignature: true,
+		},
+		"grace period over": {
+			previous: &store.WebhookKey{ID: "v1", Secret: "old", ExpiresAt: time.Now().Add(-time.Second)},
+		},
+		"never rotated": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var delivered atomic.Bool
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				delivered.Store(true)
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				var payload notify.Payload
+				assert.NoError(t, json.Unmarshal(body, &payload))
+				assert.Equal(t, "v2", payload.KeyID)
+				assert.Equal(t, test.expectedPreviousKeyID, payload.PreviousKeyID)
+
+				timestamp := r.Header.Get(notify.TimestampHeader)
+				assert.Equal(t, "sha256="+notify.Sign("n3w", timestamp, body), r.Header.Get(notify.SignatureHeader))
+				if test.expectedPreviousSignature {
+					assert.Equal(t, "sha256="+notify.Sign("old", timestamp, body), r.Header.Get(notify.PreviousSignatureHeader))
+				} else {
+					assert.Empty(t, r.Header.Get(notify.PreviousSignatureHeader))
+				}
+			}))
+			defer srv.Close()
+
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != "addr-1" {
+						return nil, store.ErrNotFound
+					}
+					return &store.Subscription{
+						Address:            addr,
+						WebhookURL:         srv.URL,
+						WebhookSecret:      "n3w",
+						WebhookKeyID:       "v2",
+						PreviousWebhookKey: test.previous,
+					}, nil
+				},
+			}
+
+			webhook := notify.NewWebhook(logrus.New(), srv.Client(), subsStoreMock, notify.WithMaxElapsedTime(time.Second))
+			defer webhook.Close()
+			err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-1", From: "addr-1", To: "addr-2"})
+			require.NoError(t, err)
+			require.NoError(t, webhook.Flush(context.Background()))
+			assert.True(t, delivered.Load())
+		})
+	}
+}
+
+func TestWebhookNotifyUsage(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	defer srv.Close()
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			switch addr {
+			case "addr-1":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL, Tenant: "acme"}, nil
+			case "addr-2":
+				// subscribed from the watchlist, without a tenant
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL}, nil
+			}
+			return nil, store.ErrNotFound
+		},
+	}
+
+	usageStore := memdb.NewUsageStore()
+	webhook := notify.NewWebhook(logrus.New(), srv.Client(), subsStoreMock, notify.WithMaxElapsedTime(time.Second), notify.WithWebhookUsage(usageStore))
+	defer webhook.Close()
+	err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-1", From: "addr-1", To: "addr-2"})
+	require.NoError(t, err)
+	err = webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-2", From: "addr-3", To: "addr-1"})
+	require.NoError(t, err)
+	require.NoError(t, webhook.Flush(context.Background()))
+
+	usages, err := usageStore.GetUsage(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, []*store.TenantUsage{
+		{Tenant: "acme", Deliveries: map[string]int64{metering.ChannelWebhook: 2}},
+	}, usages)
+}
+
+func TestWebhookNotifyRetriesClientTimeouts(t *testing.T) {
+	var attempts atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if attempts.Add(1) == 1 {
+			select {
+			case <-time.After(time.Second):
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer srv.Close()
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			if addr != "addr-1" {
+				return nil, store.ErrNotFound
+			}
+			return &store.Subscription{Address: addr, WebhookURL: srv.URL}, nil
+		},
+	}
+
+	httpClient := srv.Client()
+	httpClient.Timeout = 50 * time.Millisecond
+	webhook := notify.NewWebhook(logrus.New(), httpClient, subsStoreMock, notify.WithMaxElapsedTime(time.Second))
+	defer webhook.Close()
+	err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-1", From: "addr-1", To: "addr-2"})
+	require.NoError(t, err)
+	require.NoError(t, webhook.Flush(context.Background()))
+	assert.Equal(t, int32(2), attempts.Load())
+}
+
+func TestWebhookNotify(t *testing.T) {
+	tests := map[string]struct {
+		statusCodes      []int
+		minValue         *big.Int
+		noWebhook        bool
+		expectedAttempts int32
+	}{
+		"delivered": {
+			statusCodes:      []int{http.StatusOK},
+			expectedAttempts: 1,
+		},
+		"retried on server error": {
+			statusCodes:      []int{http.StatusInternalServerError, http.StatusTooManyRequests, http.StatusNoContent},
+			expectedAttempts: 3,
+		},
+		"client error is not retried": {
+			statusCodes:      []int{http.StatusBadRequest},
+			expectedAttempts: 1,
+		},
+		"retries exhausted": {
+			statusCodes:      []int{http.StatusBadGateway},
+			expectedAttempts: -1,
+		},
+		"below min value": {
+			minValue:         big.NewInt(1000),
+			expectedAttempts: 0,
+		},
+		"no webhook configured": {
+			noWebhook:        true,
+			expectedAttempts: 0,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var attempts atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				n := int(attempts.Add(1)) - 1
+				body, err := io.ReadAll(r.Body)
+				assert.NoError(t, err)
+
+				timestamp := r.Header.Get(notify.TimestampHeader)
+				assert.Equal(t, "sha256="+notify.Sign("s3cret", timestamp, body), r.Header.Get(notify.SignatureHeader))
+
+				var payload notify.Payload
+				assert.NoError(t, json.Unmarshal(body, &payload))
+				assert.Equal(t, "addr-1", payload.Address)
+				assert.Equal(t, "tx-1", payload.Hash)
+				assert.Equal(t, "100", payload.Value)
+
+				w.WriteHeader(test.statusCodes[min(n, len(test.statusCodes)-1)])
+			}))
+			defer srv.Close()
+
+			subsStoreMock := &mocks.SubscriptionStoreMock{
+				GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+					if addr != "addr-1" {
+						return nil, store.ErrNotFound
+					}
+					sub := &store.Subscription{
+						Address:       addr,
+						MinValue:      test.minValue,
+						WebhookURL:    srv.URL,
+						WebhookSecret: "s3cret",
+					}
+					if test.noWebhook {
+						sub.WebhookURL = ""
+					}
+					return sub, nil
+				},
+			}
+
+			webhook := notify.NewWebhook(logrus.New(), srv.Client(), subsStoreMock, notify.WithMaxElapsedTime(time.Second))
+			defer webhook.Close()
+			err := webhook.Notify(context.Background(), &store.TxRecord{
+				Hash:        "tx-1",
+				From:        "addr-1",
+				To:          "addr-2",
+				Value:       big.NewInt(100),
+				BlockNumber: 1,
+				BlockHash:   "hash-1",
+			})
+			require.NoError(t, err)
+			require.NoError(t, webhook.Flush(context.Background()))
+			assert.Len(t, subsStoreMock.GetSubscriptionCalls(), 2)
+			if test.expectedAttempts >= 0 {
+				assert.Equal(t, test.expectedAttempts, attempts.Load())
+			} else {
+				assert.Greater(t, attempts.Load(), int32(1))
+			}
+		})
+	}
+}
+
+func TestWebhookNotifyFailingWebhook(t *testing.T) {
+	var delivered, failing atomic.Int32
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/failing" {
+			failing.Add(1)
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		delivered.Add(1)
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			switch addr {
+			case "addr-1":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL + "/failing"}, nil
+			case "addr-2":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL}, nil
+			}
+			return nil, store.ErrNotFound
+		},
+	}
+
+	webhook := notify.NewWebhook(logrus.New(), srv.Client(), subsStoreMock, notify.WithMaxElapsedTime(time.Minute), notify.WithWebhookBacklog(2))
+	// once the first delivery to addr-1 is in progress, the next two fill its backlog and the fourth is dropped
+	for n := range 4 {
+		err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-" + strconv.Itoa(n), From: "addr-1"})
+		switch n {
+		case 0:
+			require.NoError(t, err)
+			require.Eventually(t, func() bool {
+				return failing.Load() == 1
+			}, time.Second, time.Millisecond*10)
+		case 3:
+			require.ErrorIs(t, err, notify.ErrWebhookBacklogFull)
+		default:
+			require.NoError(t, err)
+		}
+	}
+
+	// the deliveries of the other subscriptions aren't held up by the failing webhook
+	for n := range 2 {
+		err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-" + strconv.Itoa(n), From: "addr-3", To: "addr-2"})
+		require.NoError(t, err)
+	}
+	require.Eventually(t, func() bool {
+		return delivered.Load() == 2
+	}, time.Second, time.Millisecond*10)
+
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancelFlush()
+	require.ErrorIs(t, webhook.Flush(flushCtx), context.DeadlineExceeded)
+
+	// closing gives up on the queued deliveries
+	require.NoError(t, webhook.Close())
+	require.NoError(t, webhook.Flush(context.Background()))
+	err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-4", From: "addr-2"})
+	require.ErrorIs(t, err, notify.ErrWebhookClosed)
+}
+
+func TestWebhookNotifyAwait(t *testing.T) {
+	release := make(chan struct{})
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/rejecting":
+			w.WriteHeader(http.StatusBadRequest)
+		case "/stuck":
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}
+	}))
+	defer srv.Close()
+	defer close(release)
+
+	subsStoreMock := &mocks.SubscriptionStoreMock{
+		GetSubscriptionFunc: func(ctx context.Context, addr string) (*store.Subscription, error) {
+			switch addr {
+			case "addr-1":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL}, nil
+			case "addr-2":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL + "/rejecting"}, nil
+			case "addr-3":
+				return &store.Subscription{Address: addr, WebhookURL: srv.URL + "/stuck"}, nil
+			}
+			return nil, store.ErrNotFound
+		},
+	}
+
+	webhook := notify.NewWebhook(logrus.New(), srv.Client(), subsStoreMock, notify.WithMaxElapsedTime(time.Minute), notify.WithWebhookAwait())
+	defer webhook.Close()
+
+	err := webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-1", From: "addr-1", To: "addr-4"})
+	require.NoError(t, err)
+
+	// the failed delivery is returned, for the outbox to retry it
+	err = webhook.Notify(context.Background(), &store.TxRecord{Hash: "tx-2", From: "addr-1", To: "addr-2"})
+	require.ErrorContains(t, err, "unexpected status code: 400")
+	require.ErrorContains(t, err, `could not deliver webhook for "addr-2"`)
+
+	// the delivery is given up on along with the notification
+	ctx, cancel := context.WithTimeout(context.Background(), time.Millisecond*50)
+	defer cancel()
+	err = webhook.Notify(ctx, &store.TxRecord{Hash: "tx-3", From: "addr-3"})
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	require.NoError(t, webhook.Flush(context.Background()))
+}
@@ -0,0 +1,37 @@
+package pipebuffer
+
+import (
+	"maps"
+	"sync"
+)
+
+// lags holds the lag of every running buffer by stage, for the debug endpoints.
+var lags = struct {
+	mu sync.Mutex
+	m  map[string]int
+}{m: make(map[string]int)}
+
+func setLag(m *metrics, stage string, lag int) {
+	lags.mu.Lock()
+	defer lags.mu.Unlock()
+
+	lags.m[stage] = lag
+	m.bufferLag.WithLabelValues(stage).Set(float64(lag))
+}
+
+func removeLag(m *metrics, stage string) {
+	lags.mu.Lock()
+	defer lags.mu.Unlock()
+
+	delete(lags.m, stage)
+	m.bufferLag.WithLabelValues(stage).Set(0)
+}
+
+// Lags returns the number of values buffered by every running buffer, in memory or spilled to disk, by stage.
+// Unbuffered stages aren't included.
+func Lags() map[string]int {
+	lags.mu.Lock()
+	defer lags.mu.Unlock()
+
+	return maps.Clone(lags.m)
+}
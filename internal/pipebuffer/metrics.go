@@ -0,0 +1,32 @@
+package pipebuffer
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of the buffers, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	bufferLag     *prometheus.GaugeVec
+	droppedValues *prometheus.CounterVec
+	spilledValues *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		bufferLag: auto.NewGaugeVec(prometheus.GaugeOpts{
+			Name: "ethtxparser_pipeline_buffer_lag",
+			Help: "Number of values buffered between two pipeline stages, in memory or spilled to disk, by stage",
+		}, []string{"stage"}),
+		droppedValues: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_pipeline_buffer_dropped_total",
+			Help: "Number of oldest buffered values dropped from full pipeline buffers, by stage",
+		}, []string{"stage"}),
+		spilledValues: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_pipeline_buffer_spilled_total",
+			Help: "Number of values spilled to disk by full pipeline buffers, by stage",
+		}, []string{"stage"}),
+	}
+}
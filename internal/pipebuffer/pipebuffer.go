@@ -0,0 +1,197 @@
+// Package pipebuffer buffers the values passed between two pipeline stages, so a slow consumer doesn't stall the
+// producer until the buffer is full, and then applies the configured policy.
+package pipebuffer
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/ringbuffer"
+)
+
+// initialSize is the number of values the memory of a buffer is allocated for at first, growing up to its size as
+// the consumer falls behind.
+const initialSize = 64
+
+// Policy is what a full buffer does with the values it receives.
+type Policy string
+
+const (
+	// PolicyBlock stops receiving until the consumer catches up, stalling the producer.
+	PolicyBlock Policy = "block"
+	// PolicyDropOldest drops the oldest buffered value to make room for the received one.
+	PolicyDropOldest Policy = "drop-oldest"
+	// PolicySpill spills the received values to disk until the consumer catches up.
+	PolicySpill Policy = "spill"
+)
+
+// ParsePolicy returns the policy of the given name.
+func ParsePolicy(name string) (Policy, error) {
+	policy := Policy(name)
+	switch policy {
+	case PolicyBlock, PolicyDropOldest, PolicySpill:
+		return policy, nil
+	default:
+		return "", fmt.Errorf("unknown buffer policy %q", name)
+	}
+}
+
+type config struct {
+	policy     Policy
+	spillDir   string
+	registerer prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithPolicy sets the policy of the buffer once full, PolicyBlock by default.
+func WithPolicy(policy Policy) Option {
+	return func(c *config) {
+		c.policy = policy
+	}
+}
+
+// WithSpillDir sets the directory the spill files are created in, the default temporary directory by default.
+func WithSpillDir(dir string) Option {
+	return func(c *config) {
+		c.spillDir = dir
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the buffer are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
+
+// Buffer forwards the values received from in, buffering up to size of them in memory while the consumer is behind.
+// The returned channel is closed once in is closed and the buffered values are forwarded, or the context is done.
+// Spilled values are gob encoded. With a zero size and the block policy, in is returned as is.
+func Buffer[T any](ctx context.Context, logger *logrus.Logger, stage string, in <-chan T, size uint, opts ...Option) (<-chan T, error) {
+	cfg := &config{
+		policy:     PolicyBlock,
+		spillDir:   os.TempDir(),
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	if size == 0 && cfg.policy == PolicyBlock {
+		return in, nil
+	}
+
+	var spill *spillFile[T]
+	if cfg.policy == PolicySpill {
+		var err error
+		spill, err = newSpillFile[T](cfg.spillDir, stage)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	b := &buffer[T]{
+		logger:  logger.WithField("stage", stage),
+		stage:   stage,
+		policy:  cfg.policy,
+		metrics: newMetrics(cfg.registerer),
+		mem:     ringbuffer.NewGrowable[T](min(size, initialSize), size),
+		spill:   spill,
+	}
+	out := make(chan T)
+	go b.run(ctx, in, out)
+	return out, nil
+}
+
+type buffer[T any] struct {
+	logger  *logrus.Entry
+	stage   string
+	policy  Policy
+	metrics *metrics
+	mem     *ringbuffer.RingBuffer[T]
+	spill   *spillFile[T]
+}
+
+func (b *buffer[T]) run(ctx context.Context, in <-chan T, out chan<- T) {
+	defer close(out)
+	defer removeLag(b.metrics, b.stage)
+	if b.spill != nil {
+		defer b.spill.close()
+	}
+
+	for in != nil || b.mem.Size() > 0 {
+		receive := in
+		if b.policy == PolicyBlock && b.mem.IsFull() {
+			receive = nil
+		}
+		var send chan<- T
+		next, ok := b.mem.Front()
+		if ok {
+			send = out
+		}
+
+		select {
+		case <-ctx.Done():
+			return
+		case v, ok := <-receive:
+			if !ok {
+				in = nil
+				continue
+			}
+			b.push(v)
+		case send <- next:
+			b.mem.Pop()
+			b.refill()
+		}
+		setLag(b.metrics, b.stage, b.lag())
+	}
+}
+
+// push buffers the received value, applying the policy if the buffer is full. Once spilling, values keep being
+// spilled until the spilled ones are read back, so they're forwarded in order.
+func (b *buffer[T]) push(v T) {
+	if b.spill != nil && (b.spill.count > 0 || b.mem.IsFull()) {
+		err := b.spill.push(v)
+		if err == nil {
+			b.metrics.spilledValues.WithLabelValues(b.stage).Inc()
+			return
+		}
+		// the value is kept in memory rather than lost, growing the buffer past its size
+		b.logger.WithError(err).Error("Failed to spill value to disk, keeping it in memory")
+		b.mem.Resize(uint(b.mem.Size() + 1))
+	}
+	if b.mem.IsFull() {
+		b.mem.Pop()
+		b.metrics.droppedValues.WithLabelValues(b.stage).Inc()
+	}
+	_ = b.mem.Push(v)
+}
+
+// refill reads the spilled values back into memory while there's room.
+func (b *buffer[T]) refill() {
+	for b.spill != nil && b.spill.count > 0 && !b.mem.IsFull() {
+		v, err := b.spill.pop()
+		if err != nil {
+			b.logger.WithError(err).Error("Failed to read spilled value from disk, dropping the spilled values")
+			b.metrics.droppedValues.WithLabelValues(b.stage).Add(float64(b.spill.count))
+			b.spill.count = 0
+			_ = b.spill.truncate()
+			return
+		}
+		_ = b.mem.Push(v)
+	}
+}
+
+func (b *buffer[T]) lag() int {
+	lag := b.mem.Size()
+	if b.spill != nil {
+		lag += b.spill.count
+	}
+	return lag
+}
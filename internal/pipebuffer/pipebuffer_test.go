@@ -0,0 +1,150 @@
+package pipebuffer_test
+
+import (
+	"context"
+	"os"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/pipebuffer"
+	"github.com/hedisam/pipeline/chans"
+)
+
+func TestBuffer(t *testing.T) {
+	tests := map[string]struct {
+		policy         pipebuffer.Policy
+		sent           []int64
+		expectedBlocks []int64
+	}{
+		"drop oldest keeps the newest blocks": {
+			policy:         pipebuffer.PolicyDropOldest,
+			sent:           []int64{1, 2, 3, 4, 5},
+			expectedBlocks: []int64{4, 5},
+		},
+		"spill keeps every block in order": {
+			policy:         pipebuffer.PolicySpill,
+			sent:           []int64{1, 2, 3, 4, 5},
+			expectedBlocks: []int64{1, 2, 3, 4, 5},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			dir := t.TempDir()
+			in := make(chan *eth.Block)
+			out, err := pipebuffer.Buffer(context.Background(), logrus.New(), "blocks", in, 2,
+				pipebuffer.WithPolicy(test.policy), pipebuffer.WithSpillDir(dir))
+			require.NoError(t, err)
+
+			// the buffer never blocks the producer, nothing is consumed until all the blocks are sent
+			for number := range slices.Values(test.sent) {
+				in <- &eth.Block{Number: number, Txs: []*eth.Tx{{Hash: "0x01"}}}
+			}
+			close(in)
+
+			var received []int64
+			for block := range out {
+				assert.Len(t, block.Txs, 1)
+				received = append(received, block.Number)
+			}
+			assert.Equal(t, test.expectedBlocks, received)
+
+			// the spill file is removed once the buffer is done
+			entries, err := os.ReadDir(dir)
+			require.NoError(t, err)
+			assert.Empty(t, entries)
+		})
+	}
+}
+
+func TestBufferSpillInterleaved(t *testing.T) {
+	in := make(chan int)
+	out, err := pipebuffer.Buffer(context.Background(), logrus.New(), "ints", in, 1,
+		pipebuffer.WithPolicy(pipebuffer.PolicySpill), pipebuffer.WithSpillDir(t.TempDir()))
+	require.NoError(t, err)
+
+	in <- 1
+	in <- 2
+	in <- 3
+	assert.Equal(t, 1, <-out)
+	in <- 4
+	assert.Equal(t, 2, <-out)
+	assert.Equal(t, 3, <-out)
+	assert.Equal(t, 4, <-out)
+	// the drained spill file is reused
+	in <- 5
+	in <- 6
+	close(in)
+	assert.Equal(t, []int{5, 6}, slices.Collect(chans.ReceiveOrDoneSeq(context.Background(), out)))
+}
+
+func TestBufferBlocks(t *testing.T) {
+	in := make(chan int)
+	out, err := pipebuffer.Buffer(context.Background(), logrus.New(), "ints", in, 2)
+	require.NoError(t, err)
+
+	in <- 1
+	in <- 2
+	select {
+	case in <- 3:
+		t.Fatal("full buffer received a value")
+	case <-time.After(50 * time.Millisecond):
+	}
+
+	assert.Equal(t, 1, <-out)
+	in <- 3
+	close(in)
+	assert.Equal(t, []int{2, 3}, slices.Collect(chans.ReceiveOrDoneSeq(context.Background(), out)))
+}
+
+func TestLags(t *testing.T) {
+	in := make(chan int)
+	out, err := pipebuffer.Buffer(context.Background(), logrus.New(), "lagging", in, 2)
+	require.NoError(t, err)
+
+	in <- 1
+	in <- 2
+	require.Eventually(t, func() bool {
+		return pipebuffer.Lags()["lagging"] == 2
+	}, time.Second, time.Millisecond)
+
+	close(in)
+	assert.Equal(t, []int{1, 2}, slices.Collect(chans.ReceiveOrDoneSeq(context.Background(), out)))
+	assert.NotContains(t, pipebuffer.Lags(), "lagging")
+}
+
+func TestParsePolicy(t *testing.T) {
+	policy, err := pipebuffer.ParsePolicy("drop-oldest")
+	require.NoError(t, err)
+	assert.Equal(t, pipebuffer.PolicyDropOldest, policy)
+
+	_, err = pipebuffer.ParsePolicy("drop-newest")
+	assert.Error(t, err)
+}
+
+func TestTee(t *testing.T) {
+	in := make(chan int)
+	outs, err := pipebuffer.Tee(context.Background(), logrus.New(), in,
+		pipebuffer.Branch{Stage: "fast", Size: 1},
+		pipebuffer.Branch{Stage: "slow", Size: 1, Opts: []pipebuffer.Option{pipebuffer.WithPolicy(pipebuffer.PolicyDropOldest)}},
+	)
+	require.NoError(t, err)
+	require.Len(t, outs, 2)
+
+	// the slow branch isn't consumed, it doesn't hold back the fast one
+	for v := range 5 {
+		in <- v
+		assert.Equal(t, v, <-outs[0])
+	}
+	close(in)
+
+	_, ok := <-outs[0]
+	assert.False(t, ok)
+	assert.Equal(t, []int{4}, slices.Collect(chans.ReceiveOrDoneSeq(context.Background(), outs[1])))
+}
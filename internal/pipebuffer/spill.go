@@ -0,0 +1,90 @@
+package pipebuffer
+
+import (
+	"encoding/gob"
+	"fmt"
+	"io"
+	"os"
+)
+
+// spillFile is an on-disk FIFO of gob encoded values, reset once drained.
+type spillFile[T any] struct {
+	w     *os.File
+	r     *os.File
+	enc   *gob.Encoder
+	dec   *gob.Decoder
+	count int
+}
+
+func newSpillFile[T any](dir, stage string) (*spillFile[T], error) {
+	w, err := os.CreateTemp(dir, fmt.Sprintf("ethtxparser-%s-*.spill", stage))
+	if err != nil {
+		return nil, fmt.Errorf("could not create spill file: %w", err)
+	}
+	r, err := os.Open(w.Name())
+	if err != nil {
+		_ = w.Close()
+		_ = os.Remove(w.Name())
+		return nil, fmt.Errorf("could not open spill file: %w", err)
+	}
+
+	s := &spillFile[T]{w: w, r: r}
+	s.reset()
+	return s, nil
+}
+
+// push appends the value to the file.
+func (s *spillFile[T]) push(v T) error {
+	err := s.enc.Encode(v)
+	if err != nil {
+		return fmt.Errorf("could not spill value: %w", err)
+	}
+	s.count++
+	return nil
+}
+
+// pop reads the oldest value from the file, truncating it once all the values are read.
+func (s *spillFile[T]) pop() (T, error) {
+	var v T
+	err := s.dec.Decode(&v)
+	if err != nil {
+		return v, fmt.Errorf("could not read spilled value: %w", err)
+	}
+	s.count--
+	if s.count == 0 {
+		err = s.truncate()
+		if err != nil {
+			return v, err
+		}
+	}
+	return v, nil
+}
+
+func (s *spillFile[T]) truncate() error {
+	err := s.w.Truncate(0)
+	if err != nil {
+		return fmt.Errorf("could not truncate spill file: %w", err)
+	}
+	_, err = s.w.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("could not rewind spill file: %w", err)
+	}
+	_, err = s.r.Seek(0, io.SeekStart)
+	if err != nil {
+		return fmt.Errorf("could not rewind spill file: %w", err)
+	}
+	s.reset()
+	return nil
+}
+
+// reset starts new gob streams, as type definitions are only sent once per stream.
+func (s *spillFile[T]) reset() {
+	s.enc = gob.NewEncoder(s.w)
+	s.dec = gob.NewDecoder(s.r)
+}
+
+func (s *spillFile[T]) close() {
+	_ = s.r.Close()
+	_ = s.w.Close()
+	_ = os.Remove(s.w.Name())
+}
@@ -0,0 +1,58 @@
+package pipebuffer
+
+import (
+	"context"
+	"fmt"
+	"slices"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/pipeline/chans"
+)
+
+// Branch is a consumer of a teed stream, fed through its own buffer.
+type Branch struct {
+	// Stage names the buffer of the branch in logs and metrics.
+	Stage string
+	Size  uint
+	Opts  []Option
+}
+
+// Tee duplicates every value received from in to every branch, returning their channels in the same order. Each
+// branch is fed through its own buffer so a slow consumer only holds back the others once its buffer is full, and
+// only with the block policy; drop-oldest and spill branches never do. The returned channels are closed once in is
+// closed and their buffered values are forwarded, or the context is done.
+func Tee[T any](ctx context.Context, logger *logrus.Logger, in <-chan T, branches ...Branch) ([]<-chan T, error) {
+	feeds := make([]chan T, 0, len(branches))
+	outs := make([]<-chan T, 0, len(branches))
+	for branch := range slices.Values(branches) {
+		feed := make(chan T)
+		out, err := Buffer(ctx, logger, branch.Stage, feed, branch.Size, branch.Opts...)
+		if err != nil {
+			for feed := range slices.Values(feeds) {
+				close(feed)
+			}
+			return nil, fmt.Errorf("could not create buffer of branch %q: %w", branch.Stage, err)
+		}
+		feeds = append(feeds, feed)
+		outs = append(outs, out)
+	}
+
+	go func() {
+		defer func() {
+			for feed := range slices.Values(feeds) {
+				close(feed)
+			}
+		}()
+
+		for v := range chans.ReceiveOrDoneSeq(ctx, in) {
+			for feed := range slices.Values(feeds) {
+				if !chans.SendOrDone(ctx, feed, v) {
+					return
+				}
+			}
+		}
+	}()
+
+	return outs, nil
+}
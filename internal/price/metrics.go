@@ -0,0 +1,22 @@
+package price
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of an Oracle, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	priceLookups *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		priceLookups: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_price_lookups_total",
+			Help: "Total number of ether price lookups by result: cache hit, miss fetched from the source or failure",
+		}, []string{"result"}),
+	}
+}
@@ -0,0 +1,238 @@
+package price
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math/big"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// TimestampPlaceholder is replaced with the unix timestamp in seconds in the price source URL.
+	TimestampPlaceholder = "{timestamp}"
+
+	DefaultJSONPath   = "usd"
+	DefaultResolution = time.Minute
+	DefaultCacheSize  = 1024
+)
+
+// weiPerEther converts wei values to ether.
+var weiPerEther = new(big.Rat).SetInt(new(big.Int).Exp(big.NewInt(10), big.NewInt(18), nil))
+
+type config struct {
+	jsonPath   []string
+	resolution time.Duration
+	cacheSize  int
+	registerer prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithJSONPath sets the dot separated path of the price in the JSON responses of the source, e.g. "ethereum.usd".
+func WithJSONPath(path string) Option {
+	return func(c *config) {
+		c.jsonPath = strings.Split(path, ".")
+	}
+}
+
+// WithResolution sets the interval block timestamps are truncated to, blocks within the same interval sharing a
+// single price lookup.
+func WithResolution(resolution time.Duration) Option {
+	return func(c *config) {
+		c.resolution = resolution
+	}
+}
+
+// WithCacheSize sets the number of prices cached, the oldest looked up price being evicted first.
+func WithCacheSize(size int) Option {
+	return func(c *config) {
+		c.cacheSize = size
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the oracle are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
+
+// Oracle looks up the USD price of ether at block timestamps from an HTTP source.
+type Oracle struct {
+	logger      *logrus.Logger
+	httpClient  *http.Client
+	urlTemplate string
+	cfg         *config
+	metrics     *metrics
+
+	mu     sync.Mutex
+	prices map[int64]*big.Rat
+	order  []int64
+}
+
+// New returns an oracle looking up prices from the given URL template, its TimestampPlaceholder replaced with the
+// truncated block timestamp, e.g. https://prices.example.com/eth?at={timestamp}.
+func New(logger *logrus.Logger, httpClient *http.Client, urlTemplate string, opts ...Option) *Oracle {
+	cfg := &config{
+		jsonPath:   strings.Split(DefaultJSONPath, "."),
+		resolution: DefaultResolution,
+		cacheSize:  DefaultCacheSize,
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	return &Oracle{
+		logger:      logger,
+		httpClient:  httpClient,
+		urlTemplate: urlTemplate,
+		cfg:         cfg,
+		metrics:     newMetrics(cfg.registerer),
+		prices:      make(map[int64]*big.Rat, cfg.cacheSize),
+	}
+}
+
+// PriceAt returns the USD price of ether at the given unix timestamp in seconds.
+func (o *Oracle) PriceAt(ctx context.Context, timestamp int64) (*big.Rat, error) {
+	resolution := max(int64(o.cfg.resolution/time.Second), 1)
+	timestamp -= timestamp % resolution
+
+	price, ok := o.cached(timestamp)
+	if ok {
+		o.metrics.priceLookups.WithLabelValues("hit").Inc()
+		return price, nil
+	}
+
+	price, err := o.fetch(ctx, timestamp)
+	if err != nil {
+		o.metrics.priceLookups.WithLabelValues("failure").Inc()
+		return nil, err
+	}
+	o.metrics.priceLookups.WithLabelValues("miss").Inc()
+	o.cache(timestamp, price)
+
+	return price, nil
+}
+
+// HookTx records the approximate USD value of the transaction at the time of its block, to be registered as an
+// index.TxHook. Failed price lookups are logged and leave the value unset rather than failing the block.
+func (o *Oracle) HookTx(ctx context.Context, record *store.TxRecord) (bool, error) {
+	if record.Value == nil || record.BlockTimestamp == 0 {
+		return true, nil
+	}
+
+	price, err := o.PriceAt(ctx, record.BlockTimestamp)
+	if err != nil {
+		o.logger.WithContext(ctx).WithError(err).WithFields(logrus.Fields{
+			"tx_hash":         record.Hash,
+			"block_timestamp": record.BlockTimestamp,
+		}).Warn("Failed to look up ether price, transaction USD value not recorded")
+		return true, nil
+	}
+
+	value := new(big.Rat).SetInt(record.Value)
+	value.Quo(value, weiPerEther).Mul(value, price)
+	record.ValueUSD = value.FloatString(2)
+	return true, nil
+}
+
+func (o *Oracle) cached(timestamp int64) (*big.Rat, bool) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	price, ok := o.prices[timestamp]
+	return price, ok
+}
+
+func (o *Oracle) cache(timestamp int64, price *big.Rat) {
+	o.mu.Lock()
+	defer o.mu.Unlock()
+
+	if _, ok := o.prices[timestamp]; ok || o.cfg.cacheSize <= 0 {
+		return
+	}
+	if len(o.order) >= o.cfg.cacheSize {
+		delete(o.prices, o.order[0])
+		o.order = o.order[1:]
+	}
+	o.prices[timestamp] = price
+	o.order = append(o.order, timestamp)
+}
+
+// fetch gets the price at the given timestamp from the source.
+func (o *Oracle) fetch(ctx context.Context, timestamp int64) (*big.Rat, error) {
+	url := strings.ReplaceAll(o.urlTemplate, TimestampPlaceholder, strconv.FormatInt(timestamp, 10))
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, fmt.Errorf("could not create price request: %w", err)
+	}
+	req.Header.Set("Accept", "application/json")
+
+	resp, err := o.httpClient.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("could not request price: %w", err)
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, fmt.Errorf("could not read price response: %w", err)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("received unexpected price response status: %s", resp.Status)
+	}
+
+	return parsePrice(body, o.cfg.jsonPath)
+}
+
+// parsePrice extracts the price at the given path of the JSON body, either a number or a numeric string.
+func parsePrice(body []byte, path []string) (*big.Rat, error) {
+	decoder := json.NewDecoder(bytes.NewReader(body))
+	decoder.UseNumber()
+	var value any
+	err := decoder.Decode(&value)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode price response: %w", err)
+	}
+
+	for key := range slices.Values(path) {
+		object, ok := value.(map[string]any)
+		if !ok {
+			return nil, fmt.Errorf("price path %q not found in response", strings.Join(path, "."))
+		}
+		value, ok = object[key]
+		if !ok {
+			return nil, fmt.Errorf("price path %q not found in response", strings.Join(path, "."))
+		}
+	}
+
+	var s string
+	switch v := value.(type) {
+	case json.Number:
+		s = v.String()
+	case string:
+		s = v
+	default:
+		return nil, fmt.Errorf("price at path %q is not a number", strings.Join(path, "."))
+	}
+	price, ok := new(big.Rat).SetString(s)
+	if !ok || price.Sign() < 0 {
+		return nil, fmt.Errorf("invalid price %q", s)
+	}
+	return price, nil
+}
@@ -0,0 +1,111 @@
+package price_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/price"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+func TestHookTx(t *testing.T) {
+	oneEther, _ := new(big.Int).SetString("1000000000000000000", 10)
+
+	tests := map[string]struct {
+		status           int
+		body             string
+		opts             []price.Option
+		record           *store.TxRecord
+		expectedValueUSD string
+		expectedRequests int32
+	}{
+		"value at block price": {
+			status:           http.StatusOK,
+			body:             `{"usd": 2500.5}`,
+			record:           &store.TxRecord{Value: new(big.Int).Mul(oneEther, big.NewInt(2)), BlockTimestamp: 1700000042},
+			expectedValueUSD: "5001.00",
+			expectedRequests: 1,
+		},
+		"nested string price": {
+			status:           http.StatusOK,
+			body:             `{"ethereum": {"usd": "1999.99"}}`,
+			opts:             []price.Option{price.WithJSONPath("ethereum.usd")},
+			record:           &store.TxRecord{Value: big.NewInt(500000000000000000), BlockTimestamp: 1700000042},
+			expectedValueUSD: "1000.00",
+			expectedRequests: 1,
+		},
+		"missing price path": {
+			status:           http.StatusOK,
+			body:             `{"eur": 2300}`,
+			record:           &store.TxRecord{Value: oneEther, BlockTimestamp: 1700000042},
+			expectedRequests: 1,
+		},
+		"source failure": {
+			status:           http.StatusInternalServerError,
+			record:           &store.TxRecord{Value: oneEther, BlockTimestamp: 1700000042},
+			expectedRequests: 1,
+		},
+		"no timestamp": {
+			status: http.StatusOK,
+			body:   `{"usd": 2500}`,
+			record: &store.TxRecord{Value: oneEther},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var requests atomic.Int32
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				assert.Equal(t, "1700000040", r.URL.Query().Get("at"))
+				w.WriteHeader(test.status)
+				_, _ = w.Write([]byte(test.body))
+			}))
+			defer srv.Close()
+
+			oracle := price.New(logrus.New(), srv.Client(), srv.URL+"?at={timestamp}", test.opts...)
+			keep, err := oracle.HookTx(context.Background(), test.record)
+			require.NoError(t, err)
+			assert.True(t, keep)
+			assert.Equal(t, test.expectedValueUSD, test.record.ValueUSD)
+			assert.Equal(t, test.expectedRequests, requests.Load())
+		})
+	}
+}
+
+func TestPriceAtCache(t *testing.T) {
+	var requests atomic.Int32
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+		_, _ = w.Write([]byte(`{"usd": 3000}`))
+	}))
+	defer srv.Close()
+
+	oracle := price.New(logrus.New(), srv.Client(), srv.URL+"/{timestamp}",
+		price.WithResolution(time.Hour), price.WithCacheSize(1))
+	ctx := context.Background()
+
+	for ts := range slices.Values([]int64{7200, 7201, 10799}) {
+		p, err := oracle.PriceAt(ctx, ts)
+		require.NoError(t, err)
+		assert.Equal(t, "3000", p.RatString())
+	}
+	assert.Equal(t, int32(1), requests.Load())
+
+	// the next hour evicts the only cached price
+	_, err := oracle.PriceAt(ctx, 10800)
+	require.NoError(t, err)
+	_, err = oracle.PriceAt(ctx, 7200)
+	require.NoError(t, err)
+	assert.Equal(t, int32(3), requests.Load())
+}
@@ -0,0 +1,14 @@
+package pushgateway
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+var (
+	pushes = custompromauto.Auto().NewCounterVec(prometheus.CounterOpts{
+		Name: "ethtxparser_metrics_pushes_total",
+		Help: "Total number of pushes of the metrics to the Pushgateway by result: success or failure",
+	}, []string{"result"})
+)
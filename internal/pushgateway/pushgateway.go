@@ -0,0 +1,209 @@
+// Package pushgateway pushes the metrics to a Prometheus Pushgateway, for the short-lived runs such as backfills,
+// which end before they're scraped, and the environments the metrics can't be scraped in.
+package pushgateway
+
+import (
+	"bytes"
+	"cmp"
+	"context"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/common/expfmt"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+const (
+	DefaultInterval = time.Second * 15
+	DefaultJob      = "ethtxparser"
+
+	// finalPushTimeout bounds the last push, made once the pusher is stopped.
+	finalPushTimeout = time.Second * 5
+)
+
+// labelNamePattern is the pattern of valid Prometheus label names.
+var labelNamePattern = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+
+// ParseLabels parses comma separated name=value grouping labels, e.g. "instance=backfill-1,env=staging".
+func ParseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for pair := range strings.SplitSeq(s, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		name, value, ok := strings.Cut(pair, "=")
+		name = strings.TrimSpace(name)
+		if !ok || !labelNamePattern.MatchString(name) {
+			return nil, fmt.Errorf("invalid label %q, expected name=value", pair)
+		}
+		if name == "job" {
+			return nil, errors.New("the job label is set by the job name")
+		}
+		labels[name] = strings.TrimSpace(value)
+	}
+	return labels, nil
+}
+
+type config struct {
+	labels   map[string]string
+	interval time.Duration
+	gatherer prometheus.Gatherer
+}
+
+type Option func(*config)
+
+// WithLabels sets the grouping labels of the pushed metrics, along with the job, e.g. the instance.
+func WithLabels(labels map[string]string) Option {
+	return func(c *config) {
+		c.labels = labels
+	}
+}
+
+// WithInterval sets the interval the metrics are pushed at.
+func WithInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.interval = d
+	}
+}
+
+// WithGatherer sets the gatherer of the pushed metrics, the custom registry by default.
+func WithGatherer(g prometheus.Gatherer) Option {
+	return func(c *config) {
+		c.gatherer = g
+	}
+}
+
+// Pusher pushes the metrics to the group of its job and labels on a Pushgateway, replacing the ones pushed before.
+type Pusher struct {
+	logger     *logrus.Logger
+	httpClient *http.Client
+	url        string
+	cfg        *config
+}
+
+// New returns a pusher of the metrics to the Pushgateway at gatewayURL, e.g. http://pushgateway:9091, grouped under
+// the given job.
+func New(logger *logrus.Logger, httpClient *http.Client, gatewayURL, job string, opts ...Option) (*Pusher, error) {
+	cfg := &config{
+		interval: DefaultInterval,
+		gatherer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	u, err := url.Parse(gatewayURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("invalid Pushgateway URL %q, expected http(s)://<host>[:<port>]", gatewayURL)
+	}
+	if job == "" {
+		return nil, errors.New("job is required")
+	}
+
+	return &Pusher{
+		logger:     logger,
+		httpClient: httpClient,
+		url:        groupingURL(strings.TrimSuffix(gatewayURL, "/"), job, cfg.labels),
+		cfg:        cfg,
+	}, nil
+}
+
+// groupingURL returns the URL of the group of the job and labels, the labels sorted by name.
+func groupingURL(gatewayURL, job string, labels map[string]string) string {
+	var b strings.Builder
+	b.WriteString(gatewayURL + "/metrics/job/" + url.PathEscape(job))
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for name := range slices.Values(names) {
+		value := labels[name]
+		if value == "" || strings.Contains(value, "/") {
+			// values that can't be path segments are base64 encoded, the empty one as a single padding character
+			b.WriteString("/" + name + "@base64/" + cmp.Or(base64.RawURLEncoding.EncodeToString([]byte(value)), "="))
+			continue
+		}
+		b.WriteString("/" + name + "/" + url.PathEscape(value))
+	}
+	return b.String()
+}
+
+// Push pushes the metrics once, replacing the ones of the group.
+func (p *Pusher) Push(ctx context.Context) error {
+	err := p.push(ctx)
+	if err != nil {
+		pushes.WithLabelValues("failure").Inc()
+		return err
+	}
+	pushes.WithLabelValues("success").Inc()
+	return nil
+}
+
+func (p *Pusher) push(ctx context.Context) error {
+	families, err := p.cfg.gatherer.Gather()
+	if err != nil {
+		return fmt.Errorf("could not gather metrics: %w", err)
+	}
+	format := expfmt.NewFormat(expfmt.TypeTextPlain)
+	var body bytes.Buffer
+	enc := expfmt.NewEncoder(&body, format)
+	for family := range slices.Values(families) {
+		err = enc.Encode(family)
+		if err != nil {
+			return fmt.Errorf("could not encode metric family %q: %w", family.GetName(), err)
+		}
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, p.url, &body)
+	if err != nil {
+		return fmt.Errorf("could not create request: %w", err)
+	}
+	req.Header.Set("Content-Type", string(format))
+	resp, err := p.httpClient.Do(req)
+	if err != nil {
+		return fmt.Errorf("could not push metrics: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("unexpected status code %d pushing metrics: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// Start pushes the metrics every interval until the context is done, then pushes them a last time so the final
+// values of a run ending before the next interval aren't lost.
+func (p *Pusher) Start(ctx context.Context) {
+	t := time.NewTicker(p.cfg.interval)
+	defer t.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			finalCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), finalPushTimeout)
+			defer cancel()
+			err := p.Push(finalCtx)
+			if err != nil {
+				p.logger.WithError(err).Warn("Could not push the final metrics to the Pushgateway")
+			}
+			return
+		case <-t.C:
+			err := p.Push(ctx)
+			if err != nil && ctx.Err() == nil {
+				p.logger.WithError(err).Warn("Could not push metrics to the Pushgateway")
+			}
+		}
+	}
+}
@@ -0,0 +1,170 @@
+package pushgateway_test
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/pushgateway"
+)
+
+func TestParseLabels(t *testing.T) {
+	tests := map[string]struct {
+		labels         string
+		expectedLabels map[string]string
+		expectedErr    bool
+	}{
+		"empty": {
+			expectedLabels: map[string]string{},
+		},
+		"labels": {
+			labels:         "instance=backfill-1, env = staging,",
+			expectedLabels: map[string]string{"instance": "backfill-1", "env": "staging"},
+		},
+		"missing value": {
+			labels:      "instance",
+			expectedErr: true,
+		},
+		"invalid name": {
+			labels:      "1instance=backfill-1",
+			expectedErr: true,
+		},
+		"job label": {
+			labels:      "job=backfill",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			labels, err := pushgateway.ParseLabels(test.labels)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedLabels, labels)
+		})
+	}
+}
+
+func TestPusher(t *testing.T) {
+	tests := map[string]struct {
+		job          string
+		labels       map[string]string
+		status       int
+		expectedPath string
+		expectedErr  bool
+	}{
+		"job only": {
+			job:          "ethtxparser",
+			status:       http.StatusOK,
+			expectedPath: "/metrics/job/ethtxparser",
+		},
+		"sorted labels with encoded values": {
+			job:          "ethtxparser",
+			labels:       map[string]string{"instance": "backfill-1", "path": "a/b", "empty": ""},
+			status:       http.StatusAccepted,
+			expectedPath: "/metrics/job/ethtxparser/empty@base64/=/instance/backfill-1/path@base64/YS9i",
+		},
+		"rejected": {
+			job:          "ethtxparser",
+			status:       http.StatusBadRequest,
+			expectedPath: "/metrics/job/ethtxparser",
+			expectedErr:  true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			counter := prometheus.NewCounter(prometheus.CounterOpts{Name: "test_blocks_total", Help: "Test counter"})
+			registry.MustRegister(counter)
+			counter.Add(3)
+
+			var path, method, body string
+			srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				path, method = r.URL.EscapedPath(), r.Method
+				b, _ := io.ReadAll(r.Body)
+				body = string(b)
+				w.WriteHeader(test.status)
+			}))
+			defer srv.Close()
+
+			pusher, err := pushgateway.New(logrus.New(), srv.Client(), srv.URL+"/", test.job,
+				pushgateway.WithLabels(test.labels),
+				pushgateway.WithGatherer(registry),
+			)
+			require.NoError(t, err)
+			err = pusher.Push(context.Background())
+			assert.Equal(t, test.expectedPath, path)
+			assert.Equal(t, http.MethodPut, method)
+			assert.Contains(t, body, "test_blocks_total 3")
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestPusherStart(t *testing.T) {
+	pushed := make(chan struct{}, 10)
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pushed <- struct{}{}
+	}))
+	defer srv.Close()
+
+	pusher, err := pushgateway.New(logrus.New(), srv.Client(), srv.URL, pushgateway.DefaultJob,
+		pushgateway.WithInterval(time.Hour),
+		pushgateway.WithGatherer(prometheus.NewRegistry()),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	// the final push is made even though the run ended before the first interval
+	pusher.Start(ctx)
+	assert.Len(t, pushed, 1)
+}
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		gatewayURL  string
+		job         string
+		expectedErr bool
+	}{
+		"valid": {
+			gatewayURL: "http://pushgateway:9091",
+			job:        pushgateway.DefaultJob,
+		},
+		"missing scheme": {
+			gatewayURL:  "pushgateway:9091",
+			job:         pushgateway.DefaultJob,
+			expectedErr: true,
+		},
+		"missing job": {
+			gatewayURL:  "http://pushgateway:9091",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := pushgateway.New(logrus.New(), http.DefaultClient, test.gatewayURL, test.job)
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
@@ -1,17 +1,33 @@
 package ringbuffer
 
+import (
+	"iter"
+	"slices"
+)
+
 type RingBuffer[T any] struct {
 	buf  []T
 	head int
 	tail int
 	size int
+	// maxCapacity is the capacity the buffer grows up to, the one of its items for a buffer that doesn't grow.
+	maxCapacity int
 }
 
 // New creates a RingBuffer with the given capacity.
 // A default capacity of 1 is used of the given value is zero.
 func New[T any](capacity uint) *RingBuffer[T] {
+	return NewGrowable[T](capacity, capacity)
+}
+
+// NewGrowable creates a RingBuffer with the given initial capacity, doubling it up to maxCapacity whenever an item is
+// pushed while it's full, so the memory of a large buffer is only allocated once it's needed.
+// A default capacity of 1 is used if the given value is zero, and maxCapacity is at least the initial capacity.
+func NewGrowable[T any](capacity, maxCapacity uint) *RingBuffer[T] {
+	capacity = max(1, capacity)
 	return &RingBuffer[T]{
-		buf: make([]T, max(1, capacity)),
+		buf:         make([]T, capacity),
+		maxCapacity: int(max(capacity, maxCapacity)),
 	}
 }
 
@@ -20,16 +36,25 @@ func (r *RingBuffer[T]) Size() int {
 	return r.size
 }
 
-// IsFull returns true if the queue is full.
+// Cap returns the number of items the buffer can hold before it has to grow, see NewGrowable.
+func (r *RingBuffer[T]) Cap() int {
+	return cap(r.buf)
+}
+
+// IsFull returns true if the queue is full and can't grow any further.
 func (r *RingBuffer[T]) IsFull() bool {
-	return r.size == cap(r.buf)
+	return r.size == r.maxCapacity
 }
 
-// Push adds the provided item to the buffer. It returns false if the queue is full and a push cannot be done.
+// Push adds the provided item to the buffer, growing it if needed. It returns false if the queue is full and a push
+// cannot be done.
 func (r *RingBuffer[T]) Push(item T) bool {
-	if r.size == cap(r.buf) {
+	if r.size == r.maxCapacity {
 		return false
 	}
+	if r.size == cap(r.buf) {
+		r.realloc(min(2*cap(r.buf), r.maxCapacity))
+	}
 
 	r.buf[r.tail] = item
 	r.tail = (r.tail + 1) % cap(r.buf)
@@ -51,6 +76,15 @@ func (r *RingBuffer[T]) Pop() (T, bool) {
 	return item, true
 }
 
+// Front returns the oldest item without removing it. If empty, returns (nil, false).
+func (r *RingBuffer[T]) Front() (T, bool) {
+	var zero T
+	if r.size == 0 {
+		return zero, false
+	}
+	return r.buf[r.head], true
+}
+
 // Back returns the newest block without removing it. If empty, returns (nil, false).
 func (r *RingBuffer[T]) Back() (T, bool) {
 	var zero T
@@ -73,3 +107,56 @@ func (r *RingBuffer[T]) DropBack() {
 	r.buf[r.tail] = zero
 	r.size--
 }
+
+// All returns an iterator over the items of the buffer, oldest first.
+func (r *RingBuffer[T]) All() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := range r.size {
+			if !yield(r.buf[(r.head+i)%cap(r.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// Backward returns an iterator over the items of the buffer, newest first.
+func (r *RingBuffer[T]) Backward() iter.Seq[T] {
+	return func(yield func(T) bool) {
+		for i := r.size - 1; i >= 0; i-- {
+			if !yield(r.buf[(r.head+i)%cap(r.buf)]) {
+				return
+			}
+		}
+	}
+}
+
+// Items returns a copy of the items of the buffer, oldest first, which stays the same as the buffer is changed.
+func (r *RingBuffer[T]) Items() []T {
+	return slices.AppendSeq(make([]T, 0, r.size), r.All())
+}
+
+// Resize changes the capacity of the buffer, discarding the oldest items that no longer fit. A growable buffer that
+// hasn't grown up to its max capacity yet keeps growing up to the new one instead.
+// A capacity of 1 is used if the given value is zero.
+func (r *RingBuffer[T]) Resize(capacity uint) {
+	maxCapacity := int(max(1, capacity))
+	if cap(r.buf) < r.maxCapacity {
+		r.realloc(min(cap(r.buf), maxCapacity))
+	} else {
+		r.realloc(maxCapacity)
+	}
+	r.maxCapacity = maxCapacity
+}
+
+// realloc moves the items to a new buffer of the given capacity, discarding the oldest items that don't fit.
+func (r *RingBuffer[T]) realloc(capacity int) {
+	buf := make([]T, capacity)
+	size := min(r.size, len(buf))
+	for i := range size {
+		buf[i] = r.buf[(r.head+r.size-size+i)%cap(r.buf)]
+	}
+	r.buf = buf
+	r.head = 0
+	r.tail = size % len(buf)
+	r.size = size
+}
@@ -0,0 +1,183 @@
+package ringbuffer_test
+
+import (
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedisam/ethtxparser/internal/ringbuffer"
+)
+
+func TestRingBufferItems(t *testing.T) {
+	tests := map[string]struct {
+		capacity      uint
+		pushes        []int
+		pops          int
+		expectedItems []int
+	}{
+		"empty": {
+			capacity:      3,
+			expectedItems: []int{},
+		},
+		"partially filled": {
+			capacity:      3,
+			pushes:        []int{1, 2},
+			expectedItems: []int{1, 2},
+		},
+		"wrapped around": {
+			capacity:      3,
+			pushes:        []int{1, 2, 3, 4, 5},
+			expectedItems: []int{3, 4, 5},
+		},
+		"popped": {
+			capacity:      3,
+			pushes:        []int{1, 2, 3},
+			pops:          2,
+			expectedItems: []int{3},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rb := ringbuffer.New[int](test.capacity)
+			for item := range slices.Values(test.pushes) {
+				// the oldest item is evicted once full
+				if !rb.Push(item) {
+					_, _ = rb.Pop()
+					_ = rb.Push(item)
+				}
+			}
+			for range test.pops {
+				_, _ = rb.Pop()
+			}
+
+			assert.Equal(t, test.expectedItems, rb.Items())
+			assert.Equal(t, test.expectedItems, slices.AppendSeq([]int{}, rb.All()))
+			backward := slices.AppendSeq([]int{}, rb.Backward())
+			slices.Reverse(backward)
+			assert.Equal(t, test.expectedItems, backward)
+		})
+	}
+}
+
+func TestRingBufferItemsCopy(t *testing.T) {
+	rb := ringbuffer.New[int](2)
+	_ = rb.Push(1)
+	_ = rb.Push(2)
+
+	items := rb.Items()
+	_, _ = rb.Pop()
+	_ = rb.Push(3)
+	items[0] = 10
+
+	assert.Equal(t, []int{10, 2}, items)
+	assert.Equal(t, []int{2, 3}, rb.Items())
+}
+
+func TestRingBufferGrowable(t *testing.T) {
+	tests := map[string]struct {
+		capacity         uint
+		maxCapacity      uint
+		pushes           int
+		pops             int
+		morePushes       int
+		expectedCap      int
+		expectedFull     bool
+		expectedRejected int
+		expectedItems    []int
+	}{
+		"not grown": {
+			capacity:      2,
+			maxCapacity:   8,
+			pushes:        2,
+			expectedCap:   2,
+			expectedItems: []int{0, 1},
+		},
+		"doubled": {
+			capacity:      2,
+			maxCapacity:   8,
+			pushes:        3,
+			expectedCap:   4,
+			expectedItems: []int{0, 1, 2},
+		},
+		"grown up to max capacity": {
+			capacity:         3,
+			maxCapacity:      8,
+			pushes:           10,
+			expectedCap:      8,
+			expectedFull:     true,
+			expectedRejected: 2,
+			expectedItems:    []int{0, 1, 2, 3, 4, 5, 6, 7},
+		},
+		"grown while wrapped around": {
+			capacity:      4,
+			maxCapacity:   8,
+			pushes:        4,
+			pops:          3,
+			morePushes:    4,
+			expectedCap:   8,
+			expectedItems: []int{3, 4, 5, 6, 7},
+		},
+		"max capacity below capacity": {
+			capacity:         2,
+			maxCapacity:      1,
+			pushes:           3,
+			expectedCap:      2,
+			expectedFull:     true,
+			expectedRejected: 1,
+			expectedItems:    []int{0, 1},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rb := ringbuffer.NewGrowable[int](test.capacity, test.maxCapacity)
+			var next, rejected int
+			push := func() {
+				if !rb.Push(next) {
+					rejected++
+				}
+				next++
+			}
+			for range test.pushes {
+				push()
+			}
+			for range test.pops {
+				_, _ = rb.Pop()
+			}
+			// pushed after the pops, wrapping the buffer around before it grows
+			for range test.morePushes {
+				push()
+			}
+
+			assert.Equal(t, test.expectedCap, rb.Cap())
+			assert.Equal(t, test.expectedFull, rb.IsFull())
+			assert.Equal(t, test.expectedRejected, rejected)
+			assert.Equal(t, test.expectedItems, rb.Items())
+		})
+	}
+}
+
+func TestRingBufferResizeGrowable(t *testing.T) {
+	rb := ringbuffer.NewGrowable[int](2, 8)
+	for item := range 3 {
+		_ = rb.Push(item)
+	}
+
+	// still growing, the buffer keeps its capacity and grows up to the new max one
+	rb.Resize(16)
+	assert.Equal(t, 4, rb.Cap())
+	assert.Equal(t, []int{0, 1, 2}, rb.Items())
+
+	// below the items held, the oldest ones are discarded
+	rb.Resize(2)
+	assert.Equal(t, 2, rb.Cap())
+	assert.True(t, rb.IsFull())
+	assert.Equal(t, []int{1, 2}, rb.Items())
+
+	// once grown up to its max capacity, the buffer is resized to the new one
+	rb.Resize(5)
+	assert.Equal(t, 5, rb.Cap())
+	assert.Equal(t, []int{1, 2}, rb.Items())
+}
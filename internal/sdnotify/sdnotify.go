@@ -0,0 +1,68 @@
+// Package sdnotify implements the notify protocol of systemd, letting the process report its state to the service
+// manager when run as a Type=notify unit, and ping its watchdog when WatchdogSec is set.
+package sdnotify
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"strconv"
+	"time"
+)
+
+const (
+	// Ready reports the service has finished starting up.
+	Ready = "READY=1"
+	// Stopping reports the service is shutting down.
+	Stopping = "STOPPING=1"
+	// Watchdog pings the watchdog, which restarts the service if it isn't pinged within its timeout.
+	Watchdog = "WATCHDOG=1"
+)
+
+// Enabled reports whether the process is run by systemd as a Type=notify unit, i.e. NOTIFY_SOCKET is set.
+func Enabled() bool {
+	return os.Getenv("NOTIFY_SOCKET") != ""
+}
+
+// Notify sends the state to the socket of NOTIFY_SOCKET. It's a no-op if the variable isn't set.
+func Notify(state string) error {
+	socket := os.Getenv("NOTIFY_SOCKET")
+	if socket == "" {
+		return nil
+	}
+	if socket[0] == '@' {
+		// abstract socket
+		socket = "\x00" + socket[1:]
+	}
+
+	conn, err := net.DialUnix("unixgram", nil, &net.UnixAddr{Name: socket, Net: "unixgram"})
+	if err != nil {
+		return fmt.Errorf("could not connect to notify socket: %w", err)
+	}
+	defer conn.Close()
+
+	_, err = conn.Write([]byte(state))
+	if err != nil {
+		return fmt.Errorf("could not send %q: %w", state, err)
+	}
+	return nil
+}
+
+// WatchdogTimeout returns the timeout of the watchdog set with WatchdogSec, as given by WATCHDOG_USEC, within which it
+// must be pinged. Zero if the watchdog isn't enabled for this process.
+func WatchdogTimeout() (time.Duration, error) {
+	usec := os.Getenv("WATCHDOG_USEC")
+	if usec == "" {
+		return 0, nil
+	}
+	// the watchdog applies to the main process of the unit only
+	if pid := os.Getenv("WATCHDOG_PID"); pid != "" && pid != strconv.Itoa(os.Getpid()) {
+		return 0, nil
+	}
+
+	n, err := strconv.ParseInt(usec, 10, 64)
+	if err != nil || n <= 0 {
+		return 0, fmt.Errorf("invalid WATCHDOG_USEC %q", usec)
+	}
+	return time.Duration(n) * time.Microsecond, nil
+}
@@ -0,0 +1,77 @@
+package sdnotify_test
+
+import (
+	"net"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/sdnotify"
+)
+
+func TestNotify(t *testing.T) {
+	t.Setenv("NOTIFY_SOCKET", "")
+	assert.False(t, sdnotify.Enabled())
+	require.NoError(t, sdnotify.Notify(sdnotify.Ready))
+
+	socket := filepath.Join(t.TempDir(), "notify.sock")
+	conn, err := net.ListenUnixgram("unixgram", &net.UnixAddr{Name: socket, Net: "unixgram"})
+	require.NoError(t, err)
+	defer conn.Close()
+	t.Setenv("NOTIFY_SOCKET", socket)
+	assert.True(t, sdnotify.Enabled())
+
+	require.NoError(t, sdnotify.Notify(sdnotify.Ready))
+	buf := make([]byte, 64)
+	require.NoError(t, conn.SetReadDeadline(time.Now().Add(time.Second)))
+	n, err := conn.Read(buf)
+	require.NoError(t, err)
+	assert.Equal(t, sdnotify.Ready, string(buf[:n]))
+}
+
+func TestWatchdogTimeout(t *testing.T) {
+	tests := map[string]struct {
+		usec            string
+		pid             string
+		expectedTimeout time.Duration
+		expectedErr     bool
+	}{
+		"disabled": {},
+		"enabled": {
+			usec:            "30000000",
+			expectedTimeout: time.Second * 30,
+		},
+		"enabled for this process": {
+			usec:            "30000000",
+			pid:             strconv.Itoa(os.Getpid()),
+			expectedTimeout: time.Second * 30,
+		},
+		"enabled for another process": {
+			usec: "30000000",
+			pid:  "1",
+		},
+		"invalid": {
+			usec:        "soon",
+			expectedErr: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			t.Setenv("WATCHDOG_USEC", test.usec)
+			t.Setenv("WATCHDOG_PID", test.pid)
+			timeout, err := sdnotify.WatchdogTimeout()
+			if test.expectedErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedTimeout, timeout)
+		})
+	}
+}
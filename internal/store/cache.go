@@ -0,0 +1,99 @@
+package store
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+
+	"github.com/hedisam/pipeline/chans"
+)
+
+// maxCachedSubscriptions is the number of addresses whose subscription is cached, the cache being cleared past it.
+const maxCachedSubscriptions = 100_000
+
+// SubscriptionGetter looks up the subscription of an address.
+type SubscriptionGetter interface {
+	GetSubscription(ctx context.Context, addr string) (*Subscription, error)
+}
+
+// SubscriptionWatcher watches the changes made to the subscriptions.
+type SubscriptionWatcher interface {
+	WatchSubscriptions(ctx context.Context) <-chan []*SubscriptionChange
+}
+
+// SubscriptionCache caches the subscriptions looked up, e.g. by the notifiers for the sender and recipient of every
+// transaction they notify, refreshing them as soon as they change rather than looking them up every time. Addresses
+// that aren't subscribed are cached too. Nothing is cached until the changes are watched with Watch, as none would be
+// refreshed.
+type SubscriptionCache struct {
+	getter SubscriptionGetter
+	mu     sync.RWMutex
+	// subs holds the subscriptions of the looked up addresses, nil for the ones that aren't subscribed.
+	subs     map[string]*Subscription
+	watching bool
+	// generation is incremented on every batch of changes, so a lookup made before one isn't cached after it.
+	generation uint64
+}
+
+func NewSubscriptionCache(getter SubscriptionGetter) *SubscriptionCache {
+	return &SubscriptionCache{
+		getter: getter,
+		subs:   make(map[string]*Subscription),
+	}
+}
+
+// Watch caches the subscriptions and keeps them up to date with the changes made to them until the given context is
+// done, blocking until then.
+func (c *SubscriptionCache) Watch(ctx context.Context, watcher SubscriptionWatcher) {
+	changes := watcher.WatchSubscriptions(ctx)
+	c.mu.Lock()
+	c.watching = true
+	c.mu.Unlock()
+	defer func() {
+		c.mu.Lock()
+		defer c.mu.Unlock()
+		c.watching = false
+		clear(c.subs)
+	}()
+
+	for batch := range chans.ReceiveOrDoneSeq(ctx, changes) {
+		c.mu.Lock()
+		for change := range slices.Values(batch) {
+			c.subs[change.Address] = change.Subscription
+		}
+		c.generation++
+		c.mu.Unlock()
+	}
+}
+
+// GetSubscription returns the subscription of the given address, or ErrNotFound if not subscribed, looking it up only
+// if it isn't cached.
+func (c *SubscriptionCache) GetSubscription(ctx context.Context, addr string) (*Subscription, error) {
+	c.mu.RLock()
+	sub, cached := c.subs[addr]
+	watching, generation := c.watching, c.generation
+	c.mu.RUnlock()
+	if cached {
+		if sub == nil {
+			return nil, ErrNotFound
+		}
+		return sub, nil
+	}
+
+	sub, err := c.getter.GetSubscription(ctx, addr)
+	if err != nil && !errors.Is(err, ErrNotFound) {
+		return nil, err
+	}
+	if watching {
+		c.mu.Lock()
+		if c.watching && c.generation == generation {
+			if len(c.subs) >= maxCachedSubscriptions {
+				clear(c.subs)
+			}
+			c.subs[addr] = sub
+		}
+		c.mu.Unlock()
+	}
+	return sub, err
+}
@@ -0,0 +1,104 @@
+package store_test
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+// countingGetter counts the lookups made to the underlying store.
+type countingGetter struct {
+	store.SubscriptionGetter
+	lookups atomic.Int64
+	err     error
+}
+
+func (g *countingGetter) GetSubscription(ctx context.Context, addr string) (*store.Subscription, error) {
+	g.lookups.Add(1)
+	if g.err != nil {
+		return nil, g.err
+	}
+	return g.SubscriptionGetter.GetSubscription(ctx, addr)
+}
+
+func TestSubscriptionCache(t *testing.T) {
+	const (
+		subscribed    = "0x00000000000000000000000000000000000000aa"
+		notSubscribed = "0x00000000000000000000000000000000000000bb"
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	subsStore := memdb.NewSubscriptionStore()
+	require.NoError(t, subsStore.AddSubscription(ctx, &store.Subscription{Address: subscribed}))
+	getter := &countingGetter{SubscriptionGetter: subsStore}
+	cache := store.NewSubscriptionCache(getter)
+
+	// nothing is cached until watching
+	_, err := cache.GetSubscription(ctx, subscribed)
+	require.NoError(t, err)
+	_, err = cache.GetSubscription(ctx, subscribed)
+	require.NoError(t, err)
+	assert.Equal(t, int64(2), getter.lookups.Load())
+
+	watched := make(chan struct{})
+	go func() {
+		defer close(watched)
+		cache.Watch(ctx, subsStore)
+	}()
+	require.Eventually(t, func() bool {
+		_, _ = cache.GetSubscription(ctx, subscribed)
+		lookups := getter.lookups.Load()
+		_, _ = cache.GetSubscription(ctx, subscribed)
+		return getter.lookups.Load() == lookups
+	}, time.Second, time.Millisecond)
+
+	// addresses that aren't subscribed are cached too
+	_, err = cache.GetSubscription(ctx, notSubscribed)
+	require.ErrorIs(t, err, store.ErrNotFound)
+	lookups := getter.lookups.Load()
+	_, err = cache.GetSubscription(ctx, notSubscribed)
+	require.ErrorIs(t, err, store.ErrNotFound)
+	assert.Equal(t, lookups, getter.lookups.Load())
+
+	// the cached subscriptions are refreshed as they change, without looking them up
+	replaced := &store.Subscription{Address: subscribed, WebhookURL: "https://example.com/hook"}
+	require.NoError(t, subsStore.AddSubscription(ctx, replaced))
+	require.NoError(t, subsStore.AddSubscription(ctx, &store.Subscription{Address: notSubscribed}))
+	require.Eventually(t, func() bool {
+		sub, err := cache.GetSubscription(ctx, subscribed)
+		return err == nil && sub == replaced
+	}, time.Second, time.Millisecond)
+	require.Eventually(t, func() bool {
+		_, err := cache.GetSubscription(ctx, notSubscribed)
+		return err == nil
+	}, time.Second, time.Millisecond)
+	require.NoError(t, subsStore.RemoveSubscription(ctx, subscribed))
+	require.Eventually(t, func() bool {
+		_, err := cache.GetSubscription(ctx, subscribed)
+		return errors.Is(err, store.ErrNotFound)
+	}, time.Second, time.Millisecond)
+	assert.Equal(t, lookups, getter.lookups.Load())
+
+	// failed lookups aren't cached
+	getter.err = errors.New("dummy error")
+	_, err = cache.GetSubscription(ctx, "0x00000000000000000000000000000000000000cc")
+	require.ErrorContains(t, err, "dummy error")
+
+	// nor anything once done watching
+	cancel()
+	<-watched
+	getter.err = nil
+	lookups = getter.lookups.Load()
+	_, err = cache.GetSubscription(ctx, notSubscribed)
+	require.NoError(t, err)
+	assert.Equal(t, lookups+1, getter.lookups.Load())
+}
@@ -0,0 +1,66 @@
+package store
+
+import (
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/expr"
+)
+
+// FilterVars are the transaction fields subscription filter expressions can refer to. Status, method and type are
+// empty if unknown, method being the name of the called contract function if its ABI is known and type one of the
+// TxTypes.
+var FilterVars = expr.Vars{
+	"hash":        expr.String,
+	"from":        expr.String,
+	"to":          expr.String,
+	"value":       expr.Number,
+	"blockNumber": expr.Number,
+	"status":      expr.String,
+	"method":      expr.String,
+	"type":        expr.String,
+}
+
+// compiledFilters caches the compiled subscription filters by source, as subscriptions are matched against every
+// transaction of their addresses.
+var compiledFilters sync.Map
+
+// CompileFilter compiles the given subscription filter expression.
+func CompileFilter(src string) (*expr.Expr, error) {
+	if compiled, ok := compiledFilters.Load(src); ok {
+		return compiled.(*expr.Expr), nil
+	}
+
+	compiled, err := expr.Compile(src, FilterVars)
+	if err != nil {
+		return nil, err
+	}
+	compiledFilters.Store(src, compiled)
+	return compiled, nil
+}
+
+// filterLookup returns the values of the FilterVars of the given transaction.
+func filterLookup(tx *TxRecord) expr.Lookup {
+	return func(name string) any {
+		switch name {
+		case "hash":
+			return tx.Hash
+		case "from":
+			return tx.From
+		case "to":
+			return tx.To
+		case "value":
+			return tx.Value
+		case "blockNumber":
+			return tx.BlockNumber
+		case "status":
+			return string(tx.Status)
+		case "type":
+			return string(tx.Type)
+		case "method":
+			if tx.DecodedInput != nil {
+				return tx.DecodedInput.Method
+			}
+		}
+		return nil
+	}
+}
@@ -1,12 +1,33 @@
 package memdb
 
+import (
+	"slices"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
 const (
 	// DefaultMemSize the default map size used for storing data.
 	DefaultMemSize = 100
 )
 
 type config struct {
-	memSize int
+	memSize    int
+	maxRecords int
+	registerer prometheus.Registerer
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		memSize:    DefaultMemSize,
+		registerer: custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	return cfg
 }
 
 type Option func(*config)
@@ -19,3 +40,20 @@ func WithMemSize(memSize int) Option {
 		}
 	}
 }
+
+// WithMaxRecords caps the number of records the TxStore holds, a record shared by two addresses counting twice. The
+// records of the oldest blocks are evicted once it's exceeded. Unlimited if zero.
+func WithMaxRecords(maxRecords int) Option {
+	return func(c *config) {
+		if maxRecords >= 0 {
+			c.maxRecords = maxRecords
+		}
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the TxStore are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
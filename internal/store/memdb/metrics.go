@@ -0,0 +1,22 @@
+package memdb
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of the stores, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	evictedRecords prometheus.Counter
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		evictedRecords: auto.NewCounter(prometheus.CounterOpts{
+			Name: "ethtxparser_store_evicted_records_total",
+			Help: "Number of records of the oldest blocks evicted from the in-memory store to stay within its maximum number of records",
+		}),
+	}
+}
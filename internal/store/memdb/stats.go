@@ -0,0 +1,123 @@
+package memdb
+
+import (
+	"cmp"
+	"context"
+	"maps"
+	"math/big"
+	"slices"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	secondsPerHour = int64(time.Hour / time.Second)
+	secondsPerDay  = 24 * secondsPerHour
+	// rollingWindowHours is the size of the rolling window of the address stats.
+	rollingWindowHours = 24
+)
+
+// addressStats aggregates the recorded transactions of an address by UTC day and by hour, only the hours of the
+// rolling window being kept.
+type addressStats struct {
+	days  map[int64]*store.TxCounters
+	hours map[int64]*store.TxCounters
+}
+
+// aggregate adds the given transaction of addr to its stats, or subtracts it if sign is negative. Transactions of
+// unknown block time aren't aggregated. Must be called with the lock held.
+func (s *TxStore) aggregate(addr string, tx *store.TxRecord, sign int) {
+	if tx.BlockTimestamp <= 0 {
+		return
+	}
+
+	stats, ok := s.addrToStats[addr]
+	if !ok {
+		stats = &addressStats{
+			days:  make(map[int64]*store.TxCounters),
+			hours: make(map[int64]*store.TxCounters),
+		}
+		s.addrToStats[addr] = stats
+	}
+
+	hour := tx.BlockTimestamp / secondsPerHour
+	s.latestHour = max(s.latestHour, hour)
+	addCounters(stats.days, tx.BlockTimestamp/secondsPerDay, addr, tx, sign)
+	if hour > s.latestHour-rollingWindowHours {
+		addCounters(stats.hours, hour, addr, tx, sign)
+	}
+	maps.DeleteFunc(stats.hours, func(h int64, _ *store.TxCounters) bool {
+		return h <= s.latestHour-rollingWindowHours
+	})
+}
+
+func addCounters(buckets map[int64]*store.TxCounters, bucket int64, addr string, tx *store.TxRecord, sign int) {
+	counters, ok := buckets[bucket]
+	if !ok {
+		counters = &store.TxCounters{ValueIn: new(big.Int), ValueOut: new(big.Int)}
+		buckets[bucket] = counters
+	}
+
+	counters.TxCount += int64(sign)
+	if tx.Value == nil {
+		return
+	}
+	value := tx.Value
+	if sign < 0 {
+		value = new(big.Int).Neg(value)
+	}
+	if tx.To == addr {
+		counters.ValueIn.Add(counters.ValueIn, value)
+	}
+	if tx.From == addr {
+		counters.ValueOut.Add(counters.ValueOut, value)
+	}
+}
+
+// GetAddressStats returns the aggregated transaction counters of the given addr.
+func (s *TxStore) GetAddressStats(_ context.Context, addr string) (*store.AddressStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := &store.AddressStats{
+		Daily:   []*store.DailyTxCounters{},
+		Rolling: store.TxCounters{ValueIn: new(big.Int), ValueOut: new(big.Int)},
+	}
+	stats, ok := s.addrToStats[addr]
+	if !ok {
+		return result, nil
+	}
+
+	for day, counters := range stats.days {
+		if counters.TxCount == 0 {
+			continue
+		}
+		result.Daily = append(result.Daily, &store.DailyTxCounters{
+			Day:        time.Unix(day*secondsPerDay, 0).UTC(),
+			TxCounters: copyCounters(counters),
+		})
+	}
+	slices.SortFunc(result.Daily, func(a, b *store.DailyTxCounters) int {
+		return cmp.Compare(a.Day.Unix(), b.Day.Unix())
+	})
+
+	for hour, counters := range stats.hours {
+		if hour <= s.latestHour-rollingWindowHours {
+			continue
+		}
+		result.Rolling.TxCount += counters.TxCount
+		result.Rolling.ValueIn.Add(result.Rolling.ValueIn, counters.ValueIn)
+		result.Rolling.ValueOut.Add(result.Rolling.ValueOut, counters.ValueOut)
+	}
+
+	return result, nil
+}
+
+func copyCounters(counters *store.TxCounters) store.TxCounters {
+	return store.TxCounters{
+		TxCount:  counters.TxCount,
+		ValueIn:  new(big.Int).Set(counters.ValueIn),
+		ValueOut: new(big.Int).Set(counters.ValueOut),
+	}
+}
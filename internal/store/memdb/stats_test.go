@@ -0,0 +1,78 @@
+package memdb_test
+
+import (
+	"context"
+	"math/big"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+func TestGetAddressStats(t *testing.T) {
+	const (
+		addr  = "0xaa"
+		other = "0xbb"
+	)
+	day := time.Date(2024, 3, 1, 0, 0, 0, 0, time.UTC)
+	tx := func(hash, from, to string, value, blockNumber int64, at time.Time) *store.TxRecord {
+		return &store.TxRecord{
+			Hash:           hash,
+			From:           from,
+			To:             to,
+			Value:          big.NewInt(value),
+			BlockNumber:    blockNumber,
+			BlockHash:      "hash-" + hash,
+			BlockTimestamp: at.Unix(),
+		}
+	}
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+
+	// a day earlier than the rolling window, backfilled
+	err := s.InsertTransactions(ctx, addr, []*store.TxRecord{tx("0x1", other, addr, 100, 1, day.Add(-22*time.Hour))})
+	require.NoError(t, err)
+
+	early := tx("0x2", addr, other, 30, 2, day.Add(time.Hour))
+	self := tx("0x3", addr, addr, 5, 2, day.Add(2*time.Hour))
+	err = s.InsertBlock(ctx, &store.Block{Number: 2, AddrToTxs: map[string][]*store.TxRecord{
+		addr:  {early, self},
+		other: {early},
+	}})
+	require.NoError(t, err)
+	// the same block inserted again isn't aggregated twice
+	err = s.InsertBlock(ctx, &store.Block{Number: 2, AddrToTxs: map[string][]*store.TxRecord{addr: {early, self}}})
+	require.NoError(t, err)
+
+	orphaned := tx("0x4", other, addr, 1000, 3, day.Add(23*time.Hour))
+	err = s.InsertBlock(ctx, &store.Block{Number: 3, AddrToTxs: map[string][]*store.TxRecord{addr: {orphaned}}})
+	require.NoError(t, err)
+	removed := *orphaned
+	removed.Removed = true
+	err = s.RemoveBlock(ctx, &store.Block{Number: 3, AddrToTxs: map[string][]*store.TxRecord{addr: {&removed}}})
+	require.NoError(t, err)
+
+	stats, err := s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.DailyTxCounters{
+		{
+			Day:        day.Add(-24 * time.Hour),
+			TxCounters: store.TxCounters{TxCount: 1, ValueIn: big.NewInt(100), ValueOut: big.NewInt(0)},
+		},
+		{
+			Day:        day,
+			TxCounters: store.TxCounters{TxCount: 2, ValueIn: big.NewInt(5), ValueOut: big.NewInt(35)},
+		},
+	}, stats.Daily)
+	// the window ends at the hour of the latest recorded transaction, the orphaned one
+	assert.Equal(t, store.TxCounters{TxCount: 2, ValueIn: big.NewInt(5), ValueOut: big.NewInt(35)}, stats.Rolling)
+
+	stats, err = s.GetAddressStats(ctx, "0xcc")
+	require.NoError(t, err)
+	assert.Empty(t, stats.Daily)
+	assert.Equal(t, int64(0), stats.Rolling.TxCount)
+}
@@ -4,42 +4,161 @@ import (
 	"context"
 	"maps"
 	"slices"
+	"strings"
 	"sync"
+
+	"github.com/hedisam/pipeline/chans"
+
+	"github.com/hedisam/ethtxparser/internal/store"
 )
 
 // SubscriptionStore keeps a record of subscribed addresses.
 type SubscriptionStore struct {
-	subscribedAddresses map[string]struct{}
+	subscriptions       map[string]*store.Subscription
+	contractToEventSubs map[string][]*store.EventSubscription
+	watchers            map[*subscriptionWatcher]struct{}
+	onSubscribe         []func(addr string)
 	mu                  sync.RWMutex
 }
 
+// subscriptionWatcher holds the changes not yet received by a watcher, signalled once pending.
+type subscriptionWatcher struct {
+	signal  chan struct{}
+	pending []*store.SubscriptionChange
+}
+
 func NewSubscriptionStore(opts ...Option) *SubscriptionStore {
-	cfg := &config{memSize: DefaultMemSize}
-	for opt := range slices.Values(opts) {
-		opt(cfg)
-	}
+	cfg := newConfig(opts)
 
 	return &SubscriptionStore{
-		subscribedAddresses: make(map[string]struct{}, cfg.memSize),
+		subscriptions:       make(map[string]*store.Subscription, cfg.memSize),
+		contractToEventSubs: make(map[string][]*store.EventSubscription, cfg.memSize),
+		watchers:            make(map[*subscriptionWatcher]struct{}),
+	}
+}
+
+// AddSubscription adds a new subscription to the list of subscribed addresses.
+// If we've already subscribed to the address, its subscription is replaced with the given one.
+func (s *SubscriptionStore) AddSubscription(_ context.Context, sub *store.Subscription) error {
+	s.mu.Lock()
+	s.subscriptions[sub.Address] = sub
+	s.notify(&store.SubscriptionChange{
+		Type:         store.SubscriptionAdded,
+		Address:      sub.Address,
+		Subscription: sub,
+	})
+	onSubscribe := s.onSubscribe
+	s.mu.Unlock()
+
+	// called without the lock, so the callbacks may read the store
+	for f := range slices.Values(onSubscribe) {
+		f(sub.Address)
 	}
+	return nil
+}
+
+// OnSubscribe registers f to be called with the address of every subscription added, before AddSubscription returns.
+// It's meant for the components that can't wait for the change to be watched, and must return quickly.
+func (s *SubscriptionStore) OnSubscribe(f func(addr string)) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.onSubscribe = append(slices.Clip(s.onSubscribe), f)
 }
 
-// AddSubscription adds a new address to the list of subscribed addresses.
-// Nothing happens if we've already subscribed to the specified address.
-func (s *SubscriptionStore) AddSubscription(_ context.Context, addr string) error {
+// RemoveSubscription unsubscribes the given address, or returns store.ErrNotFound if not subscribed. Its recorded
+// transactions are kept.
+func (s *SubscriptionStore) RemoveSubscription(_ context.Context, addr string) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.subscribedAddresses[addr] = struct{}{}
+	if _, ok := s.subscriptions[addr]; !ok {
+		return store.ErrNotFound
+	}
+	delete(s.subscriptions, addr)
+	s.notify(&store.SubscriptionChange{
+		Type:    store.SubscriptionRemoved,
+		Address: addr,
+	})
 	return nil
 }
 
+// notify queues the change for every watcher. It must be called with the lock held.
+func (s *SubscriptionStore) notify(change *store.SubscriptionChange) {
+	for watcher := range s.watchers {
+		watcher.pending = append(watcher.pending, change)
+		select {
+		case watcher.signal <- struct{}{}:
+		default:
+			// the watcher is already signalled, it receives the change along with the pending ones
+		}
+	}
+}
+
+// WatchSubscriptions returns a channel receiving the changes made to the address subscriptions, in order, until the
+// given context is done. The changes made while the previous ones are being handled are received together, so a slow
+// watcher receives larger batches rather than missing any.
+func (s *SubscriptionStore) WatchSubscriptions(ctx context.Context) <-chan []*store.SubscriptionChange {
+	watcher := &subscriptionWatcher{signal: make(chan struct{}, 1)}
+	s.mu.Lock()
+	s.watchers[watcher] = struct{}{}
+	s.mu.Unlock()
+
+	out := make(chan []*store.SubscriptionChange)
+	go func() {
+		defer close(out)
+		defer func() {
+			s.mu.Lock()
+			defer s.mu.Unlock()
+			delete(s.watchers, watcher)
+		}()
+
+		for range chans.ReceiveOrDoneSeq(ctx, watcher.signal) {
+			s.mu.Lock()
+			changes := watcher.pending
+			watcher.pending = nil
+			s.mu.Unlock()
+
+			if !chans.SendOrDone(ctx, out, changes) {
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// GetSubscription returns the subscription of the given address, or store.ErrNotFound if not subscribed.
+func (s *SubscriptionStore) GetSubscription(_ context.Context, addr string) (*store.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	sub, ok := s.subscriptions[addr]
+	if !ok {
+		return nil, store.ErrNotFound
+	}
+	return sub, nil
+}
+
+// GetSubscriptionsBatch returns the subscriptions of the given addresses keyed by address, leaving out the addresses
+// that aren't subscribed.
+func (s *SubscriptionStore) GetSubscriptionsBatch(_ context.Context, addrs []string) (map[string]*store.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	subs := make(map[string]*store.Subscription)
+	for addr := range slices.Values(addrs) {
+		if sub, ok := s.subscriptions[addr]; ok {
+			subs[addr] = sub
+		}
+	}
+	return subs, nil
+}
+
 // IsSubscribed returns true if we have subscribed to the given address.
 func (s *SubscriptionStore) IsSubscribed(_ context.Context, addr string) (bool, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	_, ok := s.subscribedAddresses[addr]
+	_, ok := s.subscriptions[addr]
 	return ok, nil
 }
 
@@ -48,5 +167,58 @@ func (s *SubscriptionStore) GetSubscriptions(_ context.Context) ([]string, error
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return slices.Collect(maps.Keys(s.subscribedAddresses)), nil
+	return slices.Collect(maps.Keys(s.subscriptions)), nil
+}
+
+// SearchSubscriptions returns the subscriptions matching the query, ordered by address.
+func (s *SubscriptionStore) SearchSubscriptions(_ context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subs []*store.Subscription
+	for sub := range maps.Values(s.subscriptions) {
+		if query.Matches(sub) {
+			subs = append(subs, sub)
+		}
+	}
+	slices.SortFunc(subs, func(a, b *store.Subscription) int {
+		return strings.Compare(a.Address, b.Address)
+	})
+	return subs, nil
+}
+
+// AddEventSubscription adds a new contract event subscription.
+// Nothing happens if an identical subscription already exists for the contract.
+func (s *SubscriptionStore) AddEventSubscription(_ context.Context, sub *store.EventSubscription) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	subs := s.contractToEventSubs[sub.Contract]
+	if slices.ContainsFunc(subs, func(existing *store.EventSubscription) bool {
+		return slices.Equal(existing.Topics, sub.Topics)
+	}) {
+		return nil
+	}
+	s.contractToEventSubs[sub.Contract] = append(subs, sub)
+	return nil
+}
+
+// GetEventSubscriptions returns the event subscriptions of the given contract.
+func (s *SubscriptionStore) GetEventSubscriptions(_ context.Context, contract string) ([]*store.EventSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.contractToEventSubs[contract], nil
+}
+
+// ListEventSubscriptions returns all the contract event subscriptions.
+func (s *SubscriptionStore) ListEventSubscriptions(_ context.Context) ([]*store.EventSubscription, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	var subs []*store.EventSubscription
+	for contractSubs := range maps.Values(s.contractToEventSubs) {
+		subs = append(subs, contractSubs...)
+	}
+	return subs, nil
 }
@@ -0,0 +1,131 @@
+package memdb_test
+
+import (
+	"context"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+func TestSearchSubscriptions(t *testing.T) {
+	ctx := context.Background()
+	s := memdb.NewSubscriptionStore()
+	for sub := range slices.Values([]*store.Subscription{
+		{Address: "0x7a250d5630b4cf539739df2c5dacb4c659f2488d", Label: "Uniswap Router"},
+		{Address: "0x7a25aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa", Label: "Binance Exchange"},
+		{Address: "0x28c6c06298d514db089934071355e5743bf21d60", Label: "Binance Exchange 14"},
+		{Address: "0x0000000000000000000000000000000000000001"},
+	}) {
+		require.NoError(t, s.AddSubscription(ctx, sub))
+	}
+
+	tests := map[string]struct {
+		query             *store.SubscriptionQuery
+		expectedAddresses []string
+	}{
+		"address prefix": {
+			query: &store.SubscriptionQuery{AddressPrefix: "0x7a25"},
+			expectedAddresses: []string{
+				"0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+				"0x7a25aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			},
+		},
+		"label regardless of case": {
+			query: &store.SubscriptionQuery{Label: "exchange"},
+			expectedAddresses: []string{
+				"0x28c6c06298d514db089934071355e5743bf21d60",
+				"0x7a25aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa",
+			},
+		},
+		"address prefix and label": {
+			query:             &store.SubscriptionQuery{AddressPrefix: "0x7a25", Label: "exchange"},
+			expectedAddresses: []string{"0x7a25aaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaaa"},
+		},
+		"no match": {
+			query: &store.SubscriptionQuery{AddressPrefix: "0xff"},
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			subs, err := s.SearchSubscriptions(ctx, test.query)
+			require.NoError(t, err)
+			var addresses []string
+			for sub := range slices.Values(subs) {
+				addresses = append(addresses, sub.Address)
+			}
+			assert.Equal(t, test.expectedAddresses, addresses)
+		})
+	}
+}
+
+func TestOnSubscribe(t *testing.T) {
+	const addr = "0x00000000000000000000000000000000000000aa"
+	ctx := context.Background()
+	s := memdb.NewSubscriptionStore()
+	var subscribed []string
+	s.OnSubscribe(func(addr string) {
+		// the store can be read from the callback, the subscription being added already
+		ok, err := s.IsSubscribed(ctx, addr)
+		require.NoError(t, err)
+		require.True(t, ok)
+		subscribed = append(subscribed, addr)
+	})
+
+	require.NoError(t, s.AddSubscription(ctx, &store.Subscription{Address: addr}))
+	assert.Equal(t, []string{addr}, subscribed)
+}
+
+func TestWatchSubscriptions(t *testing.T) {
+	const (
+		addr1 = "0x00000000000000000000000000000000000000aa"
+		addr2 = "0x00000000000000000000000000000000000000bb"
+	)
+	ctx, cancel := context.WithCancel(context.Background())
+	s := memdb.NewSubscriptionStore()
+	changes := s.WatchSubscriptions(ctx)
+
+	sub1 := &store.Subscription{Address: addr1}
+	sub2 := &store.Subscription{Address: addr2}
+	require.NoError(t, s.AddSubscription(ctx, sub1))
+	require.NoError(t, s.AddSubscription(ctx, sub2))
+	require.NoError(t, s.RemoveSubscription(ctx, addr1))
+	assert.ErrorIs(t, s.RemoveSubscription(ctx, addr1), store.ErrNotFound)
+
+	// the changes may be received in one or more batches, but all of them in order
+	var received []*store.SubscriptionChange
+	for len(received) < 3 {
+		select {
+		case batch := <-changes:
+			received = append(received, batch...)
+		case <-time.After(time.Second):
+			t.Fatalf("received %d of the 3 changes", len(received))
+		}
+	}
+	assert.Equal(t, []*store.SubscriptionChange{
+		{Type: store.SubscriptionAdded, Address: addr1, Subscription: sub1},
+		{Type: store.SubscriptionAdded, Address: addr2, Subscription: sub2},
+		{Type: store.SubscriptionRemoved, Address: addr1},
+	}, received)
+
+	subscribed, err := s.IsSubscribed(ctx, addr1)
+	require.NoError(t, err)
+	assert.False(t, subscribed)
+
+	// the channel is closed once the context is done
+	cancel()
+	require.Eventually(t, func() bool {
+		select {
+		case _, ok := <-changes:
+			return !ok
+		default:
+			return false
+		}
+	}, time.Second, time.Millisecond)
+}
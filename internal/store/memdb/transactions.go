@@ -1,8 +1,13 @@
 package memdb
 
 import (
+	"cmp"
 	"context"
+	"maps"
+	"math"
+	"math/big"
 	"slices"
+	"strconv"
 	"sync"
 	"sync/atomic"
 
@@ -12,26 +17,71 @@ import (
 const (
 	// BlockNone is used to denote we haven't processed any blocks yet.
 	BlockNone = -1
+	// maxRawTxs is the number of raw transactions cached, the oldest cached ones evicted past it.
+	maxRawTxs = 10_000
+	// maxBalancedBlocks is the number of the latest blocks whose token balance changes applied are remembered.
+	maxBalancedBlocks = 1024
+	// maxOutboxBlocks is the number of the latest blocks whose outbox entries inserted are remembered.
+	maxOutboxBlocks = 1024
 )
 
 // TxStore holds a record of parsed and indexed transactions for the subscribed addresses.
+//
+// The transaction, token transfer and event lists are copy-on-write, so reads return snapshots without copying them
+// and serializing a snapshot never blocks inserts. Records are only ever appended past the length of the lists, which
+// no snapshot sees, and any other change, e.g. marking orphaned records removed or evicting old ones, replaces the
+// list of the address with an updated copy.
 type TxStore struct {
-	addrToTransactions map[string][]*store.TxRecord
-	currentBlockNum    *atomic.Int64
-	mu                 sync.RWMutex
+	addrToTransactions   map[string][]*store.TxRecord
+	addrToTokenTransfers map[string][]*store.TokenTransferRecord
+	contractToEvents     map[string][]*store.EventRecord
+	notifierToOutbox     map[string][]*store.OutboxEntry
+	deadLetters          []*store.DeadLetter
+	addrToStats          map[string]*addressStats
+	// hashToRawTx caches the raw transactions fetched from the node, rawTxHashes holding their hashes in the order
+	// they were cached in to evict the oldest ones.
+	hashToRawTx map[string]string
+	rawTxHashes []string
+	// tokenToBalances holds the balances of the holders of the tracked tokens. balancedBlocks holds the hashes of the
+	// blocks whose balance changes are applied, so a block inserted again isn't applied twice, and balancedHashes
+	// holds them in the order they were applied in to forget the oldest ones.
+	tokenToBalances map[string]map[string]*big.Int
+	balancedBlocks  map[string]struct{}
+	balancedHashes  []string
+	// outboxBlocks holds the keys of the blocks whose outbox entries are inserted, see outboxKey, so a block written
+	// again doesn't queue its notifications twice, and outboxKeys holds them in the order they were inserted in to
+	// forget the oldest ones.
+	outboxBlocks map[string]struct{}
+	outboxKeys   []string
+	// latestHour is the unix hour of the latest aggregated transaction, ending the rolling window of the stats.
+	latestHour      int64
+	lastOutboxID    uint64
+	currentBlockNum *atomic.Int64
+	// records is the number of transactions, token transfers and events held in the lists, evicted past maxRecords.
+	records    int
+	maxRecords int
+	metrics    *metrics
+	mu         sync.RWMutex
 }
 
 func NewTxStore(opts ...Option) *TxStore {
-	cfg := &config{memSize: DefaultMemSize}
-	for opt := range slices.Values(opts) {
-		opt(cfg)
-	}
+	cfg := newConfig(opts)
 
 	var currentBlockNum atomic.Int64
 	currentBlockNum.Store(BlockNone)
 	return &TxStore{
-		addrToTransactions: make(map[string][]*store.TxRecord, cfg.memSize),
-		currentBlockNum:    &currentBlockNum,
+		addrToTransactions:   make(map[string][]*store.TxRecord, cfg.memSize),
+		addrToTokenTransfers: make(map[string][]*store.TokenTransferRecord, cfg.memSize),
+		contractToEvents:     make(map[string][]*store.EventRecord, cfg.memSize),
+		notifierToOutbox:     make(map[string][]*store.OutboxEntry),
+		addrToStats:          make(map[string]*addressStats, cfg.memSize),
+		hashToRawTx:          make(map[string]string, cfg.memSize),
+		tokenToBalances:      make(map[string]map[string]*big.Int),
+		balancedBlocks:       make(map[string]struct{}, maxBalancedBlocks),
+		outboxBlocks:         make(map[string]struct{}, maxOutboxBlocks),
+		currentBlockNum:      &currentBlockNum,
+		maxRecords:           cfg.maxRecords,
+		metrics:              newMetrics(cfg.registerer),
 	}
 }
 
@@ -40,20 +90,522 @@ func (s *TxStore) InsertBlock(_ context.Context, block *store.Block) error {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 
-	s.currentBlockNum.Store(block.Number)
+	// retried blocks can be inserted after later ones, the current block number must never go backwards
+	if block.Number > s.currentBlockNum.Load() {
+		s.currentBlockNum.Store(block.Number)
+	}
 	for addr, txs := range block.AddrToTxs {
-		s.addrToTransactions[addr] = append(s.addrToTransactions[addr], txs...)
+		// records are shared across the address lists they're matched for, so we only store the pointers here.
+		// growing the slice upfront makes sure we allocate at most once per address per block.
+		existing := slices.Grow(s.addrToTransactions[addr], len(txs))
+		recorded := recordedInBlock(existing, block.Number, func(tx *store.TxRecord) (int64, string) {
+			return tx.BlockNumber, txKey(tx)
+		})
+		for tx := range slices.Values(txs) {
+			if _, ok := recorded[txKey(tx)]; ok {
+				continue
+			}
+			recorded[txKey(tx)] = struct{}{}
+			existing = append(existing, tx)
+			s.aggregate(addr, tx, 1)
+			s.records++
+		}
+		s.addrToTransactions[addr] = existing
+	}
+	for addr, transfers := range block.AddrToTokenTransfers {
+		existing := slices.Grow(s.addrToTokenTransfers[addr], len(transfers))
+		recorded := recordedInBlock(existing, block.Number, func(transfer *store.TokenTransferRecord) (int64, string) {
+			return transfer.BlockNumber, transferKey(transfer)
+		})
+		for transfer := range slices.Values(transfers) {
+			if _, ok := recorded[transferKey(transfer)]; ok {
+				continue
+			}
+			recorded[transferKey(transfer)] = struct{}{}
+			existing = append(existing, transfer)
+			s.records++
+		}
+		s.addrToTokenTransfers[addr] = existing
+	}
+	for contract, events := range block.ContractToEvents {
+		existing := slices.Grow(s.contractToEvents[contract], len(events))
+		recorded := recordedInBlock(existing, block.Number, func(event *store.EventRecord) (int64, string) {
+			return event.BlockNumber, eventKey(event)
+		})
+		for event := range slices.Values(events) {
+			if _, ok := recorded[eventKey(event)]; ok {
+				continue
+			}
+			recorded[eventKey(event)] = struct{}{}
+			existing = append(existing, event)
+			s.records++
+		}
+		s.contractToEvents[contract] = existing
+	}
+	if _, ok := s.balancedBlocks[block.Hash]; !ok && len(block.TokenBalanceChanges) > 0 {
+		s.applyBalanceChanges(block.TokenBalanceChanges, 1)
+		s.balancedBlocks[block.Hash] = struct{}{}
+		s.balancedHashes = append(s.balancedHashes, block.Hash)
+		if len(s.balancedHashes) > maxBalancedBlocks {
+			delete(s.balancedBlocks, s.balancedHashes[0])
+			s.balancedHashes = slices.Delete(s.balancedHashes, 0, 1)
+		}
+	}
+	s.forgetOutbox(outboxKey(block.Hash, true))
+	s.insertOutbox(outboxKey(block.Hash, false), block.Outbox)
+	s.evict()
+
+	return nil
+}
+
+// RemoveBlock marks the records of an orphaned block as removed, replacing the recorded ones with the removed copies
+// the given block holds, and inserts its outbox entries within a single db transaction.
+func (s *TxStore) RemoveBlock(_ context.Context, block *store.Block) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// the record lists may be held by readers as snapshots, so they're cloned rather than updated in place
+	for addr, txs := range block.AddrToTxs {
+		for tx := range slices.Values(txs) {
+			s.aggregate(addr, tx, -1)
+		}
+		s.addrToTransactions[addr] = replaceRecords(s.addrToTransactions[addr], txs, func(tx *store.TxRecord) (int64, string) {
+			return tx.BlockNumber, txKey(tx)
+		})
+	}
+	for addr, transfers := range block.AddrToTokenTransfers {
+		s.addrToTokenTransfers[addr] = replaceRecords(s.addrToTokenTransfers[addr], transfers, func(transfer *store.TokenTransferRecord) (int64, string) {
+			return transfer.BlockNumber, transferKey(transfer)
+		})
+	}
+	for contract, events := range block.ContractToEvents {
+		s.contractToEvents[contract] = replaceRecords(s.contractToEvents[contract], events, func(event *store.EventRecord) (int64, string) {
+			return event.BlockNumber, eventKey(event)
+		})
+	}
+	if _, ok := s.balancedBlocks[block.Hash]; ok {
+		s.applyBalanceChanges(block.TokenBalanceChanges, -1)
+		delete(s.balancedBlocks, block.Hash)
+		s.balancedHashes = slices.DeleteFunc(s.balancedHashes, func(hash string) bool {
+			return hash == block.Hash
+		})
+	}
+	s.forgetOutbox(outboxKey(block.Hash, false))
+	s.insertOutbox(outboxKey(block.Hash, true), block.Outbox)
+
+	return nil
+}
+
+// applyBalanceChanges adds the given changes to the balances of the holders, or subtracts them if sign is negative,
+// e.g. to revert the changes of an orphaned block. It must be called with the lock held.
+func (s *TxStore) applyBalanceChanges(changes []*store.TokenBalanceChange, sign int) {
+	for change := range slices.Values(changes) {
+		balances, ok := s.tokenToBalances[change.Token]
+		if !ok {
+			balances = make(map[string]*big.Int)
+			s.tokenToBalances[change.Token] = balances
+		}
+		balance, ok := balances[change.Holder]
+		if !ok {
+			balance = new(big.Int)
+			balances[change.Holder] = balance
+		}
+		if sign < 0 {
+			balance.Sub(balance, change.Delta)
+		} else {
+			balance.Add(balance, change.Delta)
+		}
+	}
+}
+
+// GetTokenBalance returns the balance of the given holder of the token, tracked from the transfers indexed since its
+// holders are tracked. Zero is returned for holders not seen yet.
+func (s *TxStore) GetTokenBalance(_ context.Context, token, holder string) (*big.Int, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	balance, ok := s.tokenToBalances[token][holder]
+	if !ok {
+		return new(big.Int), nil
+	}
+	return new(big.Int).Set(balance), nil
+}
+
+// insertOutbox inserts the outbox entries of the block of the given key, unless they're already inserted. It must be
+// called with the lock held.
+func (s *TxStore) insertOutbox(key string, entries []*store.OutboxEntry) {
+	if len(entries) == 0 {
+		return
+	}
+	if _, ok := s.outboxBlocks[key]; ok {
+		return
+	}
+	s.outboxBlocks[key] = struct{}{}
+	s.outboxKeys = append(s.outboxKeys, key)
+	if len(s.outboxKeys) > maxOutboxBlocks {
+		delete(s.outboxBlocks, s.outboxKeys[0])
+		s.outboxKeys = slices.Delete(s.outboxKeys, 0, 1)
+	}
+
+	for entry := range slices.Values(entries) {
+		s.lastOutboxID++
+		entry.ID = s.lastOutboxID
+		s.notifierToOutbox[entry.Notifier] = append(s.notifierToOutbox[entry.Notifier], entry)
+	}
+}
+
+// forgetOutbox forgets the outbox entries of the block of the given key were inserted, so that they're inserted again
+// once it's written again, e.g. a block inserted back after being orphaned. It must be called with the lock held.
+func (s *TxStore) forgetOutbox(key string) {
+	if _, ok := s.outboxBlocks[key]; !ok {
+		return
+	}
+	delete(s.outboxBlocks, key)
+	s.outboxKeys = slices.DeleteFunc(s.outboxKeys, func(k string) bool {
+		return k == key
+	})
+}
+
+// outboxKey returns the key the outbox entries of the block of the given hash are inserted under, the ones of the
+// block being inserted and removed being told apart.
+func outboxKey(blockHash string, removed bool) string {
+	if removed {
+		return "removed:" + blockHash
+	}
+	return "inserted:" + blockHash
+}
+
+// InsertTransactions inserts past transactions of addr, such as backfilled ones, keeping the transactions of the
+// address ordered by block number and skipping the ones already recorded.
+func (s *TxStore) InsertTransactions(_ context.Context, addr string, txs []*store.TxRecord) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	existing := s.addrToTransactions[addr]
+	recorded := make(map[string]struct{}, len(existing))
+	for tx := range slices.Values(existing) {
+		recorded[tx.Hash] = struct{}{}
+	}
+
+	merged := slices.Grow(slices.Clone(existing), len(txs))
+	for tx := range slices.Values(txs) {
+		if _, ok := recorded[tx.Hash]; ok {
+			continue
+		}
+		recorded[tx.Hash] = struct{}{}
+		merged = append(merged, tx)
+		s.aggregate(addr, tx, 1)
+		s.records++
+	}
+	// the sort is stable to keep the order of the transactions within the same block
+	slices.SortStableFunc(merged, func(a, b *store.TxRecord) int {
+		return cmp.Compare(a.BlockNumber, b.BlockNumber)
+	})
+	s.addrToTransactions[addr] = merged
+	s.evict()
+
+	return nil
+}
+
+// evict evicts the records of the oldest blocks once there are more than maxRecords of them, down to 90% of it so
+// they aren't evicted on every block. The stats of the addresses are kept. It must be called with the lock held.
+func (s *TxStore) evict() {
+	if s.maxRecords == 0 || s.records <= s.maxRecords {
+		return
+	}
+
+	blockRecords := make(map[int64]int)
+	for txs := range maps.Values(s.addrToTransactions) {
+		for tx := range slices.Values(txs) {
+			blockRecords[tx.BlockNumber]++
+		}
+	}
+	for transfers := range maps.Values(s.addrToTokenTransfers) {
+		for transfer := range slices.Values(transfers) {
+			blockRecords[transfer.BlockNumber]++
+		}
+	}
+	for events := range maps.Values(s.contractToEvents) {
+		for event := range slices.Values(events) {
+			blockRecords[event.BlockNumber]++
+		}
+	}
+	excess := s.records - s.maxRecords*9/10
+	var cutoff int64
+	for number := range slices.Values(slices.Sorted(maps.Keys(blockRecords))) {
+		if excess <= 0 {
+			break
+		}
+		excess -= blockRecords[number]
+		cutoff = number
+	}
+
+	evicted := evictRecords(s.addrToTransactions, cutoff, func(tx *store.TxRecord) int64 { return tx.BlockNumber }) +
+		evictRecords(s.addrToTokenTransfers, cutoff, func(transfer *store.TokenTransferRecord) int64 { return transfer.BlockNumber }) +
+		evictRecords(s.contractToEvents, cutoff, func(event *store.EventRecord) int64 { return event.BlockNumber })
+	s.records -= evicted
+	s.metrics.evictedRecords.Add(float64(evicted))
+}
+
+// evictRecords replaces the lists of the given map with copies without the records of the blocks up to cutoff,
+// deleting the emptied ones, and returns the number of evicted records. The lists may be held by readers, so they
+// aren't updated in place.
+func evictRecords[T any](lists map[string][]T, cutoff int64, blockNumber func(T) int64) int {
+	var evicted int
+	for key, records := range lists {
+		kept := make([]T, 0, len(records))
+		for record := range slices.Values(records) {
+			if blockNumber(record) > cutoff {
+				kept = append(kept, record)
+			}
+		}
+		evicted += len(records) - len(kept)
+		switch {
+		case len(kept) == 0:
+			delete(lists, key)
+		case len(kept) < len(records):
+			lists[key] = kept
+		}
+	}
+	return evicted
+}
+
+// ExtractRecords removes the transactions, token transfers and events of the blocks before the given block number and
+// returns them, e.g. to archive them to cold storage. The stats of the addresses are kept.
+func (s *TxStore) ExtractRecords(_ context.Context, beforeBlock int64) (*store.Records, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	records := &store.Records{
+		AddrToTxs: extractRecords(s.addrToTransactions, beforeBlock, func(tx *store.TxRecord) int64 {
+			return tx.BlockNumber
+		}),
+		AddrToTokenTransfers: extractRecords(s.addrToTokenTransfers, beforeBlock, func(transfer *store.TokenTransferRecord) int64 {
+			return transfer.BlockNumber
+		}),
+		ContractToEvents: extractRecords(s.contractToEvents, beforeBlock, func(event *store.EventRecord) int64 {
+			return event.BlockNumber
+		}),
+	}
+	s.records -= records.Len()
+	return records, nil
+}
+
+// RestoreRecords inserts records back, such as archived ones, keeping the lists ordered by block number and skipping
+// the records already held, and returns the number of restored records. The stats of the addresses aren't updated,
+// they were kept when the records were extracted. Restored records count towards the cap of WithMaxRecords, being
+// the oldest ones they're the first evicted on the next insert if it's exceeded.
+func (s *TxStore) RestoreRecords(_ context.Context, records *store.Records) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	restored := restoreRecords(s.addrToTransactions, records.AddrToTxs, func(tx *store.TxRecord) (int64, string) {
+		return tx.BlockNumber, txKey(tx)
+	}) + restoreRecords(s.addrToTokenTransfers, records.AddrToTokenTransfers, func(transfer *store.TokenTransferRecord) (int64, string) {
+		return transfer.BlockNumber, transferKey(transfer)
+	}) + restoreRecords(s.contractToEvents, records.ContractToEvents, func(event *store.EventRecord) (int64, string) {
+		return event.BlockNumber, eventKey(event)
+	})
+	s.records += restored
+	return restored, nil
+}
+
+// extractRecords replaces the lists of the given map with copies without the records of the blocks before the given
+// one, deleting the emptied ones, and returns the removed records by key. The lists may be held by readers, so they
+// aren't updated in place.
+func extractRecords[T any](lists map[string][]T, beforeBlock int64, blockNumber func(T) int64) map[string][]T {
+	extracted := make(map[string][]T)
+	for key, records := range lists {
+		// the lists are ordered by block number
+		idx := slices.IndexFunc(records, func(record T) bool {
+			return blockNumber(record) >= beforeBlock
+		})
+		if idx == -1 {
+			idx = len(records)
+		}
+		if idx == 0 {
+			continue
+		}
+		extracted[key] = records[:idx:idx]
+		if idx == len(records) {
+			delete(lists, key)
+			continue
+		}
+		lists[key] = slices.Clone(records[idx:])
+	}
+	return extracted
+}
+
+// restoreRecords merges the restored records into copies of the lists of the given map, skipping the ones whose key is
+// already held, and returns the number of merged records.
+func restoreRecords[T any](lists, restored map[string][]T, key func(T) (int64, string)) int {
+	var merged int
+	for listKey, records := range restored {
+		existing := lists[listKey]
+		held := make(map[string]struct{}, len(existing))
+		for record := range slices.Values(existing) {
+			_, k := key(record)
+			held[k] = struct{}{}
+		}
+
+		list := slices.Grow(slices.Clone(existing), len(records))
+		for record := range slices.Values(records) {
+			_, k := key(record)
+			if _, ok := held[k]; ok {
+				continue
+			}
+			held[k] = struct{}{}
+			list = append(list, record)
+			merged++
+		}
+		// the sort is stable to keep the order of the records within the same block
+		slices.SortStableFunc(list, func(a, b T) int {
+			numberA, _ := key(a)
+			numberB, _ := key(b)
+			return cmp.Compare(numberA, numberB)
+		})
+		if len(list) > 0 {
+			lists[listKey] = list
+		}
 	}
+	return merged
+}
+
+// recordedInBlock returns the keys of the trailing records of the given block number, guarding against recording a
+// transaction twice under the same address when it's matched twice or its block is inserted again.
+func recordedInBlock[T any](records []T, blockNumber int64, key func(T) (int64, string)) map[string]struct{} {
+	recorded := make(map[string]struct{})
+	for i := len(records) - 1; i >= 0; i-- {
+		number, k := key(records[i])
+		if number != blockNumber {
+			break
+		}
+		recorded[k] = struct{}{}
+	}
+	return recorded
+}
+
+// replaceRecords returns a copy of records with the ones matching the keys of the given replacements replaced.
+// Records are ordered by block number, so only the trailing ones down to the oldest replacement block are checked.
+func replaceRecords[T any](records, replacements []T, key func(T) (int64, string)) []T {
+	if len(replacements) == 0 {
+		return records
+	}
+
+	keyToReplacement := make(map[string]T, len(replacements))
+	oldest := int64(math.MaxInt64)
+	for r := range slices.Values(replacements) {
+		number, k := key(r)
+		keyToReplacement[k] = r
+		oldest = min(oldest, number)
+	}
+
+	replaced := slices.Clone(records)
+	for i := len(replaced) - 1; i >= 0; i-- {
+		number, k := key(replaced[i])
+		if number < oldest {
+			break
+		}
+		if r, ok := keyToReplacement[k]; ok {
+			replaced[i] = r
+		}
+	}
+	return replaced
+}
 
+// txKey, transferKey and eventKey identify the records within the block they were recorded in, the same transaction
+// being recorded again in the block replacing its orphaned one.
+func txKey(tx *store.TxRecord) string {
+	return tx.BlockHash + ":" + tx.Hash
+}
+
+func transferKey(transfer *store.TokenTransferRecord) string {
+	return transfer.BlockHash + ":" + transfer.TxHash + ":" + strconv.FormatInt(transfer.LogIndex, 10)
+}
+
+func eventKey(event *store.EventRecord) string {
+	return event.BlockHash + ":" + event.TxHash + ":" + strconv.FormatInt(event.LogIndex, 10)
+}
+
+// GetOutboxEntries returns up to limit of the oldest unacknowledged outbox entries of the given notifier.
+func (s *TxStore) GetOutboxEntries(_ context.Context, notifier string, limit int) ([]*store.OutboxEntry, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	outbox := s.notifierToOutbox[notifier]
+	return slices.Clone(outbox[:min(limit, len(outbox))]), nil
+}
+
+// AckOutboxEntries removes the given delivered entries from the outbox of the notifier.
+func (s *TxStore) AckOutboxEntries(_ context.Context, notifier string, ids []uint64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.notifierToOutbox[notifier] = slices.DeleteFunc(s.notifierToOutbox[notifier], func(entry *store.OutboxEntry) bool {
+		return slices.Contains(ids, entry.ID)
+	})
 	return nil
 }
 
-// GetTransactions returns recorded transactions for the given addr.
+// InsertDeadLetter records a block that could not be indexed.
+func (s *TxStore) InsertDeadLetter(_ context.Context, deadLetter *store.DeadLetter) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.deadLetters = append(s.deadLetters, deadLetter)
+	return nil
+}
+
+// GetDeadLetters returns the blocks that could not be indexed, in the order they were given up on.
+func (s *TxStore) GetDeadLetters(_ context.Context) ([]*store.DeadLetter, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clone(s.deadLetters), nil
+}
+
+// PurgeAddress deletes the recorded transactions, token transfers and stats of the given addr, along with the events
+// emitted by it and the balances of its holders if it's a contract, returning the number of deleted records.
+func (s *TxStore) PurgeAddress(_ context.Context, addr string) (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	purged := len(s.addrToTransactions[addr]) + len(s.addrToTokenTransfers[addr]) + len(s.contractToEvents[addr])
+	s.records -= purged
+	delete(s.addrToTransactions, addr)
+	delete(s.addrToTokenTransfers, addr)
+	delete(s.contractToEvents, addr)
+	delete(s.addrToStats, addr)
+	delete(s.tokenToBalances, addr)
+	return purged, nil
+}
+
+// GetTransactions returns a snapshot of the recorded transactions for the given addr, unaffected by later writes.
+// It must not be modified in place.
 func (s *TxStore) GetTransactions(_ context.Context, addr string) ([]*store.TxRecord, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
-	return s.addrToTransactions[addr], nil
+	// clipped so appending to the snapshot can't write to the spare capacity the next records are appended to
+	return slices.Clip(s.addrToTransactions[addr]), nil
+}
+
+// GetTokenTransfers returns a snapshot of the recorded token transfers for the given addr, unaffected by later
+// writes. It must not be modified in place.
+func (s *TxStore) GetTokenTransfers(_ context.Context, addr string) ([]*store.TokenTransferRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clip(s.addrToTokenTransfers[addr]), nil
+}
+
+// GetEvents returns a snapshot of the recorded events emitted by the given contract, unaffected by later writes. It
+// must not be modified in place.
+func (s *TxStore) GetEvents(_ context.Context, contract string) ([]*store.EventRecord, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return slices.Clip(s.contractToEvents[contract]), nil
 }
 
 // GetCurrentBlockNumber returns the last parsed block number.
@@ -65,3 +617,34 @@ func (s *TxStore) GetCurrentBlockNumber(_ context.Context) (int64, error) {
 
 	return blockNum, nil
 }
+
+// GetRawTransaction returns the cached raw transaction of the given hash. store.ErrNotFound is returned if it's not
+// cached.
+func (s *TxStore) GetRawTransaction(_ context.Context, hash string) (string, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	raw, ok := s.hashToRawTx[hash]
+	if !ok {
+		return "", store.ErrNotFound
+	}
+	return raw, nil
+}
+
+// InsertRawTransaction caches the raw transaction of the given hash, evicting the oldest cached one once there are
+// more than maxRawTxs of them.
+func (s *TxStore) InsertRawTransaction(_ context.Context, hash, raw string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if _, ok := s.hashToRawTx[hash]; ok {
+		return nil
+	}
+	s.hashToRawTx[hash] = raw
+	s.rawTxHashes = append(s.rawTxHashes, hash)
+	if len(s.rawTxHashes) > maxRawTxs {
+		delete(s.hashToRawTx, s.rawTxHashes[0])
+		s.rawTxHashes = slices.Delete(s.rawTxHashes, 0, 1)
+	}
+	return nil
+}
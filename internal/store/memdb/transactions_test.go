@@ -0,0 +1,365 @@
+package memdb_test
+
+import (
+	"context"
+	"fmt"
+	"math/big"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+func TestPurgeAddress(t *testing.T) {
+	const (
+		addr  = "0xaa"
+		other = "0xbb"
+	)
+	tx := &store.TxRecord{
+		Hash:           "0x1",
+		From:           addr,
+		To:             other,
+		Value:          big.NewInt(100),
+		BlockNumber:    1,
+		BlockHash:      "hash-1",
+		BlockTimestamp: time.Now().Unix(),
+	}
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	err := s.InsertBlock(ctx, &store.Block{
+		Number: 1,
+		AddrToTxs: map[string][]*store.TxRecord{
+			addr:  {tx},
+			other: {tx},
+		},
+		AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{
+			addr: {{TxHash: "0x1", Token: "0xcc", From: addr, To: other, BlockNumber: 1}},
+		},
+	})
+	require.NoError(t, err)
+
+	purged, err := s.PurgeAddress(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, 2, purged)
+
+	txs, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	assert.Empty(t, txs)
+	transfers, err := s.GetTokenTransfers(ctx, addr)
+	require.NoError(t, err)
+	assert.Empty(t, transfers)
+	stats, err := s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Zero(t, stats.Rolling.TxCount)
+	// the other party keeps its records
+	txs, err = s.GetTransactions(ctx, other)
+	require.NoError(t, err)
+	assert.Len(t, txs, 1)
+}
+
+func TestMaxRecords(t *testing.T) {
+	const addr = "0xaa"
+	ctx := context.Background()
+	s := memdb.NewTxStore(memdb.WithMaxRecords(10))
+	for number := range int64(6) {
+		txs := make([]*store.TxRecord, 0, 2)
+		for i := range 2 {
+			txs = append(txs, &store.TxRecord{
+				Hash:           fmt.Sprintf("0x%d-%d", number, i),
+				From:           addr,
+				Value:          big.NewInt(1),
+				BlockNumber:    number,
+				BlockTimestamp: time.Now().Unix(),
+			})
+		}
+		err := s.InsertBlock(ctx, &store.Block{
+			Number:    number,
+			AddrToTxs: map[string][]*store.TxRecord{addr: txs},
+		})
+		require.NoError(t, err)
+	}
+
+	// the 12 records of blocks 0 to 5 exceeded the cap, so the oldest blocks were evicted down to 9 records or less
+	txs, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	require.Len(t, txs, 8)
+	assert.Equal(t, int64(2), txs[0].BlockNumber)
+	assert.Equal(t, int64(5), txs[len(txs)-1].BlockNumber)
+	current, err := s.GetCurrentBlockNumber(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), current)
+	stats, err := s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(12), stats.Rolling.TxCount, "the stats keep the evicted records")
+}
+
+func TestExtractAndRestoreRecords(t *testing.T) {
+	const (
+		addr     = "0xaa"
+		contract = "0xcc"
+	)
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	for number := range int64(4) {
+		tx := &store.TxRecord{
+			Hash:           fmt.Sprintf("0x%d", number),
+			From:           addr,
+			Value:          big.NewInt(1),
+			BlockNumber:    number,
+			BlockTimestamp: time.Now().Unix(),
+		}
+		err := s.InsertBlock(ctx, &store.Block{
+			Number:    number,
+			AddrToTxs: map[string][]*store.TxRecord{addr: {tx}},
+			ContractToEvents: map[string][]*store.EventRecord{
+				contract: {{Contract: contract, TxHash: tx.Hash, BlockNumber: number}},
+			},
+		})
+		require.NoError(t, err)
+	}
+
+	records, err := s.ExtractRecords(ctx, 2)
+	require.NoError(t, err)
+	assert.Equal(t, 4, records.Len())
+	fromBlock, toBlock, ok := records.BlockRange()
+	require.True(t, ok)
+	assert.Equal(t, int64(0), fromBlock)
+	assert.Equal(t, int64(1), toBlock)
+	txs, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	require.Len(t, txs, 2)
+	assert.Equal(t, int64(2), txs[0].BlockNumber)
+	stats, err := s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Rolling.TxCount, "the stats keep the extracted records")
+
+	restored, err := s.RestoreRecords(ctx, records)
+	require.NoError(t, err)
+	assert.Equal(t, 4, restored)
+	txs, err = s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	require.Len(t, txs, 4)
+	for i, tx := range txs {
+		assert.Equal(t, int64(i), tx.BlockNumber)
+	}
+	events, err := s.GetEvents(ctx, contract)
+	require.NoError(t, err)
+	assert.Len(t, events, 4)
+	stats, err = s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(4), stats.Rolling.TxCount, "the stats aren't aggregated twice")
+
+	// restoring records already held is a no-op
+	restored, err = s.RestoreRecords(ctx, records)
+	require.NoError(t, err)
+	assert.Zero(t, restored)
+}
+
+func TestSnapshots(t *testing.T) {
+	const addr = "0xaa"
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	newTx := func(hash string, number int64) *store.TxRecord {
+		return &store.TxRecord{Hash: hash, From: addr, Value: big.NewInt(1), BlockNumber: number, BlockHash: fmt.Sprintf("hash-%d", number)}
+	}
+	insert := func(txs ...*store.TxRecord) {
+		err := s.InsertBlock(ctx, &store.Block{Number: txs[0].BlockNumber, AddrToTxs: map[string][]*store.TxRecord{addr: txs}})
+		require.NoError(t, err)
+	}
+	hashes := func(txs []*store.TxRecord) []string {
+		var hashes []string
+		for _, tx := range txs {
+			if tx.Removed {
+				hashes = append(hashes, tx.Hash+" (removed)")
+				continue
+			}
+			hashes = append(hashes, tx.Hash)
+		}
+		return hashes
+	}
+
+	insert(newTx("0x1", 1))
+	insert(newTx("0x2", 2), newTx("0x3", 2))
+	first, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	insert(newTx("0x4", 3))
+	second, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	// the list has spare capacity by now, which the next insert appends to rather than the snapshot
+	appended := append(second, newTx("0xforeign", 3))
+	insert(newTx("0x5", 4))
+	removed := newTx("0x4", 3)
+	removed.Removed = true
+	err = s.RemoveBlock(ctx, &store.Block{Number: 3, AddrToTxs: map[string][]*store.TxRecord{addr: {removed}}})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"0x1", "0x2", "0x3"}, hashes(first))
+	assert.Equal(t, []string{"0x1", "0x2", "0x3", "0x4"}, hashes(second))
+	assert.Equal(t, []string{"0x1", "0x2", "0x3", "0x4", "0xforeign"}, hashes(appended))
+	latest, err := s.GetTransactions(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"0x1", "0x2", "0x3", "0x4 (removed)", "0x5"}, hashes(latest))
+}
+
+func TestRawTransactions(t *testing.T) {
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	_, err := s.GetRawTransaction(ctx, "0x01")
+	require.ErrorIs(t, err, store.ErrNotFound)
+
+	require.NoError(t, s.InsertRawTransaction(ctx, "0x01", "0xf86c01"))
+	raw, err := s.GetRawTransaction(ctx, "0x01")
+	require.NoError(t, err)
+	assert.Equal(t, "0xf86c01", raw)
+}
+
+func TestTokenBalances(t *testing.T) {
+	const (
+		token   = "0xcc"
+		holderA = "0xaa"
+		holderB = "0xbb"
+	)
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	balance := func(holder string) int64 {
+		b, err := s.GetTokenBalance(ctx, token, holder)
+		require.NoError(t, err)
+		return b.Int64()
+	}
+	block := func(number int64, hash string, deltaA int64) *store.Block {
+		return &store.Block{
+			Number: number,
+			Hash:   hash,
+			TokenBalanceChanges: []*store.TokenBalanceChange{
+				{Token: token, Holder: holderA, Delta: big.NewInt(deltaA)},
+				{Token: token, Holder: holderB, Delta: big.NewInt(-deltaA)},
+			},
+		}
+	}
+
+	require.NoError(t, s.InsertBlock(ctx, block(1, "hash-1", 100)))
+	require.NoError(t, s.InsertBlock(ctx, block(2, "hash-2", 50)))
+	assert.Equal(t, int64(150), balance(holderA))
+	assert.Equal(t, int64(-150), balance(holderB))
+	assert.Zero(t, balance("0xdd"), "holders not seen have no balance")
+
+	// a block inserted again isn't applied twice
+	require.NoError(t, s.InsertBlock(ctx, block(2, "hash-2", 50)))
+	assert.Equal(t, int64(150), balance(holderA))
+
+	// the changes of an orphaned block are reverted once
+	require.NoError(t, s.RemoveBlock(ctx, block(2, "hash-2", 50)))
+	require.NoError(t, s.RemoveBlock(ctx, block(2, "hash-2", 50)))
+	assert.Equal(t, int64(100), balance(holderA))
+	assert.Equal(t, int64(-100), balance(holderB))
+
+	// the returned balance is a copy
+	b, err := s.GetTokenBalance(ctx, token, holderA)
+	require.NoError(t, err)
+	b.SetInt64(0)
+	assert.Equal(t, int64(100), balance(holderA))
+
+	_, err = s.PurgeAddress(ctx, token)
+	require.NoError(t, err)
+	assert.Zero(t, balance(holderA))
+}
+
+func TestInsertBlockAgain(t *testing.T) {
+	const contract = "0xcc"
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	block := func(removed bool) *store.Block {
+		tx := &store.TxRecord{Hash: "tx-1", BlockNumber: 1, BlockHash: "hash-1", Removed: removed}
+		return &store.Block{
+			Number:    1,
+			Hash:      "hash-1",
+			AddrToTxs: map[string][]*store.TxRecord{"0xaa": {tx}},
+			ContractToEvents: map[string][]*store.EventRecord{
+				contract: {{TxHash: "tx-1", LogIndex: 0, BlockNumber: 1, BlockHash: "hash-1", Removed: removed}},
+			},
+			Outbox: []*store.OutboxEntry{{Notifier: "webhook", Tx: tx}},
+		}
+	}
+	outbox := func() []bool {
+		entries, err := s.GetOutboxEntries(ctx, "webhook", 10)
+		require.NoError(t, err)
+		removed := make([]bool, 0, len(entries))
+		for entry := range slices.Values(entries) {
+			removed = append(removed, entry.Tx.Removed)
+		}
+		return removed
+	}
+
+	// a block written again, e.g. retried after timing out, is recorded and queued once
+	require.NoError(t, s.InsertBlock(ctx, block(false)))
+	require.NoError(t, s.InsertBlock(ctx, block(false)))
+	events, err := s.GetEvents(ctx, contract)
+	require.NoError(t, err)
+	assert.Len(t, events, 1)
+	assert.Equal(t, []bool{false}, outbox())
+
+	require.NoError(t, s.RemoveBlock(ctx, block(true)))
+	require.NoError(t, s.RemoveBlock(ctx, block(true)))
+	assert.Equal(t, []bool{false, true}, outbox())
+
+	// a block inserted back after being orphaned is queued again
+	require.NoError(t, s.InsertBlock(ctx, block(false)))
+	assert.Equal(t, []bool{false, true, false}, outbox())
+}
+
+func TestInsertBlockReorgedSameTransaction(t *testing.T) {
+	const addr = "0xaa"
+	ctx := context.Background()
+	s := memdb.NewTxStore()
+	block := func(hash string, removed bool) *store.Block {
+		tx := &store.TxRecord{Hash: "0xt", From: addr, Value: big.NewInt(1), BlockNumber: 2, BlockHash: hash, BlockTimestamp: 7200, Removed: removed}
+		return &store.Block{
+			Number:    2,
+			Hash:      hash,
+			AddrToTxs: map[string][]*store.TxRecord{addr: {tx}},
+			AddrToTokenTransfers: map[string][]*store.TokenTransferRecord{
+				addr: {{TxHash: "0xt", LogIndex: 0, BlockNumber: 2, BlockHash: hash, Removed: removed}},
+			},
+			Outbox: []*store.OutboxEntry{{Notifier: "webhook", Tx: tx}},
+		}
+	}
+	records := func() []string {
+		txs, err := s.GetTransactions(ctx, addr)
+		require.NoError(t, err)
+		transfers, err := s.GetTokenTransfers(ctx, addr)
+		require.NoError(t, err)
+		var records []string
+		for tx := range slices.Values(txs) {
+			records = append(records, fmt.Sprintf("tx %s removed=%t", tx.BlockHash, tx.Removed))
+		}
+		for transfer := range slices.Values(transfers) {
+			records = append(records, fmt.Sprintf("transfer %s removed=%t", transfer.BlockHash, transfer.Removed))
+		}
+		return records
+	}
+
+	// the canonical block replacing the orphaned one includes the same transaction, which is recorded again
+	require.NoError(t, s.InsertBlock(ctx, block("h2", false)))
+	require.NoError(t, s.RemoveBlock(ctx, block("h2", true)))
+	require.NoError(t, s.InsertBlock(ctx, block("h2b", false)))
+	assert.Equal(t, []string{
+		"tx h2 removed=true",
+		"tx h2b removed=false",
+		"transfer h2 removed=true",
+		"transfer h2b removed=false",
+	}, records())
+
+	stats, err := s.GetAddressStats(ctx, addr)
+	require.NoError(t, err)
+	assert.Equal(t, int64(1), stats.Rolling.TxCount)
+
+	entries, err := s.GetOutboxEntries(ctx, "webhook", 10)
+	require.NoError(t, err)
+	require.Len(t, entries, 3)
+	assert.Equal(t, "h2b", entries[2].Tx.BlockHash)
+}
@@ -0,0 +1,73 @@
+package memdb
+
+import (
+	"context"
+	"maps"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+// UsageStore keeps a record of the usage of the tenants.
+type UsageStore struct {
+	tenantToUsage map[string]*store.TenantUsage
+	mu            sync.RWMutex
+}
+
+func NewUsageStore() *UsageStore {
+	return &UsageStore{
+		tenantToUsage: make(map[string]*store.TenantUsage),
+	}
+}
+
+// IncrementRequests counts an API request served for the tenant.
+func (s *UsageStore) IncrementRequests(_ context.Context, tenant string) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.usage(tenant).Requests++
+	return nil
+}
+
+// IncrementDeliveries counts n transactions delivered to the tenant by the given channel.
+func (s *UsageStore) IncrementDeliveries(_ context.Context, tenant, channel string, n int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.usage(tenant).Deliveries[channel] += n
+	return nil
+}
+
+// usage returns the usage of the tenant, adding it if it's the first. It must be called with the lock held.
+func (s *UsageStore) usage(tenant string) *store.TenantUsage {
+	usage, ok := s.tenantToUsage[tenant]
+	if !ok {
+		usage = &store.TenantUsage{
+			Tenant:     tenant,
+			Deliveries: make(map[string]int64),
+		}
+		s.tenantToUsage[tenant] = usage
+	}
+	return usage
+}
+
+// GetUsage returns a copy of the usage of every tenant, ordered by tenant.
+func (s *UsageStore) GetUsage(_ context.Context) ([]*store.TenantUsage, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	usages := make([]*store.TenantUsage, 0, len(s.tenantToUsage))
+	for usage := range maps.Values(s.tenantToUsage) {
+		usages = append(usages, &store.TenantUsage{
+			Tenant:     usage.Tenant,
+			Requests:   usage.Requests,
+			Deliveries: maps.Clone(usage.Deliveries),
+		})
+	}
+	slices.SortFunc(usages, func(a, b *store.TenantUsage) int {
+		return strings.Compare(a.Tenant, b.Tenant)
+	})
+	return usages, nil
+}
@@ -0,0 +1,41 @@
+package memdb_test
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+)
+
+func TestUsageStore(t *testing.T) {
+	ctx := context.Background()
+	s := memdb.NewUsageStore()
+
+	usages, err := s.GetUsage(ctx)
+	require.NoError(t, err)
+	assert.Empty(t, usages)
+
+	require.NoError(t, s.IncrementRequests(ctx, "globex"))
+	require.NoError(t, s.IncrementRequests(ctx, "acme"))
+	require.NoError(t, s.IncrementRequests(ctx, "acme"))
+	require.NoError(t, s.IncrementDeliveries(ctx, "acme", "webhook", 3))
+	require.NoError(t, s.IncrementDeliveries(ctx, "acme", "grpc", 1))
+	require.NoError(t, s.IncrementDeliveries(ctx, "acme", "webhook", 2))
+
+	usages, err = s.GetUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, []*store.TenantUsage{
+		{Tenant: "acme", Requests: 2, Deliveries: map[string]int64{"webhook": 5, "grpc": 1}},
+		{Tenant: "globex", Requests: 1, Deliveries: map[string]int64{}},
+	}, usages)
+
+	// the returned usage is a copy
+	usages[0].Deliveries["webhook"] = 100
+	usages, err = s.GetUsage(ctx)
+	require.NoError(t, err)
+	assert.Equal(t, int64(5), usages[0].Deliveries["webhook"])
+}
@@ -0,0 +1,115 @@
+package store
+
+import (
+	"fmt"
+	"math/big"
+	"slices"
+	"time"
+)
+
+// NotificationDelivery is how the notifications of a subscription are delivered.
+type NotificationDelivery string
+
+const (
+	// NotificationDeliveryImmediate delivers the notifications as soon as their transactions are recorded.
+	NotificationDeliveryImmediate NotificationDelivery = "immediate"
+	// NotificationDeliveryDigest holds the notifications back to deliver them together at the next digest.
+	NotificationDeliveryDigest NotificationDelivery = "digest"
+)
+
+// NotificationPreferences controls how the recorded transactions of a subscription are notified, what's recorded
+// being left unchanged.
+type NotificationPreferences struct {
+	// Channels, if set, are the names of the notifiers the transactions are notified by, e.g. "webhook" or "email".
+	Channels []string `json:"channels,omitempty"`
+	// MinValue, if set, is the minimum value in wei a recorded transaction must transfer to be notified.
+	MinValue *big.Int `json:"minValue,omitempty"`
+	// QuietHours, if set, is a daily window the notifications are held back during, delivered once it ends.
+	QuietHours *QuietHours `json:"quietHours,omitempty"`
+	// Delivery defaults to NotificationDeliveryImmediate if empty.
+	Delivery NotificationDelivery `json:"delivery,omitempty"`
+}
+
+// Notifies reports whether the transaction is notified by the given notifier. Nil preferences notify everything.
+func (p *NotificationPreferences) Notifies(channel string, tx *TxRecord) bool {
+	if p == nil {
+		return true
+	}
+	if len(p.Channels) > 0 && !slices.Contains(p.Channels, channel) {
+		return false
+	}
+	return !belowMinValue(tx.Value, p.MinValue)
+}
+
+// ReleaseAt returns the time a notification made at now is delivered at: now for immediate delivery, the next
+// multiple of the digest interval for digest delivery, postponed to the end of the quiet hours it falls in if any.
+func (p *NotificationPreferences) ReleaseAt(now time.Time, digestInterval time.Duration) time.Time {
+	if p == nil {
+		return now
+	}
+	releaseAt := now
+	if p.Delivery == NotificationDeliveryDigest && digestInterval > 0 {
+		releaseAt = now.Truncate(digestInterval).Add(digestInterval)
+	}
+	if p.QuietHours != nil {
+		releaseAt = p.QuietHours.Until(releaseAt)
+	}
+	return releaseAt
+}
+
+// QuietHours is a daily window in UTC between two "HH:MM" times, wrapping around midnight if it ends before it starts.
+type QuietHours struct {
+	Start string `json:"start"`
+	End   string `json:"end"`
+}
+
+// Validate checks the start and end are "HH:MM" times, and different.
+func (q *QuietHours) Validate() error {
+	start, err := parseClock(q.Start)
+	if err != nil {
+		return fmt.Errorf("invalid start: %w", err)
+	}
+	end, err := parseClock(q.End)
+	if err != nil {
+		return fmt.Errorf("invalid end: %w", err)
+	}
+	if start == end {
+		return fmt.Errorf("start and end must be different")
+	}
+	return nil
+}
+
+// Until returns the end of the quiet window t falls in, or t if it's outside of the quiet hours. Invalid quiet hours
+// are never quiet.
+func (q *QuietHours) Until(t time.Time) time.Time {
+	if q.Validate() != nil {
+		return t
+	}
+	start, _ := parseClock(q.Start)
+	end, _ := parseClock(q.End)
+
+	t = t.UTC()
+	day := t.Truncate(24 * time.Hour)
+	offset := t.Sub(day)
+	switch {
+	case start < end:
+		if offset >= start && offset < end {
+			return day.Add(end)
+		}
+	case offset >= start:
+		// in the evening part of a window wrapping around midnight, it ends the next day
+		return day.Add(24*time.Hour + end)
+	case offset < end:
+		return day.Add(end)
+	}
+	return t
+}
+
+// parseClock returns the time of day of the given "HH:MM" time as the duration since midnight.
+func parseClock(clock string) (time.Duration, error) {
+	t, err := time.Parse("15:04", clock)
+	if err != nil {
+		return 0, fmt.Errorf("expected an HH:MM time: %q", clock)
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
@@ -0,0 +1,22 @@
+package timeout
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+)
+
+// metrics holds the metrics of the wrappers, registered with the registerer set by WithRegisterer.
+type metrics struct {
+	timeouts *prometheus.CounterVec
+}
+
+func newMetrics(reg prometheus.Registerer) *metrics {
+	auto := custompromauto.With(reg)
+	return &metrics{
+		timeouts: auto.NewCounterVec(prometheus.CounterOpts{
+			Name: "ethtxparser_store_timeouts_total",
+			Help: "Total number of store operations aborted due to exceeding their deadline",
+		}, []string{"operation"}),
+	}
+}
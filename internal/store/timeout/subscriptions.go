@@ -0,0 +1,114 @@
+package timeout
+
+import (
+	"context"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type SubscriptionStore interface {
+	AddSubscription(ctx context.Context, sub *store.Subscription) error
+	GetSubscription(ctx context.Context, addr string) (*store.Subscription, error)
+	GetSubscriptions(ctx context.Context) ([]string, error)
+	SearchSubscriptions(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error)
+	GetSubscriptionsBatch(ctx context.Context, addrs []string) (map[string]*store.Subscription, error)
+	IsSubscribed(ctx context.Context, addr string) (bool, error)
+	RemoveSubscription(ctx context.Context, addr string) error
+	WatchSubscriptions(ctx context.Context) <-chan []*store.SubscriptionChange
+	OnSubscribe(f func(addr string))
+	AddEventSubscription(ctx context.Context, sub *store.EventSubscription) error
+	GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error)
+	ListEventSubscriptions(ctx context.Context) ([]*store.EventSubscription, error)
+}
+
+// SubscriptionStoreWrapper applies per-operation deadlines around every call to the underlying SubscriptionStore.
+type SubscriptionStoreWrapper struct {
+	subsStore SubscriptionStore
+	cfg       *config
+	metrics   *metrics
+}
+
+func NewSubscriptionStore(subsStore SubscriptionStore, opts ...Option) *SubscriptionStoreWrapper {
+	cfg := newConfig(opts)
+	return &SubscriptionStoreWrapper{
+		subsStore: subsStore,
+		cfg:       cfg,
+		metrics:   newMetrics(cfg.registerer),
+	}
+}
+
+// AddSubscription calls the underlying AddSubscription using the write timeout.
+func (w *SubscriptionStoreWrapper) AddSubscription(ctx context.Context, sub *store.Subscription) error {
+	return exec(ctx, w.cfg.health, w.metrics, "AddSubscription", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.subsStore.AddSubscription(ctx, sub)
+	})
+}
+
+// GetSubscription calls the underlying GetSubscription using the read timeout.
+func (w *SubscriptionStoreWrapper) GetSubscription(ctx context.Context, addr string) (*store.Subscription, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetSubscription", w.cfg.readTimeout, func(ctx context.Context) (*store.Subscription, error) {
+		return w.subsStore.GetSubscription(ctx, addr)
+	})
+}
+
+// GetSubscriptions calls the underlying GetSubscriptions using the read timeout.
+func (w *SubscriptionStoreWrapper) GetSubscriptions(ctx context.Context) ([]string, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetSubscriptions", w.cfg.readTimeout, w.subsStore.GetSubscriptions)
+}
+
+// SearchSubscriptions calls the underlying SearchSubscriptions using the read timeout.
+func (w *SubscriptionStoreWrapper) SearchSubscriptions(ctx context.Context, query *store.SubscriptionQuery) ([]*store.Subscription, error) {
+	return call(ctx, w.cfg.health, w.metrics, "SearchSubscriptions", w.cfg.readTimeout, func(ctx context.Context) ([]*store.Subscription, error) {
+		return w.subsStore.SearchSubscriptions(ctx, query)
+	})
+}
+
+// GetSubscriptionsBatch calls the underlying GetSubscriptionsBatch using the read timeout.
+func (w *SubscriptionStoreWrapper) GetSubscriptionsBatch(ctx context.Context, addrs []string) (map[string]*store.Subscription, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetSubscriptionsBatch", w.cfg.readTimeout, func(ctx context.Context) (map[string]*store.Subscription, error) {
+		return w.subsStore.GetSubscriptionsBatch(ctx, addrs)
+	})
+}
+
+// IsSubscribed calls the underlying IsSubscribed using the read timeout.
+func (w *SubscriptionStoreWrapper) IsSubscribed(ctx context.Context, addr string) (bool, error) {
+	return call(ctx, w.cfg.health, w.metrics, "IsSubscribed", w.cfg.readTimeout, func(ctx context.Context) (bool, error) {
+		return w.subsStore.IsSubscribed(ctx, addr)
+	})
+}
+
+// RemoveSubscription calls the underlying RemoveSubscription using the write timeout.
+func (w *SubscriptionStoreWrapper) RemoveSubscription(ctx context.Context, addr string) error {
+	return exec(ctx, w.cfg.health, w.metrics, "RemoveSubscription", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.subsStore.RemoveSubscription(ctx, addr)
+	})
+}
+
+// WatchSubscriptions calls the underlying WatchSubscriptions as is, watching isn't subject to a deadline.
+func (w *SubscriptionStoreWrapper) WatchSubscriptions(ctx context.Context) <-chan []*store.SubscriptionChange {
+	return w.subsStore.WatchSubscriptions(ctx)
+}
+
+// OnSubscribe calls the underlying OnSubscribe as is.
+func (w *SubscriptionStoreWrapper) OnSubscribe(f func(addr string)) {
+	w.subsStore.OnSubscribe(f)
+}
+
+// AddEventSubscription calls the underlying AddEventSubscription using the write timeout.
+func (w *SubscriptionStoreWrapper) AddEventSubscription(ctx context.Context, sub *store.EventSubscription) error {
+	return exec(ctx, w.cfg.health, w.metrics, "AddEventSubscription", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.subsStore.AddEventSubscription(ctx, sub)
+	})
+}
+
+// GetEventSubscriptions calls the underlying GetEventSubscriptions using the read timeout.
+func (w *SubscriptionStoreWrapper) GetEventSubscriptions(ctx context.Context, contract string) ([]*store.EventSubscription, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetEventSubscriptions", w.cfg.readTimeout, func(ctx context.Context) ([]*store.EventSubscription, error) {
+		return w.subsStore.GetEventSubscriptions(ctx, contract)
+	})
+}
+
+// ListEventSubscriptions calls the underlying ListEventSubscriptions using the read timeout.
+func (w *SubscriptionStoreWrapper) ListEventSubscriptions(ctx context.Context) ([]*store.EventSubscription, error) {
+	return call(ctx, w.cfg.health, w.metrics, "ListEventSubscriptions", w.cfg.readTimeout, w.subsStore.ListEventSubscriptions)
+}
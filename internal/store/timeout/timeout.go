@@ -0,0 +1,128 @@
+package timeout
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+const (
+	// DefaultReadTimeout is the default deadline applied to store read operations.
+	DefaultReadTimeout = time.Second * 2
+	// DefaultWriteTimeout is the default deadline applied to store write operations.
+	DefaultWriteTimeout = time.Second * 5
+)
+
+type config struct {
+	readTimeout  time.Duration
+	writeTimeout time.Duration
+	health       *health.Component
+	registerer   prometheus.Registerer
+}
+
+type Option func(*config)
+
+// WithReadTimeout sets the deadline applied to read operations. A zero value disables the deadline.
+func WithReadTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d >= 0 {
+			c.readTimeout = d
+		}
+	}
+}
+
+// WithWriteTimeout sets the deadline applied to write operations. A zero value disables the deadline.
+func WithWriteTimeout(d time.Duration) Option {
+	return func(c *config) {
+		if d >= 0 {
+			c.writeTimeout = d
+		}
+	}
+}
+
+// WithHealth reports the health of the store to the given component, failing on the operations that fail or time out.
+// Records not found aren't failures.
+func WithHealth(component *health.Component) Option {
+	return func(c *config) {
+		c.health = component
+	}
+}
+
+// WithRegisterer sets the registerer the metrics of the wrapper are registered with, the custom registry by default.
+func WithRegisterer(reg prometheus.Registerer) Option {
+	return func(c *config) {
+		c.registerer = reg
+	}
+}
+
+func newConfig(opts []Option) *config {
+	cfg := &config{
+		readTimeout:  DefaultReadTimeout,
+		writeTimeout: DefaultWriteTimeout,
+		registerer:   custompromauto.Registry(),
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	return cfg
+}
+
+// call runs f with the given deadline, reporting its failure to the health component. The call is made in a separate
+// goroutine so that a backend ignoring context cancellation can't block the caller beyond the deadline; its late
+// result is simply discarded. Writes are abandoned the same way, the stores' writes being idempotent so that one
+// committing after it's reported as failed is harmless once retried.
+func call[T any](ctx context.Context, component *health.Component, m *metrics, op string, timeout time.Duration, f func(ctx context.Context) (T, error)) (T, error) {
+	val, err := callWithTimeout(ctx, m, op, timeout, f)
+	if errors.Is(err, store.ErrNotFound) {
+		component.Report(nil)
+	} else {
+		component.Report(err)
+	}
+	return val, err
+}
+
+func callWithTimeout[T any](ctx context.Context, m *metrics, op string, timeout time.Duration, f func(ctx context.Context) (T, error)) (T, error) {
+	if timeout <= 0 {
+		return f(ctx)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	type result struct {
+		val T
+		err error
+	}
+	done := make(chan result, 1)
+	go func() {
+		val, err := f(ctx)
+		done <- result{val: val, err: err}
+	}()
+
+	select {
+	case <-ctx.Done():
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			m.timeouts.WithLabelValues(op).Inc()
+		}
+		var zero T
+		return zero, fmt.Errorf("store operation %q: %w", op, ctx.Err())
+	case res := <-done:
+		return res.val, res.err
+	}
+}
+
+// exec is the same as call but for operations that only return an error.
+func exec(ctx context.Context, component *health.Component, m *metrics, op string, timeout time.Duration, f func(ctx context.Context) error) error {
+	_, err := call(ctx, component, m, op, timeout, func(ctx context.Context) (struct{}, error) {
+		return struct{}{}, f(ctx)
+	})
+	return err
+}
@@ -0,0 +1,106 @@
+package timeout_test
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/timeout"
+	"github.com/hedisam/ethtxparser/internal/store/timeout/mocks"
+)
+
+//go:generate moq -out mocks/tx_store.go -pkg mocks -skip-ensure . TxStore
+
+func TestTxStoreWrapper(t *testing.T) {
+	tests := map[string]struct {
+		delay time.Duration
+		// honoursDeadline makes the backend return once the context is done, instead of ignoring it
+		honoursDeadline bool
+		storeErr        error
+		opts            []timeout.Option
+		errContains     string
+		expectedHealthy bool
+	}{
+		"completes within deadline": {
+			delay:           0,
+			opts:            []timeout.Option{timeout.WithWriteTimeout(time.Second)},
+			expectedHealthy: true,
+		},
+		"backend exceeds write deadline": {
+			delay:           time.Second,
+			honoursDeadline: true,
+			opts:            []timeout.Option{timeout.WithWriteTimeout(time.Millisecond * 10)},
+			errContains:     context.DeadlineExceeded.Error(),
+		},
+		"stuck backend is abandoned at the write deadline": {
+			delay:       time.Second,
+			opts:        []timeout.Option{timeout.WithWriteTimeout(time.Millisecond * 10)},
+			errContains: context.DeadlineExceeded.Error(),
+		},
+		"zero timeout disables the deadline": {
+			delay:           time.Millisecond * 20,
+			opts:            []timeout.Option{timeout.WithWriteTimeout(0)},
+			expectedHealthy: true,
+		},
+		"not found isn't a failure": {
+			storeErr:        store.ErrNotFound,
+			opts:            []timeout.Option{timeout.WithWriteTimeout(time.Second)},
+			errContains:     store.ErrNotFound.Error(),
+			expectedHealthy: true,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			txStoreMock := &mocks.TxStoreMock{
+				InsertBlockFunc: func(ctx context.Context, block *store.Block) error {
+					if !test.honoursDeadline {
+						time.Sleep(test.delay)
+						return test.storeErr
+					}
+					select {
+					case <-time.After(test.delay):
+						return test.storeErr
+					case <-ctx.Done():
+						return ctx.Err()
+					}
+				},
+			}
+
+			component := health.NewRegistry(t.Name()).Component(health.Store)
+			w := timeout.NewTxStore(txStoreMock, append(test.opts, timeout.WithHealth(component))...)
+			err := w.InsertBlock(context.Background(), &store.Block{Number: 1})
+			assert.Equal(t, 1, len(txStoreMock.InsertBlockCalls()))
+			assert.Equal(t, test.expectedHealthy, component.Status().Healthy)
+			if test.errContains != "" {
+				require.Error(t, err)
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTxStoreWrapperAbandonsStuckReads(t *testing.T) {
+	release := make(chan struct{})
+	defer close(release)
+	txStoreMock := &mocks.TxStoreMock{
+		GetCurrentBlockNumberFunc: func(ctx context.Context) (int64, error) {
+			// simulate a backend that doesn't honour context cancellation
+			<-release
+			return 1, nil
+		},
+	}
+
+	component := health.NewRegistry(t.Name()).Component(health.Store)
+	w := timeout.NewTxStore(txStoreMock, timeout.WithReadTimeout(time.Millisecond*10), timeout.WithHealth(component))
+	_, err := w.GetCurrentBlockNumber(context.Background())
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.False(t, component.Status().Healthy)
+}
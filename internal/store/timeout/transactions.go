@@ -0,0 +1,167 @@
+package timeout
+
+import (
+	"context"
+	"math/big"
+
+	"github.com/hedisam/ethtxparser/internal/store"
+)
+
+type TxStore interface {
+	InsertBlock(ctx context.Context, block *store.Block) error
+	InsertTransactions(ctx context.Context, addr string, txs []*store.TxRecord) error
+	RemoveBlock(ctx context.Context, block *store.Block) error
+	GetTransactions(ctx context.Context, addr string) ([]*store.TxRecord, error)
+	GetTokenTransfers(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error)
+	GetEvents(ctx context.Context, contract string) ([]*store.EventRecord, error)
+	GetCurrentBlockNumber(ctx context.Context) (int64, error)
+	GetOutboxEntries(ctx context.Context, notifier string, limit int) ([]*store.OutboxEntry, error)
+	AckOutboxEntries(ctx context.Context, notifier string, ids []uint64) error
+	InsertDeadLetter(ctx context.Context, deadLetter *store.DeadLetter) error
+	GetDeadLetters(ctx context.Context) ([]*store.DeadLetter, error)
+	GetAddressStats(ctx context.Context, addr string) (*store.AddressStats, error)
+	PurgeAddress(ctx context.Context, addr string) (int, error)
+	ExtractRecords(ctx context.Context, beforeBlock int64) (*store.Records, error)
+	RestoreRecords(ctx context.Context, records *store.Records) (int, error)
+	GetRawTransaction(ctx context.Context, hash string) (string, error)
+	InsertRawTransaction(ctx context.Context, hash, raw string) error
+	GetTokenBalance(ctx context.Context, token, holder string) (*big.Int, error)
+}
+
+// TxStoreWrapper applies per-operation deadlines around every call to the underlying TxStore.
+type TxStoreWrapper struct {
+	txStore TxStore
+	cfg     *config
+	metrics *metrics
+}
+
+func NewTxStore(txStore TxStore, opts ...Option) *TxStoreWrapper {
+	cfg := newConfig(opts)
+	return &TxStoreWrapper{
+		txStore: txStore,
+		cfg:     cfg,
+		metrics: newMetrics(cfg.registerer),
+	}
+}
+
+// InsertBlock calls the underlying InsertBlock using the write timeout.
+func (w *TxStoreWrapper) InsertBlock(ctx context.Context, block *store.Block) error {
+	return exec(ctx, w.cfg.health, w.metrics, "InsertBlock", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.InsertBlock(ctx, block)
+	})
+}
+
+// RemoveBlock calls the underlying RemoveBlock using the write timeout.
+func (w *TxStoreWrapper) RemoveBlock(ctx context.Context, block *store.Block) error {
+	return exec(ctx, w.cfg.health, w.metrics, "RemoveBlock", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.RemoveBlock(ctx, block)
+	})
+}
+
+// InsertTransactions calls the underlying InsertTransactions using the write timeout.
+func (w *TxStoreWrapper) InsertTransactions(ctx context.Context, addr string, txs []*store.TxRecord) error {
+	return exec(ctx, w.cfg.health, w.metrics, "InsertTransactions", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.InsertTransactions(ctx, addr, txs)
+	})
+}
+
+// GetTransactions calls the underlying GetTransactions using the read timeout.
+func (w *TxStoreWrapper) GetTransactions(ctx context.Context, addr string) ([]*store.TxRecord, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetTransactions", w.cfg.readTimeout, func(ctx context.Context) ([]*store.TxRecord, error) {
+		return w.txStore.GetTransactions(ctx, addr)
+	})
+}
+
+// GetTokenTransfers calls the underlying GetTokenTransfers using the read timeout.
+func (w *TxStoreWrapper) GetTokenTransfers(ctx context.Context, addr string) ([]*store.TokenTransferRecord, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetTokenTransfers", w.cfg.readTimeout, func(ctx context.Context) ([]*store.TokenTransferRecord, error) {
+		return w.txStore.GetTokenTransfers(ctx, addr)
+	})
+}
+
+// GetEvents calls the underlying GetEvents using the read timeout.
+func (w *TxStoreWrapper) GetEvents(ctx context.Context, contract string) ([]*store.EventRecord, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetEvents", w.cfg.readTimeout, func(ctx context.Context) ([]*store.EventRecord, error) {
+		return w.txStore.GetEvents(ctx, contract)
+	})
+}
+
+// GetCurrentBlockNumber calls the underlying GetCurrentBlockNumber using the read timeout.
+func (w *TxStoreWrapper) GetCurrentBlockNumber(ctx context.Context) (int64, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetCurrentBlockNumber", w.cfg.readTimeout, w.txStore.GetCurrentBlockNumber)
+}
+
+// GetOutboxEntries calls the underlying GetOutboxEntries using the read timeout.
+func (w *TxStoreWrapper) GetOutboxEntries(ctx context.Context, notifier string, limit int) ([]*store.OutboxEntry, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetOutboxEntries", w.cfg.readTimeout, func(ctx context.Context) ([]*store.OutboxEntry, error) {
+		return w.txStore.GetOutboxEntries(ctx, notifier, limit)
+	})
+}
+
+// AckOutboxEntries calls the underlying AckOutboxEntries using the write timeout.
+func (w *TxStoreWrapper) AckOutboxEntries(ctx context.Context, notifier string, ids []uint64) error {
+	return exec(ctx, w.cfg.health, w.metrics, "AckOutboxEntries", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.AckOutboxEntries(ctx, notifier, ids)
+	})
+}
+
+// InsertDeadLetter calls the underlying InsertDeadLetter using the write timeout.
+func (w *TxStoreWrapper) InsertDeadLetter(ctx context.Context, deadLetter *store.DeadLetter) error {
+	return exec(ctx, w.cfg.health, w.metrics, "InsertDeadLetter", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.InsertDeadLetter(ctx, deadLetter)
+	})
+}
+
+// GetDeadLetters calls the underlying GetDeadLetters using the read timeout.
+func (w *TxStoreWrapper) GetDeadLetters(ctx context.Context) ([]*store.DeadLetter, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetDeadLetters", w.cfg.readTimeout, w.txStore.GetDeadLetters)
+}
+
+// GetAddressStats calls the underlying GetAddressStats using the read timeout.
+func (w *TxStoreWrapper) GetAddressStats(ctx context.Context, addr string) (*store.AddressStats, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetAddressStats", w.cfg.readTimeout, func(ctx context.Context) (*store.AddressStats, error) {
+		return w.txStore.GetAddressStats(ctx, addr)
+	})
+}
+
+// PurgeAddress calls the underlying PurgeAddress using the write timeout.
+func (w *TxStoreWrapper) PurgeAddress(ctx context.Context, addr string) (int, error) {
+	return call(ctx, w.cfg.health, w.metrics, "PurgeAddress", w.cfg.writeTimeout, func(ctx context.Context) (int, error) {
+		return w.txStore.PurgeAddress(ctx, addr)
+	})
+}
+
+// GetRawTransaction calls the underlying GetRawTransaction using the read timeout.
+func (w *TxStoreWrapper) GetRawTransaction(ctx context.Context, hash string) (string, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetRawTransaction", w.cfg.readTimeout, func(ctx context.Context) (string, error) {
+		return w.txStore.GetRawTransaction(ctx, hash)
+	})
+}
+
+// InsertRawTransaction calls the underlying InsertRawTransaction using the write timeout.
+func (w *TxStoreWrapper) InsertRawTransaction(ctx context.Context, hash, raw string) error {
+	return exec(ctx, w.cfg.health, w.metrics, "InsertRawTransaction", w.cfg.writeTimeout, func(ctx context.Context) error {
+		return w.txStore.InsertRawTransaction(ctx, hash, raw)
+	})
+}
+
+// ExtractRecords calls the underlying ExtractRecords using the write timeout.
+func (w *TxStoreWrapper) ExtractRecords(ctx context.Context, beforeBlock int64) (*store.Records, error) {
+	return call(ctx, w.cfg.health, w.metrics, "ExtractRecords", w.cfg.writeTimeout, func(ctx context.Context) (*store.Records, error) {
+		return w.txStore.ExtractRecords(ctx, beforeBlock)
+	})
+}
+
+// RestoreRecords calls the underlying RestoreRecords using the write timeout.
+func (w *TxStoreWrapper) RestoreRecords(ctx context.Context, records *store.Records) (int, error) {
+	return call(ctx, w.cfg.health, w.metrics, "RestoreRecords", w.cfg.writeTimeout, func(ctx context.Context) (int, error) {
+		return w.txStore.RestoreRecords(ctx, records)
+	})
+}
+
+// GetTokenBalance calls the underlying GetTokenBalance using the read timeout.
+func (w *TxStoreWrapper) GetTokenBalance(ctx context.Context, token, holder string) (*big.Int, error) {
+	return call(ctx, w.cfg.health, w.metrics, "GetTokenBalance", w.cfg.readTimeout, func(ctx context.Context) (*big.Int, error) {
+		return w.txStore.GetTokenBalance(ctx, token, holder)
+	})
+}
@@ -1,24 +1,416 @@
 package store
 
-import "errors"
+import (
+	"errors"
+	"maps"
+	"math/big"
+	"slices"
+	"strings"
+	"time"
+)
 
 var (
 	// ErrNotFound is returned when an item in store is not found.
 	ErrNotFound = errors.New("not found")
 )
 
+// SubscriptionMode selects which of the recorded transactions of a subscription are listed.
+type SubscriptionMode string
+
+const (
+	// SubscriptionModeLive lists only the transactions from the block the address was subscribed at onwards.
+	SubscriptionModeLive SubscriptionMode = "live"
+	// SubscriptionModeHistory lists the transactions recorded before the subscription too, such as backfilled ones.
+	SubscriptionModeHistory SubscriptionMode = "history"
+)
+
+// Subscription is a subscription to the transactions of an address.
+type Subscription struct {
+	Address string `json:"address"`
+	// StartBlock is the first block indexed after the address was subscribed.
+	StartBlock int64 `json:"startBlock"`
+	// Mode defaults to SubscriptionModeLive if empty.
+	Mode SubscriptionMode `json:"mode,omitempty"`
+	// MinValue, if set, is the minimum value in wei a transaction must transfer to be recorded.
+	MinValue *big.Int `json:"minValue,omitempty"`
+	// WebhookURL, if set, is the URL recorded transactions of the address are posted to.
+	WebhookURL string `json:"webhookUrl,omitempty"`
+	// WebhookSecret is the key webhook payloads are signed with.
+	WebhookSecret string `json:"webhookSecret,omitempty"`
+	// WebhookKeyID identifies WebhookSecret in the webhook payloads, e.g. "v2", bumped whenever the secret changes.
+	WebhookKeyID string `json:"webhookKeyId,omitempty"`
+	// PreviousWebhookKey, if set, is the key WebhookSecret was rotated from, still signing the webhook payloads
+	// alongside it until it expires so receivers can switch to the new one.
+	PreviousWebhookKey *WebhookKey `json:"previousWebhookKey,omitempty"`
+	// ChatChannel, if set, is the Slack, Discord or Telegram channel recorded transactions of the address are sent to.
+	ChatChannel string `json:"chatChannel,omitempty"`
+	// Email, if set, is the email address recorded transactions of the address are sent to.
+	Email string `json:"email,omitempty"`
+	// Priority marks the address as hot, its transactions being notified as soon as they're matched, ahead of the
+	// other notifications and of the commit of their block.
+	Priority bool `json:"priority,omitempty"`
+	// SkipFailed excludes reverted transactions, only possible if their status is known.
+	SkipFailed bool `json:"skipFailed,omitempty"`
+	// Filter, if set, is a boolean expression over the FilterVars a transaction must satisfy to be recorded, e.g.
+	// `value > 1e18 && to == "0x..."`.
+	Filter string `json:"filter,omitempty"`
+	// Label, if set, is a free-form label of the address the subscriptions can be searched by, e.g. "exchange".
+	Label string `json:"label,omitempty"`
+	// Preferences, if set, controls how the recorded transactions of the address are notified.
+	Preferences *NotificationPreferences `json:"preferences,omitempty"`
+	// TrackHolders tracks the balances of the holders of the address, an ERC-20 token contract, from its transfers.
+	TrackHolders bool `json:"trackHolders,omitempty"`
+	// Tenant, if set, is the holder of the API key the address was subscribed with, whose usage the subscription and
+	// the deliveries of its transactions count towards.
+	Tenant string `json:"tenant,omitempty"`
+}
+
+// WebhookKey is a rotated webhook secret, identified by its ID in the webhook payloads.
+type WebhookKey struct {
+	ID     string `json:"id"`
+	Secret string `json:"secret"`
+	// ExpiresAt is the end of the grace window the key keeps signing the webhook payloads in.
+	ExpiresAt time.Time `json:"expiresAt"`
+}
+
+// Active reports whether the key still signs the webhook payloads at the given time. A nil key is never active.
+func (k *WebhookKey) Active(now time.Time) bool {
+	return k != nil && now.Before(k.ExpiresAt)
+}
+
+// TenantUsage is the usage of a shared deployment by a tenant, the holder of an API key.
+type TenantUsage struct {
+	Tenant string
+	// Requests is the number of API requests served.
+	Requests int64
+	// Deliveries is the number of transactions delivered, by channel, e.g. "webhook" or "grpc".
+	Deliveries map[string]int64
+}
+
+// SubscriptionChangeType is the kind of change made to the subscription of an address.
+type SubscriptionChangeType string
+
+const (
+	// SubscriptionAdded is an address subscribed, or its subscription replaced.
+	SubscriptionAdded SubscriptionChangeType = "added"
+	// SubscriptionRemoved is an address unsubscribed.
+	SubscriptionRemoved SubscriptionChangeType = "removed"
+)
+
+// SubscriptionChange is a change made to the subscription of an address, watched by the components keeping the
+// subscriptions in memory to refresh them as soon as it's made.
+type SubscriptionChange struct {
+	Type    SubscriptionChangeType
+	Address string
+	// Subscription is the added subscription, nil if removed.
+	Subscription *Subscription
+}
+
+// SubscriptionQuery filters the subscriptions, its empty fields matching all of them.
+type SubscriptionQuery struct {
+	// AddressPrefix matches the addresses starting with it, given lower-cased and 0x-prefixed.
+	AddressPrefix string
+	// Label matches the subscriptions whose label contains it, regardless of case.
+	Label string
+}
+
+// Matches reports whether the subscription matches the query.
+func (q *SubscriptionQuery) Matches(sub *Subscription) bool {
+	if !strings.HasPrefix(sub.Address, q.AddressPrefix) {
+		return false
+	}
+	return q.Label == "" || strings.Contains(strings.ToLower(sub.Label), strings.ToLower(q.Label))
+}
+
+// Accepts reports whether the transaction passes the subscription filters. A filter expression that doesn't compile
+// accepts no transactions.
+func (s *Subscription) Accepts(tx *TxRecord) bool {
+	if s.SkipFailed && tx.Status == TxStatusFailed {
+		return false
+	}
+	if belowMinValue(tx.Value, s.MinValue) {
+		return false
+	}
+	if s.Filter == "" {
+		return true
+	}
+	filter, err := CompileFilter(s.Filter)
+	if err != nil {
+		return false
+	}
+	return filter.Eval(filterLookup(tx))
+}
+
+// belowMinValue reports whether the value is below the minimum value, if any. Unknown values are taken as zero.
+func belowMinValue(value, minValue *big.Int) bool {
+	if minValue == nil {
+		return false
+	}
+	if value == nil {
+		return minValue.Sign() > 0
+	}
+	return value.Cmp(minValue) < 0
+}
+
+// Includes reports whether the records of the given block are listed for the subscription.
+func (s *Subscription) Includes(blockNumber int64) bool {
+	return s.Mode == SubscriptionModeHistory || blockNumber >= s.StartBlock
+}
+
+// TxStatus is the execution status of a transaction, known only if its receipt was fetched.
+type TxStatus string
+
+const (
+	TxStatusSuccess TxStatus = "success"
+	TxStatusFailed  TxStatus = "failed"
+)
+
+// TxType is the name of the EIP-2718 type of a transaction.
+type TxType string
+
+const (
+	TxTypeLegacy     TxType = "legacy"
+	TxTypeAccessList TxType = "eip2930"
+	TxTypeDynamicFee TxType = "eip1559"
+	TxTypeBlob       TxType = "blob"
+	TxTypeSetCode    TxType = "eip7702"
+)
+
+// txTypes are the TxTypes by EIP-2718 type number.
+var txTypes = []TxType{TxTypeLegacy, TxTypeAccessList, TxTypeDynamicFee, TxTypeBlob, TxTypeSetCode}
+
+// TxTypeOf returns the TxType of the given EIP-2718 type number, or an empty one if unknown.
+func TxTypeOf(number int64) TxType {
+	if number < 0 || number >= int64(len(txTypes)) {
+		return ""
+	}
+	return txTypes[number]
+}
+
+// ParseTxType returns the TxType of the given name, reporting whether it's a known one.
+func ParseTxType(name string) (TxType, bool) {
+	txType := TxType(strings.ToLower(strings.TrimSpace(name)))
+	return txType, slices.Contains(txTypes, txType)
+}
+
+// TxRecord is a recorded transaction. A single record is shared between the transaction lists of all the addresses
+// it was matched for, so it must be treated as immutable once created.
 type TxRecord struct {
-	Hash        string `json:"hash"`
-	From        string `json:"from"`
-	To          string `json:"to"`
+	Hash        string   `json:"hash"`
+	From        string   `json:"from"`
+	To          string   `json:"to"`
+	Value       *big.Int `json:"value,omitempty"`
+	BlockNumber int64    `json:"blockNumber"`
+	BlockHash   string   `json:"blockHash"`
+	Raw         []byte   `json:"-"`
+	// BlockTimestamp is the unix time in seconds the block of the transaction was minted at.
+	BlockTimestamp int64 `json:"blockTimestamp,omitempty"`
+	// ValueUSD is the approximate value of the transaction in USD at the time of its block, if a price is known.
+	ValueUSD string `json:"valueUsd,omitempty"`
+	// Status is empty if the status of the transaction isn't known.
+	Status TxStatus `json:"status,omitempty"`
+	// Type is empty if the type of the transaction isn't known, e.g. for the ones recorded before types were.
+	Type TxType `json:"type,omitempty"`
+	// DecodedInput is the decoded input of the transaction, set only if the ABI of the called contract is known.
+	DecodedInput *DecodedInput `json:"decodedInput,omitempty"`
+	// Removed is set once the block of the transaction is orphaned by a chain reorganisation.
+	Removed bool `json:"removed,omitempty"`
+	// Labels holds the annotations added by indexing hooks, such as fiat prices or compliance tags.
+	Labels map[string]string `json:"labels,omitempty"`
+}
+
+// TxCounters aggregates the recorded transactions of an address.
+type TxCounters struct {
+	TxCount int64 `json:"txCount"`
+	// ValueIn is the total value in wei the address received.
+	ValueIn *big.Int `json:"valueIn"`
+	// ValueOut is the total value in wei the address sent.
+	ValueOut *big.Int `json:"valueOut"`
+}
+
+// DailyTxCounters are the TxCounters of an address for a single UTC day.
+type DailyTxCounters struct {
+	Day time.Time `json:"day"`
+	TxCounters
+}
+
+// AddressStats holds the aggregated transaction counters of an address, by the block time of the transactions.
+type AddressStats struct {
+	// Daily holds the counters of the days the address transacted on, oldest first.
+	Daily []*DailyTxCounters `json:"daily"`
+	// Rolling aggregates the 24 hours up to the latest recorded transaction, at an hourly resolution.
+	Rolling TxCounters `json:"rolling"`
+}
+
+// DecodedInput is the function call a transaction input encodes.
+type DecodedInput struct {
+	Method    string        `json:"method"`
+	Signature string        `json:"signature"`
+	Args      []*DecodedArg `json:"args"`
+}
+
+// DecodedArg is a decoded function call argument. Integers are decimal strings, addresses and byte arrays 0x-prefixed
+// hex strings, arrays slices and tuples maps keyed by component names.
+type DecodedArg struct {
+	Name  string `json:"name"`
+	Type  string `json:"type"`
+	Value any    `json:"value"`
+}
+
+// TokenStandard identifies the token standard a transfer event belongs to.
+type TokenStandard string
+
+const (
+	TokenStandardERC20  TokenStandard = "erc20"
+	TokenStandardERC721 TokenStandard = "erc721"
+)
+
+// TokenTransferRecord is a recorded ERC-20 or ERC-721 Transfer event. Similar to TxRecord, a single record is shared
+// between the sender and recipient lists and must be treated as immutable.
+type TokenTransferRecord struct {
+	TxHash   string        `json:"txHash"`
+	LogIndex int64         `json:"logIndex"`
+	Token    string        `json:"token"`
+	Standard TokenStandard `json:"standard"`
+	From     string        `json:"from"`
+	To       string        `json:"to"`
+	// Amount is the decimal amount transferred for ERC-20 tokens.
+	Amount string `json:"amount,omitempty"`
+	// TokenID is the decimal id of the transferred ERC-721 token.
+	TokenID     string `json:"tokenId,omitempty"`
 	BlockNumber int64  `json:"blockNumber"`
 	BlockHash   string `json:"blockHash"`
-	Raw         []byte `json:"-"`
+	// Removed is set once the block of the transfer is orphaned by a chain reorganisation.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// EventSubscription is a subscription to the events emitted by a contract. Topics are matched positionally against
+// the log topics, where an empty topic matches anything. No topics means every event of the contract is matched.
+type EventSubscription struct {
+	Contract string   `json:"contract"`
+	Topics   []string `json:"topics,omitempty"`
+}
+
+// Matches reports whether the given log topics satisfy the subscription.
+func (s *EventSubscription) Matches(topics []string) bool {
+	if len(s.Topics) > len(topics) {
+		return false
+	}
+	for i, topic := range s.Topics {
+		if topic != "" && !strings.EqualFold(topic, topics[i]) {
+			return false
+		}
+	}
+	return true
+}
+
+// EventRecord is a recorded contract event matched by an EventSubscription.
+type EventRecord struct {
+	Contract string   `json:"contract"`
+	TxHash   string   `json:"txHash"`
+	LogIndex int64    `json:"logIndex"`
+	Topics   []string `json:"topics"`
+	// DataWords holds the non-indexed event data split into 32 bytes words.
+	DataWords   []string `json:"dataWords,omitempty"`
+	BlockNumber int64    `json:"blockNumber"`
+	BlockHash   string   `json:"blockHash"`
+	// Removed is set once the block of the event is orphaned by a chain reorganisation.
+	Removed bool `json:"removed,omitempty"`
+}
+
+// TokenBalanceChange is the net change of the balance of a holder of a token whose holders are tracked, made by the
+// transfers of a block.
+type TokenBalanceChange struct {
+	Token  string
+	Holder string
+	// Delta is the amount the balance changed by, negative if it decreased.
+	Delta *big.Int
 }
 
 type Block struct {
-	Number     int64
-	Hash       string
-	ParentHash string
-	AddrToTxs  map[string][]*TxRecord
+	Number               int64
+	Hash                 string
+	ParentHash           string
+	AddrToTxs            map[string][]*TxRecord
+	AddrToTokenTransfers map[string][]*TokenTransferRecord
+	ContractToEvents     map[string][]*EventRecord
+	// TokenBalanceChanges holds the balance changes of the holders of the tracked tokens, applied once the block is
+	// inserted and reverted once it's removed.
+	TokenBalanceChanges []*TokenBalanceChange
+	// Outbox holds the notifications to persist within the same db transaction as the block.
+	Outbox []*OutboxEntry
+}
+
+// Records are the records of a range of blocks, moved out of the store to archive them to cold storage and back into
+// it to restore them.
+type Records struct {
+	AddrToTxs            map[string][]*TxRecord
+	AddrToTokenTransfers map[string][]*TokenTransferRecord
+	ContractToEvents     map[string][]*EventRecord
+}
+
+// Len returns the number of records, a record shared by two addresses counting twice.
+func (r *Records) Len() int {
+	var n int
+	for txs := range maps.Values(r.AddrToTxs) {
+		n += len(txs)
+	}
+	for transfers := range maps.Values(r.AddrToTokenTransfers) {
+		n += len(transfers)
+	}
+	for events := range maps.Values(r.ContractToEvents) {
+		n += len(events)
+	}
+	return n
+}
+
+// BlockRange returns the numbers of the oldest and latest blocks of the records, or false if there are none.
+func (r *Records) BlockRange() (int64, int64, bool) {
+	var fromBlock, toBlock int64
+	var found bool
+	update := func(number int64) {
+		if !found || number < fromBlock {
+			fromBlock = number
+		}
+		if !found || number > toBlock {
+			toBlock = number
+		}
+		found = true
+	}
+	for txs := range maps.Values(r.AddrToTxs) {
+		for tx := range slices.Values(txs) {
+			update(tx.BlockNumber)
+		}
+	}
+	for transfers := range maps.Values(r.AddrToTokenTransfers) {
+		for transfer := range slices.Values(transfers) {
+			update(transfer.BlockNumber)
+		}
+	}
+	for events := range maps.Values(r.ContractToEvents) {
+		for event := range slices.Values(events) {
+			update(event.BlockNumber)
+		}
+	}
+	return fromBlock, toBlock, found
+}
+
+// OutboxEntry is a notification persisted along with the block it belongs to, pending delivery to a notifier.
+// Only one of Tx and Block is set.
+type OutboxEntry struct {
+	// ID is assigned by the store when the entry is inserted, ordering the entries of a notifier.
+	ID       uint64
+	Notifier string
+	Tx       *TxRecord
+	Block    *Block
+}
+
+// DeadLetter is a block that could not be indexed after exhausting its retries, recorded so the gap can be repaired.
+type DeadLetter struct {
+	BlockNumber int64     `json:"blockNumber"`
+	BlockHash   string    `json:"blockHash"`
+	ParentHash  string    `json:"parentHash"`
+	Attempts    int       `json:"attempts"`
+	Error       string    `json:"error"`
+	FailedAt    time.Time `json:"failedAt"`
 }
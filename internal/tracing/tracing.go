@@ -0,0 +1,55 @@
+// Package tracing gives every block fetched from the node a W3C trace ID, propagated to the node in the traceparent
+// header of its calls and attached as exemplars to the latency histograms, so a latency spike can be followed to the
+// trace of the offending block, e.g. from Grafana to the traces of a node instrumented with OpenTelemetry.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// ExemplarLabel is the label of the trace ID of the exemplars, the one Grafana links to traces by default.
+const ExemplarLabel = "trace_id"
+
+type traceIDKey struct{}
+
+// NewTraceID returns a random trace ID, 16 bytes hex encoded as of the W3C trace context.
+func NewTraceID() string {
+	return randomHex(16)
+}
+
+// ContextWithTraceID returns a copy of the context carrying the trace ID.
+func ContextWithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDKey{}, traceID)
+}
+
+// TraceIDFromContext returns the trace ID carried by the context, empty if none.
+func TraceIDFromContext(ctx context.Context) string {
+	traceID, _ := ctx.Value(traceIDKey{}).(string)
+	return traceID
+}
+
+// Traceparent returns the traceparent header of a new sampled span of the trace, e.g.
+// 00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01.
+func Traceparent(traceID string) string {
+	return "00-" + traceID + "-" + randomHex(8) + "-01"
+}
+
+// Observe observes the value, with the trace ID as its exemplar if set and supported by the observer.
+func Observe(o prometheus.Observer, value float64, traceID string) {
+	eo, ok := o.(prometheus.ExemplarObserver)
+	if traceID == "" || !ok {
+		o.Observe(value)
+		return
+	}
+	eo.ObserveWithExemplar(value, prometheus.Labels{ExemplarLabel: traceID})
+}
+
+func randomHex(n int) string {
+	b := make([]byte, n)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
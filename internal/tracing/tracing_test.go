@@ -0,0 +1,71 @@
+package tracing_test
+
+import (
+	"context"
+	"regexp"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/tracing"
+)
+
+func TestTraceparent(t *testing.T) {
+	traceID := tracing.NewTraceID()
+	assert.Regexp(t, regexp.MustCompile(`^[0-9a-f]{32}$`), traceID)
+	assert.NotEqual(t, traceID, tracing.NewTraceID())
+
+	traceparent := tracing.Traceparent(traceID)
+	assert.Regexp(t, regexp.MustCompile(`^00-`+traceID+`-[0-9a-f]{16}-01$`), traceparent)
+	// every call is a new span of the same trace
+	assert.NotEqual(t, traceparent, tracing.Traceparent(traceID))
+
+	ctx := tracing.ContextWithTraceID(context.Background(), traceID)
+	assert.Equal(t, traceID, tracing.TraceIDFromContext(ctx))
+	assert.Empty(t, tracing.TraceIDFromContext(context.Background()))
+}
+
+func TestObserve(t *testing.T) {
+	tests := map[string]struct {
+		traceID          string
+		expectedExemplar bool
+	}{
+		"with trace ID": {
+			traceID:          "4bf92f3577b34da6a3ce929d0e0e4736",
+			expectedExemplar: true,
+		},
+		"without trace ID": {},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			registry := prometheus.NewRegistry()
+			histogram := prometheus.NewHistogram(prometheus.HistogramOpts{
+				Name:    "test_duration_seconds",
+				Help:    "Test histogram",
+				Buckets: []float64{1},
+			})
+			registry.MustRegister(histogram)
+
+			tracing.Observe(histogram, 0.5, test.traceID)
+
+			families, err := registry.Gather()
+			require.NoError(t, err)
+			require.Len(t, families, 1)
+			h := families[0].GetMetric()[0].GetHistogram()
+			assert.Equal(t, uint64(1), h.GetSampleCount())
+			exemplar := h.GetBucket()[0].GetExemplar()
+			if !test.expectedExemplar {
+				assert.Nil(t, exemplar)
+				return
+			}
+			require.NotNil(t, exemplar)
+			assert.Equal(t, 0.5, exemplar.GetValue())
+			require.Len(t, exemplar.GetLabel(), 1)
+			assert.Equal(t, tracing.ExemplarLabel, exemplar.GetLabel()[0].GetName())
+			assert.Equal(t, test.traceID, exemplar.GetLabel()[0].GetValue())
+		})
+	}
+}
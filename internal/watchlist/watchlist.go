@@ -0,0 +1,91 @@
+// Package watchlist reads the addresses subscribed to on startup from a file, and watches it for changes.
+package watchlist
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/hedisam/pipeline/chans"
+
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+// Entry is a watched address with its optional label.
+type Entry struct {
+	Address string
+	Label   string
+}
+
+// Load reads the entries of the watchlist file at the given path, see Parse.
+func Load(path string) ([]*Entry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("could not open watchlist: %w", err)
+	}
+	defer f.Close()
+
+	entries, err := Parse(f)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return entries, nil
+}
+
+// Parse reads a watchlist of one address per line, optionally followed by its label, e.g.
+// "0x28c6c06298d514db089934071355e5743bf21d60 Binance 14". Addresses are returned lower-cased and 0x-prefixed. Blank
+// lines and the ones starting with # are skipped.
+func Parse(r io.Reader) ([]*Entry, error) {
+	var entries []*Entry
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		addr, label, _ := strings.Cut(text, " ")
+		addr = strings.ToLower(addr)
+		if !strings.HasPrefix(addr, "0x") {
+			addr = "0x" + addr
+		}
+		if !ethaddr.IsValid(addr) {
+			return nil, fmt.Errorf("line %d: invalid address %q", line, addr)
+		}
+		entries = append(entries, &Entry{
+			Address: addr,
+			Label:   strings.TrimSpace(label),
+		})
+	}
+	err := scanner.Err()
+	if err != nil {
+		return nil, fmt.Errorf("could not read watchlist: %w", err)
+	}
+
+	return entries, nil
+}
+
+// Watch polls the file at the given path every interval until the context is done, calling onChange whenever its
+// modification time or size changes.
+func Watch(ctx context.Context, path string, interval time.Duration, onChange func()) {
+	last, _ := os.Stat(path)
+
+	t := time.NewTicker(interval)
+	defer t.Stop()
+	for range chans.ReceiveOrDoneSeq(ctx, t.C) {
+		info, err := os.Stat(path)
+		if err != nil {
+			// the file may be in the middle of being replaced
+			continue
+		}
+		if last != nil && info.ModTime().Equal(last.ModTime()) && info.Size() == last.Size() {
+			continue
+		}
+		last = info
+		onChange()
+	}
+}
@@ -0,0 +1,81 @@
+package watchlist_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/watchlist"
+)
+
+func TestParse(t *testing.T) {
+	tests := map[string]struct {
+		data            string
+		expectedEntries []*watchlist.Entry
+		errContains     string
+	}{
+		"addresses with and without labels": {
+			data: `# exchanges
+0x28C6c06298d514Db089934071355E5743bf21d60 Binance 14
+
+3ee18b2214aff97000d974cf647e7c347e8fa585
+`,
+			expectedEntries: []*watchlist.Entry{
+				{Address: "0x28c6c06298d514db089934071355e5743bf21d60", Label: "Binance 14"},
+				{Address: "0x3ee18b2214aff97000d974cf647e7c347e8fa585"},
+			},
+		},
+		"empty": {
+			data: "\n# nothing yet\n",
+		},
+		"invalid address": {
+			data:        "0x28c6c06298d514db089934071355e5743bf21d60\n0x1234 Invalid\n",
+			errContains: `line 2: invalid address "0x1234"`,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			entries, err := watchlist.Parse(strings.NewReader(test.data))
+			if test.errContains != "" {
+				assert.ErrorContains(t, err, test.errContains)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedEntries, entries)
+		})
+	}
+}
+
+func TestWatch(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "watchlist.txt")
+	err := os.WriteFile(path, []byte("0x28c6c06298d514db089934071355e5743bf21d60\n"), 0o600)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	changes := make(chan struct{}, 1)
+	go watchlist.Watch(ctx, path, time.Millisecond*10, func() {
+		changes <- struct{}{}
+	})
+
+	select {
+	case <-changes:
+		t.Fatal("unchanged file reported as changed")
+	case <-time.After(time.Millisecond * 50):
+	}
+
+	err = os.WriteFile(path, []byte("0x28c6c06298d514db089934071355e5743bf21d60\n0x3ee18b2214aff97000d974cf647e7c347e8fa585\n"), 0o600)
+	require.NoError(t, err)
+	select {
+	case <-changes:
+	case <-time.After(time.Second):
+		t.Fatal("change not reported")
+	}
+}
@@ -0,0 +1,103 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"net/http"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/mocknode"
+)
+
+// maxMockTxsPerBlock caps the transactions of the mock blocks, as their index is part of their hash.
+const maxMockTxsPerBlock = 1 << 16
+
+type mockNodeOptions struct {
+	Addr        string
+	Seed        uint64
+	StartBlock  int64
+	BlockTime   time.Duration
+	TxsPerBlock int
+	Accounts    int
+	ReorgEvery  int
+	ReorgDepth  int
+	LogFormat   string
+	LogLevel    string
+}
+
+// runMockNode serves a mock node until SIGINT or SIGTERM, sharing none of the options of the other commands as it
+// only stands in for the node they follow.
+func runMockNode(args []string) {
+	fs := flag.NewFlagSet(os.Args[0]+" "+commandMockNode, flag.ExitOnError)
+	var opts mockNodeOptions
+	fs.StringVar(&opts.Addr, "addr", "localhost:8545", "Addr to serve the JSON-RPC API on, to be given to --node-addr as http://<addr>")
+	fs.Uint64Var(&opts.Seed, "seed", 1, "Seed the blocks are derived from, the same seed minting the same blocks")
+	fs.Int64Var(&opts.StartBlock, "start-block", 0, "Number of the first block")
+	fs.DurationVar(&opts.BlockTime, "block-time", mocknode.DefaultBlockTime, "Interval blocks are minted at")
+	fs.IntVar(&opts.TxsPerBlock, "txs-per-block", mocknode.DefaultTxsPerBlock, "Number of transactions of every block")
+	fs.IntVar(&opts.Accounts, "accounts", mocknode.DefaultAccounts, "Number of accounts the transactions are sent from and to, logged on start to be subscribed to")
+	fs.IntVar(&opts.ReorgEvery, "reorg-every", 0, "Reorganise the last --reorg-depth blocks every this many blocks. Disabled if zero, reorgs can still be injected with POST /reorg?depth=<n>")
+	fs.IntVar(&opts.ReorgDepth, "reorg-depth", mocknode.DefaultReorgDepth, "Number of blocks replaced by the reorganisations")
+	fs.StringVar(&opts.LogFormat, "log-format", string(logging.FormatText), "Log format, either 'text' or 'json'")
+	fs.StringVar(&opts.LogLevel, "log-level", "info", "Log level, e.g. 'debug' to log every minted block")
+	_ = fs.Parse(args) // exits on error
+
+	logger := logrus.New()
+	err := logging.Configure(logger, opts.LogFormat, opts.LogLevel)
+	if err != nil {
+		logger.WithError(err).Error("--log-format or --log-level is invalid")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if opts.BlockTime <= 0 {
+		logger.Error("--block-time must be positive")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if opts.TxsPerBlock < 0 || opts.TxsPerBlock > maxMockTxsPerBlock {
+		logger.Errorf("--txs-per-block must be between 0 and %d", maxMockTxsPerBlock)
+		fs.Usage()
+		os.Exit(1)
+	}
+	if opts.Accounts < 1 {
+		logger.Error("--accounts must be at least 1")
+		fs.Usage()
+		os.Exit(1)
+	}
+	if opts.ReorgEvery < 0 || (opts.ReorgEvery > 0 && opts.ReorgDepth < 1) {
+		logger.Error("--reorg-every cannot be negative, and --reorg-depth must be at least 1 if it's set")
+		fs.Usage()
+		os.Exit(1)
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	node := mocknode.New(
+		logging.Logrus(logger),
+		mocknode.WithSeed(opts.Seed),
+		mocknode.WithStartBlock(opts.StartBlock),
+		mocknode.WithBlockTime(opts.BlockTime),
+		mocknode.WithTxsPerBlock(opts.TxsPerBlock),
+		mocknode.WithAccounts(opts.Accounts),
+		mocknode.WithReorgs(opts.ReorgEvery, opts.ReorgDepth),
+	)
+	logger.WithField("accounts", node.Accounts()).Info("Minting mock blocks with transactions between the accounts")
+	go node.Start(ctx)
+
+	mux := http.NewServeMux()
+	mux.Handle("/", node.Handler())
+	mux.Handle("GET /metrics", promhttp.HandlerFor(custompromauto.Registry(), promhttp.HandlerOpts{}))
+	mustListenAndServe(ctx, logger, &http.Server{
+		Addr:    opts.Addr,
+		Handler: mux,
+	})
+}
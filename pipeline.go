@@ -0,0 +1,541 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+
+	"github.com/hedisam/ethtxparser/api/admin"
+	grpcapi "github.com/hedisam/ethtxparser/api/grpc"
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/abi"
+	"github.com/hedisam/ethtxparser/internal/addressbook"
+	"github.com/hedisam/ethtxparser/internal/archive"
+	"github.com/hedisam/ethtxparser/internal/backfill"
+	"github.com/hedisam/ethtxparser/internal/custompromauto"
+	"github.com/hedisam/ethtxparser/internal/errreport"
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/health"
+	"github.com/hedisam/ethtxparser/internal/index"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/metering"
+	"github.com/hedisam/ethtxparser/internal/notify"
+	"github.com/hedisam/ethtxparser/internal/price"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+	"github.com/hedisam/ethtxparser/internal/store/timeout"
+	"github.com/hedisam/ethtxparser/internal/watchlist"
+)
+
+// pipeline is the indexing pipeline of a single chain, following its own node into its own stores.
+type pipeline struct {
+	chain      string
+	node       *eth.Client
+	idx        *index.Index
+	restServer *restapi.Server
+	// adminServer is only served if the admin API is enabled.
+	adminServer *admin.Server
+	// grpcServer is nil unless gRPC streaming is enabled.
+	grpcServer *grpcapi.Server
+	// keys are the API keys of the tenants, whose usage is recorded in usageStore.
+	keys       *metering.Keys
+	usageStore *memdb.UsageStore
+	// responseCache is nil unless the responses of the read endpoints are cached.
+	responseCache *restapi.ResponseCache
+	// indexed is closed once the indexer is done with the confirmed blocks.
+	indexed chan struct{}
+	closers []io.Closer
+}
+
+// startPipeline starts the pipeline of the given chain, returning once its indexer, backfiller and REST server are
+// set up. The node is only followed once leading is closed. The block source is stopped with stopCtx, the rest of the
+// pipeline with ctx.
+func startPipeline(ctx, stopCtx context.Context, logger *logrus.Logger, chain string, opts Options, httpClient *http.Client, leading <-chan struct{}) *pipeline {
+	registry := health.NewRegistry(chain)
+	reg := custompromauto.ForChain(chain)
+	ethClient := newEthClient(logger, chain, opts, httpClient, registry)
+	txStore, subscriptionStore := newStores(chain, opts, registry)
+	abiRegistry := newABIRegistry(logger, opts)
+	book := newAddressBook(logger, opts)
+	reporter := newErrorReporter(ctx, logger, chain, opts, httpClient)
+	keys, err := metering.ParseKeys(opts.APIKeys)
+	if err != nil {
+		logger.WithError(err).WithField("chain", chain).Fatal("Failed to parse the API keys")
+	}
+	p := &pipeline{
+		chain:      chain,
+		node:       ethClient,
+		keys:       keys,
+		usageStore: memdb.NewUsageStore(),
+		indexed:    make(chan struct{}),
+	}
+	p.idx, p.grpcServer, p.closers = newIndex(ctx, logger, chain, opts, httpClient, ethClient, txStore, subscriptionStore, abiRegistry, book, reporter, registry, p.usageStore, keys)
+
+	confirmationDepth := eth.NewConfirmationDepth(opts.ReorgConfirmationDepth)
+	go func() {
+		defer close(p.indexed)
+		defer reporter.Recover()
+		select {
+		case <-leading:
+		case <-stopCtx.Done():
+			return
+		}
+
+		var confirmedBlocks <-chan *eth.BlockEvent
+		if opts.ReplayArchive != "" {
+			confirmedBlocks = replayBlocks(stopCtx, logger, chain, opts, httpClient, opts.ReplayFromBlock, opts.ReplayToBlock)
+		} else {
+			lag := eth.NewLagTracker(chainLogger(logger, chain), reg, ethClient, txStore.GetCurrentBlockNumber)
+			if opts.FixturesDir == "" {
+				go lag.PollHead(stopCtx, opts.PollInterval)
+			}
+			confirmedBlocks = liveBlocks(ctx, stopCtx, logger, chain, opts, httpClient, ethClient, confirmationDepth, lag, registry)
+		}
+		p.idx.Start(ctx, confirmedBlocks)
+	}()
+
+	restOpts := []restapi.Option{
+		restapi.WithHealth(registry),
+		restapi.WithABIRegistry(abiRegistry),
+		restapi.WithIndexer(p.idx),
+	}
+	adminOpts := []admin.Option{
+		admin.WithIndexer(p.idx),
+		admin.WithConfirmationDepth(confirmationDepth),
+	}
+	// subscription backfills write the past transactions to the store, they're disabled in dry run mode
+	if !opts.DryRun {
+		backfiller := backfill.New(logger, ethClient, txStore,
+			backfill.WithBatchSize(opts.BackfillBatchSize),
+			backfill.WithInterval(opts.BackfillInterval),
+			backfill.WithMaxBlocks(opts.BackfillMaxBlocks),
+			backfill.WithRegisterer(reg),
+		)
+		go func() {
+			defer reporter.Recover()
+			backfiller.Start(ctx)
+		}()
+		restOpts = append(restOpts, restapi.WithBackfiller(backfiller))
+		adminOpts = append(adminOpts, admin.WithBackfiller(backfiller))
+	}
+	if opts.IndexAll {
+		restOpts = append(restOpts, restapi.WithIndexAll())
+	}
+	// the node isn't followed when replaying
+	if opts.ReplayArchive == "" && opts.FixturesDir == "" {
+		restOpts = append(restOpts, restapi.WithNode(ethClient))
+	}
+	p.restServer = restapi.NewServer(chainLogger(logger, chain), txStore, subscriptionStore, restOpts...)
+	if opts.ResponseCache {
+		// the responses are invalidated by the next block, expected after a poll interval
+		ttl := opts.ResponseCacheTTL
+		if ttl == 0 {
+			ttl = opts.PollInterval
+		}
+		p.responseCache = restapi.NewResponseCache(txStore, ttl, opts.ResponseCacheEntries)
+	}
+	if opts.Watchlist != "" {
+		subscriber := &watchlistSubscriber{
+			path:       opts.Watchlist,
+			restServer: p.restServer,
+			book:       book,
+			subscribed: make(map[string]string),
+		}
+		err := subscriber.subscribe(ctx)
+		if err != nil {
+			logger.WithError(err).WithField("chain", chain).Fatal("Failed to subscribe to the watchlist")
+		}
+		adminOpts = append(adminOpts, admin.WithReloader(subscriber))
+		if opts.WatchlistReloadInterval > 0 {
+			go watchlist.Watch(ctx, opts.Watchlist, opts.WatchlistReloadInterval, func() {
+				err := subscriber.subscribe(ctx)
+				if err != nil {
+					logger.WithError(err).WithField("chain", chain).Error("Failed to reload the watchlist")
+					return
+				}
+				logger.WithField("chain", chain).Info("Reloaded the watchlist")
+			})
+		}
+	}
+
+	if opts.ColdStorage != "" {
+		coldStorage, err := archive.OpenRecordArchive(httpClient, reg, opts.ColdStorage, s3Options(opts.ColdStorageS3Endpoint, opts.ColdStorageS3Region)...)
+		if err != nil {
+			logger.WithError(err).WithField("chain", chain).Fatal("Failed to open the cold storage")
+		}
+		adminOpts = append(adminOpts, admin.WithColdStorage(coldStorage))
+	}
+
+	if keys.Enabled() {
+		adminOpts = append(adminOpts, admin.WithUsage(p.usageStore))
+	}
+
+	p.adminServer = admin.NewServer(chainLogger(logger, chain), txStore, subscriptionStore, adminOpts...)
+
+	return p
+}
+
+// watchlistSubscriber subscribes to the addresses of the watchlist file, either a JSON list of subscriptions given as
+// the bodies of the subscribe requests along with their address, e.g. [{"address": "0x...", "minValue": "1000"}], if
+// its extension is .json, or a list of addresses with optional labels, added to the address book, see watchlist.Parse.
+type watchlistSubscriber struct {
+	path       string
+	restServer *restapi.Server
+	book       *addressbook.Book
+	// mu serializes the reloads of the watchlist, by the file watcher and the admin API.
+	mu sync.Mutex
+	// subscribed holds the JSON of the subscribe requests made by address, so the unchanged entries aren't
+	// subscribed to again on reload, which would regenerate their webhook secrets.
+	subscribed map[string]string
+}
+
+// Reload subscribes to the new and changed entries of the watchlist, through the admin API.
+func (w *watchlistSubscriber) Reload(ctx context.Context) error {
+	return w.subscribe(ctx)
+}
+
+func (w *watchlistSubscriber) subscribe(ctx context.Context) error {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	var reqs []*restapi.SubscribeRequest
+	labels := make(map[string]string)
+	if filepath.Ext(w.path) == ".json" {
+		data, err := os.ReadFile(w.path)
+		if err != nil {
+			return fmt.Errorf("could not read watchlist: %w", err)
+		}
+		err = json.Unmarshal(data, &reqs)
+		if err != nil {
+			return fmt.Errorf("could not unmarshal watchlist: %w", err)
+		}
+	} else {
+		entries, err := watchlist.Load(w.path)
+		if err != nil {
+			return err
+		}
+		for entry := range slices.Values(entries) {
+			reqs = append(reqs, &restapi.SubscribeRequest{Address: entry.Address, Label: entry.Label})
+			if entry.Label != "" {
+				labels[entry.Address] = entry.Label
+			}
+		}
+	}
+
+	err := w.book.Add(labels)
+	if err != nil {
+		return fmt.Errorf("could not add watchlist labels to the address book: %w", err)
+	}
+	for idx, req := range reqs {
+		data, err := json.Marshal(req)
+		if err != nil {
+			return fmt.Errorf("could not marshal watchlist entry %d: %w", idx, err)
+		}
+		if w.subscribed[req.Address] == string(data) {
+			continue
+		}
+		// the entries are validated as the API requests are, by the handler adapter
+		err = restapi.Validate(req)
+		if err != nil {
+			return fmt.Errorf("watchlist entry %d: %w", idx, err)
+		}
+		_, err = w.restServer.Subscribe(ctx, req)
+		if err != nil {
+			return fmt.Errorf("watchlist entry %d: %w", idx, err)
+		}
+		w.subscribed[req.Address] = string(data)
+	}
+	return nil
+}
+
+// requireKey returns the middleware authenticating the public API requests of the pipeline with the API keys of the
+// tenants, recording their usage.
+func (p *pipeline) requireKey(logger logging.Logger) restapi.Middleware {
+	return func(next http.Handler) http.Handler {
+		return metering.RequireKey(logger, p.keys, p.usageStore, next)
+	}
+}
+
+func (p *pipeline) close() {
+	for c := range slices.Values(p.closers) {
+		_ = c.Close()
+	}
+}
+
+// chainLogger returns the logger of the eth, index and rest packages, logging the chain of the pipeline. Their metrics
+// are labelled with it likewise by registering them with custompromauto.ForChain.
+func chainLogger(logger *logrus.Logger, chain string) logging.Logger {
+	return logging.Logrus(logger).WithField("chain", chain)
+}
+
+// newEthClient returns the client of the node of the chain, reporting its health to the registry unless nil.
+func newEthClient(logger *logrus.Logger, chain string, opts Options, httpClient *http.Client, registry *health.Registry) *eth.Client {
+	ethOpts := []eth.Option{
+		eth.WithHealth(registry.Component(health.Node)),
+		eth.WithRegisterer(custompromauto.ForChain(chain)),
+	}
+	if opts.IndexTokens || opts.IndexEvents || opts.IndexTxStatus || opts.IndexSkipFailed {
+		ethOpts = append(ethOpts, eth.WithReceipts())
+	}
+	if opts.PrefetchReceipts {
+		ethOpts = append(ethOpts, eth.WithPrefetchedReceipts())
+	}
+	if opts.RecordDir != "" {
+		ethOpts = append(ethOpts, eth.WithRecording(opts.RecordDir))
+	}
+	return eth.New(chainLogger(logger, chain), httpClient, opts.NodeAddr, ethOpts...)
+}
+
+// newStores returns the stores of the chain, reporting their health to the registry unless nil.
+func newStores(chain string, opts Options, registry *health.Registry) (*timeout.TxStoreWrapper, *timeout.SubscriptionStoreWrapper) {
+	reg := custompromauto.ForChain(chain)
+	storeTimeouts := []timeout.Option{
+		timeout.WithReadTimeout(opts.StoreReadTimeout),
+		timeout.WithWriteTimeout(opts.StoreWriteTimeout),
+		timeout.WithHealth(registry.Component(health.Store)),
+		timeout.WithRegisterer(reg),
+	}
+	txStore := timeout.NewTxStore(memdb.NewTxStore(memdb.WithMaxRecords(opts.StoreMaxRecords), memdb.WithRegisterer(reg)), storeTimeouts...)
+	subscriptionStore := timeout.NewSubscriptionStore(memdb.NewSubscriptionStore(), storeTimeouts...)
+	return txStore, subscriptionStore
+}
+
+// newAddressBook returns the address book labelling the counterparties of recorded transactions, loaded from the
+// address book file if set, and open to the labels of the watchlist. Nil if neither is set.
+func newAddressBook(logger *logrus.Logger, opts Options) *addressbook.Book {
+	switch {
+	case opts.AddressBook != "":
+		book, err := addressbook.Load(opts.AddressBook)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load address book")
+		}
+		return book
+	case opts.Watchlist != "":
+		book, _ := addressbook.New(nil)
+		return book
+	default:
+		return nil
+	}
+}
+
+func newABIRegistry(logger *logrus.Logger, opts Options) *abi.Registry {
+	abiRegistry := abi.NewRegistry()
+	if opts.ABIDir != "" {
+		err := abiRegistry.LoadDir(opts.ABIDir)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to load contract ABIs")
+		}
+	}
+	return abiRegistry
+}
+
+// newErrorReporter returns the reporter of the chain's errors, started until the context is done, or nil if error
+// reporting is disabled.
+func newErrorReporter(ctx context.Context, logger *logrus.Logger, chain string, opts Options, httpClient *http.Client) *errreport.Reporter {
+	if opts.ErrorDSN == "" {
+		return nil
+	}
+	reporter, err := errreport.New(logger, httpClient, opts.ErrorDSN, errreport.WithTag("chain", chain))
+	if err != nil {
+		logger.WithError(err).Fatal("Failed to create error reporter")
+	}
+	go reporter.Start(ctx)
+	return reporter
+}
+
+// newIndex returns the indexer of the chain along with its notifiers, the gRPC server if enabled, and the connections
+// of the notifiers to close once done.
+func newIndex(ctx context.Context, logger *logrus.Logger, chain string, opts Options, httpClient *http.Client, ethClient *eth.Client, txStore *timeout.TxStoreWrapper, subscriptionStore *timeout.SubscriptionStoreWrapper, abiRegistry *abi.Registry, book *addressbook.Book, reporter *errreport.Reporter, registry *health.Registry, usageRecorder metering.Recorder, keys *metering.Keys) (*index.Index, *grpcapi.Server, []io.Closer) {
+	var grpcServer *grpcapi.Server
+	var closers []io.Closer
+	reg := custompromauto.ForChain(chain)
+	indexOpts := []index.Option{
+		index.WithHealth(registry),
+		index.WithRegisterer(reg),
+		index.WithWorkers(opts.IndexWorkers),
+		index.WithRetryAttempts(opts.IndexRetryAttempts),
+		index.WithInputDecoder(abiRegistry),
+	}
+	if opts.IndexTokens {
+		indexOpts = append(indexOpts, index.WithTokenTransfers())
+	}
+	if opts.IndexEvents {
+		indexOpts = append(indexOpts, index.WithEvents())
+	}
+	if opts.IndexSkipFailed {
+		indexOpts = append(indexOpts, index.WithSkipFailed())
+	}
+	if opts.IndexAll {
+		indexOpts = append(indexOpts, index.WithIndexAll())
+	}
+	indexOpts = append(indexOpts, index.WithDigestInterval(opts.NotificationDigestInterval))
+	if book != nil {
+		indexOpts = append(indexOpts, index.WithTxHook(book))
+	}
+	if opts.PriceURL != "" {
+		oracle := price.New(logger, httpClient, opts.PriceURL,
+			price.WithJSONPath(opts.PriceJSONPath),
+			price.WithResolution(opts.PriceResolution),
+			price.WithRegisterer(reg),
+		)
+		indexOpts = append(indexOpts, index.WithTxHook(oracle))
+	}
+	if opts.IndexReorgWindow > 0 {
+		indexOpts = append(indexOpts, index.WithReorgRemovals(opts.IndexReorgWindow))
+	}
+	if opts.IndexAddressMetrics > 0 {
+		indexOpts = append(indexOpts, index.WithAddressMetrics(opts.IndexAddressMetrics))
+	}
+	if opts.SubscriptionFilter {
+		indexOpts = append(indexOpts, index.WithSubscriptionFilter(subscriptionStore, opts.SubscriptionFilterRate))
+	}
+	// the notifiers look up the subscriptions of both parties of every notified transaction, refreshed as they change
+	subscriptionCache := store.NewSubscriptionCache(subscriptionStore)
+	go subscriptionCache.Watch(ctx, subscriptionStore)
+	if opts.Webhooks {
+		webhookOpts := []notify.WebhookOption{
+			notify.WithMaxElapsedTime(opts.WebhookRetryTimeout),
+			notify.WithWebhookBacklog(opts.WebhookBacklog),
+			notify.WithWebhookRegisterer(reg),
+		}
+		if keys.Enabled() {
+			webhookOpts = append(webhookOpts, notify.WithWebhookUsage(usageRecorder))
+		}
+		if opts.NotificationOutbox {
+			// outbox entries are acknowledged once notified, so the deliveries are awaited to keep them at least once
+			webhookOpts = append(webhookOpts, notify.WithWebhookAwait())
+		}
+		webhook := notify.NewWebhook(logger, httpClient, subscriptionCache, webhookOpts...)
+		closers = append(closers, webhook)
+		indexOpts = append(indexOpts, index.WithNotifier(notify.WebhookNotifier, webhook))
+	}
+	if opts.NATSAddr != "" {
+		natsOpts := []notify.NATSOption{
+			notify.WithNATSSubjectPrefix(opts.NATSSubjectPrefix),
+			notify.WithNATSRegisterer(reg),
+		}
+		if opts.NATSJetStream {
+			natsOpts = append(natsOpts, notify.WithJetStream())
+		}
+		natsPublisher, err := notify.DialNATS(ctx, logger, opts.NATSAddr, natsOpts...)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to connect to NATS")
+		}
+		closers = append(closers, natsPublisher)
+		indexOpts = append(indexOpts, index.WithNotifier("nats", natsPublisher))
+	}
+	if opts.AMQPURL != "" {
+		amqpOpts := []notify.AMQPOption{
+			notify.WithAMQPExchange(opts.AMQPExchange),
+			notify.WithAMQPRoutingKey(opts.AMQPRoutingKey),
+			notify.WithAMQPRegisterer(reg),
+		}
+		if opts.AMQPConfirms {
+			amqpOpts = append(amqpOpts, notify.WithPublisherConfirms())
+		}
+		amqpPublisher, err := notify.DialAMQP(ctx, logger, opts.AMQPURL, amqpOpts...)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to connect to AMQP broker")
+		}
+		closers = append(closers, amqpPublisher)
+		indexOpts = append(indexOpts, index.WithNotifier("amqp", amqpPublisher))
+	}
+	if opts.KafkaBrokers != "" {
+		kafkaOpts := []notify.KafkaOption{
+			notify.WithKafkaTopic(opts.KafkaTopic),
+			notify.WithKafkaRegisterer(reg),
+		}
+		kafkaProducer, err := notify.DialKafka(ctx, logger, opts.KafkaBrokers, kafkaOpts...)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to connect to Kafka")
+		}
+		closers = append(closers, kafkaProducer)
+		indexOpts = append(indexOpts, index.WithNotifier("kafka", kafkaProducer))
+	}
+	if opts.ChatNotifications || opts.ChatChannels != "" {
+		chatOpts := []notify.ChatOption{
+			notify.WithChatRateLimit(opts.ChatRateLimit),
+			notify.WithExplorerURL(opts.ChatExplorerURL),
+			notify.WithChatRegisterer(reg),
+		}
+		for spec := range strings.SplitSeq(opts.ChatChannels, ",") {
+			if strings.TrimSpace(spec) == "" {
+				continue
+			}
+			channel, err := notify.ParseChatChannel(spec)
+			if err != nil {
+				logger.WithError(err).Fatal("Failed to parse chat channel")
+			}
+			chatOpts = append(chatOpts, notify.WithChatChannels(channel))
+		}
+		chat := notify.NewChat(logger, httpClient, subscriptionCache, chatOpts...)
+		indexOpts = append(indexOpts, index.WithNotifier(notify.ChatNotifier, chat))
+	}
+	if opts.SMTPAddr != "" {
+		var bodyTemplate []byte
+		if opts.EmailBodyTemplate != "" {
+			var err error
+			bodyTemplate, err = os.ReadFile(opts.EmailBodyTemplate)
+			if err != nil {
+				logger.WithError(err).Fatal("Failed to read email body template")
+			}
+		}
+		emailOpts := []notify.EmailOption{
+			notify.WithDigestInterval(opts.EmailDigestInterval),
+			notify.WithEmailTemplates(opts.EmailSubjectTemplate, string(bodyTemplate)),
+			notify.WithEmailExplorerURL(opts.ChatExplorerURL),
+			notify.WithEmailRegisterer(reg),
+		}
+		if opts.SMTPUsername != "" {
+			emailOpts = append(emailOpts, notify.WithEmailAuth(opts.SMTPUsername, opts.SMTPPassword))
+		}
+		for recipient := range strings.SplitSeq(opts.EmailTo, ",") {
+			if recipient = strings.TrimSpace(recipient); recipient != "" {
+				emailOpts = append(emailOpts, notify.WithEmailRecipients(recipient))
+			}
+		}
+		email, err := notify.NewEmail(logger, opts.SMTPAddr, opts.EmailFrom, subscriptionCache, emailOpts...)
+		if err != nil {
+			logger.WithError(err).Fatal("Failed to create email notifier")
+		}
+		go email.Start(ctx)
+		indexOpts = append(indexOpts, index.WithNotifier(notify.EmailNotifier, email))
+	}
+	if opts.GRPCAddr != "" {
+		grpcOpts := []grpcapi.Option{
+			grpcapi.WithStreamBuffer(opts.GRPCStreamBuffer),
+			grpcapi.WithRegisterer(reg),
+		}
+		if keys.Enabled() {
+			grpcOpts = append(grpcOpts, grpcapi.WithUsage(keys, usageRecorder))
+		}
+		grpcServer = grpcapi.NewServer(logger, grpcOpts...)
+		indexOpts = append(indexOpts, index.WithNotifier("grpc", grpcServer))
+	}
+	if opts.LogNotifications {
+		indexOpts = append(indexOpts, index.WithNotifier("log", notify.NewLog(logger)))
+	}
+	if opts.NotificationOutbox {
+		indexOpts = append(indexOpts, index.WithOutbox(txStore))
+	}
+	if opts.DryRun {
+		indexOpts = append(indexOpts, index.WithDryRun())
+	}
+	if opts.IndexFillGaps {
+		indexOpts = append(indexOpts, index.WithGapFill(ethClient))
+	}
+	if reporter != nil {
+		indexOpts = append(indexOpts, index.WithErrorReporter(reporter))
+	}
+
+	return index.New(chainLogger(logger, chain), txStore, subscriptionStore, indexOpts...), grpcServer, closers
+}
@@ -0,0 +1,246 @@
+// Package client is the Go client of a running ethtxparser server: it calls its REST API, retrying the calls failing
+// with transient errors, and consumes the transaction streams of its gRPC API.
+package client
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"iter"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/cenkalti/backoff/v4"
+
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+)
+
+const (
+	DefaultMaxRetries = 3
+	DefaultTimeout    = time.Second * 10
+)
+
+// the types of the requests and responses of the REST API
+type (
+	SubscribeRequest  = restapi.SubscribeRequest
+	SubscribeResponse = restapi.SubscribeResponse
+	BackfillProgress  = restapi.BackfillProgress
+	Transaction       = restapi.Transaction
+	DecodedInput      = restapi.DecodedInput
+	DecodedArg        = restapi.DecodedArg
+	VersionResponse   = restapi.VersionResponse
+)
+
+var (
+	// ErrBadRequest is matched by the errors of the requests rejected by the server as invalid.
+	ErrBadRequest = errors.New("bad request")
+	// ErrUnauthorized is matched by the errors of the requests missing valid credentials.
+	ErrUnauthorized = errors.New("unauthorized")
+	// ErrNotFound is matched by the errors of the requests for unknown resources.
+	ErrNotFound = errors.New("not found")
+	// ErrRateLimited is matched by the errors of the requests rejected for being over the rate limit.
+	ErrRateLimited = errors.New("rate limited")
+	// ErrUnavailable is matched by the errors of the requests the server can't serve yet, e.g. the current block
+	// before the first block is parsed, or while it's shutting down.
+	ErrUnavailable = errors.New("unavailable")
+)
+
+// Error is an error returned by the server, matching the Err sentinel of its status code with errors.Is.
+type Error struct {
+	StatusCode int
+	// Message is the message written by the server.
+	Message string
+}
+
+// Error implements the std error type.
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s: %s", http.StatusText(e.StatusCode), e.Message)
+}
+
+// Is reports whether the target is the sentinel of the status code of the error.
+func (e *Error) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.StatusCode == http.StatusBadRequest
+	case ErrUnauthorized:
+		return e.StatusCode == http.StatusUnauthorized
+	case ErrNotFound:
+		return e.StatusCode == http.StatusNotFound
+	case ErrRateLimited:
+		return e.StatusCode == http.StatusTooManyRequests
+	case ErrUnavailable:
+		return e.StatusCode == http.StatusServiceUnavailable
+	default:
+		return false
+	}
+}
+
+// temporary reports whether the call may succeed if retried.
+func (e *Error) temporary() bool {
+	switch e.StatusCode {
+	case http.StatusTooManyRequests, http.StatusBadGateway, http.StatusServiceUnavailable, http.StatusGatewayTimeout:
+		return true
+	default:
+		return false
+	}
+}
+
+type config struct {
+	httpClient *http.Client
+	chain      string
+	maxRetries uint64
+	streamURL  string
+}
+
+type Option func(*config)
+
+// WithHTTPClient sets the http client the calls are made with, one timing out after DefaultTimeout by default.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithChain sets the chain whose API is called, the one followed by the top-level options of the server by default.
+func WithChain(chain string) Option {
+	return func(c *config) {
+		c.chain = chain
+	}
+}
+
+// WithMaxRetries sets the number of times a call failing with a transient error is retried, with an exponential
+// backoff. Zero disables the retries.
+func WithMaxRetries(n uint64) Option {
+	return func(c *config) {
+		c.maxRetries = n
+	}
+}
+
+// WithStreamURL sets the URL of the gRPC API of the server, served at its --grpc-addr, e.g. http://localhost:9090.
+// The transaction streams can't be opened without it.
+func WithStreamURL(streamURL string) Option {
+	return func(c *config) {
+		c.streamURL = streamURL
+	}
+}
+
+// Client calls the API of the server.
+type Client struct {
+	rest *restapi.Client
+	// streamClient makes the gRPC calls, over HTTP/2 whether the stream URL is encrypted or not.
+	streamClient *http.Client
+	cfg          *config
+}
+
+// New returns a client of the server whose REST API is served at serverURL, e.g. http://localhost:8080.
+func New(serverURL string, opts ...Option) *Client {
+	cfg := &config{
+		httpClient: &http.Client{Timeout: DefaultTimeout},
+		maxRetries: DefaultMaxRetries,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+
+	var protocols http.Protocols
+	protocols.SetHTTP2(true)
+	protocols.SetUnencryptedHTTP2(true)
+
+	return &Client{
+		rest: restapi.NewClient(cfg.httpClient, serverURL, cfg.chain),
+		// streams are long-lived, they aren't timed out
+		streamClient: &http.Client{Transport: &http.Transport{Protocols: &protocols}},
+		cfg:          cfg,
+	}
+}
+
+// GetCurrentBlock returns the number of the last block parsed by the server. The error matches ErrUnavailable if
+// none is parsed yet.
+func (c *Client) GetCurrentBlock(ctx context.Context) (int64, error) {
+	resp, err := retry(ctx, c.cfg, c.rest.GetCurrentBlock)
+	if err != nil {
+		return 0, err
+	}
+	return resp.BlockNumberInt, nil
+}
+
+// Subscribe subscribes the address of the request, or updates its subscription.
+func (c *Client) Subscribe(ctx context.Context, req *SubscribeRequest) (*SubscribeResponse, error) {
+	// subscribing is idempotent, it's retried as the other calls
+	return retry(ctx, c.cfg, func(ctx context.Context) (*SubscribeResponse, error) {
+		return c.rest.Subscribe(ctx, req)
+	})
+}
+
+// ListSubscriptions returns the subscribed addresses.
+func (c *Client) ListSubscriptions(ctx context.Context) ([]string, error) {
+	resp, err := retry(ctx, c.cfg, c.rest.ListSubscriptions)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Addresses, nil
+}
+
+// ListTransactions iterates over the recorded transactions of the subscribed address, in the order they were
+// recorded, fetching them once the iteration starts. The iteration stops at the first error, yielded along with a nil
+// transaction.
+func (c *Client) ListTransactions(ctx context.Context, addr string) iter.Seq2[*Transaction, error] {
+	return func(yield func(*Transaction, error) bool) {
+		// the API lists all the transactions of an address in a single page, callers iterating over them don't change
+		// once it pages them
+		resp, err := retry(ctx, c.cfg, func(ctx context.Context) (*restapi.ListTransactionsResponse, error) {
+			return c.rest.ListTransactions(ctx, addr)
+		})
+		if err != nil {
+			yield(nil, err)
+			return
+		}
+		for tx := range slices.Values(resp.Transactions) {
+			if !yield(tx, nil) {
+				return
+			}
+		}
+	}
+}
+
+// GetVersion returns the build version of the server.
+func (c *Client) GetVersion(ctx context.Context) (*VersionResponse, error) {
+	return retry(ctx, c.cfg, c.rest.GetVersion)
+}
+
+// retry calls f until it succeeds, fails with a permanent error, or the retries run out, returning the errors of the
+// server as *Error.
+func retry[T any](ctx context.Context, cfg *config, f func(context.Context) (*T, error)) (*T, error) {
+	bo := backoff.WithContext(backoff.WithMaxRetries(newBackoffConfig(), cfg.maxRetries), ctx)
+	return backoff.RetryWithData(func() (*T, error) {
+		resp, err := f(ctx)
+		if err == nil {
+			return resp, nil
+		}
+		var restErr *restapi.Err
+		if errors.As(err, &restErr) {
+			apiErr := &Error{StatusCode: restErr.StatusCode, Message: restErr.Message}
+			if apiErr.temporary() {
+				return nil, apiErr
+			}
+			return nil, backoff.Permanent(apiErr)
+		}
+		if ctx.Err() != nil {
+			return nil, backoff.Permanent(err)
+		}
+		// the server couldn't be reached
+		return nil, err
+	}, bo)
+}
+
+func newBackoffConfig() *backoff.ExponentialBackOff {
+	return backoff.NewExponentialBackOff(
+		backoff.WithInitialInterval(time.Millisecond*200),
+		backoff.WithMaxInterval(time.Second*5),
+		backoff.WithMultiplier(2),
+		backoff.WithRandomizationFactor(0.2),
+		// bounded by the number of retries instead
+		backoff.WithMaxElapsedTime(0),
+	)
+}
@@ -0,0 +1,178 @@
+package client_test
+
+import (
+	"context"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	grpcapi "github.com/hedisam/ethtxparser/api/grpc"
+	restapi "github.com/hedisam/ethtxparser/api/rest"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/pkg/client"
+)
+
+const watched = "0x7a250d5630b4cf539739df2c5dacb4c659f2488d"
+
+func TestClientRetries(t *testing.T) {
+	tests := map[string]struct {
+		failures         []error
+		maxRetries       uint64
+		expectedCalls    int
+		expectedBlock    int64
+		expectedSentinel error
+	}{
+		"no failures": {
+			maxRetries:    2,
+			expectedCalls: 1,
+			expectedBlock: 42,
+		},
+		"transient failures retried": {
+			failures: []error{
+				restapi.NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet, please retry later"),
+				restapi.NewErrf(http.StatusTooManyRequests, "Rate limit exceeded"),
+			},
+			maxRetries:    2,
+			expectedCalls: 3,
+			expectedBlock: 42,
+		},
+		"retries run out": {
+			failures: []error{
+				restapi.NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet, please retry later"),
+				restapi.NewErrf(http.StatusServiceUnavailable, "No parsed blocks yet, please retry later"),
+			},
+			maxRetries:       1,
+			expectedCalls:    2,
+			expectedSentinel: client.ErrUnavailable,
+		},
+		"permanent failure not retried": {
+			failures:         []error{restapi.NewErrf(http.StatusUnauthorized, "Missing API key")},
+			maxRetries:       2,
+			expectedCalls:    1,
+			expectedSentinel: client.ErrUnauthorized,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			var calls int
+			mux := http.NewServeMux()
+			restapi.RegisterFunc(logging.Logrus(logrus.New()), mux, http.MethodGet, "/api/v1/chains/polygon/blocks/current",
+				func(ctx context.Context, req *restapi.GetCurrentBlockRequest) (*restapi.GetCurrentBlockResponse, error) {
+					calls++
+					if calls <= len(test.failures) {
+						return nil, test.failures[calls-1]
+					}
+					return &restapi.GetCurrentBlockResponse{BlockNumber: "0x2a", BlockNumberInt: 42}, nil
+				})
+			srv := httptest.NewServer(mux)
+			defer srv.Close()
+
+			c := client.New(srv.URL, client.WithChain("polygon"), client.WithMaxRetries(test.maxRetries))
+			block, err := c.GetCurrentBlock(context.Background())
+			assert.Equal(t, test.expectedCalls, calls)
+			if test.expectedSentinel != nil {
+				assert.ErrorIs(t, err, test.expectedSentinel)
+				var apiErr *client.Error
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, test.failures[len(test.failures)-1].(*restapi.Err).Message, apiErr.Message)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedBlock, block)
+		})
+	}
+}
+
+func TestClientListTransactions(t *testing.T) {
+	mux := http.NewServeMux()
+	logger := logging.Logrus(logrus.New())
+	restapi.RegisterFunc(logger, mux, http.MethodPut, "/api/v1/subscriptions/{address}",
+		func(ctx context.Context, req *restapi.SubscribeRequest) (*restapi.SubscribeResponse, error) {
+			return &restapi.SubscribeResponse{Ok: true, StartBlock: 42}, nil
+		})
+	restapi.RegisterFunc(logger, mux, http.MethodGet, "/api/v1/transactions/{address}",
+		func(ctx context.Context, req *restapi.ListTransactionsRequest) (*restapi.ListTransactionsResponse, error) {
+			if req.Address != watched {
+				return nil, restapi.NewErrf(http.StatusNotFound, "Address not subscribed")
+			}
+			return &restapi.ListTransactionsResponse{
+				Transactions: []*restapi.Transaction{{Hash: "0x01"}, {Hash: "0x02"}, {Hash: "0x03"}},
+			}, nil
+		})
+	srv := httptest.NewServer(mux)
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := client.New(srv.URL)
+	resp, err := c.Subscribe(ctx, &client.SubscribeRequest{Address: watched})
+	require.NoError(t, err)
+	assert.Equal(t, int64(42), resp.StartBlock)
+
+	var hashes []string
+	for tx, err := range c.ListTransactions(ctx, watched) {
+		require.NoError(t, err)
+		hashes = append(hashes, tx.Hash)
+		if len(hashes) == 2 {
+			break
+		}
+	}
+	assert.Equal(t, []string{"0x01", "0x02"}, hashes)
+
+	for tx, err := range c.ListTransactions(ctx, "0x0000000000000000000000000000000000000001") {
+		assert.Nil(t, tx)
+		assert.ErrorIs(t, err, client.ErrNotFound)
+	}
+}
+
+func TestClientStream(t *testing.T) {
+	server := grpcapi.NewServer(logrus.New())
+	var protocols http.Protocols
+	protocols.SetUnencryptedHTTP2(true)
+	srv := httptest.NewUnstartedServer(server)
+	srv.Config.Protocols = &protocols
+	srv.Config.RegisterOnShutdown(server.Close)
+	srv.Start()
+	defer srv.Close()
+
+	ctx := context.Background()
+	c := client.New("http://localhost:8080", client.WithStreamURL(srv.URL))
+	_, err := c.OpenStream(ctx, "0x1234")
+	assert.ErrorIs(t, err, client.ErrBadRequest)
+
+	stream, err := c.OpenStream(ctx, watched)
+	require.NoError(t, err)
+	defer stream.Close()
+	require.NoError(t, server.Notify(ctx, &store.TxRecord{
+		Hash:        "0x01",
+		From:        "0x02",
+		To:          watched,
+		Value:       big.NewInt(1000),
+		BlockNumber: 42,
+		Removed:     true,
+		Labels:      map[string]string{"to": "Uniswap"},
+	}))
+
+	tx, err := stream.Recv()
+	require.NoError(t, err)
+	assert.Equal(t, &client.Transaction{
+		Hash:           "0x01",
+		From:           "0x02",
+		To:             "0x7a250d5630B4cF539739dF2C5dAcb4c659F2488D",
+		Value:          "1000",
+		BlockNumber:    "0x2a",
+		BlockNumberInt: 42,
+		Removed:        true,
+		Labels:         map[string]string{"to": "Uniswap"},
+	}, tx)
+
+	server.Close()
+	_, err = stream.Recv()
+	assert.ErrorIs(t, err, client.ErrUnavailable)
+}
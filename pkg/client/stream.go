@@ -0,0 +1,196 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/cenkalti/backoff/v4"
+	"google.golang.org/protobuf/proto"
+
+	grpcapi "github.com/hedisam/ethtxparser/api/grpc"
+)
+
+const (
+	// messageHeaderSize is the size of the compressed flag and length prefixing every gRPC message.
+	messageHeaderSize = 5
+	// maxMessageSize is the maximum size of a received message, the default of the gRPC clients.
+	maxMessageSize = 4 << 20
+)
+
+// StreamError is the status a transaction stream was ended with by the server, matching ErrBadRequest for invalid
+// addresses and ErrUnavailable when the server shuts down.
+type StreamError struct {
+	Code    grpcapi.Code
+	Message string
+}
+
+// Error implements the std error type.
+func (e *StreamError) Error() string {
+	return fmt.Sprintf("stream ended with status %d: %s", e.Code, e.Message)
+}
+
+// Is reports whether the target is the sentinel of the status code of the error.
+func (e *StreamError) Is(target error) bool {
+	switch target {
+	case ErrBadRequest:
+		return e.Code == grpcapi.CodeInvalidArgument
+	case ErrUnavailable:
+		return e.Code == grpcapi.CodeUnavailable
+	default:
+		return false
+	}
+}
+
+// Stream is an open transaction stream of an address.
+type Stream struct {
+	resp   *http.Response
+	cancel context.CancelFunc
+}
+
+// OpenStream opens the stream of the transactions of the address recorded from now on, until it's closed or the
+// context is done. Opening the stream is retried as the other calls, see WithMaxRetries.
+func (c *Client) OpenStream(ctx context.Context, addr string) (*Stream, error) {
+	if c.cfg.streamURL == "" {
+		return nil, errors.New("the stream URL of the server isn't set")
+	}
+
+	msg, err := proto.Marshal(&grpcapi.StreamTransactionsRequest{Address: addr})
+	if err != nil {
+		return nil, fmt.Errorf("could not marshal request: %w", err)
+	}
+	body := binary.BigEndian.AppendUint32([]byte{0}, uint32(len(msg)))
+	body = append(body, msg...)
+
+	ctx, cancel := context.WithCancel(ctx)
+	bo := backoff.WithContext(backoff.WithMaxRetries(newBackoffConfig(), c.cfg.maxRetries), ctx)
+	resp, err := backoff.RetryWithData(func() (*http.Response, error) {
+		req, err := http.NewRequestWithContext(ctx, http.MethodPost, strings.TrimSuffix(c.cfg.streamURL, "/")+grpcapi.StreamTransactionsPath, bytes.NewReader(body))
+		if err != nil {
+			return nil, backoff.Permanent(fmt.Errorf("could not create request: %w", err))
+		}
+		req.Header.Set("Content-Type", "application/grpc")
+		req.Header.Set("Te", "trailers")
+
+		resp, err := c.streamClient.Do(req)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil, backoff.Permanent(err)
+			}
+			return nil, fmt.Errorf("could not open stream: %w", err)
+		}
+		if resp.StatusCode != http.StatusOK {
+			_ = resp.Body.Close()
+			apiErr := &Error{StatusCode: resp.StatusCode, Message: "unexpected status of gRPC call"}
+			if apiErr.temporary() {
+				return nil, apiErr
+			}
+			return nil, backoff.Permanent(apiErr)
+		}
+		// trailers-only responses end the call before any message is sent
+		if status := resp.Header.Get("Grpc-Status"); status != "" {
+			_ = resp.Body.Close()
+			streamErr := statusError(status, resp.Header.Get("Grpc-Message"))
+			if streamErr == nil {
+				return nil, backoff.Permanent(io.EOF)
+			}
+			if streamErr.Code == grpcapi.CodeUnavailable {
+				return nil, streamErr
+			}
+			return nil, backoff.Permanent(streamErr)
+		}
+		return resp, nil
+	}, bo)
+	if err != nil {
+		cancel()
+		return nil, err
+	}
+
+	return &Stream{
+		resp:   resp,
+		cancel: cancel,
+	}, nil
+}
+
+// Recv returns the next transaction of the stream, blocking until one is recorded. Removed transactions are received
+// again with Removed set. It returns io.EOF once the stream is ended by the server without error, or a *StreamError
+// with the status it's ended with otherwise.
+func (s *Stream) Recv() (*Transaction, error) {
+	var header [messageHeaderSize]byte
+	_, err := io.ReadFull(s.resp.Body, header[:])
+	if errors.Is(err, io.EOF) {
+		streamErr := statusError(s.resp.Trailer.Get("Grpc-Status"), s.resp.Trailer.Get("Grpc-Message"))
+		if streamErr != nil {
+			return nil, streamErr
+		}
+		return nil, io.EOF
+	}
+	if err != nil {
+		return nil, fmt.Errorf("could not read message header: %w", err)
+	}
+	if header[0] != 0 {
+		return nil, errors.New("compressed messages are not supported")
+	}
+	size := binary.BigEndian.Uint32(header[1:])
+	if size > maxMessageSize {
+		return nil, fmt.Errorf("message of %d bytes is larger than %d bytes", size, maxMessageSize)
+	}
+	msg := make([]byte, size)
+	_, err = io.ReadFull(s.resp.Body, msg)
+	if err != nil {
+		return nil, fmt.Errorf("could not read message: %w", err)
+	}
+
+	tx, err := decodeTransaction(msg)
+	if err != nil {
+		return nil, fmt.Errorf("could not decode transaction: %w", err)
+	}
+	return tx, nil
+}
+
+// Close closes the stream.
+func (s *Stream) Close() error {
+	s.cancel()
+	return s.resp.Body.Close()
+}
+
+// statusError returns the error of the gRPC status, nil if it's OK.
+func statusError(status, msg string) *StreamError {
+	code, err := strconv.Atoi(status)
+	if err != nil {
+		return &StreamError{Code: grpcapi.CodeInternal, Message: "missing or invalid grpc-status " + strconv.Quote(status)}
+	}
+	if grpcapi.Code(code) == grpcapi.CodeOK {
+		return nil
+	}
+	return &StreamError{Code: grpcapi.Code(code), Message: msg}
+}
+
+// decodeTransaction decodes a Transaction message, skipping unknown fields.
+func decodeTransaction(msg []byte) (*Transaction, error) {
+	var tx grpcapi.Transaction
+	err := proto.Unmarshal(msg, &tx)
+	if err != nil {
+		return nil, err
+	}
+	return &Transaction{
+		Hash:           tx.GetHash(),
+		From:           tx.GetFrom(),
+		To:             tx.GetTo(),
+		Value:          tx.GetValue(),
+		ValueUSD:       tx.GetValueUsd(),
+		BlockNumber:    fmt.Sprintf("0x%x", tx.GetBlockNumber()),
+		BlockNumberInt: tx.GetBlockNumber(),
+		BlockHash:      tx.GetBlockHash(),
+		BlockTimestamp: tx.GetBlockTimestamp(),
+		Status:         tx.GetStatus(),
+		Removed:        tx.GetRemoved(),
+		Labels:         tx.GetLabels(),
+	}, nil
+}
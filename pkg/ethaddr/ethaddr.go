@@ -0,0 +1,69 @@
+// Package ethaddr validates and normalizes Ethereum addresses, the 20 bytes hex strings the subscriptions, the
+// recorded transactions and the store are keyed by, lower-cased and 0x-prefixed.
+package ethaddr
+
+import (
+	"encoding/hex"
+	"strings"
+
+	"github.com/hedisam/ethtxparser/internal/keccak"
+)
+
+// Normalize returns the address lower-cased and 0x-prefixed, reporting whether it's valid. The address may be given
+// with or without the 0x prefix, in any case, and surrounded by spaces.
+func Normalize(addr string) (string, bool) {
+	addr = strings.ToLower(strings.TrimSpace(addr))
+	addr = strings.TrimPrefix(addr, "0x")
+	if !isHex(addr) {
+		return "", false
+	}
+	return "0x" + addr, true
+}
+
+// IsValid reports whether the address is a 0x-prefixed 20 bytes hex string, in any case. The checksum of mixed-case
+// addresses isn't verified.
+func IsValid(addr string) bool {
+	addr, ok := strings.CutPrefix(addr, "0x")
+	return ok && isHex(addr)
+}
+
+// Checksum returns the address in its EIP-55 mixed-case checksum encoding, reporting whether it's valid, see
+// Normalize for the addresses accepted.
+func Checksum(addr string) (string, bool) {
+	addr, ok := Normalize(addr)
+	if !ok {
+		return "", false
+	}
+
+	// every letter is upper-cased if the matching nibble of the hash of the lower-cased hex address is at least 8
+	digits := []byte(addr[2:])
+	hash := keccak.Sum256(digits)
+	for i, c := range digits {
+		nibble := hash[i/2] >> 4
+		if i%2 == 1 {
+			nibble = hash[i/2] & 0x0f
+		}
+		if c >= 'a' && nibble >= 8 {
+			digits[i] = c - 'a' + 'A'
+		}
+	}
+	return "0x" + string(digits), true
+}
+
+// Equal reports whether both addresses are valid and the same, regardless of their case and 0x prefix.
+func Equal(a, b string) bool {
+	a, ok := Normalize(a)
+	if !ok {
+		return false
+	}
+	b, ok = Normalize(b)
+	return ok && a == b
+}
+
+func isHex(s string) bool {
+	if len(s) != 40 {
+		return false
+	}
+	_, err := hex.DecodeString(s)
+	return err == nil
+}
@@ -0,0 +1,76 @@
+package ethaddr_test
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := map[string]struct {
+		addr          string
+		expectedAddr  string
+		expectedValid bool
+	}{
+		"lower-case": {
+			addr:          "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			expectedAddr:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			expectedValid: true,
+		},
+		"mixed-case without prefix and with spaces": {
+			addr:          " 7A250D5630B4CF539739DF2C5DACB4C659F2488D ",
+			expectedAddr:  "0x7a250d5630b4cf539739df2c5dacb4c659f2488d",
+			expectedValid: true,
+		},
+		"too short": {
+			addr: "0x7a250d5630b4cf539739df2c5dacb4c659f248",
+		},
+		"not hex": {
+			addr: "0x7a250d5630b4cf539739df2c5dacb4c659f2488z",
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			addr, valid := ethaddr.Normalize(test.addr)
+			assert.Equal(t, test.expectedValid, valid)
+			assert.Equal(t, test.expectedAddr, addr)
+		})
+	}
+}
+
+func TestIsValid(t *testing.T) {
+	assert.True(t, ethaddr.IsValid("0x7a250d5630b4cf539739df2c5dacb4c659f2488d"))
+	assert.True(t, ethaddr.IsValid("0x7A250D5630B4CF539739DF2C5DACB4C659F2488D"))
+	assert.False(t, ethaddr.IsValid("7a250d5630b4cf539739df2c5dacb4c659f2488d"))
+	assert.False(t, ethaddr.IsValid("0x7a250d5630b4cf539739df2c5dacb4c659f2488"))
+	assert.False(t, ethaddr.IsValid(""))
+}
+
+func TestChecksum(t *testing.T) {
+	// the test vectors of EIP-55
+	addrs := []string{
+		"0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed",
+		"0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359",
+		"0xdbF03B407c01E7cD3CBea99509d93f8DDDC8C6FB",
+		"0xD1220A0cf47c7B9Be7A2E6BA89F429762e7b9aDb",
+	}
+	for expected := range slices.Values(addrs) {
+		addr, valid := ethaddr.Checksum(strings.ToLower(expected))
+		assert.True(t, valid)
+		assert.Equal(t, expected, addr)
+	}
+
+	_, valid := ethaddr.Checksum("0x1234")
+	assert.False(t, valid)
+}
+
+func TestEqual(t *testing.T) {
+	assert.True(t, ethaddr.Equal("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "5aaeb6053f3e94c9b9a09f33669435e7ef1beaed"))
+	assert.False(t, ethaddr.Equal("0x5aAeb6053F3E94C9b9A09f33669435E7Ef1BeAed", "0xfB6916095ca1df60bB79Ce92cE3Ea74c37c5d359"))
+	assert.False(t, ethaddr.Equal("0x1234", "0x1234"))
+}
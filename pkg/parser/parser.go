@@ -0,0 +1,278 @@
+// Package parser embeds the whole transaction parser in another Go service: it follows an Ethereum node, holds back
+// the blocks until they're confirmed, and records the transactions of the subscribed addresses, as the binary does but
+// without its APIs, notifiers or flags.
+package parser
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"slices"
+	"time"
+
+	"github.com/hedisam/ethtxparser/internal/eth"
+	"github.com/hedisam/ethtxparser/internal/index"
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/store"
+	"github.com/hedisam/ethtxparser/internal/store/memdb"
+	"github.com/hedisam/ethtxparser/internal/store/timeout"
+	"github.com/hedisam/ethtxparser/pkg/ethaddr"
+)
+
+const (
+	DefaultPollInterval      = time.Second * 10
+	DefaultConfirmationDepth = 3
+)
+
+var (
+	// ErrInvalidAddress is returned when an address isn't a 20 byte hex address.
+	ErrInvalidAddress = errors.New("invalid address")
+	// ErrNotSubscribed is returned when listing the transactions of an address that isn't subscribed.
+	ErrNotSubscribed = errors.New("address not subscribed")
+	// ErrNoBlocks is returned when no block has been parsed yet.
+	ErrNoBlocks = errors.New("no parsed blocks yet")
+	// ErrUnknownStore is returned when the chosen store isn't one of the supported ones.
+	ErrUnknownStore = errors.New("unknown store")
+)
+
+// Parser records the transactions of the subscribed addresses.
+type Parser interface {
+	// GetCurrentBlock returns the number of the last parsed block, or ErrNoBlocks if none is parsed yet.
+	GetCurrentBlock(ctx context.Context) (int64, error)
+	// Subscribe records the transactions of the address from the next parsed block onwards. Subscribing to an
+	// address again keeps its start block.
+	Subscribe(ctx context.Context, address string) error
+	// GetTransactions lists the transactions recorded for the subscribed address, in the order they were recorded.
+	GetTransactions(ctx context.Context, address string) ([]*Transaction, error)
+}
+
+// Transaction is a recorded transaction sent from or to a subscribed address.
+type Transaction struct {
+	Hash string
+	From string
+	// To is empty for contract creations.
+	To string
+	// Value is the transferred value in wei.
+	Value       *big.Int
+	BlockNumber int64
+	BlockHash   string
+	// BlockTimestamp is the unix time in seconds the block of the transaction was minted at.
+	BlockTimestamp int64
+	// Removed is set once the block of the transaction is orphaned by a chain reorganisation.
+	Removed bool
+}
+
+// Store is the kind of the store the transactions and subscriptions are kept in.
+type Store string
+
+const (
+	// StoreMemory keeps them in memory, lost on restart.
+	StoreMemory Store = "memory"
+)
+
+type config struct {
+	logger            *slog.Logger
+	httpClient        *http.Client
+	store             Store
+	pollInterval      time.Duration
+	confirmationDepth uint
+	workers           int
+}
+
+type Option func(*config)
+
+// WithLogger sets the logger, nothing is logged by default.
+func WithLogger(logger *slog.Logger) Option {
+	return func(c *config) {
+		c.logger = logger
+	}
+}
+
+// WithHTTPClient sets the client the node is called with.
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *config) {
+		c.httpClient = httpClient
+	}
+}
+
+// WithStore chooses the store the transactions and subscriptions are kept in, StoreMemory by default.
+func WithStore(s Store) Option {
+	return func(c *config) {
+		c.store = s
+	}
+}
+
+// WithPollInterval sets the interval the node is polled for new blocks at.
+func WithPollInterval(d time.Duration) Option {
+	return func(c *config) {
+		c.pollInterval = d
+	}
+}
+
+// WithConfirmationDepth sets the number of blocks a block is held back for before it's parsed, so the blocks
+// orphaned by chain reorganisations aren't.
+func WithConfirmationDepth(depth uint) Option {
+	return func(c *config) {
+		c.confirmationDepth = depth
+	}
+}
+
+// WithWorkers sets the number of blocks matched concurrently, they're always recorded in order.
+func WithWorkers(n int) Option {
+	return func(c *config) {
+		c.workers = n
+	}
+}
+
+// Service is the Parser following the node, once started.
+type Service struct {
+	cfg       *config
+	logger    logging.Logger
+	ethClient *eth.Client
+	txStore   *timeout.TxStoreWrapper
+	subsStore *timeout.SubscriptionStoreWrapper
+	idx       *index.Index
+}
+
+var _ Parser = (*Service)(nil)
+
+// New returns the parser of the node at nodeAddr, which parses no block until it's started.
+func New(nodeAddr string, opts ...Option) (*Service, error) {
+	cfg := &config{
+		logger:            slog.New(slog.DiscardHandler),
+		httpClient:        &http.Client{Timeout: time.Second * 10},
+		store:             StoreMemory,
+		pollInterval:      DefaultPollInterval,
+		confirmationDepth: DefaultConfirmationDepth,
+		workers:           index.DefaultWorkers,
+	}
+	for opt := range slices.Values(opts) {
+		opt(cfg)
+	}
+	if nodeAddr == "" {
+		return nil, errors.New("node address is required")
+	}
+	if cfg.confirmationDepth < 1 {
+		return nil, errors.New("confirmation depth cannot be less than 1")
+	}
+
+	var txStore timeout.TxStore
+	var subsStore timeout.SubscriptionStore
+	switch cfg.store {
+	case StoreMemory:
+		txStore, subsStore = memdb.NewTxStore(), memdb.NewSubscriptionStore()
+	default:
+		return nil, fmt.Errorf("%w: %q", ErrUnknownStore, cfg.store)
+	}
+
+	logger := logging.Slog(cfg.logger)
+	s := &Service{
+		cfg:       cfg,
+		logger:    logger,
+		ethClient: eth.New(logger, cfg.httpClient, nodeAddr),
+		txStore:   timeout.NewTxStore(txStore),
+		subsStore: timeout.NewSubscriptionStore(subsStore),
+	}
+	s.idx = index.New(logger, s.txStore, s.subsStore,
+		index.WithWorkers(cfg.workers),
+		index.WithGapFill(s.ethClient),
+	)
+	return s, nil
+}
+
+// Start follows the node, parsing the confirmed blocks, until the context is done.
+func (s *Service) Start(ctx context.Context) {
+	blocks := s.ethClient.Stream(ctx, s.cfg.pollInterval)
+	confirmed := eth.ReorgFilter(ctx, s.logger, blocks, s.cfg.confirmationDepth)
+	s.idx.Start(ctx, confirmed)
+}
+
+func (s *Service) GetCurrentBlock(ctx context.Context) (int64, error) {
+	number, err := s.txStore.GetCurrentBlockNumber(ctx)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return 0, ErrNoBlocks
+		}
+		return 0, fmt.Errorf("could not get current block number: %w", err)
+	}
+	return number, nil
+}
+
+func (s *Service) Subscribe(ctx context.Context, address string) error {
+	addr, valid := ethaddr.Normalize(address)
+	if !valid {
+		return fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	_, err := s.subsStore.GetSubscription(ctx, addr)
+	if err == nil {
+		return nil
+	}
+	if !errors.Is(err, store.ErrNotFound) {
+		return fmt.Errorf("could not get existing subscription: %w", err)
+	}
+	startBlock, err := s.GetCurrentBlock(ctx)
+	switch {
+	case errors.Is(err, ErrNoBlocks):
+		startBlock = 0
+	case err != nil:
+		return err
+	default:
+		startBlock++
+	}
+
+	err = s.subsStore.AddSubscription(ctx, &store.Subscription{
+		Address:    addr,
+		StartBlock: startBlock,
+		Mode:       store.SubscriptionModeLive,
+	})
+	if err != nil {
+		return fmt.Errorf("could not add subscription: %w", err)
+	}
+	return nil
+}
+
+func (s *Service) GetTransactions(ctx context.Context, address string) ([]*Transaction, error) {
+	addr, valid := ethaddr.Normalize(address)
+	if !valid {
+		return nil, fmt.Errorf("%w: %q", ErrInvalidAddress, address)
+	}
+
+	sub, err := s.subsStore.GetSubscription(ctx, addr)
+	if err != nil {
+		if errors.Is(err, store.ErrNotFound) {
+			return nil, ErrNotSubscribed
+		}
+		return nil, fmt.Errorf("could not get subscription: %w", err)
+	}
+	records, err := s.txStore.GetTransactions(ctx, addr)
+	if err != nil {
+		return nil, fmt.Errorf("could not get transactions: %w", err)
+	}
+
+	txs := make([]*Transaction, 0, len(records))
+	for record := range slices.Values(records) {
+		if !sub.Includes(record.BlockNumber) {
+			continue
+		}
+		var value *big.Int
+		if record.Value != nil {
+			// the records are shared with the store
+			value = new(big.Int).Set(record.Value)
+		}
+		txs = append(txs, &Transaction{
+			Hash:           record.Hash,
+			From:           record.From,
+			To:             record.To,
+			Value:          value,
+			BlockNumber:    record.BlockNumber,
+			BlockHash:      record.BlockHash,
+			BlockTimestamp: record.BlockTimestamp,
+			Removed:        record.Removed,
+		})
+	}
+	return txs, nil
+}
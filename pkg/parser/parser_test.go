@@ -0,0 +1,88 @@
+package parser_test
+
+import (
+	"context"
+	"net/http/httptest"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/hedisam/ethtxparser/internal/logging"
+	"github.com/hedisam/ethtxparser/internal/mocknode"
+	"github.com/hedisam/ethtxparser/pkg/parser"
+)
+
+func TestNew(t *testing.T) {
+	tests := map[string]struct {
+		nodeAddr    string
+		opts        []parser.Option
+		expectedErr error
+	}{
+		"defaults": {
+			nodeAddr: "http://localhost:8545",
+		},
+		"unknown store": {
+			nodeAddr:    "http://localhost:8545",
+			opts:        []parser.Option{parser.WithStore("postgres")},
+			expectedErr: parser.ErrUnknownStore,
+		},
+	}
+
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := parser.New(test.nodeAddr, test.opts...)
+			if test.expectedErr != nil {
+				assert.ErrorIs(t, err, test.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			_, err = p.GetCurrentBlock(context.Background())
+			assert.ErrorIs(t, err, parser.ErrNoBlocks)
+		})
+	}
+}
+
+func TestParser(t *testing.T) {
+	node := mocknode.New(logging.Logrus(logrus.New()), mocknode.WithTxsPerBlock(10), mocknode.WithAccounts(2))
+	srv := httptest.NewServer(node.Handler())
+	defer srv.Close()
+
+	p, err := parser.New(srv.URL,
+		parser.WithHTTPClient(srv.Client()),
+		parser.WithPollInterval(time.Millisecond*10),
+		parser.WithConfirmationDepth(1),
+	)
+	require.NoError(t, err)
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	account := node.Accounts()[0]
+	err = p.Subscribe(ctx, "0xinvalid")
+	assert.ErrorIs(t, err, parser.ErrInvalidAddress)
+	_, err = p.GetTransactions(ctx, account)
+	assert.ErrorIs(t, err, parser.ErrNotSubscribed)
+	err = p.Subscribe(ctx, account)
+	require.NoError(t, err)
+
+	go p.Start(ctx)
+	for range 3 {
+		time.Sleep(time.Millisecond * 30)
+		node.MintBlock()
+	}
+
+	require.Eventually(t, func() bool {
+		current, err := p.GetCurrentBlock(ctx)
+		return err == nil && current >= 2
+	}, time.Second, time.Millisecond*10)
+	txs, err := p.GetTransactions(ctx, account)
+	require.NoError(t, err)
+	require.NotEmpty(t, txs)
+	for tx := range slices.Values(txs) {
+		assert.True(t, tx.From == account || tx.To == account)
+		assert.NotNil(t, tx.Value)
+	}
+}